@@ -14,6 +14,23 @@ class Constants {
 
         const val KEY_HEARTBEAT_ID = "KEY_HEARTBEAT_ID"
 
+        const val KEY_TYPE = "KEY_TYPE"
+        const val KEY_SIGNATURE_VERSION = "KEY_SIGNATURE_VERSION"
+        const val KEY_CONTROL_MESSAGE = "KEY_CONTROL_MESSAGE"
+        const val KEY_REACTION = "KEY_REACTION"
+        const val KEY_REACTION_MESSAGE_ID = "KEY_REACTION_MESSAGE_ID"
+        const val KEY_PHONE_ID = "KEY_PHONE_ID"
+        const val KEY_USSD_SESSION_ID = "KEY_USSD_SESSION_ID"
+        const val KEY_USSD_REQUEST = "KEY_USSD_REQUEST"
+        const val KEY_USSD_SIM = "KEY_USSD_SIM"
+
+        const val TYPE_REACTION = "reaction"
+        const val TYPE_APP_UPDATE = "app_update"
+        const val TYPE_CONFIG_REFRESH = "config_refresh"
+        const val TYPE_USSD = "ussd"
+
+        const val APP_RELEASES_URL = "https://github.com/NdoleStudio/httpsms/releases"
+
         const val SIM1 = "SIM1"
         const val SIM2 = "SIM2"
 
@@ -0,0 +1,75 @@
+package com.httpsms
+
+import org.bouncycastle.crypto.params.Ed25519PublicKeyParameters
+import org.bouncycastle.crypto.signers.Ed25519Signer
+import timber.log.Timber
+import java.util.Base64
+
+/**
+ * VerifiedNotification is a push notification with a valid signature, the nonce must not be used again before ExpiresAt
+ */
+data class VerifiedNotification(val nonce: String, val expiresAt: Long)
+
+/**
+ * FcmVerifier checks that a push notification was signed by the httpSMS server with the ed25519 key of the phone
+ */
+object FcmVerifier {
+    private const val KEY_SIGNATURE = "KEY_SIGNATURE"
+    private const val KEY_TIMESTAMP = "KEY_TIMESTAMP"
+    private const val KEY_NONCE = "KEY_NONCE"
+    private const val KEY_TTL = "KEY_TTL"
+
+    // DEFAULT_TTL_SECONDS is the validity of the notifications without a TTL e.g. heartbeats and commands which are sent with a high priority
+    private const val DEFAULT_TTL_SECONDS = 10 * 60L
+
+    // CLOCK_SKEW_SECONDS is how far the clock of the phone can be behind the clock of the server
+    private const val CLOCK_SKEW_SECONDS = 5 * 60L
+
+    /**
+     * verify returns the nonce of a notification which was signed with one of the keys less than its TTL ago, null is returned when the notification is not valid
+     */
+    fun verify(data: Map<String, String>, keys: Collection<String>, now: Long): VerifiedNotification? {
+        val signature = data[KEY_SIGNATURE]
+        val nonce = data[KEY_NONCE]
+        val timestamp = data[KEY_TIMESTAMP]?.toLongOrNull()
+        if (signature == null || nonce.isNullOrEmpty() || timestamp == null) {
+            Timber.w("the notification is not signed")
+            return null
+        }
+
+        val expiresAt = timestamp + (data[KEY_TTL]?.toLongOrNull() ?: DEFAULT_TTL_SECONDS)
+        if (now > expiresAt || timestamp > now + CLOCK_SKEW_SECONDS) {
+            Timber.w("the notification signed at [$timestamp] is not valid at [$now]")
+            return null
+        }
+
+        val payload = payload(data)
+        if (keys.none { isSignedWith(it, signature, payload) }) {
+            Timber.w("the signature of the notification does not match any of the [${keys.size}] verification keys")
+            return null
+        }
+
+        return VerifiedNotification(nonce, expiresAt + CLOCK_SKEW_SECONDS)
+    }
+
+    // payload is the signed content which contains a key=value line for every data key except the signature in alphabetical order
+    private fun payload(data: Map<String, String>): ByteArray {
+        return data.keys
+            .filter { it != KEY_SIGNATURE }
+            .sorted()
+            .joinToString("\n") { "$it=${data[it]}" }
+            .toByteArray(Charsets.UTF_8)
+    }
+
+    private fun isSignedWith(key: String, signature: String, payload: ByteArray): Boolean {
+        return try {
+            val verifier = Ed25519Signer()
+            verifier.init(false, Ed25519PublicKeyParameters(Base64.getDecoder().decode(key), 0))
+            verifier.update(payload, 0, payload.size)
+            verifier.verifySignature(Base64.getDecoder().decode(signature))
+        } catch (exception: Exception) {
+            Timber.e(exception)
+            false
+        }
+    }
+}
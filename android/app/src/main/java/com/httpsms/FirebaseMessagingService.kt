@@ -1,9 +1,13 @@
 package com.httpsms
 
 import android.app.Application
+import android.app.Notification
+import android.app.NotificationChannel
+import android.app.NotificationManager
 import android.app.PendingIntent
 import android.content.Context
 import android.content.Intent
+import android.net.Uri
 import androidx.work.*
 import com.google.firebase.messaging.FirebaseMessagingService
 import com.google.firebase.messaging.RemoteMessage
@@ -15,12 +19,24 @@ class MyFirebaseMessagingService : FirebaseMessagingService() {
         initTimber()
         Timber.d(MyFirebaseMessagingService::onMessageReceived.name)
 
+        if (!isVerified(remoteMessage.data)) {
+            Timber.e("ignoring push notification with ID [${remoteMessage.messageId}] which was not signed by the server")
+            return
+        }
+
         if (remoteMessage.data.containsKey(Constants.KEY_HEARTBEAT_ID)) {
             Timber.w("received heartbeat message with ID [${remoteMessage.data[Constants.KEY_HEARTBEAT_ID]}] and priority [${remoteMessage.priority}] and original priority [${remoteMessage.originalPriority}]")
             sendHeartbeat()
             return
         }
 
+        // commands have a type and they must never be handled like the notification of an outgoing message
+        val type = remoteMessage.data[Constants.KEY_TYPE]
+        if (type != null) {
+            handleCommand(type, remoteMessage.data)
+            return
+        }
+
         val messageID = remoteMessage.data[Constants.KEY_MESSAGE_ID]
         if (messageID == null)  {
             Timber.e("cannot get message id from notification data with key [${Constants.KEY_MESSAGE_ID}]")
@@ -48,6 +64,129 @@ class MyFirebaseMessagingService : FirebaseMessagingService() {
     }
     // [END on_new_token]
 
+    // isVerified checks the signature of a push notification once the server has shared a verification key, the nonce of a valid notification cannot be used again
+    private fun isVerified(data: Map<String, String>): Boolean {
+        val keys = Settings.getFcmVerificationKeys(applicationContext)
+        if (keys.isEmpty()) {
+            Timber.d("the server has not shared a key to verify push notifications")
+            return true
+        }
+
+        val now = System.currentTimeMillis() / 1000
+        val notification = FcmVerifier.verify(data, keys, now) ?: verifyWithRefreshedKeys(data, now) ?: return false
+        return Settings.useFcmNonce(applicationContext, notification.nonce, notification.expiresAt, now)
+    }
+
+    // verifyWithRefreshedKeys fetches the keys from the API once when the app missed the rotation of the key which signed the notification
+    private fun verifyWithRefreshedKeys(data: Map<String, String>, now: Long): VerifiedNotification? {
+        val token = Settings.getFcmToken(applicationContext)
+        if (token == null || !Settings.shouldRefreshFcmVerificationKeys(applicationContext, now)) {
+            return null
+        }
+
+        Timber.i("refreshing the verification keys to verify notification signed with key version [${data[Constants.KEY_SIGNATURE_VERSION]}]")
+        try {
+            sendRegistrationToServer(token)
+        } catch (exception: Exception) {
+            Timber.e(exception)
+            return null
+        }
+        return FcmVerifier.verify(data, Settings.getFcmVerificationKeys(applicationContext), now)
+    }
+
+    private fun handleCommand(type: String, data: Map<String, String>) {
+        Timber.d("received command with type [$type]")
+        when (type) {
+            Constants.TYPE_REACTION -> handleReaction(data)
+            Constants.TYPE_APP_UPDATE -> showAppUpdateNotification(data[Constants.KEY_CONTROL_MESSAGE])
+            Constants.TYPE_CONFIG_REFRESH -> refreshConfig()
+            Constants.TYPE_USSD -> handleUssd(data)
+            else -> Timber.w("ignoring command with unknown type [$type], the app may need to be updated")
+        }
+    }
+
+    private fun showAppUpdateNotification(message: String?) {
+        Timber.i("a new version of the app is available with message [$message]")
+
+        val notificationChannelId = "app_update_notification_channel"
+        val notificationManager = getSystemService(Context.NOTIFICATION_SERVICE) as NotificationManager
+        notificationManager.createNotificationChannel(
+            NotificationChannel(notificationChannelId, "App updates", NotificationManager.IMPORTANCE_DEFAULT)
+        )
+
+        val pendingIntent = PendingIntent.getActivity(
+            this,
+            0,
+            Intent(Intent.ACTION_VIEW, Uri.parse(Constants.APP_RELEASES_URL)),
+            PendingIntent.FLAG_IMMUTABLE or PendingIntent.FLAG_UPDATE_CURRENT
+        )
+
+        val notification = Notification.Builder(this, notificationChannelId)
+            .setContentTitle("httpSMS update available")
+            .setContentText(message ?: "A new version of the httpSMS app is available, tap to download it.")
+            .setContentIntent(pendingIntent)
+            .setAutoCancel(true)
+            .setSmallIcon(R.drawable.ic_stat_name)
+            .build()
+
+        notificationManager.notify(Constants.TYPE_APP_UPDATE.hashCode(), notification)
+    }
+
+    private fun refreshConfig() {
+        Timber.d("refreshing the phones from the API")
+        if (!Settings.isLoggedIn(applicationContext)) {
+            Timber.w("user is not logged in, not refreshing the phones")
+            return
+        }
+
+        val token = Settings.getFcmToken(applicationContext)
+        if (token == null) {
+            Timber.w("the app has no FCM token, not refreshing the phones")
+            return
+        }
+
+        Thread {
+            try {
+                sendRegistrationToServer(token)
+            } catch (exception: Exception) {
+                Timber.e(exception)
+            }
+            Timber.d("finished refreshing the phones")
+        }.start()
+    }
+
+    private fun handleUssd(data: Map<String, String>) {
+        val phoneID = data[Constants.KEY_PHONE_ID]
+        val sessionID = data[Constants.KEY_USSD_SESSION_ID]
+        val request = data[Constants.KEY_USSD_REQUEST]
+        if (phoneID == null || sessionID == null || request == null) {
+            Timber.e("cannot get the USSD session from the notification data [$data]")
+            return
+        }
+
+        // the public android API only executes a single USSD code so the session ends with the first response
+        Timber.i("executing USSD session [$sessionID]")
+        UssdManagerService().send(applicationContext, request, data[Constants.KEY_USSD_SIM] ?: Constants.SIM1) { response, failureReason ->
+            storeUssdResponse(phoneID, sessionID, response, failureReason)
+        }
+    }
+
+    private fun storeUssdResponse(phoneID: String, sessionID: String, response: String?, failureReason: String?) {
+        Thread {
+            try {
+                HttpSmsApiService.create(applicationContext).storeUssdResponse(phoneID, sessionID, response, failureReason)
+            } catch (exception: Exception) {
+                Timber.e(exception)
+            }
+        }.start()
+    }
+
+    private fun handleReaction(data: Map<String, String>) {
+        // Android has no public API which lets an app which is not the default messaging app send RCS messages,
+        // so the reaction is not sent in the same way the phone ignores it when the contact does not support RCS.
+        Timber.w("cannot send reaction [${data[Constants.KEY_REACTION]}] to message with ID [${data[Constants.KEY_REACTION_MESSAGE_ID]}] because RCS is not available to the app")
+    }
+
     private fun sendHeartbeat() {
         Timber.d("sending heartbeat from FCM notification")
         if (!Settings.isLoggedIn(applicationContext)) {
@@ -56,7 +195,7 @@ class MyFirebaseMessagingService : FirebaseMessagingService() {
         }
         Thread {
             try {
-                HttpSmsApiService.create(applicationContext).storeHeartbeat(Settings.getSIM1PhoneNumber(applicationContext), Settings.isCharging(applicationContext))
+                HttpSmsApiService.create(applicationContext).storeHeartbeat(Settings.getSIM1PhoneNumber(applicationContext), Settings.isCharging(applicationContext), Settings.getBatteryLevel(applicationContext))
                 Settings.setHeartbeatTimestampAsync(applicationContext, System.currentTimeMillis())
             } catch (exception: Exception) {
                 Timber.e(exception)
@@ -95,12 +234,16 @@ class MyFirebaseMessagingService : FirebaseMessagingService() {
             val phone = HttpSmsApiService.create(this).updatePhone(Settings.getSIM1PhoneNumber(this), token, Constants.SIM1)
             if (phone != null) {
                 Settings.setUserID(this, phone.userID)
+                Settings.setFcmVerificationKeys(this, Constants.SIM1, phone.verificationKeys())
             }
         }
 
         if(Settings.isDualSIM(this)) {
             Timber.d("updating SIM2 phone with new fcm token")
-            HttpSmsApiService.create(this).updatePhone(Settings.getSIM2PhoneNumber(this), token, Constants.SIM2)
+            val phone = HttpSmsApiService.create(this).updatePhone(Settings.getSIM2PhoneNumber(this), token, Constants.SIM2)
+            if (phone != null) {
+                Settings.setFcmVerificationKeys(this, Constants.SIM2, phone.verificationKeys())
+            }
         }
     }
 
@@ -177,7 +320,7 @@ class MyFirebaseMessagingService : FirebaseMessagingService() {
                     sentIntents.add(createPendingIntent(id, SmsManagerService.sentAction()))
                     deliveredIntents.add(createPendingIntent(id, SmsManagerService.deliveredAction()))
                 }
-                SmsManagerService().sendMultipartMessage(this.applicationContext,message.contact, parts, message.sim, sentIntents, deliveredIntents)
+                SmsManagerService().sendMultipartMessage(this.applicationContext,message.contact, parts, message.sim, sentIntents, deliveredIntents, message.validityPeriod)
                 Timber.d("sent SMS for message with ID [${message.id}] in [${parts.size}] parts")
                 Result.success()
             } catch (e: Exception) {
@@ -240,7 +383,7 @@ class MyFirebaseMessagingService : FirebaseMessagingService() {
         private fun sendMessage(message: Message, content: String, sentIntent: PendingIntent, deliveredIntent: PendingIntent) {
             Timber.d("sending SMS for message with ID [${message.id}]")
             try {
-                SmsManagerService().sendTextMessage(this.applicationContext,message.contact, content, message.sim, sentIntent, deliveredIntent)
+                SmsManagerService().sendTextMessage(this.applicationContext,message.contact, content, message.sim, sentIntent, deliveredIntent, message.validityPeriod)
             } catch (e: Exception) {
                 Timber.e(e)
                 Timber.d("could not send SMS for message with ID [${message.id}]")
@@ -9,6 +9,7 @@ import org.apache.commons.text.StringEscapeUtils
 import timber.log.Timber
 import java.net.URI
 import java.net.URL
+import java.net.URLEncoder
 import java.util.logging.Level
 import java.util.logging.Logger.getLogger
 
@@ -100,6 +101,79 @@ class HttpSmsApiService(private val apiKey: String, private val baseURL: URI) {
         return true
     }
 
+    fun getMessages(owner: String, contact: String): List<Message>? {
+        val request: Request = Request.Builder()
+            .url(resolveURL("/v1/messages?owner=${URLEncoder.encode(owner, "UTF-8")}&contact=${URLEncoder.encode(contact, "UTF-8")}&limit=20"))
+            .header(apiKeyHeader, apiKey)
+            .header(clientVersionHeader, BuildConfig.VERSION_NAME)
+            .build()
+
+        val response = client.newCall(request).execute()
+        if (!response.isSuccessful) {
+            Timber.e("error response [${response.body?.string()}] with code [${response.code}] while fetching messages between [$owner] and [$contact]")
+            response.close()
+            return null
+        }
+
+        val payload = ResponseMessages.fromJson(response.body!!.string())?.data
+        response.close()
+        return payload
+    }
+
+    fun storeReaction(messageId: String, emoji: String, timestamp: String): Boolean {
+        val body = """
+            {
+              "emoji": "${StringEscapeUtils.escapeJson(emoji)}",
+              "timestamp": "$timestamp"
+            }
+        """.trimIndent()
+
+        val request: Request = Request.Builder()
+            .url(resolveURL("/v1/messages/${messageId}/reactions"))
+            .post(body.toRequestBody(jsonMediaType))
+            .header(apiKeyHeader, apiKey)
+            .header(clientVersionHeader, BuildConfig.VERSION_NAME)
+            .build()
+
+        val response = client.newCall(request).execute()
+        if (!response.isSuccessful) {
+            Timber.e("error response [${response.body?.string()}] with code [${response.code}] while storing reaction [${body}] for message with ID [${messageId}]")
+            response.close()
+            return false
+        }
+
+        response.close()
+        Timber.i("reaction [$emoji] stored successfully for message with ID [$messageId]")
+        return true
+    }
+
+    fun storeUssdResponse(phoneID: String, sessionID: String, response: String?, failureReason: String?): Boolean {
+        val body = """
+            {
+              "response": "${StringEscapeUtils.escapeJson(response ?: "")}",
+              "failure_reason": "${StringEscapeUtils.escapeJson(failureReason ?: "")}"
+            }
+        """.trimIndent()
+
+        val request: Request = Request.Builder()
+            .url(resolveURL("/v1/phones/${phoneID}/ussd/${sessionID}/response"))
+            .post(body.toRequestBody(jsonMediaType))
+            .header(apiKeyHeader, apiKey)
+            .header(clientVersionHeader, BuildConfig.VERSION_NAME)
+            .build()
+
+        val httpResponse = client.newCall(request).execute()
+        if (!httpResponse.isSuccessful) {
+            Timber.e("error response [${httpResponse.body?.string()}] with code [${httpResponse.code}] while storing the response of USSD session [${sessionID}]")
+            httpResponse.close()
+            return false
+        }
+
+        httpResponse.close()
+        Timber.i("response of USSD session [$sessionID] stored successfully")
+        return true
+    }
+
     fun sendMissedCallEvent(sim: String, from: String, to: String, timestamp: String): Boolean {
         val body = """
             {
@@ -129,10 +203,11 @@ class HttpSmsApiService(private val apiKey: String, private val baseURL: URI) {
         return true
     }
 
-    fun storeHeartbeat(phoneNumber: String, charging: Boolean) {
+    fun storeHeartbeat(phoneNumber: String, charging: Boolean, battery: Int?) {
         val body = """
             {
               "charging": $charging,
+              "battery": $battery,
               "owner": "$phoneNumber"
             }
         """.trimIndent()
@@ -226,6 +301,41 @@ class HttpSmsApiService(private val apiKey: String, private val baseURL: URI) {
     }
 
 
+    // exchangeProvisioningCode registers the phone with a code from the web app, the request is not authenticated with the API key because the code is exchanged for it
+    fun exchangeProvisioningCode(code: String, phoneNumber: String, fcmToken: String, sim: String): Pair<PhoneProvisioning?, String?> {
+        val body = """
+            {
+              "code": "${StringEscapeUtils.escapeJson(code)}",
+              "fcm_token": "$fcmToken",
+              "phone_number": "$phoneNumber",
+              "sim": "$sim"
+            }
+        """.trimIndent()
+
+        val request: Request = Request.Builder()
+            .url(resolveURL("/v1/phones/provisioning-codes/exchange"))
+            .post(body.toRequestBody(jsonMediaType))
+            .header(clientVersionHeader, BuildConfig.VERSION_NAME)
+            .build()
+
+        try {
+            val response = client.newCall(request).execute()
+            if (!response.isSuccessful) {
+                Timber.e("error response [${response.body?.string()}] with code [${response.code}] while exchanging provisioning code for phone [$phoneNumber]")
+                response.close()
+                return Pair(null, "Cannot use the provisioning code. It may have expired, create a new code and try again.")
+            }
+
+            val payload = ResponsePhoneProvisioning.fromJson(response.body!!.string())?.data
+            response.close()
+            Timber.i("provisioning code exchanged successfully for phone [$phoneNumber] and id [${payload?.phone?.id}]")
+            return Pair(payload, null)
+        } catch (ex: Exception) {
+            Timber.e(ex)
+            return Pair(null, ex.message)
+        }
+    }
+
     fun validateApiKey(): Pair<String?, String?> {
         val request: Request = Request.Builder()
             .url(resolveURL("/v1/users/me"))
@@ -223,6 +223,8 @@ class LoginActivity : AppCompatActivity() {
             return
         }
 
+        // the API key is replaced by the key of the user when a provisioning code is exchanged
+        var credential = apiKey.text.toString()
         val liveData = MutableLiveData<Pair<String?, String?>>()
         liveData.observe(this) { authResult ->
             run {
@@ -239,7 +241,7 @@ class LoginActivity : AppCompatActivity() {
                     return@run
                 }
 
-                Settings.setApiKeyAsync(this, apiKey.text.toString())
+                Settings.setApiKeyAsync(this, credential)
                 Settings.setServerUrlAsync(this, serverUrl.text.toString().trim())
 
                 val e164PhoneNumber = formatE164(phoneNumber.text.toString().trim())
@@ -256,12 +258,34 @@ class LoginActivity : AppCompatActivity() {
         }
 
         Thread {
-            val error = HttpSmsApiService(apiKey.text.toString(), URI(serverUrl.text.toString().trim())).validateApiKey()
+            if (isProvisioningCode(credential)) {
+                val provisioning = HttpSmsApiService("", URI(serverUrl.text.toString().trim())).exchangeProvisioningCode(
+                    credential.trim(),
+                    formatE164(phoneNumber.text.toString().trim()),
+                    Settings.getFcmToken(this) ?: "",
+                    Constants.SIM1
+                )
+                if (provisioning.first == null) {
+                    liveData.postValue(Pair(provisioning.second, null))
+                    return@Thread
+                }
+
+                credential = provisioning.first!!.apiKey
+                Settings.setUserID(this, provisioning.first!!.phone.userID)
+                Settings.setFcmVerificationKeys(this, Constants.SIM1, provisioning.first!!.phone.verificationKeys())
+            }
+
+            val error = HttpSmsApiService(credential, URI(serverUrl.text.toString().trim())).validateApiKey()
             liveData.postValue(error)
             Timber.d("finished validating api URL")
         }.start()
     }
 
+    // isProvisioningCode checks if the user entered a short provisioning code from the web app instead of the API key e.g. K7QM-2XPD
+    private fun isProvisioningCode(value: String): Boolean {
+        return Regex("^[A-Za-z0-9]{4}-?[A-Za-z0-9]{4}$").matches(value.trim())
+    }
+
     private fun formatE164(number: String): String {
         var phoneNumber = number.trim()
         if (!number.startsWith("+")) {
@@ -119,7 +119,8 @@ class MainActivity : AppCompatActivity() {
         var permissions = arrayOf(
             Manifest.permission.SEND_SMS,
             Manifest.permission.RECEIVE_SMS,
-            Manifest.permission.READ_SMS
+            Manifest.permission.READ_SMS,
+            Manifest.permission.CALL_PHONE
         )
 
         if(Build.VERSION.SDK_INT >= 33) {
@@ -205,6 +206,7 @@ class MainActivity : AppCompatActivity() {
             val phone = HttpSmsApiService.create(context).updatePhone(phoneNumber, Settings.getFcmToken(context) ?: "", sim)
             if (phone != null) {
                 Settings.setUserID(context, phone.userID)
+                Settings.setFcmVerificationKeys(context, sim, phone.verificationKeys())
                 Settings.setFcmTokenLastUpdateTimestampAsync(context, timestamp)
                 Timber.i("[${sim}] FCM token uploaded successfully")
                 return@Thread
@@ -329,9 +331,10 @@ class MainActivity : AppCompatActivity() {
 
         Thread {
             val charging = Settings.isCharging(applicationContext)
+            val battery = Settings.getBatteryLevel(applicationContext)
             var error: String? = null
             try {
-                HttpSmsApiService.create(context).storeHeartbeat(Settings.getSIM1PhoneNumber(context), charging)
+                HttpSmsApiService.create(context).storeHeartbeat(Settings.getSIM1PhoneNumber(context), charging, battery)
                 Settings.setHeartbeatTimestampAsync(applicationContext, System.currentTimeMillis())
             } catch (exception: Exception) {
                 Timber.e(exception)
@@ -339,7 +342,7 @@ class MainActivity : AppCompatActivity() {
             }
             if (Settings.isDualSIM(context)) {
                 try {
-                    HttpSmsApiService.create(context).storeHeartbeat(Settings.getSIM2PhoneNumber(context), charging)
+                    HttpSmsApiService.create(context).storeHeartbeat(Settings.getSIM2PhoneNumber(context), charging, battery)
                     Settings.setHeartbeatTimestampAsync(applicationContext, System.currentTimeMillis())
                 } catch (exception: Exception) {
                     Timber.e(exception)
@@ -12,6 +12,16 @@ data class ResponseMessage (
         fun fromJson(json: String) = Klaxon().parse<ResponseMessage>(json)
     }
 }
+data class ResponseMessages (
+    val data: List<Message>,
+    val message: String,
+    val status: String
+) {
+    companion object {
+        fun fromJson(json: String) = Klaxon().parse<ResponseMessages>(json)
+    }
+}
+
 data class ResponsePhone (
     val data: Phone,
     val message: String,
@@ -22,12 +32,42 @@ data class ResponsePhone (
     }
 }
 
+data class ResponsePhoneProvisioning (
+    val data: PhoneProvisioning,
+    val message: String,
+    val status: String,
+) {
+    companion object {
+        fun fromJson(json: String) = Klaxon().parse<ResponsePhoneProvisioning>(json)
+    }
+}
+
+data class PhoneProvisioning (
+    @Json(name = "api_key")
+    val apiKey: String,
+
+    val phone: Phone,
+)
+
 data class Phone (
     val id: String,
 
     @Json(name = "user_id")
     val userID: String,
-)
+
+    // public key which verifies the signature of the push notifications, it is null when the server does not sign them
+    @Json(name = "fcm_verification_key")
+    val fcmVerificationKey: String? = null,
+
+    // public key of the rotated key which signs the push notifications once it is promoted
+    @Json(name = "fcm_next_verification_key")
+    val fcmNextVerificationKey: String? = null,
+) {
+    // verificationKeys contains both keys while the key is being rotated so notifications signed before and after the promotion are valid
+    fun verificationKeys(): Set<String> {
+        return setOfNotNull(fcmVerificationKey, fcmNextVerificationKey)
+    }
+}
 
 data class Message (
     val contact: String,
@@ -68,5 +108,9 @@ data class Message (
     val type: String,
 
     @Json(name = "updated_at")
-    val updatedAt: String
+    val updatedAt: String,
+
+    // validity period in seconds which the carrier should attempt to deliver the message
+    @Json(name = "validity_period")
+    val validityPeriod: Long?
 )
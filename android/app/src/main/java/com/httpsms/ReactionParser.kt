@@ -0,0 +1,52 @@
+package com.httpsms
+
+/**
+ * Reaction is an emoji which the contact sent to a message, Quote is the content of the message as the contact's app quoted it
+ */
+data class Reaction(val emoji: String, val quote: String)
+
+/**
+ * ReactionParser detects the text messages which the apps of contacts without RCS send instead of a reaction
+ * e.g. Reacted 👍 to “Hello” or Liked “Hello”
+ */
+object ReactionParser {
+    private val tapbacks = mapOf(
+        "Liked" to "👍",
+        "Loved" to "❤️",
+        "Disliked" to "👎",
+        "Laughed at" to "😂",
+        "Emphasized" to "‼️",
+        "Questioned" to "❓",
+    )
+
+    private val reactedPattern = Regex("^Reacted (\\S+) to [“\"](.+)[”\"]$", RegexOption.DOT_MATCHES_ALL)
+    private val tapbackPattern = Regex("^(${tapbacks.keys.joinToString("|")}) [“\"](.+)[”\"]$", RegexOption.DOT_MATCHES_ALL)
+
+    fun parse(content: String): Reaction? {
+        val text = content.trim()
+
+        reactedPattern.find(text)?.let {
+            return Reaction(it.groupValues[1], it.groupValues[2])
+        }
+
+        tapbackPattern.find(text)?.let {
+            return Reaction(tapbacks[it.groupValues[1]]!!, it.groupValues[2])
+        }
+
+        return null
+    }
+
+    /**
+     * matches checks if the quote is the content of a message, the apps of the contacts shorten long messages with an ellipsis
+     */
+    fun matches(reaction: Reaction, content: String): Boolean {
+        val quote = reaction.quote.removeSuffix("…").removeSuffix("...").trim()
+        if (quote.isEmpty()) {
+            return false
+        }
+        if (quote.length == reaction.quote.length) {
+            return content.trim() == quote
+        }
+        return content.trim().startsWith(quote)
+    }
+}
@@ -102,6 +102,10 @@ class ReceivedReceiver: BroadcastReceiver()
 
     internal class ReceivedSmsWorker(appContext: Context, workerParams: WorkerParameters) : Worker(appContext, workerParams) {
         override fun doWork(): Result {
+            if (!this.inputData.getBoolean(Constants.KEY_MESSAGE_ENCRYPTED, false) && storeReaction()) {
+                return Result.success()
+            }
+
             Timber.i("[${this.inputData.getString(Constants.KEY_MESSAGE_SIM)}] forwarding received message from [${this.inputData.getString(Constants.KEY_MESSAGE_FROM)}] to [${this.inputData.getString(Constants.KEY_MESSAGE_TO)}]")
 
             if (HttpSmsApiService.create(applicationContext).receive(
@@ -117,5 +121,25 @@ class ReceivedReceiver: BroadcastReceiver()
 
             return Result.retry()
         }
+
+        // storeReaction attaches a reaction which the contact sent as a text message to the message it quotes, the text is received like any other message when no message matches
+        private fun storeReaction(): Boolean {
+            val reaction = ReactionParser.parse(this.inputData.getString(Constants.KEY_MESSAGE_CONTENT)!!) ?: return false
+
+            val from = this.inputData.getString(Constants.KEY_MESSAGE_FROM)!!
+            val to = this.inputData.getString(Constants.KEY_MESSAGE_TO)!!
+            return try {
+                val service = HttpSmsApiService.create(applicationContext)
+                val message = service.getMessages(to, from)?.firstOrNull { !it.encrypted && ReactionParser.matches(reaction, it.content) }
+                if (message == null) {
+                    Timber.i("cannot find the message quoted by reaction [${reaction.emoji}] from [$from] to [$to]")
+                    return false
+                }
+                service.storeReaction(message.id, reaction.emoji, this.inputData.getString(Constants.KEY_MESSAGE_TIMESTAMP)!!)
+            } catch (exception: Exception) {
+                Timber.e(exception)
+                false
+            }
+        }
     }
 }
@@ -27,6 +27,13 @@ object Settings {
     private const val SETTINGS_HEARTBEAT_TIMESTAMP = "SETTINGS_HEARTBEAT_TIMESTAMP"
     private const val SETTINGS_ENCRYPTION_KEY = "SETTINGS_ENCRYPTION_KEY"
     private const val SETTINGS_ENCRYPT_RECEIVED_MESSAGES = "SETTINGS_ENCRYPT_RECEIVED_MESSAGES"
+    private const val SETTINGS_SIM1_FCM_VERIFICATION_KEYS = "SETTINGS_SIM1_FCM_VERIFICATION_KEYS"
+    private const val SETTINGS_SIM2_FCM_VERIFICATION_KEYS = "SETTINGS_SIM2_FCM_VERIFICATION_KEYS"
+    private const val SETTINGS_FCM_NONCES = "SETTINGS_FCM_NONCES"
+    private const val SETTINGS_FCM_VERIFICATION_KEYS_REFRESHED_AT = "SETTINGS_FCM_VERIFICATION_KEYS_REFRESHED_AT"
+
+    // FCM_VERIFICATION_KEYS_REFRESH_INTERVAL_SECONDS limits how often invalid push notifications can make the app fetch the keys from the API
+    private const val FCM_VERIFICATION_KEYS_REFRESH_INTERVAL_SECONDS = 60L
 
     fun getPhoneNumber(context:Context, sim: String): String {
         if (sim == Constants.SIM2) {
@@ -281,6 +288,15 @@ object Settings {
         return myBatteryManager.isCharging
     }
 
+    fun getBatteryLevel(context: Context): Int? {
+        val myBatteryManager = context.getSystemService(Context.BATTERY_SERVICE) as BatteryManager
+        val level = myBatteryManager.getIntProperty(BatteryManager.BATTERY_PROPERTY_CAPACITY)
+        if (level < 0 || level > 100) {
+            return null
+        }
+        return level
+    }
+
     fun setUserID(context:Context, userID: String?) {
         Timber.d(Settings::setUserID.name)
         PreferenceManager.getDefaultSharedPreferences(context)
@@ -369,6 +385,64 @@ object Settings {
     }
 
 
+    fun setFcmVerificationKeys(context: Context, sim: String, keys: Set<String>) {
+        Timber.d(Settings::setFcmVerificationKeys.name)
+
+        var setting = this.SETTINGS_SIM1_FCM_VERIFICATION_KEYS
+        if (sim == Constants.SIM2) {
+            setting = this.SETTINGS_SIM2_FCM_VERIFICATION_KEYS
+        }
+
+        PreferenceManager.getDefaultSharedPreferences(context)
+            .edit()
+            .putStringSet(setting, keys)
+            .apply()
+    }
+
+    // getFcmVerificationKeys returns the keys of both SIMs since the push notifications do not contain the SIM of the phone
+    fun getFcmVerificationKeys(context: Context): Set<String> {
+        val preferences = PreferenceManager.getDefaultSharedPreferences(context)
+        val keys = preferences.getStringSet(this.SETTINGS_SIM1_FCM_VERIFICATION_KEYS, emptySet())!!.toMutableSet()
+        if (isDualSIM(context)) {
+            keys.addAll(preferences.getStringSet(this.SETTINGS_SIM2_FCM_VERIFICATION_KEYS, emptySet())!!)
+        }
+
+        Timber.d("SETTINGS_FCM_VERIFICATION_KEYS: [${keys.size}] keys")
+        return keys
+    }
+
+    // shouldRefreshFcmVerificationKeys returns true at most once every minute when a push notification cannot be verified with the stored keys
+    @Synchronized
+    fun shouldRefreshFcmVerificationKeys(context: Context, now: Long): Boolean {
+        val preferences = PreferenceManager.getDefaultSharedPreferences(context)
+        val refreshedAt = preferences.getLong(this.SETTINGS_FCM_VERIFICATION_KEYS_REFRESHED_AT, 0)
+        if (now - refreshedAt < FCM_VERIFICATION_KEYS_REFRESH_INTERVAL_SECONDS) {
+            Timber.d("the verification keys were refreshed at [$refreshedAt]")
+            return false
+        }
+
+        preferences.edit().putLong(this.SETTINGS_FCM_VERIFICATION_KEYS_REFRESHED_AT, now).commit()
+        return true
+    }
+
+    // useFcmNonce stores the nonce of a push notification until it expires and returns false when it has already been used
+    @Synchronized
+    fun useFcmNonce(context: Context, nonce: String, expiresAt: Long, now: Long): Boolean {
+        val preferences = PreferenceManager.getDefaultSharedPreferences(context)
+        val nonces = preferences.getStringSet(this.SETTINGS_FCM_NONCES, emptySet())!!
+            .filter { (it.substringAfter(":").toLongOrNull() ?: 0) >= now }
+            .toMutableSet()
+
+        if (nonces.any { it.substringBefore(":") == nonce }) {
+            Timber.w("the nonce [$nonce] of the push notification has already been used")
+            return false
+        }
+
+        nonces.add("$nonce:$expiresAt")
+        preferences.edit().putStringSet(this.SETTINGS_FCM_NONCES, nonces).commit()
+        return true
+    }
+
     fun setHeartbeatTimestampAsync(context: Context, timestamp: Long) {
         Timber.d(Settings::setHeartbeatTimestampAsync.name)
 
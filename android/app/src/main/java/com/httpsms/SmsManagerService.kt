@@ -16,6 +16,7 @@ class SmsManagerService {
     companion object {
         private const val ACTION_SMS_SENT = "SMS_SENT"
         private const val ACTION_SMS_DELIVERED = "SMS_DELIVERED"
+        private const val SMS_MESSAGE_PRIORITY_NOT_SPECIFIED = -1
 
         fun sentAction(): String {
             return "${BuildConfig.APPLICATION_ID}.$ACTION_SMS_SENT"
@@ -44,12 +45,44 @@ class SmsManagerService {
         return getSmsManager(context).divideMessage(content)
     }
 
-    fun sendMultipartMessage(context: Context, contact: String, parts: ArrayList<String>, sim: String, sendIntents: ArrayList<PendingIntent>, deliveryIntents: ArrayList<PendingIntent>) {
-        getSmsManager(context, sim).sendMultipartTextMessage(contact, null, parts, sendIntents, deliveryIntents)
+    fun sendMultipartMessage(context: Context, contact: String, parts: ArrayList<String>, sim: String, sendIntents: ArrayList<PendingIntent>, deliveryIntents: ArrayList<PendingIntent>, validityPeriod: Long? = null) {
+        val smsManager = getSmsManager(context, sim)
+        if (validityPeriod != null) {
+            try {
+                // the SMS validity period is only available in the non-SDK interface of the SmsManager
+                smsManager.javaClass.getMethod(
+                    "sendMultipartTextMessage",
+                    String::class.java, String::class.java, ArrayList::class.java, ArrayList::class.java, ArrayList::class.java,
+                    Int::class.javaPrimitiveType, Boolean::class.javaPrimitiveType, Int::class.javaPrimitiveType
+                ).invoke(smsManager, contact, null, parts, sendIntents, deliveryIntents, SMS_MESSAGE_PRIORITY_NOT_SPECIFIED, false, validityMinutes(validityPeriod))
+                return
+            } catch (e: Exception) {
+                Timber.w(e, "cannot set the validity period of [$validityPeriod] seconds, sending without it")
+            }
+        }
+        smsManager.sendMultipartTextMessage(contact, null, parts, sendIntents, deliveryIntents)
+    }
+
+    fun sendTextMessage(context: Context, contact: String, content: String, sim: String, sentIntent:PendingIntent, deliveryIntent: PendingIntent, validityPeriod: Long? = null) {
+        val smsManager = getSmsManager(context, sim)
+        if (validityPeriod != null) {
+            try {
+                smsManager.javaClass.getMethod(
+                    "sendTextMessage",
+                    String::class.java, String::class.java, String::class.java, PendingIntent::class.java, PendingIntent::class.java,
+                    Int::class.javaPrimitiveType, Boolean::class.javaPrimitiveType, Int::class.javaPrimitiveType
+                ).invoke(smsManager, contact, null, content, sentIntent, deliveryIntent, SMS_MESSAGE_PRIORITY_NOT_SPECIFIED, false, validityMinutes(validityPeriod))
+                return
+            } catch (e: Exception) {
+                Timber.w(e, "cannot set the validity period of [$validityPeriod] seconds, sending without it")
+            }
+        }
+        smsManager.sendTextMessage(contact, null, content, sentIntent, deliveryIntent)
     }
 
-    fun sendTextMessage(context: Context, contact: String, content: String, sim: String, sentIntent:PendingIntent, deliveryIntent: PendingIntent) {
-        getSmsManager(context, sim).sendTextMessage(contact, null, content, sentIntent, deliveryIntent)
+    // the SmsManager expects the validity period in minutes between 5 minutes and 635040 minutes (63 weeks)
+    private fun validityMinutes(validityPeriod: Long): Int {
+        return (validityPeriod / 60).coerceIn(5, 635040).toInt()
     }
 
     @Suppress("DEPRECATION")
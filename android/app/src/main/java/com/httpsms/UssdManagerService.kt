@@ -0,0 +1,64 @@
+package com.httpsms
+
+import android.Manifest
+import android.annotation.SuppressLint
+import android.content.Context
+import android.content.pm.PackageManager
+import android.os.Build
+import android.os.Handler
+import android.os.Looper
+import android.telephony.SubscriptionManager
+import android.telephony.TelephonyManager
+import androidx.core.app.ActivityCompat
+import timber.log.Timber
+
+class UssdManagerService {
+    companion object {
+        const val FAILURE_NO_PERMISSION = "USSD_CALL_PHONE_PERMISSION_DENIED"
+        const val FAILURE_RETURN = "USSD_RETURN_FAILURE"
+        const val FAILURE_SERVICE_UNAVAILABLE = "USSD_ERROR_SERVICE_UNAVAIL"
+    }
+
+    /**
+     * send executes the USSD request on the SIM and reports the response with the callback, the failure reason is null when the request succeeds
+     */
+    @SuppressLint("MissingPermission")
+    fun send(context: Context, request: String, sim: String, callback: (response: String?, failureReason: String?) -> Unit) {
+        if (ActivityCompat.checkSelfPermission(context, Manifest.permission.CALL_PHONE) != PackageManager.PERMISSION_GRANTED) {
+            Timber.e("cannot execute USSD request, the [${Manifest.permission.CALL_PHONE}] permission is not granted")
+            callback(null, FAILURE_NO_PERMISSION)
+            return
+        }
+
+        getTelephonyManager(context, sim).sendUssdRequest(request, object : TelephonyManager.UssdResponseCallback() {
+            override fun onReceiveUssdResponse(telephonyManager: TelephonyManager, request: String, response: CharSequence) {
+                Timber.i("received response for USSD request [$request]")
+                callback(response.toString(), null)
+            }
+
+            override fun onReceiveUssdResponseFailed(telephonyManager: TelephonyManager, request: String, failureCode: Int) {
+                Timber.e("USSD request [$request] failed with code [$failureCode]")
+                callback(null, if (failureCode == TelephonyManager.USSD_ERROR_SERVICE_UNAVAIL) FAILURE_SERVICE_UNAVAILABLE else FAILURE_RETURN)
+            }
+        }, Handler(Looper.getMainLooper()))
+    }
+
+    @SuppressLint("MissingPermission")
+    private fun getTelephonyManager(context: Context, sim: String): TelephonyManager {
+        val localSubscriptionManager: SubscriptionManager = if (Build.VERSION.SDK_INT < 31) {
+            SubscriptionManager.from(context)
+        } else {
+            context.getSystemService(SubscriptionManager::class.java)
+        }
+
+        val subscriptionId = if (sim == Constants.SIM1 && localSubscriptionManager.activeSubscriptionInfoList.size > 0) {
+            localSubscriptionManager.activeSubscriptionInfoList[0].subscriptionId
+        } else if (sim == Constants.SIM2 && localSubscriptionManager.activeSubscriptionInfoList.size > 1) {
+            localSubscriptionManager.activeSubscriptionInfoList[1].subscriptionId
+        } else {
+            SubscriptionManager.getDefaultSubscriptionId()
+        }
+
+        return context.getSystemService(TelephonyManager::class.java).createForSubscriptionId(subscriptionId)
+    }
+}
@@ -30,7 +30,7 @@ class HeartbeatWorker(appContext: Context, workerParams: WorkerParameters) : Wor
             return
         }
 
-        HttpSmsApiService.create(applicationContext).storeHeartbeat(Settings.getSIM1PhoneNumber(applicationContext), Settings.isCharging(applicationContext))
+        HttpSmsApiService.create(applicationContext).storeHeartbeat(Settings.getSIM1PhoneNumber(applicationContext), Settings.isCharging(applicationContext), Settings.getBatteryLevel(applicationContext))
         Timber.d("[SIM1] finished sending heartbeat to server")
 
         Settings.setHeartbeatTimestampAsync(applicationContext, System.currentTimeMillis())
@@ -43,7 +43,7 @@ class HeartbeatWorker(appContext: Context, workerParams: WorkerParameters) : Wor
             return
         }
 
-        HttpSmsApiService.create(applicationContext).storeHeartbeat(Settings.getSIM2PhoneNumber(applicationContext), Settings.isCharging(applicationContext))
+        HttpSmsApiService.create(applicationContext).storeHeartbeat(Settings.getSIM2PhoneNumber(applicationContext), Settings.isCharging(applicationContext), Settings.getBatteryLevel(applicationContext))
         Timber.d("[SIM2] finished sending heartbeat to server")
 
         Settings.setHeartbeatTimestampAsync(applicationContext, System.currentTimeMillis())
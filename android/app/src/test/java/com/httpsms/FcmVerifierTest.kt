@@ -0,0 +1,76 @@
+package com.httpsms
+
+import org.bouncycastle.crypto.params.Ed25519PrivateKeyParameters
+import org.bouncycastle.crypto.signers.Ed25519Signer
+import org.junit.Assert.assertEquals
+import org.junit.Assert.assertNotNull
+import org.junit.Assert.assertNull
+import org.junit.Test
+import java.security.SecureRandom
+import java.util.Base64
+
+class FcmVerifierTest {
+    private val now = 1_700_000_000L
+    private val privateKey = Ed25519PrivateKeyParameters(SecureRandom())
+    private val publicKey = Base64.getEncoder().encodeToString(privateKey.generatePublicKey().encoded)
+
+    private fun sign(data: Map<String, String>, key: Ed25519PrivateKeyParameters = privateKey): Map<String, String> {
+        val payload = data.keys.sorted().joinToString("\n") { "$it=${data[it]}" }.toByteArray(Charsets.UTF_8)
+        val signer = Ed25519Signer()
+        signer.init(true, key)
+        signer.update(payload, 0, payload.size)
+        return data + ("KEY_SIGNATURE" to Base64.getEncoder().encodeToString(signer.generateSignature()))
+    }
+
+    private fun message(timestamp: Long = now, ttl: String? = null): Map<String, String> {
+        val data = mutableMapOf(
+            "KEY_MESSAGE_ID" to "d2b4a1c8-6a3b-4f0e-9b7a-0a1b2c3d4e5f",
+            "KEY_TIMESTAMP" to timestamp.toString(),
+            "KEY_NONCE" to "0123456789abcdef0123456789abcdef",
+            "KEY_SIGNATURE_VERSION" to "0",
+        )
+        ttl?.let { data["KEY_TTL"] = it }
+        return data
+    }
+
+    @Test
+    fun verify_validSignature() {
+        val notification = FcmVerifier.verify(sign(message()), setOf(publicKey), now)
+        assertEquals(VerifiedNotification("0123456789abcdef0123456789abcdef", now + 15 * 60), notification)
+    }
+
+    @Test
+    fun verify_acceptsAnyKnownKey() {
+        val otherKey = Base64.getEncoder().encodeToString(Ed25519PrivateKeyParameters(SecureRandom()).generatePublicKey().encoded)
+        assertNotNull(FcmVerifier.verify(sign(message()), setOf(otherKey, publicKey), now))
+    }
+
+    @Test
+    fun verify_invalidSignature() {
+        val cases = mapOf(
+            "unsigned" to message(),
+            "tampered" to sign(message()) + ("KEY_MESSAGE_ID" to "a0b1c2d3-0000-0000-0000-000000000000"),
+            "added key" to sign(message()) + ("KEY_TTL" to "86400"),
+            "other key" to sign(message(), Ed25519PrivateKeyParameters(SecureRandom())),
+            "bad base64" to message() + ("KEY_SIGNATURE" to "not base64!"),
+        )
+        cases.forEach { (name, data) ->
+            assertNull(name, FcmVerifier.verify(data, setOf(publicKey), now))
+        }
+    }
+
+    @Test
+    fun verify_timestampWindow() {
+        val cases = listOf(
+            Triple("within default ttl", message(timestamp = now - 10 * 60), true),
+            Triple("after default ttl", message(timestamp = now - 10 * 60 - 1), false),
+            Triple("within message ttl", message(timestamp = now - 3600, ttl = "7200"), true),
+            Triple("after message ttl", message(timestamp = now - 3601, ttl = "3600"), false),
+            Triple("clock skew", message(timestamp = now + 5 * 60), true),
+            Triple("future", message(timestamp = now + 5 * 60 + 1), false),
+        )
+        cases.forEach { (name, data, valid) ->
+            assertEquals(name, valid, FcmVerifier.verify(sign(data), setOf(publicKey), now) != null)
+        }
+    }
+}
@@ -0,0 +1,37 @@
+package com.httpsms
+
+import org.junit.Assert.assertEquals
+import org.junit.Assert.assertFalse
+import org.junit.Assert.assertNull
+import org.junit.Assert.assertTrue
+import org.junit.Test
+
+class ReactionParserTest {
+    @Test
+    fun parse_reactedFallback() {
+        assertEquals(Reaction("👍", "See you at 5"), ReactionParser.parse("Reacted 👍 to “See you at 5”"))
+        assertEquals(Reaction("🔥", "Done"), ReactionParser.parse("Reacted 🔥 to \"Done\""))
+    }
+
+    @Test
+    fun parse_tapbacks() {
+        assertEquals(Reaction("❤️", "Thanks"), ReactionParser.parse("Loved “Thanks”"))
+        assertEquals(Reaction("😂", "lol"), ReactionParser.parse("Laughed at “lol”"))
+        assertEquals(Reaction("❓", "Are you in?"), ReactionParser.parse("Questioned “Are you in?”"))
+    }
+
+    @Test
+    fun parse_regularMessages() {
+        assertNull(ReactionParser.parse("Liked it, thanks"))
+        assertNull(ReactionParser.parse("I reacted to \"that\" message"))
+        assertNull(ReactionParser.parse("Your code is 123456"))
+    }
+
+    @Test
+    fun matches_quotedContent() {
+        assertTrue(ReactionParser.matches(Reaction("👍", "See you at 5"), "See you at 5"))
+        assertFalse(ReactionParser.matches(Reaction("👍", "See you"), "See you at 5"))
+        assertTrue(ReactionParser.matches(Reaction("👍", "Your order has been…"), "Your order has been shipped"))
+        assertFalse(ReactionParser.matches(Reaction("👍", "…"), "Your order has been shipped"))
+    }
+}
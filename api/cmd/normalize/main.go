@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"github.com/NdoleStudio/httpsms/pkg/di"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/joho/godotenv"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// This command re-normalizes the owner and contact of historical messages and threads to E.164
+// so conversations which were fragmented by different number formats are merged into 1 thread.
+func main() {
+	dryRun := flag.Bool("dry-run", false, "log the changes without updating the database")
+	batchSize := flag.Int("batch-size", 500, "number of threads to load at once")
+	flag.Parse()
+
+	err := godotenv.Load("../../.env")
+	if err != nil {
+		log.Fatal("Error loading .env file")
+	}
+
+	container := di.NewLiteContainer()
+	logger := container.Logger()
+	db := container.DB()
+
+	logger.Info(fmt.Sprintf("normalizing message numbers with dry-run [%t]", *dryRun))
+
+	if err = normalizeMessages(db, logger, *dryRun); err != nil {
+		logger.Fatal(stacktrace.Propagate(err, "cannot normalize messages"))
+	}
+
+	if err = normalizeThreads(db, logger, *dryRun, *batchSize); err != nil {
+		logger.Fatal(stacktrace.Propagate(err, "cannot normalize message threads"))
+	}
+
+	logger.Info("finished normalizing message numbers")
+}
+
+type messageParties struct {
+	UserID  entities.UserID
+	Owner   string
+	Contact string
+}
+
+func normalizeMessages(db *gorm.DB, logger telemetry.Logger, dryRun bool) error {
+	var parties []messageParties
+	if err := db.Model(&entities.Message{}).Distinct("user_id", "owner", "contact").Find(&parties).Error; err != nil {
+		return stacktrace.Propagate(err, "cannot load the owner and contact of messages")
+	}
+
+	updated := 0
+	for _, party := range parties {
+		owner := services.NormalizePhoneNumber(party.Owner, party.Owner)
+		contact := services.NormalizePhoneNumber(party.Owner, party.Contact)
+		if owner == party.Owner && contact == party.Contact {
+			continue
+		}
+
+		logger.Info(fmt.Sprintf("user [%s] messages owner [%s] => [%s] and contact [%s] => [%s]", party.UserID, party.Owner, owner, party.Contact, contact))
+		updated++
+		if dryRun {
+			continue
+		}
+
+		err := db.Model(&entities.Message{}).
+			Where("user_id = ?", party.UserID).
+			Where("owner = ?", party.Owner).
+			Where("contact = ?", party.Contact).
+			Updates(map[string]any{"owner": owner, "contact": contact}).
+			Error
+		if err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot update messages for user [%s] with owner [%s] and contact [%s]", party.UserID, party.Owner, party.Contact))
+		}
+	}
+
+	logger.Info(fmt.Sprintf("normalized [%d] of [%d] message conversations", updated, len(parties)))
+	return nil
+}
+
+func normalizeThreads(db *gorm.DB, logger telemetry.Logger, dryRun bool, batchSize int) error {
+	var threads []*entities.MessageThread
+	result := db.FindInBatches(&threads, batchSize, func(tx *gorm.DB, batch int) error {
+		for _, thread := range threads {
+			if err := normalizeThread(db, logger, dryRun, thread); err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot normalize thread [%s] in batch [%d]", thread.ID, batch))
+			}
+		}
+		return nil
+	})
+	if result.Error != nil {
+		return stacktrace.Propagate(result.Error, "cannot normalize message threads")
+	}
+
+	logger.Info(fmt.Sprintf("processed [%d] message threads", result.RowsAffected))
+	return nil
+}
+
+func normalizeThread(db *gorm.DB, logger telemetry.Logger, dryRun bool, thread *entities.MessageThread) error {
+	owner := services.NormalizePhoneNumber(thread.Owner, thread.Owner)
+	contact := services.NormalizePhoneNumber(thread.Owner, thread.Contact)
+	if owner == thread.Owner && contact == thread.Contact {
+		return nil
+	}
+
+	existing := new(entities.MessageThread)
+	err := db.Where("user_id = ?", thread.UserID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("id != ?", thread.ID).
+		First(existing).
+		Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load thread for user [%s] with owner [%s] and contact [%s]", thread.UserID, owner, contact))
+	}
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		logger.Info(fmt.Sprintf("thread [%s] owner [%s] => [%s] and contact [%s] => [%s]", thread.ID, thread.Owner, owner, thread.Contact, contact))
+		if dryRun {
+			return nil
+		}
+		thread.Owner = owner
+		thread.Contact = contact
+		if err = db.Save(thread).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot save thread [%s]", thread.ID))
+		}
+		return nil
+	}
+
+	logger.Info(fmt.Sprintf("merging thread [%s] with contact [%s] into thread [%s] with contact [%s]", thread.ID, thread.Contact, existing.ID, existing.Contact))
+	if dryRun {
+		return nil
+	}
+
+	if thread.OrderTimestamp.After(existing.OrderTimestamp) {
+		existing.OrderTimestamp = thread.OrderTimestamp
+		existing.LastMessageID = thread.LastMessageID
+		existing.LastMessageContent = thread.LastMessageContent
+		existing.Status = thread.Status
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err = tx.Save(existing).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot save thread [%s]", existing.ID))
+		}
+		if err = tx.Delete(thread).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete thread [%s]", thread.ID))
+		}
+		return nil
+	})
+}
@@ -1,8 +1,11 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/NdoleStudio/httpsms/docs"
 	"github.com/NdoleStudio/httpsms/pkg/di"
@@ -14,6 +17,7 @@ var Version string
 // @title       HTTP SMS API
 // @version     1.0
 // @description API to send SMS messages using android [SmsManager](https://developer.android.com/reference/android/telephony/SmsManager) via HTTP
+// @description The fields of the JSON responses are snake_case, set the X-JSON-Case header or the json_case query parameter to camel for camelCase fields.
 //
 // @contact.name  HTTP SMS
 // @contact.email support@httpsms.com
@@ -36,5 +40,19 @@ func main() {
 	docs.SwaggerInfo.Host = os.Getenv("SWAGGER_HOST")
 
 	container := di.NewContainer("http-sms", Version)
-	container.Logger().Info(container.App().Listen(fmt.Sprintf("%s:%s", os.Getenv("APP_HOST"), os.Getenv("APP_PORT"))).Error())
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	go func() {
+		if err := container.App().Listen(fmt.Sprintf("%s:%s", os.Getenv("APP_HOST"), os.Getenv("APP_PORT"))); err != nil {
+			container.Logger().Info(err.Error())
+		}
+		stop()
+	}()
+
+	go container.MessageArchiveService().Run(ctx)
+
+	<-ctx.Done()
+	container.Shutdown(container.ShutdownGracePeriod())
 }
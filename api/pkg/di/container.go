@@ -4,9 +4,12 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	otelMetric "go.opentelemetry.io/otel/metric"
@@ -36,6 +39,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/emails"
 
 	cloudtasks "cloud.google.com/go/cloudtasks/apiv2"
+	"cloud.google.com/go/storage"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -51,6 +55,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/middlewares"
 	"google.golang.org/api/option"
 
+	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
@@ -79,6 +84,12 @@ type Container struct {
 	version         string
 	app             *fiber.App
 	eventDispatcher *services.EventDispatcher
+	webhookBatcher  *services.WebhookBatcher
+	webhookPool     *services.WebhookDeliveryPool
+	drainer         *services.Drainer
+	phoneSemaphore  *services.PhoneSemaphore
+	userEventBroker *services.UserEventBroker
+	fcmCredentials  *services.FcmCredentialService
 	logger          telemetry.Logger
 }
 
@@ -109,9 +120,15 @@ func NewContainer(projectID string, version string) (container *Container) {
 
 	container.InitializeTraceProvider()
 
+	container.RegisterHealthRoutes()
+
+	// the provisioning routes are registered before the authenticated /v1 routes because the provisioning code authenticates the exchange request
+	container.RegisterPhoneProvisioningRoutes()
+
 	container.RegisterMessageListeners()
 	container.RegisterMessageRoutes()
 	container.RegisterBulkMessageRoutes()
+	container.RegisterBulkMessageJobListeners()
 
 	container.RegisterMessageThreadRoutes()
 	container.RegisterMessageThreadListeners()
@@ -123,8 +140,12 @@ func NewContainer(projectID string, version string) (container *Container) {
 	container.RegisterUserListeners()
 
 	container.RegisterPhoneRoutes()
+	container.RegisterCallEventRoutes()
+	container.RegisterUssdRoutes()
 
 	container.RegisterEventRoutes()
+	container.RegisterEventLogRoutes()
+	container.RegisterEventLogListeners()
 
 	container.RegisterNotificationListeners()
 	container.RegisterEmailNotificationListeners()
@@ -135,6 +156,15 @@ func NewContainer(projectID string, version string) (container *Container) {
 	container.RegisterWebhookRoutes()
 	container.RegisterWebhookListeners()
 
+	container.RegisterContentFilterRoutes()
+	container.RegisterMessageRuleRoutes()
+	container.RegisterMessageRuleListeners()
+
+	container.RegisterProviderFallbackRoutes()
+	container.RegisterFcmCredentialRoutes()
+	container.RegisterLabelRoutes()
+	container.RegisterProviderFallbackListeners()
+
 	container.RegisterLemonsqueezyRoutes()
 
 	container.RegisterIntegration3CXRoutes()
@@ -157,18 +187,26 @@ func (container *Container) App() (app *fiber.App) {
 
 	container.logger.Debug(fmt.Sprintf("creating %T", app))
 
-	app = fiber.New()
+	// the IP of the client is read from the proxy header e.g. X-Real-IP when the API is behind a load balancer
+	app = fiber.New(fiber.Config{ProxyHeader: os.Getenv("APP_PROXY_HEADER")})
 
 	if os.Getenv("USE_HTTP_LOGGER") == "true" {
 		app.Use(fiberLogger.New())
 	}
 
+	// the key query parameter is redacted while otelfiber records the URL of the request in the attributes of the span
+	app.Use(middlewares.RedactQueryAPIKey())
 	app.Use(otelfiber.Middleware())
+	app.Use(middlewares.RestoreQueryAPIKey())
 	app.Use(cors.New())
+
+	// compress responses with gzip, deflate or brotli when it is allowed by the Accept-Encoding header
+	app.Use(compress.New())
 	app.Use(middlewares.HTTPRequestLogger(container.Tracer(), container.Logger()))
 
 	app.Use(middlewares.BearerAuth(container.Logger(), container.Tracer(), container.FirebaseAuthClient()))
 	app.Use(middlewares.APIKeyAuth(container.Logger(), container.Tracer(), container.UserRepository()))
+	app.Use(middlewares.SubAccountAuth(container.Logger(), container.Tracer(), container.UserRepository()))
 
 	container.app = app
 	return app
@@ -180,6 +218,12 @@ func (container *Container) BearerAPIKeyMiddleware() fiber.Handler {
 	return middlewares.BearerAPIKeyAuth(container.Logger(), container.Tracer(), container.UserRepository())
 }
 
+// QueryAPIKeyMiddleware creates a new instance of middlewares.QueryAPIKeyAuth
+func (container *Container) QueryAPIKeyMiddleware() fiber.Handler {
+	container.logger.Debug("creating middlewares.QueryAPIKeyAuth")
+	return middlewares.QueryAPIKeyAuth(container.Logger(), container.Tracer(), container.UserRepository())
+}
+
 // AuthenticatedMiddleware creates a new instance of middlewares.Authenticated
 func (container *Container) AuthenticatedMiddleware() fiber.Handler {
 	container.logger.Debug("creating middlewares.Authenticated")
@@ -288,10 +332,19 @@ ALTER TABLE discords ADD CONSTRAINT IF NOT EXISTS uni_discords_server_id CHECK (
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.User{})))
 	}
 
+	backfillPhoneMessageTimes := !db.Migrator().HasColumn(&entities.Phone{}, "LastSentAt")
 	if err = db.AutoMigrate(&entities.Phone{}); err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Phone{})))
 	}
 
+	if backfillPhoneMessageTimes {
+		container.backfillPhoneMessageTimes(db)
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneDailyUsage{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneDailyUsage{})))
+	}
+
 	if err = db.AutoMigrate(&entities.PhoneNotification{}); err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneNotification{})))
 	}
@@ -304,6 +357,38 @@ ALTER TABLE discords ADD CONSTRAINT IF NOT EXISTS uni_discords_server_id CHECK (
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Webhook{})))
 	}
 
+	if err = db.AutoMigrate(&entities.PhoneGroup{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneGroup{})))
+	}
+
+	if err = db.AutoMigrate(&entities.CountryRoute{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.CountryRoute{})))
+	}
+
+	if err = db.AutoMigrate(&entities.ContentFilter{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ContentFilter{})))
+	}
+
+	if err = db.AutoMigrate(&entities.MessageRule{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.MessageRule{})))
+	}
+
+	if err = db.AutoMigrate(&entities.ProviderFallback{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.ProviderFallback{})))
+	}
+
+	if err = db.AutoMigrate(&entities.Label{}, &entities.MessageLabel{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T and %T", &entities.Label{}, &entities.MessageLabel{})))
+	}
+
+	if err = db.AutoMigrate(&entities.FcmCredential{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.FcmCredential{})))
+	}
+
+	if err = db.AutoMigrate(&entities.PhoneProvisioningCode{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.PhoneProvisioningCode{})))
+	}
+
 	if err = db.AutoMigrate(&entities.Discord{}); err != nil {
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Discord{})))
 	}
@@ -312,9 +397,55 @@ ALTER TABLE discords ADD CONSTRAINT IF NOT EXISTS uni_discords_server_id CHECK (
 		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.Integration3CX{})))
 	}
 
+	if err = db.AutoMigrate(&entities.CallEvent{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.CallEvent{})))
+	}
+
+	if err = db.AutoMigrate(&entities.UssdSession{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.UssdSession{})))
+	}
+
+	if err = db.AutoMigrate(&entities.EventLog{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.EventLog{})))
+	}
+
+	if err = db.AutoMigrate(&entities.WebhookReplay{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.WebhookReplay{})))
+	}
+
+	if err = db.AutoMigrate(&entities.WebhookDeliveryStat{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.WebhookDeliveryStat{})))
+	}
+
+	if err = db.AutoMigrate(&entities.BulkMessageJob{}, &entities.BulkMessageJobItem{}); err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot migrate %T", &entities.BulkMessageJob{})))
+	}
+
 	return container.db
 }
 
+// backfillPhoneMessageTimes sets the last sent and received times of the phones from the existing messages when the columns are created
+func (container *Container) backfillPhoneMessageTimes(db *gorm.DB) {
+	backfills := []struct {
+		column      string
+		source      string
+		messageType entities.MessageType
+	}{
+		{column: "last_sent_at", source: "sent_at", messageType: entities.MessageTypeMobileTerminated},
+		{column: "last_received_at", source: "received_at", messageType: entities.MessageTypeMobileOriginated},
+	}
+
+	for _, backfill := range backfills {
+		query := fmt.Sprintf(`
+UPDATE phones SET %[1]s = m.%[1]s
+FROM (SELECT user_id, owner, MAX(%[2]s) AS %[1]s FROM messages WHERE type = ? AND %[2]s IS NOT NULL GROUP BY user_id, owner) m
+WHERE phones.user_id = m.user_id AND phones.phone_number = m.owner`, backfill.column, backfill.source)
+		if err := db.Exec(query, backfill.messageType).Error; err != nil {
+			container.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot backfill the [%s] column of phones", backfill.column)))
+		}
+	}
+}
+
 // FirebaseApp creates a new instance of firebase.App
 func (container *Container) FirebaseApp() (app *firebase.App) {
 	container.logger.Debug(fmt.Sprintf("creating %T", app))
@@ -460,6 +591,8 @@ func (container *Container) MessageHandlerValidator() (validator *validators.Mes
 		container.Logger(),
 		container.Tracer(),
 		container.PhoneService(),
+		container.UserService(),
+		container.ContentFilterService(),
 	)
 }
 
@@ -471,6 +604,8 @@ func (container *Container) BulkMessageHandlerValidator() (validator *validators
 		container.Tracer(),
 		container.PhoneService(),
 		container.UserService(),
+		container.MessageService(),
+		container.BulkMessageJobService(),
 	)
 }
 
@@ -485,6 +620,28 @@ func (container *Container) HeartbeatHandler() (h *handlers.HeartbeatHandler) {
 	)
 }
 
+// CallEventHandler creates a new instance of handlers.CallEventHandler
+func (container *Container) CallEventHandler() (h *handlers.CallEventHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewCallEventHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.CallEventHandlerValidator(),
+		container.CallEventService(),
+	)
+}
+
+// UssdHandler creates a new instance of handlers.UssdHandler
+func (container *Container) UssdHandler() (h *handlers.UssdHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewUssdHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.UssdHandlerValidator(),
+		container.UssdService(),
+	)
+}
+
 // BillingHandler creates a new instance of handlers.BillingHandler
 func (container *Container) BillingHandler() (h *handlers.BillingHandler) {
 	container.logger.Debug(fmt.Sprintf("creating %T", h))
@@ -496,6 +653,16 @@ func (container *Container) BillingHandler() (h *handlers.BillingHandler) {
 	)
 }
 
+// HealthHandler creates a new instance of handlers.HealthHandler
+func (container *Container) HealthHandler() (h *handlers.HealthHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewHealthHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.HealthService(),
+	)
+}
+
 // WebhookHandler creates a new instance of handlers.WebhookHandler
 func (container *Container) WebhookHandler() (h *handlers.WebhookHandler) {
 	container.logger.Debug(fmt.Sprintf("creating %T", h))
@@ -516,6 +683,24 @@ func (container *Container) HeartbeatHandlerValidator() (validator *validators.H
 	)
 }
 
+// CallEventHandlerValidator creates a new instance of validators.CallEventHandlerValidator
+func (container *Container) CallEventHandlerValidator() (validator *validators.CallEventHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewCallEventHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// UssdHandlerValidator creates a new instance of validators.UssdHandlerValidator
+func (container *Container) UssdHandlerValidator() (validator *validators.UssdHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewUssdHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
 // BillingHandlerValidator creates a new instance of validators.BillingHandlerValidator
 func (container *Container) BillingHandlerValidator() (validator *validators.BillingHandlerValidator) {
 	container.logger.Debug(fmt.Sprintf("creating %T", validator))
@@ -532,6 +717,129 @@ func (container *Container) DiscordHandlerValidator() (validator *validators.Dis
 		container.Logger(),
 		container.Tracer(),
 		container.DiscordClient(),
+		container.PhoneService(),
+	)
+}
+
+// ContentFilterHandler creates a new instance of handlers.ContentFilterHandler
+func (container *Container) ContentFilterHandler() (h *handlers.ContentFilterHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewContentFilterHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ContentFilterService(),
+		container.ContentFilterHandlerValidator(),
+	)
+}
+
+// ContentFilterHandlerValidator creates a new instance of validators.ContentFilterHandlerValidator
+func (container *Container) ContentFilterHandlerValidator() (validator *validators.ContentFilterHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewContentFilterHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// MessageRuleHandler creates a new instance of handlers.MessageRuleHandler
+func (container *Container) MessageRuleHandler() (h *handlers.MessageRuleHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewMessageRuleHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageRuleService(),
+		container.MessageRuleHandlerValidator(),
+	)
+}
+
+// MessageRuleHandlerValidator creates a new instance of validators.MessageRuleHandlerValidator
+func (container *Container) MessageRuleHandlerValidator() (validator *validators.MessageRuleHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewMessageRuleHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ProviderFallbackHandler creates a new instance of handlers.ProviderFallbackHandler
+func (container *Container) ProviderFallbackHandler() (h *handlers.ProviderFallbackHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewProviderFallbackHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.ProviderFallbackService(),
+		container.ProviderFallbackHandlerValidator(),
+	)
+}
+
+// LabelHandler creates a new instance of handlers.LabelHandler
+func (container *Container) LabelHandler() (h *handlers.LabelHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewLabelHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.LabelService(),
+		container.LabelHandlerValidator(),
+	)
+}
+
+// LabelHandlerValidator creates a new instance of validators.LabelHandlerValidator
+func (container *Container) LabelHandlerValidator() (validator *validators.LabelHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewLabelHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// FcmCredentialHandler creates a new instance of handlers.FcmCredentialHandler
+func (container *Container) FcmCredentialHandler() (h *handlers.FcmCredentialHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewFcmCredentialHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.FcmCredentialService(),
+		container.FcmCredentialHandlerValidator(),
+	)
+}
+
+// FcmCredentialHandlerValidator creates a new instance of validators.FcmCredentialHandlerValidator
+func (container *Container) FcmCredentialHandlerValidator() (validator *validators.FcmCredentialHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewFcmCredentialHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.FcmCredentialService(),
+	)
+}
+
+// PhoneProvisioningHandler creates a new instance of handlers.PhoneProvisioningHandler
+func (container *Container) PhoneProvisioningHandler() (h *handlers.PhoneProvisioningHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewPhoneProvisioningHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneProvisioningService(),
+		container.PhoneProvisioningHandlerValidator(),
+	)
+}
+
+// PhoneProvisioningHandlerValidator creates a new instance of validators.PhoneProvisioningHandlerValidator
+func (container *Container) PhoneProvisioningHandlerValidator() (validator *validators.PhoneProvisioningHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewPhoneProvisioningHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+	)
+}
+
+// ProviderFallbackHandlerValidator creates a new instance of validators.ProviderFallbackHandlerValidator
+func (container *Container) ProviderFallbackHandlerValidator() (validator *validators.ProviderFallbackHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewProviderFallbackHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
+		container.ProviderFallbackService(),
 	)
 }
 
@@ -542,6 +850,28 @@ func (container *Container) WebhookHandlerValidator() (validator *validators.Web
 		container.Logger(),
 		container.Tracer(),
 		container.PhoneService(),
+		container.WebhookService(),
+	)
+}
+
+// EventLogHandler creates a new instance of handlers.EventLogHandler
+func (container *Container) EventLogHandler() (h *handlers.EventLogHandler) {
+	container.logger.Debug(fmt.Sprintf("creating %T", h))
+	return handlers.NewEventLogHandler(
+		container.Logger(),
+		container.Tracer(),
+		container.EventLogService(),
+		container.Drainer(),
+		container.EventLogHandlerValidator(),
+	)
+}
+
+// EventLogHandlerValidator creates a new instance of validators.EventLogHandlerValidator
+func (container *Container) EventLogHandlerValidator() (validator *validators.EventLogHandlerValidator) {
+	container.logger.Debug(fmt.Sprintf("creating %T", validator))
+	return validators.NewEventLogHandlerValidator(
+		container.Logger(),
+		container.Tracer(),
 	)
 }
 
@@ -596,6 +926,7 @@ func (container *Container) EventDispatcher() (dispatcher *services.EventDispatc
 		container.Float64Histogram("event.publisher.duration", "ms", "measures the duration of processing CloudEvents"),
 		container.EventsQueue(),
 		container.EventsQueueConfiguration(),
+		container.Drainer(),
 	)
 
 	container.eventDispatcher = dispatcher
@@ -616,6 +947,28 @@ func (container *Container) Float64Histogram(name, unit, description string) ote
 	return histogram
 }
 
+// Int64ObservableGauge registers a new metric.Int64ObservableGauge which reports the value when the metrics are collected
+func (container *Container) Int64ObservableGauge(name, unit, description string, value func() int64) {
+	container.logger.Debug(fmt.Sprintf("creating metric.Int64ObservableGauge [%s]", name))
+	meter := otel.GetMeterProvider().Meter(
+		container.projectID,
+		otelMetric.WithInstrumentationVersion(otel.Version()),
+	)
+
+	_, err := meter.Int64ObservableGauge(
+		name,
+		otelMetric.WithUnit(unit),
+		otelMetric.WithDescription(description),
+		otelMetric.WithInt64Callback(func(_ context.Context, observer otelMetric.Int64Observer) error {
+			observer.Observe(value())
+			return nil
+		}),
+	)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, fmt.Sprintf("cannot create int64 observable gauge [%s]", name)))
+	}
+}
+
 // MessageRepository creates a new instance of repositories.MessageRepository
 func (container *Container) MessageRepository() (repository repositories.MessageRepository) {
 	container.logger.Debug("creating GORM repositories.MessageRepository")
@@ -676,6 +1029,86 @@ func (container *Container) WebhookRepository() (repository repositories.Webhook
 	)
 }
 
+// ContentFilterRepository creates a new instance of repositories.ContentFilterRepository
+func (container *Container) ContentFilterRepository() (repository repositories.ContentFilterRepository) {
+	container.logger.Debug("creating GORM repositories.ContentFilterRepository")
+	return repositories.NewGormContentFilterRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// MessageRuleRepository creates a new instance of repositories.MessageRuleRepository
+func (container *Container) MessageRuleRepository() (repository repositories.MessageRuleRepository) {
+	container.logger.Debug("creating GORM repositories.MessageRuleRepository")
+	return repositories.NewGormMessageRuleRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// ProviderFallbackRepository creates a new instance of repositories.ProviderFallbackRepository
+func (container *Container) ProviderFallbackRepository() (repository repositories.ProviderFallbackRepository) {
+	container.logger.Debug("creating GORM repositories.ProviderFallbackRepository")
+	return repositories.NewGormProviderFallbackRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// LabelRepository creates a new instance of repositories.LabelRepository
+func (container *Container) LabelRepository() (repository repositories.LabelRepository) {
+	container.logger.Debug("creating GORM repositories.LabelRepository")
+	return repositories.NewGormLabelRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// FcmCredentialRepository creates a new instance of repositories.FcmCredentialRepository
+func (container *Container) FcmCredentialRepository() (repository repositories.FcmCredentialRepository) {
+	container.logger.Debug("creating GORM repositories.FcmCredentialRepository")
+	return repositories.NewGormFcmCredentialRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneProvisioningCodeRepository creates a new instance of repositories.PhoneProvisioningCodeRepository
+func (container *Container) PhoneProvisioningCodeRepository() (repository repositories.PhoneProvisioningCodeRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneProvisioningCodeRepository")
+	return repositories.NewGormPhoneProvisioningCodeRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// PhoneGroupRepository creates a new instance of repositories.PhoneGroupRepository
+func (container *Container) PhoneGroupRepository() (repository repositories.PhoneGroupRepository) {
+	container.logger.Debug("creating GORM repositories.PhoneGroupRepository")
+	return repositories.NewGormPhoneGroupRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// CountryRouteRepository creates a new instance of repositories.CountryRouteRepository
+func (container *Container) CountryRouteRepository() (repository repositories.CountryRouteRepository) {
+	container.logger.Debug("creating GORM repositories.CountryRouteRepository")
+	return repositories.NewGormCountryRouteRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
 // PhoneNotificationRepository creates a new instance of repositories.PhoneNotificationRepository
 func (container *Container) PhoneNotificationRepository() (repository repositories.PhoneNotificationRepository) {
 	container.logger.Debug("creating GORM repositories.PhoneNotificationRepository")
@@ -734,6 +1167,7 @@ func (container *Container) HeartbeatService() (service *services.HeartbeatServi
 		container.Tracer(),
 		container.HeartbeatRepository(),
 		container.HeartbeatMonitorRepository(),
+		container.PhoneRepository(),
 		container.EventDispatcher(),
 	)
 }
@@ -761,6 +1195,47 @@ func (container *Container) DiscordService() (service *services.DiscordService)
 		container.DiscordClient(),
 		container.DiscordRepository(),
 		container.EventDispatcher(),
+		container.PhoneRepository(),
+		container.Drainer(),
+	)
+}
+
+// EventLogService creates a new instance of services.EventLogService
+func (container *Container) EventLogService() (service *services.EventLogService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewEventLogService(
+		container.Logger(),
+		container.Tracer(),
+		container.Drainer(),
+		container.EventLogRepository(),
+		container.UserEventBroker(),
+	)
+}
+
+// CallEventService creates a new instance of services.CallEventService
+func (container *Container) CallEventService() (service *services.CallEventService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewCallEventService(
+		container.Logger(),
+		container.Tracer(),
+		container.CallEventRepository(),
+		container.PhoneRepository(),
+		container.EventDispatcher(),
+	)
+}
+
+// UssdService creates a new instance of services.UssdService
+func (container *Container) UssdService() (service *services.UssdService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewUssdService(
+		container.Logger(),
+		container.Tracer(),
+		container.UssdSessionRepository(),
+		container.PhoneRepository(),
+		container.FirebaseMessagingClient(),
+		container.Drainer(),
+		container.FcmSigner(),
+		container.FcmCredentialService(),
 	)
 }
 
@@ -770,30 +1245,301 @@ func (container *Container) WebhookService() (service *services.WebhookService)
 	return services.NewWebhookService(
 		container.Logger(),
 		container.Tracer(),
-		container.HTTPClient("webhook"),
+		container.EgressHTTPClient("webhook"),
 		container.WebhookRepository(),
 		container.EventDispatcher(),
+		container.WebhookBatcher(),
+		container.Drainer(),
+		container.WebhookMaxConsecutiveFailures(),
+		container.EventLogRepository(),
+		container.WebhookReplayRepository(),
+		container.WebhookReplayMaxEvents(),
+		container.WebhookEgressIPs(),
+		container.WebhookHeadersCipher(),
+		container.WebhookDeliveryStatRepository(),
+		container.WebhookDeliveryPool(),
 	)
 }
 
-// Integration3CXService creates a new instance of services.Integration3CXService
-func (container *Container) Integration3CXService() (service *services.Integration3CXService) {
+// ContentFilterService creates a new instance of services.ContentFilterService
+func (container *Container) ContentFilterService() (service *services.ContentFilterService) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
-	return services.NewIntegration3CXService(
+	return services.NewContentFilterService(
 		container.Logger(),
 		container.Tracer(),
-		container.HTTPClient("integration_3cx"),
-		container.Integration3CXRepository(),
+		container.ContentFilterRepository(),
 	)
 }
 
-// HTTPClient creates a new http.Client
-func (container *Container) HTTPClient(name string) *http.Client {
-	container.logger.Debug(fmt.Sprintf("creating %s %T", name, http.DefaultClient))
-	return &http.Client{
-		Timeout:   60 * time.Second,
-		Transport: container.HTTPRoundTripper(name),
-	}
+// MessageRuleService creates a new instance of services.MessageRuleService
+func (container *Container) MessageRuleService() (service *services.MessageRuleService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewMessageRuleService(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageRuleRepository(),
+		container.PhoneService(),
+		container.MessageService(),
+		container.WebhookService(),
+		container.LabelService(),
+	)
+}
+
+// ProviderFallbackService creates a new instance of services.ProviderFallbackService
+func (container *Container) ProviderFallbackService() (service *services.ProviderFallbackService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewProviderFallbackService(
+		container.Logger(),
+		container.Tracer(),
+		container.ProviderFallbackRepository(),
+		container.MessageRepository(),
+		container.PhoneGroupRepository(),
+		container.EventDispatcher(),
+		[]services.SmsProvider{
+			services.NewTwilioSmsProvider(container.HTTPClient("twilio")),
+			services.NewVonageSmsProvider(container.HTTPClient("vonage")),
+		},
+		container.ProviderFallbackCipher(),
+		container.UserService(),
+	)
+}
+
+// ProviderFallbackCipher creates the services.Cipher which encrypts the auth tokens of the SMS providers, it is nil when PROVIDER_FALLBACK_ENCRYPTION_KEY is empty
+func (container *Container) ProviderFallbackCipher() *services.Cipher {
+	key := strings.TrimSpace(os.Getenv("PROVIDER_FALLBACK_ENCRYPTION_KEY"))
+	if key == "" {
+		return nil
+	}
+
+	cipher, err := services.NewCipher(key)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create the cipher for PROVIDER_FALLBACK_ENCRYPTION_KEY"))
+	}
+	return cipher
+}
+
+// BulkMessageJobService creates a new instance of services.BulkMessageJobService
+func (container *Container) BulkMessageJobService() (service *services.BulkMessageJobService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewBulkMessageJobService(
+		container.Logger(),
+		container.Tracer(),
+		container.BulkMessageJobRepository(),
+		container.MessageService(),
+		container.EventDispatcher(),
+	)
+}
+
+// LabelService creates a new instance of services.LabelService
+func (container *Container) LabelService() (service *services.LabelService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewLabelService(
+		container.Logger(),
+		container.Tracer(),
+		container.LabelRepository(),
+		container.MessageRepository(),
+	)
+}
+
+// FcmCredentialService creates a cached instance of services.FcmCredentialService which keeps the firebase messaging clients of the users
+func (container *Container) FcmCredentialService() (service *services.FcmCredentialService) {
+	if container.fcmCredentials != nil {
+		return container.fcmCredentials
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	container.fcmCredentials = services.NewFcmCredentialService(
+		container.Logger(),
+		container.Tracer(),
+		container.FcmCredentialRepository(),
+		container.FcmCredentialCipher(),
+	)
+	return container.fcmCredentials
+}
+
+// FcmCredentialCipher creates the services.Cipher which encrypts the service accounts of the firebase projects of the users, it is nil when FCM_CREDENTIALS_ENCRYPTION_KEY is empty
+func (container *Container) FcmCredentialCipher() *services.Cipher {
+	key := strings.TrimSpace(os.Getenv("FCM_CREDENTIALS_ENCRYPTION_KEY"))
+	if key == "" {
+		return nil
+	}
+
+	cipher, err := services.NewCipher(key)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create the cipher for FCM_CREDENTIALS_ENCRYPTION_KEY"))
+	}
+	return cipher
+}
+
+// WebhookHeadersCipher creates the services.Cipher which encrypts the custom headers of a webhook, it is nil when WEBHOOK_HEADERS_ENCRYPTION_KEY is empty
+func (container *Container) WebhookHeadersCipher() *services.Cipher {
+	key := strings.TrimSpace(os.Getenv("WEBHOOK_HEADERS_ENCRYPTION_KEY"))
+	if key == "" {
+		return nil
+	}
+
+	cipher, err := services.NewCipher(key)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create the cipher for WEBHOOK_HEADERS_ENCRYPTION_KEY"))
+	}
+	return cipher
+}
+
+// WebhookMaxConsecutiveFailures is the number of consecutive failed deliveries after which a webhook is disabled
+func (container *Container) WebhookMaxConsecutiveFailures() uint {
+	failures, err := strconv.ParseUint(os.Getenv("WEBHOOK_MAX_CONSECUTIVE_FAILURES"), 10, 32)
+	if err != nil || failures == 0 {
+		return 20
+	}
+	return uint(failures)
+}
+
+// WebhookReplayMaxEvents is the maximum number of events which can be sent to a webhook in a single replay
+func (container *Container) WebhookReplayMaxEvents() uint {
+	maxEvents, err := strconv.ParseUint(os.Getenv("WEBHOOK_REPLAY_MAX_EVENTS"), 10, 32)
+	if err != nil || maxEvents == 0 {
+		return 1000
+	}
+	return uint(maxEvents)
+}
+
+// HealthService creates a new instance of services.HealthService
+func (container *Container) HealthService() (service *services.HealthService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewHealthService(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+		container.DedicatedDB(),
+		container.FirebaseMessagingClient(),
+	)
+}
+
+// WebhookBatcher creates a cached instance of services.WebhookBatcher
+func (container *Container) WebhookBatcher() (batcher *services.WebhookBatcher) {
+	if container.webhookBatcher != nil {
+		return container.webhookBatcher
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", batcher))
+	container.webhookBatcher = services.NewWebhookBatcher(
+		container.Logger(),
+		container.Tracer(),
+		container.Drainer(),
+	)
+	return container.webhookBatcher
+}
+
+// WebhookDeliveryPool creates a cached instance of services.WebhookDeliveryPool, the in-flight and queued deliveries are exported as gauges
+func (container *Container) WebhookDeliveryPool() (pool *services.WebhookDeliveryPool) {
+	if container.webhookPool != nil {
+		return container.webhookPool
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", pool))
+	container.webhookPool = services.NewWebhookDeliveryPool(
+		container.Logger(),
+		container.WebhookMaxConcurrentDeliveries(),
+		container.WebhookMaxConcurrentDeliveriesPerWebhook(),
+	)
+
+	container.Int64ObservableGauge("webhook.deliveries.in_flight", "{delivery}", "number of webhook deliveries which are being sent", func() int64 {
+		return int64(container.webhookPool.InFlight())
+	})
+	container.Int64ObservableGauge("webhook.deliveries.queued", "{delivery}", "number of webhook deliveries which are waiting for a worker", func() int64 {
+		return int64(container.webhookPool.Queued())
+	})
+	return container.webhookPool
+}
+
+// WebhookMaxConcurrentDeliveries is the maximum number of webhook deliveries which are sent at the same time by an instance of the API
+func (container *Container) WebhookMaxConcurrentDeliveries() uint {
+	deliveries, err := strconv.ParseUint(os.Getenv("WEBHOOK_MAX_CONCURRENT_DELIVERIES"), 10, 32)
+	if err != nil || deliveries == 0 {
+		return 100
+	}
+	return uint(deliveries)
+}
+
+// WebhookMaxConcurrentDeliveriesPerWebhook is the maximum number of deliveries which are sent to the same webhook at the same time, there is no limit when it is 0
+func (container *Container) WebhookMaxConcurrentDeliveriesPerWebhook() uint {
+	deliveries, err := strconv.ParseUint(os.Getenv("WEBHOOK_MAX_CONCURRENT_DELIVERIES_PER_WEBHOOK"), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint(deliveries)
+}
+
+// PhoneSemaphore creates a cached instance of services.PhoneSemaphore
+func (container *Container) PhoneSemaphore() (semaphore *services.PhoneSemaphore) {
+	if container.phoneSemaphore != nil {
+		return container.phoneSemaphore
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", semaphore))
+	container.phoneSemaphore = services.NewPhoneSemaphore(container.Logger())
+	return container.phoneSemaphore
+}
+
+// Drainer creates a cached instance of services.Drainer
+func (container *Container) Drainer() (drainer *services.Drainer) {
+	if container.drainer != nil {
+		return container.drainer
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", drainer))
+	container.drainer = services.NewDrainer(container.Logger())
+	return container.drainer
+}
+
+// ShutdownGracePeriod is the maximum duration to drain in-flight work when the server is shutting down
+func (container *Container) ShutdownGracePeriod() time.Duration {
+	gracePeriod, err := time.ParseDuration(os.Getenv("SHUTDOWN_GRACE_PERIOD"))
+	if err != nil || gracePeriod <= 0 {
+		return 10 * time.Second
+	}
+	return gracePeriod
+}
+
+// Shutdown stops accepting new requests, closes the open websockets and drains in-flight FCM pushes and webhook deliveries until the grace period elapses
+func (container *Container) Shutdown(gracePeriod time.Duration) {
+	container.logger.Info(fmt.Sprintf("shutting down with a grace period of [%s]", gracePeriod))
+
+	ctx, cancel := context.WithTimeout(context.Background(), gracePeriod)
+	defer cancel()
+
+	if err := container.App().ShutdownWithContext(ctx); err != nil {
+		container.logger.Error(stacktrace.Propagate(err, "cannot shutdown the fiber app gracefully"))
+	}
+
+	container.WebhookBatcher().Flush(ctx)
+
+	drained, abandoned := container.Drainer().Drain(ctx)
+	if abandoned > 0 {
+		container.logger.Warn(stacktrace.NewError(fmt.Sprintf("drained [%d] in-flight items and abandoned [%d] items after [%s]", drained, abandoned, gracePeriod)))
+		return
+	}
+	container.logger.Info(fmt.Sprintf("drained [%d] in-flight items and abandoned [%d] items", drained, abandoned))
+}
+
+// Integration3CXService creates a new instance of services.Integration3CXService
+func (container *Container) Integration3CXService() (service *services.Integration3CXService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewIntegration3CXService(
+		container.Logger(),
+		container.Tracer(),
+		container.EgressHTTPClient("integration_3cx"),
+		container.Integration3CXRepository(),
+	)
+}
+
+// HTTPClient creates a new http.Client
+func (container *Container) HTTPClient(name string) *http.Client {
+	container.logger.Debug(fmt.Sprintf("creating %s %T", name, http.DefaultClient))
+	return &http.Client{
+		Timeout:   60 * time.Second,
+		Transport: container.HTTPRoundTripper(name),
+	}
 }
 
 // HTTPRoundTripper creates an open telemetry http.RoundTripper
@@ -818,6 +1564,69 @@ func (container *Container) OtelResources(version string, namespace string) *res
 	)
 }
 
+// EgressHTTPClient creates an http.Client for requests to URLs of users e.g. webhooks which are sent through the configured egress
+func (container *Container) EgressHTTPClient(name string) *http.Client {
+	container.logger.Debug(fmt.Sprintf("creating egress %s %T", name, http.DefaultClient))
+
+	retryClient := retryablehttp.NewClient()
+	retryClient.Logger = container.Logger()
+	retryClient.HTTPClient.Transport = services.NewWebhookDeliveryIDTransport(services.NewAttemptTimeoutTransport(services.NewClientCertificateTransport(container.EgressHTTPTransport())))
+
+	return &http.Client{
+		Timeout: 60 * time.Second,
+		Transport: otelroundtripper.New(
+			otelroundtripper.WithName(name),
+			otelroundtripper.WithParent(retryClient.StandardClient().Transport),
+			otelroundtripper.WithMeter(otel.GetMeterProvider().Meter(container.projectID)),
+			otelroundtripper.WithAttributes(container.OtelResources(container.version, container.projectID).Attributes()...),
+		),
+	}
+}
+
+// EgressHTTPTransport is the http.Transport which sends requests through the WEBHOOK_EGRESS_PROXY or from the WEBHOOK_EGRESS_SOURCE_IP
+func (container *Container) EgressHTTPTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if proxy := strings.TrimSpace(os.Getenv("WEBHOOK_EGRESS_PROXY")); proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			container.logger.Fatal(stacktrace.Propagate(err, "cannot parse the WEBHOOK_EGRESS_PROXY environment variable"))
+		}
+		container.logger.Info(fmt.Sprintf("sending egress requests through the proxy [%s]", proxyURL.Redacted()))
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if sourceIP := strings.TrimSpace(os.Getenv("WEBHOOK_EGRESS_SOURCE_IP")); sourceIP != "" {
+		ip := net.ParseIP(sourceIP)
+		if ip == nil {
+			container.logger.Fatal(stacktrace.NewError(fmt.Sprintf("the WEBHOOK_EGRESS_SOURCE_IP [%s] is not a valid IP address", sourceIP)))
+		}
+		container.logger.Info(fmt.Sprintf("sending egress requests from the source IP [%s]", ip))
+		transport.DialContext = (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+			LocalAddr: &net.TCPAddr{IP: ip},
+		}).DialContext
+	}
+
+	return transport
+}
+
+// WebhookEgressIPs are the IP addresses which webhook requests come from so that users can add them to the allowlist of their firewall
+func (container *Container) WebhookEgressIPs() []string {
+	ips := make([]string, 0)
+	for _, ip := range strings.Split(os.Getenv("WEBHOOK_EGRESS_IPS"), ",") {
+		if ip = strings.TrimSpace(ip); ip != "" {
+			ips = append(ips, ip)
+		}
+	}
+
+	if sourceIP := strings.TrimSpace(os.Getenv("WEBHOOK_EGRESS_SOURCE_IP")); len(ips) == 0 && sourceIP != "" {
+		ips = append(ips, sourceIP)
+	}
+	return ips
+}
+
 // RetryHTTPRoundTripper creates a retryable http.RoundTripper
 func (container *Container) RetryHTTPRoundTripper() http.RoundTripper {
 	container.logger.Debug(fmt.Sprintf("initializing retry %T", http.DefaultTransport))
@@ -834,6 +1643,24 @@ func (container *Container) PhoneService() (service *services.PhoneService) {
 		container.Tracer(),
 		container.PhoneRepository(),
 		container.EventDispatcher(),
+		container.HeartbeatRepository(),
+		container.PhoneSemaphore(),
+		container.FcmSigner(),
+		container.PhoneGroupRepository(),
+		container.CountryRouteRepository(),
+		container.NotificationService(),
+	)
+}
+
+// PhoneProvisioningService creates a new instance of services.PhoneProvisioningService
+func (container *Container) PhoneProvisioningService() (service *services.PhoneProvisioningService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewPhoneProvisioningService(
+		container.Logger(),
+		container.Tracer(),
+		container.PhoneProvisioningCodeRepository(),
+		container.UserRepository(),
+		container.PhoneService(),
 	)
 }
 
@@ -861,9 +1688,21 @@ func (container *Container) UserService() (service *services.UserService) {
 		container.MarketingService(),
 		container.LemonsqueezyClient(),
 		container.EventDispatcher(),
+		container.AdminUserIDs(),
 	)
 }
 
+// AdminUserIDs are the IDs of the users in ADMIN_USER_IDS who can set the feature flags of other users
+func (container *Container) AdminUserIDs() []entities.UserID {
+	var userIDs []entities.UserID
+	for _, userID := range strings.Split(os.Getenv("ADMIN_USER_IDS"), ",") {
+		if userID = strings.TrimSpace(userID); userID != "" {
+			userIDs = append(userIDs, entities.UserID(userID))
+		}
+	}
+	return userIDs
+}
+
 // Mailer creates a new instance of emails.Mailer
 func (container *Container) Mailer() (mailer emails.Mailer) {
 	container.logger.Debug("creating emails.Mailer")
@@ -908,6 +1747,8 @@ func (container *Container) MessageThreadService() (service *services.MessageThr
 		container.Tracer(),
 		container.MessageThreadRepository(),
 		container.EventDispatcher(),
+		container.MessageRepository(),
+		container.MessageArchiveService(),
 	)
 }
 
@@ -945,6 +1786,7 @@ func (container *Container) BulkMessageHandler() (handler *handlers.BulkMessageH
 		container.BulkMessageHandlerValidator(),
 		container.BillingService(),
 		container.MessageService(),
+		container.BulkMessageJobService(),
 	)
 }
 
@@ -1091,7 +1933,19 @@ func (container *Container) RegisterIntegration3CXRoutes() {
 // RegisterDiscordRoutes registers routes for the /discord prefix
 func (container *Container) RegisterDiscordRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.DiscordHandler{}))
-	container.DiscordHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+	container.DiscordHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware(), container.DiscordEventRateLimitMiddleware())
+}
+
+// DiscordEventRateLimitMiddleware limits the requests of each IP to the public discord event route to DISCORD_EVENT_RATE_LIMIT requests per minute
+func (container *Container) DiscordEventRateLimitMiddleware() fiber.Handler {
+	container.logger.Debug("creating middlewares.IPRateLimit for discord events")
+
+	limit, err := strconv.Atoi(os.Getenv("DISCORD_EVENT_RATE_LIMIT"))
+	if err != nil || limit <= 0 {
+		// all the interactions are sent from the servers of discord so the default limit is high
+		limit = 300
+	}
+	return middlewares.IPRateLimit(container.Logger(), container.Tracer(), limit, time.Minute)
 }
 
 // RegisterMessageThreadListeners registers event listeners for listeners.MessageThreadListener
@@ -1206,6 +2060,34 @@ func (container *Container) RegisterIntegration3CXListeners() {
 	}
 }
 
+// RegisterProviderFallbackListeners registers event listeners for listeners.ProviderFallbackListener
+func (container *Container) RegisterProviderFallbackListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.ProviderFallbackListener{}))
+	_, routes := listeners.NewProviderFallbackListener(
+		container.Logger(),
+		container.Tracer(),
+		container.ProviderFallbackService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RegisterMessageRuleListeners registers event listeners for listeners.MessageRuleListener
+func (container *Container) RegisterMessageRuleListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.MessageRuleListener{}))
+	_, routes := listeners.NewMessageRuleListener(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageRuleService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
 // RegisterWebhookListeners registers event listeners for listeners.WebhookListener
 func (container *Container) RegisterWebhookListeners() {
 	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.WebhookListener{}))
@@ -1220,6 +2102,34 @@ func (container *Container) RegisterWebhookListeners() {
 	}
 }
 
+// RegisterBulkMessageJobListeners registers event listeners for listeners.BulkMessageJobListener
+func (container *Container) RegisterBulkMessageJobListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.BulkMessageJobListener{}))
+	_, routes := listeners.NewBulkMessageJobListener(
+		container.Logger(),
+		container.Tracer(),
+		container.BulkMessageJobService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
+// RegisterEventLogListeners registers event listeners for listeners.EventLogListener
+func (container *Container) RegisterEventLogListeners() {
+	container.logger.Debug(fmt.Sprintf("registering listeners for %T", listeners.EventLogListener{}))
+	_, routes := listeners.NewEventLogListener(
+		container.Logger(),
+		container.Tracer(),
+		container.EventLogService(),
+	)
+
+	for event, handler := range routes {
+		container.EventDispatcher().Subscribe(event, handler)
+	}
+}
+
 // MessageService creates a new instance of services.MessageService
 func (container *Container) MessageService() (service *services.MessageService) {
 	container.logger.Debug(fmt.Sprintf("creating %T", service))
@@ -1229,6 +2139,132 @@ func (container *Container) MessageService() (service *services.MessageService)
 		container.MessageRepository(),
 		container.EventDispatcher(),
 		container.PhoneService(),
+		container.UserRepository(),
+		container.MessageClassifier(),
+		container.MediaStorage(),
+		container.UserEventBroker(),
+		container.MessagePollTimeout(),
+		container.ContentFilterService(),
+		container.ReplyTokenSigner(),
+		container.PhoneNotificationRepository(),
+		container.MessageArchiveService(),
+	)
+}
+
+// MessageArchiveService creates a new instance of services.MessageArchiveService
+func (container *Container) MessageArchiveService() (service *services.MessageArchiveService) {
+	container.logger.Debug(fmt.Sprintf("creating %T", service))
+	return services.NewMessageArchiveService(
+		container.Logger(),
+		container.Tracer(),
+		container.MessageRepository(),
+		container.MessageArchiveStorage(),
+		container.EventLogRepository(),
+		container.MessageArchiveAfter(),
+	)
+}
+
+// MessageArchiveAfter is the age of the messages which are moved to cold storage, messages are not archived when MESSAGE_ARCHIVE_AFTER_DAYS is not set
+func (container *Container) MessageArchiveAfter() time.Duration {
+	days, err := strconv.Atoi(strings.TrimSpace(os.Getenv("MESSAGE_ARCHIVE_AFTER_DAYS")))
+	if err != nil || days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// ReplyTokenSigner signs the reply tokens of received messages, it is nil when REPLY_TOKEN_SIGNING_KEY is not set
+func (container *Container) ReplyTokenSigner() *services.ReplyTokenSigner {
+	key := strings.TrimSpace(os.Getenv("REPLY_TOKEN_SIGNING_KEY"))
+	if key == "" {
+		return nil
+	}
+
+	signer, err := services.NewReplyTokenSigner(key)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create the signer for REPLY_TOKEN_SIGNING_KEY"))
+	}
+	return signer
+}
+
+// UserEventBroker creates a cached instance of services.UserEventBroker
+func (container *Container) UserEventBroker() (broker *services.UserEventBroker) {
+	if container.userEventBroker != nil {
+		return container.userEventBroker
+	}
+
+	container.logger.Debug(fmt.Sprintf("creating %T", broker))
+	container.userEventBroker = services.NewUserEventBroker(container.Logger())
+	return container.userEventBroker
+}
+
+// MessagePollTimeout is the maximum duration a long-poll request for new messages is held open
+func (container *Container) MessagePollTimeout() time.Duration {
+	timeout, err := time.ParseDuration(os.Getenv("MESSAGE_POLL_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return 30 * time.Second
+	}
+	return timeout
+}
+
+// MediaStorage creates a new instance of services.MediaStorage
+func (container *Container) MediaStorage() (storage services.MediaStorage) {
+	container.logger.Debug("creating services.MediaStorage")
+	return container.storage(os.Getenv("MEDIA_STORAGE_TYPE"), os.Getenv("MEDIA_STORAGE_PATH"), os.Getenv("MEDIA_STORAGE_BUCKET"))
+}
+
+// MessageArchiveStorage creates the services.MediaStorage which is the cold storage of archived messages
+func (container *Container) MessageArchiveStorage() (storage services.MediaStorage) {
+	container.logger.Debug("creating archive services.MediaStorage")
+	return container.storage(os.Getenv("MESSAGE_ARCHIVE_STORAGE_TYPE"), os.Getenv("MESSAGE_ARCHIVE_STORAGE_PATH"), os.Getenv("MESSAGE_ARCHIVE_STORAGE_BUCKET"))
+}
+
+// storage creates a services.MediaStorage which saves files in the local path, an S3 bucket or a google cloud storage bucket when the storage type is empty
+func (container *Container) storage(storageType string, path string, bucket string) services.MediaStorage {
+	switch storageType {
+	case "local":
+		return services.NewLocalMediaStorage(
+			container.Logger(),
+			container.Tracer(),
+			path,
+		)
+	case "s3":
+		return services.NewS3MediaStorage(
+			container.Logger(),
+			container.Tracer(),
+			container.HTTPClient("s3"),
+			os.Getenv("S3_ENDPOINT"),
+			os.Getenv("S3_REGION"),
+			bucket,
+			os.Getenv("S3_ACCESS_KEY_ID"),
+			os.Getenv("S3_SECRET_ACCESS_KEY"),
+		)
+	default:
+		return services.NewGoogleCloudMediaStorage(
+			container.Logger(),
+			container.Tracer(),
+			container.CloudStorageClient().Bucket(bucket),
+		)
+	}
+}
+
+// CloudStorageClient creates a new instance of storage.Client
+func (container *Container) CloudStorageClient() (client *storage.Client) {
+	container.logger.Debug(fmt.Sprintf("creating %T", client))
+	client, err := storage.NewClient(context.Background(), option.WithCredentialsJSON(container.FirebaseCredentials()))
+	if err != nil {
+		msg := "cannot initialize google cloud storage client"
+		container.logger.Fatal(stacktrace.Propagate(err, msg))
+	}
+	return client
+}
+
+// MessageClassifier creates a new instance of services.MessageClassifier
+func (container *Container) MessageClassifier() (classifier *services.MessageClassifier) {
+	container.logger.Debug(fmt.Sprintf("creating %T", classifier))
+	return services.NewMessageClassifier(
+		container.Logger(),
+		container.Tracer(),
 	)
 }
 
@@ -1241,14 +2277,58 @@ func (container *Container) NotificationService() (service *services.PhoneNotifi
 		container.FirebaseMessagingClient(),
 		container.PhoneRepository(),
 		container.PhoneNotificationRepository(),
+		container.MessageRepository(),
 		container.EventDispatcher(),
+		container.Drainer(),
+		container.PhoneSemaphore(),
+		container.FcmSigner(),
+		container.EgressHTTPClient("virtual_phone"),
+		container.FcmCredentialService(),
 	)
 }
 
+// FcmSigner signs the push notifications sent to phones, it is nil when FCM_SIGNING_KEY is not set
+func (container *Container) FcmSigner() *services.FcmSigner {
+	key := strings.TrimSpace(os.Getenv("FCM_SIGNING_KEY"))
+	if key == "" {
+		return nil
+	}
+
+	signer, err := services.NewFcmSigner(key)
+	if err != nil {
+		container.logger.Fatal(stacktrace.Propagate(err, "cannot create the signer for FCM_SIGNING_KEY"))
+	}
+	return signer
+}
+
+// RegisterHealthRoutes registers the /healthz and /readyz routes
+func (container *Container) RegisterHealthRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.HealthHandler{}))
+	container.HealthHandler().RegisterRoutes(container.App())
+}
+
 // RegisterMessageRoutes registers routes for the /messages prefix
 func (container *Container) RegisterMessageRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.MessageHandler{}))
-	container.MessageHandler().RegisterRoutes(container.AuthRouter())
+	handler := container.MessageHandler()
+
+	// the reply route is registered before the authenticated /v1 routes because the reply token authenticates the request
+	handler.RegisterReplyRoutes(container.App(), container.MessageReplyRateLimitMiddleware())
+	// the GET send route is also registered before the authenticated /v1 routes because the API key is in the key query parameter
+	handler.RegisterQueryKeyRoutes(container.App(), container.QueryAPIKeyMiddleware(), container.AuthenticatedMiddleware())
+	handler.RegisterRoutes(container.AuthRouter())
+}
+
+// MessageReplyRateLimitMiddleware limits the requests of each IP to the public reply route to MESSAGE_REPLY_RATE_LIMIT requests per minute
+func (container *Container) MessageReplyRateLimitMiddleware() fiber.Handler {
+	container.logger.Debug("creating middlewares.IPRateLimit for message replies")
+
+	limit, err := strconv.Atoi(os.Getenv("MESSAGE_REPLY_RATE_LIMIT"))
+	if err != nil || limit <= 0 {
+		// a bot replies once to each message it receives so the default limit only needs to cover bursts of received messages
+		limit = 60
+	}
+	return middlewares.IPRateLimit(container.Logger(), container.Tracer(), limit, time.Minute)
 }
 
 // RegisterBulkMessageRoutes registers routes for the /bulk-messages prefix
@@ -1269,6 +2349,18 @@ func (container *Container) RegisterHeartbeatRoutes() {
 	container.HeartbeatHandler().RegisterRoutes(container.AuthRouter())
 }
 
+// RegisterCallEventRoutes registers routes for the /phones/:phoneID/call-events prefix
+func (container *Container) RegisterCallEventRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.CallEventHandler{}))
+	container.CallEventHandler().RegisterRoutes(container.AuthRouter())
+}
+
+// RegisterUssdRoutes registers routes for the /phones/:phoneID/ussd prefix
+func (container *Container) RegisterUssdRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.UssdHandler{}))
+	container.UssdHandler().RegisterRoutes(container.AuthRouter())
+}
+
 // RegisterBillingRoutes registers routes for the /billing prefix
 func (container *Container) RegisterBillingRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.BillingHandler{}))
@@ -1281,6 +2373,54 @@ func (container *Container) RegisterWebhookRoutes() {
 	container.WebhookHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
 }
 
+// RegisterContentFilterRoutes registers routes for the /content-filters prefix
+func (container *Container) RegisterContentFilterRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ContentFilterHandler{}))
+	container.ContentFilterHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterMessageRuleRoutes registers routes for the /message-rules prefix
+func (container *Container) RegisterMessageRuleRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.MessageRuleHandler{}))
+	container.MessageRuleHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterProviderFallbackRoutes registers routes for the /provider-fallback prefix
+func (container *Container) RegisterProviderFallbackRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.ProviderFallbackHandler{}))
+	container.ProviderFallbackHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterLabelRoutes registers routes for the /labels prefix and the labels of messages
+func (container *Container) RegisterLabelRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.LabelHandler{}))
+	container.LabelHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
+// RegisterPhoneProvisioningRoutes registers routes for the /phones/provisioning-codes prefix
+func (container *Container) RegisterPhoneProvisioningRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.PhoneProvisioningHandler{}))
+	container.PhoneProvisioningHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware(), container.PhoneProvisioningRateLimitMiddleware())
+}
+
+// PhoneProvisioningRateLimitMiddleware limits the requests of each IP to the public provisioning code exchange route to PHONE_PROVISIONING_RATE_LIMIT requests per minute
+func (container *Container) PhoneProvisioningRateLimitMiddleware() fiber.Handler {
+	container.logger.Debug("creating middlewares.IPRateLimit for provisioning code exchanges")
+
+	limit, err := strconv.Atoi(os.Getenv("PHONE_PROVISIONING_RATE_LIMIT"))
+	if err != nil || limit <= 0 {
+		// a phone only exchanges a code once so the default limit is low to make guessing codes impractical
+		limit = 10
+	}
+	return middlewares.IPRateLimit(container.Logger(), container.Tracer(), limit, time.Minute)
+}
+
+// RegisterFcmCredentialRoutes registers routes for the /fcm-credential prefix
+func (container *Container) RegisterFcmCredentialRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.FcmCredentialHandler{}))
+	container.FcmCredentialHandler().RegisterRoutes(container.App(), container.AuthenticatedMiddleware())
+}
+
 // RegisterPhoneRoutes registers routes for the /phone prefix
 func (container *Container) RegisterPhoneRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.PhoneHandler{}))
@@ -1299,6 +2439,12 @@ func (container *Container) RegisterEventRoutes() {
 	container.EventsHandler().RegisterRoutes(container.AuthRouter())
 }
 
+// RegisterEventLogRoutes registers routes for the /events audit trail
+func (container *Container) RegisterEventLogRoutes() {
+	container.logger.Debug(fmt.Sprintf("registering %T routes", &handlers.EventLogHandler{}))
+	container.EventLogHandler().RegisterRoutes(container.AuthRouter())
+}
+
 // RegisterSwaggerRoutes registers routes for swagger
 func (container *Container) RegisterSwaggerRoutes() {
 	container.logger.Debug(fmt.Sprintf("registering %T routes", swagger.HandlerDefault))
@@ -1315,6 +2461,66 @@ func (container *Container) HeartbeatRepository() repositories.HeartbeatReposito
 	)
 }
 
+// CallEventRepository registers a new instance of repositories.CallEventRepository
+func (container *Container) CallEventRepository() repositories.CallEventRepository {
+	container.logger.Debug("creating GORM repositories.CallEventRepository")
+	return repositories.NewGormCallEventRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// UssdSessionRepository registers a new instance of repositories.UssdSessionRepository
+func (container *Container) UssdSessionRepository() repositories.UssdSessionRepository {
+	container.logger.Debug("creating GORM repositories.UssdSessionRepository")
+	return repositories.NewGormUssdSessionRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// EventLogRepository registers a new instance of repositories.EventLogRepository
+func (container *Container) EventLogRepository() repositories.EventLogRepository {
+	container.logger.Debug("creating GORM repositories.EventLogRepository")
+	return repositories.NewGormEventLogRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// BulkMessageJobRepository registers a new instance of repositories.BulkMessageJobRepository
+func (container *Container) BulkMessageJobRepository() repositories.BulkMessageJobRepository {
+	container.logger.Debug("creating GORM repositories.BulkMessageJobRepository")
+	return repositories.NewGormBulkMessageJobRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// WebhookReplayRepository registers a new instance of repositories.WebhookReplayRepository
+func (container *Container) WebhookReplayRepository() repositories.WebhookReplayRepository {
+	container.logger.Debug("creating GORM repositories.WebhookReplayRepository")
+	return repositories.NewGormWebhookReplayRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
+// WebhookDeliveryStatRepository registers a new instance of repositories.WebhookDeliveryStatRepository
+func (container *Container) WebhookDeliveryStatRepository() repositories.WebhookDeliveryStatRepository {
+	container.logger.Debug("creating GORM repositories.WebhookDeliveryStatRepository")
+	return repositories.NewGormWebhookDeliveryStatRepository(
+		container.Logger(),
+		container.Tracer(),
+		container.DB(),
+	)
+}
+
 // UserRepository registers a new instance of repositories.UserRepository
 func (container *Container) UserRepository() repositories.UserRepository {
 	container.logger.Debug("creating GORM repositories.UserRepository")
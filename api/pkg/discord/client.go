@@ -25,6 +25,7 @@ type Client struct {
 	Channel     *ChannelService
 	Guild       *GuildService
 	Application *ApplicationService
+	Interaction *InteractionService
 }
 
 // New creates and returns a new campay.Client from a slice of campay.ClientOption.
@@ -47,6 +48,7 @@ func New(options ...Option) *Client {
 	client.Channel = (*ChannelService)(&client.common)
 	client.Application = (*ApplicationService)(&client.common)
 	client.Guild = (*GuildService)(&client.common)
+	client.Interaction = (*InteractionService)(&client.common)
 
 	return client
 }
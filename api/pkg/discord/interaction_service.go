@@ -0,0 +1,35 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// InteractionService is the API client for responding to interactions
+type InteractionService service
+
+// EditOriginalResponse edits the response of an interaction e.g. after a deferred response.
+// The interaction token is valid for 15 minutes after the interaction is received.
+//
+// API Docs: https://discord.com/developers/docs/interactions/receiving-and-responding#edit-original-interaction-response
+func (service *InteractionService) EditOriginalResponse(ctx context.Context, interactionToken string, payload map[string]any) (map[string]any, *Response, error) {
+	url := fmt.Sprintf("/webhooks/%s/%s/messages/@original", service.client.applicationID, interactionToken)
+	request, err := service.client.newRequest(ctx, http.MethodPatch, url, payload)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	response, err := service.client.do(request)
+	if err != nil {
+		return nil, response, err
+	}
+
+	message := map[string]any{}
+	if err = json.Unmarshal(*response.Body, &message); err != nil {
+		return nil, response, err
+	}
+
+	return message, response, nil
+}
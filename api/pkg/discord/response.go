@@ -2,9 +2,11 @@ package discord
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"net/http"
 	"strconv"
+	"time"
 )
 
 // Response captures the http response
@@ -33,3 +35,27 @@ func (r *Response) errorMessage() string {
 
 	return buf.String()
 }
+
+// IsRateLimited checks if the request was rate limited by discord
+func (r *Response) IsRateLimited() bool {
+	return r.HTTPResponse != nil && r.HTTPResponse.StatusCode == http.StatusTooManyRequests
+}
+
+// RetryAfter returns the duration to wait before retrying a rate limited request
+//
+// API Docs: https://discord.com/developers/docs/topics/rate-limits#exceeding-a-rate-limit
+func (r *Response) RetryAfter() time.Duration {
+	payload := struct {
+		RetryAfter float64 `json:"retry_after"`
+	}{}
+
+	if r.Body != nil && json.Unmarshal(*r.Body, &payload) == nil && payload.RetryAfter > 0 {
+		return time.Duration(payload.RetryAfter * float64(time.Second))
+	}
+
+	if seconds, err := strconv.ParseFloat(r.HTTPResponse.Header.Get("Retry-After"), 64); err == nil && seconds > 0 {
+		return time.Duration(seconds * float64(time.Second))
+	}
+
+	return time.Second
+}
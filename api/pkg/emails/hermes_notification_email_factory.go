@@ -127,6 +127,56 @@ func (factory *hermesNotificationEmailFactory) WebhookSendFailed(user *entities.
 	}, nil
 }
 
+func (factory *hermesNotificationEmailFactory) WebhookDisabled(user *entities.User, payload *events.WebhookDisabledPayload) (*Email, error) {
+	email := hermes.Email{
+		Body: hermes.Body{
+			Title: "Hello",
+			Intros: []string{
+				fmt.Sprintf("We disabled your webhook at %s because the last %d events could not be delivered to your webserver.", user.UserTimeString(payload.DisabledAt), payload.ConsecutiveFailures),
+				"No more events will be sent to this webhook until you enable it again.",
+			},
+			Dictionary: []hermes.Entry{
+				{"Server URL", payload.WebhookURL},
+				{"Webhook ID", payload.WebhookID.String()},
+				{"Consecutive Failures", fmt.Sprintf("%d", payload.ConsecutiveFailures)},
+				{"Last Error Message", payload.LastErrorMessage},
+			},
+			Actions: []hermes.Action{
+				{
+					Instructions: "Once your webserver is back online, you can enable the webhook again on the httpSMS website under the settings page.",
+					Button: hermes.Button{
+						Color:     "#329ef4",
+						TextColor: "#FFFFFF",
+						Text:      "WEBHOOK SETTINGS",
+						Link:      "https://httpsms.com/settings/#webhook-settings",
+					},
+				},
+			},
+			Signature: "Cheers",
+			Outros: []string{
+				"Don't hesitate to contact us by replying to this email.",
+			},
+		},
+	}
+
+	html, err := factory.generator.GenerateHTML(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate html email")
+	}
+
+	text, err := factory.generator.GeneratePlainText(email)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate text email")
+	}
+
+	return &Email{
+		ToEmail: user.Email,
+		Subject: "📢 Your webhook has been disabled",
+		HTML:    html,
+		Text:    text,
+	}, nil
+}
+
 func (factory *hermesNotificationEmailFactory) MessageExpired(user *entities.User, payload *events.MessageSendExpiredPayload) (*Email, error) {
 	email := hermes.Email{
 		Body: hermes.Body{
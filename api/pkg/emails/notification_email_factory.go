@@ -18,4 +18,7 @@ type NotificationEmailFactory interface {
 
 	// WebhookSendFailed sends an email when the user's webhook message is failed
 	WebhookSendFailed(user *entities.User, payload *events.WebhookSendFailedPayload) (*Email, error)
+
+	// WebhookDisabled sends an email when the user's webhook is disabled after too many consecutive failures
+	WebhookDisabled(user *entities.User, payload *events.WebhookDisabledPayload) (*Email, error)
 }
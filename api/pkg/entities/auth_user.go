@@ -4,6 +4,12 @@ package entities
 type AuthUser struct {
 	ID    UserID `json:"id"`
 	Email string `json:"email"`
+
+	// Locale is the language of the user's setting used for the response messages
+	Locale string `json:"locale"`
+
+	// Timezone is the timezone of the user's setting used for the local timestamps in list responses
+	Timezone string `json:"timezone"`
 }
 
 // IsNoop checks if a user is empty
@@ -0,0 +1,105 @@
+package entities
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BulkMessageJobStatus is the status of a bulk message job
+type BulkMessageJobStatus string
+
+const (
+	// BulkMessageJobStatusRunning means the messages of the job are being released at the interval of the job
+	BulkMessageJobStatusRunning = BulkMessageJobStatus("running")
+
+	// BulkMessageJobStatusPaused means no message is released until the job is resumed
+	BulkMessageJobStatusPaused = BulkMessageJobStatus("paused")
+
+	// BulkMessageJobStatusCompleted means all the messages of the job have been released
+	BulkMessageJobStatusCompleted = BulkMessageJobStatus("completed")
+)
+
+// BulkMessageJob spreads the messages of a bulk message file over a period of time by releasing them one after the other at a fixed interval.
+// The released messages are sent like the other messages so the rate limits of the phones still apply.
+type BulkMessageJob struct {
+	ID     uuid.UUID            `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID               `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Status BulkMessageJobStatus `json:"status" example:"running"`
+
+	// IntervalMilliseconds is the time between the release of 2 messages
+	IntervalMilliseconds uint `json:"interval_milliseconds" example:"4320"`
+
+	// TotalMessages is the number of messages in the bulk message file
+	TotalMessages uint `json:"total_messages" example:"5000"`
+
+	// ReleasedMessages is the number of messages which have been added to the queue of their phone
+	ReleasedMessages uint `json:"released_messages" example:"1200"`
+
+	// FailedMessages is the number of messages which could not be added to the queue e.g. because the phone was deleted
+	FailedMessages uint `json:"failed_messages" example:"0"`
+
+	// Sequence is the version of the job which is incremented by every change, a release is only valid for the sequence it was scheduled with so a release which is delivered twice or after the job was paused is ignored
+	Sequence uint `json:"-"`
+
+	// NextReleaseAt is the time when the next messages are released, it is nil when the job is paused or completed
+	NextReleaseAt *time.Time `json:"next_release_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// EstimatedCompletionAt is the time when the last message is released if the job is not paused, it is nil when the job is paused or completed
+	EstimatedCompletionAt *time.Time `json:"estimated_completion_at" example:"2022-06-05T20:26:10.303278+03:00"`
+
+	PausedAt *time.Time `json:"paused_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// PausedReason is set when the job was paused because the next messages could not be released, the job continues when it is resumed
+	PausedReason *string `json:"paused_reason" example:"cannot schedule the next release of the messages"`
+
+	CompletedAt *time.Time `json:"completed_at" example:"2022-06-05T20:26:10.303278+03:00"`
+	CreatedAt   time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt   time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// BulkMessageJobItem is a message of a BulkMessageJob which is sent when the job releases its Position
+type BulkMessageJobItem struct {
+	JobID    uuid.UUID `json:"job_id" gorm:"primaryKey;type:uuid;"`
+	Position uint      `json:"position" gorm:"primaryKey"`
+	UserID   UserID    `json:"user_id"`
+	Owner    string    `json:"owner"`
+	Contact  string    `json:"contact"`
+	Content  string    `json:"content"`
+}
+
+// MessageID is the ID of the message which is sent for the item, it is derived from the position so the message is not sent twice when a release is retried
+func (item *BulkMessageJobItem) MessageID() uuid.UUID {
+	return uuid.NewSHA1(item.JobID, []byte(strconv.FormatUint(uint64(item.Position), 10)))
+}
+
+// ProcessedMessages is the number of messages which have been released or have failed
+func (job *BulkMessageJob) ProcessedMessages() uint {
+	return job.ReleasedMessages + job.FailedMessages
+}
+
+// Interval is the time between the release of 2 messages
+func (job *BulkMessageJob) Interval() time.Duration {
+	return time.Duration(job.IntervalMilliseconds) * time.Millisecond
+}
+
+// ReleaseBatch returns the number of messages which are released together and the delay before the next batch.
+// Multiple messages are released together when the interval is shorter than a second so that a large job does not schedule an event for every message.
+func (job *BulkMessageJob) ReleaseBatch() (size uint, delay time.Duration) {
+	interval := max(job.Interval(), time.Millisecond)
+	if interval >= time.Second {
+		return 1, interval
+	}
+
+	size = uint((time.Second + interval - 1) / interval)
+	return size, interval * time.Duration(size)
+}
+
+// Schedule sets the NextReleaseAt and the EstimatedCompletionAt of a running job when the next batch is released after the delay
+func (job *BulkMessageJob) Schedule(timestamp time.Time, delay time.Duration) {
+	next := timestamp.Add(delay)
+	completion := next.Add(job.Interval() * time.Duration(job.TotalMessages-job.ProcessedMessages()-1))
+	job.NextReleaseAt = &next
+	job.EstimatedCompletionAt = &completion
+}
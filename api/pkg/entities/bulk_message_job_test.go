@@ -0,0 +1,134 @@
+package entities
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBulkMessageJob_ReleaseBatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval uint
+		size     uint
+		delay    time.Duration
+	}{
+		{name: "one message is released when the interval is a second", interval: 1000, size: 1, delay: time.Second},
+		{name: "one message is released when the interval is longer than a second", interval: 4320, size: 1, delay: 4320 * time.Millisecond},
+		{name: "the batch fills a second when the interval divides a second", interval: 250, size: 4, delay: time.Second},
+		{name: "the batch is rounded up when the interval does not divide a second", interval: 300, size: 4, delay: 1200 * time.Millisecond},
+		{name: "a zero interval is released as one millisecond", interval: 0, size: 1000, delay: time.Second},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Arrange
+			job := &BulkMessageJob{IntervalMilliseconds: tt.interval}
+
+			// Act
+			size, delay := job.ReleaseBatch()
+
+			// Assert
+			assert.Equal(t, tt.size, size)
+			assert.Equal(t, tt.delay, delay)
+		})
+	}
+}
+
+func TestBulkMessageJob_Schedule(t *testing.T) {
+	timestamp := time.Date(2022, 6, 5, 14, 26, 0, 0, time.UTC)
+	tests := []struct {
+		name       string
+		job        BulkMessageJob
+		delay      time.Duration
+		next       time.Time
+		completion time.Time
+	}{
+		{
+			name:       "the first release of a new job is now",
+			job:        BulkMessageJob{IntervalMilliseconds: 1000, TotalMessages: 10},
+			delay:      0,
+			next:       timestamp,
+			completion: timestamp.Add(9 * time.Second),
+		},
+		{
+			name:       "the processed messages are not counted in the completion",
+			job:        BulkMessageJob{IntervalMilliseconds: 2000, TotalMessages: 10, ReleasedMessages: 4, FailedMessages: 1},
+			delay:      2 * time.Second,
+			next:       timestamp.Add(2 * time.Second),
+			completion: timestamp.Add(2*time.Second + 8*time.Second),
+		},
+		{
+			name:       "the last message completes at the next release",
+			job:        BulkMessageJob{IntervalMilliseconds: 1000, TotalMessages: 10, ReleasedMessages: 9},
+			delay:      time.Second,
+			next:       timestamp.Add(time.Second),
+			completion: timestamp.Add(time.Second),
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Arrange
+			job := tt.job
+
+			// Act
+			job.Schedule(timestamp, tt.delay)
+
+			// Assert
+			assert.Equal(t, tt.next, *job.NextReleaseAt)
+			assert.Equal(t, tt.completion, *job.EstimatedCompletionAt)
+		})
+	}
+}
+
+func TestBulkMessageJobItem_MessageID(t *testing.T) {
+	jobID := uuid.MustParse("32343a19-da5e-4b1b-a767-3298a73703cb")
+	tests := []struct {
+		name  string
+		item  BulkMessageJobItem
+		other BulkMessageJobItem
+		equal bool
+	}{
+		{
+			name:  "a retried item has the same message ID",
+			item:  BulkMessageJobItem{JobID: jobID, Position: 3},
+			other: BulkMessageJobItem{JobID: jobID, Position: 3, Content: "retried"},
+			equal: true,
+		},
+		{
+			name:  "items at different positions have different message IDs",
+			item:  BulkMessageJobItem{JobID: jobID, Position: 1},
+			other: BulkMessageJobItem{JobID: jobID, Position: 11},
+		},
+		{
+			name:  "items of different jobs have different message IDs",
+			item:  BulkMessageJobItem{JobID: jobID, Position: 1},
+			other: BulkMessageJobItem{JobID: uuid.MustParse("32343a19-da5e-4b1b-a767-3298a73703ca"), Position: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			id, other := tt.item.MessageID(), tt.other.MessageID()
+
+			// Assert
+			assert.Equal(t, tt.equal, id == other)
+		})
+	}
+}
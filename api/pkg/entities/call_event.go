@@ -0,0 +1,45 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CallEventType is the type of phone call registered by the android phone
+type CallEventType string
+
+const (
+	// CallEventTypeReceived means the phone call was answered by the android phone
+	CallEventTypeReceived = CallEventType("received")
+
+	// CallEventTypeMissed means the phone call was missed by the android phone
+	CallEventTypeMissed = CallEventType("missed")
+)
+
+// String gets the string representation of the CallEventType
+func (t CallEventType) String() string {
+	return string(t)
+}
+
+// CallEvent is a phone call which was received or missed by the android phone
+type CallEvent struct {
+	ID      uuid.UUID     `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	PhoneID uuid.UUID     `json:"phone_id" gorm:"index:idx_call_events__phone_id_timestamp" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID  UserID        `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner   string        `json:"owner" example:"+18005550199"`
+	Contact string        `json:"contact" example:"+18005550100"`
+	Type    CallEventType `json:"type" example:"missed"`
+	SIM     SIM           `json:"sim" example:"SIM1"`
+
+	// DurationSeconds is the length of the phone call in seconds, it is 0 for missed calls
+	DurationSeconds uint `json:"duration_seconds" example:"65"`
+
+	Timestamp time.Time `json:"timestamp" gorm:"index:idx_call_events__phone_id_timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsMissed checks if the phone call was missed
+func (event *CallEvent) IsMissed() bool {
+	return event.Type == CallEventTypeMissed
+}
@@ -0,0 +1,56 @@
+package entities
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ContentFilterDirection is the direction of the messages which are checked by a ContentFilter
+type ContentFilterDirection string
+
+const (
+	// ContentFilterDirectionOutbound checks the content of the messages which are sent
+	ContentFilterDirectionOutbound = ContentFilterDirection("outbound")
+
+	// ContentFilterDirectionInbound checks the content of the messages which are received
+	ContentFilterDirectionInbound = ContentFilterDirection("inbound")
+
+	// ContentFilterDirectionAll checks the content of both the sent and the received messages
+	ContentFilterDirectionAll = ContentFilterDirection("all")
+)
+
+// ContentFilter is a word or a regular expression which blocks sent messages and flags received messages
+type ContentFilter struct {
+	ID        uuid.UUID              `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID                 `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Term      string                 `json:"term" example:"casino"`
+	IsRegex   bool                   `json:"is_regex" example:"false"`
+	Direction ContentFilterDirection `json:"direction" example:"outbound"`
+	CreatedAt time.Time              `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time              `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// AppliesTo checks if the ContentFilter checks messages in the direction
+func (filter *ContentFilter) AppliesTo(direction ContentFilterDirection) bool {
+	return filter.Direction == ContentFilterDirectionAll || filter.Direction == direction
+}
+
+// Pattern returns the regular expression of the ContentFilter.
+// A word matches case-insensitively when it is not part of a longer word.
+func (filter *ContentFilter) Pattern() string {
+	if filter.IsRegex {
+		return filter.Term
+	}
+	return `(?i)(^|[^\p{L}\p{N}])` + regexp.QuoteMeta(filter.Term) + `($|[^\p{L}\p{N}])`
+}
+
+// Matches checks if the content contains the term of the ContentFilter
+func (filter *ContentFilter) Matches(content string) bool {
+	pattern, err := regexp.Compile(filter.Pattern())
+	if err != nil {
+		return false
+	}
+	return pattern.MatchString(content)
+}
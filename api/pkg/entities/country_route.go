@@ -0,0 +1,23 @@
+package entities
+
+import "time"
+
+// CountryRouteDefault is the country of the route which is used when no route matches the country of the recipient
+const CountryRouteDefault = "default"
+
+// CountryRoute selects the phone which sends the messages of a user to the recipients in a country when the messages are sent without a from number
+type CountryRoute struct {
+	UserID UserID `json:"user_id" gorm:"primaryKey" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// Country is the ISO 3166-1 alpha-2 code of the country of the recipients, the CountryRouteDefault route is used when no other route matches
+	Country string `json:"country" gorm:"primaryKey" example:"US"`
+
+	// Owner is the phone number of the phone which sends the messages, it is null when the messages are sent by the PhoneGroup
+	Owner *string `json:"owner" example:"+18005550199"`
+
+	// PhoneGroup is the group of phones which sends the messages with the strategy of the group, it is null when the messages are sent by the Owner
+	PhoneGroup *string `json:"phone_group" example:"warehouse-1"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
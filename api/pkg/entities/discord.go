@@ -13,6 +13,10 @@ type Discord struct {
 	Name              string    `json:"name" example:"Game Server"`
 	ServerID          string    `json:"server_id" gorm:"uniqueIndex:idx_discords_server_id" example:"1095778291488653372"`
 	IncomingChannelID string    `json:"incoming_channel_id" example:"1095780203256627291"`
+	CommandID         *string   `json:"command_id" example:"1096009806122663977"`
 	CreatedAt         time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt         time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// DefaultFrom is the phone number which sends the SMS of a slash command without a from option
+	DefaultFrom *string `json:"default_from" example:"+18005550199"`
 }
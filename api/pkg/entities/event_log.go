@@ -0,0 +1,30 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// EventLog is an append-only record of a cloudevents.Event which changed the state of a resource e.g. a message or a phone
+type EventLog struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID         `json:"user_id" gorm:"index:idx_event_logs__user_id__timestamp;index:idx_event_logs__user_id__resource" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner     string         `json:"owner" example:"+18005550199"`
+	Type      string         `json:"type" example:"message.phone.received"`
+	Source    string         `json:"source" example:"/v1/messages/receive"`
+	Event     datatypes.JSON `json:"event" swaggertype:"object"`
+	Timestamp time.Time      `json:"timestamp" gorm:"index:idx_event_logs__user_id__timestamp" example:"2022-06-05T14:26:02.302718+03:00"`
+	CreatedAt time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+
+	// ResourceType and ResourceID identify the entity which was changed by the event e.g. a message
+	ResourceType string `json:"resource_type" gorm:"index:idx_event_logs__user_id__resource" example:"message"`
+	ResourceID   string `json:"resource_id" gorm:"index:idx_event_logs__user_id__resource" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// EventID is the ID of the cloudevents.Event, it is the same for every delivery of the event to a webhook so it is used to deduplicate the deliveries
+	EventID string `json:"event_id" gorm:"index:idx_event_logs__event_id" example:"0b8c5d0e-3f2a-4b7e-9d52-6a3c4f1e2b7a"`
+
+	// Sequence increases with every stored event and it is the ID used to resume the server-sent events stream
+	Sequence int64 `json:"sequence" gorm:"autoIncrement;uniqueIndex:idx_event_logs__sequence" example:"1024"`
+}
@@ -0,0 +1,20 @@
+package entities
+
+import "time"
+
+// FcmCredential stores the service account of the firebase project which sends the push notifications to the phones of a user instead of the httpSMS firebase project
+type FcmCredential struct {
+	UserID UserID `json:"user_id" gorm:"primaryKey" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// ProjectID is the ID of the firebase project of the service account
+	ProjectID string `json:"project_id" example:"my-firebase-project"`
+
+	// ClientEmail is the email address of the service account
+	ClientEmail string `json:"client_email" example:"firebase-adminsdk@my-firebase-project.iam.gserviceaccount.com"`
+
+	// EncryptedServiceAccount is the encrypted JSON key of the service account
+	EncryptedServiceAccount string `json:"-"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
@@ -0,0 +1,64 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// FeatureFlag is the name of a behavior which is rolled out to some users before it is enabled for everyone
+type FeatureFlag string
+
+const (
+	// FeatureFlagProviderFallback sends messages with the third-party SMS provider of the user when no phone picks them up
+	FeatureFlagProviderFallback = FeatureFlag("provider_fallback")
+)
+
+// FeatureFlags are all the supported feature flags
+var FeatureFlags = []FeatureFlag{
+	FeatureFlagProviderFallback,
+}
+
+// String gets the string representation of the FeatureFlag
+func (flag FeatureFlag) String() string {
+	return string(flag)
+}
+
+// UserFeatureFlags are the feature flags of a user, a flag which is not set is disabled
+type UserFeatureFlags map[FeatureFlag]bool
+
+// IsEnabled checks if a feature flag is enabled
+func (flags UserFeatureFlags) IsEnabled(flag FeatureFlag) bool {
+	return flags[flag]
+}
+
+// Value implements the driver.Valuer interface
+func (flags UserFeatureFlags) Value() (driver.Value, error) {
+	if flags == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(flags)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (flags *UserFeatureFlags) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*flags = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan [%T] into [%T]", value, flags)
+	}
+	return json.Unmarshal(data, flags)
+}
+
+// GormDataType is the data type of UserFeatureFlags in the database
+func (UserFeatureFlags) GormDataType() string {
+	return "jsonb"
+}
@@ -12,6 +12,7 @@ type Heartbeat struct {
 	Owner     string    `json:"owner" gorm:"index:idx_heartbeats_owner_timestamp" example:"+18005550199"`
 	Version   string    `json:"version" example:"344c10f"`
 	Charging  bool      `json:"charging" example:"true"`
+	Battery   *uint     `json:"battery" example:"85"`
 	UserID    UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
 	Timestamp time.Time `json:"timestamp" gorm:"index:idx_heartbeats_owner_timestamp" example:"2022-06-05T14:26:01.520828+03:00"`
 }
@@ -14,6 +14,7 @@ type HeartbeatMonitor struct {
 	QueueID     string    `json:"queue_id" example:"0360259236613675274"`
 	Owner       string    `json:"owner" example:"+18005550199"`
 	PhoneOnline bool      `json:"phone_online" example:"true" default:"true"`
+	BatteryLow  bool      `json:"battery_low" example:"false"`
 	CreatedAt   time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt   time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
 }
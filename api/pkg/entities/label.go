@@ -0,0 +1,28 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Label is a colored tag e.g. urgent or follow-up which a user attaches to messages to organize them
+type Label struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name   string    `json:"name" example:"follow-up"`
+
+	// Color is the hex code of the color of the label in the UI
+	Color string `json:"color" example:"#ff5722"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// MessageLabel attaches a Label to a Message
+type MessageLabel struct {
+	MessageID uuid.UUID `json:"message_id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	LabelID   uuid.UUID `json:"label_id" gorm:"primaryKey;type:uuid;index" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+}
@@ -1,6 +1,7 @@
 package entities
 
 import (
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -45,13 +46,44 @@ const (
 	// MessageStatusDelivered means the mobile phone has delivered the message
 	MessageStatusDelivered = "delivered"
 
+	// MessageStatusRead means the phone has reported that the recipient has read the delivered message
+	MessageStatusRead = "read"
+
 	// MessageStatusExpired means the message could not be sent by the mobile phone after 5 minutes
 	MessageStatusExpired = "expired"
 
+	// MessageStatusCancelled means the message was cancelled by the user before a phone picked it up
+	MessageStatusCancelled = "cancelled"
+
 	// MessageStatusDeleted is for deleted messages and threads
 	MessageStatusDeleted = "deleted"
 )
 
+// MessageContactType is the format of the contact of a message
+type MessageContactType string
+
+const (
+	// MessageContactTypePhoneNumber means the contact is a phone number which is stored in the E.164 format when it can be parsed
+	MessageContactTypePhoneNumber = MessageContactType("phone_number")
+
+	// MessageContactTypeShortCode means the contact is a short code e.g. 12345 which is stored without E.164 normalization
+	MessageContactTypeShortCode = MessageContactType("short_code")
+
+	// MessageContactTypeAlphanumeric means the contact is an alphanumeric sender e.g. MyBrand which cannot receive replies
+	MessageContactTypeAlphanumeric = MessageContactType("alphanumeric")
+)
+
+// MessagePushStatus is the result of the push notification which wakes up the phone to send the message
+type MessagePushStatus string
+
+const (
+	// MessagePushStatusSent means firebase cloud messaging accepted the push notification for the phone
+	MessagePushStatusSent = MessagePushStatus("sent")
+
+	// MessagePushStatusFailed means the push notification could not be sent to the phone so the phone never got the message
+	MessagePushStatusFailed = MessagePushStatus("failed")
+)
+
 // MessageEventName is the type of event generated by the mobile phone for a message
 type MessageEventName string
 
@@ -83,15 +115,23 @@ func (s SIM) String() string {
 
 // Message represents a message sent between 2 phone numbers
 type Message struct {
-	ID        uuid.UUID     `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
-	RequestID *string       `json:"request_id" example:"153554b5-ae44-44a0-8f4f-7bbac5657ad4"`
-	Owner     string        `json:"owner" example:"+18005550199"`
-	UserID    UserID        `json:"user_id" gorm:"index:idx_messages__user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
-	Contact   string        `json:"contact" example:"+18005550100"`
+	ID        uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	RequestID *string   `json:"request_id" example:"153554b5-ae44-44a0-8f4f-7bbac5657ad4"`
+	Owner     string    `json:"owner" example:"+18005550199"`
+	UserID    UserID    `json:"user_id" gorm:"index:idx_messages__user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Contact   string    `json:"contact" example:"+18005550100"`
+
+	// ContactType is the format of the contact e.g. phone_number, short_code or alphanumeric
+	ContactType MessageContactType `json:"contact_type" gorm:"default:phone_number" example:"phone_number"`
+
 	Content   string        `json:"content" example:"This is a sample text message"`
 	Encrypted bool          `json:"encrypted" example:"false" gorm:"default:false"`
 	Type      MessageType   `json:"type" example:"mobile-terminated"`
 	Status    MessageStatus `json:"status" example:"pending"`
+
+	// Segments is the number of SMS segments of the content of an outgoing message including the signature of the phone, it is null for encrypted and received messages
+	Segments *uint `json:"segments" example:"1"`
+
 	// SIM is the SIM card to use to send the message
 	// * SMS1: use the SIM card in slot 1
 	// * SMS2: use the SIM card in slot 2
@@ -101,6 +141,96 @@ type Message struct {
 	// SendDuration is the number of nanoseconds from when the request was received until when the mobile phone send the message
 	SendDuration *int64 `json:"send_time" example:"133414"`
 
+	// Metadata is arbitrary key/value data attached to the message when it was sent
+	Metadata MessageMetadata `json:"metadata" gorm:"type:jsonb;index:idx_messages__metadata,type:gin" swaggertype:"object,string" example:"campaign:spring_sale"`
+
+	// SenderID is the alphanumeric sender ID e.g. MyBrand which selected the phone, the carrier of the SIM applies it instead of the owner's phone number
+	SenderID *string `json:"sender_id" example:"MyBrand"`
+
+	// Category is detected by the classifier for received messages e.g. otp, marketing, personal or unknown
+	Category *MessageCategory `json:"category" gorm:"index:idx_messages__category" example:"otp"`
+
+	// Attachments are the media files received in an MMS message, they are downloaded from /messages/{messageID}/media/{index}
+	Attachments MessageAttachments `json:"attachments" gorm:"type:jsonb" swaggertype:"array,object"`
+
+	// ValidityPeriod is the number of seconds the carrier should attempt to deliver the message, the phone sets it as the SMS validity period
+	ValidityPeriod *uint `json:"validity_period" example:"600"`
+
+	// InReplyTo is the ID of the message in the same conversation which this message is a reply to
+	InReplyTo *uuid.UUID `json:"in_reply_to" gorm:"type:uuid;index:idx_messages__in_reply_to" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// GroupID is shared by the messages which were created by sending the same content to multiple recipients in a single request
+	GroupID *uuid.UUID `json:"group_id" gorm:"type:uuid;index:idx_messages__group_id" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+
+	// PhoneGroup is the group of phones which the Owner was selected from by the strategy of the group, it is nil when the message was sent from a single phone
+	PhoneGroup *string `json:"phone_group" example:"warehouse-1"`
+
+	// CountryRoute is the country of the route which selected the Owner when the message was sent without a from number, it is default when no route matched the country of the recipient
+	CountryRoute *string `json:"country_route" example:"US"`
+
+	// Language is the ISO 639-1 code detected for received messages when language detection is enabled e.g. en or unknown
+	Language *string `json:"language" gorm:"index:idx_messages__language" example:"en"`
+
+	// Starred is true when the user has starred the message so that it can be found with the starred filter
+	Starred bool `json:"starred" gorm:"default:false;index:idx_messages__starred" example:"false"`
+
+	// ReadAt is the time when the user marked the message as read, it is null for messages which have not been read
+	ReadAt *time.Time `json:"read_at" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// ContentFilterID is the ID of the inbound content filter which flagged the content of a received message
+	ContentFilterID *uuid.UUID `json:"content_filter_id" gorm:"type:uuid" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// ContentFlagged is true when the content of a received message matches one of the inbound content filters of the user
+	ContentFlagged bool `json:"content_flagged" gorm:"default:false;index:idx_messages__content_flagged" example:"false"`
+
+	// ContentTruncated is true when the content of a received message was longer than the inbound content limit of the user and only the start of the content is stored
+	ContentTruncated bool `json:"content_truncated" gorm:"default:false" example:"false"`
+
+	// OriginalContentLength is the number of characters in the content of a received message before it was truncated, it is nil when the content is not truncated
+	OriginalContentLength *uint `json:"original_content_length" example:"4800"`
+
+	// QueuePosition is the position of a pending message in the rate limited queue of its phone starting from 1, it is nil when the message is not pending
+	QueuePosition *uint `json:"queue_position" example:"3" gorm:"-"`
+
+	// EstimatedSendAt is when the rate limiter of the phone will send a pending message, it is nil when the message is not pending
+	EstimatedSendAt *time.Time `json:"estimated_send_at" example:"2022-06-05T14:26:09.527976+03:00" gorm:"-"`
+
+	// PushStatus is the result of the last push notification sent to the phone, it is separate from the status of the SMS which is sent by the phone
+	PushStatus *MessagePushStatus `json:"push_status" example:"sent"`
+
+	// PushMessageID is the ID returned by firebase cloud messaging when it accepted the last push notification
+	PushMessageID *string `json:"push_message_id" example:"projects/httpsms/messages/0:1656072000000000%31bd1c9631bd1c96"`
+
+	// PushErrorCode is the firebase cloud messaging error code e.g. UNREGISTERED or QUOTA_EXCEEDED when the last push notification failed
+	PushErrorCode *string `json:"push_error_code" example:"UNREGISTERED"`
+
+	// PushError is the error message of the last push notification which failed
+	PushError *string `json:"push_error" example:"registration token is not a valid FCM registration token"`
+
+	// PushAttemptedAt is the time of the last push notification sent to the phone
+	PushAttemptedAt *time.Time `json:"push_attempted_at" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// PushWaitTimedOut is true when the send request waited for the push notification and it was not sent before the timeout, the message is still in the queue
+	PushWaitTimedOut bool `json:"push_wait_timed_out,omitempty" example:"false" gorm:"-"`
+
+	// ProviderFallback is true when the message is sent with the third-party SMS provider of the user if no phone picks it up within the timeout of the provider
+	ProviderFallback bool `json:"provider_fallback" example:"false" gorm:"default:false"`
+
+	// Provider is the third-party SMS provider e.g. twilio which sent the message, it is nil when the message was sent by a phone
+	Provider *SmsProviderName `json:"provider" example:"twilio" swaggertype:"string"`
+
+	// ProviderMessageID is the ID of the message at the third-party SMS provider which sent it
+	ProviderMessageID *string `json:"provider_message_id" example:"SM1f0e8ade8d4a4bb28e2e5d2c8b4d9c1a"`
+
+	// RecipientReadAt is the time when the phone reported that the recipient read the message e.g. with RCS read receipts
+	RecipientReadAt *time.Time `json:"recipient_read_at" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// Reactions are the emoji which the owner and the contact sent over RCS to react to the message
+	Reactions MessageReactions `json:"reactions" gorm:"type:jsonb" swaggertype:"array,object"`
+
+	// ArchivedAt is the time when the content of the message was moved to cold storage, the content is empty in the lists of messages and it is fetched from cold storage when the message is loaded by ID
+	ArchivedAt *time.Time `json:"archived_at" gorm:"index:idx_messages__archived_at" example:"2022-06-05T14:26:09.527976+03:00"`
+
 	RequestReceivedAt       time.Time  `json:"request_received_at" example:"2022-06-05T14:26:01.520828+03:00"`
 	CreatedAt               time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt               time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
@@ -112,6 +242,7 @@ type Message struct {
 	DeliveredAt             *time.Time `json:"delivered_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	ExpiredAt               *time.Time `json:"expired_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	FailedAt                *time.Time `json:"failed_at" example:"2022-06-05T14:26:09.527976+03:00"`
+	CancelledAt             *time.Time `json:"cancelled_at" example:"2022-06-05T14:26:09.527976+03:00"`
 	CanBePolled             bool       `json:"can_be_polled" example:"false"`
 	SendAttemptCount        uint       `json:"send_attempt_count" example:"0"`
 	MaxSendAttempts         uint       `json:"max_send_attempts" example:"1"`
@@ -119,14 +250,34 @@ type Message struct {
 	FailureReason           *string    `json:"failure_reason" example:"UNKNOWN"`
 }
 
+// AttachmentKey is the key of the attachment at the index in the media storage
+func (message *Message) AttachmentKey(index int) string {
+	return fmt.Sprintf("%s/%s/%d", message.UserID, message.ID, index)
+}
+
+// ArchiveKey is the key of the archived message in the cold storage
+func (message *Message) ArchiveKey() string {
+	return fmt.Sprintf("%s/%s.json", message.UserID, message.ID)
+}
+
+// IsArchived checks if the content of the message has been moved to cold storage
+func (message *Message) IsArchived() bool {
+	return message.ArchivedAt != nil
+}
+
 // IsSending determines if a message is being sent
 func (message *Message) IsSending() bool {
 	return message.Status == MessageStatusSending
 }
 
-// IsDelivered checks if a message is delivered
+// IsDelivered checks if a message is delivered, a message which has been read by the recipient is also delivered
 func (message *Message) IsDelivered() bool {
-	return message.Status == MessageStatusDelivered
+	return message.Status == MessageStatusDelivered || message.IsRead()
+}
+
+// IsRead checks if the recipient has read the message
+func (message *Message) IsRead() bool {
+	return message.Status == MessageStatusRead
 }
 
 // IsPending checks if a message is pending
@@ -134,6 +285,16 @@ func (message *Message) IsPending() bool {
 	return message.Status == MessageStatusPending
 }
 
+// SetQueuePosition sets the computed queue fields of a pending message, the estimated send time is never before the timestamp
+func (message *Message) SetQueuePosition(position uint, scheduledAt time.Time, timestamp time.Time) *Message {
+	if scheduledAt.Before(timestamp) {
+		scheduledAt = timestamp
+	}
+	message.QueuePosition = &position
+	message.EstimatedSendAt = &scheduledAt
+	return message
+}
+
 // IsScheduled checks if a message is scheduled
 func (message *Message) IsScheduled() bool {
 	return message.Status == MessageStatusScheduled
@@ -144,11 +305,26 @@ func (message *Message) IsExpired() bool {
 	return message.Status == MessageStatusExpired
 }
 
+// IsCancelled checks if a message has been cancelled
+func (message *Message) IsCancelled() bool {
+	return message.Status == MessageStatusCancelled
+}
+
+// CanBeCancelled checks if a message has not been picked up by a phone
+func (message *Message) CanBeCancelled() bool {
+	return message.IsPending() || message.IsScheduled()
+}
+
 // CanBeRescheduled checks if a message can be rescheduled
 func (message *Message) CanBeRescheduled() bool {
 	return message.SendAttemptCount < message.MaxSendAttempts
 }
 
+// CanBeReacted checks if a message has been received or has left the phone, the contact has not seen the other outgoing messages
+func (message *Message) CanBeReacted() bool {
+	return message.Status == MessageStatusReceived || message.IsSent() || message.IsDelivered()
+}
+
 // IsSent determines if a message has been sent
 func (message *Message) IsSent() bool {
 	return message.Status == MessageStatusSent
@@ -165,6 +341,13 @@ func (message *Message) Sent(timestamp time.Time) *Message {
 	return message
 }
 
+// SentWithProvider registers the third-party SMS provider which sent the message instead of a phone
+func (message *Message) SentWithProvider(provider SmsProviderName, providerMessageID string) *Message {
+	message.Provider = &provider
+	message.ProviderMessageID = &providerMessageID
+	return message
+}
+
 // Failed registers a message as failed
 func (message *Message) Failed(timestamp time.Time, errorMessage string) *Message {
 	message.FailedAt = &timestamp
@@ -187,6 +370,42 @@ func (message *Message) Delivered(timestamp time.Time) *Message {
 	return message
 }
 
+// RecipientRead registers a message as read by the recipient
+func (message *Message) RecipientRead(timestamp time.Time) *Message {
+	message.RecipientReadAt = &timestamp
+	message.Status = MessageStatusRead
+	message.updateOrderTimestamp(timestamp)
+	return message
+}
+
+// AddReaction registers an emoji reaction to the message
+func (message *Message) AddReaction(emoji string, from string, timestamp time.Time) *Message {
+	message.Reactions = append(message.Reactions, MessageReaction{Emoji: emoji, From: from, Timestamp: timestamp})
+	return message
+}
+
+// PushSent registers the push notification of a message as accepted by firebase cloud messaging
+func (message *Message) PushSent(timestamp time.Time, pushMessageID string) *Message {
+	status := MessagePushStatusSent
+	message.PushStatus = &status
+	message.PushMessageID = &pushMessageID
+	message.PushErrorCode = nil
+	message.PushError = nil
+	message.PushAttemptedAt = &timestamp
+	return message
+}
+
+// PushFailed registers the push notification of a message as failed
+func (message *Message) PushFailed(timestamp time.Time, errorCode string, errorMessage string) *Message {
+	status := MessagePushStatusFailed
+	message.PushStatus = &status
+	message.PushMessageID = nil
+	message.PushErrorCode = &errorCode
+	message.PushError = &errorMessage
+	message.PushAttemptedAt = &timestamp
+	return message
+}
+
 // AddSendAttemptCount increments the send attempt count of a message
 func (message *Message) AddSendAttemptCount() *Message {
 	message.SendAttemptCount++
@@ -0,0 +1,47 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MessageAttachment is a media file e.g. an image received in an MMS message
+type MessageAttachment struct {
+	ContentType string `json:"content_type" example:"image/jpeg"`
+	Size        int64  `json:"size" example:"102400"`
+}
+
+// MessageAttachments are the media files of a message in the order they were received
+type MessageAttachments []MessageAttachment
+
+// Value implements the driver.Valuer interface
+func (attachments MessageAttachments) Value() (driver.Value, error) {
+	if attachments == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(attachments)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (attachments *MessageAttachments) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*attachments = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan [%T] into [%T]", value, attachments)
+	}
+	return json.Unmarshal(data, attachments)
+}
+
+// GormDataType is the data type of MessageAttachments in the database
+func (MessageAttachments) GormDataType() string {
+	return "jsonb"
+}
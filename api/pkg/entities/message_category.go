@@ -0,0 +1,77 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MessageCategory is the category of a received message which is detected by the classifier
+type MessageCategory string
+
+const (
+	// MessageCategoryOTP is a one time password or verification code
+	MessageCategoryOTP = MessageCategory("otp")
+
+	// MessageCategoryMarketing is a promotional message
+	MessageCategoryMarketing = MessageCategory("marketing")
+
+	// MessageCategoryPersonal is a message from a person
+	MessageCategoryPersonal = MessageCategory("personal")
+
+	// MessageCategoryUnknown is used when the classifier cannot detect the category of the message
+	MessageCategoryUnknown = MessageCategory("unknown")
+)
+
+// MessageCategories are all the supported message categories
+var MessageCategories = []MessageCategory{
+	MessageCategoryOTP,
+	MessageCategoryMarketing,
+	MessageCategoryPersonal,
+	MessageCategoryUnknown,
+}
+
+// String gets the string representation of the MessageCategory
+func (category MessageCategory) String() string {
+	return string(category)
+}
+
+// MessageCategoryRule is a regex rule which tags the content of a message with a category
+type MessageCategoryRule struct {
+	Category MessageCategory `json:"category" example:"otp"`
+	Pattern  string          `json:"pattern" example:"(?i)\\b(code|otp|verification)\\b"`
+}
+
+// MessageCategoryRules are the rules of the message classifier, they are evaluated in order and the first match wins
+type MessageCategoryRules []MessageCategoryRule
+
+// Value implements the driver.Valuer interface
+func (rules MessageCategoryRules) Value() (driver.Value, error) {
+	if rules == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(rules)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (rules *MessageCategoryRules) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*rules = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan [%T] into [%T]", value, rules)
+	}
+	return json.Unmarshal(data, rules)
+}
+
+// GormDataType is the data type of MessageCategoryRules in the database
+func (MessageCategoryRules) GormDataType() string {
+	return "jsonb"
+}
@@ -0,0 +1,41 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+)
+
+// MessageMetadata is arbitrary key/value data attached to a message by the API client
+type MessageMetadata map[string]string
+
+// Value implements the driver.Valuer interface
+func (metadata MessageMetadata) Value() (driver.Value, error) {
+	if metadata == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(metadata)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (metadata *MessageMetadata) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*metadata = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan [%T] into [%T]", value, metadata)
+	}
+	return json.Unmarshal(data, metadata)
+}
+
+// GormDataType is the data type of MessageMetadata in the database
+func (MessageMetadata) GormDataType() string {
+	return "jsonb"
+}
@@ -0,0 +1,53 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// MessageReaction is an emoji which the owner or the contact of a conversation sent over RCS to react to a message
+type MessageReaction struct {
+	// Emoji is the reaction e.g. 👍
+	Emoji string `json:"emoji" example:"👍"`
+
+	// From is the phone number which sent the reaction, it is the owner of the message when the reaction was sent with the API
+	From string `json:"from" example:"+18005550199"`
+
+	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+}
+
+// MessageReactions are the reactions to a message in the order they were sent
+type MessageReactions []MessageReaction
+
+// Value implements the driver.Valuer interface
+func (reactions MessageReactions) Value() (driver.Value, error) {
+	if reactions == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(reactions)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (reactions *MessageReactions) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*reactions = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan [%T] into [%T]", value, reactions)
+	}
+	return json.Unmarshal(data, reactions)
+}
+
+// GormDataType is the data type of MessageReactions in the database
+func (MessageReactions) GormDataType() string {
+	return "jsonb"
+}
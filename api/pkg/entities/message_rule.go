@@ -0,0 +1,100 @@
+package entities
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageRule routes the messages received by a user, the rules are evaluated in the order of their Position and all the actions of a matching rule are performed.
+// A condition which is not set matches every received message. The AutoAckMessage and the OutOfOfficeMessage of a phone are evaluated as rules before the rules of the user.
+type MessageRule struct {
+	ID       uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID   UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name     string    `json:"name" example:"Forward orders"`
+	Position uint      `json:"position" example:"1"`
+	Enabled  bool      `json:"enabled" gorm:"default:true" example:"true"`
+
+	// StopProcessing skips the rules with a higher Position when the rule matches a message
+	StopProcessing bool `json:"stop_processing" example:"false"`
+
+	// Sender matches the phone number of the contact who sent the message
+	Sender *string `json:"sender" example:"+18005550100"`
+
+	// Recipient matches the phone number of the phone which received the message
+	Recipient *string `json:"recipient" example:"+18005550199"`
+
+	// ContentPattern is a regular expression which matches the content of the message, it never matches encrypted messages
+	ContentPattern *string `json:"content_pattern" example:"(?i)^order"`
+
+	// Schedule matches the messages which are received within its hours in the timezone of the phone which received the message
+	Schedule PhoneBusinessHoursSchedule `json:"schedule" swaggertype:"array,object"`
+
+	// WebhookID is the webhook which the message.phone.received event is sent to even when the webhook is not subscribed to the event
+	WebhookID *uuid.UUID `json:"webhook_id" gorm:"type:uuid" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// ReplyContent is sent to the contact who sent the message, {{contact}} is replaced with the phone number of the contact
+	ReplyContent *string `json:"reply_content" example:"Thanks, we have received your order"`
+
+	// LabelID is the label which is attached to the message
+	LabelID *uuid.UUID `json:"label_id" gorm:"type:uuid" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// phone is set on the rules which are derived from the settings of a phone, these rules are not stored
+	phone *Phone
+
+	// outOfOffice is set on the rule of the OutOfOfficeMessage of a phone, it matches the messages which are received outside the business hours of the phone
+	outOfOffice bool
+}
+
+// Matches checks if a message which was received by the owner from the contact at the timestamp matches all the conditions of the MessageRule.
+// The pattern is the compiled ContentPattern, the location is the timezone of the phone which received the message and the content is empty when the message is encrypted.
+func (rule *MessageRule) Matches(pattern *regexp.Regexp, owner string, contact string, content string, timestamp time.Time, location *time.Location) bool {
+	if rule.Sender != nil && *rule.Sender != contact {
+		return false
+	}
+
+	if rule.Recipient != nil && *rule.Recipient != owner {
+		return false
+	}
+
+	if len(rule.Schedule) > 0 && !rule.Schedule.Contains(timestamp, location) {
+		return false
+	}
+
+	if rule.outOfOffice && rule.phone.IsOpen(timestamp) {
+		return false
+	}
+
+	if rule.ContentPattern == nil {
+		return true
+	}
+	return pattern != nil && content != "" && pattern.MatchString(content)
+}
+
+// ReplyText returns the ReplyContent of the MessageRule for a message received from the contact at the timestamp
+func (rule *MessageRule) ReplyText(contact string, timestamp time.Time) string {
+	if rule.ReplyContent == nil {
+		return ""
+	}
+
+	if rule.outOfOffice {
+		return rule.phone.OutOfOfficeContent(contact, timestamp)
+	}
+	return strings.ReplaceAll(*rule.ReplyContent, phoneAutoAckContactPlaceholder, contact)
+}
+
+// ReplyMessageID returns the ID of the reply to a message received from the contact at the timestamp so that the reply is sent once.
+// The out of office reply of a phone is sent at most once a day to each contact so its ID is derived from the date in the timezone of the phone.
+func (rule *MessageRule) ReplyMessageID(messageID uuid.UUID, contact string, timestamp time.Time) uuid.UUID {
+	if rule.outOfOffice {
+		date := timestamp.In(rule.phone.Location()).Format(time.DateOnly)
+		return uuid.NewSHA1(rule.phone.ID, []byte(fmt.Sprintf("out-of-office:%s:%s", contact, date)))
+	}
+	return uuid.NewSHA1(rule.ID, []byte(fmt.Sprintf("reply:%s", messageID)))
+}
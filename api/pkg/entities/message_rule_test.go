@@ -0,0 +1,142 @@
+package entities
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageRule_Matches(t *testing.T) {
+	owner, contact := "+18005550199", "+18005550100"
+	other := "+18005550111"
+	pattern := "(?i)^order"
+
+	// 2022-06-06 is a Monday
+	open := time.Date(2022, 6, 6, 10, 0, 0, 0, time.UTC)
+	closed := time.Date(2022, 6, 6, 20, 0, 0, 0, time.UTC)
+	schedule := PhoneBusinessHoursSchedule{{Day: "monday", Start: "09:00", End: "17:00"}}
+
+	tests := []struct {
+		name      string
+		rule      *MessageRule
+		content   string
+		timestamp time.Time
+		matches   bool
+	}{
+		{name: "a rule without conditions matches every message", rule: &MessageRule{}, content: "hello", timestamp: open, matches: true},
+		{name: "the sender matches the contact", rule: &MessageRule{Sender: &contact}, content: "hello", timestamp: open, matches: true},
+		{name: "the sender does not match another contact", rule: &MessageRule{Sender: &other}, content: "hello", timestamp: open, matches: false},
+		{name: "the recipient matches the owner", rule: &MessageRule{Recipient: &owner}, content: "hello", timestamp: open, matches: true},
+		{name: "the recipient does not match another owner", rule: &MessageRule{Recipient: &other}, content: "hello", timestamp: open, matches: false},
+		{name: "the content pattern matches the content", rule: &MessageRule{ContentPattern: &pattern}, content: "Order 123", timestamp: open, matches: true},
+		{name: "the content pattern does not match other content", rule: &MessageRule{ContentPattern: &pattern}, content: "hello", timestamp: open, matches: false},
+		{name: "the content pattern never matches an encrypted message", rule: &MessageRule{ContentPattern: &pattern}, content: "", timestamp: open, matches: false},
+		{name: "the schedule matches a message within its hours", rule: &MessageRule{Schedule: schedule}, content: "hello", timestamp: open, matches: true},
+		{name: "the schedule does not match a message outside its hours", rule: &MessageRule{Schedule: schedule}, content: "hello", timestamp: closed, matches: false},
+		{name: "the out of office rule does not match a message within the business hours", rule: &MessageRule{phone: &Phone{BusinessHours: schedule}, outOfOffice: true}, content: "hello", timestamp: open, matches: false},
+		{name: "the out of office rule matches a message outside the business hours", rule: &MessageRule{phone: &Phone{BusinessHours: schedule}, outOfOffice: true}, content: "hello", timestamp: closed, matches: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Arrange
+			var compiled *regexp.Regexp
+			if tt.rule.ContentPattern != nil {
+				compiled = regexp.MustCompile(*tt.rule.ContentPattern)
+			}
+
+			// Act
+			matches := tt.rule.Matches(compiled, owner, contact, tt.content, tt.timestamp, time.UTC)
+
+			// Assert
+			assert.Equal(t, tt.matches, matches)
+		})
+	}
+}
+
+func TestPhone_MessageRules(t *testing.T) {
+	t.Run("a phone without an auto reply has no rules", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		phone := &Phone{ID: uuid.New(), PhoneNumber: "+18005550199"}
+
+		// Act
+		rules := phone.MessageRules()
+
+		// Assert
+		assert.Empty(t, rules)
+	})
+
+	t.Run("the auto acknowledgement and the out of office reply are rules of the phone", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		ack, outOfOffice := "Received by {{contact}}", "We're closed until {{opens_on}} at {{opens_at}}"
+		phone := &Phone{
+			ID:                 uuid.New(),
+			PhoneNumber:        "+18005550199",
+			Timezone:           "UTC",
+			AutoAckMessage:     &ack,
+			OutOfOfficeMessage: &outOfOffice,
+			BusinessHours:      PhoneBusinessHoursSchedule{{Day: "tuesday", Start: "09:00", End: "17:00"}},
+		}
+		timestamp := time.Date(2022, 6, 6, 20, 0, 0, 0, time.UTC)
+
+		// Act
+		rules := phone.MessageRules()
+
+		// Assert
+		assert.Equal(t, 2, len(rules))
+		assert.Equal(t, &phone.PhoneNumber, rules[0].Recipient)
+		assert.Equal(t, "Received by +18005550100", rules[0].ReplyText("+18005550100", timestamp))
+		assert.Equal(t, "We're closed until Tuesday at 09:00", rules[1].ReplyText("+18005550100", timestamp))
+	})
+}
+
+func TestMessageRule_ReplyMessageID(t *testing.T) {
+	t.Run("the reply of a rule is derived from the received message", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		rule := &MessageRule{ID: uuid.New()}
+		timestamp := time.Date(2022, 6, 6, 20, 0, 0, 0, time.UTC)
+
+		// Act
+		first := rule.ReplyMessageID(uuid.New(), "+18005550100", timestamp)
+		second := rule.ReplyMessageID(uuid.New(), "+18005550100", timestamp)
+
+		// Assert
+		assert.NotEqual(t, first, second)
+	})
+
+	t.Run("the out of office reply is sent once a day to each contact", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		outOfOffice := "We're closed"
+		phone := &Phone{ID: uuid.New(), Timezone: "UTC", OutOfOfficeMessage: &outOfOffice}
+		rule := phone.MessageRules()[0]
+		timestamp := time.Date(2022, 6, 6, 20, 0, 0, 0, time.UTC)
+
+		// Act
+		first := rule.ReplyMessageID(uuid.New(), "+18005550100", timestamp)
+		second := rule.ReplyMessageID(uuid.New(), "+18005550100", timestamp.Add(time.Hour))
+		nextDay := rule.ReplyMessageID(uuid.New(), "+18005550100", timestamp.Add(24*time.Hour))
+
+		// Assert
+		assert.Equal(t, first, second)
+		assert.NotEqual(t, first, nextDay)
+	})
+}
@@ -1,19 +1,28 @@
 package entities
 
 import (
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 // Phone represents an android phone which has installed the http sms app
 type Phone struct {
 	ID                uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
-	UserID            UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	UserID            UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC" gorm:"uniqueIndex:idx_phones__user_id__external_id,priority:1;uniqueIndex:idx_phones__user_id__alphanumeric_sender_id,priority:1"`
 	FcmToken          *string   `json:"fcm_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzd....."`
 	PhoneNumber       string    `json:"phone_number" example:"+18005550199"`
 	MessagesPerMinute uint      `json:"messages_per_minute" example:"1"`
 	SIM               SIM       `json:"sim" gorm:"default:SIM1"`
+
+	// Type is android when the messages are sent by the httpSMS app and virtual when they are posted to the VirtualURL
+	Type PhoneType `json:"type" example:"android" gorm:"default:android" swaggertype:"string"`
+
+	// VirtualURL is the URL which receives the outgoing messages of a virtual phone, it is nil for android phones
+	VirtualURL *string `json:"virtual_url" example:"https://example.com/sms"`
 	// MaxSendAttempts determines how many times to retry sending an SMS message
 	MaxSendAttempts uint `json:"max_send_attempts" example:"2"`
 
@@ -22,8 +31,339 @@ type Phone struct {
 
 	MissedCallAutoReply *string `json:"missed_call_auto_reply" example:"This phone cannot receive calls. Please send an SMS instead."`
 
+	// AutoAckMessage is sent as a reply to every received message, {{contact}} is replaced with the phone number of the sender and no reply is sent when it is nil
+	AutoAckMessage *string `json:"auto_ack_message" example:"Message received from {{contact}}, thanks"`
+
+	// BatteryLowThreshold is the battery percentage below which the phone.battery_low event is fired
+	BatteryLowThreshold uint `json:"battery_low_threshold" example:"20"`
+
+	// ShortCodesDisabled is true when the SIM cannot send messages to short codes e.g. 12345
+	ShortCodesDisabled bool `json:"short_codes_disabled" example:"false" gorm:"default:false"`
+
+	// RcsEnabled is true when the messaging app of the phone has RCS turned on so that it can send reactions to messages
+	RcsEnabled bool `json:"rcs_enabled" example:"true" gorm:"default:false"`
+
+	// AlphanumericSenderID is the sender ID e.g. MyBrand which is configured for the SIM by the carrier, it is unique for each user and it is nil when the SIM does not support alphanumeric senders.
+	// It only selects the phone which sends a message with this sender ID, the android app cannot set the sender ID of a message so the carrier of the SIM must apply it.
+	AlphanumericSenderID *string `json:"alphanumeric_sender_id" example:"MyBrand" gorm:"uniqueIndex:idx_phones__user_id__alphanumeric_sender_id,priority:2"`
+
+	// MaxSegments overrides the maximum number of SMS segments of the user's plan for special cases, it is nil when the plan limit applies
+	MaxSegments *uint `json:"max_segments" example:"3"`
+
 	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// DailyQuota is the maximum number of messages the phone can send per day, there is no limit when it is 0
+	DailyQuota uint `json:"daily_quota" example:"100" gorm:"default:0"`
+
+	// DailyQuotaTimezone is the timezone used to reset DailySent at midnight
+	DailyQuotaTimezone string `json:"daily_quota_timezone" example:"Europe/Helsinki" gorm:"default:UTC"`
+
+	// DailySent is the number of messages charged to the daily quota on DailySentDate
+	DailySent uint `json:"daily_sent" example:"12" gorm:"-"`
+
+	// DailySentDate is the current date in DailyQuotaTimezone which DailySent is counted on
+	DailySentDate string `json:"daily_sent_date" example:"2022-06-05" gorm:"-"`
+
+	// Group is an optional label used to organize phones in a fleet e.g. warehouse-1
+	Group *string `json:"group" example:"warehouse-1" gorm:"index:idx_phones__user_id__group"`
+
+	// GroupPriority is used by the failover strategy of the group, the online phone with the highest priority sends the messages of the group
+	GroupPriority uint `json:"group_priority" example:"10" gorm:"default:0"`
+
+	// DailyQuotaRemaining is the number of messages the phone can still send today, it is nil when there is no DailyQuota
+	DailyQuotaRemaining *uint `json:"daily_quota_remaining" example:"88" gorm:"-"`
+
+	// HeartbeatIntervalSeconds is the expected duration in seconds between the heartbeats of the phone, the default of 15 minutes is used when it is 0
+	HeartbeatIntervalSeconds uint `json:"heartbeat_interval_seconds" example:"900" gorm:"default:0"`
+
+	// SecondsUntilOffline is the number of seconds until the phone is considered offline if it does not send a heartbeat, it is nil when the phone has never sent a heartbeat
+	SecondsUntilOffline *int64 `json:"seconds_until_offline" example:"2700" gorm:"-"`
+
+	// FcmTokenRefreshedAt is the time when the phone last sent a new FCM token
+	FcmTokenRefreshedAt *time.Time `json:"fcm_token_refreshed_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// FcmTokenInvalidAt is the time when FCM rejected the token as unregistered or invalid, no notifications are sent to the phone until it sends a new token
+	FcmTokenInvalidAt *time.Time `json:"fcm_token_invalid_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// MaxConcurrentSends is the maximum number of messages which are pushed to the phone at the same time, the default of 10 is used when it is 0
+	MaxConcurrentSends uint `json:"max_concurrent_sends" example:"10" gorm:"default:0"`
+
+	// SendsInFlight is the number of messages which are currently being pushed to the phone
+	SendsInFlight uint `json:"sends_in_flight" example:"3" gorm:"-"`
+
+	// FcmVerificationKey is the base64 encoded ed25519 public key which verifies the signature of the push notifications, it is nil when the notifications are not signed
+	FcmVerificationKey *string `json:"fcm_verification_key" example:"Gb9ECWmEzf6FQbrBZ9w7lshQhqowtrbLDFw4rXAxZuE=" gorm:"-"`
+
+	// FcmKeyVersion is the version of the key which signs the push notifications of the phone, it changes when a rotated key is promoted
+	FcmKeyVersion uint `json:"fcm_key_version" example:"1" gorm:"default:0"`
+
+	// FcmNextKeyVersion is the version of the rotated key which replaces the current key when it is promoted, it is nil when the key is not being rotated
+	FcmNextKeyVersion *uint `json:"fcm_next_key_version" example:"2"`
+
+	// FcmNextVerificationKey is the public key of the rotated key, the phone should accept the signatures of both keys until the rotated key is promoted
+	FcmNextVerificationKey *string `json:"fcm_next_verification_key" example:"q2Xb7Jm0dG6o1Ztg3mJXqkHPRyQwztrOe0m9bFhNgXQ=" gorm:"-"`
+
+	// DeliveryReportTimeoutSeconds is the duration in seconds after a message is sent when it is marked as failed if there is no delivery report, it is disabled when it is 0
+	DeliveryReportTimeoutSeconds uint `json:"delivery_report_timeout_seconds" example:"86400" gorm:"default:0"`
+
+	// LastSentAt and LastReceivedAt are the times of the latest message sent and received by the phone, they are only written by the message paths so that saving a phone does not overwrite them
+	LastSentAt     *time.Time `json:"last_sent_at" example:"2022-06-05T14:26:10.303278+03:00" gorm:"<-:false"`
+	LastReceivedAt *time.Time `json:"last_received_at" example:"2022-06-05T14:26:10.303278+03:00" gorm:"<-:false"`
+
+	// LastControlType and LastControlSentAt record the latest control notification sent to the phone, they are only written when a control notification is sent
+	LastControlType   *PhoneControlType `json:"last_control_type" example:"app_update" gorm:"<-:false" swaggertype:"string"`
+	LastControlSentAt *time.Time        `json:"last_control_sent_at" example:"2022-06-05T14:26:10.303278+03:00" gorm:"<-:false"`
+
+	// LastError is the latest error reported by the phone when it failed to send a message e.g. SIM not ready, it is cleared when the phone sends a message
+	LastError   *string    `json:"last_error" example:"RESULT_ERROR_NO_SERVICE" gorm:"<-:false"`
+	LastErrorAt *time.Time `json:"last_error_at" example:"2022-06-05T14:26:10.303278+03:00" gorm:"<-:false"`
+
+	// AllowedRecipients are the only phone numbers which the phone can send messages to, the phone can send to any number when it is empty
+	AllowedRecipients pq.StringArray `json:"allowed_recipients" example:"[+18005550100]" gorm:"type:text[]" swaggertype:"array,string"`
+
+	// Timezone is the IANA timezone of the phone which is used for scheduled messages whose send time is in the timezone of the phone and for the BusinessHours
+	Timezone string `json:"timezone" example:"Europe/Helsinki" gorm:"default:UTC"`
+
+	// BusinessHours is the weekly schedule of the phone, the OutOfOfficeMessage is sent to the messages which are received outside the business hours
+	BusinessHours PhoneBusinessHoursSchedule `json:"business_hours" swaggertype:"array,object"`
+
+	// PausedAt is the time when the phone was taken out of rotation e.g. for maintenance, the phone sends no messages while it is paused but it still sends heartbeats
+	PausedAt *time.Time `json:"paused_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// PausedRejectsMessages is true when the messages sent from the phone while it is paused are rejected instead of being queued until the phone is resumed
+	PausedRejectsMessages bool `json:"paused_rejects_messages" example:"false" gorm:"default:false"`
+
+	// OutOfOfficeMessage is sent at most once a day to each contact outside the BusinessHours, {{contact}}, {{opens_on}} and {{opens_at}} are replaced with the phone number of the sender and the day and time of the next opening
+	OutOfOfficeMessage *string `json:"out_of_office_message" example:"We're closed, we'll reply on {{opens_on}} at {{opens_at}}"`
+
+	// ExternalID is an optional ID of the phone in an external system e.g. an inventory, it is unique for each user
+	ExternalID *string `json:"external_id" example:"inventory-1042" gorm:"uniqueIndex:idx_phones__user_id__external_id,priority:2"`
+
+	// Signature is appended on a new line to the content of the messages sent from the phone, it is not appended to encrypted messages
+	Signature *string `json:"signature" example:"- Sent via httpSMS"`
+}
+
+// IsVirtual checks if the messages of the phone are posted to its VirtualURL instead of an android device
+func (phone *Phone) IsVirtual() bool {
+	return phone.Type == PhoneTypeVirtual
+}
+
+// IsPaused checks if the phone has been taken out of rotation
+func (phone *Phone) IsPaused() bool {
+	return phone.PausedAt != nil
+}
+
+// Location returns the timezone of the phone, UTC is used when the timezone is not valid
+func (phone *Phone) Location() *time.Location {
+	location, err := time.LoadLocation(phone.Timezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return location
+}
+
+// AllowsRecipient checks if the phone can send a message to the contact
+func (phone *Phone) AllowsRecipient(contact string) bool {
+	return len(phone.AllowedRecipients) == 0 || slices.Contains(phone.AllowedRecipients, contact)
+}
+
+// phoneAutoAckContactPlaceholder is replaced with the phone number of the sender in the AutoAckMessage of a phone
+const phoneAutoAckContactPlaceholder = "{{contact}}"
+
+// MessageRules returns the AutoAckMessage and the OutOfOfficeMessage of the phone as the rules which are evaluated before the MessageRule of the user
+func (phone *Phone) MessageRules() []*MessageRule {
+	var rules []*MessageRule
+	if phone.AutoAckMessage != nil {
+		rules = append(rules, &MessageRule{
+			ID:           uuid.NewSHA1(phone.ID, []byte("auto-ack")),
+			UserID:       phone.UserID,
+			Name:         "Auto acknowledgement",
+			Enabled:      true,
+			Recipient:    &phone.PhoneNumber,
+			ReplyContent: phone.AutoAckMessage,
+			phone:        phone,
+		})
+	}
+
+	if phone.OutOfOfficeMessage != nil {
+		rules = append(rules, &MessageRule{
+			ID:           uuid.NewSHA1(phone.ID, []byte("out-of-office")),
+			UserID:       phone.UserID,
+			Name:         "Out of office",
+			Enabled:      true,
+			Recipient:    &phone.PhoneNumber,
+			ReplyContent: phone.OutOfOfficeMessage,
+			phone:        phone,
+			outOfOffice:  true,
+		})
+	}
+	return rules
+}
+
+const (
+	// phoneOutOfOfficeOpensOnPlaceholder is replaced with the day of the next opening of the phone in the OutOfOfficeMessage e.g. Monday
+	phoneOutOfOfficeOpensOnPlaceholder = "{{opens_on}}"
+
+	// phoneOutOfOfficeOpensAtPlaceholder is replaced with the time of the next opening of the phone in the OutOfOfficeMessage e.g. 09:00
+	phoneOutOfOfficeOpensAtPlaceholder = "{{opens_at}}"
+)
+
+// IsOpen checks if the timestamp is within the BusinessHours of the phone, the phone is never closed when it has no business hours
+func (phone *Phone) IsOpen(timestamp time.Time) bool {
+	if len(phone.BusinessHours) == 0 {
+		return true
+	}
+	return phone.BusinessHours.Contains(timestamp, phone.Location())
+}
+
+// NextOpening returns the time after the timestamp when the BusinessHours of the phone start next, it is nil when the schedule has no valid hours
+func (phone *Phone) NextOpening(timestamp time.Time) *time.Time {
+	local := timestamp.In(phone.Location())
+
+	var result *time.Time
+	for day := 0; day <= len(PhoneBusinessHoursDays) && result == nil; day++ {
+		date := local.AddDate(0, 0, day)
+		for _, hours := range phone.BusinessHours {
+			start, _, ok := hours.Minutes()
+			if !ok || hours.Day != PhoneBusinessHoursDays[date.Weekday()] {
+				continue
+			}
+
+			opening := time.Date(date.Year(), date.Month(), date.Day(), start/60, start%60, 0, 0, local.Location())
+			if opening.After(local) && (result == nil || opening.Before(*result)) {
+				result = &opening
+			}
+		}
+	}
+	return result
+}
+
+// OutOfOfficeContent returns the OutOfOfficeMessage of the phone for a message received from the contact at the timestamp
+func (phone *Phone) OutOfOfficeContent(contact string, timestamp time.Time) string {
+	if phone.OutOfOfficeMessage == nil {
+		return ""
+	}
+
+	opensOn, opensAt := "", ""
+	if opening := phone.NextOpening(timestamp); opening != nil {
+		opensOn, opensAt = opening.Format("Monday"), opening.Format("15:04")
+	}
+
+	return strings.NewReplacer(
+		phoneAutoAckContactPlaceholder, contact,
+		phoneOutOfOfficeOpensOnPlaceholder, opensOn,
+		phoneOutOfOfficeOpensAtPlaceholder, opensAt,
+	).Replace(*phone.OutOfOfficeMessage)
+}
+
+// SignedContent returns the content of an outgoing message with the Signature of the phone appended on a new line
+func (phone *Phone) SignedContent(content string) string {
+	if phone.Signature == nil || *phone.Signature == "" {
+		return content
+	}
+	return content + "\n" + *phone.Signature
+}
+
+// DeliveryReportTimeout is the duration after a message is sent when it is marked as failed if there is no delivery report
+func (phone *Phone) DeliveryReportTimeout() time.Duration {
+	return time.Duration(phone.DeliveryReportTimeoutSeconds) * time.Second
+}
+
+const (
+	// phoneHeartbeatGracePeriod is added to the heartbeat interval so that a heartbeat which is a few seconds late is not considered missed
+	phoneHeartbeatGracePeriod = time.Minute
+
+	// phoneHeartbeatOfflineMultiplier is the number of heartbeat check intervals without a heartbeat after which the phone is considered offline
+	phoneHeartbeatOfflineMultiplier = 4
+)
+
+// HeartbeatIntervalSecondsSanitized returns the heartbeat interval seconds with a default of 15 minutes
+func (phone *Phone) HeartbeatIntervalSecondsSanitized() uint {
+	if phone.HeartbeatIntervalSeconds == 0 {
+		return 15 * 60 // 15 minutes
+	}
+	return phone.HeartbeatIntervalSeconds
+}
+
+// HeartbeatCheckInterval returns the duration after the last heartbeat when the heartbeat is considered missed
+func (phone *Phone) HeartbeatCheckInterval() time.Duration {
+	return time.Duration(phone.HeartbeatIntervalSecondsSanitized())*time.Second + phoneHeartbeatGracePeriod
+}
+
+// HeartbeatOfflineThreshold returns the duration after the last heartbeat when the phone is considered offline
+func (phone *Phone) HeartbeatOfflineThreshold() time.Duration {
+	return phoneHeartbeatOfflineMultiplier * phone.HeartbeatCheckInterval()
+}
+
+// SetSecondsUntilOffline sets SecondsUntilOffline using the timestamp of the last heartbeat of the phone
+func (phone *Phone) SetSecondsUntilOffline(lastHeartbeat *time.Time, timestamp time.Time) *Phone {
+	phone.SecondsUntilOffline = nil
+	if lastHeartbeat == nil {
+		return phone
+	}
+
+	seconds := int64(lastHeartbeat.Add(phone.HeartbeatOfflineThreshold()).Sub(timestamp).Seconds())
+	if seconds < 0 {
+		seconds = 0
+	}
+	phone.SecondsUntilOffline = &seconds
+	return phone
+}
+
+// IsOnline checks if the phone has sent a heartbeat recently, SetSecondsUntilOffline must be called first
+func (phone *Phone) IsOnline() bool {
+	return phone.SecondsUntilOffline != nil && *phone.SecondsUntilOffline > 0
+}
+
+// MaxConcurrentSendsSanitized returns the maximum number of concurrent sends with a default of 10
+func (phone *Phone) MaxConcurrentSendsSanitized() uint {
+	if phone.MaxConcurrentSends == 0 {
+		return 10
+	}
+	return phone.MaxConcurrentSends
+}
+
+// HasValidFcmToken returns true when the phone has an FCM token which has not been rejected by FCM
+func (phone *Phone) HasValidFcmToken() bool {
+	return phone.FcmToken != nil && phone.FcmTokenInvalidAt == nil
+}
+
+// DailyQuotaDate returns the current date in the DailyQuotaTimezone of the phone
+func (phone *Phone) DailyQuotaDate(timestamp time.Time) string {
+	location, err := time.LoadLocation(phone.DailyQuotaTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+	return timestamp.In(location).Format(time.DateOnly)
+}
+
+// SetDailyQuotaRemaining sets DailyQuotaRemaining using the number of messages sent on the current date
+func (phone *Phone) SetDailyQuotaRemaining(timestamp time.Time) *Phone {
+	phone.DailyQuotaRemaining = nil
+	if phone.DailyQuota == 0 {
+		return phone
+	}
+
+	sent := phone.DailySent
+	if phone.DailySentDate != phone.DailyQuotaDate(timestamp) {
+		sent = 0
+	}
+
+	remaining := uint(0)
+	if sent < phone.DailyQuota {
+		remaining = phone.DailyQuota - sent
+	}
+	phone.DailyQuotaRemaining = &remaining
+	return phone
+}
+
+// MaxSegmentsOrDefault returns the MaxSegments override of the phone or the maximum segments of the plan
+func (phone *Phone) MaxSegmentsOrDefault(subscription SubscriptionName) uint {
+	if phone.MaxSegments != nil {
+		return *phone.MaxSegments
+	}
+	return subscription.MaxSegments()
 }
 
 // MessageExpirationDuration returns the message expiration as time.Duration
@@ -39,6 +379,14 @@ func (phone *Phone) MessageExpirationSecondsSanitized() uint {
 	return phone.MessageExpirationSeconds
 }
 
+// BatteryLowThresholdSanitized returns the battery low threshold replacing 0 with 20
+func (phone *Phone) BatteryLowThresholdSanitized() uint {
+	if phone.BatteryLowThreshold == 0 {
+		return 20
+	}
+	return phone.BatteryLowThreshold
+}
+
 // MaxSendAttemptsSanitized returns the max send attempts replacing 0 with 2
 func (phone *Phone) MaxSendAttemptsSanitized() uint {
 	if phone.MaxSendAttempts == 0 {
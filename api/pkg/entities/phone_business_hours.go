@@ -0,0 +1,86 @@
+package entities
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// PhoneBusinessHoursDays are the days of PhoneBusinessHours indexed by time.Weekday
+var PhoneBusinessHoursDays = []string{"sunday", "monday", "tuesday", "wednesday", "thursday", "friday", "saturday"}
+
+// PhoneBusinessHours are the opening hours of a phone on a day of the week, Start and End are in the Timezone of the phone and End is excluded
+type PhoneBusinessHours struct {
+	Day   string `json:"day" example:"monday"`
+	Start string `json:"start" example:"09:00"`
+	End   string `json:"end" example:"17:00"`
+}
+
+// Minutes returns the minutes after midnight of Start and End, ok is false when they are not valid times e.g. 09:00 or End is not after Start
+func (hours PhoneBusinessHours) Minutes() (start int, end int, ok bool) {
+	start, startErr := phoneBusinessHoursMinutes(hours.Start)
+	end, endErr := phoneBusinessHoursMinutes(hours.End)
+	return start, end, startErr == nil && endErr == nil && start < end && slices.Contains(PhoneBusinessHoursDays, hours.Day)
+}
+
+// phoneBusinessHoursMinutes parses a time e.g. 09:30 into the minutes after midnight, 24:00 is the end of the day
+func phoneBusinessHoursMinutes(value string) (int, error) {
+	if value == "24:00" {
+		return 24 * 60, nil
+	}
+
+	timestamp, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return timestamp.Hour()*60 + timestamp.Minute(), nil
+}
+
+// PhoneBusinessHoursSchedule is the weekly schedule of a phone, a day can have multiple opening hours and the phone is closed on the days which are not in the schedule
+type PhoneBusinessHoursSchedule []PhoneBusinessHours
+
+// Contains checks if the timestamp is within one of the opening hours of the schedule in the location
+func (schedule PhoneBusinessHoursSchedule) Contains(timestamp time.Time, location *time.Location) bool {
+	local := timestamp.In(location)
+	minutes := local.Hour()*60 + local.Minute()
+	for _, hours := range schedule {
+		start, end, ok := hours.Minutes()
+		if ok && hours.Day == PhoneBusinessHoursDays[local.Weekday()] && minutes >= start && minutes < end {
+			return true
+		}
+	}
+	return false
+}
+
+// Value implements the driver.Valuer interface
+func (schedule PhoneBusinessHoursSchedule) Value() (driver.Value, error) {
+	if schedule == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(schedule)
+	return string(data), err
+}
+
+// Scan implements the sql.Scanner interface
+func (schedule *PhoneBusinessHoursSchedule) Scan(value any) error {
+	var data []byte
+	switch v := value.(type) {
+	case nil:
+		*schedule = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("cannot scan [%T] into [%T]", value, schedule)
+	}
+	return json.Unmarshal(data, schedule)
+}
+
+// GormDataType is the data type of PhoneBusinessHoursSchedule in the database
+func (PhoneBusinessHoursSchedule) GormDataType() string {
+	return "jsonb"
+}
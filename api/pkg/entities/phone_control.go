@@ -0,0 +1,20 @@
+package entities
+
+// PhoneControlType is the type of a control notification which the httpSMS app interprets instead of sending an SMS
+type PhoneControlType string
+
+const (
+	// PhoneControlTypeAppUpdate tells the phone that a new version of the httpSMS app is available
+	PhoneControlTypeAppUpdate = PhoneControlType("app_update")
+
+	// PhoneControlTypeConfigRefresh tells the phone to fetch its settings from the API again
+	PhoneControlTypeConfigRefresh = PhoneControlType("config_refresh")
+)
+
+// PhoneControlTypes are all the types of control notifications
+var PhoneControlTypes = []PhoneControlType{PhoneControlTypeAppUpdate, PhoneControlTypeConfigRefresh}
+
+// String converts the PhoneControlType into a string
+func (controlType PhoneControlType) String() string {
+	return string(controlType)
+}
@@ -0,0 +1,20 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneDailyUsage counts the messages which are charged to the daily quota of an entities.Phone on a date
+type PhoneDailyUsage struct {
+	PhoneID uuid.UUID `json:"phone_id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// Date is the date in the DailyQuotaTimezone of the phone when the messages are sent
+	Date string `json:"date" gorm:"primaryKey" example:"2022-06-05"`
+
+	UserID    UserID    `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Sent      uint      `json:"sent" example:"12"`
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
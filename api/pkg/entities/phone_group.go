@@ -0,0 +1,27 @@
+package entities
+
+import "time"
+
+// PhoneGroupStrategy determines which phone of a group sends a message
+type PhoneGroupStrategy string
+
+const (
+	// PhoneGroupStrategyRoundRobin sends each message from the online phone which has not sent a message for the longest time
+	PhoneGroupStrategyRoundRobin = PhoneGroupStrategy("round_robin")
+
+	// PhoneGroupStrategyFailover sends every message from the online phone with the highest group priority
+	PhoneGroupStrategyFailover = PhoneGroupStrategy("failover")
+)
+
+// PhoneGroup stores the settings of the phones of a user which have the same Phone.Group, the PhoneGroupStrategyRoundRobin is used when a group has no settings
+type PhoneGroup struct {
+	UserID   UserID             `json:"user_id" gorm:"primaryKey" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Name     string             `json:"name" gorm:"primaryKey" example:"warehouse-1"`
+	Strategy PhoneGroupStrategy `json:"strategy" example:"failover"`
+
+	// ProviderFallback is true when the messages of the group are sent with the third-party SMS provider of the user if no phone picks them up within the timeout of the provider
+	ProviderFallback bool `json:"provider_fallback" example:"false" gorm:"default:false"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
@@ -13,6 +13,10 @@ const (
 	PhoneNotificationStatusSent = "sent"
 	// PhoneNotificationStatusFailed is the status when a notification could not be sent.
 	PhoneNotificationStatusFailed = "failed"
+	// PhoneNotificationStatusCancelled is the status when the message of a notification was cancelled before the notification was sent
+	PhoneNotificationStatusCancelled = "cancelled"
+	// PhoneNotificationStatusPaused is the status when the phone was paused before the notification was sent, the notification is scheduled again when the phone is resumed
+	PhoneNotificationStatusPaused = "paused"
 )
 
 // PhoneNotificationStatus is the status of a phone notification
@@ -29,3 +33,10 @@ type PhoneNotification struct {
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
+
+// PhoneNotificationQueuePosition is the position of the pending notification of a message in the queue of its phone
+type PhoneNotificationQueuePosition struct {
+	MessageID   uuid.UUID
+	Position    uint
+	ScheduledAt time.Time
+}
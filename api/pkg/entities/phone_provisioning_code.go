@@ -0,0 +1,23 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PhoneProvisioningCode is a short-lived code which is exchanged once by the android app for the API key of the user and the registration of the phone
+type PhoneProvisioningCode struct {
+	ID     uuid.UUID `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID UserID    `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// CodeHash is the SHA-256 hash of the code, the code itself is never stored
+	CodeHash string `json:"-" gorm:"uniqueIndex"`
+
+	// Code is only returned when the provisioning code is created
+	Code string `json:"code" gorm:"-" example:"K7QM-2XPD"`
+
+	ExpiresAt time.Time  `json:"expires_at" example:"2022-06-05T14:36:09.279Z"`
+	UsedAt    *time.Time `json:"used_at" example:"2022-06-05T14:31:09.279Z"`
+	CreatedAt time.Time  `json:"created_at" example:"2022-06-05T14:26:09.279Z"`
+}
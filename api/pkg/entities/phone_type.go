@@ -0,0 +1,20 @@
+package entities
+
+// PhoneType is the type of the device which sends the messages of a phone
+type PhoneType string
+
+const (
+	// PhoneTypeAndroid is a phone which sends messages with the httpSMS android app
+	PhoneTypeAndroid = PhoneType("android")
+
+	// PhoneTypeVirtual is a phone which sends messages by posting them to its VirtualURL instead of an android device
+	PhoneTypeVirtual = PhoneType("virtual")
+)
+
+// PhoneTypes are all the types of phones
+var PhoneTypes = []PhoneType{PhoneTypeAndroid, PhoneTypeVirtual}
+
+// String converts the PhoneType into a string
+func (phoneType PhoneType) String() string {
+	return string(phoneType)
+}
@@ -0,0 +1,51 @@
+package entities
+
+import "time"
+
+// SmsProviderName is the name of a third-party SMS provider which sends the messages of a user when none of the phones can send them
+type SmsProviderName string
+
+const (
+	// SmsProviderTwilio sends messages with the Twilio messaging API
+	SmsProviderTwilio = SmsProviderName("twilio")
+
+	// SmsProviderVonage sends messages with the Vonage SMS API
+	SmsProviderVonage = SmsProviderName("vonage")
+)
+
+// SmsProviderNames are all the third-party SMS providers
+var SmsProviderNames = []SmsProviderName{SmsProviderTwilio, SmsProviderVonage}
+
+// String converts the SmsProviderName into a string
+func (name SmsProviderName) String() string {
+	return string(name)
+}
+
+// ProviderFallback stores the credentials of the third-party SMS provider which sends the messages of a user when no phone sends them within the timeout
+type ProviderFallback struct {
+	UserID   UserID          `json:"user_id" gorm:"primaryKey" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Provider SmsProviderName `json:"provider" example:"twilio" swaggertype:"string"`
+
+	// AccountID is the account SID of a Twilio account or the API key of a Vonage account
+	AccountID string `json:"account_id" example:"ACXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"`
+
+	// EncryptedAuthToken is the encrypted auth token of a Twilio account or the API secret of a Vonage account
+	EncryptedAuthToken string `json:"-"`
+
+	// From is the phone number or the alphanumeric sender ID which the provider uses to send the messages
+	From string `json:"from" example:"+18005550199"`
+
+	// TimeoutSeconds is the duration in seconds after a message is sent when it is sent with the provider if no phone has picked it up
+	TimeoutSeconds uint `json:"timeout_seconds" example:"300"`
+
+	// Enabled is false when the provider_fallback feature flag of the user is off, the messages are then never sent with the provider
+	Enabled bool `json:"enabled" example:"true" gorm:"-"`
+
+	CreatedAt time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// Timeout is the duration after a message is sent when it is sent with the provider if no phone has picked it up
+func (fallback *ProviderFallback) Timeout() time.Duration {
+	return time.Duration(fallback.TimeoutSeconds) * time.Second
+}
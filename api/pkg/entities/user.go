@@ -30,6 +30,44 @@ func (subscription SubscriptionName) Limit() uint {
 	}
 }
 
+// MaxSegments returns the maximum number of SMS segments in a message sent on a subscription
+func (subscription SubscriptionName) MaxSegments() uint {
+	switch subscription {
+	case SubscriptionNameFree, "":
+		return 1
+	default:
+		return 10
+	}
+}
+
+// MaxBulkRecipients returns the maximum number of recipients in a single bulk message file on a subscription
+func (subscription SubscriptionName) MaxBulkRecipients() int {
+	switch subscription {
+	case SubscriptionNameFree, "":
+		return 50
+	default:
+		return 10_000
+	}
+}
+
+// MaxPendingBulkJobs returns the maximum number of bulk message files which can be sending at the same time on a subscription
+func (subscription SubscriptionName) MaxPendingBulkJobs() int {
+	switch subscription {
+	case SubscriptionNameFree, "":
+		return 1
+	default:
+		return 5
+	}
+}
+
+// Plan returns the name of the subscription which is shown to the user
+func (subscription SubscriptionName) Plan() string {
+	if subscription == "" {
+		return string(SubscriptionNameFree)
+	}
+	return string(subscription)
+}
+
 // SubscriptionNameFree represents a free subscription
 const SubscriptionNameFree = SubscriptionName("free")
 
@@ -72,6 +110,7 @@ type User struct {
 	Email                            string           `json:"email" example:"name@email.com"`
 	APIKey                           string           `json:"api_key" gorm:"uniqueIndex:idx_users_api_key" example:"x-api-key"`
 	Timezone                         string           `json:"timezone" example:"Europe/Helsinki" gorm:"default:Africa/Accra"`
+	Locale                           string           `json:"locale" example:"en" gorm:"default:en"`
 	ActivePhoneID                    *uuid.UUID       `json:"active_phone_id" gorm:"type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
 	SubscriptionName                 SubscriptionName `json:"subscription_name" example:"free"`
 	SubscriptionID                   *string          `json:"subscription_id" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
@@ -83,6 +122,62 @@ type User struct {
 	NotificationHeartbeatEnabled     bool             `json:"notification_heartbeat_enabled" gorm:"default:true" example:"true"`
 	CreatedAt                        time.Time        `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt                        time.Time        `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// MessageCategoryRules override the default rules used to classify received messages
+	MessageCategoryRules MessageCategoryRules `json:"message_category_rules" gorm:"type:jsonb" swaggertype:"array,object"`
+
+	// LanguageDetectionEnabled detects the language of received messages, it is disabled by default because it adds latency
+	LanguageDetectionEnabled bool `json:"language_detection_enabled" gorm:"default:false" example:"false"`
+
+	// DefaultCountry is the ISO 3166-1 alpha-2 code of the country used to resolve the national numbers of sent messages
+	DefaultCountry *string `json:"default_country" example:"US"`
+
+	// ParentUserID is the ID of the main account of a sub-account, it is nil when the user is a main account
+	ParentUserID *UserID `json:"parent_user_id" gorm:"index:idx_users__parent_user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+
+	// Name is the label of a sub-account e.g. the name of the client of a reseller
+	Name *string `json:"name" example:"Acme Inc"`
+
+	// FeatureFlags are the new behaviors which are enabled for the user, they are set by an admin and are disabled by default
+	FeatureFlags UserFeatureFlags `json:"feature_flags" gorm:"type:jsonb" swaggertype:"object,boolean" example:"provider_fallback:true"`
+
+	// InboundContentMaxLength is the maximum number of characters in the content of a received message, there is no limit when it is 0
+	InboundContentMaxLength uint `json:"inbound_content_max_length" gorm:"default:0" example:"1600"`
+
+	// InboundContentLimitMode is truncate when the content of a received message above InboundContentMaxLength is truncated and reject when the message is rejected
+	InboundContentLimitMode InboundContentLimitMode `json:"inbound_content_limit_mode" gorm:"default:truncate" example:"truncate" swaggertype:"string"`
+
+	// GetSendEnabled allows the APIKey to send messages with GET /v1/messages/send?key= for devices which cannot send POST requests, it is disabled when the APIKey is rotated
+	GetSendEnabled bool `json:"get_send_enabled" gorm:"default:false" example:"false"`
+}
+
+// InboundContentLimitMode is how a received message is handled when its content is longer than the InboundContentMaxLength of the user
+type InboundContentLimitMode string
+
+const (
+	// InboundContentLimitModeTruncate stores the first InboundContentMaxLength characters of the content
+	InboundContentLimitModeTruncate = InboundContentLimitMode("truncate")
+
+	// InboundContentLimitModeReject does not store the message
+	InboundContentLimitModeReject = InboundContentLimitMode("reject")
+)
+
+// String gets the string representation of the InboundContentLimitMode
+func (mode InboundContentLimitMode) String() string {
+	return string(mode)
+}
+
+// IsSubAccount checks if the user is a sub-account of a main account
+func (user User) IsSubAccount() bool {
+	return user.ParentUserID != nil
+}
+
+// BillingUserID is the ID of the user whose plan and usage are used for the user, the usage of a sub-account is billed to its main account
+func (user User) BillingUserID() UserID {
+	if user.ParentUserID != nil {
+		return *user.ParentUserID
+	}
+	return user.ID
 }
 
 // IsOnProPlan checks if a user is on the pro plan
@@ -0,0 +1,50 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UssdSessionStatus is the status of a USSD session
+type UssdSessionStatus string
+
+const (
+	// UssdSessionStatusPending means the USSD request has been created but not pushed to the phone
+	UssdSessionStatusPending = UssdSessionStatus("pending")
+
+	// UssdSessionStatusSent means the USSD request has been pushed to the phone and we are waiting for the response
+	UssdSessionStatusSent = UssdSessionStatus("sent")
+
+	// UssdSessionStatusCompleted means the phone reported the final response of the USSD session
+	UssdSessionStatusCompleted = UssdSessionStatus("completed")
+
+	// UssdSessionStatusFailed means the USSD request could not be executed by the phone
+	UssdSessionStatusFailed = UssdSessionStatus("failed")
+)
+
+// UssdSession is a single-step USSD request e.g. *123# which is executed by an android phone, android cannot reply to an interactive USSD menu
+type UssdSession struct {
+	ID      uuid.UUID         `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	PhoneID uuid.UUID         `json:"phone_id" gorm:"index" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID  UserID            `json:"user_id" gorm:"index" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Owner   string            `json:"owner" example:"+18005550199"`
+	SIM     SIM               `json:"sim" example:"SIM1"`
+	Code    string            `json:"code" example:"*123#"`
+	Status  UssdSessionStatus `json:"status" example:"completed"`
+
+	// Request is the input which was sent to the phone
+	Request string `json:"request" example:"*123#"`
+
+	// Response is the response reported by the phone
+	Response *string `json:"response" example:"Your balance is $10.00"`
+
+	FailureReason *string   `json:"failure_reason" example:"USSD_RETURN_FAILURE"`
+	CreatedAt     time.Time `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt     time.Time `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// IsAwaitingResponse checks if the USSD session is waiting for a response from the phone
+func (session *UssdSession) IsAwaitingResponse() bool {
+	return session.Status == UssdSessionStatusSent
+}
@@ -1,12 +1,48 @@
 package entities
 
 import (
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
+const (
+	// WebhookDefaultTimeout is the timeout of a delivery when the webhook has no TimeoutSeconds
+	WebhookDefaultTimeout = 10 * time.Second
+
+	// WebhookMaxTimeoutSeconds is the maximum TimeoutSeconds of a webhook
+	WebhookMaxTimeoutSeconds = 30
+)
+
+// WebhookContentType is the encoding of the body of the requests which are sent to a webhook
+type WebhookContentType string
+
+const (
+	// WebhookContentTypeJSON sends the events as application/json
+	WebhookContentTypeJSON = WebhookContentType("json")
+
+	// WebhookContentTypeForm sends the events as application/x-www-form-urlencoded with the nested fields in brackets e.g. data[message_id]
+	WebhookContentTypeForm = WebhookContentType("form")
+)
+
+// WebhookContentTypes are all the supported content types of a webhook
+var WebhookContentTypes = []WebhookContentType{WebhookContentTypeJSON, WebhookContentTypeForm}
+
+// String converts the WebhookContentType into a string
+func (contentType WebhookContentType) String() string {
+	return string(contentType)
+}
+
+// MimeType is the value of the Content-Type header of the requests which are sent with the WebhookContentType
+func (contentType WebhookContentType) MimeType() string {
+	if contentType == WebhookContentTypeForm {
+		return "application/x-www-form-urlencoded"
+	}
+	return "application/json"
+}
+
 // Webhook stores the webhooks of a user
 type Webhook struct {
 	ID           uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
@@ -17,4 +53,84 @@ type Webhook struct {
 	Events       pq.StringArray `json:"events" example:"[message.phone.received]" gorm:"type:text[]" swaggertype:"array,string"`
 	CreatedAt    time.Time      `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
 	UpdatedAt    time.Time      `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// BatchSize is the maximum number of events sent in a single request, batching is disabled when it is less than 2
+	BatchSize uint `json:"batch_size" example:"10" gorm:"default:0"`
+
+	// BatchWindowSeconds is the maximum number of seconds an event is buffered before the batch is sent
+	BatchWindowSeconds uint `json:"batch_window_seconds" example:"5" gorm:"default:0"`
+
+	// Enabled is false when the webhook has been disabled after too many consecutive failed deliveries
+	Enabled bool `json:"enabled" example:"true" gorm:"default:true"`
+
+	// ConsecutiveFailures is the number of failed deliveries since the last successful delivery
+	ConsecutiveFailures uint `json:"consecutive_failures" example:"0" gorm:"default:0"`
+
+	// DisabledAt is the time when the webhook was automatically disabled
+	DisabledAt *time.Time `json:"disabled_at" example:"2022-06-05T14:26:10.303278+03:00"`
+
+	// EncryptedHeaders are the custom headers encrypted as JSON because the values may contain secrets
+	EncryptedHeaders string `json:"-" gorm:"default:''"`
+
+	// Headers are the custom headers which are sent with every delivery, they are decrypted from EncryptedHeaders and never returned by the API
+	Headers map[string]string `json:"-" gorm:"-"`
+
+	// HeaderNames are the names of the custom headers in alphabetical order, the values are secrets so they are not returned
+	HeaderNames []string `json:"header_names" example:"X-Api-Key" gorm:"-"`
+
+	// PhoneID scopes the webhook to the events of a single phone, the webhook receives the events of all the PhoneNumbers when it is nil
+	PhoneID *uuid.UUID `json:"phone_id" gorm:"type:uuid;index:idx_webhooks__phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// FieldMask are the only fields of the data of message events which are sent to the webhook, all the fields are sent when it is empty
+	FieldMask pq.StringArray `json:"field_mask" example:"[id,owner,contact,timestamp]" gorm:"type:text[]" swaggertype:"array,string"`
+
+	// TimeoutSeconds is the number of seconds to wait for the response of each delivery attempt, the default of 10 seconds is used when it is 0
+	TimeoutSeconds uint `json:"timeout_seconds" example:"10" gorm:"default:0"`
+
+	// ContentType is the encoding of the body of the requests which are sent to the webhook, it is either json or form
+	ContentType WebhookContentType `json:"content_type" example:"json" gorm:"default:json" swaggertype:"string"`
+
+	// EncryptedClientCertificate is the PEM encoded client certificate and private key encrypted as JSON, they are presented to the URL for mutual TLS
+	EncryptedClientCertificate string `json:"-" gorm:"default:''"`
+
+	// ClientCertificateExpiresAt is the expiry of the client certificate which is presented to the URL for mutual TLS, it is nil when the webhook has no client certificate
+	ClientCertificateExpiresAt *time.Time `json:"client_certificate_expires_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// SetHeaders sets the decrypted custom headers and their names
+func (webhook *Webhook) SetHeaders(headers map[string]string) {
+	webhook.Headers = headers
+	webhook.HeaderNames = make([]string, 0, len(headers))
+	for name := range headers {
+		webhook.HeaderNames = append(webhook.HeaderNames, name)
+	}
+	sort.Strings(webhook.HeaderNames)
+}
+
+// HasClientCertificate checks if a client certificate is presented to the URL of the webhook for mutual TLS
+func (webhook *Webhook) HasClientCertificate() bool {
+	return webhook.EncryptedClientCertificate != ""
+}
+
+// Timeout is the duration to wait for the response of each delivery attempt
+func (webhook *Webhook) Timeout() time.Duration {
+	if webhook.TimeoutSeconds == 0 {
+		return WebhookDefaultTimeout
+	}
+	return time.Duration(webhook.TimeoutSeconds) * time.Second
+}
+
+// IsBatched checks if events are sent to the webhook in batches
+func (webhook *Webhook) IsBatched() bool {
+	return webhook.BatchSize > 1
+}
+
+// HasFieldMask checks if only some fields of the message events are sent to the webhook
+func (webhook *Webhook) HasFieldMask() bool {
+	return len(webhook.FieldMask) > 0
+}
+
+// BatchWindow is the maximum duration an event is buffered before the batch is sent
+func (webhook *Webhook) BatchWindow() time.Duration {
+	return time.Duration(webhook.BatchWindowSeconds) * time.Second
 }
@@ -0,0 +1,27 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebhookDeliveryStat counts the deliveries to an entities.Webhook in a minute which have the same status code and latency bucket
+type WebhookDeliveryStat struct {
+	WebhookID   uuid.UUID `json:"webhook_id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	BucketStart time.Time `json:"bucket_start" gorm:"primaryKey;index:idx_webhook_delivery_stats__bucket_start" example:"2022-06-05T14:26:00+03:00"`
+
+	// StatusCode is the HTTP status code of the response, it is 0 when the webhook did not respond
+	StatusCode int `json:"status_code" gorm:"primaryKey;autoIncrement:false" example:"200"`
+
+	// LatencyMilliseconds is the upper bound of the latency bucket of the deliveries
+	LatencyMilliseconds int64 `json:"latency_milliseconds" gorm:"primaryKey;autoIncrement:false" example:"250"`
+
+	UserID     UserID `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Deliveries uint   `json:"deliveries" example:"12"`
+}
+
+// IsSuccessful checks if the webhook accepted the deliveries
+func (stat *WebhookDeliveryStat) IsSuccessful() bool {
+	return stat.StatusCode > 0 && stat.StatusCode < 400
+}
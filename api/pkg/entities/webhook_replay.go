@@ -0,0 +1,60 @@
+package entities
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// WebhookReplayStatus is the status of a webhook replay
+type WebhookReplayStatus string
+
+const (
+	// WebhookReplayStatusPending means the replay has been created but no event has been sent
+	WebhookReplayStatusPending = WebhookReplayStatus("pending")
+
+	// WebhookReplayStatusRunning means the events are being sent to the webhook
+	WebhookReplayStatusRunning = WebhookReplayStatus("running")
+
+	// WebhookReplayStatusCompleted means all the events have been sent to the webhook
+	WebhookReplayStatusCompleted = WebhookReplayStatus("completed")
+
+	// WebhookReplayStatusFailed means the replay stopped before all the events were sent e.g. the webhook was deleted
+	WebhookReplayStatusFailed = WebhookReplayStatus("failed")
+)
+
+// WebhookReplay re-sends the historical events in a time range to an entities.Webhook
+type WebhookReplay struct {
+	ID        uuid.UUID           `json:"id" gorm:"primaryKey;type:uuid;" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	WebhookID uuid.UUID           `json:"webhook_id" gorm:"index" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	UserID    UserID              `json:"user_id" example:"WB7DRDWrJZRGbYrv2CKGkqbzvqdC"`
+	Events    pq.StringArray      `json:"events" example:"[message.phone.received]" gorm:"type:text[]" swaggertype:"array,string"`
+	From      time.Time           `json:"from" example:"2022-06-05T08:26:02.302718+03:00"`
+	To        time.Time           `json:"to" example:"2022-06-05T14:26:02.302718+03:00"`
+	Status    WebhookReplayStatus `json:"status" example:"running"`
+
+	// TotalEvents is the number of events which match the time range and the events of the replay
+	TotalEvents uint `json:"total_events" example:"120"`
+
+	// DeliveredEvents is the number of events which were accepted by the webhook
+	DeliveredEvents uint `json:"delivered_events" example:"80"`
+
+	// FailedEvents is the number of events which could not be sent to the webhook
+	FailedEvents uint `json:"failed_events" example:"2"`
+
+	FailureReason *string    `json:"failure_reason" example:"the webhook was deleted"`
+	CompletedAt   *time.Time `json:"completed_at" example:"2022-06-05T14:26:10.303278+03:00"`
+	CreatedAt     time.Time  `json:"created_at" example:"2022-06-05T14:26:02.302718+03:00"`
+	UpdatedAt     time.Time  `json:"updated_at" example:"2022-06-05T14:26:10.303278+03:00"`
+}
+
+// ProcessedEvents is the number of events which have been sent to the webhook
+func (replay *WebhookReplay) ProcessedEvents() uint {
+	return replay.DeliveredEvents + replay.FailedEvents
+}
+
+// IsFinished checks if the replay has completed or failed
+func (replay *WebhookReplay) IsFinished() bool {
+	return replay.Status == WebhookReplayStatusCompleted || replay.Status == WebhookReplayStatusFailed
+}
@@ -0,0 +1,16 @@
+package events
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeBulkMessageJobRelease is emitted to release the next messages of an entities.BulkMessageJob
+const EventTypeBulkMessageJobRelease = "bulk-message.job.release"
+
+// BulkMessageJobReleasePayload is the payload of the EventTypeBulkMessageJobRelease event
+type BulkMessageJobReleasePayload struct {
+	JobID    uuid.UUID       `json:"job_id"`
+	UserID   entities.UserID `json:"user_id"`
+	Sequence uint            `json:"sequence"`
+}
@@ -0,0 +1,23 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeCallMissed is emitted when a phone call is missed by the android phone
+const EventTypeCallMissed = "call.missed"
+
+// CallMissedPayload is the payload of the EventTypeCallMissed event
+type CallMissedPayload struct {
+	CallEventID     uuid.UUID       `json:"call_event_id"`
+	PhoneID         uuid.UUID       `json:"phone_id"`
+	UserID          entities.UserID `json:"user_id"`
+	Owner           string          `json:"owner"`
+	Contact         string          `json:"contact"`
+	SIM             entities.SIM    `json:"sim"`
+	DurationSeconds uint            `json:"duration_seconds"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
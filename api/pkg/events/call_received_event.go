@@ -0,0 +1,23 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeCallReceived is emitted when a phone call is received by the android phone
+const EventTypeCallReceived = "call.received"
+
+// CallReceivedPayload is the payload of the EventTypeCallReceived event
+type CallReceivedPayload struct {
+	CallEventID     uuid.UUID       `json:"call_event_id"`
+	PhoneID         uuid.UUID       `json:"phone_id"`
+	UserID          entities.UserID `json:"user_id"`
+	Owner           string          `json:"owner"`
+	Contact         string          `json:"contact"`
+	SIM             entities.SIM    `json:"sim"`
+	DurationSeconds uint            `json:"duration_seconds"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
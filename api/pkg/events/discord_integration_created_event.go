@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeDiscordIntegrationCreated is emitted when a discord integration is created
+const EventTypeDiscordIntegrationCreated = "discord_integration.created"
+
+// DiscordIntegrationCreatedPayload is the payload of the EventTypeDiscordIntegrationCreated event, it never contains the bot token
+type DiscordIntegrationCreatedPayload struct {
+	IntegrationID uuid.UUID       `json:"integration_id"`
+	UserID        entities.UserID `json:"user_id"`
+	Name          string          `json:"name"`
+	ServerID      string          `json:"server_id"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
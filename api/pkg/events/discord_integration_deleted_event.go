@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeDiscordIntegrationDeleted is emitted when a discord integration is deleted
+const EventTypeDiscordIntegrationDeleted = "discord_integration.deleted"
+
+// DiscordIntegrationDeletedPayload is the payload of the EventTypeDiscordIntegrationDeleted event, it never contains the bot token
+type DiscordIntegrationDeletedPayload struct {
+	IntegrationID uuid.UUID       `json:"integration_id"`
+	UserID        entities.UserID `json:"user_id"`
+	Name          string          `json:"name"`
+	ServerID      string          `json:"server_id"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeDiscordIntegrationUpdated is emitted when a discord integration is updated
+const EventTypeDiscordIntegrationUpdated = "discord_integration.updated"
+
+// DiscordIntegrationUpdatedPayload is the payload of the EventTypeDiscordIntegrationUpdated event, it never contains the bot token
+type DiscordIntegrationUpdatedPayload struct {
+	IntegrationID uuid.UUID       `json:"integration_id"`
+	UserID        entities.UserID `json:"user_id"`
+	Name          string          `json:"name"`
+	ServerID      string          `json:"server_id"`
+	Timestamp     time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeIntegrationCreated is emitted when an integration e.g. a webhook or a discord server is created
+const EventTypeIntegrationCreated = "integration.created"
+
+// IntegrationCreatedPayload is the payload of the EventTypeIntegrationCreated event
+type IntegrationCreatedPayload struct {
+	IntegrationID   uuid.UUID       `json:"integration_id"`
+	IntegrationType string          `json:"integration_type"`
+	UserID          entities.UserID `json:"user_id"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeIntegrationDeleted is emitted when an integration e.g. a webhook or a discord server is deleted
+const EventTypeIntegrationDeleted = "integration.deleted"
+
+// IntegrationDeletedPayload is the payload of the EventTypeIntegrationDeleted event
+type IntegrationDeletedPayload struct {
+	IntegrationID   uuid.UUID       `json:"integration_id"`
+	IntegrationType string          `json:"integration_type"`
+	UserID          entities.UserID `json:"user_id"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
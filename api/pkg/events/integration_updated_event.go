@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeIntegrationUpdated is emitted when an integration e.g. a webhook or a discord server is updated
+const EventTypeIntegrationUpdated = "integration.updated"
+
+// IntegrationUpdatedPayload is the payload of the EventTypeIntegrationUpdated event
+type IntegrationUpdatedPayload struct {
+	IntegrationID   uuid.UUID       `json:"integration_id"`
+	IntegrationType string          `json:"integration_type"`
+	UserID          entities.UserID `json:"user_id"`
+	Timestamp       time.Time       `json:"timestamp"`
+}
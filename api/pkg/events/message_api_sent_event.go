@@ -13,15 +13,29 @@ const EventTypeMessageAPISent = "message.api.sent"
 
 // MessageAPISentPayload is the payload of the EventTypeMessageSent event
 type MessageAPISentPayload struct {
-	MessageID         uuid.UUID       `json:"message_id"`
-	UserID            entities.UserID `json:"user_id"`
-	Owner             string          `json:"owner"`
-	RequestID         *string         `json:"request_id"`
-	MaxSendAttempts   uint            `json:"max_send_attempts"`
-	Contact           string          `json:"contact"`
-	ScheduledSendTime *time.Time      `json:"scheduled_send_time"`
-	RequestReceivedAt time.Time       `json:"request_received_at"`
-	Content           string          `json:"content"`
-	Encrypted         bool            `json:"encrypted"`
-	SIM               entities.SIM    `json:"sim"`
+	MessageID         uuid.UUID                `json:"message_id"`
+	UserID            entities.UserID          `json:"user_id"`
+	Owner             string                   `json:"owner"`
+	SenderID          *string                  `json:"sender_id"`
+	RequestID         *string                  `json:"request_id"`
+	MaxSendAttempts   uint                     `json:"max_send_attempts"`
+	Contact           string                   `json:"contact"`
+	ScheduledSendTime *time.Time               `json:"scheduled_send_time"`
+	RequestReceivedAt time.Time                `json:"request_received_at"`
+	Content           string                   `json:"content"`
+	Metadata          entities.MessageMetadata `json:"metadata"`
+	Encrypted         bool                     `json:"encrypted"`
+	SIM               entities.SIM             `json:"sim"`
+	ValidityPeriod    *time.Duration           `json:"validity_period"`
+	InReplyTo         *uuid.UUID               `json:"in_reply_to"`
+	GroupID           *uuid.UUID               `json:"group_id"`
+
+	// PhoneGroup is the group of phones which the Owner was selected from, it is nil when the message is sent from a single phone
+	PhoneGroup *string `json:"phone_group"`
+
+	// CountryRoute is the country of the route which selected the Owner, it is nil when the message is sent with a from number
+	CountryRoute *string `json:"country_route"`
+
+	// ProviderFallback is true when the message is sent with the third-party SMS provider of the user if no phone picks it up
+	ProviderFallback bool `json:"provider_fallback"`
 }
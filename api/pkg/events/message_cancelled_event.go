@@ -0,0 +1,26 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageCancelled is emitted when the user cancels a message before it is picked up by a phone
+const EventTypeMessageCancelled = "message.cancelled"
+
+// MessageCancelledPayload is the payload of the EventTypeMessageCancelled event
+type MessageCancelledPayload struct {
+	MessageID uuid.UUID                `json:"message_id"`
+	Owner     string                   `json:"owner"`
+	Contact   string                   `json:"contact"`
+	RequestID *string                  `json:"request_id"`
+	UserID    entities.UserID          `json:"user_id"`
+	Encrypted bool                     `json:"encrypted"`
+	Timestamp time.Time                `json:"timestamp"`
+	Content   string                   `json:"content"`
+	Metadata  entities.MessageMetadata `json:"metadata"`
+	SIM       entities.SIM             `json:"sim"`
+}
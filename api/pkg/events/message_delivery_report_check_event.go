@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageDeliveryReportCheck is emitted to trigger checking if a sent message has received a delivery report
+const EventTypeMessageDeliveryReportCheck = "message.delivery_report.check"
+
+// MessageDeliveryReportCheckPayload is the payload of the EventTypeMessageDeliveryReportCheck event
+type MessageDeliveryReportCheckPayload struct {
+	MessageID   uuid.UUID       `json:"message_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	UserID      entities.UserID `json:"user_id"`
+}
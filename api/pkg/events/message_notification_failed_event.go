@@ -18,5 +18,6 @@ type MessageNotificationFailedPayload struct {
 	NotificationID       uuid.UUID       `json:"notification_id"`
 	PhoneID              uuid.UUID       `json:"phone_id"`
 	ErrorMessage         string          `json:"error_message"`
+	ErrorCode            string          `json:"error_code"`
 	NotificationFailedAt time.Time       `json:"notification_failed_at"`
 }
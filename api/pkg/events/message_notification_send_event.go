@@ -18,4 +18,5 @@ type MessageNotificationSendPayload struct {
 	PhoneID        uuid.UUID       `json:"phone_id"`
 	ScheduledAt    time.Time       `json:"scheduled_at"`
 	NotificationID uuid.UUID       `json:"notification_id"`
+	ValidityPeriod *time.Duration  `json:"validity_period"`
 }
@@ -0,0 +1,36 @@
+package events
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// messageWebhookPayloads are the payloads of the message events which can be sent to a webhook
+var messageWebhookPayloads = []any{
+	MessagePhoneReceivedPayload{},
+	MessagePhoneSentPayload{},
+	MessagePhoneDeliveredPayload{},
+	MessageReadPayload{},
+	MessageCancelledPayload{},
+	MessageSendFailedPayload{},
+	MessageSendExpiredPayload{},
+	MessageCallMissedPayload{},
+}
+
+// MessagePayloadFields returns the JSON fields of the payloads of the message events which can be sent to a webhook
+func MessagePayloadFields() []string {
+	var fields []string
+	for _, payload := range messageWebhookPayloads {
+		payloadType := reflect.TypeOf(payload)
+		for index := 0; index < payloadType.NumField(); index++ {
+			name, _, _ := strings.Cut(payloadType.Field(index).Tag.Get("json"), ",")
+			if name != "" && name != "-" && !slices.Contains(fields, name) {
+				fields = append(fields, name)
+			}
+		}
+	}
+
+	slices.Sort(fields)
+	return fields
+}
@@ -13,13 +13,14 @@ const EventTypeMessagePhoneDelivered = "message.phone.delivered"
 
 // MessagePhoneDeliveredPayload is the payload of the EventTypeMessagePhoneDelivered event
 type MessagePhoneDeliveredPayload struct {
-	ID        uuid.UUID       `json:"id"`
-	Owner     string          `json:"owner"`
-	Contact   string          `json:"contact"`
-	RequestID *string         `json:"request_id"`
-	UserID    entities.UserID `json:"user_id"`
-	Encrypted bool            `json:"encrypted"`
-	Timestamp time.Time       `json:"timestamp"`
-	Content   string          `json:"content"`
-	SIM       entities.SIM    `json:"sim"`
+	ID        uuid.UUID                `json:"id"`
+	Owner     string                   `json:"owner"`
+	Contact   string                   `json:"contact"`
+	RequestID *string                  `json:"request_id"`
+	UserID    entities.UserID          `json:"user_id"`
+	Encrypted bool                     `json:"encrypted"`
+	Timestamp time.Time                `json:"timestamp"`
+	Content   string                   `json:"content"`
+	Metadata  entities.MessageMetadata `json:"metadata"`
+	SIM       entities.SIM             `json:"sim"`
 }
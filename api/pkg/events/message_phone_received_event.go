@@ -18,7 +18,29 @@ type MessagePhoneReceivedPayload struct {
 	Owner     string          `json:"owner"`
 	Encrypted bool            `json:"encrypted"`
 	Contact   string          `json:"contact"`
-	Timestamp time.Time       `json:"timestamp"`
-	Content   string          `json:"content"`
-	SIM       entities.SIM    `json:"sim"`
+
+	// ContactType is short_code when the message was sent by a short code e.g. 12345 which is not normalized to E.164
+	ContactType entities.MessageContactType `json:"contact_type"`
+
+	Timestamp time.Time    `json:"timestamp"`
+	Content   string       `json:"content"`
+	SIM       entities.SIM `json:"sim"`
+
+	// Category is detected by the message classifier e.g. otp, marketing, personal or unknown
+	Category entities.MessageCategory `json:"category"`
+
+	// Language is the ISO 639-1 code of the content, it is empty when language detection is disabled
+	Language string `json:"language,omitempty"`
+
+	// ContentFilterID is the ID of the inbound content filter which matched the content, it is nil when the content is not flagged
+	ContentFilterID *uuid.UUID `json:"content_filter_id,omitempty"`
+
+	// ReplyToken can be sent to the /v1/messages/reply endpoint within 15 minutes to reply to the message without an API key, it is nil when reply tokens are not enabled
+	ReplyToken *string `json:"reply_token,omitempty"`
+
+	// Attachments are the content type and size of the media files received in an MMS message
+	Attachments entities.MessageAttachments `json:"attachments"`
+
+	// OriginalContentLength is the number of characters in the content before it was truncated to the inbound content limit of the user, it is nil when the content is not truncated
+	OriginalContentLength *uint `json:"original_content_length,omitempty"`
 }
@@ -13,13 +13,14 @@ const EventTypeMessagePhoneSent = "message.phone.sent"
 
 // MessagePhoneSentPayload is the payload of the EventTypeMessagePhoneSent event
 type MessagePhoneSentPayload struct {
-	ID        uuid.UUID       `json:"id"`
-	UserID    entities.UserID `json:"user_id"`
-	RequestID *string         `json:"request_id"`
-	Owner     string          `json:"owner"`
-	Contact   string          `json:"contact"`
-	Encrypted bool            `json:"encrypted"`
-	Timestamp time.Time       `json:"timestamp"`
-	Content   string          `json:"content"`
-	SIM       entities.SIM    `json:"sim"`
+	ID        uuid.UUID                `json:"id"`
+	UserID    entities.UserID          `json:"user_id"`
+	RequestID *string                  `json:"request_id"`
+	Owner     string                   `json:"owner"`
+	Contact   string                   `json:"contact"`
+	Encrypted bool                     `json:"encrypted"`
+	Timestamp time.Time                `json:"timestamp"`
+	Content   string                   `json:"content"`
+	Metadata  entities.MessageMetadata `json:"metadata"`
+	SIM       entities.SIM             `json:"sim"`
 }
@@ -0,0 +1,19 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageProviderFallbackCheck is emitted to trigger sending a message with the third-party SMS provider of the user if no phone has picked it up
+const EventTypeMessageProviderFallbackCheck = "message.provider_fallback.check"
+
+// MessageProviderFallbackCheckPayload is the payload of the EventTypeMessageProviderFallbackCheck event
+type MessageProviderFallbackCheckPayload struct {
+	MessageID   uuid.UUID       `json:"message_id"`
+	ScheduledAt time.Time       `json:"scheduled_at"`
+	UserID      entities.UserID `json:"user_id"`
+}
@@ -0,0 +1,26 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageReacted is emitted when an emoji reaction to a message is sent with the API or received by the phone
+const EventTypeMessageReacted = "message.reacted"
+
+// MessageReactedPayload is the payload of the EventTypeMessageReacted event
+type MessageReactedPayload struct {
+	ID        uuid.UUID                `json:"id"`
+	Owner     string                   `json:"owner"`
+	Contact   string                   `json:"contact"`
+	RequestID *string                  `json:"request_id"`
+	UserID    entities.UserID          `json:"user_id"`
+	Emoji     string                   `json:"emoji"`
+	From      string                   `json:"from"`
+	Timestamp time.Time                `json:"timestamp"`
+	Metadata  entities.MessageMetadata `json:"metadata"`
+	SIM       entities.SIM             `json:"sim"`
+}
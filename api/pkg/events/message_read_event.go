@@ -0,0 +1,26 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageRead is emitted when the phone reports that the recipient has read a message
+const EventTypeMessageRead = "message.read"
+
+// MessageReadPayload is the payload of the EventTypeMessageRead event
+type MessageReadPayload struct {
+	ID        uuid.UUID                `json:"id"`
+	Owner     string                   `json:"owner"`
+	Contact   string                   `json:"contact"`
+	RequestID *string                  `json:"request_id"`
+	UserID    entities.UserID          `json:"user_id"`
+	Encrypted bool                     `json:"encrypted"`
+	Timestamp time.Time                `json:"timestamp"`
+	Content   string                   `json:"content"`
+	Metadata  entities.MessageMetadata `json:"metadata"`
+	SIM       entities.SIM             `json:"sim"`
+}
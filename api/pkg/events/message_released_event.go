@@ -0,0 +1,27 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageReleased is emitted when the send time of a scheduled message is reached and it is queued to be sent by the phone
+const EventTypeMessageReleased = "message.released"
+
+// MessageReleasedPayload is the payload of the EventTypeMessageReleased event
+type MessageReleasedPayload struct {
+	MessageID  uuid.UUID                `json:"message_id"`
+	Owner      string                   `json:"owner"`
+	Contact    string                   `json:"contact"`
+	RequestID  *string                  `json:"request_id"`
+	UserID     entities.UserID          `json:"user_id"`
+	Encrypted  bool                     `json:"encrypted"`
+	SendAt     time.Time                `json:"send_at"`
+	ReleasedAt time.Time                `json:"released_at"`
+	Content    string                   `json:"content"`
+	Metadata   entities.MessageMetadata `json:"metadata"`
+	SIM        entities.SIM             `json:"sim"`
+}
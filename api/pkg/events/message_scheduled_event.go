@@ -0,0 +1,27 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+
+	"github.com/google/uuid"
+)
+
+// EventTypeMessageScheduled is emitted when a message with a send time in the future is accepted
+const EventTypeMessageScheduled = "message.scheduled"
+
+// MessageScheduledPayload is the payload of the EventTypeMessageScheduled event
+type MessageScheduledPayload struct {
+	MessageID uuid.UUID                `json:"message_id"`
+	Owner     string                   `json:"owner"`
+	Contact   string                   `json:"contact"`
+	RequestID *string                  `json:"request_id"`
+	UserID    entities.UserID          `json:"user_id"`
+	Encrypted bool                     `json:"encrypted"`
+	SendAt    time.Time                `json:"send_at"`
+	Timestamp time.Time                `json:"timestamp"`
+	Content   string                   `json:"content"`
+	Metadata  entities.MessageMetadata `json:"metadata"`
+	SIM       entities.SIM             `json:"sim"`
+}
@@ -0,0 +1,22 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneBatteryLow is emitted when the battery of a phone drops below the threshold
+const EventTypePhoneBatteryLow = "phone.battery_low"
+
+// PhoneBatteryLowPayload is the payload of the EventTypePhoneBatteryLow event
+type PhoneBatteryLowPayload struct {
+	PhoneID   uuid.UUID       `json:"phone_id"`
+	UserID    entities.UserID `json:"user_id"`
+	Owner     string          `json:"owner"`
+	Battery   uint            `json:"battery"`
+	Threshold uint            `json:"threshold"`
+	Charging  bool            `json:"charging"`
+	Timestamp time.Time       `json:"timestamp"`
+}
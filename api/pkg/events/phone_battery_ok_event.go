@@ -0,0 +1,22 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneBatteryOk is emitted when the battery of a phone recovers above the threshold
+const EventTypePhoneBatteryOk = "phone.battery_ok"
+
+// PhoneBatteryOkPayload is the payload of the EventTypePhoneBatteryOk event
+type PhoneBatteryOkPayload struct {
+	PhoneID   uuid.UUID       `json:"phone_id"`
+	UserID    entities.UserID `json:"user_id"`
+	Owner     string          `json:"owner"`
+	Battery   uint            `json:"battery"`
+	Threshold uint            `json:"threshold"`
+	Charging  bool            `json:"charging"`
+	Timestamp time.Time       `json:"timestamp"`
+}
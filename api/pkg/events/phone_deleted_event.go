@@ -17,4 +17,7 @@ type PhoneDeletedPayload struct {
 	Timestamp time.Time       `json:"timestamp"`
 	Owner     string          `json:"owner"`
 	SIM       entities.SIM    `json:"sim"`
+
+	// ReassignTo are the phone numbers which will send the pending messages of the deleted phone
+	ReassignTo []string `json:"reassign_to"`
 }
@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneFcmTokenInvalid is emitted when FCM rejects the token of a phone because it is unregistered or invalid
+const EventTypePhoneFcmTokenInvalid = "phone.fcm_token.invalid"
+
+// PhoneFcmTokenInvalidPayload is the payload of the EventTypePhoneFcmTokenInvalid event
+type PhoneFcmTokenInvalidPayload struct {
+	PhoneID      uuid.UUID       `json:"phone_id"`
+	UserID       entities.UserID `json:"user_id"`
+	Owner        string          `json:"owner"`
+	ErrorMessage string          `json:"error_message"`
+	Timestamp    time.Time       `json:"timestamp"`
+}
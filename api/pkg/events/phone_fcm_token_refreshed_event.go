@@ -0,0 +1,20 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneFcmTokenRefreshed is emitted when the phone sends a new FCM token
+const EventTypePhoneFcmTokenRefreshed = "phone.fcm_token.refreshed"
+
+// PhoneFcmTokenRefreshedPayload is the payload of the EventTypePhoneFcmTokenRefreshed event
+type PhoneFcmTokenRefreshedPayload struct {
+	PhoneID   uuid.UUID       `json:"phone_id"`
+	UserID    entities.UserID `json:"user_id"`
+	Owner     string          `json:"owner"`
+	SIM       entities.SIM    `json:"sim"`
+	Timestamp time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,21 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypePhoneResumed is emitted when a paused phone is put back into rotation
+const EventTypePhoneResumed = "phone.resumed"
+
+// PhoneResumedPayload is the payload of the EventTypePhoneResumed event
+type PhoneResumedPayload struct {
+	PhoneID   uuid.UUID       `json:"phone_id"`
+	UserID    entities.UserID `json:"user_id"`
+	Owner     string          `json:"owner"`
+	SIM       entities.SIM    `json:"sim"`
+	PausedAt  time.Time       `json:"paused_at"`
+	Timestamp time.Time       `json:"timestamp"`
+}
@@ -0,0 +1,21 @@
+package events
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeWebhookDisabled is emitted when a webhook is disabled after too many consecutive failed deliveries
+const EventTypeWebhookDisabled = "webhook.disabled"
+
+// WebhookDisabledPayload is the payload of the EventTypeWebhookDisabled event
+type WebhookDisabledPayload struct {
+	WebhookID           uuid.UUID       `json:"webhook_id"`
+	WebhookURL          string          `json:"webhook_url"`
+	UserID              entities.UserID `json:"user_id"`
+	ConsecutiveFailures uint            `json:"consecutive_failures"`
+	LastErrorMessage    string          `json:"last_error_message"`
+	DisabledAt          time.Time       `json:"disabled_at"`
+}
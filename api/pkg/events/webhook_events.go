@@ -0,0 +1,39 @@
+package events
+
+import "slices"
+
+// webhookEventPayloads are the payloads of the events which can be sent to a webhook, keyed by the type of the event
+var webhookEventPayloads = map[string]any{
+	EventTypeMessagePhoneReceived:  MessagePhoneReceivedPayload{},
+	EventTypeMessagePhoneSent:      MessagePhoneSentPayload{},
+	EventTypeMessagePhoneDelivered: MessagePhoneDeliveredPayload{},
+	EventTypeMessageRead:           MessageReadPayload{},
+	EventTypeMessageReacted:        MessageReactedPayload{},
+	EventTypeMessageCancelled:      MessageCancelledPayload{},
+	EventTypeMessageScheduled:      MessageScheduledPayload{},
+	EventTypeMessageReleased:       MessageReleasedPayload{},
+	EventTypeMessageSendFailed:     MessageSendFailedPayload{},
+	EventTypeMessageSendExpired:    MessageSendExpiredPayload{},
+	EventTypePhoneHeartbeatOnline:  PhoneHeartbeatOnlinePayload{},
+	EventTypePhoneHeartbeatOffline: PhoneHeartbeatOfflinePayload{},
+	MessageCallMissed:              MessageCallMissedPayload{},
+	EventTypePhoneBatteryLow:       PhoneBatteryLowPayload{},
+	EventTypePhoneBatteryOk:        PhoneBatteryOkPayload{},
+	EventTypeCallReceived:          CallReceivedPayload{},
+	EventTypeCallMissed:            CallMissedPayload{},
+
+	EventTypeDiscordIntegrationCreated: DiscordIntegrationCreatedPayload{},
+	EventTypeDiscordIntegrationUpdated: DiscordIntegrationUpdatedPayload{},
+	EventTypeDiscordIntegrationDeleted: DiscordIntegrationDeletedPayload{},
+}
+
+// WebhookEventTypes returns the sorted types of the events which can be sent to a webhook
+func WebhookEventTypes() []string {
+	eventTypes := make([]string, 0, len(webhookEventPayloads))
+	for eventType := range webhookEventPayloads {
+		eventTypes = append(eventTypes, eventType)
+	}
+
+	slices.Sort(eventTypes)
+	return eventTypes
+}
@@ -0,0 +1,17 @@
+package events
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+)
+
+// EventTypeWebhookReplayRequested is emitted to send the next page of events of an entities.WebhookReplay
+const EventTypeWebhookReplayRequested = "webhook.replay.requested"
+
+// WebhookReplayRequestedPayload is the payload of the EventTypeWebhookReplayRequested event
+type WebhookReplayRequestedPayload struct {
+	ReplayID  uuid.UUID       `json:"replay_id"`
+	WebhookID uuid.UUID       `json:"webhook_id"`
+	UserID    entities.UserID `json:"user_id"`
+	Offset    int             `json:"offset"`
+}
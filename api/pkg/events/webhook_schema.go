@@ -0,0 +1,159 @@
+package events
+
+import (
+	"encoding"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// JSONSchema is a JSON schema which describes a JSON value
+type JSONSchema struct {
+	Schema      string `json:"$schema,omitempty" example:"https://json-schema.org/draft/2020-12/schema"`
+	Ref         string `json:"$ref,omitempty" example:"#/$defs/MessagePhoneSentPayload"`
+	Title       string `json:"title,omitempty" example:"MessagePhoneSentPayload"`
+	Description string `json:"description,omitempty" example:"the payload of the message.phone.sent event"`
+
+	// Type is either the name of the JSON type e.g. string or a list of types when the value is nullable e.g. ["string", "null"]
+	Type                 any                    `json:"type,omitempty" swaggertype:"string" example:"object"`
+	Format               string                 `json:"format,omitempty" example:"date-time"`
+	Const                string                 `json:"const,omitempty" example:"message.phone.sent"`
+	Minimum              *int                   `json:"minimum,omitempty" example:"0"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	AdditionalProperties *JSONSchema            `json:"additionalProperties,omitempty"`
+	OneOf                []*JSONSchema          `json:"oneOf,omitempty"`
+	Defs                 map[string]*JSONSchema `json:"$defs,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	uuidType          = reflect.TypeOf(uuid.UUID{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// WebhookSchema returns the JSON schema of the events which are sent to a webhook.
+// The schema is generated from the payloads which are dispatched so that it cannot drift from the events which are sent.
+func WebhookSchema() *JSONSchema {
+	schema := &JSONSchema{
+		Schema:      "https://json-schema.org/draft/2020-12/schema",
+		Title:       "httpSMS webhook event",
+		Description: "A cloudevent which is sent to a webhook, a webhook with a batch_size greater than 1 receives a JSON array of these events and the fields which are not in the field_mask of a webhook are removed from the data",
+		Defs:        map[string]*JSONSchema{},
+	}
+
+	for _, eventType := range WebhookEventTypes() {
+		payloadType := reflect.TypeOf(webhookEventPayloads[eventType])
+
+		payload := schemaOf(payloadType)
+		payload.Title = payloadType.Name()
+		payload.Description = "the payload of the " + eventType + " event"
+		schema.Defs[payloadType.Name()] = payload
+
+		schema.OneOf = append(schema.OneOf, webhookEnvelopeSchema(eventType, "#/$defs/"+payloadType.Name()))
+	}
+
+	return schema
+}
+
+// webhookEnvelopeSchema is the schema of the cloudevent of an event type whose data is the schema at ref
+func webhookEnvelopeSchema(eventType string, ref string) *JSONSchema {
+	return &JSONSchema{
+		Title: eventType,
+		Type:  "object",
+		Properties: map[string]*JSONSchema{
+			"specversion":     {Type: "string", Const: "1.0"},
+			"id":              {Type: "string", Format: "uuid"},
+			"source":          {Type: "string"},
+			"type":            {Type: "string", Const: eventType},
+			"datacontenttype": {Type: "string", Const: "application/json"},
+			"time":            {Type: "string", Format: "date-time"},
+			"data":            {Ref: ref},
+		},
+		Required: []string{"specversion", "id", "source", "type", "datacontenttype", "time", "data"},
+	}
+}
+
+// schemaOf returns the JSON schema of the value which encoding/json produces for a Go type
+func schemaOf(valueType reflect.Type) *JSONSchema {
+	switch {
+	case valueType == timeType:
+		return &JSONSchema{Type: "string", Format: "date-time"}
+	case valueType == uuidType:
+		return &JSONSchema{Type: "string", Format: "uuid"}
+	case valueType.Kind() != reflect.Pointer && valueType.Implements(textMarshalerType):
+		return &JSONSchema{Type: "string"}
+	}
+
+	switch valueType.Kind() {
+	case reflect.Pointer:
+		return nullable(schemaOf(valueType.Elem()))
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0
+		return &JSONSchema{Type: "integer", Minimum: &minimum}
+	case reflect.Float32, reflect.Float64:
+		return &JSONSchema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		// a nil slice is encoded as null
+		return nullable(&JSONSchema{Type: "array", Items: schemaOf(valueType.Elem())})
+	case reflect.Map:
+		return nullable(&JSONSchema{Type: "object", AdditionalProperties: schemaOf(valueType.Elem())})
+	case reflect.Struct:
+		return structSchema(valueType)
+	default:
+		// interfaces can contain any JSON value
+		return &JSONSchema{}
+	}
+}
+
+// structSchema returns the JSON schema of a struct, the fields without omitempty are required
+func structSchema(structType reflect.Type) *JSONSchema {
+	schema := &JSONSchema{Type: "object", Properties: map[string]*JSONSchema{}}
+	for index := 0; index < structType.NumField(); index++ {
+		field := structType.Field(index)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, options, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if name == "" && field.Anonymous && field.Type.Kind() == reflect.Struct {
+			embedded := structSchema(field.Type)
+			for key, value := range embedded.Properties {
+				schema.Properties[key] = value
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		schema.Properties[name] = schemaOf(field.Type)
+		if !strings.Contains(options, "omitempty") {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
+
+// nullable allows a schema to also be null
+func nullable(schema *JSONSchema) *JSONSchema {
+	if schemaType, ok := schema.Type.(string); ok {
+		schema.Type = []string{schemaType, "null"}
+	}
+	return schema
+}
@@ -51,6 +51,7 @@ func (h *BillingHandler) RegisterRoutes(router fiber.Router) {
 // @Produce      json
 // @Param        skip		query  int  	false	"number of heartbeats to skip"		minimum(0)
 // @Param        limit		query  int  	false	"number of heartbeats to return"	minimum(1)	maximum(100)
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
 // @Success      200 		{object}	responses.BillingUsagesResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
@@ -83,7 +84,7 @@ func (h *BillingHandler) UsageHistory(c *fiber.Ctx) error {
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d billing usage %s", len(*heartbeats), h.pluralize("record", len(*heartbeats))), heartbeats)
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(*heartbeats), h.pluralize(c, "billing usage record", len(*heartbeats))), heartbeats)
 }
 
 // Usage returns the current usage history of a user
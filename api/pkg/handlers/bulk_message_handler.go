@@ -2,9 +2,12 @@ package handlers
 
 import (
 	"fmt"
+	"net/url"
 	"sync"
 
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
 	"github.com/google/uuid"
 
 	"github.com/NdoleStudio/httpsms/pkg/services"
@@ -23,6 +26,7 @@ type BulkMessageHandler struct {
 	validator      *validators.BulkMessageHandlerValidator
 	messageService *services.MessageService
 	billingService *services.BillingService
+	jobService     *services.BulkMessageJobService
 }
 
 // NewBulkMessageHandler creates a new BulkMessageHandler
@@ -32,6 +36,7 @@ func NewBulkMessageHandler(
 	validator *validators.BulkMessageHandlerValidator,
 	billingService *services.BillingService,
 	messageService *services.MessageService,
+	jobService *services.BulkMessageJobService,
 ) (h *BulkMessageHandler) {
 	return &BulkMessageHandler{
 		logger:         logger.WithService(fmt.Sprintf("%T", h)),
@@ -39,22 +44,29 @@ func NewBulkMessageHandler(
 		validator:      validator,
 		messageService: messageService,
 		billingService: billingService,
+		jobService:     jobService,
 	}
 }
 
 // RegisterRoutes registers the routes for the MessageHandler
 func (h *BulkMessageHandler) RegisterRoutes(router fiber.Router) {
 	router.Post("/bulk-messages", h.Store)
+	router.Get("/bulk-messages/jobs/:jobID", h.ShowJob)
+	router.Post("/bulk-messages/jobs/:jobID/pause", h.PauseJob)
+	router.Post("/bulk-messages/jobs/:jobID/resume", h.ResumeJob)
 }
 
 // Store sends bulk SMS messages from a CSV file.
 // @Summary      Store bulk SMS file
-// @Description  Sends bulk SMS messages to multiple users from a CSV file.
+// @Description  Sends bulk SMS messages to multiple users from a CSV file. Set the duration e.g. 6h or the rate in messages per minute to spread the messages evenly over time with a bulk message job.
 // @Security	 ApiKeyAuth
 // @Tags         BulkSMS
-// @Accept       json
+// @Accept       multipart/form-data
 // @Produce      json
-// @Success      202 		{object}	responses.NoContent
+// @Param        document	formData	file	true	"CSV or Excel file with the messages"
+// @Param        duration	formData	string	false	"Period over which the messages are sent evenly e.g. 6h"
+// @Param        rate		formData	string	false	"Number of messages which are sent every minute"
+// @Success      202 		{object}	responses.BulkMessageJobResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
 // @Failure      422		{object}	responses.UnprocessableEntity
@@ -71,7 +83,8 @@ func (h *BulkMessageHandler) Store(c *fiber.Ctx) error {
 		return h.responseBadRequest(c, err)
 	}
 
-	messages, validationErrors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), file)
+	request := requests.BulkMessageStore{Duration: c.FormValue("duration"), Rate: c.FormValue("rate")}
+	messages, validationErrors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), file, request.Sanitize())
 	if len(validationErrors) != 0 {
 		msg := fmt.Sprintf("validation errors [%s], while sending bulk sms from CSV file [%s] for [%s]", spew.Sdump(validationErrors), file.Filename, h.userIDFomContext(c))
 		ctxLogger.Warn(stacktrace.NewError(msg))
@@ -83,6 +96,10 @@ func (h *BulkMessageHandler) Store(c *fiber.Ctx) error {
 		return h.responsePaymentRequired(c, *msg)
 	}
 
+	if request.IsPaced() {
+		return h.storeJob(c, request, messages)
+	}
+
 	requestID := uuid.New()
 	wg := sync.WaitGroup{}
 	for _, message := range messages {
@@ -102,5 +119,151 @@ func (h *BulkMessageHandler) Store(c *fiber.Ctx) error {
 	}
 
 	wg.Wait()
-	return h.responseAccepted(c, fmt.Sprintf("Added %d messages to the queue", len(messages)))
+	return h.responseAccepted(c, fmt.Sprintf("Added %d messages to the queue", len(messages)), nil)
+}
+
+func (h *BulkMessageHandler) storeJob(c *fiber.Ctx, request requests.BulkMessageStore, messages []*requests.BulkMessage) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	job, err := h.jobService.Store(ctx, request.ToBulkMessageJobStoreParams(h.userIDFomContext(c), c.OriginalURL(), messages))
+	if err != nil {
+		msg := fmt.Sprintf("cannot create bulk message job with [%d] messages for user [%s]", len(messages), h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseAccepted(c, fmt.Sprintf("Scheduled %d messages to be sent every %s", job.TotalMessages, job.Interval()), job)
+}
+
+// ShowJob returns the schedule and the progress of an entities.BulkMessageJob
+// @Summary      Get a bulk message job
+// @Description  Get the schedule and the progress of a bulk message job
+// @Security	 ApiKeyAuth
+// @Tags         BulkSMS
+// @Accept       json
+// @Produce      json
+// @Param 		 jobID		path		string 							true 	"ID of the bulk message job" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.BulkMessageJobResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /bulk-messages/jobs/{jobID} 	[get]
+func (h *BulkMessageHandler) ShowJob(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	jobID := c.Params("jobID")
+	if errors := h.validator.ValidateUUID(ctx, jobID, "jobID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching bulk message job with ID [%s]", spew.Sdump(errors), jobID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching bulk message job")
+	}
+
+	job, err := h.jobService.Load(ctx, h.userIDFomContext(c), uuid.MustParse(jobID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find bulk message job with ID [%s]", jobID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load bulk message job with ID [%s]", jobID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "bulk message job fetched successfully", job)
+}
+
+// PauseJob stops releasing the messages of a running entities.BulkMessageJob
+// @Summary      Pause a bulk message job
+// @Description  Stop releasing the messages of a running bulk message job, the messages which were already released are still sent.
+// @Security	 ApiKeyAuth
+// @Tags         BulkSMS
+// @Accept       json
+// @Produce      json
+// @Param 		 jobID		path		string 							true 	"ID of the bulk message job" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.BulkMessageJobResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /bulk-messages/jobs/{jobID}/pause 	[post]
+func (h *BulkMessageHandler) PauseJob(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	jobID := c.Params("jobID")
+	if errors := h.validator.ValidateUUID(ctx, jobID, "jobID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while pausing bulk message job with ID [%s]", spew.Sdump(errors), jobID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while pausing bulk message job")
+	}
+
+	job, err := h.jobService.Pause(ctx, h.userIDFomContext(c), uuid.MustParse(jobID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find bulk message job with ID [%s]", jobID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeBulkMessageJobInvalidState {
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while pausing bulk message job"), url.Values{"jobID": {"Only a running bulk message job can be paused"}})
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot pause bulk message job with ID [%s]", jobID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "bulk message job paused successfully", job)
+}
+
+// ResumeJob releases the remaining messages of a paused entities.BulkMessageJob
+// @Summary      Resume a bulk message job
+// @Description  Release the remaining messages of a paused bulk message job at the interval of the job.
+// @Security	 ApiKeyAuth
+// @Tags         BulkSMS
+// @Accept       json
+// @Produce      json
+// @Param 		 jobID		path		string 							true 	"ID of the bulk message job" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.BulkMessageJobResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /bulk-messages/jobs/{jobID}/resume 	[post]
+func (h *BulkMessageHandler) ResumeJob(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	jobID := c.Params("jobID")
+	if errors := h.validator.ValidateUUID(ctx, jobID, "jobID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while resuming bulk message job with ID [%s]", spew.Sdump(errors), jobID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while resuming bulk message job")
+	}
+
+	job, err := h.jobService.Resume(ctx, &services.BulkMessageJobResumeParams{
+		UserID: h.userIDFomContext(c),
+		JobID:  uuid.MustParse(jobID),
+		Source: c.OriginalURL(),
+	})
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find bulk message job with ID [%s]", jobID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeBulkMessageJobInvalidState {
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while resuming bulk message job"), url.Values{"jobID": {"Only a paused bulk message job can be resumed"}})
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot resume bulk message job with ID [%s]", jobID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "bulk message job resumed successfully", job)
 }
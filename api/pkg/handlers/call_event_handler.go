@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// CallEventHandler handles phone call http requests.
+type CallEventHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.CallEventHandlerValidator
+	service   *services.CallEventService
+}
+
+// NewCallEventHandler creates a new CallEventHandler
+func NewCallEventHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.CallEventHandlerValidator,
+	service *services.CallEventService,
+) (h *CallEventHandler) {
+	return &CallEventHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the CallEventHandler
+func (h *CallEventHandler) RegisterRoutes(router fiber.Router) {
+	router.Post("/phones/:phoneID/call-events", h.Store)
+	router.Get("/phones/:phoneID/call-events", h.Index)
+}
+
+// Index returns the call events of a phone
+// @Summary      Get call events of a phone
+// @Description  Get list of phone calls which were received or missed by a phone. It will be sorted by timestamp in descending order.
+// @Security	 ApiKeyAuth
+// @Tags         CallEvents
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID	path		string 	true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        skip		query  int  	false	"number of call events to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter call events containing query"
+// @Param        limit		query  int  	false	"number of call events to return"	minimum(1)	maximum(100)
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
+// @Success      200 		{object}	responses.CallEventsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/call-events [get]
+func (h *CallEventHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CallEventIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching call events [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching call events")
+	}
+
+	callEvents, err := h.service.Index(ctx, h.userIDFomContext(c), request.PhoneIDUuid(), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get call events with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(*callEvents), h.pluralize(c, "call event", len(*callEvents))), callEvents)
+}
+
+// Store a phone call registered by a phone
+// @Summary      Register a phone call
+// @Description  Register a phone call which was received or missed by the android phone. It fires the call.received or call.missed event.
+// @Security	 ApiKeyAuth
+// @Tags         CallEvents
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID	path		string 					true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.CallEventStore	true 	"Payload of the phone call"
+// @Success      201 		{object}	responses.CallEventResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/call-events [post]
+func (h *CallEventHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CallEventStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing call event [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing call event")
+	}
+
+	callEvent, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", request.PhoneID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store call event with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "call event created successfully", callEvent)
+}
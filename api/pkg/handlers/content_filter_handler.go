@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// maxContentFilters is the maximum number of content filters of a user
+const maxContentFilters = 100
+
+// ContentFilterHandler handles content filter requests
+type ContentFilterHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.ContentFilterService
+	validator *validators.ContentFilterHandlerValidator
+}
+
+// NewContentFilterHandler creates a new ContentFilterHandler
+func NewContentFilterHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ContentFilterService,
+	validator *validators.ContentFilterHandlerValidator,
+) (h *ContentFilterHandler) {
+	return &ContentFilterHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the ContentFilterHandler
+func (h *ContentFilterHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/content-filters")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Put("/:contentFilterID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:contentFilterID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the content filters of a user
+// @Summary      Get content filters of a user
+// @Description  Get the words and regular expressions which block the messages sent by a user and flag the messages received by a user
+// @Security	 ApiKeyAuth
+// @Tags         ContentFilters
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of content filters to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter content filters containing query"
+// @Param        limit		query  int  	false	"number of content filters to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.ContentFiltersResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /content-filters 	[get]
+func (h *ContentFilterHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContentFilterIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching content filters [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching content filters")
+	}
+
+	filters, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get content filters for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(filters), h.pluralize(c, "content filter", len(filters))), filters)
+}
+
+// Store a content filter
+// @Summary      Store a content filter
+// @Description  Store a word or a regular expression which blocks the messages sent by the authenticated user and flags the messages received by the user
+// @Security	 ApiKeyAuth
+// @Tags         ContentFilters
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.ContentFilterStore  	true "Payload of the content filter"
+// @Success      201 		{object}	responses.ContentFilterResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /content-filters [post]
+func (h *ContentFilterHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContentFilterStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T] for user [%s]", request, h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing content filter for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing content filter")
+	}
+
+	filters, err := h.service.Index(ctx, h.userIDFomContext(c), repositories.IndexParams{Skip: 0, Limit: maxContentFilters})
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index content filters for user [%s]", h.userIDFomContext(c))))
+		return h.responseInternalServerError(c)
+	}
+
+	if len(filters) == maxContentFilters {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] wants to create more than [%d] content filters", h.userIDFomContext(c), maxContentFilters)))
+		return h.responsePaymentRequired(c, fmt.Sprintf("You can't create more than %d content filters contact us to upgrade to our enterprise plan.", maxContentFilters))
+	}
+
+	filter, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store content filter for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "content filter created successfully", filter)
+}
+
+// Update an entities.ContentFilter
+// @Summary      Update a content filter
+// @Description  Update a content filter of the currently authenticated user
+// @Security	 ApiKeyAuth
+// @Tags         ContentFilters
+// @Accept       json
+// @Produce      json
+// @Param 		 contentFilterID	path		string 							true 	"ID of the content filter" 				default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   			body 		requests.ContentFilterUpdate  	true 	"Payload of the content filter to update"
+// @Success      200 		{object}	responses.ContentFilterResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /content-filters/{contentFilterID} 	[put]
+func (h *ContentFilterHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ContentFilterUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T] for user [%s]", request, h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.ContentFilterID = c.Params("contentFilterID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating content filter [%s]", spew.Sdump(errors), request.ContentFilterID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating content filter")
+	}
+
+	filter, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find content filter with ID [%s]", request.ContentFilterID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update content filter with ID [%s]", request.ContentFilterID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "content filter updated successfully", filter)
+}
+
+// Delete a content filter
+// @Summary      Delete content filter
+// @Description  Delete a content filter of a user
+// @Security	 ApiKeyAuth
+// @Tags         ContentFilters
+// @Accept       json
+// @Produce      json
+// @Param 		 contentFilterID 	path		string 							true 	"ID of the content filter"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /content-filters/{contentFilterID} [delete]
+func (h *ContentFilterHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	filterID := c.Params("contentFilterID")
+	if errors := h.validator.ValidateUUID(ctx, filterID, "contentFilterID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting content filter with ID [%s]", spew.Sdump(errors), filterID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting content filter")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(filterID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find content filter with ID [%s]", filterID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete content filter with ID [%s]", filterID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "content filter deleted successfully", nil)
+}
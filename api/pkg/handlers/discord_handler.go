@@ -18,6 +18,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"github.com/davecgh/go-spew/spew"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
 	"github.com/palantir/stacktrace"
 )
 
@@ -55,15 +56,17 @@ func NewDiscordHandler(
 }
 
 // RegisterRoutes registers the routes for the MessageHandler
-func (h *DiscordHandler) RegisterRoutes(app *fiber.App, authMiddleware fiber.Handler, middlewares ...fiber.Handler) {
+func (h *DiscordHandler) RegisterRoutes(app *fiber.App, authMiddleware fiber.Handler, rateLimitMiddleware fiber.Handler, middlewares ...fiber.Handler) {
 	router := app.Group("discord")
-	router.Post("/event", h.computeRoute(middlewares, h.Event)...)
+	// the event route is public so it is rate limited per IP before the signature of the interaction is verified
+	router.Post("/event", h.computeRoute(append(middlewares, rateLimitMiddleware), h.Event)...)
 
 	authRouter := app.Group("v1/discord-integrations")
 	authRouter.Post("/", h.computeRoute(append(middlewares, authMiddleware), h.Store)...)
 	authRouter.Get("/", h.computeRoute(append(middlewares, authMiddleware), h.Index)...)
 	authRouter.Delete("/:discordID", h.computeRoute(append(middlewares, authMiddleware), h.Delete)...)
 	authRouter.Put("/:discordID", h.computeRoute(append(middlewares, authMiddleware), h.Update)...)
+	authRouter.Post("/:discordID/commands", h.computeRoute(append(middlewares, authMiddleware), h.SyncCommand)...)
 }
 
 // Index returns the discord integrations of a user
@@ -76,6 +79,7 @@ func (h *DiscordHandler) RegisterRoutes(app *fiber.App, authMiddleware fiber.Han
 // @Param        skip		query  int  	false	"number of discord integrations to skip"		minimum(0)
 // @Param        query		query  string  	false 	"filter discord integrations containing query"
 // @Param        limit		query  int  	false	"number of discord integrations to return"	minimum(1)	maximum(20)
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
 // @Success      200 		{object}	responses.DiscordsResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
@@ -106,7 +110,7 @@ func (h *DiscordHandler) Index(c *fiber.Ctx) error {
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d discord %s", len(discordIntegrations), h.pluralize("integration", len(discordIntegrations))), discordIntegrations)
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(discordIntegrations), h.pluralize(c, "discord integration", len(discordIntegrations))), discordIntegrations)
 }
 
 // Delete a discord integration
@@ -134,7 +138,7 @@ func (h *DiscordHandler) Delete(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting discord integration")
 	}
 
-	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(discordID))
+	err := h.service.Delete(ctx, c.OriginalURL(), h.userIDFomContext(c), uuid.MustParse(discordID))
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete discord integration with ID [%+#v]", discordID)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -171,13 +175,13 @@ func (h *DiscordHandler) Update(c *fiber.Ctx) error {
 	}
 
 	request.DiscordID = c.Params("discordID")
-	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+	if errors := h.validator.ValidateUpdate(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
 		msg := fmt.Sprintf("validation errors [%s], while updating user [%+#v]", spew.Sdump(errors), request)
 		ctxLogger.Warn(stacktrace.NewError(msg))
 		return h.responseUnprocessableEntity(c, errors, "validation errors while updating discord integration")
 	}
 
-	user, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	user, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c), c.OriginalURL()))
 	if err != nil {
 		msg := fmt.Sprintf("cannot update discord integration with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -187,6 +191,52 @@ func (h *DiscordHandler) Update(c *fiber.Ctx) error {
 	return h.responseOK(c, "discord integration updated successfully", user)
 }
 
+// SyncCommand registers the slash command of an entities.Discord
+// @Summary      Register the discord slash command
+// @Description  Register or re-sync the /httpsms slash command on the discord server of a discord integration
+// @Security	 ApiKeyAuth
+// @Tags         DiscordIntegration
+// @Accept       json
+// @Produce      json
+// @Param 		 discordID	path		string 							true 	"ID of the discord integration" 					default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.DiscordCommandResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      429		{object}	responses.TooManyRequests
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /discord-integrations/{discordID}/commands 	[post]
+func (h *DiscordHandler) SyncCommand(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	discordID := c.Params("discordID")
+	if errors := h.validator.ValidateUUID(ctx, discordID, "discordID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while syncing command for discord integration with ID [%s]", spew.Sdump(errors), discordID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while syncing discord command")
+	}
+
+	command, err := h.service.SyncCommand(ctx, h.userIDFomContext(c), uuid.MustParse(discordID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find discord integration with ID [%s]", discordID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeDiscordRateLimited {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("discord rate limited syncing command for discord integration [%s]", discordID)))
+		return h.responseTooManyRequests(c, "discord is rate limiting requests, please try again later")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot sync command for discord integration with ID [%s]", discordID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "discord command registered successfully", command)
+}
+
 // Store an entities.Discord
 // @Summary      Store discord integration
 // @Description  Store a discord integration for the authenticated user
@@ -214,7 +264,7 @@ func (h *DiscordHandler) Store(c *fiber.Ctx) error {
 		return h.responseBadRequest(c, err)
 	}
 
-	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+	if errors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
 		msg := fmt.Sprintf("validation errors [%s], while storing discord integration [%+#v]", spew.Sdump(errors), request)
 		ctxLogger.Warn(stacktrace.NewError(msg))
 		return h.responseUnprocessableEntity(c, errors, "validation errors while storing discord integration")
@@ -231,7 +281,7 @@ func (h *DiscordHandler) Store(c *fiber.Ctx) error {
 		return h.responsePaymentRequired(c, "You can't create more than 1 discord integration contact us to upgrade your account.")
 	}
 
-	discordIntegration, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	discordIntegration, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c), c.OriginalURL()))
 	if err != nil {
 		msg := fmt.Sprintf("cannot store discord integration with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -243,14 +293,16 @@ func (h *DiscordHandler) Store(c *fiber.Ctx) error {
 
 // Event consumes a discord event
 // @Summary      Consume a discord event
-// @Description  Publish a discord event to the registered listeners
+// @Description  Publish a discord event to the registered listeners. A slash command gets a deferred response which is edited when the SMS has been sent.
 // @Tags         Discord
 // @Accept       json
 // @Produce      json
+// @Param        payload   	body 		requests.DiscordEvent  		true "Payload of the discord interaction"
 // @Success      204 		{object}	responses.NoContent
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
 // @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      429		{object}	responses.TooManyRequests
 // @Failure      500		{object}	responses.InternalServerError
 // @Router       /discord/event [post]
 func (h *DiscordHandler) Event(c *fiber.Ctx) error {
@@ -261,67 +313,85 @@ func (h *DiscordHandler) Event(c *fiber.Ctx) error {
 		return h.responseUnauthorized(c)
 	}
 
-	var payload map[string]any
-	if err := json.Unmarshal(c.Body(), &payload); err != nil {
-		msg := fmt.Sprintf("cannot unmarshall [%s] to [%T]", string(c.Body()), payload)
-		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	var request requests.DiscordEvent
+	if err := json.Unmarshal(c.Body(), &request); err != nil {
+		msg := fmt.Sprintf("cannot unmarshall [%s] to [%T]", string(c.Body()), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
 		return h.responseBadRequest(c, err)
 	}
 
 	ctxLogger.Info(string(c.Body()))
 
-	if payload["type"].(float64) == 1 {
-		return c.JSON(fiber.Map{"type": 1})
+	if errors := h.validator.ValidateEvent(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while consuming discord event [%s]", spew.Sdump(errors), c.Body())
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while consuming discord event")
 	}
 
-	if payload["type"].(float64) == 2 {
-		return h.sendSMS(ctx, c, payload)
+	if request.IsPing() {
+		return c.JSON(fiber.Map{"type": 1})
 	}
 
-	return h.responseBadRequest(c, stacktrace.NewError(fmt.Sprintf("unknown type [%d]", payload["type"])))
-}
+	// discord requires a response within 3 seconds so the SMS is sent after a deferred response and the response is edited when it is done
+	source := utils.CopyString(c.OriginalURL())
+	h.service.DeferInteraction(ctx, request.ID, request.Token, func(ctx context.Context) fiber.Map {
+		return h.sendSMS(ctx, source, request)
+	})
 
-func (h *DiscordHandler) createRequest(payload map[string]any) requests.MessageSend {
-	getOption := func(name string) string {
-		for _, option := range payload["data"].(map[string]any)["options"].([]any) {
-			if option.(map[string]any)["name"].(string) == name {
-				return option.(map[string]any)["value"].(string)
-			}
-		}
-		return ""
-	}
-	return requests.MessageSend{
-		From:    getOption("from"),
-		To:      getOption("to"),
-		Content: getOption("message"),
-	}
+	return c.JSON(fiber.Map{"type": 5})
 }
 
-func (h *DiscordHandler) sendSMS(ctx context.Context, c *fiber.Ctx, payload map[string]any) error {
+// sendSMS sends the SMS of a slash command and returns the payload which is used to edit the deferred response of the interaction
+func (h *DiscordHandler) sendSMS(ctx context.Context, source string, event requests.DiscordEvent) fiber.Map {
 	_, span, ctxLogger := h.tracer.StartWithLogger(ctx, h.logger)
 	defer span.End()
 
-	discord, err := h.service.GetByServerID(ctx, payload["guild_id"].(string))
+	discord, err := h.service.GetByServerID(ctx, event.GuildID)
 	if err != nil {
-		msg := fmt.Sprintf("cannot get discord integration by server ID [%s]", payload["guild_id"].(string))
+		msg := fmt.Sprintf("cannot get discord integration by server ID [%s]", event.GuildID)
 		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
-		return c.JSON(
-			fiber.Map{
-				"type": 4,
-				"data": fiber.Map{
-					"content": "**⚠️ error while sending message**",
-					"embeds": []fiber.Map{
-						{
-							"title": "We cannot find the link to your discord server to an account on [httpsms.com](https://httpsms.com/settings).",
-							"color": 14681092,
-						},
-					},
+		return fiber.Map{
+			"content": "**⚠️ error while sending message**",
+			"embeds": []fiber.Map{
+				{
+					"title": "We cannot find the link to your discord server to an account on [httpsms.com](https://httpsms.com/settings).",
+					"color": 14681092,
 				},
 			},
-		)
+		}
+	}
+
+	request := event.ToMessageSend()
+	request.Sanitize()
+
+	from, err := h.service.ResolveSender(ctx, discord, request.From)
+	if code := stacktrace.GetCode(err); code == services.ErrCodeDiscordSenderRequired || code == services.ErrCodeDiscordSenderNotOwned {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send message from [%s] with discord integration [%s]", request.From, discord.ID)))
+		return fiber.Map{
+			"content": "**⚠️ error while sending message**",
+			"embeds": []fiber.Map{
+				{
+					"title": h.discordSenderError(code, request.From),
+					"color": 14681092,
+				},
+			},
+		}
 	}
 
-	request := h.createRequest(payload)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot resolve the sender [%s] of discord integration [%s]", request.From, discord.ID)))
+		return fiber.Map{
+			"content": "**Could not send the message⚠️**",
+			"embeds": []fiber.Map{
+				{
+					"title": "Internal server error while sending SMS. Please try again later or contact support.",
+					"color": 14681092,
+				},
+			},
+		}
+	}
+
+	request.From = from
 	messageEmbed := fiber.Map{
 		"fields": []fiber.Map{
 			{
@@ -342,7 +412,7 @@ func (h *DiscordHandler) sendSMS(ctx context.Context, c *fiber.Ctx, payload map[
 	}
 
 	if errors := h.messageValidator.ValidateMessageSend(ctx, discord.UserID, request.Sanitize()); len(errors) != 0 {
-		msg := fmt.Sprintf("validation errors [%s], while sending payload [%s]", spew.Sdump(errors), c.Body())
+		msg := fmt.Sprintf("validation errors [%s], while sending message from discord interaction [%s]", spew.Sdump(errors), event.ID)
 		ctxLogger.Warn(stacktrace.NewError(msg))
 
 		var embeds []fiber.Map
@@ -353,68 +423,82 @@ func (h *DiscordHandler) sendSMS(ctx context.Context, c *fiber.Ctx, payload map[
 			})
 		}
 
-		return c.JSON(
-			fiber.Map{
-				"type": 4,
-				"data": fiber.Map{
-					"content": "**⚠️ error while sending message**",
-					"embeds":  append(embeds, messageEmbed),
+		return fiber.Map{
+			"content": "**⚠️ error while sending message**",
+			"embeds":  append(embeds, messageEmbed),
+		}
+	}
+
+	if errors := h.messageValidator.ValidateMessageContent(ctx, discord.UserID, request.Content, request.Encrypted); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("the content of the message from discord server [%s] is blocked by a content filter", discord.ServerID)))
+		return fiber.Map{
+			"content": "**⚠️ error while sending message**",
+			"embeds": append([]fiber.Map{
+				{
+					"title": errors.Get("content"),
+					"color": 14681092,
 				},
-			},
-		)
+			}, messageEmbed),
+		}
 	}
 
 	if msg := h.billingService.IsEntitled(ctx, discord.UserID); msg != nil {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a message", discord.UserID)))
-		return c.JSON(
-			fiber.Map{
-				"type": 4,
-				"data": fiber.Map{
-					"content": "**⚠️ error while sending message**",
-					"embeds": append([]fiber.Map{
-						{
-							"title": msg,
-							"color": 14681092,
-						},
-					}, messageEmbed),
+		return fiber.Map{
+			"content": "**⚠️ error while sending message**",
+			"embeds": append([]fiber.Map{
+				{
+					"title": msg,
+					"color": 14681092,
 				},
-			},
-		)
+			}, messageEmbed),
+		}
+	}
+
+	message, err := h.messageService.SendMessage(ctx, request.ToMessageSendParams(discord.UserID, source))
+	if stacktrace.GetCode(err) == services.ErrCodeMessageContactUnresolvable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot resolve the recipient of message from discord server [%s]", discord.ServerID)))
+		return fiber.Map{
+			"content": "**⚠️ error while sending message**",
+			"embeds": append([]fiber.Map{
+				{
+					"title": "The recipient is not a valid phone number in your default country, use the international format e.g. +18005550199",
+					"color": 14681092,
+				},
+			}, messageEmbed),
+		}
 	}
 
-	message, err := h.messageService.SendMessage(ctx, request.ToMessageSendParams(discord.UserID, c.OriginalURL()))
 	if err != nil {
-		msg := fmt.Sprintf("cannot send message with paylod [%s] from discord server [%s]", c.Body(), discord.ServerID)
+		msg := fmt.Sprintf("cannot send message from discord interaction [%s] of discord server [%s]", event.ID, discord.ServerID)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
-		return c.JSON(
-			fiber.Map{
-				"type": 4,
-				"data": fiber.Map{
-					"content": "**Could not send the message⚠️**",
-					"embeds": append([]fiber.Map{
-						{
-							"title": "Internal server error while sending SMS. Please try again later or contact support.",
-							"color": 14681092,
-						},
-					}, messageEmbed),
+		return fiber.Map{
+			"content": "**Could not send the message⚠️**",
+			"embeds": append([]fiber.Map{
+				{
+					"title": "Internal server error while sending SMS. Please try again later or contact support.",
+					"color": 14681092,
 				},
-			},
-		)
+			}, messageEmbed),
+		}
 	}
 
 	messageEmbed["fields"] = append(messageEmbed["fields"].([]fiber.Map), fiber.Map{
 		"name":  "MessageID:",
 		"value": message.ID,
 	})
-	return c.JSON(
-		fiber.Map{
-			"type": 4,
-			"data": fiber.Map{
-				"content": "✔ sending sms",
-				"embeds":  []fiber.Map{messageEmbed},
-			},
-		},
-	)
+	return fiber.Map{
+		"content": "✔ sending sms",
+		"embeds":  []fiber.Map{messageEmbed},
+	}
+}
+
+// discordSenderError is the title of the embed which explains why the sender of a slash command cannot be used
+func (h *DiscordHandler) discordSenderError(code stacktrace.ErrorCode, from string) string {
+	if code == services.ErrCodeDiscordSenderRequired {
+		return "Set the `from` option or pin a default sender on your discord integration on [httpsms.com](https://httpsms.com/settings)."
+	}
+	return fmt.Sprintf("The number %s is not one of your phones on [httpsms.com](https://httpsms.com/settings). Install the android app on the phone to send messages from it.", from)
 }
 
 // verifyInteraction implements message verification of the discord interactions api
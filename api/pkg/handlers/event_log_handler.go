@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// EventLogHandler handles the audit trail requests
+type EventLogHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.EventLogService
+	drainer   *services.Drainer
+	validator *validators.EventLogHandlerValidator
+}
+
+// NewEventLogHandler creates a new EventLogHandler
+func NewEventLogHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.EventLogService,
+	drainer *services.Drainer,
+	validator *validators.EventLogHandlerValidator,
+) (h *EventLogHandler) {
+	return &EventLogHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		drainer:   drainer,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the EventLogHandler
+func (h *EventLogHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/events", h.Index)
+	router.Get("/events/stream", h.Stream)
+	router.Get("/events/phones/ws", h.PhoneStatus)
+}
+
+// Index returns the event logs of a user
+// @Summary      Get the audit trail of a user
+// @Description  Get the events which changed a message, phone, call or integration of a user ordered by timestamp
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Accept       json
+// @Produce      json
+// @Param        resource_type	query  string  	false	"type of the resource which was changed"	Enums(message, phone, call, webhook, discord)
+// @Param        resource_id	query  string  	false	"ID of the resource which was changed"
+// @Param        from			query  string  	false	"RFC3339 timestamp of the earliest event"	default(2022-06-05T08:26:02Z)
+// @Param        to				query  string  	false	"RFC3339 timestamp of the latest event"	default(2022-06-05T14:26:02Z)
+// @Param        skip			query  int  	false	"number of events to skip"		minimum(0)
+// @Param        limit			query  int  	false	"number of events to return"	minimum(1)	maximum(100)
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
+// @Success      200 		{object}	responses.EventLogsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /events 	[get]
+func (h *EventLogHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.EventLogIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching event logs [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching events")
+	}
+
+	logs, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToSearchParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get event logs with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(logs), h.pluralize(c, "event", len(logs))), logs)
+}
+
+// Stream sends the events of a user as server-sent events
+// @Summary      Stream the message and phone events of a user
+// @Description  Sends the message and phone events of a user as server-sent events as soon as they happen. The ID of each event is its sequence in the audit trail and the events after the `Last-Event-ID` header are sent first when a client reconnects.
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Produce      text/event-stream
+// @Param        Last-Event-ID	header  string	false	"sequence of the last event received before the reconnect"	default(1024)
+// @Param        last_event_id	query  	string	false	"sequence of the last event for clients which cannot set the Last-Event-ID header"	default(1024)
+// @Success      200 		{string}	string
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Router       /events/stream [get]
+func (h *EventLogHandler) Stream(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.EventLogStream
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if lastEventID := c.Get("Last-Event-ID"); lastEventID != "" {
+		request.LastEventID = lastEventID
+	}
+
+	if errors := h.validator.ValidateStream(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while streaming events [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while streaming events")
+	}
+
+	c.Set(fiber.HeaderContentType, "text/event-stream")
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	c.Set(fiber.HeaderConnection, "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	userID := h.userIDFomContext(c)
+	ctx = context.WithoutCancel(ctx)
+	c.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		send := func(log *entities.EventLog) error {
+			if _, err := fmt.Fprintf(writer, "id: %d\nevent: %s\ndata: %s\n\n", log.Sequence, log.Type, log.Event); err != nil {
+				return err
+			}
+			return writer.Flush()
+		}
+
+		// a comment line is ignored by the clients and it fails when the client has disconnected
+		keepAlive := func() error {
+			if _, err := writer.WriteString(": keep-alive\n\n"); err != nil {
+				return err
+			}
+			return writer.Flush()
+		}
+
+		if err := h.service.Stream(ctx, request.ToStreamParams(userID, send, keepAlive)); err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the event stream for user [%s] has ended", userID)))
+		}
+	})
+	return nil
+}
+
+// PhoneStatus sends the online and offline events of the phones of a user over a websocket
+// @Summary      Stream the online status of the phones of a user
+// @Description  Upgrades the request to a websocket which receives the phone.heartbeat.online and phone.heartbeat.offline events of the phones of the user as text messages. Browsers which cannot set the X-API-Key header can send the API key in the x-api-key query parameter.
+// @Security	 ApiKeyAuth
+// @Tags         Events
+// @Param        x-api-key	query  	string	false	"API key for clients which cannot set the X-API-Key header"
+// @Success      101 		{string}	string
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Router       /events/phones/ws [get]
+func (h *EventLogHandler) PhoneStatus(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	userID := h.userIDFomContext(c)
+	ctx = context.WithoutCancel(ctx)
+	return h.upgradeWebsocket(c, func(conn *websocketConn) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		// the websocket is closed with a going away frame when the server shuts down so the client can reconnect to another instance
+		done := h.drainer.Add()
+		defer done()
+
+		// the subscription of the stream is removed as soon as the client closes the connection
+		go func() {
+			defer cancel()
+			if err := conn.ReadUntilClosed(); err != nil {
+				ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot read from the phone status websocket of user [%s]", userID)))
+			}
+		}()
+
+		go func() {
+			select {
+			case <-h.drainer.Stopping():
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+
+		params := services.PhoneStatusStreamParams{
+			UserID:    userID,
+			Send:      func(log *entities.EventLog) error { return conn.WriteText(log.Event) },
+			KeepAlive: conn.Ping,
+		}
+
+		if err := h.service.StreamPhoneStatus(ctx, params); err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the phone status websocket of user [%s] has ended", userID)))
+		}
+
+		select {
+		case <-h.drainer.Stopping():
+			_ = conn.Close(websocket.CloseGoingAway, "the server is shutting down")
+		default:
+		}
+	})
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// FcmCredentialHandler handles the requests for the firebase project which sends the push notifications of a user
+type FcmCredentialHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.FcmCredentialService
+	validator *validators.FcmCredentialHandlerValidator
+}
+
+// NewFcmCredentialHandler creates a new FcmCredentialHandler
+func NewFcmCredentialHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.FcmCredentialService,
+	validator *validators.FcmCredentialHandlerValidator,
+) (h *FcmCredentialHandler) {
+	return &FcmCredentialHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the FcmCredentialHandler
+func (h *FcmCredentialHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/fcm-credential")
+	router.Get("/", h.computeRoute(middlewares, h.Show)...)
+	router.Put("/", h.computeRoute(middlewares, h.Upsert)...)
+	router.Delete("/", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Show returns the FCM credential of a user
+// @Summary      Get the FCM credential of a user
+// @Description  Get the firebase project which sends the push notifications to the phones of the user. The service account key is never returned.
+// @Security	 ApiKeyAuth
+// @Tags         FcmCredential
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.FcmCredentialResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /fcm-credential 	[get]
+func (h *FcmCredentialHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	credential, err := h.service.Load(ctx, h.userIDFomContext(c))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "you have not configured an FCM credential")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load FCM credential for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "fetched FCM credential", credential)
+}
+
+// Upsert the FCM credential of a user
+// @Summary      Set the FCM credential of a user
+// @Description  Set the service account of your own firebase project which sends the push notifications to your phones instead of the httpSMS firebase project. The service account is verified with a dry run message before it is saved.
+// @Security	 ApiKeyAuth
+// @Tags         FcmCredential
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.FcmCredentialUpsert  	true "Payload of the FCM credential"
+// @Success      200 		{object}	responses.FcmCredentialResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /fcm-credential [put]
+func (h *FcmCredentialHandler) Upsert(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.FcmCredentialUpsert
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T] for user [%s]", request, h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateUpsert(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while setting the FCM credential for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while setting the FCM credential")
+	}
+
+	credential, err := h.service.Upsert(ctx, request.ToUpsertParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot set the FCM credential for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "FCM credential saved successfully", credential)
+}
+
+// Delete the FCM credential of a user
+// @Summary      Delete the FCM credential
+// @Description  Delete the service account of a user, the push notifications are then sent with the httpSMS firebase project
+// @Security	 ApiKeyAuth
+// @Tags         FcmCredential
+// @Accept       json
+// @Produce      json
+// @Success      204		{object}    responses.NoContent
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /fcm-credential [delete]
+func (h *FcmCredentialHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "you have not configured an FCM credential")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete the FCM credential for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "FCM credential deleted successfully")
+}
@@ -1,10 +1,23 @@
 package handlers
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"net/url"
+	"reflect"
+	"strings"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/i18n"
 	"github.com/NdoleStudio/httpsms/pkg/middlewares"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/palantir/stacktrace"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -13,92 +26,364 @@ import (
 type handler struct{}
 
 func (h *handler) responseBadRequest(c *fiber.Ctx, err error) error {
-	return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-		"status":  "error",
-		"message": "The request isn't properly formed",
-		"data":    err,
-	})
+	return h.responseError(c, fiber.StatusBadRequest, responses.ErrorCodeBadRequest, h.translate(c, "The request isn't properly formed"), err)
 }
 
 func (h *handler) responseInternalServerError(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-		"status":  "error",
-		"message": "We ran into an internal error while handling the request.",
-	})
+	return h.responseError(c, fiber.StatusInternalServerError, responses.ErrorCodeInternalError, h.translate(c, "We ran into an internal error while handling the request."), nil)
 }
 
 func (h *handler) responseUnauthorized(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-		"status":  "error",
-		"message": "You are not authorized to carry out this request.",
-		"data":    "Make sure your API key is set in the [X-API-Key] header in the request",
-	})
+	return h.responseError(c, fiber.StatusUnauthorized, responses.ErrorCodeUnauthorized, h.translate(c, "You are not authorized to carry out this request."), h.translate(c, "Make sure your API key is set in the [X-API-Key] header in the request"))
 }
 
 func (h *handler) responseForbidden(c *fiber.Ctx) error {
-	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-		"status":  "error",
-		"message": fiber.ErrForbidden.Message,
-	})
+	return h.responseError(c, fiber.StatusForbidden, responses.ErrorCodeForbidden, fiber.ErrForbidden.Message, nil)
 }
 
 func (h *handler) responseUnprocessableEntity(c *fiber.Ctx, errors url.Values, message string) error {
-	return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
-		"status":  "error",
-		"message": message,
-		"data":    errors,
-	})
+	code := responses.ErrorCodeValidationFailed
+	if validators.HasPhoneNumberError(errors) {
+		code = responses.ErrorCodeInvalidPhoneNumber
+	}
+	return h.responseError(c, fiber.StatusUnprocessableEntity, code, h.translate(c, message), errors)
 }
 
 func (h *handler) responseNotFound(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
-		"status":  "error",
-		"message": message,
-	})
+	return h.responseError(c, fiber.StatusNotFound, responses.ErrorCodeNotFound, h.translate(c, message), nil)
 }
 
 func (h *handler) responsePaymentRequired(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusPaymentRequired).JSON(fiber.Map{
-		"status":  "error",
-		"message": message,
-	})
+	return h.responseError(c, fiber.StatusPaymentRequired, responses.ErrorCodePaymentRequired, h.translate(c, message), nil)
+}
+
+func (h *handler) responseConflict(c *fiber.Ctx, message string, data interface{}) error {
+	return h.responseError(c, fiber.StatusConflict, responses.ErrorCodeConflict, h.translate(c, message), data)
+}
+
+func (h *handler) responseTooManyRequests(c *fiber.Ctx, message string) error {
+	return h.responseError(c, fiber.StatusTooManyRequests, responses.ErrorCodeRateLimited, h.translate(c, message), nil)
+}
+
+func (h *handler) responseServiceUnavailable(c *fiber.Ctx, message string, data interface{}) error {
+	return h.responseError(c, fiber.StatusServiceUnavailable, responses.ErrorCodeServiceUnavailable, h.translate(c, message), data)
+}
+
+func (h *handler) responseRangeNotSatisfiable(c *fiber.Ctx, message string) error {
+	return h.responseError(c, fiber.StatusRequestedRangeNotSatisfiable, responses.ErrorCodeRangeNotSatisfiable, h.translate(c, message), nil)
+}
+
+// responseError returns an error response with a machine-readable responses.ErrorCode, the message must already be translated
+func (h *handler) responseError(c *fiber.Ctx, status int, code responses.ErrorCode, message string, data interface{}) error {
+	payload := fiber.Map{
+		"status":     "error",
+		"message":    message,
+		"error_code": code,
+	}
+	if data != nil {
+		payload["data"] = data
+	}
+	return c.Status(status).JSON(h.fieldCase(c, payload))
+}
+
+// errorCode returns the responses.ErrorCode for the stacktrace.ErrorCode of an error returned by a service
+func (h *handler) errorCode(err error, fallback responses.ErrorCode) responses.ErrorCode {
+	switch stacktrace.GetCode(err) {
+	case repositories.ErrCodeNotFound:
+		return responses.ErrorCodeNotFound
+	case services.ErrCodeDiscordRateLimited:
+		return responses.ErrorCodeRateLimited
+	case services.ErrCodeUssdSessionInvalidState:
+		return responses.ErrorCodeUssdSessionInvalidState
+	case services.ErrCodeUssdPhoneUnreachable:
+		return responses.ErrorCodePhoneOffline
+	case services.ErrCodePhoneDailyQuotaExceeded:
+		return responses.ErrorCodeDailyQuotaExceeded
+	case services.ErrCodePhoneBulkStoreRejected:
+		return responses.ErrorCodePhoneAlreadyExists
+	case services.ErrCodePhoneReassignTargetNotFound:
+		return responses.ErrorCodePhoneReassignTargetNotFound
+	case services.ErrCodeWebhookReplayTooLarge:
+		return responses.ErrorCodeWebhookReplayTooLarge
+	case services.ErrCodeMessageInReplyToInvalid:
+		return responses.ErrorCodeMessageInReplyToInvalid
+	case services.ErrCodeMessageRecipientNotAllowed:
+		return responses.ErrorCodeRecipientNotAllowed
+	case services.ErrCodeMessageContactUnresolvable:
+		return responses.ErrorCodeContactUnresolvable
+	case services.ErrCodeMessageShortCodeNotSupported:
+		return responses.ErrorCodeShortCodeNotSupported
+	case services.ErrCodePhonePaused:
+		return responses.ErrorCodePhonePaused
+	case services.ErrCodeCountryRouteNotFound:
+		return responses.ErrorCodeCountryRouteNotFound
+	case services.ErrCodeReplyTokenInvalid:
+		return responses.ErrorCodeReplyTokenInvalid
+	case services.ErrCodePhoneControlUnreachable:
+		return responses.ErrorCodePhoneOffline
+	case services.ErrCodeUserSubAccountNested:
+		return responses.ErrorCodeForbidden
+	case services.ErrCodePhoneFcmSigningDisabled:
+		return responses.ErrorCodeFcmSigningDisabled
+	case services.ErrCodePhoneFcmKeyNotRotated:
+		return responses.ErrorCodeFcmKeyNotRotated
+	case services.ErrCodeMessageLabelLimitExceeded:
+		return responses.ErrorCodeMessageLabelLimitExceeded
+	case services.ErrCodeMessageContentTooLong:
+		return responses.ErrorCodeMessageContentTooLong
+	case services.ErrCodeBulkMessageJobInvalidState:
+		return responses.ErrorCodeBulkMessageJobInvalidState
+	default:
+		return fallback
+	}
+}
+
+// responseMultiStatus returns 207 when only some of the items of a batch are accepted and 200 when all of them are accepted
+func (h *handler) responseMultiStatus(c *fiber.Ctx, rejected int, message string, data interface{}) error {
+	if rejected == 0 {
+		return h.responseOK(c, message, data)
+	}
+	return c.Status(fiber.StatusMultiStatus).JSON(h.fieldCase(c, fiber.Map{
+		"status":  "success",
+		"message": h.translate(c, message),
+		"data":    h.localizeTimestamps(c, data),
+	}))
 }
 
 func (h *handler) responseNoContent(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusNoContent).JSON(fiber.Map{
+	return c.Status(fiber.StatusNoContent).JSON(h.fieldCase(c, fiber.Map{
 		"status":  "success",
-		"message": message,
-	})
+		"message": h.translate(c, message),
+	}))
 }
 
-func (h *handler) responseAccepted(c *fiber.Ctx, message string) error {
-	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+func (h *handler) responseAccepted(c *fiber.Ctx, message string, data interface{}) error {
+	return c.Status(fiber.StatusAccepted).JSON(h.fieldCase(c, fiber.Map{
 		"status":  "success",
-		"message": message,
-	})
+		"message": h.translate(c, message),
+		"data":    data,
+	}))
 }
 
 func (h *handler) responseOK(c *fiber.Ctx, message string, data interface{}) error {
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+	return c.Status(fiber.StatusOK).JSON(h.fieldCase(c, fiber.Map{
 		"status":  "success",
-		"message": message,
-		"data":    data,
-	})
+		"message": h.translate(c, message),
+		"data":    h.localizeTimestamps(c, data),
+	}))
+}
+
+const (
+	// jsonCaseHeader and jsonCaseQuery select the naming of the fields of a JSON response, the fields are snake_case when they are not set
+	jsonCaseHeader = "X-JSON-Case"
+	jsonCaseQuery  = "json_case"
+	jsonCaseCamel  = "camel"
+)
+
+// jsonCaseUserFields are the fields whose keys are set by the user or sent back in a request so they are never renamed
+var jsonCaseUserFields = map[string]bool{"metadata": true, "feature_flags": true}
+
+// isCamelCase checks if the request asked for camelCase fields with the json_case query parameter or the X-JSON-Case header
+func (h *handler) isCamelCase(c *fiber.Ctx) bool {
+	value := c.Query(jsonCaseQuery)
+	if value == "" {
+		value = c.Get(jsonCaseHeader)
+	}
+	value = strings.ToLower(strings.TrimSpace(value))
+	return value == jsonCaseCamel || value == "camelcase"
+}
+
+// fieldCase renames the fields of a response payload including the nested fields to camelCase when the request asked for it.
+// The validation errors are not renamed because they are keyed by the fields of the request which are always snake_case.
+func (h *handler) fieldCase(c *fiber.Ctx, payload fiber.Map) fiber.Map {
+	if !h.isCamelCase(c) {
+		return payload
+	}
+
+	result := make(fiber.Map, len(payload))
+	for key, value := range payload {
+		switch value.(type) {
+		// the webhook schema describes the webhook payloads whose fields are always snake_case
+		case url.Values, map[string][]string, *events.JSONSchema:
+		default:
+			value = h.camelCaseValue(value)
+		}
+		result[h.camelCase(key)] = value
+	}
+	return result
+}
+
+func (h *handler) camelCaseValue(data interface{}) interface{} {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var result interface{}
+	if err = decoder.Decode(&result); err != nil {
+		return data
+	}
+	return h.camelCaseKeys(result)
+}
+
+func (h *handler) camelCaseKeys(value interface{}) interface{} {
+	switch item := value.(type) {
+	case []interface{}:
+		for index := range item {
+			item[index] = h.camelCaseKeys(item[index])
+		}
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(item))
+		for key, field := range item {
+			if !jsonCaseUserFields[key] {
+				field = h.camelCaseKeys(field)
+			}
+			result[h.camelCase(key)] = field
+		}
+		return result
+	}
+	return value
+}
+
+// camelCase converts a snake_case field e.g. created_at_local to camelCase e.g. createdAtLocal
+func (h *handler) camelCase(key string) string {
+	parts := strings.Split(key, "_")
+	for index := 1; index < len(parts); index++ {
+		if parts[index] != "" {
+			parts[index] = strings.ToUpper(parts[index][:1]) + parts[index][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// localTimestampFormat is the human-readable format of the *_local timestamps in list responses
+const localTimestampFormat = "Mon, 02 Jan 2006 15:04:05 MST"
+
+// location returns the timezone of the tz query parameter, falling back to the timezone of the user's setting and then UTC
+func (h *handler) location(c *fiber.Ctx) *time.Location {
+	if timezone := strings.TrimSpace(c.Query("tz")); timezone != "" {
+		if location, err := time.LoadLocation(timezone); err == nil {
+			return location
+		}
+	}
+	if user, ok := c.Locals(middlewares.ContextKeyAuthUserID).(entities.AuthUser); ok && user.Timezone != "" {
+		if location, err := time.LoadLocation(user.Timezone); err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
+
+// localizeTimestamps adds a human-readable *_local field in the timezone of the request next to every UTC timestamp of a list.
+// The data is returned unchanged when it is not a list so that only list endpoints have the extra fields.
+func (h *handler) localizeTimestamps(c *fiber.Ctx, data interface{}) interface{} {
+	value := reflect.ValueOf(data)
+	for value.Kind() == reflect.Pointer && !value.IsNil() {
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Slice {
+		return data
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return data
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	var result interface{}
+	if err = decoder.Decode(&result); err != nil {
+		return data
+	}
+	return h.addLocalTimestamps(result, h.location(c))
+}
+
+// addLocalTimestamps adds the *_local fields to the nested fields as well except the fields in jsonCaseUserFields which belong to the user
+func (h *handler) addLocalTimestamps(value interface{}, location *time.Location) interface{} {
+	switch item := value.(type) {
+	case []interface{}:
+		for index := range item {
+			item[index] = h.addLocalTimestamps(item[index], location)
+		}
+	case map[string]interface{}:
+		for key, field := range item {
+			if timestamp, ok := field.(string); ok && (strings.HasSuffix(key, "_at") || strings.HasSuffix(key, "timestamp")) {
+				if parsed, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+					item[key+"_local"] = parsed.In(location).Format(localTimestampFormat)
+				}
+				continue
+			}
+			if !jsonCaseUserFields[key] {
+				item[key] = h.addLocalTimestamps(field, location)
+			}
+		}
+	}
+	return value
+}
+
+// responseOKWithETag responds with 304 Not Modified without a body when the If-None-Match header matches the etag
+func (h *handler) responseOKWithETag(c *fiber.Ctx, etag string, message string, data interface{}) error {
+	// the timezone, the language of the message and the naming of the fields are part of the etag because they change the body
+	etag = fmt.Sprintf(`%s-%s-%s"`, strings.TrimSuffix(etag, `"`), h.location(c), h.locale(c))
+	if h.isCamelCase(c) {
+		etag = fmt.Sprintf(`%s-%s"`, strings.TrimSuffix(etag, `"`), jsonCaseCamel)
+	}
+	c.Set(fiber.HeaderVary, fiber.HeaderAcceptLanguage+", "+jsonCaseHeader)
+	c.Set(fiber.HeaderETag, etag)
+	if h.etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+	return h.responseOK(c, message, data)
+}
+
+// listETag is a weak ETag for a list derived from the number of items which match the filter and the latest time when one of them was updated.
+// The page is not enough because an item which is updated or deleted outside the page changes the items in the page.
+// It is weak because the body is compressed.
+func (h *handler) listETag(version *repositories.ListVersion, params repositories.IndexParams) string {
+	return fmt.Sprintf(`W/"%d-%x-%d-%d"`, version.Count, version.LatestUpdatedAt.UnixNano(), params.Skip, params.Limit)
+}
+
+func (h *handler) etagMatches(header string, etag string) bool {
+	for _, value := range strings.Split(header, ",") {
+		value = strings.TrimSpace(value)
+		if value == "*" || strings.TrimPrefix(value, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
 }
 
 func (h *handler) responseCreated(c *fiber.Ctx, message string, data interface{}) error {
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+	return c.Status(fiber.StatusCreated).JSON(h.fieldCase(c, fiber.Map{
 		"status":  "success",
-		"message": message,
+		"message": h.translate(c, message),
 		"data":    data,
-	})
+	}))
+}
+
+// pluralize returns the singular or plural form of an english noun in the locale of the request
+func (h *handler) pluralize(c *fiber.Ctx, value string, count int) string {
+	return i18n.Pluralize(h.locale(c), value, count)
+}
+
+// translate returns the message in the locale of the request
+func (h *handler) translate(c *fiber.Ctx, message string, args ...any) string {
+	return i18n.Translate(h.locale(c), message, args...)
 }
 
-func (h *handler) pluralize(value string, count int) string {
-	if count == 1 {
-		return value
+// locale returns the locale of the user's setting, falling back to the Accept-Language header
+func (h *handler) locale(c *fiber.Ctx) i18n.Locale {
+	if user, ok := c.Locals(middlewares.ContextKeyAuthUserID).(entities.AuthUser); ok {
+		if locale := i18n.ParseLocale(user.Locale); locale != "" {
+			return locale
+		}
 	}
-	return value + "s"
+	return i18n.ParseAcceptLanguage(c.Get(fiber.HeaderAcceptLanguage))
 }
 
 func (h *handler) userFromContext(c *fiber.Ctx) entities.AuthUser {
@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// HealthHandler handles liveness and readiness http requests.
+type HealthHandler struct {
+	handler
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.HealthService
+}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.HealthService,
+) (h *HealthHandler) {
+	return &HealthHandler{
+		logger:  logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// RegisterRoutes registers the routes for the HealthHandler
+func (h *HealthHandler) RegisterRoutes(router fiber.Router) {
+	router.Get("/healthz", h.Liveness)
+	router.Get("/readyz", h.Readiness)
+}
+
+// Liveness checks that the server is running without checking any dependency
+// This is an internal API so no documentation provided
+func (h *HealthHandler) Liveness(c *fiber.Ctx) error {
+	return h.responseOK(c, "service is alive", nil)
+}
+
+// Readiness checks that the server can reach the database and firebase cloud messaging
+// This is an internal API so no documentation provided
+func (h *HealthHandler) Readiness(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	ready, checks := h.service.Readiness(ctx)
+	if !ready {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("service is not ready with checks [%+#v]", checks)))
+		return h.responseServiceUnavailable(c, "some dependencies are unavailable", checks)
+	}
+
+	return h.responseOK(c, "service is ready", checks)
+}
@@ -53,7 +53,10 @@ func (h *HeartbeatHandler) RegisterRoutes(router fiber.Router) {
 // @Param        skip		query  int  	false	"number of heartbeats to skip"		minimum(0)
 // @Param        query		query  string  	false 	"filter containing query"
 // @Param        limit		query  int  	false	"number of heartbeats to return"	minimum(1)	maximum(20)
+// @Param        If-None-Match	header string	false	"ETag of a previous response, 304 Not Modified is returned when the list has not changed"
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
 // @Success      200 		{object}	responses.HeartbeatsResponse
+// @Header       200		{string}	ETag	"weak ETag derived from the number of items matching the filter and their latest update"
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
 // @Failure      422		{object}	responses.UnprocessableEntity
@@ -78,14 +81,24 @@ func (h *HeartbeatHandler) Index(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching heartbeats")
 	}
 
-	heartbeats, err := h.service.Index(ctx, h.userIDFomContext(c), request.Owner, request.ToIndexParams())
+	params := request.ToIndexParams()
+
+	// the version is fetched before the heartbeats so that a heartbeat which is stored in between changes the etag of the next request
+	version, err := h.service.IndexVersion(ctx, h.userIDFomContext(c), request.Owner, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot get the version of heartbeats with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	heartbeats, err := h.service.Index(ctx, h.userIDFomContext(c), request.Owner, params)
 	if err != nil {
 		msg := fmt.Sprintf("cannot get messgaes with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*heartbeats), h.pluralize("heartbeat", len(*heartbeats))), heartbeats)
+	return h.responseOKWithETag(c, h.listETag(version, params), h.translate(c, "fetched %d %s", len(*heartbeats), h.pluralize(c, "heartbeat", len(*heartbeats))), heartbeats)
 }
 
 // Store the heartbeat of a phone number
@@ -6,6 +6,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/davecgh/go-spew/spew"
 
+	"github.com/NdoleStudio/httpsms/pkg/responses"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/gofiber/fiber/v2"
@@ -74,6 +75,11 @@ func (h *Integration3CXHandler) Messages(c *fiber.Ctx) error {
 
 	request.Sanitize()
 	message, err := h.messageService.SendMessage(ctx, request.ToMessageSendParams(h.userIDFomContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodeMessageContactUnresolvable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot resolve the recipient of [3cx] message with payload [%s]", c.Body())))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeContactUnresolvable), h.translate(c, "the to field is not a valid phone number in your default country, use the international format e.g. +18005550199"), nil)
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("cannot send [3cx] message with paylod [%s]", c.Body())
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -0,0 +1,367 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// maxLabels is the maximum number of labels of a user
+const maxLabels = 100
+
+// LabelHandler handles label requests
+type LabelHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.LabelService
+	validator *validators.LabelHandlerValidator
+}
+
+// NewLabelHandler creates a new LabelHandler
+func NewLabelHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.LabelService,
+	validator *validators.LabelHandlerValidator,
+) (h *LabelHandler) {
+	return &LabelHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the LabelHandler
+func (h *LabelHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/labels")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Put("/:labelID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:labelID", h.computeRoute(middlewares, h.Delete)...)
+
+	messages := app.Group("/v1/messages")
+	messages.Get("/:messageID/labels", h.computeRoute(middlewares, h.MessageIndex)...)
+	messages.Put("/:messageID/labels/:labelID", h.computeRoute(middlewares, h.Attach)...)
+	messages.Delete("/:messageID/labels/:labelID", h.computeRoute(middlewares, h.Detach)...)
+}
+
+// Index returns the labels of a user
+// @Summary      Get labels of a user
+// @Description  Get the labels which a user attaches to messages e.g. urgent or follow-up
+// @Security	 ApiKeyAuth
+// @Tags         Labels
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of labels to skip"		minimum(0)
+// @Param        query		query  string  	false 	"label labels containing query"
+// @Param        limit		query  int  	false	"number of labels to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.LabelsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /labels 	[get]
+func (h *LabelHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.LabelIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching labels [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching labels")
+	}
+
+	labels, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get labels for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(labels), h.pluralize(c, "label", len(labels))), labels)
+}
+
+// Store a label
+// @Summary      Store a label
+// @Description  Store a colored label which the authenticated user can attach to messages
+// @Security	 ApiKeyAuth
+// @Tags         Labels
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.LabelStore  	true "Payload of the label"
+// @Success      201 		{object}	responses.LabelResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /labels [post]
+func (h *LabelHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.LabelStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T] for user [%s]", request, h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing label for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing label")
+	}
+
+	labels, err := h.service.Index(ctx, h.userIDFomContext(c), repositories.IndexParams{Skip: 0, Limit: maxLabels})
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index labels for user [%s]", h.userIDFomContext(c))))
+		return h.responseInternalServerError(c)
+	}
+
+	if len(labels) == maxLabels {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] wants to create more than [%d] labels", h.userIDFomContext(c), maxLabels)))
+		return h.responsePaymentRequired(c, fmt.Sprintf("You can't create more than %d labels contact us to upgrade to our enterprise plan.", maxLabels))
+	}
+
+	label, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store label for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "label created successfully", label)
+}
+
+// Update an entities.Label
+// @Summary      Update a label
+// @Description  Update a label of the currently authenticated user
+// @Security	 ApiKeyAuth
+// @Tags         Labels
+// @Accept       json
+// @Produce      json
+// @Param 		 labelID	path		string 							true 	"ID of the label" 				default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   			body 		requests.LabelUpdate  	true 	"Payload of the label to update"
+// @Success      200 		{object}	responses.LabelResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /labels/{labelID} 	[put]
+func (h *LabelHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.LabelUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T] for user [%s]", request, h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.LabelID = c.Params("labelID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating label [%s]", spew.Sdump(errors), request.LabelID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating label")
+	}
+
+	label, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find label with ID [%s]", request.LabelID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update label with ID [%s]", request.LabelID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "label updated successfully", label)
+}
+
+// Delete a label
+// @Summary      Delete label
+// @Description  Delete a label of a user, it is detached from all the messages
+// @Security	 ApiKeyAuth
+// @Tags         Labels
+// @Accept       json
+// @Produce      json
+// @Param 		 labelID 	path		string 							true 	"ID of the label"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /labels/{labelID} [delete]
+func (h *LabelHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	labelID := c.Params("labelID")
+	if errors := h.validator.ValidateUUID(ctx, labelID, "labelID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting label with ID [%s]", spew.Sdump(errors), labelID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting label")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(labelID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find label with ID [%s]", labelID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete label with ID [%s]", labelID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "label deleted successfully", nil)
+}
+
+// MessageIndex returns the labels of a message
+// @Summary      Get the labels of a message
+// @Description  Get the labels which are attached to a message of the authenticated user
+// @Security	 ApiKeyAuth
+// @Tags         Labels
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID	path		string 	true 	"ID of the message" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.LabelsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/{messageID}/labels 	[get]
+func (h *LabelHandler) MessageIndex(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateUUID(ctx, messageID, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching the labels of message [%s]", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching the labels of the message")
+	}
+
+	labels, err := h.service.MessageLabels(ctx, h.userIDFomContext(c), uuid.MustParse(messageID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the labels of message [%s] for user [%s]", messageID, h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(labels), h.pluralize(c, "label", len(labels))), labels)
+}
+
+// Attach a label to a message
+// @Summary      Attach a label to a message
+// @Description  Attach a label of the authenticated user to a message, the labels of the message are returned. A message can have at most 10 labels.
+// @Security	 ApiKeyAuth
+// @Tags         Labels
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID	path		string 	true 	"ID of the message" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 labelID	path		string 	true 	"ID of the label" 		default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      200 		{object}	responses.LabelsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/{messageID}/labels/{labelID} 	[put]
+func (h *LabelHandler) Attach(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID, labelID := c.Params("messageID"), c.Params("labelID")
+	if errors := h.validator.ValidateMessageLabel(ctx, messageID, labelID); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while attaching label [%s] to message [%s]", spew.Sdump(errors), labelID, messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while attaching the label")
+	}
+
+	labels, err := h.service.Attach(ctx, h.userIDFomContext(c), uuid.MustParse(messageID), uuid.MustParse(labelID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s] or label with ID [%s]", messageID, labelID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageLabelLimitExceeded {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot attach label [%s] to message [%s]", labelID, messageID)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "a message cannot have more than %d labels", services.MaxLabelsPerMessage), nil)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot attach label [%s] to message [%s] for user [%s]", labelID, messageID, h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "label attached successfully", labels)
+}
+
+// Detach a label from a message
+// @Summary      Detach a label from a message
+// @Description  Detach a label from a message of the authenticated user, the remaining labels of the message are returned
+// @Security	 ApiKeyAuth
+// @Tags         Labels
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID	path		string 	true 	"ID of the message" 	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 labelID	path		string 	true 	"ID of the label" 		default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      200 		{object}	responses.LabelsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/{messageID}/labels/{labelID} 	[delete]
+func (h *LabelHandler) Detach(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID, labelID := c.Params("messageID"), c.Params("labelID")
+	if errors := h.validator.ValidateMessageLabel(ctx, messageID, labelID); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while detaching label [%s] from message [%s]", spew.Sdump(errors), labelID, messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while detaching the label")
+	}
+
+	labels, err := h.service.Detach(ctx, h.userIDFomContext(c), uuid.MustParse(messageID), uuid.MustParse(labelID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("the label with ID [%s] is not attached to the message with ID [%s]", labelID, messageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot detach label [%s] from message [%s] for user [%s]", labelID, messageID, h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "label detached successfully", labels)
+}
@@ -1,17 +1,23 @@
 package handlers
 
 import (
+	"bufio"
+	"context"
+	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/i18n"
 
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/google/uuid"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
@@ -56,22 +62,46 @@ func (h *MessageHandler) RegisterRoutes(router fiber.Router) {
 	router.Get("/messages/outstanding", h.GetOutstanding)
 	router.Get("/messages", h.Index)
 	router.Get("/messages/search", h.Search)
+	router.Get("/messages/poll", h.Poll)
+	router.Post("/messages/mark-read", h.PostMarkRead)
 	router.Post("/messages/:messageID/events", h.PostEvent)
+	router.Post("/messages/:messageID/cancel", h.PostCancel)
+	router.Patch("/messages/:messageID/read-receipt", h.PatchReadReceipt)
+	router.Post("/messages/:messageID/react", h.PostReact)
+	router.Post("/messages/:messageID/reactions", h.PostReaction)
+	router.Patch("/messages/:messageID/star", h.PatchStar)
 	router.Delete("/messages/:messageID", h.Delete)
+	router.Get("/messages/:messageID/reply-chain", h.GetReplyChain)
+	router.Get("/messages/:messageID/media/:index", h.GetMedia)
+}
+
+// RegisterReplyRoutes registers the routes which are authenticated with a reply token instead of an API key, the middlewares limit the requests of each IP
+func (h *MessageHandler) RegisterReplyRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	app.Post("/v1/messages/reply", h.computeRoute(middlewares, h.PostReply)...)
+}
+
+// RegisterQueryKeyRoutes registers the routes which are authenticated with the key query parameter instead of the X-API-Key header
+func (h *MessageHandler) RegisterQueryKeyRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	app.Get("/v1/messages/send", h.computeRoute(middlewares, h.GetSend)...)
 }
 
 // PostSend a new entities.Message
 // @Summary      Send a new SMS message
-// @Description  Add a new SMS message to be sent by the android phone
+// @Description  Add a new SMS message to be sent by the android phone. When the to field is an array, a message is created for each valid recipient with the same group ID and the response contains a services.MessageFanOut with the status 207 when some recipients are rejected. When wait is true, the response is sent once the push notification of the message is accepted or rejected by firebase cloud messaging, the message has push_wait_timed_out set when this does not happen before the timeout.
 // @Security	 ApiKeyAuth
 // @Tags         Messages
 // @Accept       json
 // @Produce      json
 // @Param        payload   body requests.MessageSend  true  "PostSend message request payload"
+// @Param        wait      query  bool    false  "wait for the push notification of the message before responding"  default(false)
+// @Param        timeout   query  string  false  "maximum duration to wait for the push notification between 1s and 30s"  default(5s)
 // @Success      200  {object}  responses.MessageResponse
+// @Success      207  {object}  responses.MessageFanOutResponse
 // @Failure      400  {object}  responses.BadRequest
 // @Failure 	 401  {object}	responses.Unauthorized
+// @Failure      409  {object}  responses.BadRequest
 // @Failure      422  {object}  responses.UnprocessableEntity
+// @Failure      429  {object}  responses.TooManyRequests
 // @Failure      500  {object}  responses.InternalServerError
 // @Router       /messages/send [post]
 func (h *MessageHandler) PostSend(c *fiber.Ctx) error {
@@ -93,14 +123,264 @@ func (h *MessageHandler) PostSend(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
 	}
 
+	if errors := h.validator.ValidateMessageContent(ctx, h.userIDFomContext(c), request.Content, request.Encrypted); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("the content of the message from user [%s] is blocked by a content filter", h.userIDFomContext(c))))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	var wait requests.MessageSendWait
+	if err := c.QueryParser(&wait); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), wait)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageSendWait(ctx, wait.Sanitize(), request); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while sending message with query [%s]", spew.Sdump(errors), c.OriginalURL())
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	return h.send(c, request, wait, c.OriginalURL())
+}
+
+// GetSend a new entities.Message with a GET request
+// @Summary      Send a new SMS message with a GET request
+// @Description  Add a new SMS message to be sent by the android phone for devices which can only send GET requests. The request is validated like POST /messages/send and it is authenticated with the API key in the key query parameter. The API key is part of the URL so it can be stored in the logs of proxies and in the browser history, this is why get_send_enabled must be enabled in the user settings and it is disabled when the API key is rotated. Use POST /messages/send whenever the device supports it.
+// @Tags         Messages
+// @Produce      json
+// @Param        key       query  string  true   "API key of the user"
+// @Param        from      query  string  true   "phone number of the phone which sends the message"  default(+18005550199)
+// @Param        to        query  string  true   "phone number of the recipient"  default(+18005550100)
+// @Param        content   query  string  true   "content of the message"  default(This is a sample text message)
+// @Param        wait      query  bool    false  "wait for the push notification of the message before responding"  default(false)
+// @Param        timeout   query  string  false  "maximum duration to wait for the push notification between 1s and 30s"  default(5s)
+// @Success      200  {object}  responses.MessageResponse
+// @Failure      400  {object}  responses.BadRequest
+// @Failure 	 401  {object}	responses.Unauthorized
+// @Failure 	 403  {object}	responses.Forbidden
+// @Failure      409  {object}  responses.BadRequest
+// @Failure      422  {object}  responses.UnprocessableEntity
+// @Failure      429  {object}  responses.TooManyRequests
+// @Failure      500  {object}  responses.InternalServerError
+// @Router       /messages/send [get]
+func (h *MessageHandler) GetSend(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	// the URL contains the API key so the response must not be cached by proxies
+	c.Set(fiber.HeaderCacheControl, "no-store")
+
+	var query requests.MessageSendQuery
+	if err := c.QueryParser(&query); err != nil {
+		msg := fmt.Sprintf("cannot marshall params of [%s] into %T", c.Path(), query)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request := query.ToMessageSend()
+	if errors := h.validator.ValidateMessageSend(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while sending message with a GET request for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	if errors := h.validator.ValidateMessageContent(ctx, h.userIDFomContext(c), request.Content, request.Encrypted); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("the content of the message from user [%s] is blocked by a content filter", h.userIDFomContext(c))))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	var wait requests.MessageSendWait
+	if err := c.QueryParser(&wait); err != nil {
+		msg := fmt.Sprintf("cannot marshall params of [%s] into %T", c.Path(), wait)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageSendWait(ctx, wait.Sanitize(), request); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while sending message with a GET request for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	// the path is the source of the message events because the query contains the API key
+	return h.send(c, request, wait, c.Path())
+}
+
+// send the validated request of POST or GET /v1/messages/send
+func (h *MessageHandler) send(c *fiber.Ctx, request requests.MessageSend, wait requests.MessageSendWait, source string) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if request.IsFanOut() {
+		return h.sendFanOut(c, request)
+	}
+
 	if msg := h.billingService.IsEntitled(ctx, h.userIDFomContext(c)); msg != nil {
 		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't send a message", h.userIDFomContext(c))))
 		return h.responsePaymentRequired(c, *msg)
 	}
 
-	message, err := h.service.SendMessage(ctx, request.ToMessageSendParams(h.userIDFomContext(c), c.OriginalURL()))
+	message, err := h.service.SendMessage(ctx, request.ToMessageSendParams(h.userIDFomContext(c), source))
+	if stacktrace.GetCode(err) == services.ErrCodePhoneDailyQuotaExceeded {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone [%s] has exhausted its daily quota", request.From)))
+		return h.responseError(c, fiber.StatusTooManyRequests, h.errorCode(err, responses.ErrorCodeRateLimited), h.translate(c, "the phone has already sent its daily quota of messages, please try again tomorrow"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageInReplyToInvalid {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot reply to message [%s]", request.InReplyTo)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeNotFound), h.translate(c, "the message being replied to does not exist or is not in the same conversation"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageRecipientNotAllowed {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone [%s] cannot send messages to [%s]", request.From, request.To)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeRecipientNotAllowed), h.translate(c, "the phone can only send messages to its allowed recipients and the to field is not one of them"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageShortCodeNotSupported {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone [%s] cannot send messages to the short code [%s]", request.From, request.To)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeShortCodeNotSupported), h.translate(c, "the phone cannot send messages to short codes, use a phone which supports short codes"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhonePaused {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone [%s] is paused and it rejects messages", request.From)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodePhonePaused), h.translate(c, "the phone is paused and it does not accept messages, resume the phone or send the message from another phone"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeCountryRouteNotFound {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("no country route matches the recipient [%s]", request.To)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeCountryRouteNotFound), h.translate(c, "no country route matches the recipient, set the from field or add a default country route"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageContactUnresolvable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot resolve the recipient [%s]", request.To)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeContactUnresolvable), h.translate(c, "the to field is not a valid phone number in your default country, use the international format e.g. +18005550199"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageIDConflict {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the message ID [%s] is already used", request.ID)))
+		return h.responseConflict(c, "a message with this id already exists, generate a new id for every message", nil)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot send message from [%s] to [%s] for user [%s]", request.From, request.To, h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	if wait.IsWaiting() {
+		return h.waitForPush(c, message, wait.TimeoutDuration())
+	}
+
+	return h.responseOK(c, "message added to queue", message)
+}
+
+// waitForPush responds with the message once its push notification is sent, the message is already in the queue so it is returned even when the wait fails
+func (h *MessageHandler) waitForPush(c *fiber.Ctx, message *entities.Message, timeout time.Duration) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	result, err := h.service.WaitForPush(ctx, message.UserID, message.ID, timeout)
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot wait for the push notification of message [%s]", message.ID)))
+		result = message
+		result.PushWaitTimedOut = true
+	}
+
+	if result.PushWaitTimedOut {
+		return h.responseOK(c, "message added to queue but the push notification timed out", result)
+	}
+	return h.responseOK(c, "message added to queue and the push notification was sent", result)
+}
+
+// PostReply replies to a received message
+// @Summary      Reply to a received message
+// @Description  Sends a reply to the contact of a received message using the reply_token of the message.phone.received event. The request does not need an API key because the token is signed and it expires 15 minutes after the message is received. A token can only send 1 reply.
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        payload   body requests.MessageReply  true  "Payload with the reply token and the content of the reply"
+// @Success      200  {object}  responses.MessageResponse
+// @Failure      400  {object}  responses.BadRequest
+// @Failure 	 401  {object}	responses.Unauthorized
+// @Failure      402  {object}  responses.BadRequest
+// @Failure      409  {object}  responses.BadRequest
+// @Failure      422  {object}  responses.UnprocessableEntity
+// @Failure      429  {object}  responses.TooManyRequests
+// @Failure      500  {object}  responses.InternalServerError
+// @Router       /messages/reply [post]
+func (h *MessageHandler) PostReply(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageReply
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageReply(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while replying with the token of a received message", spew.Sdump(errors))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	claims, err := h.service.VerifyReplyToken(ctx, request.ReplyToken)
+	if stacktrace.GetCode(err) == services.ErrCodeReplyTokenInvalid {
+		ctxLogger.Warn(stacktrace.Propagate(err, "cannot reply with an invalid reply token"))
+		return h.responseError(c, fiber.StatusUnauthorized, h.errorCode(err, responses.ErrorCodeUnauthorized), h.translate(c, "the reply token is not valid or it has expired"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeReplyTokenUsed {
+		ctxLogger.Warn(stacktrace.Propagate(err, "cannot reply with a reply token which has already been used"))
+		return h.responseConflict(c, "the reply token has already been used to reply to the message", nil)
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot verify the reply token"))
+		return h.responseInternalServerError(c)
+	}
+
+	if errors := h.validator.ValidateMessageReplySegments(ctx, claims, request.Content); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while replying to message [%s]", spew.Sdump(errors), claims.MessageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	if errors := h.validator.ValidateMessageContent(ctx, claims.UserID, request.Content, false); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("the content of the reply from user [%s] is blocked by a content filter", claims.UserID)))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending message")
+	}
+
+	if msg := h.billingService.IsEntitled(ctx, claims.UserID); msg != nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] can't reply to message [%s]", claims.UserID, claims.MessageID)))
+		return h.responsePaymentRequired(c, *msg)
+	}
+
+	message, err := h.service.SendMessage(ctx, request.ToMessageSendParams(claims, c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodePhoneDailyQuotaExceeded {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone [%s] has exhausted its daily quota", claims.Owner)))
+		return h.responseError(c, fiber.StatusTooManyRequests, h.errorCode(err, responses.ErrorCodeRateLimited), h.translate(c, "the phone has already sent its daily quota of messages, please try again tomorrow"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageRecipientNotAllowed {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone [%s] cannot send messages to [%s]", claims.Owner, claims.Contact)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeRecipientNotAllowed), h.translate(c, "the phone can only send messages to its allowed recipients and the to field is not one of them"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageShortCodeNotSupported {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone [%s] cannot send messages to the short code [%s]", claims.Owner, claims.Contact)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeShortCodeNotSupported), h.translate(c, "the phone cannot send messages to short codes, use a phone which supports short codes"), nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhonePaused {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone [%s] is paused and it rejects messages", claims.Owner)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodePhonePaused), h.translate(c, "the phone is paused and it does not accept messages, resume the phone or send the message from another phone"), nil)
+	}
+
 	if err != nil {
-		msg := fmt.Sprintf("cannot send message with paylod [%s]", c.Body())
+		msg := fmt.Sprintf("cannot reply to message [%s] of user [%s]", claims.MessageID, claims.UserID)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
@@ -108,15 +388,86 @@ func (h *MessageHandler) PostSend(c *fiber.Ctx) error {
 	return h.responseOK(c, "message added to queue", message)
 }
 
+// sendFanOut sends the content of the request to each recipient, the recipients which are not valid or cannot be sent the message are reported as failures
+func (h *MessageHandler) sendFanOut(c *fiber.Ctx, request requests.MessageSend) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	recipients, failures := h.validateRecipients(request.Recipients)
+
+	if msg := h.billingService.IsEntitledWithCount(ctx, h.userIDFomContext(c), uint(len(recipients))); msg != nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] is not entitled to send [%d] messages", h.userIDFomContext(c), len(recipients))))
+		return h.responsePaymentRequired(c, *msg)
+	}
+
+	groupID, params := request.ToFanOutParams(h.userIDFomContext(c), c.OriginalURL(), recipients)
+	result := services.MessageFanOut{GroupID: groupID, Messages: []entities.Message{}, Failures: failures}
+	for _, message := range params {
+		response, err := h.service.SendMessage(ctx, message)
+		if err != nil {
+			result.Failures = append(result.Failures, h.fanOutFailure(c, ctxLogger, message.Contact, err))
+			continue
+		}
+		result.Messages = append(result.Messages, *response)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent [%d] messages with group ID [%s] and [%d] failures for user [%s]", len(result.Messages), groupID, len(result.Failures), h.userIDFomContext(c)))
+	return h.responseMultiStatus(c, len(result.Failures), fmt.Sprintf("[%d] messages added to queue", len(result.Messages)), result)
+}
+
+// validateRecipients validates every recipient on its own so that an invalid phone number does not reject the other recipients
+func (h *MessageHandler) validateRecipients(recipients []string) ([]string, []services.MessageFanOutFailure) {
+	var valid []string
+	failures := []services.MessageFanOutFailure{}
+	for _, recipient := range recipients {
+		if errors := h.validator.ValidateMessageSendRecipient(recipient); len(errors) != 0 {
+			failures = append(failures, services.MessageFanOutFailure{To: recipient, Error: errors.Get("to"), ErrorCode: string(responses.ErrorCodeInvalidPhoneNumber)})
+			continue
+		}
+		valid = append(valid, recipient)
+	}
+	return valid, failures
+}
+
+// fanOutFailure returns the reason why a recipient of a fan-out was not sent the message
+func (h *MessageHandler) fanOutFailure(c *fiber.Ctx, ctxLogger telemetry.Logger, to string, err error) services.MessageFanOutFailure {
+	failure := services.MessageFanOutFailure{To: to, ErrorCode: string(h.errorCode(err, responses.ErrorCodeInternalError))}
+	switch stacktrace.GetCode(err) {
+	case services.ErrCodePhoneDailyQuotaExceeded:
+		ctxLogger.Warn(stacktrace.Propagate(err, "the phone has exhausted its daily quota"))
+		failure.Error = h.translate(c, "the phone has already sent its daily quota of messages, please try again tomorrow")
+	case services.ErrCodeMessageRecipientNotAllowed:
+		ctxLogger.Warn(stacktrace.Propagate(err, "the recipient is not allowed"))
+		failure.Error = h.translate(c, "the phone can only send messages to its allowed recipients and the to field is not one of them")
+	case services.ErrCodeMessageShortCodeNotSupported:
+		ctxLogger.Warn(stacktrace.Propagate(err, "the recipient is a short code"))
+		failure.Error = h.translate(c, "the phone cannot send messages to short codes, use a phone which supports short codes")
+	case services.ErrCodePhonePaused:
+		ctxLogger.Warn(stacktrace.Propagate(err, "the phone is paused"))
+		failure.Error = h.translate(c, "the phone is paused and it does not accept messages, resume the phone or send the message from another phone")
+	case services.ErrCodeCountryRouteNotFound:
+		ctxLogger.Warn(stacktrace.Propagate(err, "no country route matches the recipient"))
+		failure.Error = h.translate(c, "no country route matches the recipient, set the from field or add a default country route")
+	case services.ErrCodeMessageContactUnresolvable:
+		ctxLogger.Warn(stacktrace.Propagate(err, "the recipient cannot be resolved"))
+		failure.Error = h.translate(c, "the to field is not a valid phone number in your default country, use the international format e.g. +18005550199")
+	default:
+		ctxLogger.Error(stacktrace.Propagate(err, "cannot send message of fan-out"))
+		failure.Error = h.translate(c, "the message could not be sent, please try again later")
+	}
+	return failure
+}
+
 // BulkSend a bulk entities.Message
 // @Summary      Send bulk SMS messages
-// @Description  Add bulk SMS messages to be sent by the android phone
+// @Description  Add bulk SMS messages to be sent by the android phone. The valid recipients are sent the message and the response has the status 207 with the reason of each rejected recipient when some recipients are rejected
 // @Security	 ApiKeyAuth
 // @Tags         Messages
 // @Accept       json
 // @Produce      json
 // @Param        payload   body requests.MessageBulkSend  true  "Bulk send message request payload"
-// @Success      200  {object}  []responses.MessagesResponse
+// @Success      200  {object}  responses.MessageFanOutResponse
+// @Success      207  {object}  responses.MessageFanOutResponse
 // @Failure      400  {object}  responses.BadRequest
 // @Failure 	 401  {object}	responses.Unauthorized
 // @Failure      422  {object}  responses.UnprocessableEntity
@@ -141,30 +492,43 @@ func (h *MessageHandler) BulkSend(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while sending messages")
 	}
 
-	if msg := h.billingService.IsEntitledWithCount(ctx, h.userIDFomContext(c), uint(len(request.To))); msg != nil {
-		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] is not entitled to send [%d] messages", h.userIDFomContext(c), len(request.To))))
+	if errors := h.validator.ValidateMessageContent(ctx, h.userIDFomContext(c), request.Content, request.Encrypted); len(errors) != 0 {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("the content of the bulk messages from user [%s] is blocked by a content filter", h.userIDFomContext(c))))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while sending messages")
+	}
+
+	recipients, failures := h.validateRecipients(request.To)
+	if msg := h.billingService.IsEntitledWithCount(ctx, h.userIDFomContext(c), uint(len(recipients))); msg != nil {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] is not entitled to send [%d] messages", h.userIDFomContext(c), len(recipients))))
 		return h.responsePaymentRequired(c, *msg)
 	}
 
 	wg := sync.WaitGroup{}
-	params := request.ToMessageSendParams(h.userIDFomContext(c), c.OriginalURL())
-	responses := make([]*entities.Message, len(params))
+	groupID, params := request.ToMessageSendParams(h.userIDFomContext(c), c.OriginalURL(), recipients)
+	messages := make([]*entities.Message, len(params))
+	errs := make([]error, len(params))
 
 	for index, message := range params {
 		wg.Add(1)
 		go func(message services.MessageSendParams, index int) {
-			response, err := h.service.SendMessage(ctx, message)
-			if err != nil {
-				msg := fmt.Sprintf("cannot send message with paylod [%s]", c.Body())
-				ctxLogger.Error(stacktrace.Propagate(err, msg))
-			}
-			responses[index] = response
+			messages[index], errs[index] = h.service.SendMessage(ctx, message)
 			wg.Done()
 		}(message, index)
 	}
-
 	wg.Wait()
-	return h.responseOK(c, fmt.Sprintf("[%d] messages processed successfully", len(responses)), responses)
+
+	// the results are collected after all the messages are sent so that they are in the same order as the recipients
+	result := services.MessageFanOut{GroupID: groupID, Messages: []entities.Message{}, Failures: failures}
+	for index, message := range params {
+		if errs[index] != nil {
+			result.Failures = append(result.Failures, h.fanOutFailure(c, ctxLogger, message.Contact, errs[index]))
+			continue
+		}
+		result.Messages = append(result.Messages, *messages[index])
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent [%d] bulk messages with group ID [%s] and [%d] failures for user [%s]", len(result.Messages), groupID, len(result.Failures), h.userIDFomContext(c)))
+	return h.responseMultiStatus(c, len(result.Failures), fmt.Sprintf("[%d] messages processed successfully", len(result.Messages)), result)
 }
 
 // GetOutstanding returns an entities.Message which is still to be sent by the mobile phone
@@ -224,12 +588,22 @@ func (h *MessageHandler) GetOutstanding(c *fiber.Ctx) error {
 // @Tags         Messages
 // @Accept       json
 // @Produce      json
-// @Param        owner		query  string  	true 	"the owner's phone number" 			default(+18005550199)
-// @Param        contact	query  string  	true 	"the contact's phone number" 		default(+18005550100)
-// @Param        skip		query  int  	false	"number of messages to skip"		minimum(0)
-// @Param        query		query  string  	false 	"filter messages containing query"
-// @Param        limit		query  int  	false	"number of messages to return"		minimum(1)	maximum(20)
+// @Param        owner			query  string  	true 	"the owner's phone number" 			default(+18005550199)
+// @Param        contact		query  string  	true 	"the contact's phone number" 		default(+18005550100)
+// @Param        contact_match	query  string  	false 	"exact by default, contains or ends_with match the digits of the contact e.g. the last 7 digits"	Enums(exact, contains, ends_with)
+// @Param        skip			query  int  	false	"number of messages to skip"		minimum(0)
+// @Param        query			query  string  	false 	"filter messages containing query"
+// @Param        limit			query  int  	false	"number of messages to return"		minimum(1)	maximum(20)
+// @Param        metadata_key	query  string  	false 	"filter messages having this metadata key, owner and contact are optional when set"
+// @Param        metadata_value	query  string  	false 	"value of the metadata key to filter messages"
+// @Param        category		query  string  	false 	"filter received messages by category"	Enums(otp, marketing, personal, unknown)
+// @Param        language		query  string  	false 	"filter received messages by the detected ISO 639-1 language code"	default(en)
+// @Param        starred		query  bool  	false 	"fetch only the starred messages, owner and contact are optional when set"
+// @Param        label_id		query  string  	false 	"fetch only the messages with the label, owner and contact are optional when set"
+// @Param        If-None-Match	header string	false	"ETag of a previous response, 304 Not Modified is returned when the list has not changed"
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
 // @Success      200 		{object}	responses.MessagesResponse
+// @Header       200		{string}	ETag	"weak ETag derived from the number of items matching the filter and their latest update, it is not set when the messages have a queue position"
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
 // @Failure      422		{object}	responses.UnprocessableEntity
@@ -254,14 +628,123 @@ func (h *MessageHandler) Index(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching messages")
 	}
 
-	messages, err := h.service.GetMessages(ctx, request.ToGetParams(h.userIDFomContext(c)))
+	params := request.ToGetParams(h.userIDFomContext(c))
+
+	// the version is fetched before the messages so that a message which is updated in between changes the etag of the next request
+	version, err := h.service.GetMessagesVersion(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot get the version of messages with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	messages, err := h.service.GetMessages(ctx, params)
 	if err != nil {
 		msg := fmt.Sprintf("cannot get messgaes with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*messages), h.pluralize("message", len(*messages))), messages)
+	message := h.translate(c, "fetched %d %s", len(*messages), h.pluralize(c, "message", len(*messages)))
+
+	// the queue position and the estimated send time are computed on every request without changing the updated_at of the message
+	for _, item := range *messages {
+		if item.QueuePosition != nil || item.EstimatedSendAt != nil {
+			return h.responseOK(c, message, messages)
+		}
+	}
+
+	return h.responseOKWithETag(c, h.listETag(version, params.IndexParams), message, messages)
+}
+
+// Poll waits for new messages
+// @Summary      Wait for new messages
+// @Description  Holds the request open until a message of the user is received, sent or updated after the `since` cursor. The response is returned with no messages when nothing changes before the timeout.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Produce      json
+// @Param        since		query  string  	false 	"cursor returned by the previous poll or an RFC3339 timestamp, defaults to the current time"	default(2022-06-05T14:26:10.303278Z)
+// @Param        limit		query  int  	false 	"maximum number of messages to return"		minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.MessagePollResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /messages/poll [get]
+func (h *MessageHandler) Poll(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessagePoll
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessagePoll(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while polling messages [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while polling messages")
+	}
+
+	// the fiber context cannot be used after the handler returns so everything needed by the stream is computed here
+	userID, locale, location := h.userIDFomContext(c), h.locale(c), h.location(c)
+
+	ctx = context.WithoutCancel(ctx)
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	c.Context().SetBodyStreamWriter(func(writer *bufio.Writer) {
+		// whitespace is written while waiting to detect clients which have disconnected
+		poll, err := h.service.Poll(ctx, request.ToPollParams(userID, func() error {
+			if _, err := writer.WriteString(" "); err != nil {
+				return err
+			}
+			return writer.Flush()
+		}))
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot poll messages for user [%s] with params [%+#v]", userID, request)))
+			h.writePollError(writer, i18n.Translate(locale, "We ran into an internal error while handling the request."))
+			return
+		}
+
+		message := i18n.Translate(locale, "fetched %d %s", len(poll.Messages), i18n.Pluralize(locale, "message", len(poll.Messages)))
+		if err = h.writePoll(writer, message, poll, location); err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot write [%d] polled messages for user [%s]", len(poll.Messages), userID)))
+		}
+	})
+	return nil
+}
+
+// writePoll writes the services.MessagePoll in the same envelope as the other responses
+func (h *MessageHandler) writePoll(writer *bufio.Writer, message string, poll *services.MessagePoll, location *time.Location) error {
+	payload, err := json.Marshal(poll)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal [%T] into JSON", poll))
+	}
+
+	var data interface{}
+	if err = json.Unmarshal(payload, &data); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal [%s] into [%T]", payload, data))
+	}
+
+	if err = json.NewEncoder(writer).Encode(fiber.Map{
+		"status":  "success",
+		"message": message,
+		"data":    h.addLocalTimestamps(data, location),
+	}); err != nil {
+		return stacktrace.Propagate(err, "cannot write the poll response")
+	}
+	return writer.Flush()
+}
+
+// writePollError writes the error envelope of the other responses, the status code cannot be changed because the whitespace has already been written
+func (h *MessageHandler) writePollError(writer *bufio.Writer, message string) {
+	_ = json.NewEncoder(writer).Encode(fiber.Map{
+		"status":     "error",
+		"message":    message,
+		"error_code": responses.ErrorCodeInternalError,
+	})
+	_ = writer.Flush()
 }
 
 // PostEvent registers an event on a message
@@ -325,9 +808,190 @@ func (h *MessageHandler) PostEvent(c *fiber.Ctx) error {
 	return h.responseOK(c, "message event stored successfully", message)
 }
 
+// PatchReadReceipt registers that the recipient has read a message
+// @Summary      Store the read receipt of a message
+// @Description  Use this endpoint on the mobile phone when the recipient has read a delivered message e.g. with RCS read receipts. The message is not changed if read receipts are not supported.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.MessageReadReceipt 	true 	"Payload of the read receipt"
+// @Success      200  		{object} 	responses.MessageResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/read-receipt [patch]
+func (h *MessageHandler) PatchReadReceipt(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageReadReceipt
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.MessageID = c.Params("messageID")
+	if errors := h.validator.ValidateReadReceipt(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing read receipt [%s] for message [%s]", spew.Sdump(errors), c.Body(), request.MessageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing read receipt")
+	}
+
+	message, err := h.service.GetMessage(ctx, h.userIDFomContext(c), uuid.MustParse(request.MessageID))
+	if err != nil && stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", request.MessageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", request.MessageID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	message, err = h.service.StoreReadReceipt(ctx, message, request.ToParams(c.OriginalURL()))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store read receipt for message [%s] with payload [%s]", request.MessageID, c.Body())
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "read receipt stored successfully", message)
+}
+
+// PostReact sends an emoji reaction to a message
+// @Summary      React to a message
+// @Description  Sends an emoji reaction to a message over RCS from the phone of the message. The message is not changed if RCS is not enabled on the phone and the phone does not send the reaction if the contact does not support RCS. A 409 response containing the message is returned when an outgoing message has not been sent yet.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.MessageReact 			true 	"Payload of the reaction"
+// @Success      200  		{object} 	responses.MessageResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      409  		{object}  	responses.BadRequest
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/react [post]
+func (h *MessageHandler) PostReact(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageReact
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.MessageID = c.Params("messageID")
+	if errors := h.validator.ValidateReact(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while reacting to message [%s] with [%s]", spew.Sdump(errors), request.MessageID, c.Body())
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while reacting to message")
+	}
+
+	message, err := h.service.GetMessage(ctx, h.userIDFomContext(c), uuid.MustParse(request.MessageID))
+	if err != nil && stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", request.MessageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", request.MessageID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	reacted, err := h.service.React(ctx, message, request.ToParams(c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodeMessageNotReactable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot react to message with ID [%s]", request.MessageID)))
+		return h.responseConflict(c, "the message has not been sent by the phone and cannot be reacted to", message)
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find the phone [%s] of message with ID [%s]", message.Owner, request.MessageID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhoneControlUnreachable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send the reaction to message [%s] to the phone", request.MessageID)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodePhoneOffline), h.translate(c, "the phone has no valid FCM token, open the httpSMS app on the phone and try again"), nil)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot react to message [%s] with payload [%s]", request.MessageID, c.Body())
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "reaction processed successfully", reacted)
+}
+
+// PostReaction registers that the contact has reacted to a message
+// @Summary      Store the reaction of the contact to a message
+// @Description  Use this endpoint on the mobile phone when the contact has reacted to a message with an emoji over RCS. The message is not changed if the contact does not support reactions.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.MessageReaction 		true 	"Payload of the reaction"
+// @Success      200  		{object} 	responses.MessageResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/reactions [post]
+func (h *MessageHandler) PostReaction(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageReaction
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.MessageID = c.Params("messageID")
+	if errors := h.validator.ValidateReaction(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing reaction [%s] for message [%s]", spew.Sdump(errors), c.Body(), request.MessageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing reaction")
+	}
+
+	message, err := h.service.GetMessage(ctx, h.userIDFomContext(c), uuid.MustParse(request.MessageID))
+	if err != nil && stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", request.MessageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", request.MessageID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	message, err = h.service.StoreReaction(ctx, message, request.ToParams(c.OriginalURL()))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store reaction for message [%s] with payload [%s]", request.MessageID, c.Body())
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "reaction stored successfully", message)
+}
+
 // PostReceive receives a new entities.Message
 // @Summary      Receive a new SMS message from a mobile phone
-// @Description  Add a new message received from a mobile phone
+// @Description  This is the endpoint which the httpSMS app calls when the phone receives an SMS message, a custom app can call it in the same way. The message is validated, classified and stored before the message.phone.received event is fired.
+// @Description  A retried request with the same from, to, content and timestamp returns the message which has already been stored, use the ID of the message in the response to track it on the device.
 // @Security	 ApiKeyAuth
 // @Tags         Messages
 // @Accept       json
@@ -363,6 +1027,11 @@ func (h *MessageHandler) PostReceive(c *fiber.Ctx) error {
 	}
 
 	message, err := h.service.ReceiveMessage(ctx, request.ToMessageReceiveParams(h.userIDFomContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodeMessageContentTooLong {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("rejected the message received by [%s] from [%s]", request.To, request.From)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeMessageContentTooLong), h.translate(c, "the message is longer than the inbound limit of your account"), nil)
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("cannot receive message with paylod [%s]", c.Body())
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -420,6 +1089,297 @@ func (h *MessageHandler) Delete(c *fiber.Ctx) error {
 	return h.responseNoContent(c, "message deleted successfully")
 }
 
+// PatchStar stars or unstars a message
+// @Summary      Star or unstar a message
+// @Description  Stars a message which is not starred and unstars a starred message. Use the starred filter of the messages index to fetch the starred messages.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200  		{object} 	responses.MessageResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/star [patch]
+func (h *MessageHandler) PatchStar(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateUUID(ctx, messageID, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while starring a message with ID [%s]", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while starring message")
+	}
+
+	message, err := h.service.ToggleStar(ctx, h.userIDFomContext(c), uuid.MustParse(messageID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot star message with ID [%s]", messageID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	if message.Starred {
+		return h.responseOK(c, "message starred successfully", message)
+	}
+	return h.responseOK(c, "message unstarred successfully", message)
+}
+
+// PostMarkRead marks many messages as read
+// @Summary      Mark messages as read
+// @Description  Marks many messages as read with one request. IDs of messages which do not belong to the user or which are already read are skipped, the response contains the number of messages which were marked as read.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.MessageMarkRead  			true 	"IDs of the messages to mark as read"
+// @Success      200  		{object} 	responses.MessagesMarkedReadResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/mark-read [post]
+func (h *MessageHandler) PostMarkRead(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageMarkRead
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMarkRead(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while marking [%d] messages as read", spew.Sdump(errors), len(request.MessageIDs))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while marking messages as read")
+	}
+
+	result, err := h.service.MarkRead(ctx, h.userIDFomContext(c), request.MessageUUIDs())
+	if err != nil {
+		msg := fmt.Sprintf("cannot mark [%d] messages as read for user with ID [%s]", len(request.MessageIDs), h.userIDFomContext(c))
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "marked %d %s as read", result.Count, h.pluralize(c, "message", int(result.Count))), result)
+}
+
+// PostCancel cancels a message which has not been sent
+// @Summary      Cancel a message
+// @Description  Cancel a message which is pending or scheduled so that it is never sent. A 409 response containing the message is returned when a phone has already picked it up.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Accept       json
+// @Produce      json
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200  		{object} 	responses.MessageResponse
+// @Failure      400  		{object}  	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      409  		{object}  	responses.BadRequest
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/cancel [post]
+func (h *MessageHandler) PostCancel(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateUUID(ctx, messageID, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while cancelling a message with ID [%s]", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while cancelling message")
+	}
+
+	message, err := h.service.CancelMessage(ctx, services.MessageCancelParams{
+		UserID:    h.userIDFomContext(c),
+		MessageID: uuid.MustParse(messageID),
+		Timestamp: time.Now().UTC(),
+		Source:    c.OriginalURL(),
+	})
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeMessageNotCancellable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot cancel message with ID [%s]", messageID)))
+		return h.responseConflict(c, "the message has already been picked up by a phone and can no longer be cancelled", message)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot cancel message with ID [%s] for user with ID [%s]", messageID, h.userIDFomContext(c))
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "message cancelled successfully", message)
+}
+
+// GetReplyChain returns a message and the messages it replies to
+// @Summary      Get the reply chain of a message
+// @Description  Get a message followed by the messages it replies to using the `in_reply_to` field, the oldest message is last.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Produce      json
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
+// @Success      200 		{object}	responses.MessagesResponse
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/reply-chain [get]
+func (h *MessageHandler) GetReplyChain(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateUUID(ctx, messageID, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching the reply chain of message [%s]", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching the reply chain")
+	}
+
+	messages, err := h.service.GetReplyChain(ctx, h.userIDFomContext(c), uuid.MustParse(messageID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the reply chain of message [%s]", messageID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(messages), h.pluralize(c, "message", len(messages))), messages)
+}
+
+// GetMedia streams an attachment of a message
+// @Summary      Download a media file of a message
+// @Description  Streams the media file e.g. an image received in an MMS message. Range requests are supported for large media files.
+// @Security	 ApiKeyAuth
+// @Tags         Messages
+// @Produce      application/octet-stream
+// @Param 		 messageID 	path		string 							true 	"ID of the message" 			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 index 		path		int 							true 	"index of the media file" 		minimum(0)
+// @Param 		 Range 		header		string 							false 	"byte range of the media file" 	default(bytes=0-1023)
+// @Success      200  		{file} 		file
+// @Success      206  		{file} 		file
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      416  		{object} 	responses.BadRequest
+// @Failure      422  		{object} 	responses.UnprocessableEntity
+// @Failure      500  		{object}  	responses.InternalServerError
+// @Router       /messages/{messageID}/media/{index} [get]
+func (h *MessageHandler) GetMedia(c *fiber.Ctx) error {
+	ctx, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	ctxLogger := h.tracer.CtxLogger(h.logger, span)
+
+	messageID := c.Params("messageID")
+	if errors := h.validator.ValidateUUID(ctx, messageID, "messageID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching media of message with ID [%s]", spew.Sdump(errors), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching message media")
+	}
+
+	index, err := c.ParamsInt("index")
+	if err != nil || index < 0 {
+		msg := fmt.Sprintf("invalid media index [%s] for message with ID [%s]", c.Params("index"), messageID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, map[string][]string{"index": {"The index field must be a number greater than or equal to 0"}}, "validation errors while fetching message media")
+	}
+
+	message, err := h.service.GetMessage(ctx, h.userIDFomContext(c), uuid.MustParse(messageID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message with ID [%s]", messageID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", messageID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	if index >= len(message.Attachments) {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find media [%d] of message with ID [%s]", index, messageID))
+	}
+
+	attachment := message.Attachments[index]
+	offset, length, partial, ok := h.byteRange(c.Get(fiber.HeaderRange), attachment.Size)
+	if !ok {
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", attachment.Size))
+		return h.responseRangeNotSatisfiable(c, fmt.Sprintf("the range [%s] is not satisfiable for media with [%d] bytes", c.Get(fiber.HeaderRange), attachment.Size))
+	}
+
+	reader, err := h.service.LoadAttachment(ctx, message, index, offset, length)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load media [%d] of message with ID [%s]", index, messageID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	c.Set(fiber.HeaderContentType, attachment.ContentType)
+	c.Set(fiber.HeaderAcceptRanges, "bytes")
+	if partial {
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", offset, offset+length-1, attachment.Size))
+		c.Status(fiber.StatusPartialContent)
+	}
+
+	return c.SendStream(reader, int(length))
+}
+
+// byteRange parses a single range of the Range header. Multiple or malformed ranges are ignored so the whole file is sent.
+func (h *MessageHandler) byteRange(header string, size int64) (offset int64, length int64, partial bool, ok bool) {
+	value, found := strings.CutPrefix(strings.TrimSpace(header), "bytes=")
+	if !found || strings.Contains(value, ",") {
+		return 0, size, false, true
+	}
+
+	first, last, found := strings.Cut(value, "-")
+	if !found {
+		return 0, size, false, true
+	}
+
+	if first == "" {
+		suffix, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || suffix <= 0 {
+			return 0, size, false, true
+		}
+		suffix = min(suffix, size)
+		return size - suffix, suffix, true, size > 0
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, size, false, true
+	}
+
+	if start >= size {
+		return 0, 0, false, false
+	}
+
+	end := size - 1
+	if last != "" {
+		if end, err = strconv.ParseInt(last, 10, 64); err != nil || end < start {
+			return 0, size, false, true
+		}
+		end = min(end, size-1)
+	}
+
+	return start, end - start + 1, true, true
+}
+
 // PostCallMissed registers a missed phone call
 // @Summary      Register a missed call event on the mobile phone
 // @Description  This endpoint is called by the httpSMS android app to register a missed call event on the mobile phone.
@@ -475,6 +1435,7 @@ func (h *MessageHandler) PostCallMissed(c *fiber.Ctx) error {
 // @Param        skip		query  int  	false	"number of messages to skip"		minimum(0)
 // @Param        query		query  string  	false 	"filter messages containing query"
 // @Param        limit		query  int  	false	"number of messages to return"		minimum(1)	maximum(200)
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
 // @Success      200 		{object}	responses.MessagesResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
@@ -507,5 +1468,5 @@ func (h *MessageHandler) Search(c *fiber.Ctx) error {
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("found %d %s", len(messages), h.pluralize("message", len(messages))), messages)
+	return h.responseOK(c, h.translate(c, "found %d %s", len(messages), h.pluralize(c, "message", len(messages))), messages)
 }
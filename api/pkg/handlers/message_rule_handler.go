@@ -0,0 +1,234 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// maxMessageRules is the maximum number of message rules of a user
+const maxMessageRules = 100
+
+// MessageRuleHandler handles message rule requests
+type MessageRuleHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.MessageRuleService
+	validator *validators.MessageRuleHandlerValidator
+}
+
+// NewMessageRuleHandler creates a new MessageRuleHandler
+func NewMessageRuleHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.MessageRuleService,
+	validator *validators.MessageRuleHandlerValidator,
+) (h *MessageRuleHandler) {
+	return &MessageRuleHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the MessageRuleHandler
+func (h *MessageRuleHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/message-rules")
+	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Post("/", h.computeRoute(middlewares, h.Store)...)
+	router.Put("/:messageRuleID", h.computeRoute(middlewares, h.Update)...)
+	router.Delete("/:messageRuleID", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Index returns the message rules of a user
+// @Summary      Get message rules of a user
+// @Description  Get the rules which forward, reply to and label the messages received by a user in the order they are evaluated
+// @Security	 ApiKeyAuth
+// @Tags         MessageRules
+// @Accept       json
+// @Produce      json
+// @Param        skip		query  int  	false	"number of message rules to skip"		minimum(0)
+// @Param        query		query  string  	false 	"filter message rules with a name containing query"
+// @Param        limit		query  int  	false	"number of message rules to return"	minimum(1)	maximum(100)
+// @Success      200 		{object}	responses.MessageRulesResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /message-rules 	[get]
+func (h *MessageRuleHandler) Index(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageRuleIndex
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall URL [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateIndex(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching message rules [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching message rules")
+	}
+
+	rules, err := h.service.Index(ctx, h.userIDFomContext(c), request.ToIndexParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot get message rules for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(rules), h.pluralize(c, "message rule", len(rules))), rules)
+}
+
+// Store a message rule
+// @Summary      Store a message rule
+// @Description  Store a rule which forwards the messages received by the authenticated user to a webhook, replies to them or labels them when they match its conditions
+// @Security	 ApiKeyAuth
+// @Tags         MessageRules
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.MessageRuleStore  	true "Payload of the message rule"
+// @Success      201 		{object}	responses.MessageRuleResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /message-rules [post]
+func (h *MessageRuleHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageRuleStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T] for user [%s]", request, h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing message rule for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing message rule")
+	}
+
+	rules, err := h.service.Index(ctx, h.userIDFomContext(c), repositories.IndexParams{Skip: 0, Limit: maxMessageRules})
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot index message rules for user [%s]", h.userIDFomContext(c))))
+		return h.responseInternalServerError(c)
+	}
+
+	if len(rules) == maxMessageRules {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] wants to create more than [%d] message rules", h.userIDFomContext(c), maxMessageRules)))
+		return h.responsePaymentRequired(c, fmt.Sprintf("You can't create more than %d message rules contact us to upgrade to our enterprise plan.", maxMessageRules))
+	}
+
+	rule, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot store message rule for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "message rule created successfully", rule)
+}
+
+// Update an entities.MessageRule
+// @Summary      Update a message rule
+// @Description  Update a message rule of the currently authenticated user
+// @Security	 ApiKeyAuth
+// @Tags         MessageRules
+// @Accept       json
+// @Produce      json
+// @Param 		 messageRuleID	path		string 							true 	"ID of the message rule" 				default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   			body 		requests.MessageRuleUpdate  	true 	"Payload of the message rule to update"
+// @Success      200 		{object}	responses.MessageRuleResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /message-rules/{messageRuleID} 	[put]
+func (h *MessageRuleHandler) Update(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.MessageRuleUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T] for user [%s]", request, h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.MessageRuleID = c.Params("messageRuleID")
+	if errors := h.validator.ValidateUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating message rule [%s]", spew.Sdump(errors), request.MessageRuleID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating message rule")
+	}
+
+	rule, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message rule with ID [%s]", request.MessageRuleID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update message rule with ID [%s]", request.MessageRuleID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "message rule updated successfully", rule)
+}
+
+// Delete a message rule
+// @Summary      Delete message rule
+// @Description  Delete a message rule of a user
+// @Security	 ApiKeyAuth
+// @Tags         MessageRules
+// @Accept       json
+// @Produce      json
+// @Param 		 messageRuleID 	path		string 							true 	"ID of the message rule"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      204		{object}    responses.NoContent
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /message-rules/{messageRuleID} [delete]
+func (h *MessageRuleHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	ruleID := c.Params("messageRuleID")
+	if errors := h.validator.ValidateUUID(ctx, ruleID, "messageRuleID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting message rule with ID [%s]", spew.Sdump(errors), ruleID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting message rule")
+	}
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(ruleID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find message rule with ID [%s]", ruleID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete message rule with ID [%s]", ruleID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "message rule deleted successfully", nil)
+}
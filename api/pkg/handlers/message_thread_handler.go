@@ -44,6 +44,7 @@ func (h *MessageThreadHandler) RegisterRoutes(router fiber.Router) {
 	router.Get("/message-threads", h.Index)
 	router.Put("/message-threads/:messageThreadID", h.Update)
 	router.Delete("/message-threads/:messageThreadID", h.Delete)
+	router.Delete("/message-threads/:ownerNumber/:contactNumber", h.DeleteMessages)
 }
 
 // Index returns message threads for a phone number
@@ -57,7 +58,10 @@ func (h *MessageThreadHandler) RegisterRoutes(router fiber.Router) {
 // @Param        skip	query  int  	false	"number of messages to skip"				minimum(0)
 // @Param        query	query  string  	false 	"filter message threads containing query"
 // @Param        limit	query  int  	false	"number of messages to return"				minimum(1)	maximum(20)
+// @Param        If-None-Match	header string	false	"ETag of a previous response, 304 Not Modified is returned when the list has not changed"
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
 // @Success      200 	{object}	responses.MessageThreadsResponse
+// @Header       200		{string}	ETag	"weak ETag derived from the number of items matching the filter and their latest update"
 // @Failure      400	{object}	responses.BadRequest
 // @Failure 	 401    {object}	responses.Unauthorized
 // @Failure      422	{object}	responses.UnprocessableEntity
@@ -84,14 +88,24 @@ func (h *MessageThreadHandler) Index(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching message threads")
 	}
 
-	threads, err := h.service.GetThreads(ctx, request.ToGetParams(h.userIDFomContext(c)))
+	params := request.ToGetParams(h.userIDFomContext(c))
+
+	// the version is fetched before the threads so that a thread which is updated in between changes the etag of the next request
+	version, err := h.service.GetThreadsVersion(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot get the version of message threads with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	threads, err := h.service.GetThreads(ctx, params)
 	if err != nil {
 		msg := fmt.Sprintf("cannot get message threads with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d message %s", len(*threads), h.pluralize("thread", len(*threads))), threads)
+	return h.responseOKWithETag(c, h.listETag(version, params.IndexParams), h.translate(c, "fetched %d %s", len(*threads), h.pluralize(c, "message thread", len(*threads))), threads)
 }
 
 // Update an entities.MessageThread
@@ -182,3 +196,44 @@ func (h *MessageThreadHandler) Delete(c *fiber.Ctx) error {
 
 	return h.responseNoContent(c, "thread thread deleted successfully")
 }
+
+// DeleteMessages deletes all the messages between an owner and a contact
+// @Summary      Delete all the messages of a conversation
+// @Description  Delete all the messages between a phone number of the user and a contact in one request and then delete the message thread. The messages are deleted permanently.
+// @Security	 ApiKeyAuth
+// @Tags         MessageThreads
+// @Accept       json
+// @Produce      json
+// @Param 		 ownerNumber	path		string 		true	"phone number which sent or received the messages"	default(+18005550199)
+// @Param 		 contactNumber	path		string 		true	"phone number of the contact"						default(+18005550100)
+// @Success      200  			{object} 	responses.MessageThreadMessagesDeletedResponse
+// @Failure      400  			{object}  	responses.BadRequest
+// @Failure 	 401    		{object}	responses.Unauthorized
+// @Failure 	 404			{object}	responses.NotFound
+// @Failure      422  			{object} 	responses.UnprocessableEntity
+// @Failure      500  			{object}  	responses.InternalServerError
+// @Router       /message-threads/{ownerNumber}/{contactNumber} [delete]
+func (h *MessageThreadHandler) DeleteMessages(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	request := requests.MessageThreadDeleteMessages{Owner: c.Params("ownerNumber"), Contact: c.Params("contactNumber")}
+	if errors := h.validator.ValidateDeleteMessages(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting the messages between owner [%s] and contact [%s]", spew.Sdump(errors), request.Owner, request.Contact)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting the messages of a thread")
+	}
+
+	result, err := h.service.DeleteMessages(ctx, c.OriginalURL(), h.userIDFomContext(c), request.Owner, request.Contact)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find messages between owner [%s] and contact [%s]", request.Owner, request.Contact))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete the messages between owner [%s] and contact [%s] for user with ID [%s]", request.Owner, request.Contact, h.userIDFomContext(c))
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "deleted %d %s", result.Count, h.pluralize(c, "message", int(result.Count))), result)
+}
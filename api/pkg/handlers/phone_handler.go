@@ -2,10 +2,15 @@ package handlers
 
 import (
 	"fmt"
+	"net/url"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"github.com/davecgh/go-spew/spew"
+	"github.com/google/uuid"
 
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -41,7 +46,20 @@ func NewPhoneHandler(
 func (h *PhoneHandler) RegisterRoutes(router fiber.Router) {
 	router.Get("/phones", h.Index)
 	router.Put("/phones", h.Upsert)
+	router.Patch("/phones/:phoneID", h.Patch)
+	router.Post("/phones/bulk", h.BulkStore)
+	router.Post("/phones/notify", h.NotifyAll)
+	router.Post("/phones/:phoneID/notify", h.Notify)
 	router.Delete("/phones/:phoneID", h.Delete)
+	router.Put("/phones/:phoneID/fcm-token", h.UpdateFcmToken)
+	router.Post("/phones/:phoneID/fcm-key/rotate", h.RotateFcmKey)
+	router.Post("/phones/:phoneID/fcm-key/promote", h.PromoteFcmKey)
+	router.Post("/phones/:phoneID/pause", h.Pause)
+	router.Post("/phones/:phoneID/resume", h.Resume)
+	router.Put("/phone-groups/:group", h.UpsertGroup)
+	router.Get("/country-routes", h.IndexCountryRoutes)
+	router.Put("/country-routes/:country", h.UpsertCountryRoute)
+	router.Delete("/country-routes/:country", h.DeleteCountryRoute)
 }
 
 // Index returns the phones of a user
@@ -54,6 +72,8 @@ func (h *PhoneHandler) RegisterRoutes(router fiber.Router) {
 // @Param        skip		query  int  	false	"number of heartbeats to skip"		minimum(0)
 // @Param        query		query  string  	false 	"filter phones containing query"
 // @Param        limit		query  int  	false	"number of phones to return"		minimum(1)	maximum(20)
+// @Param        external_id	query  string  	false	"return only the phone with this ID in your external system"
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
 // @Success      200 		{object}	responses.PhonesResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
@@ -79,14 +99,21 @@ func (h *PhoneHandler) Index(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching phones")
 	}
 
-	phones, err := h.service.Index(ctx, h.userFromContext(c), request.ToIndexParams())
+	var phones *[]entities.Phone
+	var err error
+	if request.ExternalID != "" {
+		phones, err = h.service.IndexByExternalID(ctx, h.userFromContext(c), request.ExternalID)
+	} else {
+		phones, err = h.service.Index(ctx, h.userFromContext(c), request.ToIndexParams())
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("cannot index phones with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(*phones), h.pluralize("phone", len(*phones))), phones)
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(*phones), h.pluralize(c, "phone", len(*phones))), phones)
 }
 
 // Upsert a phone
@@ -123,6 +150,16 @@ func (h *PhoneHandler) Upsert(c *fiber.Ctx) error {
 	}
 
 	phone, err := h.service.Upsert(ctx, request.ToUpsertParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodePhoneExternalIDConflict {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the external ID of phone [%s] is already used", request.PhoneNumber)))
+		return h.responseConflict(c, "another phone already has this external_id, the external_id must be unique for each of your phones", nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhoneSenderIDConflict {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the alphanumeric sender ID of phone [%s] is already used", request.PhoneNumber)))
+		return h.responseConflict(c, "another phone already has this alphanumeric_sender_id, the alphanumeric_sender_id must be unique for each of your phones", nil)
+	}
+
 	if err != nil {
 		msg := fmt.Sprintf("cannot update phones with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -132,6 +169,441 @@ func (h *PhoneHandler) Upsert(c *fiber.Ctx) error {
 	return h.responseOK(c, "phone updated successfully", phone)
 }
 
+// Patch updates some fields of a phone
+// @Summary      Update some fields of a phone
+// @Description  Updates only the fields of a phone which are present in the payload. The omitted fields are not changed and the nullable fields e.g. missed_call_auto_reply, alphanumeric_sender_id, group and allowed_recipients are removed when they are null.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.PhonePatch  			true 	"Payload with the fields to update"
+// @Success      200 		{object}	responses.PhoneResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID} [patch]
+func (h *PhoneHandler) Patch(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhonePatch
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidatePatch(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while patching phone [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating phones")
+	}
+
+	phone, err := h.service.Patch(ctx, request.ToPatchParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", request.PhoneID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhoneExternalIDConflict {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the external ID of phone [%s] is already used", request.PhoneID)))
+		return h.responseConflict(c, "another phone already has this external_id, the external_id must be unique for each of your phones", nil)
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhoneSenderIDConflict {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("the alphanumeric sender ID of phone [%s] is already used", request.PhoneID)))
+		return h.responseConflict(c, "another phone already has this alphanumeric_sender_id, the alphanumeric_sender_id must be unique for each of your phones", nil)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot patch phone with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "phone updated successfully", phone)
+}
+
+// UpsertGroup sets the strategy of a group of phones
+// @Summary      Set the strategy of a phone group
+// @Description  Sets the strategy which selects the phone of a group that sends a message with the from_group field. The round_robin strategy is used when it is not set.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 group		path		string 							true 	"Name of the group" default(warehouse-1)
+// @Param        payload   	body 		requests.PhoneGroupUpsert  		true 	"Payload of the phone group"
+// @Success      200 		{object}	responses.PhoneGroupResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phone-groups/{group} [put]
+func (h *PhoneHandler) UpsertGroup(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneGroupUpsert
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.Group = c.Params("group")
+	if errors := h.validator.ValidateGroupUpsert(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating phone group [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating phone group")
+	}
+
+	group, err := h.service.UpsertGroup(ctx, request.ToUpsertParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot update phone group with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "phone group updated successfully", group)
+}
+
+// IndexCountryRoutes returns the country routes of a user
+// @Summary      Get the country routes of a user
+// @Description  Get the routes which select the phone that sends a message without a from number using the country of the recipient
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.CountryRoutesResponse
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /country-routes [get]
+func (h *PhoneHandler) IndexCountryRoutes(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	routes, err := h.service.IndexCountryRoutes(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the country routes of user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(routes), h.pluralize(c, "country route", len(routes))), routes)
+}
+
+// UpsertCountryRoute sets the phone which sends the messages to the recipients in a country
+// @Summary      Set the route of a country
+// @Description  Sets the phone or the group of phones which sends the messages without a from number to the recipients in a country. The route of the country "default" is used when no route matches the country of the recipient.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 country	path		string 							true 	"ISO 3166-1 alpha-2 code of the country or default" default(US)
+// @Param        payload   	body 		requests.CountryRouteUpsert  	true 	"Payload of the country route"
+// @Success      200 		{object}	responses.CountryRouteResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /country-routes/{country} [put]
+func (h *PhoneHandler) UpsertCountryRoute(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.CountryRouteUpsert
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.Country = c.Params("country")
+	if errors := h.validator.ValidateCountryRouteUpsert(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating country route [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating country route")
+	}
+
+	route, err := h.service.UpsertCountryRoute(ctx, request.ToUpsertParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot update country route with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "country route updated successfully", route)
+}
+
+// DeleteCountryRoute removes the route of a country
+// @Summary      Delete the route of a country
+// @Description  Removes the route of a country, the messages without a from number to the recipients in the country are then sent with the default route
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 country	path		string 							true 	"ISO 3166-1 alpha-2 code of the country or default" default(US)
+// @Success      204		{object}    responses.NoContent
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /country-routes/{country} [delete]
+func (h *PhoneHandler) DeleteCountryRoute(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	request := requests.CountryRouteUpsert{Country: c.Params("country")}
+	country := request.Sanitize().Country
+	if errors := h.validator.ValidateCountry(ctx, country); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while deleting country route [%s]", spew.Sdump(errors), country)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting country route")
+	}
+
+	err := h.service.DeleteCountryRoute(ctx, h.userIDFomContext(c), country)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find the route of country [%s]", country))
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete country route [%s]", country)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "country route deleted successfully")
+}
+
+// BulkStore registers multiple phones
+// @Summary      Register multiple phones
+// @Description  Registers multiple phones in a single transaction, no phone is created when any phone is a duplicate or already exists
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.PhoneBulkStore  		true 	"Payload of the phones to register"
+// @Success      201 		{object}	responses.PhoneBulkStoreResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      409		{object}	responses.PhoneBulkStoreResponse
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/bulk [post]
+func (h *PhoneHandler) BulkStore(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneBulkStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateBulkStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while registering phones [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while registering phones")
+	}
+
+	results, err := h.service.BulkStore(ctx, request.ToUpsertParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == services.ErrCodePhoneBulkStoreRejected {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot register [%d] phones for user [%s]", len(request.Phones), h.userIDFomContext(c))))
+		return h.responseError(c, fiber.StatusConflict, h.errorCode(err, responses.ErrorCodeConflict), h.translate(c, "no phone was registered because some phones are duplicates or already exist"), results)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot register phones with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, h.translate(c, "registered %d %s", len(results), h.pluralize(c, "phone", len(results))), results)
+}
+
+// Notify sends a control notification to a phone
+// @Summary      Send a control notification to a phone
+// @Description  Sends a push notification which the httpSMS app interprets e.g. to tell the phone that a new version of the app is available or to refresh its settings. No SMS is sent.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.PhoneNotify			true 	"Payload of the control notification"
+// @Success      200 		{object}	responses.PhoneResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/notify [post]
+func (h *PhoneHandler) Notify(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneNotify
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidateNotify(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while notifying phone [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while notifying phone")
+	}
+
+	phone, err := h.service.Notify(ctx, request.ToNotifyParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", request.PhoneID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhoneControlUnreachable {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot notify phone [%s]", request.PhoneID)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while notifying phone"), url.Values{"phoneID": {"The phone has no valid FCM token, open the httpSMS app on the phone and try again"}})
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot notify phone with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "control notification sent to the phone successfully", phone)
+}
+
+// Pause takes a phone out of rotation
+// @Summary      Pause a phone
+// @Description  Takes the phone out of rotation e.g. for maintenance. A paused phone still sends heartbeats but it sends no messages and it is skipped when a message is sent from its group. The messages sent from the phone are held until it is resumed unless reject_messages is set.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.PhonePause				true 	"Payload for pausing the phone"
+// @Success      200 		{object}	responses.PhoneResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/pause [post]
+func (h *PhoneHandler) Pause(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhonePause
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidatePause(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while pausing phone [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while pausing the phone")
+	}
+
+	phone, err := h.service.Pause(ctx, request.ToPauseParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", request.PhoneID))
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot pause phone with params [%+#v]", request)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "the phone has been paused", phone)
+}
+
+// Resume puts a paused phone back into rotation
+// @Summary      Resume a phone
+// @Description  Puts a paused phone back into rotation, the messages which were held while the phone was paused are sent in the order in which they were sent to the API.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.PhoneResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/resume [post]
+func (h *PhoneHandler) Resume(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	phoneID := c.Params("phoneID")
+	if errors := h.validator.ValidateUUID(ctx, phoneID, "phoneID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while resuming phone [%s]", spew.Sdump(errors), phoneID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while resuming the phone")
+	}
+
+	phone, err := h.service.Resume(ctx, c.OriginalURL(), h.userIDFomContext(c), uuid.MustParse(phoneID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", phoneID))
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot resume phone [%s]", phoneID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "the phone has been resumed", phone)
+}
+
+// NotifyAll sends a control notification to all the phones of a user
+// @Summary      Send a control notification to all phones
+// @Description  Sends a push notification which the httpSMS app interprets to all the phones of the user or to the phones of a group. A phone which cannot be notified does not stop the other phones from being notified.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.PhoneNotifyAll			true 	"Payload of the control notification"
+// @Success      200 		{object}	responses.PhoneNotifyAllResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/notify [post]
+func (h *PhoneHandler) NotifyAll(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneNotifyAll
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateNotifyAll(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while notifying phones [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while notifying phones")
+	}
+
+	results, err := h.service.NotifyAll(ctx, request.ToNotifyParams(h.userFromContext(c), c.OriginalURL()))
+	if err != nil {
+		msg := fmt.Sprintf("cannot notify phones with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "sent control notification to %d %s", len(results), h.pluralize(c, "phone", len(results))), results)
+}
+
 // Delete a phone
 // @Summary      Delete Phone
 // @Description  Delete a phone that has been sored in the database
@@ -140,6 +612,7 @@ func (h *PhoneHandler) Upsert(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        reassign_to	query  		string  						false 	"Phone number or group of phones which will send the pending messages of the deleted phone. The pending messages are marked as failed when it is not set"	default(+18005550100)
 // @Success      204		{object}    responses.NoContent
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401    	{object}	responses.Unauthorized
@@ -152,14 +625,25 @@ func (h *PhoneHandler) Delete(c *fiber.Ctx) error {
 
 	ctxLogger := h.tracer.CtxLogger(h.logger, span)
 
-	request := requests.PhoneDelete{PhoneID: c.Params("phoneID")}
-	if errors := h.validator.ValidateDelete(ctx, request); len(errors) != 0 {
+	var request requests.PhoneDelete
+	if err := c.QueryParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidateDelete(ctx, request.Sanitize()); len(errors) != 0 {
 		msg := fmt.Sprintf("validation errors [%s], while deleting phone [%+#v]", spew.Sdump(errors), request)
 		ctxLogger.Warn(stacktrace.NewError(msg))
 		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting phone")
 	}
 
-	err := h.service.Delete(ctx, c.OriginalURL(), h.userIDFomContext(c), request.PhoneIDUuid())
+	err := h.service.Delete(ctx, c.OriginalURL(), h.userIDFomContext(c), request.PhoneIDUuid(), request.ReassignTo)
+	if stacktrace.GetCode(err) == services.ErrCodePhoneReassignTargetNotFound {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot reassign the messages of phone with params [%+#v]", request)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while deleting phone"), map[string][]string{"reassign_to": {"There is no other phone with this phone number or group"}})
+	}
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete phones with params [%+#v]", request)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -168,3 +652,147 @@ func (h *PhoneHandler) Delete(c *fiber.Ctx) error {
 
 	return h.responseOK(c, "phone deleted successfully", nil)
 }
+
+// UpdateFcmToken updates the FCM token of a phone
+// @Summary      Update the FCM token of a phone
+// @Description  Sets the new FCM token after it is rotated on the Android phone. Messages which failed because FCM rejected the previous token are sent again.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.PhoneFcmTokenUpdate	true 	"Payload with the new FCM token"
+// @Success      200 		{object}	responses.PhoneResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/fcm-token [put]
+func (h *PhoneHandler) UpdateFcmToken(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneFcmTokenUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidateFcmTokenUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating the FCM token of phone [%s]", spew.Sdump(errors), request.PhoneID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating phones")
+	}
+
+	phone, err := h.service.UpdateFcmToken(ctx, request.ToUpdateParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", request.PhoneID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update the FCM token of phone [%s]", request.PhoneID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "phone updated successfully", phone)
+}
+
+// RotateFcmKey creates the next key which signs the push notifications of a phone
+// @Summary      Rotate the signing key of a phone
+// @Description  Creates the next key which signs the push notifications of the phone. The current key is still used until the next key is promoted, so the phone should accept the signatures of both fcm_verification_key and fcm_next_verification_key during the rotation.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.PhoneResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/fcm-key/rotate [post]
+func (h *PhoneHandler) RotateFcmKey(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	phoneID := c.Params("phoneID")
+	if errors := h.validator.ValidateUUID(ctx, phoneID, "phoneID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while rotating the key of phone [%s]", spew.Sdump(errors), phoneID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while rotating the key of the phone")
+	}
+
+	phone, err := h.service.RotateFcmKey(ctx, c.OriginalURL(), h.userIDFomContext(c), uuid.MustParse(phoneID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", phoneID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhoneFcmSigningDisabled {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot rotate the key of phone [%s]", phoneID)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while rotating the key of the phone"), url.Values{"phoneID": {"The push notifications of the phone are not signed"}})
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot rotate the key of phone [%s]", phoneID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "the key of the phone is being rotated", phone)
+}
+
+// PromoteFcmKey makes the rotated key of a phone its current key
+// @Summary      Promote the rotated signing key of a phone
+// @Description  Makes the rotated key of the phone the key which signs its push notifications and retires the previous key.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID 	path		string 							true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.PhoneResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/fcm-key/promote [post]
+func (h *PhoneHandler) PromoteFcmKey(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	phoneID := c.Params("phoneID")
+	if errors := h.validator.ValidateUUID(ctx, phoneID, "phoneID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while promoting the key of phone [%s]", spew.Sdump(errors), phoneID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while promoting the key of the phone")
+	}
+
+	phone, err := h.service.PromoteFcmKey(ctx, c.OriginalURL(), h.userIDFomContext(c), uuid.MustParse(phoneID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", phoneID))
+	}
+
+	if code := stacktrace.GetCode(err); code == services.ErrCodePhoneFcmSigningDisabled || code == services.ErrCodePhoneFcmKeyNotRotated {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot promote the key of phone [%s]", phoneID)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while promoting the key of the phone"), url.Values{"phoneID": {h.fcmKeyError(code)}})
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot promote the key of phone [%s]", phoneID)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "the rotated key of the phone has been promoted", phone)
+}
+
+// fcmKeyError explains why the key of a phone cannot be promoted
+func (h *PhoneHandler) fcmKeyError(code stacktrace.ErrorCode) string {
+	if code == services.ErrCodePhoneFcmSigningDisabled {
+		return "The push notifications of the phone are not signed"
+	}
+	return "The key of the phone is not being rotated, rotate the key before promoting it"
+}
@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneProvisioningHandler handles the provisioning codes which pair the android app with the account of a user
+type PhoneProvisioningHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.PhoneProvisioningService
+	validator *validators.PhoneProvisioningHandlerValidator
+}
+
+// NewPhoneProvisioningHandler creates a new PhoneProvisioningHandler
+func NewPhoneProvisioningHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.PhoneProvisioningService,
+	validator *validators.PhoneProvisioningHandlerValidator,
+) (h *PhoneProvisioningHandler) {
+	return &PhoneProvisioningHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the PhoneProvisioningHandler
+func (h *PhoneProvisioningHandler) RegisterRoutes(app *fiber.App, authMiddleware fiber.Handler, rateLimitMiddleware fiber.Handler, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/phones/provisioning-codes")
+	// the exchange route is public because the android app does not have an API key yet so it is rate limited per IP to prevent guessing codes
+	router.Post("/exchange", h.computeRoute(append(middlewares, rateLimitMiddleware), h.Exchange)...)
+	router.Post("/", h.computeRoute(append(middlewares, authMiddleware), h.Store)...)
+}
+
+// Store creates a new provisioning code
+// @Summary      Create a provisioning code
+// @Description  Creates a single-use code which is entered in the android app to pair it with your account without copying the API key. The code is only returned once and it expires after 10 minutes.
+// @Security	 ApiKeyAuth
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Success      201 		{object}	responses.PhoneProvisioningCodeResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/provisioning-codes [post]
+func (h *PhoneProvisioningHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	code, err := h.service.Store(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot create provisioning code for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "provisioning code created successfully", code)
+}
+
+// Exchange a provisioning code for the credentials of the android app
+// @Summary      Exchange a provisioning code
+// @Description  Exchanges a provisioning code for the API key of the user and registers the phone in one step. The request does not need an API key because the code authenticates it, the code can only be exchanged once before it expires.
+// @Tags         Phones
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.PhoneProvisioningExchange  	true "Payload with the provisioning code and the phone"
+// @Success      200 		{object}	responses.PhoneProvisioningResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      429		{object}	responses.TooManyRequests
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/provisioning-codes/exchange [post]
+func (h *PhoneProvisioningHandler) Exchange(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.PhoneProvisioningExchange
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateExchange(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while exchanging provisioning code for phone [%s]", spew.Sdump(errors), request.PhoneNumber)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while exchanging the provisioning code")
+	}
+
+	provisioning, err := h.service.Exchange(ctx, request.ToExchangeParams(c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot exchange provisioning code from IP [%s]", c.IP())))
+		return h.responseNotFound(c, "the provisioning code is invalid, it has expired or it is already used")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot exchange provisioning code for phone [%s]", request.PhoneNumber)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "provisioning code exchanged successfully", provisioning)
+}
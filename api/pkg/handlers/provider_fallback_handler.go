@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ProviderFallbackHandler handles provider fallback requests
+type ProviderFallbackHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	service   *services.ProviderFallbackService
+	validator *validators.ProviderFallbackHandlerValidator
+}
+
+// NewProviderFallbackHandler creates a new ProviderFallbackHandler
+func NewProviderFallbackHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ProviderFallbackService,
+	validator *validators.ProviderFallbackHandlerValidator,
+) (h *ProviderFallbackHandler) {
+	return &ProviderFallbackHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		service:   service,
+		validator: validator,
+	}
+}
+
+// RegisterRoutes registers the routes for the ProviderFallbackHandler
+func (h *ProviderFallbackHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
+	router := app.Group("/v1/provider-fallback")
+	router.Get("/", h.computeRoute(middlewares, h.Show)...)
+	router.Put("/", h.computeRoute(middlewares, h.Upsert)...)
+	router.Delete("/", h.computeRoute(middlewares, h.Delete)...)
+}
+
+// Show returns the provider fallback of a user
+// @Summary      Get the provider fallback of a user
+// @Description  Get the third-party SMS provider which sends the messages of the user when no phone picks them up within the timeout. The auth token is never returned and enabled is false while the provider_fallback feature flag of the user is off.
+// @Security	 ApiKeyAuth
+// @Tags         ProviderFallback
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.ProviderFallbackResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /provider-fallback 	[get]
+func (h *ProviderFallbackHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	fallback, err := h.service.Load(ctx, h.userIDFomContext(c))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "you have not configured a provider fallback")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load provider fallback for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "fetched provider fallback", fallback)
+}
+
+// Upsert the provider fallback of a user
+// @Summary      Set the provider fallback of a user
+// @Description  Set the third-party SMS provider e.g. twilio which sends a message when no phone picks it up within the timeout. Messages opt in with the provider_fallback field and phone groups opt in with their provider_fallback setting. The messages are only sent with the provider when enabled is true in the response i.e. the provider_fallback feature flag of the user is on.
+// @Security	 ApiKeyAuth
+// @Tags         ProviderFallback
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.ProviderFallbackUpsert  	true "Payload of the provider fallback"
+// @Success      200 		{object}	responses.ProviderFallbackResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /provider-fallback [put]
+func (h *ProviderFallbackHandler) Upsert(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.ProviderFallbackUpsert
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall body into [%T] for user [%s]", request, h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateUpsert(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while setting the provider fallback for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while setting the provider fallback")
+	}
+
+	fallback, err := h.service.Upsert(ctx, request.ToUpsertParams(h.userFromContext(c)))
+	if err != nil {
+		msg := fmt.Sprintf("cannot set the provider fallback for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "provider fallback saved successfully", fallback)
+}
+
+// Delete the provider fallback of a user
+// @Summary      Delete the provider fallback
+// @Description  Delete the third-party SMS provider of a user, the messages which opted in are then only sent by the phones
+// @Security	 ApiKeyAuth
+// @Tags         ProviderFallback
+// @Accept       json
+// @Produce      json
+// @Success      204		{object}    responses.NoContent
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /provider-fallback [delete]
+func (h *ProviderFallbackHandler) Delete(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	err := h.service.Delete(ctx, h.userIDFomContext(c))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, "you have not configured a provider fallback")
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete the provider fallback for user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseNoContent(c, "provider fallback deleted successfully")
+}
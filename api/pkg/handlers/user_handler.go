@@ -3,7 +3,9 @@ package handlers
 import (
 	"fmt"
 
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
 	"github.com/NdoleStudio/httpsms/pkg/validators"
 	"github.com/davecgh/go-spew/spew"
 
@@ -41,8 +43,13 @@ func NewUserHandler(
 func (h *UserHandler) RegisterRoutes(router fiber.Router) {
 	router.Get("/users/me", h.Show)
 	router.Put("/users/me", h.Update)
+	router.Patch("/users/me", h.Patch)
 	router.Delete("/users/:userID/api-keys", h.DeleteAPIKey)
 	router.Put("/users/:userID/notifications", h.UpdateNotifications)
+	router.Put("/users/:userID/message-category-rules", h.UpdateMessageCategoryRules)
+	router.Put("/users/:userID/feature-flags", h.UpdateFeatureFlags)
+	router.Post("/users/sub-accounts", h.StoreSubAccount)
+	router.Get("/users/sub-accounts", h.IndexSubAccounts)
 	router.Get("/users/subscription-update-url", h.subscriptionUpdateURL)
 	router.Delete("/users/subscription", h.cancelSubscription)
 }
@@ -121,6 +128,120 @@ func (h *UserHandler) Update(c *fiber.Ctx) error {
 	return h.responseOK(c, "user updated successfully", user)
 }
 
+// Patch an entities.User
+// @Summary      Update some fields of a user
+// @Description  Updates only the fields of the currently authenticated user which are present in the payload. The omitted fields are not changed and the active_phone_id and default_country fields are removed when they are null.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.UserPatch  			true 	"Payload with the fields to update"
+// @Success      200 		{object}	responses.UserResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/me [patch]
+func (h *UserHandler) Patch(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.UserPatch
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidatePatch(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while patching user [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating user")
+	}
+
+	user, err := h.service.Patch(ctx, h.userIDFomContext(c), request.ToPatchParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot patch user with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "user updated successfully", user)
+}
+
+// StoreSubAccount creates a sub-account
+// @Summary      Create a sub-account
+// @Description  Creates a sub-account of the currently authenticated user with its own API key, phones and messages. The usage of the sub-account is billed to the main account. The main account can act as the sub-account by setting the X-Sub-Account-ID header to the ID of the sub-account.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param        payload   	body 		requests.UserSubAccountStore	true 	"Payload of the sub-account to create"
+// @Success      201 		{object}	responses.UserResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/sub-accounts [post]
+func (h *UserHandler) StoreSubAccount(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.UserSubAccountStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateSubAccountStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while creating sub-account [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while creating sub-account")
+	}
+
+	user, err := h.service.CreateSubAccount(ctx, h.userIDFomContext(c), request.Name)
+	if stacktrace.GetCode(err) == services.ErrCodeUserSubAccountNested {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("user [%s] cannot create a sub-account", h.userIDFomContext(c))))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeForbidden), h.translate(c, "a sub-account cannot have sub-accounts, create the sub-account using your main account"), nil)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot create sub-account with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "sub-account created successfully", user)
+}
+
+// IndexSubAccounts returns the sub-accounts of a user
+// @Summary      Get sub-accounts
+// @Description  Get the sub-accounts of the currently authenticated user
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.UsersResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/sub-accounts [get]
+func (h *UserHandler) IndexSubAccounts(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	users, err := h.service.GetSubAccounts(ctx, h.userIDFomContext(c))
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the sub-accounts of user [%s]", h.userIDFomContext(c))
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(users), h.pluralize(c, "sub-account", len(users))), users)
+}
+
 // UpdateNotifications an entities.User
 // @Summary      Update notification settings
 // @Description  Update the email notification settings for a user
@@ -159,6 +280,106 @@ func (h *UserHandler) UpdateNotifications(c *fiber.Ctx) error {
 	return h.responseOK(c, "user notification settings updated successfully", user)
 }
 
+// UpdateMessageCategoryRules an entities.User
+// @Summary      Update message category rules
+// @Description  Override the regex rules used to classify received messages as otp, marketing, personal or unknown. Send an empty list to use the default rules.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param 		 userID 	path		string 									true 	"ID of the user to update" 		default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.UserMessageCategoryRulesUpdate	true 	"Message category rules of the user"
+// @Success      200 		{object}	responses.UserResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/{userID}/message-category-rules [put]
+func (h *UserHandler) UpdateMessageCategoryRules(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if c.Params("userID") != string(h.userIDFomContext(c)) {
+		return h.responseUnauthorized(c)
+	}
+
+	var request requests.UserMessageCategoryRulesUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	if errors := h.validator.ValidateMessageCategoryRulesUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating message category rules [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating message category rules")
+	}
+
+	user, err := h.service.UpdateMessageCategoryRules(ctx, h.userIDFomContext(c), request.ToUpdateParams())
+	if err != nil {
+		msg := fmt.Sprintf("cannot update message category rules for [%T] with ID [%s]", user, h.userIDFomContext(c))
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "user message category rules updated successfully", user)
+}
+
+// UpdateFeatureFlags of an entities.User
+// @Summary      Update the feature flags of a user
+// @Description  Enable or disable new behaviors for a user without a deployment. The flags are merged with the existing flags of the user and this endpoint can only be used by an admin.
+// @Security	 ApiKeyAuth
+// @Tags         Users
+// @Accept       json
+// @Produce      json
+// @Param 		 userID 	path		string 							true 	"ID of the user to update" 		default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.UserFeatureFlagsUpdate	true 	"Feature flags of the user"
+// @Success      200 		{object}	responses.UserResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure 	 403    	{object}	responses.Forbidden
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /users/{userID}/feature-flags [put]
+func (h *UserHandler) UpdateFeatureFlags(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	if !h.service.IsAdmin(h.userIDFomContext(c)) {
+		ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user [%s] is not an admin and cannot update the feature flags of user [%s]", h.userIDFomContext(c), c.Params("userID"))))
+		return h.responseForbidden(c)
+	}
+
+	var request requests.UserFeatureFlagsUpdate
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.OriginalURL(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.UserID = c.Params("userID")
+	if errors := h.validator.ValidateFeatureFlagsUpdate(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while updating feature flags [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while updating feature flags")
+	}
+
+	user, err := h.service.UpdateFeatureFlags(ctx, request.ToUpdateParams())
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find user with ID [%s]", request.UserID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot update feature flags for [%T] with ID [%s]", user, request.UserID)
+		ctxLogger.Error(h.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "user feature flags updated successfully", user)
+}
+
 // subscriptionUpdateURL returns the subscription update URL for the authenticated entities.User
 // @Summary      Currently authenticated user subscription update URL
 // @Description  Fetches the subscription URL of the authenticated user.
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/NdoleStudio/httpsms/pkg/validators"
+	"github.com/davecgh/go-spew/spew"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// UssdHandler handles USSD http requests.
+type UssdHandler struct {
+	handler
+	logger    telemetry.Logger
+	tracer    telemetry.Tracer
+	validator *validators.UssdHandlerValidator
+	service   *services.UssdService
+}
+
+// NewUssdHandler creates a new UssdHandler
+func NewUssdHandler(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	validator *validators.UssdHandlerValidator,
+	service *services.UssdService,
+) (h *UssdHandler) {
+	return &UssdHandler{
+		logger:    logger.WithService(fmt.Sprintf("%T", h)),
+		tracer:    tracer,
+		validator: validator,
+		service:   service,
+	}
+}
+
+// RegisterRoutes registers the routes for the UssdHandler
+func (h *UssdHandler) RegisterRoutes(router fiber.Router) {
+	router.Post("/phones/:phoneID/ussd", h.Store)
+	router.Get("/phones/:phoneID/ussd/:sessionID", h.Show)
+	router.Post("/phones/:phoneID/ussd/:sessionID/response", h.StoreResponse)
+}
+
+// Store executes a USSD request on a phone
+// @Summary      Execute a USSD request
+// @Description  Send a USSD request e.g. *123# to the android phone. The request is executed asynchronously so poll the USSD session to get the response. Only single-step USSD codes are supported because android cannot reply to an interactive USSD menu.
+// @Security	 ApiKeyAuth
+// @Tags         USSD
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID	path		string 				true 	"ID of the phone"	default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.UssdStore	true 	"Payload of the USSD request"
+// @Success      201 		{object}	responses.UssdSessionResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/ussd [post]
+func (h *UssdHandler) Store(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.UssdStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	if errors := h.validator.ValidateStore(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing USSD request [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing USSD request")
+	}
+
+	session, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find phone with ID [%s]", request.PhoneID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeUssdPhoneUnreachable {
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while storing USSD request"), url.Values{"phoneID": {"The phone has no FCM token, open the httpSMS app on the phone and try again"}})
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store USSD request with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseCreated(c, "USSD request sent to the phone successfully", session)
+}
+
+// Show returns a USSD session
+// @Summary      Get a USSD session
+// @Description  Get a USSD session to poll the response of a USSD request.
+// @Security	 ApiKeyAuth
+// @Tags         USSD
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID	path		string 	true 	"ID of the phone"			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 sessionID	path		string 	true 	"ID of the USSD session"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Success      200 		{object}	responses.UssdSessionResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/ussd/{sessionID} [get]
+func (h *UssdHandler) Show(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	request := requests.UssdLoad{PhoneID: c.Params("phoneID"), SessionID: c.Params("sessionID")}
+	if errors := h.validator.ValidateLoad(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while loading USSD session [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while loading USSD session")
+	}
+
+	session, err := h.service.Load(ctx, h.userIDFomContext(c), request.PhoneIDUuid(), request.SessionIDUuid())
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find USSD session with ID [%s]", request.SessionID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load USSD session with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "USSD session fetched successfully", session)
+}
+
+// StoreResponse stores the response of a USSD request reported by the phone
+// @Summary      Store the response of a USSD request
+// @Description  Used by the android phone to report the response of a USSD request.
+// @Security	 ApiKeyAuth
+// @Tags         USSD
+// @Accept       json
+// @Produce      json
+// @Param 		 phoneID	path		string 						true 	"ID of the phone"			default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 sessionID	path		string 						true 	"ID of the USSD session"	default(32343a19-da5e-4b1b-a767-3298a73703cb)
+// @Param        payload   	body 		requests.UssdResponseStore	true 	"Payload of the USSD response"
+// @Success      200 		{object}	responses.UssdSessionResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /phones/{phoneID}/ussd/{sessionID}/response [post]
+func (h *UssdHandler) StoreResponse(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.UssdResponseStore
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into %T", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.PhoneID = c.Params("phoneID")
+	request.SessionID = c.Params("sessionID")
+	if errors := h.validator.ValidateResponse(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while storing USSD response [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while storing USSD response")
+	}
+
+	session, err := h.service.StoreResponse(ctx, request.ToResponseParams(h.userFromContext(c)))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find USSD session with ID [%s]", request.SessionID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeUssdSessionInvalidState {
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while storing USSD response"), url.Values{"sessionID": {"The USSD session is not waiting for a response"}})
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot store USSD response with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "USSD response stored successfully", session)
+}
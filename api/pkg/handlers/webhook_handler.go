@@ -6,6 +6,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
 	"github.com/google/uuid"
 
 	"github.com/NdoleStudio/httpsms/pkg/services"
@@ -44,9 +45,14 @@ func NewWebhookHandler(
 func (h *WebhookHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Handler) {
 	router := app.Group("/v1/webhooks")
 	router.Get("/", h.computeRoute(middlewares, h.Index)...)
+	router.Get("/egress", h.computeRoute(middlewares, h.Egress)...)
+	router.Get("/schema", h.computeRoute(middlewares, h.Schema)...)
 	router.Post("/", h.computeRoute(middlewares, h.Store)...)
 	router.Put("/:webhookID", h.computeRoute(middlewares, h.Update)...)
 	router.Delete("/:webhookID", h.computeRoute(middlewares, h.Delete)...)
+	router.Post("/:webhookID/replay", h.computeRoute(middlewares, h.Replay)...)
+	router.Get("/:webhookID/replays/:replayID", h.computeRoute(middlewares, h.ShowReplay)...)
+	router.Get("/:webhookID/stats", h.computeRoute(middlewares, h.Stats)...)
 }
 
 // Index returns the webhooks of a user
@@ -59,6 +65,7 @@ func (h *WebhookHandler) RegisterRoutes(app *fiber.App, middlewares ...fiber.Han
 // @Param        skip		query  int  	false	"number of webhooks to skip"		minimum(0)
 // @Param        query		query  string  	false 	"filter webhooks containing query"
 // @Param        limit		query  int  	false	"number of webhooks to return"	minimum(1)	maximum(20)
+// @Param        tz			query  string  	false	"timezone of the *_local timestamps, the timezone of the user is used when it is not set"	default(Europe/Helsinki)
 // @Success      200 		{object}	responses.WebhooksResponse
 // @Failure      400		{object}	responses.BadRequest
 // @Failure 	 401	    {object}	responses.Unauthorized
@@ -89,7 +96,44 @@ func (h *WebhookHandler) Index(c *fiber.Ctx) error {
 		return h.responseInternalServerError(c)
 	}
 
-	return h.responseOK(c, fmt.Sprintf("fetched %d %s", len(webhooks), h.pluralize("webhook", len(webhooks))), webhooks)
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(webhooks), h.pluralize(c, "webhook", len(webhooks))), webhooks)
+}
+
+// Egress returns the IP addresses which webhook requests are sent from
+// @Summary      Get the webhook egress IP addresses
+// @Description  Get the IP addresses which webhook requests are sent from so that they can be added to the allowlist of a firewall. The list is empty when the egress is not configured.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.WebhookEgressResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/egress 	[get]
+func (h *WebhookHandler) Egress(c *fiber.Ctx) error {
+	_, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	egress := h.service.Egress()
+	return h.responseOK(c, h.translate(c, "fetched %d %s", len(egress.IPs), h.pluralize(c, "IP address", len(egress.IPs))), egress)
+}
+
+// Schema returns the JSON schema of the webhook events
+// @Summary      Get the JSON schema of the webhook events
+// @Description  Get the JSON schema of the cloudevents which are sent to a webhook. There is a definition for the payload of each event type so that clients can generate types for the webhook events.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Success      200 		{object}	responses.WebhookSchemaResponse
+// @Failure 	 401	    {object}	responses.Unauthorized
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/schema 	[get]
+func (h *WebhookHandler) Schema(c *fiber.Ctx) error {
+	_, span := h.tracer.StartFromFiberCtx(c)
+	defer span.End()
+
+	return h.responseOK(c, "fetched the schema of the webhook events", h.service.Schema())
 }
 
 // Delete a webhook
@@ -117,7 +161,7 @@ func (h *WebhookHandler) Delete(c *fiber.Ctx) error {
 		return h.responseUnprocessableEntity(c, errors, "validation errors while deleting webhook")
 	}
 
-	err := h.service.Delete(ctx, h.userIDFomContext(c), uuid.MustParse(webhookID))
+	err := h.service.Delete(ctx, c.OriginalURL(), h.userIDFomContext(c), uuid.MustParse(webhookID))
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete webhook with ID [%+#v]", webhookID)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
@@ -149,13 +193,15 @@ func (h *WebhookHandler) Store(c *fiber.Ctx) error {
 
 	var request requests.WebhookStore
 	if err := c.BodyParser(&request); err != nil {
-		msg := fmt.Sprintf("cannot marshall body [%s] into [%T]", c.Body(), request)
+		// the body is not logged because it can contain the private key of a client certificate
+		msg := fmt.Sprintf("cannot marshall body with [%d] bytes into [%T]", len(c.Body()), request)
 		ctxLogger.Warn(stacktrace.Propagate(err, msg))
 		return h.responseBadRequest(c, err)
 	}
 
 	if errors := h.validator.ValidateStore(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
-		msg := fmt.Sprintf("validation errors [%s], while storing webhook [%+#v]", spew.Sdump(errors), request)
+		// the request is not logged because the headers, the signing key and the client key are secrets
+		msg := fmt.Sprintf("validation errors [%s], while storing webhook for user [%s]", spew.Sdump(errors), h.userIDFomContext(c))
 		ctxLogger.Warn(stacktrace.NewError(msg))
 		return h.responseUnprocessableEntity(c, errors, "validation errors while storing webhook")
 	}
@@ -171,9 +217,9 @@ func (h *WebhookHandler) Store(c *fiber.Ctx) error {
 		return h.responsePaymentRequired(c, "You can't create more than 10 webhooks contact us to upgrade to our enterprise plan.")
 	}
 
-	webhook, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c)))
+	webhook, err := h.service.Store(ctx, request.ToStoreParams(h.userFromContext(c), c.OriginalURL()))
 	if err != nil {
-		msg := fmt.Sprintf("cannot store webhoook with params [%+#v]", request)
+		msg := fmt.Sprintf("cannot store webhoook for user [%s]", h.userIDFomContext(c))
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
@@ -202,24 +248,166 @@ func (h *WebhookHandler) Update(c *fiber.Ctx) error {
 
 	var request requests.WebhookUpdate
 	if err := c.BodyParser(&request); err != nil {
-		msg := fmt.Sprintf("cannot marshall params [%s] into [%T]", c.Body(), request)
+		// the body is not logged because it can contain the private key of a client certificate
+		msg := fmt.Sprintf("cannot marshall params with [%d] bytes into [%T]", len(c.Body()), request)
 		ctxLogger.Warn(stacktrace.Propagate(err, msg))
 		return h.responseBadRequest(c, err)
 	}
 
 	request.WebhookID = c.Params("webhookID")
 	if errors := h.validator.ValidateUpdate(ctx, h.userIDFomContext(c), request.Sanitize()); len(errors) != 0 {
-		msg := fmt.Sprintf("validation errors [%s], while updating user [%+#v]", spew.Sdump(errors), request)
+		// the request is not logged because the headers, the signing key and the client key are secrets
+		msg := fmt.Sprintf("validation errors [%s], while updating webhook [%s] for user [%s]", spew.Sdump(errors), request.WebhookID, h.userIDFomContext(c))
 		ctxLogger.Warn(stacktrace.NewError(msg))
 		return h.responseUnprocessableEntity(c, errors, "validation errors while updating webhook")
 	}
 
-	user, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c)))
+	user, err := h.service.Update(ctx, request.ToUpdateParams(h.userFromContext(c), c.OriginalURL()))
 	if err != nil {
-		msg := fmt.Sprintf("cannot update user with params [%+#v]", request)
+		msg := fmt.Sprintf("cannot update webhook [%s] for user [%s]", request.WebhookID, h.userIDFomContext(c))
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
 		return h.responseInternalServerError(c)
 	}
 
 	return h.responseOK(c, "webhook updated successfully", user)
 }
+
+// Replay re-sends historical events to an entities.Webhook
+// @Summary      Replay webhook events
+// @Description  Re-send the events in a time range to a webhook. The events are sent asynchronously, use the ID of the replay to check the progress.
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param 		 webhookID	path		string 							true 	"ID of the webhook" 					default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param        payload   	body 		requests.WebhookReplay  		true 	"Time range and events to replay"
+// @Success      202 		{object}	responses.WebhookReplayResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/replay 	[post]
+func (h *WebhookHandler) Replay(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	var request requests.WebhookReplay
+	if err := c.BodyParser(&request); err != nil {
+		msg := fmt.Sprintf("cannot marshall params [%s] into [%T]", c.Body(), request)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return h.responseBadRequest(c, err)
+	}
+
+	request.WebhookID = c.Params("webhookID")
+	if errors := h.validator.ValidateReplay(ctx, request.Sanitize()); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while replaying webhook [%+#v]", spew.Sdump(errors), request)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while replaying webhook")
+	}
+
+	replay, err := h.service.Replay(ctx, request.ToReplayParams(h.userFromContext(c), c.OriginalURL()))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook with ID [%s]", request.WebhookID))
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodeWebhookReplayTooLarge {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot replay webhook with params [%+#v]", request)))
+		return h.responseError(c, fiber.StatusUnprocessableEntity, h.errorCode(err, responses.ErrorCodeValidationFailed), h.translate(c, "validation errors while replaying webhook"), map[string][]string{"from": {"The time range has too many events, use a shorter time range"}})
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot replay webhook with params [%+#v]", request)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseAccepted(c, h.translate(c, "replaying %d %s", replay.TotalEvents, h.pluralize(c, "event", int(replay.TotalEvents))), replay)
+}
+
+// ShowReplay returns the progress of an entities.WebhookReplay
+// @Summary      Get a webhook replay
+// @Description  Get the progress of a webhook replay
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param 		 webhookID	path		string 							true 	"ID of the webhook" 					default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Param 		 replayID	path		string 							true 	"ID of the replay" 						default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.WebhookReplayResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/replays/{replayID} 	[get]
+func (h *WebhookHandler) ShowReplay(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	webhookID := c.Params("webhookID")
+	replayID := c.Params("replayID")
+	errors := h.validator.ValidateUUID(ctx, webhookID, "webhookID")
+	for field, messages := range h.validator.ValidateUUID(ctx, replayID, "replayID") {
+		errors[field] = messages
+	}
+
+	if len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching replay with ID [%s]", spew.Sdump(errors), replayID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching webhook replay")
+	}
+
+	replay, err := h.service.LoadReplay(ctx, h.userIDFomContext(c), uuid.MustParse(webhookID), uuid.MustParse(replayID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook replay with ID [%s]", replayID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook replay with ID [%s]", replayID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "webhook replay fetched successfully", replay)
+}
+
+// Stats returns the delivery metrics of an entities.Webhook
+// @Summary      Get webhook delivery stats
+// @Description  Get the p50/p95 latency, success rate and counts by status code of the deliveries to a webhook over the last hour
+// @Security	 ApiKeyAuth
+// @Tags         Webhooks
+// @Accept       json
+// @Produce      json
+// @Param 		 webhookID	path		string 							true 	"ID of the webhook" 					default(32343a19-da5e-4b1b-a767-3298a73703ca)
+// @Success      200 		{object}	responses.WebhookStatsResponse
+// @Failure      400		{object}	responses.BadRequest
+// @Failure 	 401    	{object}	responses.Unauthorized
+// @Failure      404		{object}	responses.NotFound
+// @Failure      422		{object}	responses.UnprocessableEntity
+// @Failure      500		{object}	responses.InternalServerError
+// @Router       /webhooks/{webhookID}/stats 	[get]
+func (h *WebhookHandler) Stats(c *fiber.Ctx) error {
+	ctx, span, ctxLogger := h.tracer.StartFromFiberCtxWithLogger(c, h.logger)
+	defer span.End()
+
+	webhookID := c.Params("webhookID")
+	if errors := h.validator.ValidateUUID(ctx, webhookID, "webhookID"); len(errors) != 0 {
+		msg := fmt.Sprintf("validation errors [%s], while fetching stats of webhook with ID [%s]", spew.Sdump(errors), webhookID)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return h.responseUnprocessableEntity(c, errors, "validation errors while fetching webhook stats")
+	}
+
+	stats, err := h.service.Stats(ctx, h.userIDFomContext(c), uuid.MustParse(webhookID))
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return h.responseNotFound(c, fmt.Sprintf("cannot find webhook with ID [%s]", webhookID))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch stats of webhook with ID [%s]", webhookID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return h.responseInternalServerError(c)
+	}
+
+	return h.responseOK(c, "webhook stats fetched successfully", stats)
+}
@@ -0,0 +1,77 @@
+package handlers
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	fasthttpwebsocket "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+)
+
+// websocketMaxMessageSize is the maximum size of a message sent by a client, the clients of the API only send control frames
+const websocketMaxMessageSize = 4096
+
+// websocketWriteTimeout is the maximum duration for writing a message to a client whose connection is stuck
+const websocketWriteTimeout = 10 * time.Second
+
+// websocketConn is a server side websocket connection which sends text messages to the client.
+// The writes are serialized because a websocket connection supports only 1 concurrent writer.
+type websocketConn struct {
+	conn  *fasthttpwebsocket.Conn
+	mutex sync.Mutex
+}
+
+// upgradeWebsocket switches the protocol of the request to a websocket and calls handle with the connection after the handshake
+func (h *handler) upgradeWebsocket(c *fiber.Ctx, handle func(conn *websocketConn)) error {
+	if !websocket.IsWebSocketUpgrade(c) {
+		return h.responseBadRequest(c, errors.New("the request must be a websocket upgrade request"))
+	}
+
+	return websocket.New(func(conn *websocket.Conn) {
+		// the *websocket.Conn is reused once this function returns so the underlying connection is used instead
+		socket := &websocketConn{conn: conn.Conn}
+		defer socket.close()
+
+		socket.conn.SetReadLimit(websocketMaxMessageSize)
+		handle(socket)
+	})(c)
+}
+
+// WriteText sends a text message to the client
+func (conn *websocketConn) WriteText(payload []byte) error {
+	conn.mutex.Lock()
+	defer conn.mutex.Unlock()
+
+	if err := conn.conn.SetWriteDeadline(time.Now().Add(websocketWriteTimeout)); err != nil {
+		return err
+	}
+	return conn.conn.WriteMessage(websocket.TextMessage, payload)
+}
+
+// Ping sends a ping frame which fails when the client has disconnected
+func (conn *websocketConn) Ping() error {
+	return conn.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(websocketWriteTimeout))
+}
+
+// Close sends a close frame with the code and the reason to the client
+func (conn *websocketConn) Close(code int, reason string) error {
+	return conn.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(websocketWriteTimeout))
+}
+
+// ReadUntilClosed answers the control frames of the client and returns nil when the client closes the connection
+func (conn *websocketConn) ReadUntilClosed() error {
+	for {
+		if _, _, err := conn.conn.ReadMessage(); err != nil {
+			if websocket.IsCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseNoStatusReceived) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func (conn *websocketConn) close() {
+	_ = conn.conn.Close()
+}
@@ -0,0 +1,143 @@
+package handlers
+
+import (
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	fasthttpwebsocket "github.com/fasthttp/websocket"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/websocket/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveWebsocket starts an app which upgrades the requests to a websocket handled by handle and dials it
+func serveWebsocket(t *testing.T, handle func(conn *websocketConn)) *fasthttpwebsocket.Conn {
+	h := &handler{}
+	app := fiber.New(fiber.Config{DisableStartupMessage: true})
+	app.Get("/ws", func(c *fiber.Ctx) error { return h.upgradeWebsocket(c, handle) })
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() { _ = app.Listener(listener) }()
+	t.Cleanup(func() { _ = app.Shutdown() })
+
+	client, _, err := fasthttpwebsocket.DefaultDialer.Dial("ws://"+listener.Addr().String()+"/ws", nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+	return client
+}
+
+func TestHandler_upgradeWebsocket(t *testing.T) {
+	t.Run("a request which is not a websocket upgrade is rejected", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		h := &handler{}
+		app := fiber.New()
+		app.Get("/ws", func(c *fiber.Ctx) error {
+			return h.upgradeWebsocket(c, func(conn *websocketConn) {})
+		})
+
+		// Act
+		response, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/ws", nil))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusBadRequest, response.StatusCode)
+	})
+
+	t.Run("text messages are sent to the client", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		client := serveWebsocket(t, func(conn *websocketConn) {
+			_ = conn.WriteText([]byte(`{"type":"phone.heartbeat.online"}`))
+			_ = conn.ReadUntilClosed()
+		})
+
+		// Act
+		messageType, payload, err := client.ReadMessage()
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, websocket.TextMessage, messageType)
+		assert.Equal(t, `{"type":"phone.heartbeat.online"}`, string(payload))
+	})
+
+	t.Run("ReadUntilClosed returns nil when the client closes the connection", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		result := make(chan error, 1)
+
+		// Arrange
+		client := serveWebsocket(t, func(conn *websocketConn) {
+			result <- conn.ReadUntilClosed()
+		})
+
+		// Act
+		err := client.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+
+		// Assert
+		require.NoError(t, err)
+		assert.NoError(t, <-result)
+	})
+
+	t.Run("ReadUntilClosed fails when the client sends a message which is too large", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		result := make(chan error, 1)
+
+		// Arrange
+		client := serveWebsocket(t, func(conn *websocketConn) {
+			result <- conn.ReadUntilClosed()
+		})
+
+		// Act
+		err := client.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("a", websocketMaxMessageSize+1)))
+
+		// Assert
+		require.NoError(t, err)
+		assert.ErrorIs(t, <-result, fasthttpwebsocket.ErrReadLimit)
+	})
+
+	t.Run("the client receives the code of the close frame", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		client := serveWebsocket(t, func(conn *websocketConn) {
+			_ = conn.Close(websocket.CloseGoingAway, "the server is shutting down")
+		})
+
+		// Act
+		_, _, err := client.ReadMessage()
+
+		// Assert
+		assert.True(t, websocket.IsCloseError(err, websocket.CloseGoingAway))
+	})
+
+	t.Run("the client receives the ping frames", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		pings := make(chan string, 1)
+
+		// Arrange
+		client := serveWebsocket(t, func(conn *websocketConn) {
+			_ = conn.Ping()
+			_ = conn.ReadUntilClosed()
+		})
+		client.SetPingHandler(func(data string) error {
+			pings <- data
+			return nil
+		})
+
+		// Act
+		go func() { _, _, _ = client.ReadMessage() }()
+
+		// Assert
+		assert.Equal(t, "", <-pings)
+	})
+}
@@ -0,0 +1,78 @@
+package i18n
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Locale is the language used for the human-readable messages of the API
+type Locale string
+
+const (
+	// LocaleEnglish is the default locale
+	LocaleEnglish = Locale("en")
+
+	// LocaleFrench is used for french speaking users
+	LocaleFrench = Locale("fr")
+)
+
+// Locales are all the supported locales
+var Locales = []Locale{
+	LocaleEnglish,
+	LocaleFrench,
+}
+
+// String gets the string representation of the Locale
+func (locale Locale) String() string {
+	return string(locale)
+}
+
+// ParseLocale returns the supported Locale for a language tag e.g. fr-CA, it returns an empty Locale when the language is not supported
+func ParseLocale(value string) Locale {
+	language := strings.ToLower(strings.TrimSpace(value))
+	if index := strings.IndexAny(language, "-_"); index != -1 {
+		language = language[:index]
+	}
+
+	for _, locale := range Locales {
+		if locale.String() == language {
+			return locale
+		}
+	}
+	return ""
+}
+
+// ParseAcceptLanguage returns the supported Locale with the highest quality in an Accept-Language header e.g. "fr-CH, fr;q=0.9, en;q=0.8".
+// The LocaleEnglish is returned when no language in the header is supported.
+func ParseAcceptLanguage(header string) Locale {
+	type language struct {
+		locale  Locale
+		quality float64
+	}
+
+	var languages []language
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+
+		quality := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				quality = parsed
+			}
+		}
+
+		if locale := ParseLocale(tag); locale != "" && quality > 0 {
+			languages = append(languages, language{locale: locale, quality: quality})
+		}
+	}
+
+	if len(languages) == 0 {
+		return LocaleEnglish
+	}
+
+	sort.SliceStable(languages, func(i, j int) bool {
+		return languages[i].quality > languages[j].quality
+	})
+	return languages[0].locale
+}
@@ -0,0 +1,64 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseLocale(t *testing.T) {
+	tests := []struct {
+		value  string
+		locale Locale
+	}{
+		{value: "en", locale: LocaleEnglish},
+		{value: "fr", locale: LocaleFrench},
+		{value: "fr-CA", locale: LocaleFrench},
+		{value: " FR_ch ", locale: LocaleFrench},
+		{value: "de", locale: ""},
+		{value: "", locale: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.value, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			locale := ParseLocale(tt.value)
+
+			// Assert
+			assert.Equal(t, tt.locale, locale)
+		})
+	}
+}
+
+func TestParseAcceptLanguage(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		locale Locale
+	}{
+		{name: "an empty header is english", header: "", locale: LocaleEnglish},
+		{name: "the first supported language is used", header: "fr-CH, fr;q=0.9, en;q=0.8", locale: LocaleFrench},
+		{name: "the language with the highest quality is used", header: "en;q=0.5, fr;q=0.9", locale: LocaleFrench},
+		{name: "unsupported languages are skipped", header: "de-DE, es;q=0.9, fr;q=0.1", locale: LocaleFrench},
+		{name: "a language with a zero quality is not accepted", header: "fr;q=0", locale: LocaleEnglish},
+		{name: "english is used when no language is supported", header: "de, es", locale: LocaleEnglish},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			locale := ParseAcceptLanguage(tt.header)
+
+			// Assert
+			assert.Equal(t, tt.locale, locale)
+		})
+	}
+}
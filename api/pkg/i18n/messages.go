@@ -0,0 +1,47 @@
+package i18n
+
+// messages are the translations of the english response messages
+var messages = map[Locale]map[string]string{
+	LocaleFrench: {
+		"fetched %d %s":                     "%d %s récupéré(s)",
+		"deleted %d %s":                     "%d %s supprimé(s)",
+		"found %d %s":                       "%d %s trouvé(s)",
+		"registered %d %s":                  "%d %s enregistré(s)",
+		"replaying %d %s":                   "renvoi de %d %s",
+		"marked %d %s as read":              "%d %s marqué(s) comme lu(s)",
+		"The request isn't properly formed": "La requête est mal formée",
+		"We ran into an internal error while handling the request.":              "Une erreur interne s'est produite lors du traitement de la requête.",
+		"You are not authorized to carry out this request.":                      "Vous n'êtes pas autorisé à effectuer cette requête.",
+		"Make sure your API key is set in the [X-API-Key] header in the request": "Assurez-vous que votre clé API est définie dans l'en-tête [X-API-Key] de la requête",
+		"validation errors while sending message":                                "erreurs de validation lors de l'envoi du message",
+		"message added to queue":                                                 "message ajouté à la file d'attente",
+		"message added to queue and the push notification was sent":              "message ajouté à la file d'attente et la notification push a été envoyée",
+		"message added to queue but the push notification timed out":             "message ajouté à la file d'attente mais la notification push a expiré",
+		"user fetched successfully":                                              "utilisateur récupéré avec succès",
+		"user updated successfully":                                              "utilisateur mis à jour avec succès",
+		"phone updated successfully":                                             "téléphone mis à jour avec succès",
+		"phone deleted successfully":                                             "téléphone supprimé avec succès",
+		"webhook updated successfully":                                           "webhook mis à jour avec succès",
+		"webhook deleted successfully":                                           "webhook supprimé avec succès",
+		"message thread updated successfully":                                    "fil de discussion mis à jour avec succès",
+		"the message is longer than the inbound limit of your account":           "le message dépasse la limite de réception de votre compte",
+		"a message cannot have more than %d labels":                              "un message ne peut pas avoir plus de %d libellés",
+	},
+}
+
+// nouns are the singular and plural forms of the english nouns used in response messages
+var nouns = map[Locale]map[string][2]string{
+	LocaleFrench: {
+		"phone":                {"téléphone", "téléphones"},
+		"heartbeat":            {"signal de vie", "signaux de vie"},
+		"message":              {"message", "messages"},
+		"message thread":       {"fil de discussion", "fils de discussion"},
+		"webhook":              {"webhook", "webhooks"},
+		"discord integration":  {"intégration discord", "intégrations discord"},
+		"billing usage record": {"enregistrement de facturation", "enregistrements de facturation"},
+		"call event":           {"appel", "appels"},
+		"event":                {"événement", "événements"},
+		"IP address":           {"adresse IP", "adresses IP"},
+		"label":                {"libellé", "libellés"},
+	},
+}
@@ -0,0 +1,42 @@
+package i18n
+
+import "fmt"
+
+// Translate returns the message in the locale, the message is used as is when it has no translation.
+// The message is formatted with fmt.Sprintf when args are provided.
+func Translate(locale Locale, message string, args ...any) string {
+	if translation, ok := messages[locale][message]; ok {
+		message = translation
+	}
+
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// Pluralize returns the singular or plural form of an english noun in the locale
+func Pluralize(locale Locale, noun string, count int) string {
+	forms, ok := nouns[locale][noun]
+	if !ok {
+		return pluralizeEnglish(noun, count)
+	}
+
+	if rules[locale](count) {
+		return forms[0]
+	}
+	return forms[1]
+}
+
+func pluralizeEnglish(noun string, count int) string {
+	if count == 1 {
+		return noun
+	}
+	return noun + "s"
+}
+
+// rules determine if the singular form of a noun is used for a count
+var rules = map[Locale]func(count int) bool{
+	LocaleEnglish: func(count int) bool { return count == 1 },
+	LocaleFrench:  func(count int) bool { return count == 0 || count == 1 },
+}
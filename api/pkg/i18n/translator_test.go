@@ -0,0 +1,67 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslate(t *testing.T) {
+	tests := []struct {
+		name        string
+		locale      Locale
+		message     string
+		args        []any
+		translation string
+	}{
+		{name: "an english message is not translated", locale: LocaleEnglish, message: "user updated successfully", translation: "user updated successfully"},
+		{name: "a french message is translated", locale: LocaleFrench, message: "user updated successfully", translation: "utilisateur mis à jour avec succès"},
+		{name: "the translation is formatted", locale: LocaleFrench, message: "fetched %d %s", args: []any{2, "messages"}, translation: "2 messages récupéré(s)"},
+		{name: "a message without a translation is used as is", locale: LocaleFrench, message: "no translation for %s", args: []any{"this"}, translation: "no translation for this"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			translation := Translate(tt.locale, tt.message, tt.args...)
+
+			// Assert
+			assert.Equal(t, tt.translation, translation)
+		})
+	}
+}
+
+func TestPluralize(t *testing.T) {
+	tests := []struct {
+		name   string
+		locale Locale
+		noun   string
+		count  int
+		result string
+	}{
+		{name: "a single english noun", locale: LocaleEnglish, noun: "phone", count: 1, result: "phone"},
+		{name: "many english nouns", locale: LocaleEnglish, noun: "phone", count: 2, result: "phones"},
+		{name: "zero english nouns are plural", locale: LocaleEnglish, noun: "phone", count: 0, result: "phones"},
+		{name: "zero french nouns are singular", locale: LocaleFrench, noun: "heartbeat", count: 0, result: "signal de vie"},
+		{name: "many french nouns", locale: LocaleFrench, noun: "heartbeat", count: 2, result: "signaux de vie"},
+		{name: "a noun without a translation is pluralized in english", locale: LocaleFrench, noun: "widget", count: 2, result: "widgets"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			result := Pluralize(tt.locale, tt.noun, tt.count)
+
+			// Assert
+			assert.Equal(t, tt.result, result)
+		})
+	}
+}
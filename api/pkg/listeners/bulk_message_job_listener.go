@@ -0,0 +1,62 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// BulkMessageJobListener releases the messages of the bulk message jobs
+type BulkMessageJobListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.BulkMessageJobService
+}
+
+// NewBulkMessageJobListener creates a new instance of BulkMessageJobListener
+func NewBulkMessageJobListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.BulkMessageJobService,
+) (l *BulkMessageJobListener, routes map[string]events.EventListener) {
+	l = &BulkMessageJobListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeBulkMessageJobRelease: l.onBulkMessageJobRelease,
+	}
+}
+
+// onBulkMessageJobRelease handles the events.EventTypeBulkMessageJobRelease event
+func (listener *BulkMessageJobListener) onBulkMessageJobRelease(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.BulkMessageJobReleasePayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.BulkMessageJobReleaseParams{
+		UserID:   payload.UserID,
+		JobID:    payload.JobID,
+		Sequence: payload.Sequence,
+		Source:   event.Source(),
+	}
+
+	if err := listener.service.Release(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot release bulk message job [%s] with sequence [%d] for event with ID [%s]", payload.JobID, payload.Sequence, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,76 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// EventLogListener stores the events which change the state of a resource in the audit trail
+type EventLogListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.EventLogService
+}
+
+// NewEventLogListener creates a new instance of EventLogListener
+func NewEventLogListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.EventLogService,
+) (l *EventLogListener, routes map[string]events.EventListener) {
+	l = &EventLogListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessageAPISent:         l.onEvent,
+		events.MessageAPIDeleted:               l.onEvent,
+		events.EventTypeMessagePhoneReceived:   l.onEvent,
+		events.EventTypeMessagePhoneSent:       l.onEvent,
+		events.EventTypeMessagePhoneDelivered:  l.onEvent,
+		events.EventTypeMessageRead:            l.onEvent,
+		events.EventTypeMessageReacted:         l.onEvent,
+		events.EventTypeMessageCancelled:       l.onEvent,
+		events.EventTypeMessageScheduled:       l.onEvent,
+		events.EventTypeMessageReleased:        l.onEvent,
+		events.EventTypeMessageSendFailed:      l.onEvent,
+		events.EventTypeMessageSendExpired:     l.onEvent,
+		events.MessageCallMissed:               l.onEvent,
+		events.EventTypePhoneUpdated:           l.onEvent,
+		events.EventTypePhoneDeleted:           l.onEvent,
+		events.EventTypePhoneHeartbeatOnline:   l.onEvent,
+		events.EventTypePhoneHeartbeatOffline:  l.onEvent,
+		events.EventTypePhoneBatteryLow:        l.onEvent,
+		events.EventTypePhoneBatteryOk:         l.onEvent,
+		events.EventTypePhoneFcmTokenInvalid:   l.onEvent,
+		events.EventTypePhoneFcmTokenRefreshed: l.onEvent,
+		events.EventTypePhoneResumed:           l.onEvent,
+		events.EventTypeCallReceived:           l.onEvent,
+		events.EventTypeCallMissed:             l.onEvent,
+		events.EventTypeWebhookDisabled:        l.onEvent,
+		events.EventTypeIntegrationCreated:     l.onEvent,
+		events.EventTypeIntegrationUpdated:     l.onEvent,
+		events.EventTypeIntegrationDeleted:     l.onEvent,
+	}
+}
+
+// onEvent stores any event which is subscribed to by the EventLogListener
+func (listener *EventLogListener) onEvent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	if err := listener.service.Log(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot log [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -40,9 +40,13 @@ func NewMessageListener(
 		events.EventTypeMessageNotificationFailed:    l.onMessageNotificationFailed,
 		events.EventTypeMessageSendExpiredCheck:      l.onMessageSendExpiredCheck,
 		events.EventTypeMessageSendExpired:           l.onMessageSendExpired,
+		events.EventTypeMessageDeliveryReportCheck:   l.onMessageDeliveryReportCheck,
 		events.EventTypeMessageNotificationScheduled: l.onMessageNotificationScheduled,
 		events.MessageThreadAPIDeleted:               l.onMessageThreadAPIDeleted,
 		events.MessageCallMissed:                     l.onMessageCallMissed,
+		events.EventTypePhoneDeleted:                 l.onPhoneDeleted,
+		events.EventTypePhoneFcmTokenRefreshed:       l.onPhoneFcmTokenRefreshed,
+		events.EventTypeMessageAPISent:               l.onMessageAPISent,
 	}
 }
 
@@ -94,6 +98,18 @@ func (listener *MessageListener) OnMessagePhoneSent(ctx context.Context, event c
 		msg := fmt.Sprintf("cannot handle [%s] for message with ID [%s] for event with ID [%s]", event.Type(), handleParams.ID, event.ID())
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
+
+	checkParams := services.MessageScheduleDeliveryReportCheckParams{
+		MessageID: payload.ID,
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		SentAt:    payload.Timestamp,
+		Source:    event.Source(),
+	}
+	if err := listener.service.ScheduleDeliveryReportCheck(ctx, checkParams); err != nil {
+		msg := fmt.Sprintf("cannot schedule delivery report check for message with ID [%s] for event with ID [%s]", checkParams.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
 	return nil
 }
 
@@ -159,9 +175,16 @@ func (listener *MessageListener) onMessageNotificationFailed(ctx context.Context
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	message, err := listener.service.GetMessage(ctx, payload.UserID, payload.MessageID)
+	failedParams := services.HandleMessageNotificationFailedParams{
+		ID:           payload.MessageID,
+		UserID:       payload.UserID,
+		Timestamp:    payload.NotificationFailedAt,
+		ErrorCode:    payload.ErrorCode,
+		ErrorMessage: payload.ErrorMessage,
+	}
+	message, err := listener.service.HandleMessageNotificationFailed(ctx, failedParams)
 	if err != nil {
-		msg := fmt.Sprintf("cannot load message with id [%s] and user id [%s]", payload.MessageID, payload.UserID)
+		msg := fmt.Sprintf("cannot handle event [%s] for message [%s] and user id [%s]", event.Type(), payload.MessageID, payload.UserID)
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
@@ -180,6 +203,32 @@ func (listener *MessageListener) onMessageNotificationFailed(ctx context.Context
 	return nil
 }
 
+// onPhoneFcmTokenRefreshed handles the events.EventTypePhoneFcmTokenRefreshed event
+func (listener *MessageListener) onPhoneFcmTokenRefreshed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneFcmTokenRefreshedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	handleParams := services.HandlePhoneFcmTokenRefreshedParams{
+		UserID:    payload.UserID,
+		Owner:     payload.Owner,
+		Timestamp: payload.Timestamp,
+		Source:    event.Source(),
+	}
+
+	if err := listener.service.HandlePhoneFcmTokenRefreshed(ctx, handleParams); err != nil {
+		msg := fmt.Sprintf("cannot handle [%s] for phone [%s] for event with ID [%s]", event.Type(), payload.PhoneID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // onMessageNotificationSent handles the events.EventTypeMessageNotificationSent event
 func (listener *MessageListener) onMessageNotificationSent(ctx context.Context, event cloudevents.Event) error {
 	ctx, span := listener.tracer.Start(ctx)
@@ -204,11 +253,12 @@ func (listener *MessageListener) onMessageNotificationSent(ctx context.Context,
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	handleParams := services.HandleMessageParams{
-		ID:        payload.MessageID,
-		UserID:    payload.UserID,
-		Source:    event.Source(),
-		Timestamp: payload.NotificationSentAt,
+	handleParams := services.HandleMessageNotificationSentParams{
+		ID:           payload.MessageID,
+		UserID:       payload.UserID,
+		Source:       event.Source(),
+		Timestamp:    payload.NotificationSentAt,
+		FcmMessageID: payload.FcmMessageID,
 	}
 	if err := listener.service.HandleMessageNotificationSent(ctx, handleParams); err != nil {
 		msg := fmt.Sprintf("cannot handle event [%s] for message [%s] and userID [%s]", event.Type(), checkParams.MessageID, checkParams.UserID)
@@ -242,6 +292,30 @@ func (listener *MessageListener) onMessageSendExpiredCheck(ctx context.Context,
 	return nil
 }
 
+// onMessageDeliveryReportCheck handles the events.EventTypeMessageDeliveryReportCheck event
+func (listener *MessageListener) onMessageDeliveryReportCheck(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageDeliveryReportCheckPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	checkParams := services.MessageCheckDeliveryReport{
+		MessageID: payload.MessageID,
+		UserID:    payload.UserID,
+		Source:    event.Source(),
+	}
+	if err := listener.service.CheckDeliveryReport(ctx, checkParams); err != nil {
+		msg := fmt.Sprintf("cannot check delivery report for message with ID [%s] and userID [%s]", checkParams.MessageID, checkParams.UserID)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // onMessageSendExpired handles the events.EventTypeMessageSendExpired event
 func (listener *MessageListener) onMessageSendExpired(ctx context.Context, event cloudevents.Event) error {
 	ctx, span := listener.tracer.Start(ctx)
@@ -329,3 +403,49 @@ func (listener *MessageListener) onMessageCallMissed(ctx context.Context, event
 
 	return nil
 }
+
+// onPhoneDeleted handles the events.EventTypePhoneDeleted event
+func (listener *MessageListener) onPhoneDeleted(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneDeletedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	handleParams := services.HandlePhoneDeletedParams{
+		UserID:     payload.UserID,
+		Owner:      payload.Owner,
+		ReassignTo: payload.ReassignTo,
+		Timestamp:  event.Time(),
+		Source:     event.Source(),
+	}
+
+	if err := listener.service.HandlePhoneDeleted(ctx, handleParams); err != nil {
+		msg := fmt.Sprintf("cannot handle pending messages of deleted phone [%s] for event with ID [%s]", payload.Owner, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageAPISent handles the events.EventTypeMessageAPISent event
+func (listener *MessageListener) onMessageAPISent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageAPISentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.HandleMessageReleased(ctx, event.Source(), payload); err != nil {
+		msg := fmt.Sprintf("cannot release scheduled message [%s] for event with ID [%s]", payload.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
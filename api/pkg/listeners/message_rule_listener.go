@@ -0,0 +1,55 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// MessageRuleListener evaluates the message rules of a user on the messages which are received
+type MessageRuleListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.MessageRuleService
+}
+
+// NewMessageRuleListener creates a new instance of MessageRuleListener
+func NewMessageRuleListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.MessageRuleService,
+) (l *MessageRuleListener, routes map[string]events.EventListener) {
+	l = &MessageRuleListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessagePhoneReceived: l.onMessagePhoneReceived,
+	}
+}
+
+// onMessagePhoneReceived handles the events.EventTypeMessagePhoneReceived event
+func (listener *MessageRuleListener) onMessagePhoneReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessagePhoneReceivedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Evaluate(ctx, event, &payload); err != nil {
+		msg := fmt.Sprintf("cannot evaluate the message rules for [%s] event with ID [%s] and userID [%s]", event.Type(), event.ID(), payload.UserID)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
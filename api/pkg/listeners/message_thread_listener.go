@@ -42,6 +42,7 @@ func NewMessageThreadListener(
 		events.EventTypeMessagePhoneReceived:         l.OnMessagePhoneReceived,
 		events.EventTypeMessageNotificationScheduled: l.onMessageNotificationScheduled,
 		events.EventTypeMessageSendExpired:           l.onMessageExpired,
+		events.EventTypeMessageCancelled:             l.onMessageCancelled,
 	}
 }
 
@@ -296,6 +297,35 @@ func (listener *MessageThreadListener) onMessageExpired(ctx context.Context, eve
 	return nil
 }
 
+// onMessageCancelled handles the events.EventTypeMessageCancelled event
+func (listener *MessageThreadListener) onMessageCancelled(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageCancelledPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	updateParams := services.MessageThreadUpdateParams{
+		Owner:     payload.Owner,
+		Contact:   payload.Contact,
+		Timestamp: payload.Timestamp,
+		UserID:    payload.UserID,
+		Content:   payload.Content,
+		Status:    entities.MessageStatusCancelled,
+		MessageID: payload.MessageID,
+	}
+
+	if err := listener.service.UpdateThread(ctx, updateParams); err != nil {
+		msg := fmt.Sprintf("cannot update thread for message with ID [%s] for event with ID [%s]", updateParams.MessageID, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 func (listener *MessageThreadListener) updateThread(ctx context.Context, params services.MessageThreadUpdateParams) error {
 	return listener.service.UpdateThread(ctx, params)
 }
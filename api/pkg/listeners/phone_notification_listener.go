@@ -37,6 +37,7 @@ func NewNotificationListener(
 		events.EventTypeMessageSendRetry:        l.onMessageSendRetry,
 		events.EventTypeMessageNotificationSend: l.onMessageNotificationSend,
 		events.PhoneHeartbeatMissed:             l.onPhoneHeartbeatMissed,
+		events.EventTypePhoneResumed:            l.onPhoneResumed,
 	}
 }
 
@@ -60,6 +61,8 @@ func (listener *PhoneNotificationListener) onMessageAPISent(ctx context.Context,
 		Encrypted: payload.Encrypted,
 		Source:    event.Source(),
 		MessageID: payload.MessageID,
+
+		ValidityPeriod: payload.ValidityPeriod,
 	}
 
 	if err := listener.service.Schedule(ctx, sendParams); err != nil {
@@ -111,7 +114,7 @@ func (listener *PhoneNotificationListener) onPhoneHeartbeatMissed(ctx context.Co
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if err := listener.service.SendHeartbeatFCM(ctx, payload); err != nil {
+	if err := listener.service.SendHeartbeatFCM(ctx, event.Source(), payload); err != nil {
 		msg := fmt.Sprintf("cannot schedule send heartbeat FCM with params [%s] for event with ID [%s]", spew.Sdump(payload), event.ID())
 		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -137,6 +140,7 @@ func (listener *PhoneNotificationListener) onMessageNotificationSend(ctx context
 		ScheduledAt:         payload.ScheduledAt,
 		PhoneNotificationID: payload.NotificationID,
 		MessageID:           payload.MessageID,
+		ValidityPeriod:      payload.ValidityPeriod,
 	}
 
 	if err := listener.service.Send(ctx, scheduleParams); err != nil {
@@ -146,3 +150,28 @@ func (listener *PhoneNotificationListener) onMessageNotificationSend(ctx context
 
 	return nil
 }
+
+// onPhoneResumed handles the events.EventTypePhoneResumed event
+func (listener *PhoneNotificationListener) onPhoneResumed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneResumedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	resumeParams := &services.PhoneNotificationResumeParams{
+		UserID:  payload.UserID,
+		PhoneID: payload.PhoneID,
+		Source:  event.Source(),
+	}
+
+	if err := listener.service.Resume(ctx, resumeParams); err != nil {
+		msg := fmt.Sprintf("cannot schedule the paused notifications with params [%s] for event with ID [%s]", spew.Sdump(resumeParams), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,75 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// ProviderFallbackListener sends messages with the third-party SMS provider of a user when no phone picks them up
+type ProviderFallbackListener struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	service *services.ProviderFallbackService
+}
+
+// NewProviderFallbackListener creates a new instance of ProviderFallbackListener
+func NewProviderFallbackListener(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ProviderFallbackService,
+) (l *ProviderFallbackListener, routes map[string]events.EventListener) {
+	l = &ProviderFallbackListener{
+		logger:  logger.WithService(fmt.Sprintf("%T", l)),
+		tracer:  tracer,
+		service: service,
+	}
+
+	return l, map[string]events.EventListener{
+		events.EventTypeMessageAPISent:               l.onMessageAPISent,
+		events.EventTypeMessageProviderFallbackCheck: l.onMessageProviderFallbackCheck,
+	}
+}
+
+// onMessageAPISent handles the events.EventTypeMessageAPISent event
+func (listener *ProviderFallbackListener) onMessageAPISent(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageAPISentPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.ScheduleCheck(ctx, event.Source(), payload); err != nil {
+		msg := fmt.Sprintf("cannot schedule the provider fallback of message with ID [%s] and userID [%s]", payload.MessageID, payload.UserID)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageProviderFallbackCheck handles the events.EventTypeMessageProviderFallbackCheck event
+func (listener *ProviderFallbackListener) onMessageProviderFallbackCheck(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageProviderFallbackCheckPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, event.Source(), payload); err != nil {
+		msg := fmt.Sprintf("cannot send message with ID [%s] and userID [%s] with the provider fallback", payload.MessageID, payload.UserID)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
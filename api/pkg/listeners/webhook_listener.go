@@ -31,14 +31,28 @@ func NewWebhookListener(
 	}
 
 	return l, map[string]events.EventListener{
-		events.EventTypeMessagePhoneReceived:  l.OnMessagePhoneReceived,
-		events.EventTypeMessageSendExpired:    l.OnMessageSendExpired,
-		events.EventTypeMessagePhoneDelivered: l.OnMessagePhoneDelivered,
-		events.EventTypeMessageSendFailed:     l.OnMessageSendFailed,
-		events.EventTypeMessagePhoneSent:      l.OnMessagePhoneSent,
-		events.EventTypePhoneHeartbeatOnline:  l.onPhoneHeartbeatOnline,
-		events.EventTypePhoneHeartbeatOffline: l.onPhoneHeartbeatOffline,
-		events.MessageCallMissed:              l.onMessageCallMissed,
+		events.EventTypeMessagePhoneReceived:   l.OnMessagePhoneReceived,
+		events.EventTypeMessageSendExpired:     l.OnMessageSendExpired,
+		events.EventTypeMessagePhoneDelivered:  l.OnMessagePhoneDelivered,
+		events.EventTypeMessageRead:            l.onMessageRead,
+		events.EventTypeMessageReacted:         l.onMessageReacted,
+		events.EventTypeMessageCancelled:       l.onMessageCancelled,
+		events.EventTypeMessageScheduled:       l.onMessageScheduled,
+		events.EventTypeMessageReleased:        l.onMessageReleased,
+		events.EventTypeMessageSendFailed:      l.OnMessageSendFailed,
+		events.EventTypeMessagePhoneSent:       l.OnMessagePhoneSent,
+		events.EventTypePhoneHeartbeatOnline:   l.onPhoneHeartbeatOnline,
+		events.EventTypePhoneHeartbeatOffline:  l.onPhoneHeartbeatOffline,
+		events.MessageCallMissed:               l.onMessageCallMissed,
+		events.EventTypePhoneBatteryLow:        l.onPhoneBatteryLow,
+		events.EventTypePhoneBatteryOk:         l.onPhoneBatteryOk,
+		events.EventTypeCallReceived:           l.onCallReceived,
+		events.EventTypeCallMissed:             l.onCallMissed,
+		events.EventTypeWebhookReplayRequested: l.onWebhookReplayRequested,
+
+		events.EventTypeDiscordIntegrationCreated: l.onDiscordIntegrationCreated,
+		events.EventTypeDiscordIntegrationUpdated: l.onDiscordIntegrationUpdated,
+		events.EventTypeDiscordIntegrationDeleted: l.onDiscordIntegrationDeleted,
 	}
 }
 
@@ -80,6 +94,101 @@ func (listener *WebhookListener) OnMessageSendExpired(ctx context.Context, event
 	return nil
 }
 
+// onMessageReacted handles the events.EventTypeMessageReacted event
+func (listener *WebhookListener) onMessageReacted(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageReactedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageRead handles the events.EventTypeMessageRead event
+func (listener *WebhookListener) onMessageRead(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageReadPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageCancelled handles the events.EventTypeMessageCancelled event
+func (listener *WebhookListener) onMessageCancelled(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageCancelledPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageScheduled handles the events.EventTypeMessageScheduled event
+func (listener *WebhookListener) onMessageScheduled(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageScheduledPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onMessageReleased handles the events.EventTypeMessageReleased event
+func (listener *WebhookListener) onMessageReleased(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.MessageReleasedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // OnMessageSendFailed handles the events.EventTypeMessageSendFailed event
 func (listener *WebhookListener) OnMessageSendFailed(ctx context.Context, event cloudevents.Event) error {
 	ctx, span := listener.tracer.Start(ctx)
@@ -193,3 +302,162 @@ func (listener *WebhookListener) onMessageCallMissed(ctx context.Context, event
 
 	return nil
 }
+
+// onPhoneBatteryLow handles the events.EventTypePhoneBatteryLow event
+func (listener *WebhookListener) onPhoneBatteryLow(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneBatteryLowPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onPhoneBatteryOk handles the events.EventTypePhoneBatteryOk event
+func (listener *WebhookListener) onPhoneBatteryOk(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.PhoneBatteryOkPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onCallReceived handles the events.EventTypeCallReceived event
+func (listener *WebhookListener) onCallReceived(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.CallReceivedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onCallMissed handles the events.EventTypeCallMissed event
+func (listener *WebhookListener) onCallMissed(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.CallMissedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, payload.Owner); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onDiscordIntegrationCreated handles the events.EventTypeDiscordIntegrationCreated event
+func (listener *WebhookListener) onDiscordIntegrationCreated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.DiscordIntegrationCreatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, ""); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onDiscordIntegrationUpdated handles the events.EventTypeDiscordIntegrationUpdated event
+func (listener *WebhookListener) onDiscordIntegrationUpdated(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.DiscordIntegrationUpdatedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, ""); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onDiscordIntegrationDeleted handles the events.EventTypeDiscordIntegrationDeleted event
+func (listener *WebhookListener) onDiscordIntegrationDeleted(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.DiscordIntegrationDeletedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := listener.service.Send(ctx, payload.UserID, event, ""); err != nil {
+		msg := fmt.Sprintf("cannot process [%s] event with ID [%s]", event.Type(), event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// onWebhookReplayRequested handles the events.EventTypeWebhookReplayRequested event
+func (listener *WebhookListener) onWebhookReplayRequested(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := listener.tracer.Start(ctx)
+	defer span.End()
+
+	var payload events.WebhookReplayRequestedPayload
+	if err := event.DataAs(&payload); err != nil {
+		msg := fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload)
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params := &services.WebhookReplayProcessParams{
+		UserID:   payload.UserID,
+		ReplayID: payload.ReplayID,
+		Offset:   payload.Offset,
+		Source:   event.Source(),
+	}
+
+	if err := listener.service.ProcessReplay(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot process replay [%s] at offset [%d] for event with ID [%s]", payload.ReplayID, payload.Offset, event.ID())
+		return listener.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
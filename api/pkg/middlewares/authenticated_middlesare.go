@@ -2,6 +2,7 @@ package middlewares
 
 import (
 	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/gofiber/fiber/v2"
 )
@@ -25,9 +26,10 @@ func Authenticated(tracer telemetry.Tracer) fiber.Handler {
 
 		if tokenUser, ok := c.Locals(ContextKeyAuthUserID).(entities.AuthUser); !ok || tokenUser.IsNoop() {
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"status":  "error",
-				"message": "You are not authorized to carry out this request.",
-				"data":    "Make sure your API key is set in the [x-api-key] header in the request",
+				"status":     "error",
+				"message":    "You are not authorized to carry out this request.",
+				"error_code": responses.ErrorCodeUnauthorized,
+				"data":       "Make sure your API key is set in the [x-api-key] header in the request",
 			})
 		}
 
@@ -21,7 +21,7 @@ func HTTPRequestLogger(tracer telemetry.Tracer, logger telemetry.Logger) fiber.H
 		ctxLogger.WithString("http.method", c.Method()).
 			WithString("http.path", c.Path()).
 			WithString("client.version", c.Get(clientVersionHeader)).
-			Trace(fmt.Sprintf("%s %s", c.Method(), c.OriginalURL()))
+			Trace(fmt.Sprintf("%s %s", c.Method(), redactedURL(c)))
 
 		response := c.Next()
 
@@ -0,0 +1,40 @@
+package middlewares
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/limiter"
+	"github.com/palantir/stacktrace"
+)
+
+// IPRateLimit limits the number of requests from an IP address in a window, the Retry-After header is set when the limit is exceeded
+func IPRateLimit(logger telemetry.Logger, tracer telemetry.Tracer, max int, window time.Duration) fiber.Handler {
+	logger = logger.WithService("middlewares.IPRateLimit")
+
+	return limiter.New(limiter.Config{
+		Max:        max,
+		Expiration: window,
+		KeyGenerator: func(c *fiber.Ctx) string {
+			// the IP is read from the APP_PROXY_HEADER when the API is behind a load balancer
+			return c.IP()
+		},
+		LimitReached: func(c *fiber.Ctx) error {
+			_, span := tracer.StartFromFiberCtx(c, "middlewares.IPRateLimit")
+			defer span.End()
+
+			ctxLogger := tracer.CtxLogger(logger, span)
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("the IP [%s] has sent more than [%d] requests to [%s] in [%s]", c.IP(), max, c.Path(), window)))
+
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"status":     "error",
+				"message":    "You have sent too many requests, please try again later.",
+				"error_code": responses.ErrorCodeRateLimited,
+				"data":       fmt.Sprintf("retry after [%s] seconds", c.GetRespHeader(fiber.HeaderRetryAfter)),
+			})
+		},
+	})
+}
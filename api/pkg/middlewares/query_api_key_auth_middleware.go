@@ -0,0 +1,78 @@
+package middlewares
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+const queryAPIKey = "key"
+
+// QueryAPIKeyAuth authenticates a user from the key query parameter for the devices which can only send GET requests.
+// The request is forbidden when the user has not enabled GetSendEnabled because the API key in the URL can be stored in logs and caches.
+func QueryAPIKeyAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository repositories.UserRepository) fiber.Handler {
+	logger = logger.WithService("middlewares.QueryAPIKeyAuth")
+
+	return func(c *fiber.Ctx) error {
+		ctx, span := tracer.StartFromFiberCtx(c, "middlewares.QueryAPIKeyAuth")
+		defer span.End()
+
+		ctxLogger := tracer.CtxLogger(logger, span)
+
+		apiKey := strings.TrimSpace(c.Query(queryAPIKey))
+		if len(apiKey) == 0 {
+			span.AddEvent(fmt.Sprintf("the request has no [%s] query parameter", queryAPIKey))
+			return c.Next()
+		}
+
+		authUser, err := userRepository.LoadAuthUser(ctx, apiKey)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot load user with the [%s] query parameter", queryAPIKey)))
+			return c.Next()
+		}
+
+		// the user is not cached with the API key so that disabling GET requests takes effect immediately
+		user, err := userRepository.Load(ctx, authUser.ID)
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load user with ID [%s]", authUser.ID)))
+			return c.Next()
+		}
+
+		if !user.GetSendEnabled {
+			ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("user with ID [%s] has not enabled GET requests for the API key", user.ID)))
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":     "error",
+				"message":    "You are not authorized to carry out this request.",
+				"error_code": responses.ErrorCodeForbidden,
+				"data":       "Sending messages with GET requests is disabled for your API key, set [get_send_enabled] to true in your user settings to enable it",
+			})
+		}
+
+		c.Locals(ContextKeyAuthUserID, authUser)
+		ctxLogger.Info(fmt.Sprintf("[%T] set successfully for user with ID [%s] from the [%s] query parameter", authUser, authUser.ID, queryAPIKey))
+		return c.Next()
+	}
+}
+
+// redactedURL is the original URL of the request without the value of the key query parameter
+func redactedURL(c *fiber.Ctx) string {
+	if len(c.Query(queryAPIKey)) == 0 {
+		return c.OriginalURL()
+	}
+
+	value, err := url.Parse(c.OriginalURL())
+	if err != nil {
+		return c.Path()
+	}
+
+	query := value.Query()
+	query.Set(queryAPIKey, "REDACTED")
+	value.RawQuery = query.Encode()
+	return value.String()
+}
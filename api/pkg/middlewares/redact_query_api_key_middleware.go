@@ -0,0 +1,33 @@
+package middlewares
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// contextKeyRequestURI is the local which stores the request URI containing the key query parameter while it is redacted
+const contextKeyRequestURI = "request.uri"
+
+// RedactQueryAPIKey replaces the value of the key query parameter in the request URI so that the tracing middleware which runs next does not record the API key.
+// RestoreQueryAPIKey must run after the tracing middleware so that QueryAPIKeyAuth can read the API key.
+func RedactQueryAPIKey() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if len(c.Query(queryAPIKey)) == 0 {
+			return c.Next()
+		}
+
+		c.Locals(contextKeyRequestURI, string(c.Request().RequestURI()))
+		c.Request().SetRequestURI(redactedURL(c))
+		return c.Next()
+	}
+}
+
+// RestoreQueryAPIKey puts back the request URI which was redacted by RedactQueryAPIKey
+func RestoreQueryAPIKey() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if uri, ok := c.Locals(contextKeyRequestURI).(string); ok {
+			c.Request().SetRequestURI(uri)
+			c.Locals(contextKeyRequestURI, nil)
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,93 @@
+package middlewares
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/contrib/otelfiber"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
+)
+
+func TestRedactQueryAPIKey(t *testing.T) {
+	const apiKey = "uk_2mUO8bREbO0voPPsk2utfHIaYuQCC4lV"
+
+	newApp := func(recorder *tracetest.SpanRecorder) *fiber.App {
+		app := fiber.New()
+		app.Use(RedactQueryAPIKey())
+		app.Use(otelfiber.Middleware(otelfiber.WithTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))))
+		app.Use(RestoreQueryAPIKey())
+		app.Get("/v1/messages/send", func(c *fiber.Ctx) error {
+			return c.SendString(c.Query(queryAPIKey) + " " + c.Query("to") + " " + c.OriginalURL())
+		})
+		return app
+	}
+
+	t.Run("no span attribute contains the key query parameter", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		recorder := tracetest.NewSpanRecorder()
+		app := newApp(recorder)
+
+		// Act
+		response, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/v1/messages/send?to=%2B18005550100&key="+apiKey, nil))
+
+		// Assert
+		require.NoError(t, err)
+		assert.Equal(t, fiber.StatusOK, response.StatusCode)
+
+		spans := recorder.Ended()
+		require.Len(t, spans, 1)
+		for _, attribute := range spans[0].Attributes() {
+			assert.NotContains(t, attribute.Value.Emit(), apiKey, attribute.Key)
+			if attribute.Key == semconv.HTTPTargetKey {
+				assert.Equal(t, "/v1/messages/send?key=REDACTED&to=%2B18005550100", attribute.Value.AsString())
+			}
+		}
+		assert.NotContains(t, spans[0].Name(), apiKey)
+	})
+
+	t.Run("the handlers read the key query parameter after the span is started", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		app := newApp(tracetest.NewSpanRecorder())
+
+		// Act
+		response, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/v1/messages/send?to=%2B18005550100&key="+apiKey, nil))
+
+		// Assert
+		require.NoError(t, err)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		assert.Equal(t, []string{apiKey, "+18005550100", "/v1/messages/send?to=%2B18005550100&key=" + apiKey}, strings.Split(string(body), " "))
+	})
+
+	t.Run("requests without the key query parameter are not changed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		recorder := tracetest.NewSpanRecorder()
+		app := newApp(recorder)
+
+		// Act
+		response, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/v1/messages/send?to=%2B18005550100", nil))
+
+		// Assert
+		require.NoError(t, err)
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		assert.Equal(t, " +18005550100 /v1/messages/send?to=%2B18005550100", string(body))
+		require.Len(t, recorder.Ended(), 1)
+	})
+}
@@ -0,0 +1,59 @@
+package middlewares
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/responses"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/gofiber/fiber/v2"
+	"github.com/palantir/stacktrace"
+)
+
+const authHeaderSubAccountID = "x-sub-account-id"
+
+// SubAccountAuth makes a sub-account the effective account of a request which is authenticated by its main account with the X-Sub-Account-ID header
+func SubAccountAuth(logger telemetry.Logger, tracer telemetry.Tracer, userRepository repositories.UserRepository) fiber.Handler {
+	logger = logger.WithService("middlewares.SubAccountAuth")
+
+	return func(c *fiber.Ctx) error {
+		ctx, span := tracer.StartFromFiberCtx(c, "middlewares.SubAccountAuth")
+		defer span.End()
+
+		ctxLogger := tracer.CtxLogger(logger, span)
+
+		subAccountID := strings.TrimSpace(c.Get(authHeaderSubAccountID))
+		if len(subAccountID) == 0 {
+			return c.Next()
+		}
+
+		authUser, ok := c.Locals(ContextKeyAuthUserID).(entities.AuthUser)
+		if !ok || authUser.IsNoop() {
+			span.AddEvent(fmt.Sprintf("the request with the [%s] header is not authenticated", authHeaderSubAccountID))
+			return c.Next()
+		}
+
+		user, err := userRepository.LoadSubAccount(ctx, authUser.ID, entities.UserID(subAccountID))
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot load sub-account [%s] of user [%s]", subAccountID, authUser.ID)))
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"status":     "error",
+				"message":    "You are not authorized to carry out this request.",
+				"error_code": responses.ErrorCodeForbidden,
+				"data":       fmt.Sprintf("The [%s] header must be the ID of one of your sub-accounts", authHeaderSubAccountID),
+			})
+		}
+
+		c.Locals(ContextKeyAuthUserID, entities.AuthUser{
+			ID:       user.ID,
+			Email:    user.Email,
+			Locale:   user.Locale,
+			Timezone: user.Timezone,
+		})
+
+		ctxLogger.Info(fmt.Sprintf("sub-account [%s] set successfully for user with ID [%s]", user.ID, authUser.ID))
+		return c.Next()
+	}
+}
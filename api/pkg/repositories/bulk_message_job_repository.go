@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// BulkMessageJobRepository loads and persists an entities.BulkMessageJob and its entities.BulkMessageJobItem
+type BulkMessageJobRepository interface {
+	// Store creates an entities.BulkMessageJob and its items in a single transaction
+	Store(ctx context.Context, job *entities.BulkMessageJob, items []*entities.BulkMessageJobItem) error
+
+	// Update saves an entities.BulkMessageJob when its sequence in the database is still the given sequence, it returns false when the job was changed by another request
+	Update(ctx context.Context, job *entities.BulkMessageJob, sequence uint) (bool, error)
+
+	// Load an entities.BulkMessageJob by ID
+	Load(ctx context.Context, userID entities.UserID, jobID uuid.UUID) (*entities.BulkMessageJob, error)
+
+	// CountActive counts the entities.BulkMessageJob of a user which are running or paused
+	CountActive(ctx context.Context, userID entities.UserID) (int, error)
+
+	// FetchItems fetches the items of an entities.BulkMessageJob starting at the position
+	FetchItems(ctx context.Context, jobID uuid.UUID, position uint, limit uint) ([]*entities.BulkMessageJobItem, error)
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// CallEventRepository loads and persists an entities.CallEvent
+type CallEventRepository interface {
+	// Store a new entities.CallEvent
+	Store(ctx context.Context, event *entities.CallEvent) error
+
+	// Index entities.CallEvent of a phone
+	Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, params IndexParams) (*[]entities.CallEvent, error)
+}
@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// ContentFilterRepository loads and persists an entities.ContentFilter
+type ContentFilterRepository interface {
+	// Save Upsert a new entities.ContentFilter
+	Save(ctx context.Context, filter *entities.ContentFilter) error
+
+	// Index entities.ContentFilter by entities.UserID
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.ContentFilter, error)
+
+	// FetchAll loads all the entities.ContentFilter of a user
+	FetchAll(ctx context.Context, userID entities.UserID) ([]*entities.ContentFilter, error)
+
+	// Load loads an entities.ContentFilter by ID.
+	Load(ctx context.Context, userID entities.UserID, filterID uuid.UUID) (*entities.ContentFilter, error)
+
+	// Delete an entities.ContentFilter
+	Delete(ctx context.Context, userID entities.UserID, filterID uuid.UUID) error
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// CountryRouteRepository loads and persists an entities.CountryRoute
+type CountryRouteRepository interface {
+	// Save Upsert an entities.CountryRoute
+	Save(ctx context.Context, route *entities.CountryRoute) error
+
+	// Load an entities.CountryRoute by the country of the recipients
+	Load(ctx context.Context, userID entities.UserID, country string) (*entities.CountryRoute, error)
+
+	// Index the entities.CountryRoute of a user ordered by country
+	Index(ctx context.Context, userID entities.UserID) ([]entities.CountryRoute, error)
+
+	// Delete an entities.CountryRoute by the country of the recipients
+	Delete(ctx context.Context, userID entities.UserID, country string) error
+}
@@ -0,0 +1,43 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// EventLogSearchParams are the parameters for searching entities.EventLog
+type EventLogSearchParams struct {
+	Types  []string
+	Owners []string
+	From   time.Time
+	To     time.Time
+	Skip   int
+	Limit  int
+
+	// ResourceType and ResourceID filter the events which changed a specific resource
+	ResourceType string
+	ResourceID   string
+}
+
+// EventLogRepository loads and persists an entities.EventLog
+type EventLogRepository interface {
+	// Store a new entities.EventLog
+	Store(ctx context.Context, log *entities.EventLog) error
+
+	// Search entities.EventLog of a user ordered by timestamp in ascending order
+	Search(ctx context.Context, userID entities.UserID, params EventLogSearchParams) ([]*entities.EventLog, error)
+
+	// IndexAfterSequence fetches the entities.EventLog of a user with a sequence greater than the sequence ordered by sequence in ascending order
+	IndexAfterSequence(ctx context.Context, userID entities.UserID, sequence int64, resourceTypes []string, limit int) ([]*entities.EventLog, error)
+
+	// LastSequence returns the sequence of the latest entities.EventLog of a user or 0 when the user has no events
+	LastSequence(ctx context.Context, userID entities.UserID) (int64, error)
+
+	// Count the entities.EventLog of a user which match the params, the Skip and Limit are ignored
+	Count(ctx context.Context, userID entities.UserID, params EventLogSearchParams) (int, error)
+
+	// RedactContent removes the content from the data of the entities.EventLog of a resource e.g. when the content of a message is archived
+	RedactContent(ctx context.Context, userID entities.UserID, resourceType string, resourceID string) error
+}
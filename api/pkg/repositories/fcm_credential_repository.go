@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// FcmCredentialRepository loads and persists an entities.FcmCredential
+type FcmCredentialRepository interface {
+	// Save Upsert an entities.FcmCredential
+	Save(ctx context.Context, credential *entities.FcmCredential) error
+
+	// Load the entities.FcmCredential of a user
+	Load(ctx context.Context, userID entities.UserID) (*entities.FcmCredential, error)
+
+	// Delete the entities.FcmCredential of a user
+	Delete(ctx context.Context, userID entities.UserID) error
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormBulkMessageJobRepository is responsible for persisting entities.BulkMessageJob
+type gormBulkMessageJobRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormBulkMessageJobRepository creates the GORM version of the BulkMessageJobRepository
+func NewGormBulkMessageJobRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) BulkMessageJobRepository {
+	return &gormBulkMessageJobRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormBulkMessageJobRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store creates an entities.BulkMessageJob and its items in a single transaction
+func (repository *gormBulkMessageJobRepository) Store(ctx context.Context, job *entities.BulkMessageJob, items []*entities.BulkMessageJobItem) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot create bulk message job with ID [%s]", job.ID))
+		}
+		if err := tx.CreateInBatches(items, 500).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%d] items for bulk message job with ID [%s]", len(items), job.ID))
+		}
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store bulk message job with ID [%s] for user [%s]", job.ID, job.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Update saves an entities.BulkMessageJob when its sequence in the database is still the given sequence
+func (repository *gormBulkMessageJobRepository) Update(ctx context.Context, job *entities.BulkMessageJob, sequence uint) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(job).
+		Where("sequence = ?", sequence).
+		Select("*").
+		Updates(job)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot update bulk message job with ID [%s] and sequence [%d]", job.ID, sequence)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// Load an entities.BulkMessageJob by ID
+func (repository *gormBulkMessageJobRepository) Load(ctx context.Context, userID entities.UserID, jobID uuid.UUID) (*entities.BulkMessageJob, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	job := new(entities.BulkMessageJob)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", jobID).First(job).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("bulk message job with ID [%s] for user [%s] does not exist", jobID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load bulk message job with ID [%s] for user [%s]", jobID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return job, nil
+}
+
+// CountActive counts the entities.BulkMessageJob of a user which are running or paused
+func (repository *gormBulkMessageJobRepository) CountActive(ctx context.Context, userID entities.UserID) (int, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var count int64
+	err := repository.db.WithContext(ctx).
+		Model(&entities.BulkMessageJob{}).
+		Where("user_id = ?", userID).
+		Where("status IN ?", []entities.BulkMessageJobStatus{entities.BulkMessageJobStatusRunning, entities.BulkMessageJobStatusPaused}).
+		Count(&count).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count the active bulk message jobs of user [%s]", userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return int(count), nil
+}
+
+// FetchItems fetches the items of an entities.BulkMessageJob starting at the position
+func (repository *gormBulkMessageJobRepository) FetchItems(ctx context.Context, jobID uuid.UUID, position uint, limit uint) ([]*entities.BulkMessageJobItem, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	items := make([]*entities.BulkMessageJobItem, 0, limit)
+	err := repository.db.WithContext(ctx).
+		Where("job_id = ?", jobID).
+		Where("position >= ?", position).
+		Order("position ASC").
+		Limit(int(limit)).
+		Find(&items).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch [%d] items at position [%d] of bulk message job with ID [%s]", limit, position, jobID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return items, nil
+}
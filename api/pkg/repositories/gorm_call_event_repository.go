@@ -0,0 +1,69 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormCallEventRepository is responsible for persisting entities.CallEvent
+type gormCallEventRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormCallEventRepository creates the GORM version of the CallEventRepository
+func NewGormCallEventRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) CallEventRepository {
+	return &gormCallEventRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormCallEventRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.CallEvent
+func (repository *gormCallEventRepository) Store(ctx context.Context, event *entities.CallEvent) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(event).Error; err != nil {
+		msg := fmt.Sprintf("cannot save call event with ID [%s]", event.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index entities.CallEvent of a phone
+func (repository *gormCallEventRepository) Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, params IndexParams) (*[]entities.CallEvent, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
+	defer cancel()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("phone_id = ?", phoneID)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where("contact ILIKE ?", queryPattern)
+	}
+
+	callEvents := new([]entities.CallEvent)
+	if err := query.Order("timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&callEvents).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch call events for phone [%s] and params [%+#v]", phoneID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return callEvents, nil
+}
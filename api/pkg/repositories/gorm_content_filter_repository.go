@@ -0,0 +1,111 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormContentFilterRepository is responsible for persisting entities.ContentFilter
+type gormContentFilterRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormContentFilterRepository creates the GORM version of the ContentFilterRepository
+func NewGormContentFilterRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ContentFilterRepository {
+	return &gormContentFilterRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormContentFilterRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormContentFilterRepository) Save(ctx context.Context, filter *entities.ContentFilter) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(filter).Error; err != nil {
+		msg := fmt.Sprintf("cannot save content filter with ID [%s]", filter.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormContentFilterRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.ContentFilter, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		query.Where("term ILIKE ?", "%"+params.Query+"%")
+	}
+
+	filters := make([]*entities.ContentFilter, 0)
+	if err := query.Order("created_at DESC").Limit(params.Limit).Offset(params.Skip).Find(&filters).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch content filters for user [%s] with skip [%d] and limit [%d]", userID, params.Skip, params.Limit)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return filters, nil
+}
+
+func (repository *gormContentFilterRepository) FetchAll(ctx context.Context, userID entities.UserID) ([]*entities.ContentFilter, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	filters := make([]*entities.ContentFilter, 0)
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Order("created_at ASC").Find(&filters).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch all content filters for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return filters, nil
+}
+
+func (repository *gormContentFilterRepository) Load(ctx context.Context, userID entities.UserID, filterID uuid.UUID) (*entities.ContentFilter, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	filter := new(entities.ContentFilter)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", filterID).First(filter).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("content filter with ID [%s] for user [%s] does not exist", filterID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load content filter with ID [%s] for user [%s]", filterID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return filter, nil
+}
+
+func (repository *gormContentFilterRepository) Delete(ctx context.Context, userID entities.UserID, filterID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", filterID).
+		Delete(&entities.ContentFilter{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete content filter with ID [%s] and userID [%s]", filterID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormCountryRouteRepository is responsible for persisting entities.CountryRoute
+type gormCountryRouteRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormCountryRouteRepository creates the GORM version of the CountryRouteRepository
+func NewGormCountryRouteRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) CountryRouteRepository {
+	return &gormCountryRouteRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormCountryRouteRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormCountryRouteRepository) Save(ctx context.Context, route *entities.CountryRoute) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(route).Error; err != nil {
+		msg := fmt.Sprintf("cannot save country route [%s] for user [%s]", route.Country, route.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormCountryRouteRepository) Load(ctx context.Context, userID entities.UserID, country string) (*entities.CountryRoute, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	route := new(entities.CountryRoute)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("country = ?", country).First(route).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("country route [%s] for user [%s] does not exist", country, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load country route [%s] for user [%s]", country, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return route, nil
+}
+
+func (repository *gormCountryRouteRepository) Index(ctx context.Context, userID entities.UserID) ([]entities.CountryRoute, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	routes := make([]entities.CountryRoute, 0)
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Order("country ASC").Find(&routes).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch the country routes of user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return routes, nil
+}
+
+func (repository *gormCountryRouteRepository) Delete(ctx context.Context, userID entities.UserID, country string) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("country = ?", country).Delete(&entities.CountryRoute{}).Error; err != nil {
+		msg := fmt.Sprintf("cannot delete country route [%s] for user [%s]", country, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -0,0 +1,164 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormEventLogRepository is responsible for persisting entities.EventLog
+type gormEventLogRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormEventLogRepository creates the GORM version of the EventLogRepository
+func NewGormEventLogRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) EventLogRepository {
+	return &gormEventLogRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormEventLogRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.EventLog
+func (repository *gormEventLogRepository) Store(ctx context.Context, log *entities.EventLog) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(log).Error; err != nil {
+		msg := fmt.Sprintf("cannot save event log with ID [%s] and type [%s]", log.ID, log.Type)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// RedactContent removes the content from the data of the entities.EventLog of a resource
+func (repository *gormEventLogRepository) RedactContent(ctx context.Context, userID entities.UserID, resourceType string, resourceID string) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.
+		WithContext(ctx).
+		Model(&entities.EventLog{}).
+		Where("user_id = ?", userID).
+		Where("resource_type = ?", resourceType).
+		Where("resource_id = ?", resourceID).
+		UpdateColumn("event", gorm.Expr("event #- '{data,content}'")).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot redact the content of the event logs of [%s] [%s] for user [%s]", resourceType, resourceID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Search entities.EventLog of a user ordered by timestamp in ascending order
+func (repository *gormEventLogRepository) Search(ctx context.Context, userID entities.UserID, params EventLogSearchParams) ([]*entities.EventLog, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	logs := make([]*entities.EventLog, 0, params.Limit)
+	err := repository.query(ctx, userID, params).
+		Order("timestamp ASC").
+		Order("id ASC").
+		Limit(params.Limit).
+		Offset(params.Skip).
+		Find(&logs).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot search event logs for user [%s] with params [%+#v]", userID, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return logs, nil
+}
+
+// IndexAfterSequence fetches the entities.EventLog of a user with a sequence greater than the sequence ordered by sequence in ascending order
+func (repository *gormEventLogRepository) IndexAfterSequence(ctx context.Context, userID entities.UserID, sequence int64, resourceTypes []string, limit int) ([]*entities.EventLog, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	logs := make([]*entities.EventLog, 0, limit)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("sequence > ?", sequence).
+		Where("resource_type IN ?", resourceTypes).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&logs).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch event logs for user [%s] after sequence [%d]", userID, sequence)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return logs, nil
+}
+
+// LastSequence returns the sequence of the latest entities.EventLog of a user or 0 when the user has no events
+func (repository *gormEventLogRepository) LastSequence(ctx context.Context, userID entities.UserID) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var sequence int64
+	err := repository.db.WithContext(ctx).
+		Model(&entities.EventLog{}).
+		Where("user_id = ?", userID).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&sequence).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the last event log sequence for user [%s]", userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return sequence, nil
+}
+
+// Count the entities.EventLog of a user which match the params, the Skip and Limit are ignored
+func (repository *gormEventLogRepository) Count(ctx context.Context, userID entities.UserID, params EventLogSearchParams) (int, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var count int64
+	if err := repository.query(ctx, userID, params).Count(&count).Error; err != nil {
+		msg := fmt.Sprintf("cannot count event logs for user [%s] with params [%+#v]", userID, params)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return int(count), nil
+}
+
+func (repository *gormEventLogRepository) query(ctx context.Context, userID entities.UserID, params EventLogSearchParams) *gorm.DB {
+	query := repository.db.WithContext(ctx).
+		Model(&entities.EventLog{}).
+		Where("user_id = ?", userID).
+		Where("timestamp >= ?", params.From).
+		Where("timestamp <= ?", params.To)
+
+	if len(params.Types) > 0 {
+		query = query.Where("type IN ?", params.Types)
+	}
+	if len(params.Owners) > 0 {
+		query = query.Where("owner IN ?", params.Owners)
+	}
+	if params.ResourceType != "" {
+		query = query.Where("resource_type = ?", params.ResourceType)
+	}
+	if params.ResourceID != "" {
+		query = query.Where("resource_id = ?", params.ResourceID)
+	}
+	return query
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormFcmCredentialRepository is responsible for persisting entities.FcmCredential
+type gormFcmCredentialRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormFcmCredentialRepository creates the GORM version of the FcmCredentialRepository
+func NewGormFcmCredentialRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) FcmCredentialRepository {
+	return &gormFcmCredentialRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormFcmCredentialRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save Upsert an entities.FcmCredential
+func (repository *gormFcmCredentialRepository) Save(ctx context.Context, credential *entities.FcmCredential) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(credential).Error; err != nil {
+		msg := fmt.Sprintf("cannot save FCM credential for user [%s]", credential.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the entities.FcmCredential of a user
+func (repository *gormFcmCredentialRepository) Load(ctx context.Context, userID entities.UserID) (*entities.FcmCredential, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	credential := new(entities.FcmCredential)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).First(credential).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("FCM credential for user [%s] does not exist", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load FCM credential for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return credential, nil
+}
+
+// Delete the entities.FcmCredential of a user
+func (repository *gormFcmCredentialRepository) Delete(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.FcmCredential{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete FCM credential for user [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
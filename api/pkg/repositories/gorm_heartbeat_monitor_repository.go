@@ -45,6 +45,27 @@ func (repository *gormHeartbeatMonitorRepository) UpdatePhoneOnline(ctx context.
 	return nil
 }
 
+// UpdateBatteryLow updates the battery low status of a phone
+func (repository *gormHeartbeatMonitorRepository) UpdateBatteryLow(ctx context.Context, userID entities.UserID, monitorID uuid.UUID, batteryLow bool) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
+	defer cancel()
+
+	err := repository.db.
+		WithContext(ctx).
+		Model(&entities.HeartbeatMonitor{}).
+		Where("id = ?", monitorID).
+		Where("user_id = ?", userID).
+		Update("battery_low", batteryLow).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update battery low status of heartbeat monitor ID [%s] for user [%s]", monitorID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
 // UpdateQueueID updates the queueID of a monitor
 func (repository *gormHeartbeatMonitorRepository) UpdateQueueID(ctx context.Context, monitorID uuid.UUID, queueID string) error {
 	ctx, span := repository.tracer.Start(ctx)
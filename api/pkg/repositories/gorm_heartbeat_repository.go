@@ -66,14 +66,8 @@ func (repository *gormHeartbeatRepository) Index(ctx context.Context, userID ent
 	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
 	defer cancel()
 
-	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("owner = ?", owner)
-	if len(params.Query) > 0 {
-		queryPattern := "%" + params.Query + "%"
-		query.Where("version LIKE ?", queryPattern)
-	}
-
 	heartbeats := new([]entities.Heartbeat)
-	if err := query.Order("timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&heartbeats).Error; err != nil {
+	if err := repository.indexQuery(ctx, userID, owner, params).Order("timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&heartbeats).Error; err != nil {
 		msg := fmt.Sprintf("cannot fetch heartbeats with owner [%s] and params [%+#v]", owner, params)
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -81,6 +75,38 @@ func (repository *gormHeartbeatRepository) Index(ctx context.Context, userID ent
 	return heartbeats, nil
 }
 
+// IndexVersion fetches the ListVersion of all the entities.Heartbeat which match the filter of Index ignoring the pagination, heartbeats are never updated so the timestamp is used
+func (repository *gormHeartbeatRepository) IndexVersion(ctx context.Context, userID entities.UserID, owner string, params IndexParams) (*ListVersion, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, dbOperationDuration)
+	defer cancel()
+
+	version := new(ListVersion)
+	err := repository.indexQuery(ctx, userID, owner, params).
+		Model(&entities.Heartbeat{}).
+		Select("COUNT(*) AS count, COALESCE(MAX(timestamp), 'epoch') AS latest_updated_at").
+		Scan(version).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the version of heartbeats with owner [%s] and params [%+#v]", owner, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return version, nil
+}
+
+// indexQuery filters the heartbeats which are fetched by Index
+func (repository *gormHeartbeatRepository) indexQuery(ctx context.Context, userID entities.UserID, owner string, params IndexParams) *gorm.DB {
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("owner = ?", owner)
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where("version LIKE ?", queryPattern)
+	}
+	return query
+}
+
 // Store a new entities.Message
 func (repository *gormHeartbeatRepository) Store(ctx context.Context, heartbeat *entities.Heartbeat) error {
 	ctx, span := repository.tracer.Start(ctx)
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormLabelRepository is responsible for persisting entities.Label
+type gormLabelRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormLabelRepository creates the GORM version of the LabelRepository
+func NewGormLabelRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) LabelRepository {
+	return &gormLabelRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormLabelRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormLabelRepository) Save(ctx context.Context, label *entities.Label) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(label).Error; err != nil {
+		msg := fmt.Sprintf("cannot save label with ID [%s]", label.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormLabelRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Label, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		query.Where("name ILIKE ?", "%"+params.Query+"%")
+	}
+
+	labels := make([]*entities.Label, 0)
+	if err := query.Order("name ASC").Limit(params.Limit).Offset(params.Skip).Find(&labels).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch labels for user [%s] with skip [%d] and limit [%d]", userID, params.Skip, params.Limit)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return labels, nil
+}
+
+func (repository *gormLabelRepository) Load(ctx context.Context, userID entities.UserID, labelID uuid.UUID) (*entities.Label, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	label := new(entities.Label)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", labelID).First(label).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("label with ID [%s] for user [%s] does not exist", labelID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load label with ID [%s] for user [%s]", labelID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return label, nil
+}
+
+func (repository *gormLabelRepository) Delete(ctx context.Context, userID entities.UserID, labelID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Where("label_id = ?", labelID).Delete(&entities.MessageLabel{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot detach label with ID [%s] from the messages", labelID))
+		}
+		return tx.Where("user_id = ?", userID).Where("id = ?", labelID).Delete(&entities.Label{}).Error
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete label with ID [%s] and userID [%s]", labelID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormLabelRepository) IndexByMessage(ctx context.Context, userID entities.UserID, messageID uuid.UUID) ([]*entities.Label, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	labels := make([]*entities.Label, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id IN (?)", repository.db.Model(&entities.MessageLabel{}).Select("label_id").Where("user_id = ?", userID).Where("message_id = ?", messageID)).
+		Order("name ASC").
+		Find(&labels).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the labels of message [%s] for user [%s]", messageID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return labels, nil
+}
+
+func (repository *gormLabelRepository) Attach(ctx context.Context, messageLabel *entities.MessageLabel) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(messageLabel).Error; err != nil {
+		msg := fmt.Sprintf("cannot attach label with ID [%s] to message [%s]", messageLabel.LabelID, messageLabel.MessageID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormLabelRepository) Detach(ctx context.Context, userID entities.UserID, messageID uuid.UUID, labelID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("message_id = ?", messageID).
+		Where("label_id = ?", labelID).
+		Delete(&entities.MessageLabel{})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot detach label with ID [%s] from message [%s]", labelID, messageID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	if result.RowsAffected == 0 {
+		msg := fmt.Sprintf("label with ID [%s] is not attached to message [%s] of user [%s]", labelID, messageID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return nil
+}
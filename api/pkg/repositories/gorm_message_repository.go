@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"gorm.io/gorm/clause"
 
@@ -36,22 +37,21 @@ func NewGormMessageRepository(
 }
 
 // DeleteByOwnerAndContact deletes all the messages between and owner and a contact
-func (repository *gormMessageRepository) DeleteByOwnerAndContact(ctx context.Context, userID entities.UserID, owner string, contact string) error {
+func (repository *gormMessageRepository) DeleteByOwnerAndContact(ctx context.Context, userID entities.UserID, owner string, contact string) (int64, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	err := repository.db.WithContext(ctx).
+	result := repository.db.WithContext(ctx).
 		Where("user_id = ?", userID).
 		Where("owner = ?", owner).
 		Where("contact = ?", contact).
-		Delete(&entities.Message{}).
-		Error
-	if err != nil {
+		Delete(&entities.Message{})
+	if result.Error != nil {
 		msg := fmt.Sprintf("cannot delete messages between owner [%s] and contact [%s] for user with ID [%s]", owner, contact, userID)
-		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
 	}
 
-	return nil
+	return result.RowsAffected, nil
 }
 
 // Delete a message by the ID
@@ -69,19 +69,11 @@ func (repository *gormMessageRepository) Delete(ctx context.Context, userID enti
 }
 
 // Index entities.Message between 2 parties
-func (repository *gormMessageRepository) Index(ctx context.Context, userID entities.UserID, owner string, contact string, params IndexParams) (*[]entities.Message, error) {
+func (repository *gormMessageRepository) Index(ctx context.Context, userID entities.UserID, owner string, contact string, contactMatch ContactMatch, metadata entities.MessageMetadata, category entities.MessageCategory, language string, starred bool, labelID *uuid.UUID, params IndexParams) (*[]entities.Message, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	query := repository.db.
-		WithContext(ctx).
-		Where("user_id = ?", userID).
-		Where("owner = ?", owner).
-		Where("contact =  ?", contact)
-	if len(params.Query) > 0 {
-		queryPattern := "%" + params.Query + "%"
-		query.Where("content ILIKE ?", queryPattern)
-	}
+	query := repository.indexQuery(ctx, userID, owner, contact, contactMatch, metadata, category, language, starred, labelID, params)
 
 	messages := new([]entities.Message)
 	if err := query.Order("order_timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&messages).Error; err != nil {
@@ -92,6 +84,82 @@ func (repository *gormMessageRepository) Index(ctx context.Context, userID entit
 	return messages, nil
 }
 
+// IndexVersion fetches the ListVersion of all the entities.Message which match the filter of Index ignoring the pagination
+func (repository *gormMessageRepository) IndexVersion(ctx context.Context, userID entities.UserID, owner string, contact string, contactMatch ContactMatch, metadata entities.MessageMetadata, category entities.MessageCategory, language string, starred bool, labelID *uuid.UUID, params IndexParams) (*ListVersion, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	version := new(ListVersion)
+	err := repository.indexQuery(ctx, userID, owner, contact, contactMatch, metadata, category, language, starred, labelID, params).
+		Model(&entities.Message{}).
+		Select("COUNT(*) AS count, COALESCE(MAX(updated_at), 'epoch') AS latest_updated_at").
+		Scan(version).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the version of messges with owner [%s] and contact [%s] and params [%+#v]", owner, contact, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return version, nil
+}
+
+// indexQuery filters the messages which are fetched by Index
+func (repository *gormMessageRepository) indexQuery(ctx context.Context, userID entities.UserID, owner string, contact string, contactMatch ContactMatch, metadata entities.MessageMetadata, category entities.MessageCategory, language string, starred bool, labelID *uuid.UUID, params IndexParams) *gorm.DB {
+	query := repository.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID)
+
+	conversation := len(metadata) == 0 && !starred && labelID == nil
+	if conversation || owner != "" {
+		query.Where("owner = ?", owner)
+	}
+
+	if conversation || contact != "" {
+		repository.whereContact(query, contact, contactMatch)
+	}
+
+	if starred {
+		query.Where("starred = ?", true)
+	}
+
+	if labelID != nil {
+		query.Where("id IN (?)", repository.db.Model(&entities.MessageLabel{}).Select("message_id").Where("user_id = ?", userID).Where("label_id = ?", *labelID))
+	}
+
+	if len(metadata) > 0 {
+		// the containment operator uses the GIN index on the metadata column
+		query.Where("metadata @> ?::jsonb", metadata)
+	}
+
+	if category != "" {
+		query.Where("category = ?", category)
+	}
+
+	if language != "" {
+		query.Where("language = ?", language)
+	}
+
+	if len(params.Query) > 0 {
+		queryPattern := "%" + params.Query + "%"
+		query.Where("content ILIKE ?", queryPattern)
+	}
+
+	return query
+}
+
+// whereContact filters the messages by the contact, the digits of the contact are compared by the partial matches
+// so that legacy contacts which were stored with different country codes or formatting characters are matched.
+func (repository *gormMessageRepository) whereContact(query *gorm.DB, contact string, contactMatch ContactMatch) {
+	switch contactMatch {
+	case ContactMatchEndsWith:
+		query.Where("regexp_replace(contact, '[^0-9]', '', 'g') LIKE ?", "%"+contact)
+	case ContactMatchContains:
+		query.Where("regexp_replace(contact, '[^0-9]', '', 'g') LIKE ?", "%"+contact+"%")
+	default:
+		query.Where("contact =  ?", contact)
+	}
+}
+
 func (repository *gormMessageRepository) LastMessage(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.Message, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
@@ -118,6 +186,30 @@ func (repository *gormMessageRepository) LastMessage(ctx context.Context, userID
 	return message, nil
 }
 
+// IndexUpdatedSince fetches the entities.Message of a user which have been updated after the timestamp and the ID
+func (repository *gormMessageRepository) IndexUpdatedSince(ctx context.Context, userID entities.UserID, since time.Time, sinceID uuid.UUID, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// the ID breaks the ties between the messages which are updated at the same time so a poll never skips or repeats a message
+	messages := make([]*entities.Message, 0)
+	err := repository.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("(updated_at > ? OR (updated_at = ? AND id > ?))", since, since, sinceID).
+		Order("updated_at ASC").
+		Order("id ASC").
+		Limit(limit).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch messages for user [%s] updated after [%s]", userID, since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
 func (repository *gormMessageRepository) Search(ctx context.Context, userID entities.UserID, owners []string, types []entities.MessageType, statuses []entities.MessageStatus, params IndexParams) ([]*entities.Message, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
@@ -164,12 +256,167 @@ func (repository *gormMessageRepository) Search(ctx context.Context, userID enti
 	return messages, nil
 }
 
+// IndexFailedByReason fetches the failed mobile terminated entities.Message of an owner with a failure reason
+func (repository *gormMessageRepository) IndexFailedByReason(ctx context.Context, userID entities.UserID, owner string, reason string, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := make([]*entities.Message, 0, limit)
+	err := repository.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("type = ?", entities.MessageTypeMobileTerminated).
+		Where("status = ?", entities.MessageStatusFailed).
+		Where("failure_reason = ?", reason).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch failed messages of owner [%s] with reason [%s] for user [%s]", owner, reason, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// IndexArchived fetches the entities.Message between an owner and a contact whose content has been moved to cold storage
+func (repository *gormMessageRepository) IndexArchived(ctx context.Context, userID entities.UserID, owner string, contact string) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := make([]*entities.Message, 0)
+	err := repository.db.
+		WithContext(ctx).
+		Select("id", "user_id", "archived_at").
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("archived_at IS NOT NULL").
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the archived messages between owner [%s] and contact [%s] for user with ID [%s]", owner, contact, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// IndexArchivable fetches the entities.Message which were created before the timestamp, have a final status and are not yet archived
+func (repository *gormMessageRepository) IndexArchivable(ctx context.Context, before time.Time, limit int) ([]*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	messages := make([]*entities.Message, 0, limit)
+	err := repository.db.
+		WithContext(ctx).
+		Where("archived_at IS NULL").
+		Where("created_at < ?", before).
+		Where("status NOT IN ?", []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled, entities.MessageStatusSending}).
+		Order("created_at ASC").
+		Limit(limit).
+		Find(&messages).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch [%d] archivable messages created before [%s]", limit, before)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return messages, nil
+}
+
+// Archive removes the content of an entities.Message which has been moved to cold storage, ErrCodeNotFound is returned when the message does not exist or is already archived
+func (repository *gormMessageRepository) Archive(ctx context.Context, message *entities.Message, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// the columns are updated without changing updated_at so that clients which sync updated messages keep the content they already have
+	result := repository.db.
+		WithContext(ctx).
+		Model(&entities.Message{}).
+		Where("user_id = ?", message.UserID).
+		Where("id = ?", message.ID).
+		Where("archived_at IS NULL").
+		UpdateColumns(map[string]any{"content": "", "archived_at": timestamp})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot archive message with ID [%s] for user [%s]", message.ID, message.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	if result.RowsAffected == 0 {
+		msg := fmt.Sprintf("message with ID [%s] and userID [%s] does not exist or it is already archived", message.ID, message.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return nil
+}
+
+// LoadReceived fetches the mobile originated entities.Message which was received by the owner from the contact at the timestamp, ErrCodeNotFound is returned when there is no such message
+func (repository *gormMessageRepository) LoadReceived(ctx context.Context, userID entities.UserID, owner string, contact string, content string, timestamp time.Time) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	message := new(entities.Message)
+	err := repository.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("owner = ?", owner).
+		Where("contact = ?", contact).
+		Where("type = ?", entities.MessageTypeMobileOriginated).
+		Where("received_at = ?", timestamp).
+		Where("content = ?", content).
+		First(message).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("received message from [%s] to [%s] at [%s] does not exist for user [%s]", contact, owner, timestamp, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load received message from [%s] to [%s] at [%s] for user [%s]", contact, owner, timestamp, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return message, nil
+}
+
+// CountPendingRequests counts the distinct request IDs with the prefix which have messages that are not yet sent
+func (repository *gormMessageRepository) CountPendingRequests(ctx context.Context, userID entities.UserID, requestIDPrefix string) (int, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var count int64
+	err := repository.db.
+		WithContext(ctx).
+		Model(&entities.Message{}).
+		Distinct("request_id").
+		Where("user_id = ?", userID).
+		Where("request_id LIKE ?", requestIDPrefix+"%").
+		Where("status IN ?", []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled, entities.MessageStatusSending}).
+		Count(&count).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot count the pending requests with prefix [%s] for user [%s]", requestIDPrefix, userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return int(count), nil
+}
+
 // Store a new entities.Message
 func (repository *gormMessageRepository) Store(ctx context.Context, message *entities.Message) error {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	if err := repository.db.WithContext(ctx).Create(message).Error; err != nil {
+	err := repository.db.WithContext(ctx).Create(message).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		msg := fmt.Sprintf("a message with ID [%s] already exists", message.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeConflict, msg))
+	}
+
+	if err != nil {
 		msg := fmt.Sprintf("cannot save message with ID [%s]", message.ID)
 		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -202,7 +449,13 @@ func (repository *gormMessageRepository) Update(ctx context.Context, message *en
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	if err := repository.db.WithContext(ctx).Save(message).Error; err != nil {
+	query := repository.db.WithContext(ctx)
+	if message.IsArchived() {
+		// the content of an archived message which was fetched from cold storage is not written back to the database
+		query = query.Omit("content")
+	}
+
+	if err := query.Save(message).Error; err != nil {
 		msg := fmt.Sprintf("cannot update message with ID [%s]", message.ID)
 		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
@@ -210,6 +463,86 @@ func (repository *gormMessageRepository) Update(ctx context.Context, message *en
 	return nil
 }
 
+// Cancel an entities.Message which is pending or scheduled, ErrCodeNotFound is returned when there is no such message
+func (repository *gormMessageRepository) Cancel(ctx context.Context, userID entities.UserID, messageID uuid.UUID, timestamp time.Time) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// the status is checked in the same statement so that a phone cannot pick up the message while it is being cancelled
+	message := new(entities.Message)
+	err := repository.db.WithContext(ctx).Model(message).
+		Clauses(clause.Returning{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Where("status IN ?", []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled}).
+		Updates(map[string]any{
+			"status":          entities.MessageStatusCancelled,
+			"cancelled_at":    timestamp,
+			"order_timestamp": gorm.Expr("GREATEST(order_timestamp, ?)", timestamp),
+			"updated_at":      time.Now().UTC(),
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot cancel message with userID [%s] and messageID [%s]", userID, messageID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.ID == uuid.Nil {
+		msg := fmt.Sprintf("pending or scheduled message with ID [%s] and userID [%s] does not exist", messageID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return message, nil
+}
+
+// ToggleStarred stars an entities.Message which is not starred and unstars a starred one, ErrCodeNotFound is returned when there is no such message
+func (repository *gormMessageRepository) ToggleStarred(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// the flag is toggled in the same statement so that concurrent requests do not overwrite each other
+	message := new(entities.Message)
+	err := repository.db.WithContext(ctx).Model(message).
+		Clauses(clause.Returning{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", messageID).
+		Updates(map[string]any{
+			"starred":    gorm.Expr("NOT starred"),
+			"updated_at": time.Now().UTC(),
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot toggle the starred flag of message with userID [%s] and messageID [%s]", userID, messageID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.ID == uuid.Nil {
+		msg := fmt.Sprintf("message with ID [%s] and userID [%s] does not exist", messageID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return message, nil
+}
+
+// MarkRead sets the read time of the unread messages of the user with the given IDs in one query, IDs of messages of other users are ignored
+func (repository *gormMessageRepository) MarkRead(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID, timestamp time.Time) (int64, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).Model(&entities.Message{}).
+		Where("user_id = ?", userID).
+		Where("id IN ?", messageIDs).
+		Where("read_at IS NULL").
+		Updates(map[string]any{
+			"read_at":    timestamp,
+			"updated_at": time.Now().UTC(),
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot mark [%d] messages as read for user with ID [%s]", len(messageIDs), userID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected, nil
+}
+
 // GetOutstanding fetches messages that still to be sent to the phone
 func (repository *gormMessageRepository) GetOutstanding(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
 	ctx, span := repository.tracer.Start(ctx)
@@ -0,0 +1,116 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormMessageRuleRepository is responsible for persisting entities.MessageRule
+type gormMessageRuleRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormMessageRuleRepository creates the GORM version of the MessageRuleRepository
+func NewGormMessageRuleRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) MessageRuleRepository {
+	return &gormMessageRuleRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormMessageRuleRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormMessageRuleRepository) Save(ctx context.Context, rule *entities.MessageRule) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(rule).Error; err != nil {
+		msg := fmt.Sprintf("cannot save message rule with ID [%s]", rule.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormMessageRuleRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.MessageRule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := repository.db.WithContext(ctx).Where("user_id = ?", userID)
+	if len(params.Query) > 0 {
+		query.Where("name ILIKE ?", "%"+params.Query+"%")
+	}
+
+	rules := make([]*entities.MessageRule, 0)
+	if err := query.Order("position ASC").Order("created_at ASC").Limit(params.Limit).Offset(params.Skip).Find(&rules).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch message rules for user [%s] with skip [%d] and limit [%d]", userID, params.Skip, params.Limit)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rules, nil
+}
+
+func (repository *gormMessageRuleRepository) FetchEnabled(ctx context.Context, userID entities.UserID) ([]*entities.MessageRule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	rules := make([]*entities.MessageRule, 0)
+	if err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("enabled = ?", true).
+		Order("position ASC").
+		Order("created_at ASC").
+		Find(&rules).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch enabled message rules for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rules, nil
+}
+
+func (repository *gormMessageRuleRepository) Load(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) (*entities.MessageRule, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	rule := new(entities.MessageRule)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", ruleID).First(rule).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("message rule with ID [%s] for user [%s] does not exist", ruleID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message rule with ID [%s] for user [%s]", ruleID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return rule, nil
+}
+
+func (repository *gormMessageRuleRepository) Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("id = ?", ruleID).
+		Delete(&entities.MessageRule{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete message rule with ID [%s] and userID [%s]", ruleID, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
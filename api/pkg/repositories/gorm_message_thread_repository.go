@@ -154,6 +154,38 @@ func (repository *gormMessageThreadRepository) Index(ctx context.Context, userID
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
+	query := repository.indexQuery(ctx, userID, owner, isArchived, params)
+
+	threads := new([]entities.MessageThread)
+	if err := query.Order("order_timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&threads).Error; err != nil {
+		msg := fmt.Sprintf("cannot fetch message threads with owner [%s] and params [%+#v]", owner, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return threads, nil
+}
+
+// IndexVersion fetches the ListVersion of all the message threads which match the filter of Index ignoring the pagination
+func (repository *gormMessageThreadRepository) IndexVersion(ctx context.Context, userID entities.UserID, owner string, isArchived bool, params IndexParams) (*ListVersion, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	version := new(ListVersion)
+	err := repository.indexQuery(ctx, userID, owner, isArchived, params).
+		Model(&entities.MessageThread{}).
+		Select("COUNT(*) AS count, COALESCE(MAX(updated_at), 'epoch') AS latest_updated_at").
+		Scan(version).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the version of message threads with owner [%s] and params [%+#v]", owner, params)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return version, nil
+}
+
+// indexQuery filters the message threads which are fetched by Index
+func (repository *gormMessageThreadRepository) indexQuery(ctx context.Context, userID entities.UserID, owner string, isArchived bool, params IndexParams) *gorm.DB {
 	query := repository.db.
 		WithContext(ctx).
 		Where("user_id = ?", userID).
@@ -174,11 +206,5 @@ func (repository *gormMessageThreadRepository) Index(ctx context.Context, userID
 		)
 	}
 
-	threads := new([]entities.MessageThread)
-	if err := query.Order("order_timestamp DESC").Limit(params.Limit).Offset(params.Skip).Find(&threads).Error; err != nil {
-		msg := fmt.Sprintf("cannot fetch message threads with owner [%s] and params [%+#v]", owner, params)
-		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-	}
-
-	return threads, nil
+	return query
 }
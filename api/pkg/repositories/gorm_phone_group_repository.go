@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormPhoneGroupRepository is responsible for persisting entities.PhoneGroup
+type gormPhoneGroupRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPhoneGroupRepository creates the GORM version of the PhoneGroupRepository
+func NewGormPhoneGroupRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PhoneGroupRepository {
+	return &gormPhoneGroupRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPhoneGroupRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+func (repository *gormPhoneGroupRepository) Save(ctx context.Context, group *entities.PhoneGroup) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(group).Error; err != nil {
+		msg := fmt.Sprintf("cannot save phone group [%s] for user [%s]", group.Name, group.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormPhoneGroupRepository) Load(ctx context.Context, userID entities.UserID, name string) (*entities.PhoneGroup, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	group := new(entities.PhoneGroup)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("name = ?", name).First(group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("phone group [%s] for user [%s] does not exist", name, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone group [%s] for user [%s]", name, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return group, nil
+}
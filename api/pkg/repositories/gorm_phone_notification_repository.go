@@ -53,6 +53,76 @@ func (repository *gormPhoneNotificationRepository) UpdateStatus(ctx context.Cont
 	return nil
 }
 
+// UpdateStatusFrom changes the status of a notification only when it has the from status
+func (repository *gormPhoneNotificationRepository) UpdateStatusFrom(ctx context.Context, notificationID uuid.UUID, from entities.PhoneNotificationStatus, to entities.PhoneNotificationStatus) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.
+		WithContext(ctx).
+		Model(&entities.PhoneNotification{ID: notificationID}).
+		Where("status = ?", from).
+		Update("status", to)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot update notification [%s] from status [%s] to [%s]", notificationID, from, to)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	if result.RowsAffected == 0 {
+		msg := fmt.Sprintf("notification [%s] does not exist or it does not have the status [%s]", notificationID, from)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return nil
+}
+
+// IndexPaused loads the notifications of a phone which were not sent because the phone was paused, the oldest notification is first
+func (repository *gormPhoneNotificationRepository) IndexPaused(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) ([]*entities.PhoneNotification, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	notifications := make([]*entities.PhoneNotification, 0)
+	err := repository.db.
+		WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("phone_id = ?", phoneID).
+		Where("status = ?", entities.PhoneNotificationStatusPaused).
+		Order("scheduled_at ASC").
+		Find(&notifications).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the paused notifications of phone [%s] for user [%s]", phoneID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return notifications, nil
+}
+
+// QueuePositions loads the positions of the pending notifications of the messages, the position is 1 more than the number of pending notifications of the phone which are scheduled before it
+func (repository *gormPhoneNotificationRepository) QueuePositions(ctx context.Context, messageIDs []uuid.UUID) ([]*entities.PhoneNotificationQueuePosition, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	query := `SELECT DISTINCT ON (n.message_id) n.message_id, n.scheduled_at, (
+			SELECT COUNT(*) FROM phone_notifications p WHERE p.phone_id = n.phone_id AND p.status = ? AND p.scheduled_at < n.scheduled_at
+		) + 1 AS position
+		FROM phone_notifications n WHERE n.message_id IN ? AND n.status = ?
+		ORDER BY n.message_id, n.scheduled_at DESC`
+
+	positions := make([]*entities.PhoneNotificationQueuePosition, 0, len(messageIDs))
+	err := repository.db.
+		WithContext(ctx).
+		Raw(query, entities.PhoneNotificationStatusPending, messageIDs, entities.PhoneNotificationStatusPending).
+		Scan(&positions).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the queue positions of [%d] messages", len(messageIDs))
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return positions, nil
+}
+
 // Schedule a notification to be sent in the future
 func (repository *gormPhoneNotificationRepository) Schedule(ctx context.Context, messagesPerMinute uint, notification *entities.PhoneNotification) error {
 	ctx, span := repository.tracer.Start(ctx)
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormPhoneProvisioningCodeRepository is responsible for persisting entities.PhoneProvisioningCode
+type gormPhoneProvisioningCodeRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormPhoneProvisioningCodeRepository creates the GORM version of the PhoneProvisioningCodeRepository
+func NewGormPhoneProvisioningCodeRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) PhoneProvisioningCodeRepository {
+	return &gormPhoneProvisioningCodeRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormPhoneProvisioningCodeRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Store a new entities.PhoneProvisioningCode
+func (repository *gormPhoneProvisioningCodeRepository) Store(ctx context.Context, code *entities.PhoneProvisioningCode) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Create(code).Error; err != nil {
+		msg := fmt.Sprintf("cannot store provisioning code with ID [%s] for user [%s]", code.ID, code.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Consume marks the unused entities.PhoneProvisioningCode with the hash as used, ErrCodeNotFound is returned when the code does not exist, is used or has expired
+func (repository *gormPhoneProvisioningCodeRepository) Consume(ctx context.Context, codeHash string, timestamp time.Time) (*entities.PhoneProvisioningCode, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// the code is marked as used in a single statement so that it cannot be exchanged twice by concurrent requests
+	code := new(entities.PhoneProvisioningCode)
+	result := repository.db.
+		WithContext(ctx).
+		Model(code).
+		Clauses(clause.Returning{}).
+		Where("code_hash = ?", codeHash).
+		Where("used_at IS NULL").
+		Where("expires_at > ?", timestamp).
+		Update("used_at", timestamp)
+	if result.Error != nil {
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, "cannot consume provisioning code"))
+	}
+
+	if result.RowsAffected == 0 {
+		msg := "provisioning code does not exist, it has expired or it is already used"
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return code, nil
+}
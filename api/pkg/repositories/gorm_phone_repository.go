@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // gormPhoneRepository is responsible for persisting entities.Phone
@@ -60,10 +62,12 @@ func (repository *gormPhoneRepository) Delete(ctx context.Context, userID entiti
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
-	err := repository.db.WithContext(ctx).
-		Where("user_id = ?", userID).
-		Where("id = ?", phoneID).
-		Delete(&entities.Phone{}).Error
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("user_id = ?", userID).Where("phone_id = ?", phoneID).Delete(&entities.PhoneDailyUsage{}).Error; err != nil {
+			return stacktrace.Propagate(err, fmt.Sprintf("cannot delete the daily usage of phone with ID [%s]", phoneID))
+		}
+		return tx.Where("user_id = ?", userID).Where("id = ?", phoneID).Delete(&entities.Phone{}).Error
+	})
 	if err != nil {
 		msg := fmt.Sprintf("cannot delete phone with ID [%s] and userID [%s]", phoneID, userID)
 		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -97,6 +101,27 @@ func (repository *gormPhoneRepository) Save(ctx context.Context, phone *entities
 	return nil
 }
 
+// StoreMany creates multiple entities.Phone in a single transaction
+func (repository *gormPhoneRepository) StoreMany(ctx context.Context, phones []*entities.Phone) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, phone := range phones {
+			if err := tx.Create(phone).Error; err != nil {
+				return stacktrace.Propagate(err, fmt.Sprintf("cannot create phone with user [%s] and number [%s]", phone.UserID, phone.PhoneNumber))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot store [%d] phones", len(phones))
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // Load a phone based on entities.UserID and phoneNumber
 func (repository *gormPhoneRepository) Load(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Phone, error) {
 	ctx, span := repository.tracer.Start(ctx)
@@ -117,6 +142,46 @@ func (repository *gormPhoneRepository) Load(ctx context.Context, userID entities
 	return phone, nil
 }
 
+// LoadByAlphanumericSenderID a phone based on entities.UserID and the alphanumeric sender ID of the SIM
+func (repository *gormPhoneRepository) LoadByAlphanumericSenderID(ctx context.Context, userID entities.UserID, senderID string) (*entities.Phone, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	phone := new(entities.Phone)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("alphanumeric_sender_id = ?", senderID).First(phone).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("phone with userID [%s] and alphanumeric sender ID [%s] does not exist", userID, senderID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and alphanumeric sender ID [%s]", userID, senderID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return phone, nil
+}
+
+// LoadByExternalID a phone based on entities.UserID and the ID of the phone in an external system
+func (repository *gormPhoneRepository) LoadByExternalID(ctx context.Context, userID entities.UserID, externalID string) (*entities.Phone, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	phone := new(entities.Phone)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("external_id = ?", externalID).First(phone).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("phone with userID [%s] and external ID [%s] does not exist", userID, externalID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and external ID [%s]", userID, externalID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return phone, nil
+}
+
 func (repository *gormPhoneRepository) Index(ctx context.Context, userID entities.UserID, params IndexParams) (*[]entities.Phone, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
@@ -135,3 +200,214 @@ func (repository *gormPhoneRepository) Index(ctx context.Context, userID entitie
 
 	return phones, nil
 }
+
+// IndexByGroup fetches the entities.Phone of a user in a group
+func (repository *gormPhoneRepository) IndexByGroup(ctx context.Context, userID entities.UserID, group string) ([]*entities.Phone, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	phones := make([]*entities.Phone, 0)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where(`"group" = ?`, group).
+		Order("created_at ASC").
+		Find(&phones).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch phones with userID [%s] and group [%s]", userID, group)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return phones, nil
+}
+
+// IncrementDailySent increments the messages sent by an entities.Phone on a date, it returns false when the daily quota is exhausted
+func (repository *gormPhoneRepository) IncrementDailySent(ctx context.Context, phone *entities.Phone, date string) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	usage := &entities.PhoneDailyUsage{
+		PhoneID:   phone.ID,
+		Date:      date,
+		UserID:    phone.UserID,
+		Sent:      1,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	// the quota is checked in the same statement as the increment so concurrent requests cannot exceed it
+	result := repository.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "phone_id"}, {Name: "date"}},
+			DoUpdates: clause.Assignments(map[string]any{
+				"sent":       gorm.Expr("phone_daily_usages.sent + 1"),
+				"updated_at": usage.UpdatedAt,
+			}),
+			Where: clause.Where{Exprs: []clause.Expression{gorm.Expr("? = 0 OR phone_daily_usages.sent < ?", phone.DailyQuota, phone.DailyQuota)}},
+		}).
+		Create(usage)
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot increment daily sent messages for phone with ID [%s] on [%s]", phone.ID, date)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// DecrementDailySent gives back a message which was counted by IncrementDailySent but was not sent
+func (repository *gormPhoneRepository) DecrementDailySent(ctx context.Context, phoneID uuid.UUID, date string) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(&entities.PhoneDailyUsage{}).
+		Where("phone_id = ?", phoneID).
+		Where("date = ?", date).
+		Where("sent > 0").
+		UpdateColumns(map[string]any{
+			"sent":       gorm.Expr("sent - 1"),
+			"updated_at": time.Now().UTC(),
+		}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot decrement daily sent messages for phone with ID [%s] on [%s]", phoneID, date)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// LoadDailySent returns the number of messages sent by an entities.Phone on a date
+func (repository *gormPhoneRepository) LoadDailySent(ctx context.Context, phoneID uuid.UUID, date string) (uint, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	usage := new(entities.PhoneDailyUsage)
+	err := repository.db.WithContext(ctx).
+		Where("phone_id = ?", phoneID).
+		Where("date = ?", date).
+		Limit(1).
+		Find(usage).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot load daily sent messages for phone with ID [%s] on [%s]", phoneID, date)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return usage.Sent, nil
+}
+
+// UpdateFcmToken sets a new FCM token on an entities.Phone and clears the time when the previous token was rejected
+func (repository *gormPhoneRepository) UpdateFcmToken(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, fcmToken string, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(&entities.Phone{}).
+		Where("user_id = ?", userID).
+		Where("id = ?", phoneID).
+		UpdateColumns(map[string]any{
+			"fcm_token":              fcmToken,
+			"fcm_token_refreshed_at": timestamp,
+			"fcm_token_invalid_at":   nil,
+			"updated_at":             timestamp,
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot update FCM token of phone with ID [%s]", phoneID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	if result.RowsAffected == 0 {
+		msg := fmt.Sprintf("phone with ID [%s] does not exist", phoneID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeNotFound, msg))
+	}
+
+	return nil
+}
+
+// InvalidateFcmToken marks the FCM token of an entities.Phone as rejected, it returns false when the phone already has a different token
+func (repository *gormPhoneRepository) InvalidateFcmToken(ctx context.Context, phoneID uuid.UUID, fcmToken string, timestamp time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// the token is compared in the same statement so a stale FCM error cannot invalidate a token which was refreshed concurrently
+	result := repository.db.WithContext(ctx).
+		Model(&entities.Phone{}).
+		Where("id = ?", phoneID).
+		Where("fcm_token = ?", fcmToken).
+		Where("fcm_token_invalid_at IS NULL").
+		UpdateColumns(map[string]any{
+			"fcm_token_invalid_at": timestamp,
+			"updated_at":           timestamp,
+		})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot invalidate FCM token of phone with ID [%s]", phoneID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// UpdateLastSentAt sets the time of the latest message sent by an entities.Phone, an older timestamp does not replace a newer one
+func (repository *gormPhoneRepository) UpdateLastSentAt(ctx context.Context, userID entities.UserID, phoneNumber string, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.updateLastMessageAt(ctx, "last_sent_at", userID, phoneNumber, timestamp); err != nil {
+		msg := fmt.Sprintf("cannot update the last sent time of phone [%s] for user [%s]", phoneNumber, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// UpdateLastReceivedAt sets the time of the latest message received by an entities.Phone, an older timestamp does not replace a newer one
+func (repository *gormPhoneRepository) UpdateLastReceivedAt(ctx context.Context, userID entities.UserID, phoneNumber string, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.updateLastMessageAt(ctx, "last_received_at", userID, phoneNumber, timestamp); err != nil {
+		msg := fmt.Sprintf("cannot update the last received time of phone [%s] for user [%s]", phoneNumber, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// updateLastMessageAt uses raw SQL because the columns are read-only for GORM so that Save does not overwrite them with a stale value
+func (repository *gormPhoneRepository) updateLastMessageAt(ctx context.Context, column string, userID entities.UserID, phoneNumber string, timestamp time.Time) error {
+	query := fmt.Sprintf("UPDATE phones SET %[1]s = ? WHERE user_id = ? AND phone_number = ? AND (%[1]s IS NULL OR %[1]s < ?)", column)
+	return repository.db.WithContext(ctx).Exec(query, timestamp, userID, phoneNumber, timestamp).Error
+}
+
+// UpdateLastError sets the latest error reported by an entities.Phone, the error is cleared when lastError is nil and an error older than the timestamp does not replace a newer one
+func (repository *gormPhoneRepository) UpdateLastError(ctx context.Context, userID entities.UserID, phoneNumber string, lastError *string, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// raw SQL is used because the columns are read-only for GORM so that Save does not overwrite them with a stale value
+	query := "UPDATE phones SET last_error = ?, last_error_at = ? WHERE user_id = ? AND phone_number = ? AND (last_error_at IS NULL OR last_error_at < ?) AND (last_sent_at IS NULL OR last_sent_at < ?)"
+	args := []any{lastError, timestamp, userID, phoneNumber, timestamp, timestamp}
+	if lastError == nil {
+		query = "UPDATE phones SET last_error = NULL, last_error_at = NULL WHERE user_id = ? AND phone_number = ? AND last_error_at < ?"
+		args = []any{userID, phoneNumber, timestamp}
+	}
+
+	if err := repository.db.WithContext(ctx).Exec(query, args...).Error; err != nil {
+		msg := fmt.Sprintf("cannot update the last error of phone [%s] for user [%s]", phoneNumber, userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// UpdateLastControl records the latest control notification sent to an entities.Phone
+func (repository *gormPhoneRepository) UpdateLastControl(ctx context.Context, phoneID uuid.UUID, controlType entities.PhoneControlType, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	// raw SQL is used because the columns are read-only for GORM so that Save does not overwrite them with a stale value
+	err := repository.db.WithContext(ctx).
+		Exec("UPDATE phones SET last_control_type = ?, last_control_sent_at = ? WHERE id = ?", controlType, timestamp, phoneID).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update the last control notification of phone with ID [%s] to [%s]", phoneID, controlType)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormProviderFallbackRepository is responsible for persisting entities.ProviderFallback
+type gormProviderFallbackRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormProviderFallbackRepository creates the GORM version of the ProviderFallbackRepository
+func NewGormProviderFallbackRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) ProviderFallbackRepository {
+	return &gormProviderFallbackRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormProviderFallbackRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save Upsert an entities.ProviderFallback
+func (repository *gormProviderFallbackRepository) Save(ctx context.Context, fallback *entities.ProviderFallback) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(fallback).Error; err != nil {
+		msg := fmt.Sprintf("cannot save provider fallback for user [%s]", fallback.UserID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load the entities.ProviderFallback of a user
+func (repository *gormProviderFallbackRepository) Load(ctx context.Context, userID entities.UserID) (*entities.ProviderFallback, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	fallback := new(entities.ProviderFallback)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).First(fallback).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("provider fallback for user [%s] does not exist", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load provider fallback for user [%s]", userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return fallback, nil
+}
+
+// Delete the entities.ProviderFallback of a user
+func (repository *gormProviderFallbackRepository) Delete(ctx context.Context, userID entities.UserID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Delete(&entities.ProviderFallback{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete provider fallback for user [%s]", userID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/cockroachdb/cockroach-go/v2/crdb/crdbgorm"
 	"github.com/dgraph-io/ristretto"
+	"github.com/google/uuid"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -52,12 +53,19 @@ func (repository *gormUserRepository) RotateAPIKey(ctx context.Context, userID e
 	}
 
 	user := new(entities.User)
+	var previousAPIKey string
 	err = crdbgorm.ExecuteTx(ctx, repository.db, nil,
 		func(tx *gorm.DB) error {
+			if err = tx.WithContext(ctx).First(user, userID).Error; err != nil {
+				return err
+			}
+			previousAPIKey = user.APIKey
+
 			return tx.WithContext(ctx).Model(user).
 				Clauses(clause.Returning{}).
 				Where("id = ?", userID).
-				Update("api_key", apiKey).Error
+				// sending messages with GET requests is enabled for an API key so it must be enabled again for the new key
+				Updates(map[string]any{"api_key": apiKey, "get_send_enabled": false}).Error
 		},
 	)
 	if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -65,6 +73,8 @@ func (repository *gormUserRepository) RotateAPIKey(ctx context.Context, userID e
 		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
 	}
 
+	// the previous API key must not authenticate the user from the cache after it is rotated
+	repository.cache.Del(previousAPIKey)
 	return user, nil
 }
 
@@ -111,6 +121,8 @@ func (repository *gormUserRepository) Update(ctx context.Context, user *entities
 		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	// the cached entities.AuthUser contains the locale and the timezone of the user
+	repository.cache.Del(user.APIKey)
 	return nil
 }
 
@@ -136,8 +148,10 @@ func (repository *gormUserRepository) LoadAuthUser(ctx context.Context, apiKey s
 	}
 
 	authUser := entities.AuthUser{
-		ID:    user.ID,
-		Email: user.Email,
+		ID:       user.ID,
+		Email:    user.Email,
+		Locale:   user.Locale,
+		Timezone: user.Timezone,
 	}
 
 	if result := repository.cache.SetWithTTL(apiKey, authUser, 1, 2*time.Hour); !result {
@@ -167,6 +181,98 @@ func (repository *gormUserRepository) Load(ctx context.Context, userID entities.
 	return user, nil
 }
 
+func (repository *gormUserRepository) StoreSubAccount(ctx context.Context, parent *entities.User, name string) (*entities.User, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	apiKey, err := repository.generateAPIKey(64)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot generate apiKey for sub-account of user [%s]", parent.ID))
+	}
+
+	user := &entities.User{
+		ID:                               entities.UserID(uuid.NewString()),
+		Email:                            parent.Email,
+		APIKey:                           apiKey,
+		Timezone:                         parent.Timezone,
+		Locale:                           parent.Locale,
+		SubscriptionName:                 parent.SubscriptionName,
+		NotificationMessageStatusEnabled: parent.NotificationMessageStatusEnabled,
+		NotificationWebhookEnabled:       parent.NotificationWebhookEnabled,
+		NotificationHeartbeatEnabled:     parent.NotificationHeartbeatEnabled,
+		DefaultCountry:                   parent.DefaultCountry,
+		ParentUserID:                     &parent.ID,
+		Name:                             &name,
+		CreatedAt:                        time.Now().UTC(),
+		UpdatedAt:                        time.Now().UTC(),
+	}
+
+	if err = repository.db.WithContext(ctx).Create(user).Error; err != nil {
+		msg := fmt.Sprintf("cannot save sub-account [%s] of user [%s]", user.ID, parent.ID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return user, nil
+}
+
+func (repository *gormUserRepository) LoadSubAccount(ctx context.Context, parentUserID entities.UserID, userID entities.UserID) (*entities.User, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	user := new(entities.User)
+	err := repository.db.WithContext(ctx).
+		Where("parent_user_id = ?", parentUserID).
+		Where("id = ?", userID).
+		First(user).
+		Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("sub-account with ID [%s] of user [%s] does not exist", userID, parentUserID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load sub-account with ID [%s] of user [%s]", userID, parentUserID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return user, nil
+}
+
+func (repository *gormUserRepository) IndexSubAccounts(ctx context.Context, parentUserID entities.UserID) ([]*entities.User, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	users := make([]*entities.User, 0)
+	err := repository.db.WithContext(ctx).
+		Where("parent_user_id = ?", parentUserID).
+		Order("created_at ASC").
+		Find(&users).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the sub-accounts of user [%s]", parentUserID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return users, nil
+}
+
+func (repository *gormUserRepository) UpdateSubAccountSubscriptions(ctx context.Context, parent *entities.User) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(&entities.User{}).
+		Where("parent_user_id = ?", parent.ID).
+		Updates(map[string]any{"subscription_name": parent.SubscriptionName, "updated_at": time.Now().UTC()}).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot update the subscription of the sub-accounts of user [%s] to [%s]", parent.ID, parent.SubscriptionName)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 func (repository *gormUserRepository) LoadOrStore(ctx context.Context, authUser entities.AuthUser) (*entities.User, bool, error) {
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormUssdSessionRepository is responsible for persisting entities.UssdSession
+type gormUssdSessionRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormUssdSessionRepository creates the GORM version of the UssdSessionRepository
+func NewGormUssdSessionRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) UssdSessionRepository {
+	return &gormUssdSessionRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormUssdSessionRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save Upsert an entities.UssdSession
+func (repository *gormUssdSessionRepository) Save(ctx context.Context, session *entities.UssdSession) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(session).Error; err != nil {
+		msg := fmt.Sprintf("cannot save USSD session with ID [%s]", session.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.UssdSession by ID
+func (repository *gormUssdSessionRepository) Load(ctx context.Context, userID entities.UserID, sessionID uuid.UUID) (*entities.UssdSession, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	session := new(entities.UssdSession)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", sessionID).First(session).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("USSD session with ID [%s] for user [%s] does not exist", sessionID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load USSD session with ID [%s] for user [%s]", sessionID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return session, nil
+}
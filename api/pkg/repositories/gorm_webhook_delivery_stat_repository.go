@@ -0,0 +1,89 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// gormWebhookDeliveryStatRepository is responsible for persisting entities.WebhookDeliveryStat
+type gormWebhookDeliveryStatRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormWebhookDeliveryStatRepository creates the GORM version of the WebhookDeliveryStatRepository
+func NewGormWebhookDeliveryStatRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) WebhookDeliveryStatRepository {
+	return &gormWebhookDeliveryStatRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormWebhookDeliveryStatRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Increment adds the deliveries of the entities.WebhookDeliveryStat to the bucket with the same key
+func (repository *gormWebhookDeliveryStatRepository) Increment(ctx context.Context, stat *entities.WebhookDeliveryStat) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "webhook_id"}, {Name: "bucket_start"}, {Name: "status_code"}, {Name: "latency_milliseconds"}},
+			DoUpdates: clause.Assignments(map[string]any{"deliveries": gorm.Expr("webhook_delivery_stats.deliveries + ?", stat.Deliveries)}),
+		}).
+		Create(stat).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot increment delivery stats of webhook [%s] for bucket [%s]", stat.WebhookID, stat.BucketStart)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Index the entities.WebhookDeliveryStat of a webhook which started at or after the timestamp
+func (repository *gormWebhookDeliveryStatRepository) Index(ctx context.Context, userID entities.UserID, webhookID uuid.UUID, since time.Time) ([]*entities.WebhookDeliveryStat, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	stats := new([]*entities.WebhookDeliveryStat)
+	err := repository.db.WithContext(ctx).
+		Where("user_id = ?", userID).
+		Where("webhook_id = ?", webhookID).
+		Where("bucket_start >= ?", since).
+		Find(stats).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch delivery stats of webhook [%s] for user [%s] since [%s]", webhookID, userID, since)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return *stats, nil
+}
+
+// DeleteBefore removes the entities.WebhookDeliveryStat of all webhooks which started before the timestamp
+func (repository *gormWebhookDeliveryStatRepository) DeleteBefore(ctx context.Context, timestamp time.Time) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Where("bucket_start < ?", timestamp).
+		Delete(&entities.WebhookDeliveryStat{}).Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete webhook delivery stats before [%s]", timestamp)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
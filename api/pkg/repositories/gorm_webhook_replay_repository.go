@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// gormWebhookReplayRepository is responsible for persisting entities.WebhookReplay
+type gormWebhookReplayRepository struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	db     *gorm.DB
+}
+
+// NewGormWebhookReplayRepository creates the GORM version of the WebhookReplayRepository
+func NewGormWebhookReplayRepository(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+) WebhookReplayRepository {
+	return &gormWebhookReplayRepository{
+		logger: logger.WithService(fmt.Sprintf("%T", &gormWebhookReplayRepository{})),
+		tracer: tracer,
+		db:     db,
+	}
+}
+
+// Save Upsert an entities.WebhookReplay
+func (repository *gormWebhookReplayRepository) Save(ctx context.Context, replay *entities.WebhookReplay) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	if err := repository.db.WithContext(ctx).Save(replay).Error; err != nil {
+		msg := fmt.Sprintf("cannot save webhook replay with ID [%s]", replay.ID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// Load an entities.WebhookReplay by ID
+func (repository *gormWebhookReplayRepository) Load(ctx context.Context, userID entities.UserID, replayID uuid.UUID) (*entities.WebhookReplay, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	replay := new(entities.WebhookReplay)
+	err := repository.db.WithContext(ctx).Where("user_id = ?", userID).Where("id = ?", replayID).First(replay).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		msg := fmt.Sprintf("webhook replay with ID [%s] for user [%s] does not exist", replayID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook replay with ID [%s] for user [%s]", replayID, userID)
+		return nil, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return replay, nil
+}
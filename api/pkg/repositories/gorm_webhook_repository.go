@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
@@ -68,9 +69,20 @@ func (repository *gormWebhookRepository) LoadByEvent(ctx context.Context, userID
 	ctx, span := repository.tracer.Start(ctx)
 	defer span.End()
 
+	query := "SELECT * FROM webhooks WHERE user_id = ? AND enabled = true AND CAST(? as TEXT) = ANY(events) AND phone_id IS NULL"
+	args := []any{userID, event}
+	if phoneNumber != "" {
+		query = `SELECT * FROM webhooks WHERE user_id = ? AND enabled = true AND CAST(? as TEXT) = ANY(events) AND (
+			(phone_id IS NULL AND CAST(? as TEXT) = ANY(phone_numbers)) OR
+			phone_id IN (SELECT id FROM phones WHERE user_id = ? AND phone_number = ?)
+		)`
+		args = append(args, phoneNumber, userID, phoneNumber)
+	}
+
 	webhooks := make([]*entities.Webhook, 0)
 	err := repository.db.
-		Raw("SELECT * FROM webhooks WHERE user_id = ? AND CAST(? as TEXT) = ANY(events) AND CAST(? as TEXT) = ANY(phone_numbers)", userID, event, phoneNumber).
+		WithContext(ctx).
+		Raw(query, args...).
 		Scan(&webhooks).
 		Error
 	if err != nil {
@@ -115,3 +127,54 @@ func (repository *gormWebhookRepository) Delete(ctx context.Context, userID enti
 
 	return nil
 }
+
+func (repository *gormWebhookRepository) IncrementFailures(ctx context.Context, webhookID uuid.UUID) (uint, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	var failures uint
+	err := repository.db.WithContext(ctx).
+		Raw("UPDATE webhooks SET consecutive_failures = consecutive_failures + 1 WHERE id = ? RETURNING consecutive_failures", webhookID).
+		Scan(&failures).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot increment consecutive failures of webhook with ID [%s]", webhookID)
+		return 0, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return failures, nil
+}
+
+func (repository *gormWebhookRepository) ResetFailures(ctx context.Context, webhookID uuid.UUID) error {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	err := repository.db.WithContext(ctx).
+		Model(&entities.Webhook{}).
+		Where("id = ?", webhookID).
+		Update("consecutive_failures", 0).
+		Error
+	if err != nil {
+		msg := fmt.Sprintf("cannot reset consecutive failures of webhook with ID [%s]", webhookID)
+		return repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+func (repository *gormWebhookRepository) Disable(ctx context.Context, webhookID uuid.UUID, timestamp time.Time) (bool, error) {
+	ctx, span := repository.tracer.Start(ctx)
+	defer span.End()
+
+	result := repository.db.WithContext(ctx).
+		Model(&entities.Webhook{}).
+		Where("id = ?", webhookID).
+		Where("enabled = ?", true).
+		Updates(map[string]any{"enabled": false, "disabled_at": timestamp, "updated_at": timestamp})
+	if result.Error != nil {
+		msg := fmt.Sprintf("cannot disable webhook with ID [%s]", webhookID)
+		return false, repository.tracer.WrapErrorSpan(span, stacktrace.Propagate(result.Error, msg))
+	}
+
+	return result.RowsAffected == 1, nil
+}
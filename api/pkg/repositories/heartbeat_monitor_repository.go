@@ -27,4 +27,7 @@ type HeartbeatMonitorRepository interface {
 
 	// UpdatePhoneOnline updates the phone online status of a monitor
 	UpdatePhoneOnline(ctx context.Context, userID entities.UserID, monitorID uuid.UUID, online bool) error
+
+	// UpdateBatteryLow updates the battery low status of a monitor
+	UpdateBatteryLow(ctx context.Context, userID entities.UserID, monitorID uuid.UUID, batteryLow bool) error
 }
@@ -14,6 +14,9 @@ type HeartbeatRepository interface {
 	// Index entities.Heartbeat of an owner
 	Index(ctx context.Context, userID entities.UserID, owner string, params IndexParams) (*[]entities.Heartbeat, error)
 
+	// IndexVersion fetches the ListVersion of all the entities.Heartbeat which match the filter of Index ignoring the pagination
+	IndexVersion(ctx context.Context, userID entities.UserID, owner string, params IndexParams) (*ListVersion, error)
+
 	// Last entities.Heartbeat returns the last heartbeat
 	Last(ctx context.Context, userID entities.UserID, owner string) (*entities.Heartbeat, error)
 }
@@ -0,0 +1,33 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// LabelRepository loads and persists an entities.Label and the entities.MessageLabel which attach it to messages
+type LabelRepository interface {
+	// Save Upsert a new entities.Label
+	Save(ctx context.Context, label *entities.Label) error
+
+	// Index entities.Label by entities.UserID
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Label, error)
+
+	// Load loads an entities.Label by ID.
+	Load(ctx context.Context, userID entities.UserID, labelID uuid.UUID) (*entities.Label, error)
+
+	// Delete an entities.Label and detach it from all the messages
+	Delete(ctx context.Context, userID entities.UserID, labelID uuid.UUID) error
+
+	// IndexByMessage fetches the entities.Label attached to a message
+	IndexByMessage(ctx context.Context, userID entities.UserID, messageID uuid.UUID) ([]*entities.Label, error)
+
+	// Attach an entities.Label to a message, nothing is changed when the label is already attached
+	Attach(ctx context.Context, messageLabel *entities.MessageLabel) error
+
+	// Detach an entities.Label from a message, ErrCodeNotFound is returned when the label is not attached to the message
+	Detach(ctx context.Context, userID entities.UserID, messageID uuid.UUID, labelID uuid.UUID) error
+}
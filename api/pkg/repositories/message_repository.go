@@ -2,11 +2,36 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/google/uuid"
 )
 
+// ContactMatch is how the contact of a message is matched when indexing messages
+type ContactMatch string
+
+const (
+	// ContactMatchExact matches the contact which is equal to the number, it is the default because it uses the index on the contact
+	ContactMatchExact = ContactMatch("exact")
+
+	// ContactMatchContains matches the contact whose digits contain the digits of the number
+	ContactMatchContains = ContactMatch("contains")
+
+	// ContactMatchEndsWith matches the contact whose digits end with the digits of the number e.g. the last 7 digits without the country code
+	ContactMatchEndsWith = ContactMatch("ends_with")
+)
+
+// ContactMatches are the supported values of ContactMatch
+func ContactMatches() []ContactMatch {
+	return []ContactMatch{ContactMatchExact, ContactMatchContains, ContactMatchEndsWith}
+}
+
+// IsPartial checks if only some digits of the contact are matched
+func (match ContactMatch) IsPartial() bool {
+	return match == ContactMatchContains || match == ContactMatchEndsWith
+}
+
 // MessageRepository loads and persists an entities.Message
 type MessageRepository interface {
 	// Store a new entities.Message
@@ -18,8 +43,11 @@ type MessageRepository interface {
 	// Load an entities.Message by ID
 	Load(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error)
 
-	// Index entities.Message between 2 phone numbers
-	Index(ctx context.Context, userID entities.UserID, owner string, contact string, params IndexParams) (*[]entities.Message, error)
+	// Index entities.Message between 2 phone numbers, owner and contact are ignored when empty and metadata, starred or the label is set, category is ignored when empty
+	Index(ctx context.Context, userID entities.UserID, owner string, contact string, contactMatch ContactMatch, metadata entities.MessageMetadata, category entities.MessageCategory, language string, starred bool, labelID *uuid.UUID, params IndexParams) (*[]entities.Message, error)
+
+	// IndexVersion fetches the ListVersion of all the entities.Message which match the filter of Index ignoring the pagination
+	IndexVersion(ctx context.Context, userID entities.UserID, owner string, contact string, contactMatch ContactMatch, metadata entities.MessageMetadata, category entities.MessageCategory, language string, starred bool, labelID *uuid.UUID, params IndexParams) (*ListVersion, error)
 
 	// LastMessage fetches the last message between an owner and a contact
 	LastMessage(ctx context.Context, userID entities.UserID, owner string, contact string) (*entities.Message, error)
@@ -27,12 +55,43 @@ type MessageRepository interface {
 	// Search entities.Message for a user
 	Search(ctx context.Context, userID entities.UserID, owners []string, types []entities.MessageType, statuses []entities.MessageStatus, params IndexParams) ([]*entities.Message, error)
 
+	// IndexUpdatedSince fetches the entities.Message of a user which have been created or updated after the timestamp in the order they were updated.
+	// The messages which were updated at the timestamp are fetched when their ID is greater than the sinceID.
+	IndexUpdatedSince(ctx context.Context, userID entities.UserID, since time.Time, sinceID uuid.UUID, limit int) ([]*entities.Message, error)
+
+	// IndexFailedByReason fetches the failed mobile terminated entities.Message of an owner with a failure reason
+	IndexFailedByReason(ctx context.Context, userID entities.UserID, owner string, reason string, limit int) ([]*entities.Message, error)
+
+	// IndexArchived fetches the entities.Message between an owner and a contact whose content has been moved to cold storage
+	IndexArchived(ctx context.Context, userID entities.UserID, owner string, contact string) ([]*entities.Message, error)
+
+	// IndexArchivable fetches the entities.Message which were created before the timestamp, have a final status and are not yet archived
+	IndexArchivable(ctx context.Context, before time.Time, limit int) ([]*entities.Message, error)
+
+	// Archive removes the content of an entities.Message which has been moved to cold storage, ErrCodeNotFound is returned when the message does not exist or is already archived
+	Archive(ctx context.Context, message *entities.Message, timestamp time.Time) error
+
+	// LoadReceived fetches the mobile originated entities.Message which was received by the owner from the contact at the timestamp, ErrCodeNotFound is returned when there is no such message
+	LoadReceived(ctx context.Context, userID entities.UserID, owner string, contact string, content string, timestamp time.Time) (*entities.Message, error)
+
 	// GetOutstanding fetches an entities.Message which is outstanding
 	GetOutstanding(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error)
 
+	// Cancel an entities.Message which is pending or scheduled, ErrCodeNotFound is returned when there is no such message
+	Cancel(ctx context.Context, userID entities.UserID, messageID uuid.UUID, timestamp time.Time) (*entities.Message, error)
+
+	// ToggleStarred stars an entities.Message which is not starred and unstars a starred one, ErrCodeNotFound is returned when there is no such message
+	ToggleStarred(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error)
+
+	// MarkRead sets the read time of the unread messages of the user with the given IDs and returns the number of updated messages
+	MarkRead(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID, timestamp time.Time) (int64, error)
+
+	// CountPendingRequests counts the distinct request IDs with the prefix which have messages that are not yet sent
+	CountPendingRequests(ctx context.Context, userID entities.UserID, requestIDPrefix string) (int, error)
+
 	// Delete an entities.Message by ID
 	Delete(ctx context.Context, userID entities.UserID, messageID uuid.UUID) error
 
-	// DeleteByOwnerAndContact deletes messages between an owner and a contact
-	DeleteByOwnerAndContact(ctx context.Context, userID entities.UserID, owner string, contact string) error
+	// DeleteByOwnerAndContact deletes messages between an owner and a contact and returns the number of deleted messages
+	DeleteByOwnerAndContact(ctx context.Context, userID entities.UserID, owner string, contact string) (int64, error)
 }
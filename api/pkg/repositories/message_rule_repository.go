@@ -0,0 +1,27 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// MessageRuleRepository loads and persists an entities.MessageRule
+type MessageRuleRepository interface {
+	// Save Upsert a new entities.MessageRule
+	Save(ctx context.Context, rule *entities.MessageRule) error
+
+	// Index entities.MessageRule by entities.UserID in the order of their position
+	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.MessageRule, error)
+
+	// FetchEnabled loads the enabled entities.MessageRule of a user in the order of their position
+	FetchEnabled(ctx context.Context, userID entities.UserID) ([]*entities.MessageRule, error)
+
+	// Load loads an entities.MessageRule by ID.
+	Load(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) (*entities.MessageRule, error)
+
+	// Delete an entities.MessageRule
+	Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error
+}
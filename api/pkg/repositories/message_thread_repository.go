@@ -25,6 +25,9 @@ type MessageThreadRepository interface {
 	// Index message threads for an owner
 	Index(ctx context.Context, userID entities.UserID, owner string, archived bool, params IndexParams) (*[]entities.MessageThread, error)
 
+	// IndexVersion fetches the ListVersion of all the message threads which match the filter of Index ignoring the pagination
+	IndexVersion(ctx context.Context, userID entities.UserID, owner string, archived bool, params IndexParams) (*ListVersion, error)
+
 	// UpdateAfterDeletedMessage updates a thread after the original message has been deleted
 	UpdateAfterDeletedMessage(ctx context.Context, userID entities.UserID, messageID uuid.UUID) error
 
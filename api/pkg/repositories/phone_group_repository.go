@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// PhoneGroupRepository loads and persists an entities.PhoneGroup
+type PhoneGroupRepository interface {
+	// Save Upsert an entities.PhoneGroup
+	Save(ctx context.Context, group *entities.PhoneGroup) error
+
+	// Load an entities.PhoneGroup by the name of the group
+	Load(ctx context.Context, userID entities.UserID, name string) (*entities.PhoneGroup, error)
+}
@@ -13,6 +13,15 @@ type PhoneNotificationRepository interface {
 	// Schedule a new entities.PhoneNotification
 	Schedule(ctx context.Context, messagesPerMinute uint, notification *entities.PhoneNotification) error
 
+	// QueuePositions loads the positions of the pending notifications of the messages in the queues of their phones
+	QueuePositions(ctx context.Context, messageIDs []uuid.UUID) ([]*entities.PhoneNotificationQueuePosition, error)
+
+	// IndexPaused loads the notifications of a phone which were not sent because the phone was paused, the oldest notification is first
+	IndexPaused(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) ([]*entities.PhoneNotification, error)
+
 	// UpdateStatus of a notification
 	UpdateStatus(ctx context.Context, notificationID uuid.UUID, status entities.PhoneNotificationStatus) error
+
+	// UpdateStatusFrom changes the status of a notification only when it has the from status, ErrCodeNotFound is returned when it has another status
+	UpdateStatusFrom(ctx context.Context, notificationID uuid.UUID, from entities.PhoneNotificationStatus, to entities.PhoneNotificationStatus) error
 }
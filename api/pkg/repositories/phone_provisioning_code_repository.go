@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// PhoneProvisioningCodeRepository loads and persists an entities.PhoneProvisioningCode
+type PhoneProvisioningCodeRepository interface {
+	// Store a new entities.PhoneProvisioningCode
+	Store(ctx context.Context, code *entities.PhoneProvisioningCode) error
+
+	// Consume marks the unused entities.PhoneProvisioningCode with the hash as used, ErrCodeNotFound is returned when the code does not exist, is used or has expired
+	Consume(ctx context.Context, codeHash string, timestamp time.Time) (*entities.PhoneProvisioningCode, error)
+}
@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -13,15 +14,54 @@ type PhoneRepository interface {
 	// Save Upsert a new entities.Phone
 	Save(ctx context.Context, phone *entities.Phone) error
 
+	// StoreMany creates multiple entities.Phone in a single transaction
+	StoreMany(ctx context.Context, phones []*entities.Phone) error
+
 	// Index entities.Phone of a user
 	Index(ctx context.Context, userID entities.UserID, params IndexParams) (*[]entities.Phone, error)
 
+	// IndexByGroup fetches the entities.Phone of a user in a group
+	IndexByGroup(ctx context.Context, userID entities.UserID, group string) ([]*entities.Phone, error)
+
 	// Load a phone by user and phone number
 	Load(ctx context.Context, userID entities.UserID, phoneNumber string) (*entities.Phone, error)
 
+	// LoadByAlphanumericSenderID a phone by the alphanumeric sender ID of its SIM
+	LoadByAlphanumericSenderID(ctx context.Context, userID entities.UserID, senderID string) (*entities.Phone, error)
+
+	// LoadByExternalID a phone by the ID of the phone in an external system
+	LoadByExternalID(ctx context.Context, userID entities.UserID, externalID string) (*entities.Phone, error)
+
 	// LoadByID a phone by ID
 	LoadByID(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.Phone, error)
 
 	// Delete an entities.Phone
 	Delete(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) error
+
+	// IncrementDailySent increments the messages sent by an entities.Phone on a date, it returns false when the daily quota is exhausted
+	IncrementDailySent(ctx context.Context, phone *entities.Phone, date string) (bool, error)
+
+	// DecrementDailySent gives back a message which was counted by IncrementDailySent but was not sent
+	DecrementDailySent(ctx context.Context, phoneID uuid.UUID, date string) error
+
+	// LoadDailySent returns the number of messages sent by an entities.Phone on a date
+	LoadDailySent(ctx context.Context, phoneID uuid.UUID, date string) (uint, error)
+
+	// UpdateFcmToken sets a new FCM token on an entities.Phone and clears the time when the previous token was rejected
+	UpdateFcmToken(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, fcmToken string, timestamp time.Time) error
+
+	// UpdateLastSentAt sets the time of the latest message sent by an entities.Phone, an older timestamp does not replace a newer one
+	UpdateLastSentAt(ctx context.Context, userID entities.UserID, phoneNumber string, timestamp time.Time) error
+
+	// UpdateLastReceivedAt sets the time of the latest message received by an entities.Phone, an older timestamp does not replace a newer one
+	UpdateLastReceivedAt(ctx context.Context, userID entities.UserID, phoneNumber string, timestamp time.Time) error
+
+	// UpdateLastError sets the latest error reported by an entities.Phone, the error is cleared when lastError is nil and an error older than the timestamp does not replace a newer one
+	UpdateLastError(ctx context.Context, userID entities.UserID, phoneNumber string, lastError *string, timestamp time.Time) error
+
+	// UpdateLastControl records the latest control notification sent to an entities.Phone
+	UpdateLastControl(ctx context.Context, phoneID uuid.UUID, controlType entities.PhoneControlType, timestamp time.Time) error
+
+	// InvalidateFcmToken marks the FCM token of an entities.Phone as rejected, it returns false when the phone already has a different token
+	InvalidateFcmToken(ctx context.Context, phoneID uuid.UUID, fcmToken string, timestamp time.Time) (bool, error)
 }
@@ -0,0 +1,19 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// ProviderFallbackRepository loads and persists an entities.ProviderFallback
+type ProviderFallbackRepository interface {
+	// Save Upsert an entities.ProviderFallback
+	Save(ctx context.Context, fallback *entities.ProviderFallback) error
+
+	// Load the entities.ProviderFallback of a user
+	Load(ctx context.Context, userID entities.UserID) (*entities.ProviderFallback, error)
+
+	// Delete the entities.ProviderFallback of a user
+	Delete(ctx context.Context, userID entities.UserID) error
+}
@@ -15,9 +15,18 @@ type IndexParams struct {
 	Limit          int    `json:"take"`
 }
 
+// ListVersion is the number of entities which match the filter of a list and the latest time when one of them was updated
+type ListVersion struct {
+	Count           int64
+	LatestUpdatedAt time.Time
+}
+
 const (
 	// ErrCodeNotFound is thrown when an entity does not exist in storage
 	ErrCodeNotFound = stacktrace.ErrorCode(1000)
 
+	// ErrCodeConflict is thrown when an entity with the same ID already exists in storage
+	ErrCodeConflict = stacktrace.ErrorCode(1001)
+
 	dbOperationDuration = 5 * time.Second
 )
@@ -26,6 +26,18 @@ type UserRepository interface {
 	// LoadOrStore an entities.User by entities.AuthUser
 	LoadOrStore(ctx context.Context, user entities.AuthUser) (*entities.User, bool, error)
 
+	// StoreSubAccount creates a new sub-account with its own API key under the parent entities.User
+	StoreSubAccount(ctx context.Context, parent *entities.User, name string) (*entities.User, error)
+
+	// LoadSubAccount loads a sub-account by the entities.UserID of its parent
+	LoadSubAccount(ctx context.Context, parentUserID entities.UserID, userID entities.UserID) (*entities.User, error)
+
+	// IndexSubAccounts fetches the sub-accounts of a parent entities.User
+	IndexSubAccounts(ctx context.Context, parentUserID entities.UserID) ([]*entities.User, error)
+
+	// UpdateSubAccountSubscriptions sets the subscription of the sub-accounts to the subscription of their parent
+	UpdateSubAccountSubscriptions(ctx context.Context, parent *entities.User) error
+
 	// LoadBySubscriptionID loads a user based on the lemonsqueezy subscriptionID
 	LoadBySubscriptionID(ctx context.Context, subscriptionID string) (*entities.User, error)
 }
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// UssdSessionRepository loads and persists an entities.UssdSession
+type UssdSessionRepository interface {
+	// Save Upsert an entities.UssdSession
+	Save(ctx context.Context, session *entities.UssdSession) error
+
+	// Load an entities.UssdSession by ID
+	Load(ctx context.Context, userID entities.UserID, sessionID uuid.UUID) (*entities.UssdSession, error)
+}
@@ -0,0 +1,22 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// WebhookDeliveryStatRepository loads and persists an entities.WebhookDeliveryStat
+type WebhookDeliveryStatRepository interface {
+	// Increment adds the deliveries of the entities.WebhookDeliveryStat to the bucket with the same key
+	Increment(ctx context.Context, stat *entities.WebhookDeliveryStat) error
+
+	// Index the entities.WebhookDeliveryStat of a webhook which started at or after the timestamp
+	Index(ctx context.Context, userID entities.UserID, webhookID uuid.UUID, since time.Time) ([]*entities.WebhookDeliveryStat, error)
+
+	// DeleteBefore removes the entities.WebhookDeliveryStat of all webhooks which started before the timestamp
+	DeleteBefore(ctx context.Context, timestamp time.Time) error
+}
@@ -0,0 +1,18 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// WebhookReplayRepository loads and persists an entities.WebhookReplay
+type WebhookReplayRepository interface {
+	// Save Upsert an entities.WebhookReplay
+	Save(ctx context.Context, replay *entities.WebhookReplay) error
+
+	// Load an entities.WebhookReplay by ID
+	Load(ctx context.Context, userID entities.UserID, replayID uuid.UUID) (*entities.WebhookReplay, error)
+}
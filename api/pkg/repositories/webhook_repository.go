@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -17,6 +18,7 @@ type WebhookRepository interface {
 	Index(ctx context.Context, userID entities.UserID, params IndexParams) ([]*entities.Webhook, error)
 
 	// LoadByEvent loads webhooks for a user and event.
+	// The global webhooks and the webhooks scoped to the phone with the phoneNumber are loaded, only global webhooks are loaded when the phoneNumber is empty.
 	LoadByEvent(ctx context.Context, userID entities.UserID, event string, phoneNumber string) ([]*entities.Webhook, error)
 
 	// Load loads a webhook by ID.
@@ -24,4 +26,13 @@ type WebhookRepository interface {
 
 	// Delete an entities.Webhook
 	Delete(ctx context.Context, userID entities.UserID, webhookID uuid.UUID) error
+
+	// IncrementFailures increments the consecutive failures of an entities.Webhook and returns the new count
+	IncrementFailures(ctx context.Context, webhookID uuid.UUID) (uint, error)
+
+	// ResetFailures sets the consecutive failures of an entities.Webhook to 0
+	ResetFailures(ctx context.Context, webhookID uuid.UUID) error
+
+	// Disable an enabled entities.Webhook, it returns false if the webhook was already disabled
+	Disable(ctx context.Context, webhookID uuid.UUID, timestamp time.Time) (bool, error)
 }
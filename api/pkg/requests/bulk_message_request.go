@@ -1,7 +1,6 @@
 package requests
 
 import (
-	"fmt"
 	"strings"
 	"time"
 
@@ -34,7 +33,7 @@ func (input *BulkMessage) ToMessageSendParams(userID entities.UserID, requestID
 	return services.MessageSendParams{
 		Source:            source,
 		Owner:             from,
-		RequestID:         input.sanitizeStringPointer(fmt.Sprintf("bulk-%s", requestID.String())),
+		RequestID:         input.sanitizeStringPointer(services.MessageBulkRequestIDPrefix + requestID.String()),
 		UserID:            userID,
 		SendAt:            input.SendTime,
 		RequestReceivedAt: time.Now().UTC(),
@@ -0,0 +1,61 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// BulkMessageStore are the form fields which are uploaded with a bulk message file
+type BulkMessageStore struct {
+	request
+
+	// Duration spreads the messages evenly over a period of time e.g. 6h, the messages are sent immediately when it and the Rate are empty
+	Duration string `json:"duration" example:"6h"`
+
+	// Rate is the number of messages which are released every minute
+	Rate string `json:"rate" example:"15"`
+}
+
+// Sanitize sets defaults to BulkMessageStore
+func (input *BulkMessageStore) Sanitize() BulkMessageStore {
+	input.Duration = strings.ToLower(strings.TrimSpace(input.Duration))
+	input.Rate = strings.TrimSpace(input.Rate)
+	return *input
+}
+
+// IsPaced checks if the messages are released over a period of time instead of being sent immediately
+func (input *BulkMessageStore) IsPaced() bool {
+	return input.Duration != "" || input.Rate != ""
+}
+
+// Interval is the time between the release of 2 of the messages
+func (input *BulkMessageStore) Interval(count int) time.Duration {
+	if input.Rate != "" {
+		return time.Minute / time.Duration(max(input.getInt(input.Rate), 1))
+	}
+
+	duration, _ := time.ParseDuration(input.Duration)
+	return duration / time.Duration(max(count, 1))
+}
+
+// ToBulkMessageJobStoreParams converts BulkMessageStore to services.BulkMessageJobStoreParams
+func (input *BulkMessageStore) ToBulkMessageJobStoreParams(userID entities.UserID, source string, messages []*BulkMessage) *services.BulkMessageJobStoreParams {
+	items := make([]*entities.BulkMessageJobItem, 0, len(messages))
+	for _, message := range messages {
+		items = append(items, &entities.BulkMessageJobItem{
+			Owner:   message.FromPhoneNumber,
+			Contact: input.sanitizeAddress(message.ToPhoneNumber),
+			Content: message.Content,
+		})
+	}
+
+	return &services.BulkMessageJobStoreParams{
+		UserID:   userID,
+		Interval: input.Interval(len(messages)),
+		Items:    items,
+		Source:   source,
+	}
+}
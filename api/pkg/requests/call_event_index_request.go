@@ -0,0 +1,47 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// CallEventIndex is the payload for fetching entities.CallEvent of a phone
+type CallEventIndex struct {
+	request
+	Skip  string `json:"skip" query:"skip"`
+	Query string `json:"query" query:"query"`
+	Limit string `json:"limit" query:"limit"`
+
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to CallEventIndex
+func (input *CallEventIndex) Sanitize() CallEventIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Query = strings.TrimSpace(input.Query)
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	return *input
+}
+
+// PhoneIDUuid returns the phoneID as uuid.UUID
+func (input *CallEventIndex) PhoneIDUuid() uuid.UUID {
+	return uuid.MustParse(input.PhoneID)
+}
+
+// ToIndexParams converts CallEventIndex to repositories.IndexParams
+func (input *CallEventIndex) ToIndexParams() repositories.IndexParams {
+	return repositories.IndexParams{
+		Skip:  input.getInt(input.Skip),
+		Query: input.Query,
+		Limit: input.getInt(input.Limit),
+	}
+}
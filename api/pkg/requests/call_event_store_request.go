@@ -0,0 +1,51 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// CallEventStore is the payload for registering a phone call on the android phone
+type CallEventStore struct {
+	request
+	From      string    `json:"from" example:"+18005550100"`
+	Type      string    `json:"type" example:"missed"`
+	Duration  uint      `json:"duration" example:"65"`
+	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// SIM is the SIM slot of the phone which received the call
+	SIM string `json:"sim" example:"SIM1"`
+
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to CallEventStore
+func (input *CallEventStore) Sanitize() CallEventStore {
+	input.From = input.sanitizeAddress(input.From)
+	input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+	input.SIM = input.sanitizeSIM(input.SIM)
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	if input.Type == entities.CallEventTypeMissed.String() {
+		input.Duration = 0
+	}
+	return *input
+}
+
+// ToStoreParams converts CallEventStore to services.CallEventStoreParams
+func (input *CallEventStore) ToStoreParams(user entities.AuthUser, source string) *services.CallEventStoreParams {
+	return &services.CallEventStoreParams{
+		PhoneID:         uuid.MustParse(input.PhoneID),
+		UserID:          user.ID,
+		Contact:         input.From,
+		Type:            entities.CallEventType(input.Type),
+		SIM:             entities.SIM(input.SIM),
+		DurationSeconds: input.Duration,
+		Timestamp:       input.Timestamp.UTC(),
+		Source:          source,
+	}
+}
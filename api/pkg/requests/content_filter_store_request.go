@@ -0,0 +1,41 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// ContentFilterStore is the payload for creating a new entities.ContentFilter
+type ContentFilterStore struct {
+	request
+	// Term is a word which is matched case-insensitively or a regular expression when is_regex is true
+	Term    string `json:"term" example:"casino"`
+	IsRegex bool   `json:"is_regex" example:"false"`
+
+	// Direction of the messages which are checked, outbound messages are rejected and inbound messages are flagged
+	Direction string `json:"direction" example:"outbound" validate:"optional"`
+}
+
+// Sanitize sets defaults to ContentFilterStore
+func (input *ContentFilterStore) Sanitize() ContentFilterStore {
+	if !input.IsRegex {
+		input.Term = strings.TrimSpace(input.Term)
+	}
+	input.Direction = strings.ToLower(strings.TrimSpace(input.Direction))
+	if input.Direction == "" {
+		input.Direction = string(entities.ContentFilterDirectionOutbound)
+	}
+	return *input
+}
+
+// ToStoreParams converts ContentFilterStore to services.ContentFilterStoreParams
+func (input *ContentFilterStore) ToStoreParams(user entities.AuthUser) *services.ContentFilterStoreParams {
+	return &services.ContentFilterStoreParams{
+		UserID:    user.ID,
+		Term:      input.Term,
+		IsRegex:   input.IsRegex,
+		Direction: entities.ContentFilterDirection(input.Direction),
+	}
+}
@@ -0,0 +1,30 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// ContentFilterUpdate is the payload for updating an entities.ContentFilter
+type ContentFilterUpdate struct {
+	ContentFilterStore
+	ContentFilterID string `json:"contentFilterID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to ContentFilterUpdate
+func (input *ContentFilterUpdate) Sanitize() ContentFilterUpdate {
+	input.ContentFilterStore.Sanitize()
+	return *input
+}
+
+// ToUpdateParams converts ContentFilterUpdate to services.ContentFilterUpdateParams
+func (input *ContentFilterUpdate) ToUpdateParams(user entities.AuthUser) *services.ContentFilterUpdateParams {
+	return &services.ContentFilterUpdateParams{
+		UserID:    user.ID,
+		FilterID:  uuid.MustParse(input.ContentFilterID),
+		Term:      input.Term,
+		IsRegex:   input.IsRegex,
+		Direction: entities.ContentFilterDirection(input.Direction),
+	}
+}
@@ -0,0 +1,47 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// CountryRouteUpsert is the payload for setting the phone which sends the messages without a from number to the recipients in a country
+type CountryRouteUpsert struct {
+	request
+	// Owner is the phone number of the phone which sends the messages, it cannot be set together with the phone_group
+	Owner string `json:"owner" example:"+18005550199"`
+
+	// PhoneGroup is the group of phones which sends the messages with the strategy of the group, it cannot be set together with the owner
+	PhoneGroup string `json:"phone_group" example:"warehouse-1"`
+
+	Country string `json:"country" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to CountryRouteUpsert
+func (input *CountryRouteUpsert) Sanitize() CountryRouteUpsert {
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.PhoneGroup = strings.TrimSpace(input.PhoneGroup)
+	input.Country = input.sanitizeCountry(input.Country)
+	return *input
+}
+
+// sanitizeCountry uppercases the ISO 3166-1 alpha-2 code of a country, the entities.CountryRouteDefault country is kept in lowercase
+func (input *CountryRouteUpsert) sanitizeCountry(country string) string {
+	country = strings.TrimSpace(country)
+	if strings.EqualFold(country, entities.CountryRouteDefault) {
+		return entities.CountryRouteDefault
+	}
+	return strings.ToUpper(country)
+}
+
+// ToUpsertParams converts CountryRouteUpsert to services.CountryRouteUpsertParams
+func (input *CountryRouteUpsert) ToUpsertParams(user entities.AuthUser) *services.CountryRouteUpsertParams {
+	return &services.CountryRouteUpsertParams{
+		UserID:     user.ID,
+		Country:    input.Country,
+		Owner:      input.sanitizeStringPointer(input.Owner),
+		PhoneGroup: input.sanitizeStringPointer(input.PhoneGroup),
+	}
+}
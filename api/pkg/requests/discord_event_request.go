@@ -0,0 +1,95 @@
+package requests
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	discordInteractionTypePing               = 1
+	discordInteractionTypeApplicationCommand = 2
+)
+
+// DiscordEvent is the payload of an interaction sent by discord
+// https://discord.com/developers/docs/interactions/receiving-and-responding#interaction-object
+type DiscordEvent struct {
+	request
+	ID            string              `json:"id"`
+	ApplicationID string              `json:"application_id"`
+	Type          int                 `json:"type"`
+	Data          *DiscordEventData   `json:"data"`
+	GuildID       string              `json:"guild_id"`
+	ChannelID     string              `json:"channel_id"`
+	Member        *DiscordEventMember `json:"member"`
+	Token         string              `json:"token"`
+	Version       int                 `json:"version"`
+}
+
+// DiscordEventData is the data of an application command interaction
+type DiscordEventData struct {
+	ID      string                   `json:"id"`
+	Name    string                   `json:"name"`
+	Type    int                      `json:"type"`
+	Options []DiscordEventDataOption `json:"options"`
+}
+
+// DiscordEventDataOption is an option passed to an application command
+type DiscordEventDataOption struct {
+	Name  string `json:"name"`
+	Type  int    `json:"type"`
+	Value any    `json:"value"`
+}
+
+// DiscordEventMember is the guild member who triggered the interaction
+type DiscordEventMember struct {
+	User *DiscordEventUser `json:"user"`
+}
+
+// DiscordEventUser is the discord user who triggered the interaction
+type DiscordEventUser struct {
+	ID       string `json:"id"`
+	Username string `json:"username"`
+}
+
+// IsPing checks if the discord interaction is a PING
+func (input *DiscordEvent) IsPing() bool {
+	return input.Type == discordInteractionTypePing
+}
+
+// IsApplicationCommand checks if the discord interaction is an APPLICATION_COMMAND
+func (input *DiscordEvent) IsApplicationCommand() bool {
+	return input.Type == discordInteractionTypeApplicationCommand
+}
+
+// Sanitize sets defaults to DiscordEvent
+func (input *DiscordEvent) Sanitize() DiscordEvent {
+	input.GuildID = strings.TrimSpace(input.GuildID)
+	return *input
+}
+
+// Option returns the value of an application command option as a string
+func (input *DiscordEvent) Option(name string) string {
+	if input.Data == nil {
+		return ""
+	}
+
+	for _, option := range input.Data.Options {
+		if option.Name != name || option.Value == nil {
+			continue
+		}
+		if value, ok := option.Value.(string); ok {
+			return value
+		}
+		return fmt.Sprintf("%v", option.Value)
+	}
+	return ""
+}
+
+// ToMessageSend converts DiscordEvent to MessageSend
+func (input *DiscordEvent) ToMessageSend() MessageSend {
+	return MessageSend{
+		From:    input.Option("from"),
+		To:      input.Option("to"),
+		Content: input.Option("message"),
+	}
+}
@@ -13,6 +13,7 @@ type DiscordStore struct {
 	Name              string `json:"name"`
 	ServerID          string `json:"server_id"`
 	IncomingChannelID string `json:"incoming_channel_id"`
+	DefaultFrom       string `json:"default_from"`
 }
 
 // Sanitize sets defaults to DiscordStore
@@ -20,15 +21,26 @@ func (input *DiscordStore) Sanitize() DiscordStore {
 	input.Name = strings.TrimSpace(input.Name)
 	input.ServerID = strings.TrimSpace(input.ServerID)
 	input.IncomingChannelID = strings.TrimSpace(input.IncomingChannelID)
+	input.DefaultFrom = input.sanitizeAddress(input.DefaultFrom)
 	return *input
 }
 
+// defaultFrom returns the DefaultFrom and nil when the integration has no default sender
+func (input *DiscordStore) defaultFrom() *string {
+	if input.DefaultFrom == "" {
+		return nil
+	}
+	return &input.DefaultFrom
+}
+
 // ToStoreParams converts DiscordStore to services.WebhookStoreParams
-func (input *DiscordStore) ToStoreParams(user entities.AuthUser) *services.DiscordStoreParams {
+func (input *DiscordStore) ToStoreParams(user entities.AuthUser, source string) *services.DiscordStoreParams {
 	return &services.DiscordStoreParams{
 		UserID:            user.ID,
 		Name:              input.Name,
 		ServerID:          input.ServerID,
 		IncomingChannelID: input.IncomingChannelID,
+		DefaultFrom:       input.defaultFrom(),
+		Source:            source,
 	}
 }
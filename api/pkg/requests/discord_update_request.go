@@ -19,12 +19,14 @@ func (input *DiscordUpdate) Sanitize() DiscordUpdate {
 }
 
 // ToUpdateParams converts DiscordUpdate to services.DiscordUpdateParams
-func (input *DiscordUpdate) ToUpdateParams(user entities.AuthUser) *services.DiscordUpdateParams {
+func (input *DiscordUpdate) ToUpdateParams(user entities.AuthUser, source string) *services.DiscordUpdateParams {
 	return &services.DiscordUpdateParams{
 		UserID:            user.ID,
 		Name:              input.Name,
 		ServerID:          input.ServerID,
 		IncomingChannelID: input.IncomingChannelID,
+		DefaultFrom:       input.defaultFrom(),
 		DiscordID:         uuid.MustParse(input.DiscordID),
+		Source:            source,
 	}
 }
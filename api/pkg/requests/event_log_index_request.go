@@ -0,0 +1,56 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+)
+
+// EventLogIndex is the payload for fetching entities.EventLog of a user
+type EventLogIndex struct {
+	request
+	ResourceType string `json:"resource_type" query:"resource_type"`
+	ResourceID   string `json:"resource_id" query:"resource_id"`
+	From         string `json:"from" query:"from"`
+	To           string `json:"to" query:"to"`
+	Skip         string `json:"skip" query:"skip"`
+	Limit        string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to EventLogIndex
+func (input *EventLogIndex) Sanitize() EventLogIndex {
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	input.Skip = strings.TrimSpace(input.Skip)
+	if input.Skip == "" {
+		input.Skip = "0"
+	}
+
+	input.ResourceType = strings.ToLower(strings.TrimSpace(input.ResourceType))
+	input.ResourceID = strings.TrimSpace(input.ResourceID)
+	input.From = strings.TrimSpace(input.From)
+	input.To = strings.TrimSpace(input.To)
+	return *input
+}
+
+// ToSearchParams converts EventLogIndex to repositories.EventLogSearchParams
+func (input *EventLogIndex) ToSearchParams() repositories.EventLogSearchParams {
+	return repositories.EventLogSearchParams{
+		ResourceType: input.ResourceType,
+		ResourceID:   input.ResourceID,
+		From:         input.getTime(input.From, time.Time{}),
+		To:           input.getTime(input.To, time.Now().UTC()),
+		Skip:         input.getInt(input.Skip),
+		Limit:        input.getInt(input.Limit),
+	}
+}
+
+func (input *EventLogIndex) getTime(value string, fallback time.Time) time.Time {
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return fallback
+	}
+	return timestamp.UTC()
+}
@@ -0,0 +1,36 @@
+package requests
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// EventLogStream is the payload for streaming the events of a user
+type EventLogStream struct {
+	request
+	// LastEventID is the ID of the last event received before a reconnect, browsers send it in the Last-Event-ID header
+	LastEventID string `json:"last_event_id" query:"last_event_id"`
+}
+
+// Sanitize sets defaults to EventLogStream
+func (input *EventLogStream) Sanitize() EventLogStream {
+	input.LastEventID = strings.TrimSpace(input.LastEventID)
+	return *input
+}
+
+// ToStreamParams converts EventLogStream to services.EventLogStreamParams
+func (input *EventLogStream) ToStreamParams(userID entities.UserID, send func(log *entities.EventLog) error, keepAlive func() error) services.EventLogStreamParams {
+	params := services.EventLogStreamParams{
+		UserID:    userID,
+		Send:      send,
+		KeepAlive: keepAlive,
+	}
+
+	if sequence, err := strconv.ParseInt(input.LastEventID, 10, 64); err == nil {
+		params.LastSequence = &sequence
+	}
+	return params
+}
@@ -11,6 +11,7 @@ import (
 type HeartbeatStore struct {
 	request
 	Charging bool   `json:"charging"`
+	Battery  *uint  `json:"battery" example:"85"`
 	Owner    string `json:"owner"`
 }
 
@@ -27,6 +28,7 @@ func (input *HeartbeatStore) ToStoreParams(user entities.AuthUser, source string
 		Version:   version,
 		Source:    source,
 		Charging:  input.Charging,
+		Battery:   input.Battery,
 		Timestamp: time.Now().UTC(),
 		UserID:    user.ID,
 	}
@@ -0,0 +1,33 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// LabelStore is the payload for creating a new entities.Label
+type LabelStore struct {
+	request
+	Name string `json:"name" example:"follow-up"`
+
+	// Color is the hex code of the color of the label in the UI
+	Color string `json:"color" example:"#ff5722"`
+}
+
+// Sanitize sets defaults to LabelStore
+func (input *LabelStore) Sanitize() LabelStore {
+	input.Name = strings.TrimSpace(input.Name)
+	input.Color = strings.ToLower(strings.TrimSpace(input.Color))
+	return *input
+}
+
+// ToStoreParams converts LabelStore to services.LabelStoreParams
+func (input *LabelStore) ToStoreParams(user entities.AuthUser) *services.LabelStoreParams {
+	return &services.LabelStoreParams{
+		UserID: user.ID,
+		Name:   input.Name,
+		Color:  input.Color,
+	}
+}
@@ -0,0 +1,29 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// LabelUpdate is the payload for updating an entities.Label
+type LabelUpdate struct {
+	LabelStore
+	LabelID string `json:"labelID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to LabelUpdate
+func (input *LabelUpdate) Sanitize() LabelUpdate {
+	input.LabelStore.Sanitize()
+	return *input
+}
+
+// ToUpdateParams converts LabelUpdate to services.LabelUpdateParams
+func (input *LabelUpdate) ToUpdateParams(user entities.AuthUser) *services.LabelUpdateParams {
+	return &services.LabelUpdateParams{
+		UserID:  user.ID,
+		LabelID: uuid.MustParse(input.LabelID),
+		Name:    input.Name,
+		Color:   input.Color,
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 
+	"github.com/google/uuid"
 	"github.com/nyaruka/phonenumbers"
 
 	"github.com/NdoleStudio/httpsms/pkg/services"
@@ -35,12 +36,13 @@ func (input *MessageBulkSend) Sanitize() MessageBulkSend {
 	return *input
 }
 
-// ToMessageSendParams converts MessageSend to services.MessageSendParams
-func (input *MessageBulkSend) ToMessageSendParams(userID entities.UserID, source string) []services.MessageSendParams {
+// ToMessageSendParams converts MessageBulkSend to a services.MessageSendParams with the same group ID for each of the valid recipients
+func (input *MessageBulkSend) ToMessageSendParams(userID entities.UserID, source string, recipients []string) (uuid.UUID, []services.MessageSendParams) {
 	from, _ := phonenumbers.Parse(input.From, phonenumbers.UNKNOWN_REGION)
 
-	var result []services.MessageSendParams
-	for _, to := range input.To {
+	groupID := uuid.New()
+	result := make([]services.MessageSendParams, 0, len(recipients))
+	for _, to := range recipients {
 		result = append(result, services.MessageSendParams{
 			Source:            source,
 			Owner:             from,
@@ -50,8 +52,9 @@ func (input *MessageBulkSend) ToMessageSendParams(userID entities.UserID, source
 			RequestReceivedAt: time.Now().UTC(),
 			Contact:           to,
 			Content:           input.Content,
+			GroupID:           &groupID,
 		})
 	}
 
-	return result
+	return groupID, result
 }
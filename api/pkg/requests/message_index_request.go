@@ -9,6 +9,7 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 
 	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
 )
 
 // MessageIndex is the payload fetching entities.Message sent between 2 numbers
@@ -18,6 +19,23 @@ type MessageIndex struct {
 	Owner   string `json:"owner" query:"owner"`
 	Query   string `json:"query" query:"query"`
 	Limit   string `json:"limit" query:"limit"`
+
+	MetadataKey   string `json:"metadata_key" query:"metadata_key"`
+	MetadataValue string `json:"metadata_value" query:"metadata_value"`
+
+	Category string `json:"category" query:"category"`
+
+	// Language filters received messages by the detected ISO 639-1 code e.g. en or unknown
+	Language string `json:"language" query:"language"`
+
+	// Starred fetches only the starred messages when it is true, owner and contact are optional in this case
+	Starred string `json:"starred" query:"starred"`
+
+	// LabelID fetches only the messages with the label, owner and contact are optional in this case
+	LabelID string `json:"label_id" query:"label_id"`
+
+	// ContactMatch is exact by default, contains or ends_with match the digits of the contact e.g. the last 7 digits for legacy messages with inconsistent country codes
+	ContactMatch string `json:"contact_match" query:"contact_match"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
@@ -27,10 +45,25 @@ func (input *MessageIndex) Sanitize() MessageIndex {
 	}
 
 	input.Query = strings.TrimSpace(input.Query)
+	input.MetadataKey = strings.TrimSpace(input.MetadataKey)
+	input.Category = strings.ToLower(strings.TrimSpace(input.Category))
+	input.Language = strings.ToLower(strings.TrimSpace(input.Language))
+	input.Starred = strings.ToLower(strings.TrimSpace(input.Starred))
+	input.LabelID = strings.TrimSpace(input.LabelID)
 
 	input.Owner = input.sanitizeAddress(input.Owner)
 	input.Contact = input.sanitizeAddress(input.Contact)
 
+	input.ContactMatch = strings.ToLower(strings.TrimSpace(input.ContactMatch))
+	if input.contactMatch().IsPartial() {
+		input.Contact = strings.Map(func(char rune) rune {
+			if char >= '0' && char <= '9' {
+				return char
+			}
+			return -1
+		}, input.Contact)
+	}
+
 	input.Skip = strings.TrimSpace(input.Skip)
 	if input.Skip == "" {
 		input.Skip = "0"
@@ -47,10 +80,42 @@ func (input *MessageIndex) ToGetParams(userID entities.UserID) services.MessageG
 			Query: input.Query,
 			Limit: input.getInt(input.Limit),
 		},
-		UserID:  userID,
-		Owner:   input.Owner,
-		Contact: input.Contact,
+		UserID:   userID,
+		Owner:    input.Owner,
+		Contact:  input.Contact,
+		Metadata: input.metadata(),
+		Category: entities.MessageCategory(input.Category),
+		Language: input.Language,
+		Starred:  input.Starred == "true",
+		LabelID:  input.labelID(),
+
+		ContactMatch: input.contactMatch(),
+	}
+}
+
+// labelID returns the ID of the label filter of the request, it is nil when it is not set
+func (input *MessageIndex) labelID() *uuid.UUID {
+	if input.LabelID == "" {
+		return nil
+	}
+	labelID := uuid.MustParse(input.LabelID)
+	return &labelID
+}
+
+// contactMatch returns how the contact is matched, it is an exact match when it is not set
+func (input *MessageIndex) contactMatch() repositories.ContactMatch {
+	if input.ContactMatch == "" {
+		return repositories.ContactMatchExact
+	}
+	return repositories.ContactMatch(input.ContactMatch)
+}
+
+// metadata returns the metadata filter of the request
+func (input *MessageIndex) metadata() entities.MessageMetadata {
+	if input.MetadataKey == "" {
+		return nil
 	}
+	return entities.MessageMetadata{input.MetadataKey: input.MetadataValue}
 }
 
 // getLimit gets the take as a string
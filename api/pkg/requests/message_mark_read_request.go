@@ -0,0 +1,36 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MessageMarkRead is the payload for marking many messages as read with one request
+type MessageMarkRead struct {
+	request
+
+	// MessageIDs are the IDs of the messages which are marked as read, IDs of messages which do not belong to the user are skipped
+	MessageIDs []string `json:"message_ids" example:"32343a19-da5e-4b1b-a767-3298a73703ca,32343a19-da5e-4b1b-a767-3298a73703cb"`
+}
+
+// Sanitize sets defaults to MessageMarkRead
+func (input *MessageMarkRead) Sanitize() MessageMarkRead {
+	var ids []string
+	for _, id := range input.MessageIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			ids = append(ids, strings.ToLower(id))
+		}
+	}
+	input.MessageIDs = input.removeStringDuplicates(ids)
+	return *input
+}
+
+// MessageUUIDs converts the MessageIDs of MessageMarkRead to uuid.UUID
+func (input *MessageMarkRead) MessageUUIDs() []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(input.MessageIDs))
+	for _, id := range input.MessageIDs {
+		ids = append(ids, uuid.MustParse(id))
+	}
+	return ids
+}
@@ -0,0 +1,42 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessagePoll is the payload for waiting for new entities.Message
+type MessagePoll struct {
+	request
+	// Since is the cursor returned by the previous poll, it defaults to the current time
+	Since string `json:"since" query:"since"`
+	Limit string `json:"limit" query:"limit"`
+}
+
+// Sanitize sets defaults to MessagePoll
+func (input *MessagePoll) Sanitize() MessagePoll {
+	input.Since = strings.TrimSpace(input.Since)
+	if input.Since == "" {
+		input.Since = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+
+	if strings.TrimSpace(input.Limit) == "" {
+		input.Limit = "20"
+	}
+	return *input
+}
+
+// ToPollParams converts MessagePoll into services.MessagePollParams
+func (input *MessagePoll) ToPollParams(userID entities.UserID, keepAlive func() error) services.MessagePollParams {
+	since, sinceID, _ := services.ParseMessagePollCursor(input.Since)
+	return services.MessagePollParams{
+		UserID:    userID,
+		Since:     since,
+		SinceID:   sinceID,
+		Limit:     input.getInt(input.Limit),
+		KeepAlive: keepAlive,
+	}
+}
@@ -0,0 +1,69 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessageReact is the payload for sending an emoji reaction to a message over RCS
+type MessageReact struct {
+	request
+
+	// Emoji is the reaction which is sent to the contact e.g. 👍
+	Emoji string `json:"emoji" example:"👍"`
+
+	MessageID string `json:"messageID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to MessageReact
+func (input *MessageReact) Sanitize() MessageReact {
+	input.MessageID = input.sanitizeMessageID(input.MessageID)
+	input.Emoji = strings.TrimSpace(input.Emoji)
+	return *input
+}
+
+// ToParams converts MessageReact to services.MessageReactParams
+func (input *MessageReact) ToParams(source string) services.MessageReactParams {
+	return services.MessageReactParams{
+		Emoji:  input.Emoji,
+		Source: source,
+	}
+}
+
+// MessageReaction is the payload sent by the phone when the contact has reacted to a message over RCS
+type MessageReaction struct {
+	request
+
+	// Emoji is the reaction which the contact sent e.g. 👍
+	Emoji string `json:"emoji" example:"👍"`
+
+	// Timestamp is the time when the contact reacted to the message, the current time is used when it is not set
+	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// Supported is false when the contact does not support RCS reactions, the message is not changed
+	Supported *bool `json:"supported" example:"true"`
+
+	MessageID string `json:"messageID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to MessageReaction
+func (input *MessageReaction) Sanitize() MessageReaction {
+	input.MessageID = input.sanitizeMessageID(input.MessageID)
+	input.Emoji = strings.TrimSpace(input.Emoji)
+	if input.Timestamp.IsZero() {
+		input.Timestamp = time.Now().UTC()
+	}
+	return *input
+}
+
+// ToParams converts MessageReaction to services.MessageReactionParams
+func (input *MessageReaction) ToParams(source string) services.MessageReactionParams {
+	return services.MessageReactionParams{
+		Emoji:     input.Emoji,
+		Timestamp: input.Timestamp,
+		Supported: input.Supported == nil || *input.Supported,
+		Source:    source,
+	}
+}
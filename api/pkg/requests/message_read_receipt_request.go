@@ -0,0 +1,40 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// MessageReadReceipt is the payload sent by the phone when the recipient has read a message
+type MessageReadReceipt struct {
+	request
+
+	// Timestamp is the time when the recipient read the message, the current time is used when it is not set
+	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+
+	// Supported is false when the carrier of the recipient does not support read receipts, the message is not changed
+	Supported *bool `json:"supported" example:"true"`
+
+	MessageID string `json:"messageID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to MessageReadReceipt
+func (input *MessageReadReceipt) Sanitize() MessageReadReceipt {
+	input.MessageID = input.sanitizeMessageID(input.MessageID)
+	if input.Timestamp.IsZero() {
+		input.Timestamp = time.Now().UTC()
+	}
+	return *input
+}
+
+// ToParams converts MessageReadReceipt to services.MessageReadReceiptParams
+func (input *MessageReadReceipt) ToParams(source string) services.MessageReadReceiptParams {
+	return services.MessageReadReceiptParams{
+		MessageID: uuid.MustParse(input.MessageID),
+		Timestamp: input.Timestamp,
+		Supported: input.Supported == nil || *input.Supported,
+		Source:    source,
+	}
+}
@@ -1,6 +1,7 @@
 package requests
 
 import (
+	"encoding/base64"
 	"strings"
 	"time"
 
@@ -11,18 +12,30 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/services"
 )
 
-// MessageReceive is the payload for sending and SMS message
+// MessageReceive is the payload which a phone sends when it receives an SMS message
 type MessageReceive struct {
 	request
-	From    string `json:"from" example:"+18005550199"`
+	// From is the phone number or the sender ID of the contact who sent the message
+	From string `json:"from" example:"+18005550199"`
+	// To is the phone number of the phone which received the message in the E.164 format
 	To      string `json:"to" example:"+18005550100"`
 	Content string `json:"content" example:"This is a sample text message received on a phone"`
 	// Encrypted is used to determine if the content is end-to-end encrypted. Make sure to set the encryption key on the httpSMS mobile app
 	Encrypted bool `json:"encrypted" example:"false"`
 	// SIM card that received the message
 	SIM entities.SIM `json:"sim" example:"SIM1"`
-	// Timestamp is the time when the event was emitted, Please send the timestamp in UTC with as much precision as possible
+	// Timestamp is the time when the phone received the message, Please send the timestamp in UTC with as much precision as possible.
+	// The same timestamp must be sent when the request is retried so that the message is not stored twice
 	Timestamp time.Time `json:"timestamp" example:"2022-06-05T14:26:09.527976+03:00"`
+	// Attachments are the media files received in an MMS message
+	Attachments []MessageReceiveAttachment `json:"attachments"`
+}
+
+// MessageReceiveAttachment is a media file received in an MMS message
+type MessageReceiveAttachment struct {
+	ContentType string `json:"content_type" example:"image/jpeg"`
+	// Content is the base64 encoded content of the media file
+	Content string `json:"content" example:"iVBORw0KGgoAAAANSUhEUgAAAAEAAAABCAYAAAAfFcSJAAAADUlEQVR42mNkYPhfDwAChwGA60e6kgAAAABJRU5ErkJggg=="`
 }
 
 // Sanitize sets defaults to MessageReceive
@@ -32,12 +45,25 @@ func (input *MessageReceive) Sanitize() MessageReceive {
 	if strings.TrimSpace(string(input.SIM)) == "" || input.SIM == ("DEFAULT") {
 		input.SIM = entities.SIM1
 	}
+	for index := range input.Attachments {
+		input.Attachments[index].ContentType = strings.ToLower(strings.TrimSpace(input.Attachments[index].ContentType))
+	}
 	return *input
 }
 
 // ToMessageReceiveParams converts MessageReceive to services.MessageReceiveParams
 func (input *MessageReceive) ToMessageReceiveParams(userID entities.UserID, source string) *services.MessageReceiveParams {
 	phone, _ := phonenumbers.Parse(input.To, phonenumbers.UNKNOWN_REGION)
+
+	var attachments []services.MessageAttachmentParams
+	for _, attachment := range input.Attachments {
+		content, _ := base64.StdEncoding.DecodeString(attachment.Content)
+		attachments = append(attachments, services.MessageAttachmentParams{
+			ContentType: attachment.ContentType,
+			Content:     content,
+		})
+	}
+
 	return &services.MessageReceiveParams{
 		Source:    source,
 		Contact:   input.From,
@@ -47,5 +73,7 @@ func (input *MessageReceive) ToMessageReceiveParams(userID entities.UserID, sour
 		Owner:     *phone,
 		Content:   input.Content,
 		SIM:       input.SIM,
+
+		Attachments: attachments,
 	}
 }
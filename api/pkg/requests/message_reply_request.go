@@ -0,0 +1,42 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nyaruka/phonenumbers"
+
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// MessageReply is the payload for replying to a received message with its reply token
+type MessageReply struct {
+	request
+
+	// ReplyToken is the reply_token field of the message.phone.received event
+	ReplyToken string `json:"reply_token" example:"eyJ1aWQiOiJXQjd..."`
+
+	Content string `json:"content" example:"Thanks for your message"`
+}
+
+// Sanitize sets defaults to MessageReply
+func (input *MessageReply) Sanitize() MessageReply {
+	input.ReplyToken = strings.TrimSpace(input.ReplyToken)
+	return *input
+}
+
+// ToMessageSendParams converts MessageReply to services.MessageSendParams which replies to the message of the claims
+func (input *MessageReply) ToMessageSendParams(claims *services.ReplyTokenClaims, source string) services.MessageSendParams {
+	owner, _ := phonenumbers.Parse(claims.Owner, phonenumbers.UNKNOWN_REGION)
+	id := claims.ReplyMessageID()
+	return services.MessageSendParams{
+		ID:                &id,
+		Source:            source,
+		Owner:             owner,
+		UserID:            claims.UserID,
+		RequestReceivedAt: time.Now().UTC(),
+		Contact:           claims.Contact,
+		Content:           input.Content,
+		InReplyTo:         &claims.MessageID,
+	}
+}
@@ -0,0 +1,101 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// MessageRuleStore is the payload for creating a new entities.MessageRule
+type MessageRuleStore struct {
+	request
+	Name string `json:"name" example:"Forward orders"`
+
+	// Position orders the evaluation of the rules, the rule with the lowest position is evaluated first
+	Position uint `json:"position" example:"1"`
+
+	// Enabled is true by default
+	Enabled        *bool `json:"enabled" example:"true" validate:"optional"`
+	StopProcessing bool  `json:"stop_processing" example:"false"`
+
+	// Sender matches the phone number of the contact who sent the message, it matches every contact when it is empty
+	Sender string `json:"sender" example:"+18005550100" validate:"optional"`
+
+	// Recipient matches the phone number of the phone which received the message, it matches every phone when it is empty
+	Recipient string `json:"recipient" example:"+18005550199" validate:"optional"`
+
+	// ContentPattern is a regular expression which matches the content of the message, it matches every message when it is empty
+	ContentPattern string `json:"content_pattern" example:"(?i)^order" validate:"optional"`
+
+	// Schedule is the weekly hours in the timezone of the receiving phone when the rule matches, it matches at any time when it is empty
+	Schedule entities.PhoneBusinessHoursSchedule `json:"schedule" validate:"optional"`
+
+	// WebhookID is the webhook which the received message is forwarded to
+	WebhookID string `json:"webhook_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb" validate:"optional"`
+
+	// ReplyContent is sent to the contact who sent the message, {{contact}} is replaced with the phone number of the contact
+	ReplyContent string `json:"reply_content" example:"Thanks, we have received your order" validate:"optional"`
+
+	// LabelID is the label which is attached to the received message
+	LabelID string `json:"label_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb" validate:"optional"`
+}
+
+// Sanitize sets defaults to MessageRuleStore
+func (input *MessageRuleStore) Sanitize() MessageRuleStore {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Enabled == nil {
+		enabled := true
+		input.Enabled = &enabled
+	}
+
+	input.Sender = input.sanitizeAddress(input.Sender)
+	input.Recipient = input.sanitizeAddress(input.Recipient)
+	input.WebhookID = strings.TrimSpace(input.WebhookID)
+	input.LabelID = strings.TrimSpace(input.LabelID)
+	input.ReplyContent = strings.TrimSpace(input.ReplyContent)
+	if len(input.Schedule) > 0 {
+		input.Schedule = *input.sanitizeBusinessHours(input.Schedule)
+	}
+	return *input
+}
+
+// ToStoreParams converts MessageRuleStore to services.MessageRuleStoreParams
+func (input *MessageRuleStore) ToStoreParams(user entities.AuthUser) *services.MessageRuleStoreParams {
+	var schedule entities.PhoneBusinessHoursSchedule
+	if len(input.Schedule) > 0 {
+		schedule = input.Schedule
+	}
+
+	return &services.MessageRuleStoreParams{
+		UserID:         user.ID,
+		Name:           input.Name,
+		Position:       input.Position,
+		Enabled:        *input.Enabled,
+		StopProcessing: input.StopProcessing,
+		Sender:         input.sanitizeStringPointer(input.Sender),
+		Recipient:      input.sanitizeStringPointer(input.Recipient),
+		ContentPattern: input.optionalString(input.ContentPattern),
+		Schedule:       schedule,
+		WebhookID:      input.optionalUUID(input.WebhookID),
+		ReplyContent:   input.sanitizeStringPointer(input.ReplyContent),
+		LabelID:        input.optionalUUID(input.LabelID),
+	}
+}
+
+// optionalString returns nil when the value is empty, the value is not trimmed because the spaces of a regular expression are significant
+func (input *MessageRuleStore) optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
+
+func (input *MessageRuleStore) optionalUUID(value string) *uuid.UUID {
+	if value == "" {
+		return nil
+	}
+	id := uuid.MustParse(value)
+	return &id
+}
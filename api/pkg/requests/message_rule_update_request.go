@@ -0,0 +1,27 @@
+package requests
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// MessageRuleUpdate is the payload for updating an entities.MessageRule
+type MessageRuleUpdate struct {
+	MessageRuleStore
+	MessageRuleID string `json:"messageRuleID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to MessageRuleUpdate
+func (input *MessageRuleUpdate) Sanitize() MessageRuleUpdate {
+	input.MessageRuleStore.Sanitize()
+	return *input
+}
+
+// ToUpdateParams converts MessageRuleUpdate to services.MessageRuleUpdateParams
+func (input *MessageRuleUpdate) ToUpdateParams(user entities.AuthUser) *services.MessageRuleUpdateParams {
+	return &services.MessageRuleUpdateParams{
+		MessageRuleStoreParams: *input.ToStoreParams(user),
+		RuleID:                 uuid.MustParse(input.MessageRuleID),
+	}
+}
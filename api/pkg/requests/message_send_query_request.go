@@ -0,0 +1,18 @@
+package requests
+
+// MessageSendQuery are the query parameters of GET /v1/messages/send for devices which can only send GET requests
+type MessageSendQuery struct {
+	request
+	From    string `json:"from" query:"from"`
+	To      string `json:"to" query:"to"`
+	Content string `json:"content" query:"content"`
+}
+
+// ToMessageSend converts MessageSendQuery to MessageSend so that it is validated and sent like the payload of POST /v1/messages/send
+func (input *MessageSendQuery) ToMessageSend() MessageSend {
+	return MessageSend{
+		From:    input.From,
+		To:      input.To,
+		Content: input.Content,
+	}
+}
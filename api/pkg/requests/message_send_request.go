@@ -1,11 +1,15 @@
 package requests
 
 import (
+	"encoding/json"
+	"slices"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 
+	"github.com/google/uuid"
 	"github.com/nyaruka/phonenumbers"
 
 	"github.com/NdoleStudio/httpsms/pkg/services"
@@ -14,38 +18,156 @@ import (
 // MessageSend is the payload for sending and SMS message
 type MessageSend struct {
 	request
-	From    string `json:"from" example:"+18005550199"`
+	// From is the phone number of the phone, or the alphanumeric sender ID e.g. MyBrand of a phone whose carrier applies it to the messages of the SIM.
+	// The alphanumeric sender ID only selects the phone, the app cannot change the sender which the recipient sees.
+	// The phone is selected by the country routes of the user using the country of the recipient when both from and from_group are empty
+	From string `json:"from" example:"+18005550199"`
+	// FromGroup is an optional group of phones which sends the message instead of from, the phone is selected by the strategy of the group
+	FromGroup string `json:"from_group" example:"warehouse-1" validate:"optional"`
+	// To is the phone number of the recipient, it can also be an array of up to 100 phone numbers which are each sent the same content
 	To      string `json:"to" example:"+18005550100"`
 	Content string `json:"content" example:"This is a sample text message"`
 
+	// Recipients are the phone numbers in the to field when it is an array
+	Recipients []string `json:"-"`
+
 	// Encrypted is used to determine if the content is end-to-end encrypted. Make sure to set the encryption key on the httpSMS mobile app
 	Encrypted bool `json:"encrypted" example:"false"`
 	// RequestID is an optional parameter used to track a request from the client's perspective
 	RequestID string `json:"request_id" example:"153554b5-ae44-44a0-8f4f-7bbac5657ad4" validate:"optional"`
 	// SendAt is an optional parameter used to schedule a message to be sent at a later time
 	SendAt *time.Time `json:"send_at" example:"2022-06-05T14:26:09.527976+03:00" validate:"optional"`
+	// SendAtTimezone is an optional parameter which is either "user" or "phone", the date and time of send_at are then used in the timezone of the user or the phone and its UTC offset is ignored
+	SendAtTimezone string `json:"send_at_timezone" example:"phone" validate:"optional"`
+	// Metadata is an optional map of key/value pairs which is stored with the message and returned in webhook events
+	Metadata map[string]string `json:"metadata" example:"campaign:spring_sale" validate:"optional"`
+	// ValidityPeriod is an optional number of seconds the carrier should attempt to deliver the message before giving up, it must be between 300 (5 minutes) and 2419200 (4 weeks)
+	ValidityPeriod *uint `json:"validity_period" example:"600" validate:"optional"`
+	// InReplyTo is an optional ID of a message in the same conversation which this message is a reply to
+	InReplyTo string `json:"in_reply_to" example:"32343a19-da5e-4b1b-a767-3298a73703cb" validate:"optional"`
+	// ID is an optional client generated UUID of the message, the existing message is returned when a message with this ID has already been sent
+	ID string `json:"id" example:"b0f3a8d2-3c4e-4c1b-9d2a-6f1e2b7c8d9e" validate:"optional"`
+	// ProviderFallback is an optional parameter which sends the message with your third-party SMS provider e.g. twilio if no phone picks it up within the timeout of the provider
+	ProviderFallback bool `json:"provider_fallback" example:"false" validate:"optional"`
+	// SkipSignature is an optional parameter which sends the content without the signature of the phone
+	SkipSignature bool `json:"skip_signature" example:"false" validate:"optional"`
+}
+
+// UnmarshalJSON decodes the to field into Recipients when it is an array of phone numbers
+func (input *MessageSend) UnmarshalJSON(data []byte) error {
+	type messageSend MessageSend
+	payload := struct {
+		*messageSend
+		To json.RawMessage `json:"to"`
+	}{messageSend: (*messageSend)(input)}
+
+	if err := json.Unmarshal(data, &payload); err != nil {
+		return err
+	}
+
+	if len(payload.To) > 0 && payload.To[0] == '[' {
+		return json.Unmarshal(payload.To, &input.Recipients)
+	}
+
+	if len(payload.To) > 0 && string(payload.To) != "null" {
+		return json.Unmarshal(payload.To, &input.To)
+	}
+	return nil
+}
+
+// IsFanOut checks if the message is sent to an array of recipients
+func (input *MessageSend) IsFanOut() bool {
+	return input.Recipients != nil
 }
 
 // Sanitize sets defaults to MessageReceive
 func (input *MessageSend) Sanitize() MessageSend {
+	if input.IsFanOut() {
+		recipients := make([]string, 0, len(input.Recipients))
+		for _, recipient := range input.Recipients {
+			if recipient = input.sanitizeAddress(recipient); !slices.Contains(recipients, recipient) {
+				recipients = append(recipients, recipient)
+			}
+		}
+		input.Recipients = recipients
+	}
+
 	input.To = input.sanitizeAddress(input.To)
 	input.RequestID = strings.TrimSpace(input.RequestID)
 	input.From = input.sanitizeAddress(input.From)
+	input.FromGroup = strings.TrimSpace(input.FromGroup)
+	input.Metadata = input.sanitizeMetadata(input.Metadata)
+	input.InReplyTo = strings.TrimSpace(input.InReplyTo)
+	input.ID = strings.ToLower(strings.TrimSpace(input.ID))
+	input.SendAtTimezone = strings.ToLower(strings.TrimSpace(input.SendAtTimezone))
 	return *input
 }
 
+// IsAlphanumericSender checks if the from field is an alphanumeric sender ID instead of a phone number
+func (input *MessageSend) IsAlphanumericSender() bool {
+	return strings.IndexFunc(input.From, unicode.IsLetter) != -1
+}
+
 // ToMessageSendParams converts MessageSend to services.MessageSendParams
 func (input *MessageSend) ToMessageSendParams(userID entities.UserID, source string) services.MessageSendParams {
-	from, _ := phonenumbers.Parse(input.From, phonenumbers.UNKNOWN_REGION)
+	var senderID *string
+	if input.IsAlphanumericSender() {
+		senderID = &input.From
+	}
+
+	var validityPeriod *time.Duration
+	if input.ValidityPeriod != nil {
+		duration := time.Duration(*input.ValidityPeriod) * time.Second
+		validityPeriod = &duration
+	}
+
+	var inReplyTo *uuid.UUID
+	if id, err := uuid.Parse(input.InReplyTo); err == nil {
+		inReplyTo = &id
+	}
+
+	var messageID *uuid.UUID
+	if id, err := uuid.Parse(input.ID); err == nil {
+		messageID = &id
+	}
+
+	// the owner is left empty without a from number so the phone is selected by the country routes of the user
+	var from *phonenumbers.PhoneNumber
+	if input.From != "" {
+		from, _ = phonenumbers.Parse(input.From, phonenumbers.UNKNOWN_REGION)
+	}
+
 	return services.MessageSendParams{
+		SenderID:          senderID,
 		Source:            source,
 		Owner:             from,
 		Encrypted:         input.Encrypted,
 		RequestID:         input.sanitizeStringPointer(input.RequestID),
 		UserID:            userID,
 		SendAt:            input.SendAt,
+		SendAtTimezone:    services.MessageSendAtTimezone(input.SendAtTimezone),
 		RequestReceivedAt: time.Now().UTC(),
 		Contact:           input.sanitizeAddress(input.To),
 		Content:           input.Content,
+		Metadata:          input.Metadata,
+		ValidityPeriod:    validityPeriod,
+		InReplyTo:         inReplyTo,
+		ID:                messageID,
+		PhoneGroup:        input.sanitizeStringPointer(input.FromGroup),
+		ProviderFallback:  input.ProviderFallback,
+		SkipSignature:     input.SkipSignature,
+	}
+}
+
+// ToFanOutParams converts MessageSend to a services.MessageSendParams for each recipient with the same group ID
+func (input *MessageSend) ToFanOutParams(userID entities.UserID, source string, recipients []string) (uuid.UUID, []services.MessageSendParams) {
+	groupID := uuid.New()
+	result := make([]services.MessageSendParams, 0, len(recipients))
+	for _, recipient := range recipients {
+		params := input.ToMessageSendParams(userID, source)
+		params.Contact = recipient
+		params.GroupID = &groupID
+		result = append(result, params)
 	}
+	return groupID, result
 }
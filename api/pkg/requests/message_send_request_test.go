@@ -0,0 +1,59 @@
+package requests
+
+import (
+	"testing"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageSend_ToMessageSendParams(t *testing.T) {
+	userID := entities.UserID("user-id")
+
+	tests := []struct {
+		name   string
+		input  MessageSend
+		owner  string
+		routed bool
+	}{
+		{name: "a message without a from number is routed by country", input: MessageSend{To: "+18005550100", Content: "hello"}, routed: true},
+		{name: "a message with a from number is sent by the phone", input: MessageSend{From: "+18005550199", To: "+18005550100", Content: "hello"}, owner: "+18005550199", routed: false},
+		{name: "a message with an alphanumeric sender ID is not routed by country", input: MessageSend{From: "MyBrand", To: "+18005550100", Content: "hello"}, routed: false},
+		{name: "a message with a from group is not routed by country", input: MessageSend{FromGroup: "warehouse-1", To: "+18005550100", Content: "hello"}, routed: false},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+			input := tt.input.Sanitize()
+
+			// Act
+			params := input.ToMessageSendParams(userID, "test")
+
+			// Assert
+			assert.Equal(t, tt.routed, params.IsRouted())
+			if tt.owner != "" {
+				assert.Equal(t, tt.owner, phonenumbers.Format(params.Owner, phonenumbers.E164))
+			}
+		})
+	}
+}
+
+func TestMessageSend_ToFanOutParams(t *testing.T) {
+	// Setup
+	input := MessageSend{Content: "hello"}
+	input = input.Sanitize()
+
+	// Act
+	_, params := input.ToFanOutParams(entities.UserID("user-id"), "test", []string{"+18005550100", "+447700900123"})
+
+	// Assert
+	assert.Len(t, params, 2)
+	for _, param := range params {
+		assert.Nil(t, param.Owner)
+		assert.True(t, param.IsRouted())
+	}
+}
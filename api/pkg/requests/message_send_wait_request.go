@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"strings"
+	"time"
+)
+
+// MessageSendWait are the query parameters of a send request which waits for the push notification of the message to be accepted before responding
+type MessageSendWait struct {
+	request
+	Wait string `json:"wait" query:"wait"`
+	// Timeout is the maximum duration to wait for the push notification e.g. 5s, it defaults to 5 seconds
+	Timeout string `json:"timeout" query:"timeout"`
+}
+
+// Sanitize sets defaults to MessageSendWait
+func (input *MessageSendWait) Sanitize() MessageSendWait {
+	input.Wait = input.sanitizeBool(input.Wait)
+	input.Timeout = strings.TrimSpace(input.Timeout)
+	if input.Timeout == "" {
+		input.Timeout = "5s"
+	}
+	return *input
+}
+
+// IsWaiting checks if the send request waits for the push notification
+func (input *MessageSendWait) IsWaiting() bool {
+	return input.getBool(input.Wait)
+}
+
+// TimeoutDuration returns the Timeout as a time.Duration
+func (input *MessageSendWait) TimeoutDuration() time.Duration {
+	timeout, _ := time.ParseDuration(input.Timeout)
+	return timeout
+}
@@ -0,0 +1,26 @@
+package requests
+
+import (
+	"net/url"
+)
+
+// MessageThreadDeleteMessages is the payload for deleting all the messages between an owner and a contact
+type MessageThreadDeleteMessages struct {
+	request
+	Owner   string `json:"ownerNumber" swaggerignore:"true"`   // used internally for validation
+	Contact string `json:"contactNumber" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize decodes the phone numbers from the path and normalizes them like the owner and the contact of a stored message
+func (input *MessageThreadDeleteMessages) Sanitize() MessageThreadDeleteMessages {
+	if owner, err := url.PathUnescape(input.Owner); err == nil {
+		input.Owner = owner
+	}
+	if contact, err := url.PathUnescape(input.Contact); err == nil {
+		input.Contact = contact
+	}
+
+	input.Owner = input.sanitizeAddress(input.Owner)
+	input.Contact = input.sanitizeContact(input.Owner, input.Contact)
+	return *input
+}
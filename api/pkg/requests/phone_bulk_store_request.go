@@ -0,0 +1,76 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneBulkStore is the payload for registering multiple phones at once
+type PhoneBulkStore struct {
+	request
+	Phones []PhoneBulkStorePhone `json:"phones"`
+}
+
+// PhoneBulkStorePhone is a phone in the PhoneBulkStore payload
+type PhoneBulkStorePhone struct {
+	PhoneNumber string `json:"phone_number" example:"+18005550199"`
+	FcmToken    string `json:"fcm_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzd....."`
+
+	// Group is an optional label used to organize phones in a fleet e.g. warehouse-1
+	Group *string `json:"group" example:"warehouse-1" validate:"optional"`
+
+	// GroupPriority is used by the failover strategy of the group, the online phone with the highest priority sends the messages of the group
+	GroupPriority *uint `json:"group_priority" example:"10" validate:"optional"`
+
+	// ExternalID is an optional ID of the phone in an external system e.g. an inventory, it must be unique for each of your phones
+	ExternalID *string `json:"external_id" example:"inventory-1042" validate:"optional"`
+
+	// SIM is the SIM slot of the phone in case the phone has more than 1 SIM slot
+	SIM string `json:"sim" example:"SIM1"`
+}
+
+// Sanitize sets defaults to PhoneBulkStore
+func (input *PhoneBulkStore) Sanitize() PhoneBulkStore {
+	for index := range input.Phones {
+		phone := &input.Phones[index]
+		phone.FcmToken = strings.TrimSpace(phone.FcmToken)
+		phone.PhoneNumber = input.sanitizeAddress(phone.PhoneNumber)
+		phone.SIM = input.sanitizeSIM(phone.SIM)
+		if phone.Group != nil {
+			phone.Group = input.sanitizeStringPointer(*phone.Group)
+		}
+		if phone.ExternalID != nil {
+			phone.ExternalID = input.sanitizeStringPointer(*phone.ExternalID)
+		}
+	}
+	return *input
+}
+
+// ToUpsertParams converts PhoneBulkStore to services.PhoneUpsertParams
+func (input *PhoneBulkStore) ToUpsertParams(user entities.AuthUser, source string) []*services.PhoneUpsertParams {
+	result := make([]*services.PhoneUpsertParams, 0, len(input.Phones))
+	for _, phone := range input.Phones {
+		phoneNumber, _ := phonenumbers.Parse(phone.PhoneNumber, phonenumbers.UNKNOWN_REGION)
+
+		var fcmToken *string
+		if phone.FcmToken != "" {
+			fcmToken = &phone.FcmToken
+		}
+
+		result = append(result, &services.PhoneUpsertParams{
+			Source:        source,
+			PhoneNumber:   phoneNumber,
+			FcmToken:      fcmToken,
+			Group:         phone.Group,
+			GroupPriority: phone.GroupPriority,
+			ExternalID:    phone.ExternalID,
+			UserID:        user.ID,
+			SIM:           entities.SIM(phone.SIM),
+		})
+	}
+	return result
+}
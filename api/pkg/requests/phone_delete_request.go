@@ -1,6 +1,8 @@
 package requests
 
 import (
+	"strings"
+
 	"github.com/google/uuid"
 )
 
@@ -8,6 +10,18 @@ import (
 type PhoneDelete struct {
 	request
 	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	// ReassignTo is a phone number or a group of phones which will send the pending messages of the deleted phone
+	ReassignTo string `json:"reassign_to" query:"reassign_to"`
+}
+
+// Sanitize sets defaults to PhoneDelete
+func (input *PhoneDelete) Sanitize() PhoneDelete {
+	input.ReassignTo = strings.TrimSpace(input.ReassignTo)
+	if strings.HasPrefix(input.ReassignTo, "+") {
+		input.ReassignTo = input.sanitizeAddress(input.ReassignTo)
+	}
+	return *input
 }
 
 // PhoneIDUuid returns the phoneID as uuid.UUID
@@ -0,0 +1,33 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// PhoneFcmTokenUpdate is the payload for updating the FCM token of a phone
+type PhoneFcmTokenUpdate struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	FcmToken string `json:"fcm_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzd....."`
+}
+
+// Sanitize sets defaults to PhoneFcmTokenUpdate
+func (input *PhoneFcmTokenUpdate) Sanitize() PhoneFcmTokenUpdate {
+	input.FcmToken = strings.TrimSpace(input.FcmToken)
+	return *input
+}
+
+// ToUpdateParams converts PhoneFcmTokenUpdate to services.PhoneFcmTokenUpdateParams
+func (input *PhoneFcmTokenUpdate) ToUpdateParams(user entities.AuthUser, source string) *services.PhoneFcmTokenUpdateParams {
+	return &services.PhoneFcmTokenUpdateParams{
+		UserID:   user.ID,
+		PhoneID:  uuid.MustParse(input.PhoneID),
+		FcmToken: input.FcmToken,
+		Source:   source,
+	}
+}
@@ -0,0 +1,37 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneGroupUpsert is the payload for setting the strategy of a group of phones
+type PhoneGroupUpsert struct {
+	request
+	// Strategy is either round_robin which spreads the messages over the online phones or failover which sends from the online phone with the highest group_priority
+	Strategy string `json:"strategy" example:"failover"`
+
+	// ProviderFallback sends the messages of the group with your third-party SMS provider if no phone picks them up, it is not changed when it is null
+	ProviderFallback *bool `json:"provider_fallback" example:"false"`
+
+	Group string `json:"group" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to PhoneGroupUpsert
+func (input *PhoneGroupUpsert) Sanitize() PhoneGroupUpsert {
+	input.Strategy = strings.ToLower(strings.TrimSpace(input.Strategy))
+	input.Group = strings.TrimSpace(input.Group)
+	return *input
+}
+
+// ToUpsertParams converts PhoneGroupUpsert to services.PhoneGroupUpsertParams
+func (input *PhoneGroupUpsert) ToUpsertParams(user entities.AuthUser) *services.PhoneGroupUpsertParams {
+	return &services.PhoneGroupUpsertParams{
+		UserID:           user.ID,
+		Name:             input.Group,
+		Strategy:         entities.PhoneGroupStrategy(input.Strategy),
+		ProviderFallback: input.ProviderFallback,
+	}
+}
@@ -12,6 +12,9 @@ type PhoneIndex struct {
 	Skip  string `json:"skip" query:"skip"`
 	Query string `json:"query" query:"query"`
 	Limit string `json:"limit" query:"limit"`
+
+	// ExternalID returns only the phone with the ID in an external system
+	ExternalID string `json:"external_id" query:"external_id"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
@@ -21,6 +24,7 @@ func (input *PhoneIndex) Sanitize() PhoneIndex {
 	}
 	input.Query = strings.TrimSpace(input.Query)
 	input.Skip = strings.TrimSpace(input.Skip)
+	input.ExternalID = strings.TrimSpace(input.ExternalID)
 	if input.Skip == "" {
 		input.Skip = "0"
 	}
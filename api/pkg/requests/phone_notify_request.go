@@ -0,0 +1,79 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneNotify is the payload for sending a control notification to a phone
+type PhoneNotify struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	// Type is the control notification which the httpSMS app interprets e.g. app_update or config_refresh
+	Type string `json:"type" example:"app_update"`
+
+	// Message is an optional text which the httpSMS app displays with the notification
+	Message string `json:"message" example:"Version 1.2.0 of httpSMS is available"`
+}
+
+// Sanitize sets defaults to PhoneNotify
+func (input *PhoneNotify) Sanitize() PhoneNotify {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+	input.Message = strings.TrimSpace(input.Message)
+	return *input
+}
+
+// ToNotifyParams converts PhoneNotify to services.PhoneNotifyParams
+func (input *PhoneNotify) ToNotifyParams(user entities.AuthUser, source string) *services.PhoneNotifyParams {
+	return &services.PhoneNotifyParams{
+		UserID:  user.ID,
+		Source:  source,
+		Type:    entities.PhoneControlType(input.Type),
+		Message: input.Message,
+		PhoneID: uuid.MustParse(input.PhoneID),
+	}
+}
+
+// PhoneNotifyAll is the payload for sending a control notification to all the phones of a user
+type PhoneNotifyAll struct {
+	request
+
+	// Type is the control notification which the httpSMS app interprets e.g. app_update or config_refresh
+	Type string `json:"type" example:"config_refresh"`
+
+	// Message is an optional text which the httpSMS app displays with the notification
+	Message string `json:"message" example:"Version 1.2.0 of httpSMS is available"`
+
+	// Group limits the notification to the phones of a group, all the phones are notified when it is empty
+	Group string `json:"group" example:"warehouse-1"`
+}
+
+// Sanitize sets defaults to PhoneNotifyAll
+func (input *PhoneNotifyAll) Sanitize() PhoneNotifyAll {
+	input.Type = strings.ToLower(strings.TrimSpace(input.Type))
+	input.Message = strings.TrimSpace(input.Message)
+	input.Group = strings.TrimSpace(input.Group)
+	return *input
+}
+
+// ToNotifyParams converts PhoneNotifyAll to services.PhoneNotifyParams
+func (input *PhoneNotifyAll) ToNotifyParams(user entities.AuthUser, source string) *services.PhoneNotifyParams {
+	var group *string
+	if input.Group != "" {
+		group = &input.Group
+	}
+
+	return &services.PhoneNotifyParams{
+		UserID:  user.ID,
+		Source:  source,
+		Type:    entities.PhoneControlType(input.Type),
+		Message: input.Message,
+		Group:   group,
+	}
+}
@@ -0,0 +1,233 @@
+package requests
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhonePatch is the payload for updating some fields of a phone, the fields which are omitted are not changed
+type PhonePatch struct {
+	request
+	patch
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	MessagesPerMinute *uint `json:"messages_per_minute" example:"1"`
+
+	// MessageExpirationSeconds is the duration in seconds after sending a message when it is considered to be expired.
+	MessageExpirationSeconds *uint `json:"message_expiration_seconds" example:"12345"`
+
+	// MaxSendAttempts is the number of attempts when sending an SMS message to handle the case where the phone is offline.
+	MaxSendAttempts *uint `json:"max_send_attempts" example:"2"`
+
+	// MissedCallAutoReply is removed when it is null or empty
+	MissedCallAutoReply *string `json:"missed_call_auto_reply" example:"e.g. This phone cannot receive calls. Please send an SMS instead."`
+
+	// AutoAckMessage is sent as a reply to every received message, {{contact}} is replaced with the phone number of the sender and it is removed when it is null or empty
+	AutoAckMessage *string `json:"auto_ack_message" example:"Message received from {{contact}}, thanks"`
+
+	// BatteryLowThreshold is the battery percentage below which the phone.battery_low event is fired
+	BatteryLowThreshold *uint `json:"battery_low_threshold" example:"20"`
+
+	// AlphanumericSenderID is removed when it is null or empty
+	AlphanumericSenderID *string `json:"alphanumeric_sender_id" example:"MyBrand"`
+
+	// DailyQuota is the maximum number of messages the phone can send per day, there is no limit when it is 0
+	DailyQuota *uint `json:"daily_quota" example:"100"`
+
+	// DailyQuotaTimezone is the timezone used to reset the daily quota at midnight e.g. Europe/Helsinki
+	DailyQuotaTimezone *string `json:"daily_quota_timezone" example:"Europe/Helsinki"`
+
+	// SIM is the SIM slot of the phone in case the phone has more than 1 SIM slot
+	SIM *string `json:"sim" example:"SIM1"`
+
+	// HeartbeatIntervalSeconds is the expected duration in seconds between the heartbeats of the phone
+	HeartbeatIntervalSeconds *uint `json:"heartbeat_interval_seconds" example:"900"`
+
+	// MaxConcurrentSends is the maximum number of messages which are pushed to the phone at the same time
+	MaxConcurrentSends *uint `json:"max_concurrent_sends" example:"10"`
+
+	// DeliveryReportTimeoutSeconds is the duration in seconds after which a sent message without a delivery report is marked as failed, 0 disables the timeout
+	DeliveryReportTimeoutSeconds *uint `json:"delivery_report_timeout_seconds" example:"86400"`
+
+	// AllowedRecipients are the only phone numbers which the phone can send messages to, the restriction is removed when it is null or empty
+	AllowedRecipients *[]string `json:"allowed_recipients" example:"+18005550100"`
+
+	// Timezone is the IANA timezone of the phone e.g. Europe/Helsinki which is used for scheduled messages sent in the timezone of the phone
+	Timezone *string `json:"timezone" example:"Europe/Helsinki"`
+
+	// BusinessHours is the weekly schedule of the phone in its Timezone, the business hours are removed when it is null or empty
+	BusinessHours *entities.PhoneBusinessHoursSchedule `json:"business_hours"`
+
+	// OutOfOfficeMessage is sent at most once a day to each contact outside the business hours, it is removed when it is null or empty
+	OutOfOfficeMessage *string `json:"out_of_office_message" example:"We're closed, we'll reply on {{opens_on}} at {{opens_at}}"`
+
+	// Signature is appended to the messages sent from the phone, it is removed when it is null or empty
+	Signature *string `json:"signature" example:"- Sent via httpSMS"`
+
+	// ExternalID is the ID of the phone in an external system e.g. an inventory, it is removed when it is null or empty
+	ExternalID *string `json:"external_id" example:"inventory-1042"`
+
+	// Group is the label used to organize phones in a fleet, it is removed when it is null or empty
+	Group *string `json:"group" example:"warehouse-1"`
+
+	// GroupPriority is used by the failover strategy of the group, the online phone with the highest priority sends the messages of the group
+	GroupPriority *uint `json:"group_priority" example:"10"`
+
+	// ShortCodesDisabled is true when the SIM cannot send messages to short codes e.g. 12345
+	ShortCodesDisabled *bool `json:"short_codes_disabled" example:"false"`
+
+	// RcsEnabled is true when the messaging app of the phone has RCS turned on so that it can send reactions to messages
+	RcsEnabled *bool `json:"rcs_enabled" example:"true"`
+
+	// Type is virtual when the outgoing messages are posted to the VirtualURL instead of the httpSMS android app
+	Type *string `json:"type" example:"virtual"`
+
+	// VirtualURL is the URL which receives the outgoing messages of a virtual phone, it is removed when it is null or empty
+	VirtualURL *string `json:"virtual_url" example:"https://example.com/sms"`
+}
+
+// UnmarshalJSON decodes the payload and records the fields which are explicitly null
+func (input *PhonePatch) UnmarshalJSON(data []byte) error {
+	type payload PhonePatch
+	if err := json.Unmarshal(data, (*payload)(input)); err != nil {
+		return err
+	}
+	return input.decodeNulls(data)
+}
+
+// Sanitize sets defaults to PhonePatch
+func (input *PhonePatch) Sanitize() PhonePatch {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	if input.MissedCallAutoReply != nil {
+		input.MissedCallAutoReply = input.sanitizeClearable(*input.MissedCallAutoReply)
+	}
+	if input.AutoAckMessage != nil {
+		input.AutoAckMessage = input.sanitizeClearable(*input.AutoAckMessage)
+	}
+	if input.OutOfOfficeMessage != nil {
+		input.OutOfOfficeMessage = input.sanitizeClearable(*input.OutOfOfficeMessage)
+	}
+	if input.Signature != nil {
+		input.Signature = input.sanitizeClearable(*input.Signature)
+	}
+	if input.ExternalID != nil {
+		input.ExternalID = input.sanitizeClearable(*input.ExternalID)
+	}
+	if input.AlphanumericSenderID != nil {
+		input.AlphanumericSenderID = input.sanitizeClearable(*input.AlphanumericSenderID)
+	}
+	if input.Group != nil {
+		input.Group = input.sanitizeClearable(*input.Group)
+	}
+	if input.DailyQuotaTimezone != nil {
+		input.DailyQuotaTimezone = input.sanitizeClearable(*input.DailyQuotaTimezone)
+	}
+	if input.Timezone != nil {
+		input.Timezone = input.sanitizeClearable(*input.Timezone)
+	}
+	if input.VirtualURL != nil {
+		input.VirtualURL = input.sanitizeClearable(*input.VirtualURL)
+	}
+	if input.Type != nil {
+		phoneType := strings.ToLower(strings.TrimSpace(*input.Type))
+		input.Type = &phoneType
+	}
+	if input.SIM != nil {
+		sim := strings.ToUpper(strings.TrimSpace(*input.SIM))
+		input.SIM = &sim
+	}
+	if input.BusinessHours != nil {
+		input.BusinessHours = input.sanitizeBusinessHours(*input.BusinessHours)
+	}
+	if input.AllowedRecipients != nil {
+		recipients := make([]string, 0, len(*input.AllowedRecipients))
+		for _, recipient := range *input.AllowedRecipients {
+			if recipient = input.sanitizeAddress(recipient); recipient != "" && !slices.Contains(recipients, recipient) {
+				recipients = append(recipients, recipient)
+			}
+		}
+		input.AllowedRecipients = &recipients
+	}
+	return *input
+}
+
+// ToPatchParams converts PhonePatch to services.PhonePatchParams
+func (input *PhonePatch) ToPatchParams(user entities.AuthUser, source string) *services.PhonePatchParams {
+	var expiration *time.Duration
+	if input.MessageExpirationSeconds != nil {
+		duration := time.Duration(*input.MessageExpirationSeconds) * time.Second
+		expiration = &duration
+	}
+
+	var heartbeatInterval *time.Duration
+	if input.HeartbeatIntervalSeconds != nil {
+		duration := time.Duration(*input.HeartbeatIntervalSeconds) * time.Second
+		heartbeatInterval = &duration
+	}
+
+	var deliveryReportTimeout *time.Duration
+	if input.DeliveryReportTimeoutSeconds != nil {
+		duration := time.Duration(*input.DeliveryReportTimeoutSeconds) * time.Second
+		deliveryReportTimeout = &duration
+	}
+
+	var sim *entities.SIM
+	if input.SIM != nil {
+		value := entities.SIM(*input.SIM)
+		sim = &value
+	}
+
+	var phoneType *entities.PhoneType
+	if input.Type != nil {
+		value := entities.PhoneType(*input.Type)
+		phoneType = &value
+	}
+
+	allowedRecipients := input.AllowedRecipients
+	if input.IsNull("allowed_recipients") {
+		allowedRecipients = &[]string{}
+	}
+
+	businessHours := input.BusinessHours
+	if input.IsNull("business_hours") {
+		businessHours = &entities.PhoneBusinessHoursSchedule{}
+	}
+
+	return &services.PhonePatchParams{
+		UserID:                    user.ID,
+		PhoneID:                   uuid.MustParse(input.PhoneID),
+		Source:                    source,
+		MessagesPerMinute:         input.MessagesPerMinute,
+		MessageExpirationDuration: expiration,
+		MaxSendAttempts:           input.MaxSendAttempts,
+		MissedCallAutoReply:       input.nullable("missed_call_auto_reply", input.MissedCallAutoReply),
+		AutoAckMessage:            input.nullable("auto_ack_message", input.AutoAckMessage),
+		BatteryLowThreshold:       input.BatteryLowThreshold,
+		AlphanumericSenderID:      input.nullable("alphanumeric_sender_id", input.AlphanumericSenderID),
+		DailyQuota:                input.DailyQuota,
+		DailyQuotaTimezone:        input.DailyQuotaTimezone,
+		SIM:                       sim,
+		HeartbeatInterval:         heartbeatInterval,
+		MaxConcurrentSends:        input.MaxConcurrentSends,
+		DeliveryReportTimeout:     deliveryReportTimeout,
+		AllowedRecipients:         allowedRecipients,
+		Timezone:                  input.Timezone,
+		BusinessHours:             businessHours,
+		OutOfOfficeMessage:        input.nullable("out_of_office_message", input.OutOfOfficeMessage),
+		Signature:                 input.nullable("signature", input.Signature),
+		ExternalID:                input.nullable("external_id", input.ExternalID),
+		Group:                     input.nullable("group", input.Group),
+		GroupPriority:             input.GroupPriority,
+		ShortCodesDisabled:        input.ShortCodesDisabled,
+		RcsEnabled:                input.RcsEnabled,
+		Type:                      phoneType,
+		VirtualURL:                input.nullable("virtual_url", input.VirtualURL),
+	}
+}
@@ -0,0 +1,35 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhonePause is the payload for pausing a phone
+type PhonePause struct {
+	request
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+
+	// RejectMessages rejects the messages sent from the phone while it is paused instead of holding them until it is resumed
+	RejectMessages bool `json:"reject_messages" example:"false"`
+}
+
+// Sanitize sets defaults to PhonePause
+func (input *PhonePause) Sanitize() PhonePause {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	return *input
+}
+
+// ToPauseParams converts PhonePause to services.PhonePauseParams
+func (input *PhonePause) ToPauseParams(user entities.AuthUser, source string) *services.PhonePauseParams {
+	return &services.PhonePauseParams{
+		Source:         source,
+		UserID:         user.ID,
+		PhoneID:        uuid.MustParse(input.PhoneID),
+		RejectMessages: input.RejectMessages,
+	}
+}
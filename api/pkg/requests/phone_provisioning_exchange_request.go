@@ -0,0 +1,51 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/nyaruka/phonenumbers"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneProvisioningExchange is the payload which the android app sends to exchange a provisioning code for its credentials
+type PhoneProvisioningExchange struct {
+	request
+	// Code is the provisioning code which is displayed on the web app, it is case insensitive and the separator is optional
+	Code string `json:"code" example:"K7QM-2XPD"`
+
+	PhoneNumber string `json:"phone_number" example:"+18005550199"`
+	FcmToken    string `json:"fcm_token" example:"eyJhbGciOiJIUzI1NiIsInR5cCI6IkpXVCJ9.eyJzd....."`
+
+	// SIM is the SIM slot of the phone in case the phone has more than 1 SIM slot
+	SIM string `json:"sim" example:"SIM1"`
+}
+
+// Sanitize sets defaults to PhoneProvisioningExchange
+func (input *PhoneProvisioningExchange) Sanitize() PhoneProvisioningExchange {
+	input.Code = strings.NewReplacer("-", "", " ", "").Replace(strings.ToUpper(strings.TrimSpace(input.Code)))
+	input.FcmToken = strings.TrimSpace(input.FcmToken)
+	input.PhoneNumber = input.sanitizeAddress(input.PhoneNumber)
+	input.SIM = input.sanitizeSIM(input.SIM)
+	return *input
+}
+
+// ToExchangeParams converts PhoneProvisioningExchange to services.PhoneProvisioningExchangeParams
+func (input *PhoneProvisioningExchange) ToExchangeParams(source string) *services.PhoneProvisioningExchangeParams {
+	phone, _ := phonenumbers.Parse(input.PhoneNumber, phonenumbers.UNKNOWN_REGION)
+
+	// ignore default
+	var fcmToken *string
+	if input.FcmToken != "" {
+		fcmToken = &input.FcmToken
+	}
+
+	return &services.PhoneProvisioningExchangeParams{
+		Code:        input.Code,
+		PhoneNumber: phone,
+		FcmToken:    fcmToken,
+		SIM:         entities.SIM(input.SIM),
+		Source:      source,
+	}
+}
@@ -1,6 +1,7 @@
 package requests
 
 import (
+	"slices"
 	"strings"
 	"time"
 
@@ -26,8 +27,65 @@ type PhoneUpsert struct {
 
 	MissedCallAutoReply *string `json:"missed_call_auto_reply" example:"e.g. This phone cannot receive calls. Please send an SMS instead."`
 
+	// AutoAckMessage is sent as a reply to every received message, {{contact}} is replaced with the phone number of the sender
+	AutoAckMessage *string `json:"auto_ack_message" example:"Message received from {{contact}}, thanks"`
+
+	// BatteryLowThreshold is the battery percentage below which the phone.battery_low event is fired
+	BatteryLowThreshold uint `json:"battery_low_threshold" example:"20"`
+
+	// AlphanumericSenderID is set when the carrier of the SIM sends its messages with an alphanumeric sender ID e.g. MyBrand, it selects the phone which sends the messages with this sender ID
+	AlphanumericSenderID *string `json:"alphanumeric_sender_id" example:"MyBrand"`
+
+	// DailyQuota is the maximum number of messages the phone can send per day, there is no limit when it is 0
+	DailyQuota *uint `json:"daily_quota" example:"100"`
+
+	// DailyQuotaTimezone is the timezone used to reset the daily quota at midnight e.g. Europe/Helsinki
+	DailyQuotaTimezone *string `json:"daily_quota_timezone" example:"Europe/Helsinki"`
+
 	// SIM is the SIM slot of the phone in case the phone has more than 1 SIM slot
 	SIM string `json:"sim" example:"SIM1"`
+
+	// HeartbeatIntervalSeconds is the expected duration in seconds between the heartbeats of the phone
+	HeartbeatIntervalSeconds uint `json:"heartbeat_interval_seconds" example:"900"`
+
+	// MaxConcurrentSends is the maximum number of messages which are pushed to the phone at the same time
+	MaxConcurrentSends uint `json:"max_concurrent_sends" example:"10"`
+
+	// DeliveryReportTimeoutSeconds is the duration in seconds after which a sent message without a delivery report is marked as failed, 0 disables the timeout
+	DeliveryReportTimeoutSeconds *uint `json:"delivery_report_timeout_seconds" example:"86400"`
+
+	// AllowedRecipients are the only phone numbers which the phone can send messages to, an empty list removes the restriction
+	AllowedRecipients *[]string `json:"allowed_recipients" example:"+18005550100"`
+
+	// Timezone is the IANA timezone of the phone e.g. Europe/Helsinki which is used for scheduled messages sent in the timezone of the phone
+	Timezone *string `json:"timezone" example:"Europe/Helsinki"`
+
+	// BusinessHours is the weekly schedule of the phone in its Timezone, an empty list removes the business hours
+	BusinessHours *entities.PhoneBusinessHoursSchedule `json:"business_hours"`
+
+	// OutOfOfficeMessage is sent at most once a day to each contact outside the business hours, {{contact}}, {{opens_on}} and {{opens_at}} are replaced with the sender and the day and time of the next opening
+	OutOfOfficeMessage *string `json:"out_of_office_message" example:"We're closed, we'll reply on {{opens_on}} at {{opens_at}}"`
+
+	// Signature is appended on a new line to the content of the messages sent from the phone
+	Signature *string `json:"signature" example:"- Sent via httpSMS"`
+
+	// ExternalID is an optional ID of the phone in an external system e.g. an inventory, it must be unique for each of your phones
+	ExternalID *string `json:"external_id" example:"inventory-1042"`
+
+	// GroupPriority is used by the failover strategy of the group, the online phone with the highest priority sends the messages of the group
+	GroupPriority *uint `json:"group_priority" example:"10"`
+
+	// ShortCodesDisabled is true when the SIM cannot send messages to short codes e.g. 12345
+	ShortCodesDisabled *bool `json:"short_codes_disabled" example:"false"`
+
+	// RcsEnabled is true when the messaging app of the phone has RCS turned on so that it can send reactions to messages
+	RcsEnabled *bool `json:"rcs_enabled" example:"true"`
+
+	// Type is virtual when the outgoing messages are posted to the VirtualURL instead of the httpSMS android app
+	Type *string `json:"type" example:"virtual"`
+
+	// VirtualURL is the URL which receives the outgoing messages of a virtual phone
+	VirtualURL *string `json:"virtual_url" example:"https://example.com/sms"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
@@ -38,6 +96,45 @@ func (input *PhoneUpsert) Sanitize() PhoneUpsert {
 	if input.MissedCallAutoReply != nil {
 		input.MissedCallAutoReply = input.sanitizeStringPointer(*input.MissedCallAutoReply)
 	}
+	if input.AutoAckMessage != nil {
+		input.AutoAckMessage = input.sanitizeStringPointer(*input.AutoAckMessage)
+	}
+	if input.OutOfOfficeMessage != nil {
+		input.OutOfOfficeMessage = input.sanitizeStringPointer(*input.OutOfOfficeMessage)
+	}
+	if input.Signature != nil {
+		input.Signature = input.sanitizeStringPointer(*input.Signature)
+	}
+	if input.ExternalID != nil {
+		input.ExternalID = input.sanitizeStringPointer(*input.ExternalID)
+	}
+	if input.AlphanumericSenderID != nil {
+		input.AlphanumericSenderID = input.sanitizeStringPointer(*input.AlphanumericSenderID)
+	}
+	if input.DailyQuotaTimezone != nil {
+		input.DailyQuotaTimezone = input.sanitizeStringPointer(*input.DailyQuotaTimezone)
+	}
+	if input.Timezone != nil {
+		input.Timezone = input.sanitizeStringPointer(*input.Timezone)
+	}
+	if input.Type != nil {
+		input.Type = input.sanitizeStringPointer(strings.ToLower(*input.Type))
+	}
+	if input.VirtualURL != nil {
+		input.VirtualURL = input.sanitizeStringPointer(*input.VirtualURL)
+	}
+	if input.BusinessHours != nil {
+		input.BusinessHours = input.sanitizeBusinessHours(*input.BusinessHours)
+	}
+	if input.AllowedRecipients != nil {
+		recipients := make([]string, 0, len(*input.AllowedRecipients))
+		for _, recipient := range *input.AllowedRecipients {
+			if recipient = input.sanitizeAddress(recipient); recipient != "" && !slices.Contains(recipients, recipient) {
+				recipients = append(recipients, recipient)
+			}
+		}
+		input.AllowedRecipients = &recipients
+	}
 	return *input
 }
 
@@ -69,15 +166,62 @@ func (input *PhoneUpsert) ToUpsertParams(user entities.AuthUser, source string)
 		maxSendAttempts = &input.MaxSendAttempts
 	}
 
+	var batteryLowThreshold *uint
+	if input.BatteryLowThreshold != 0 {
+		batteryLowThreshold = &input.BatteryLowThreshold
+	}
+
+	var heartbeatInterval *time.Duration
+	if input.HeartbeatIntervalSeconds != 0 {
+		duration := time.Duration(input.HeartbeatIntervalSeconds) * time.Second
+		heartbeatInterval = &duration
+	}
+
+	var maxConcurrentSends *uint
+	if input.MaxConcurrentSends != 0 {
+		maxConcurrentSends = &input.MaxConcurrentSends
+	}
+
+	var deliveryReportTimeout *time.Duration
+	if input.DeliveryReportTimeoutSeconds != nil {
+		duration := time.Duration(*input.DeliveryReportTimeoutSeconds) * time.Second
+		deliveryReportTimeout = &duration
+	}
+
+	var phoneType *entities.PhoneType
+	if input.Type != nil {
+		value := entities.PhoneType(*input.Type)
+		phoneType = &value
+	}
+
 	return &services.PhoneUpsertParams{
 		Source:                    source,
 		PhoneNumber:               phone,
 		MessagesPerMinute:         messagesPerMinute,
 		MissedCallAutoReply:       input.MissedCallAutoReply,
+		AutoAckMessage:            input.AutoAckMessage,
+		AlphanumericSenderID:      input.AlphanumericSenderID,
+		DailyQuota:                input.DailyQuota,
+		DailyQuotaTimezone:        input.DailyQuotaTimezone,
 		MessageExpirationDuration: timeout,
 		MaxSendAttempts:           maxSendAttempts,
+		BatteryLowThreshold:       batteryLowThreshold,
 		FcmToken:                  fcmToken,
 		UserID:                    user.ID,
 		SIM:                       entities.SIM(input.SIM),
+		HeartbeatInterval:         heartbeatInterval,
+		MaxConcurrentSends:        maxConcurrentSends,
+		DeliveryReportTimeout:     deliveryReportTimeout,
+		AllowedRecipients:         input.AllowedRecipients,
+		Timezone:                  input.Timezone,
+		BusinessHours:             input.BusinessHours,
+		OutOfOfficeMessage:        input.OutOfOfficeMessage,
+		Signature:                 input.Signature,
+		ExternalID:                input.ExternalID,
+		GroupPriority:             input.GroupPriority,
+		ShortCodesDisabled:        input.ShortCodesDisabled,
+		RcsEnabled:                input.RcsEnabled,
+		Type:                      phoneType,
+		VirtualURL:                input.VirtualURL,
 	}
 }
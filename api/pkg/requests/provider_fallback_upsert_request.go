@@ -0,0 +1,49 @@
+package requests
+
+import (
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// ProviderFallbackUpsert is the payload for setting the third-party SMS provider which sends messages when no phone picks them up
+type ProviderFallbackUpsert struct {
+	request
+	// Provider is either twilio or vonage
+	Provider string `json:"provider" example:"twilio"`
+
+	// AccountID is the account SID of your Twilio account or the API key of your Vonage account
+	AccountID string `json:"account_id" example:"ACXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXXX"`
+
+	// AuthToken is the auth token of your Twilio account or the API secret of your Vonage account, the existing token is kept when it is empty
+	AuthToken string `json:"auth_token" example:"your_auth_token" validate:"optional"`
+
+	// From is the phone number or the alphanumeric sender ID which the provider uses to send the messages
+	From string `json:"from" example:"+18005550199"`
+
+	// TimeoutSeconds is the duration in seconds after a message is sent when it is sent with the provider if no phone has picked it up
+	TimeoutSeconds uint `json:"timeout_seconds" example:"300"`
+}
+
+// Sanitize sets defaults to ProviderFallbackUpsert
+func (input *ProviderFallbackUpsert) Sanitize() ProviderFallbackUpsert {
+	input.Provider = strings.ToLower(strings.TrimSpace(input.Provider))
+	input.AccountID = strings.TrimSpace(input.AccountID)
+	input.AuthToken = strings.TrimSpace(input.AuthToken)
+	input.From = input.sanitizeAddress(input.From)
+	return *input
+}
+
+// ToUpsertParams converts ProviderFallbackUpsert to services.ProviderFallbackUpsertParams
+func (input *ProviderFallbackUpsert) ToUpsertParams(user entities.AuthUser) *services.ProviderFallbackUpsertParams {
+	return &services.ProviderFallbackUpsertParams{
+		UserID:    user.ID,
+		Provider:  entities.SmsProviderName(input.Provider),
+		AccountID: input.AccountID,
+		AuthToken: input.AuthToken,
+		From:      input.From,
+		Timeout:   time.Duration(input.TimeoutSeconds) * time.Second,
+	}
+}
@@ -1,6 +1,7 @@
 package requests
 
 import (
+	"encoding/json"
 	"net/url"
 	"strconv"
 	"strings"
@@ -13,6 +14,46 @@ import (
 
 type request struct{}
 
+// patch records the fields which are explicitly null in the payload of a partial update, a nil pointer alone cannot tell them apart from omitted fields
+type patch struct {
+	nulls map[string]bool
+}
+
+// IsNull checks if the field is present in the payload with a null value
+func (input *patch) IsNull(field string) bool {
+	return input.nulls[field]
+}
+
+// nullable returns an empty string for a field which is explicitly null so that the service removes its value
+func (input *patch) nullable(field string, value *string) *string {
+	if input.IsNull(field) {
+		empty := ""
+		return &empty
+	}
+	return value
+}
+
+// sanitizeClearable trims a string field of a partial update, an empty string is kept because it removes the value of the field
+func (input *patch) sanitizeClearable(value string) *string {
+	value = strings.TrimSpace(value)
+	return &value
+}
+
+func (input *patch) decodeNulls(data []byte) error {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+
+	input.nulls = map[string]bool{}
+	for field, value := range fields {
+		if string(value) == "null" {
+			input.nulls[field] = true
+		}
+	}
+	return nil
+}
+
 func (input *request) sanitizeAddress(value string) string {
 	value = strings.TrimSpace(value)
 	if !strings.HasPrefix(value, "+") && input.isDigits(value) && len(value) > 9 {
@@ -86,6 +127,19 @@ func (input *request) sanitizeStringPointer(value string) *string {
 	return &value
 }
 
+// sanitizeBusinessHours lowercases the days and trims the times of a weekly schedule
+func (input *request) sanitizeBusinessHours(schedule entities.PhoneBusinessHoursSchedule) *entities.PhoneBusinessHoursSchedule {
+	result := make(entities.PhoneBusinessHoursSchedule, 0, len(schedule))
+	for _, hours := range schedule {
+		result = append(result, entities.PhoneBusinessHours{
+			Day:   strings.ToLower(strings.TrimSpace(hours.Day)),
+			Start: strings.TrimSpace(hours.Start),
+			End:   strings.TrimSpace(hours.End),
+		})
+	}
+	return &result
+}
+
 func (input *request) removeStringDuplicates(values []string) []string {
 	cache := map[string]struct{}{}
 	for _, value := range values {
@@ -133,3 +187,15 @@ func (input *request) isDigits(value string) bool {
 	}
 	return true
 }
+
+func (input *request) sanitizeMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	result := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		result[strings.TrimSpace(key)] = value
+	}
+	return result
+}
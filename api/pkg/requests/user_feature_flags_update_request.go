@@ -0,0 +1,42 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// UserFeatureFlagsUpdate is the payload for setting the feature flags of a user
+type UserFeatureFlagsUpdate struct {
+	request
+	UserID string `json:"userID" swaggerignore:"true"` // used internally for validation
+
+	// Flags are merged with the existing flags of the user, a flag is disabled when it is false
+	Flags map[string]bool `json:"flags" example:"provider_fallback:true"`
+}
+
+// Sanitize sets defaults to UserFeatureFlagsUpdate
+func (input *UserFeatureFlagsUpdate) Sanitize() UserFeatureFlagsUpdate {
+	input.UserID = strings.TrimSpace(input.UserID)
+
+	flags := make(map[string]bool, len(input.Flags))
+	for flag, enabled := range input.Flags {
+		flags[strings.ToLower(strings.TrimSpace(flag))] = enabled
+	}
+	input.Flags = flags
+
+	return *input
+}
+
+// ToUpdateParams converts UserFeatureFlagsUpdate to services.UserFeatureFlagsUpdateParams
+func (input *UserFeatureFlagsUpdate) ToUpdateParams() *services.UserFeatureFlagsUpdateParams {
+	flags := make(entities.UserFeatureFlags, len(input.Flags))
+	for flag, enabled := range input.Flags {
+		flags[entities.FeatureFlag(flag)] = enabled
+	}
+	return &services.UserFeatureFlagsUpdateParams{
+		UserID: entities.UserID(input.UserID),
+		Flags:  flags,
+	}
+}
@@ -0,0 +1,43 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// UserMessageCategoryRulesUpdate is the payload for overriding the rules used to classify received messages
+type UserMessageCategoryRulesUpdate struct {
+	request
+	Rules []UserMessageCategoryRule `json:"rules"`
+}
+
+// UserMessageCategoryRule is a regex rule which tags received messages with a category
+type UserMessageCategoryRule struct {
+	Category string `json:"category" example:"otp"`
+	Pattern  string `json:"pattern" example:"(?i)\\b(code|otp)\\b.*\\b[0-9]{4,8}\\b"`
+}
+
+// Sanitize sets defaults to UserMessageCategoryRulesUpdate
+func (input *UserMessageCategoryRulesUpdate) Sanitize() UserMessageCategoryRulesUpdate {
+	for index, rule := range input.Rules {
+		input.Rules[index].Category = strings.ToLower(strings.TrimSpace(rule.Category))
+		input.Rules[index].Pattern = strings.TrimSpace(rule.Pattern)
+	}
+	return *input
+}
+
+// ToUpdateParams converts UserMessageCategoryRulesUpdate to services.UserMessageCategoryRulesUpdateParams
+func (input *UserMessageCategoryRulesUpdate) ToUpdateParams() *services.UserMessageCategoryRulesUpdateParams {
+	var rules entities.MessageCategoryRules
+	for _, rule := range input.Rules {
+		rules = append(rules, entities.MessageCategoryRule{
+			Category: entities.MessageCategory(rule.Category),
+			Pattern:  rule.Pattern,
+		})
+	}
+	return &services.UserMessageCategoryRulesUpdateParams{
+		Rules: rules,
+	}
+}
@@ -0,0 +1,109 @@
+package requests
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// UserPatch is the payload for updating some fields of a user, the fields which are omitted are not changed
+type UserPatch struct {
+	request
+	patch
+	Timezone *string `json:"timezone" example:"Europe/Helsinki"`
+
+	// ActivePhoneID is removed when it is null or empty
+	ActivePhoneID *string `json:"active_phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// Locale is the language of the response messages e.g. en or fr
+	Locale *string `json:"locale" example:"en"`
+
+	// LanguageDetectionEnabled detects the language of received messages
+	LanguageDetectionEnabled *bool `json:"language_detection_enabled" example:"true"`
+
+	// DefaultCountry is the ISO 3166-1 alpha-2 code used to resolve national numbers, it is removed when it is null or empty
+	DefaultCountry *string `json:"default_country" example:"US"`
+
+	// InboundContentMaxLength is the maximum number of characters in the content of a received message, 0 removes the limit
+	InboundContentMaxLength *uint `json:"inbound_content_max_length" example:"1600"`
+
+	// InboundContentLimitMode is truncate when the content is truncated to the limit and reject when the message is rejected
+	InboundContentLimitMode *string `json:"inbound_content_limit_mode" example:"truncate"`
+
+	// GetSendEnabled allows the API key to send messages with GET /v1/messages/send, the key is then in the URL of the requests
+	GetSendEnabled *bool `json:"get_send_enabled" example:"false"`
+}
+
+// UnmarshalJSON decodes the payload and records the fields which are explicitly null
+func (input *UserPatch) UnmarshalJSON(data []byte) error {
+	type payload UserPatch
+	if err := json.Unmarshal(data, (*payload)(input)); err != nil {
+		return err
+	}
+	return input.decodeNulls(data)
+}
+
+// Sanitize sets defaults to UserPatch
+func (input *UserPatch) Sanitize() UserPatch {
+	if input.Timezone != nil {
+		input.Timezone = input.sanitizeClearable(*input.Timezone)
+	}
+	if input.ActivePhoneID != nil {
+		input.ActivePhoneID = input.sanitizeClearable(*input.ActivePhoneID)
+	}
+	if input.Locale != nil {
+		locale := strings.ToLower(strings.TrimSpace(*input.Locale))
+		input.Locale = &locale
+	}
+	if input.DefaultCountry != nil {
+		country := strings.ToUpper(strings.TrimSpace(*input.DefaultCountry))
+		input.DefaultCountry = &country
+	}
+	if input.InboundContentLimitMode != nil {
+		mode := strings.ToLower(strings.TrimSpace(*input.InboundContentLimitMode))
+		input.InboundContentLimitMode = &mode
+	}
+	return *input
+}
+
+// ToPatchParams converts UserPatch to services.UserPatchParams
+func (input *UserPatch) ToPatchParams() services.UserPatchParams {
+	var location *time.Location
+	if input.Timezone != nil {
+		location, _ = time.LoadLocation(*input.Timezone)
+	}
+
+	var activePhoneID *uuid.UUID
+	if value := input.nullable("active_phone_id", input.ActivePhoneID); value != nil {
+		id := uuid.Nil
+		if *value != "" {
+			id = uuid.MustParse(*value)
+		}
+		activePhoneID = &id
+	}
+
+	return services.UserPatchParams{
+		Timezone:                 location,
+		ActivePhoneID:            activePhoneID,
+		Locale:                   input.Locale,
+		LanguageDetectionEnabled: input.LanguageDetectionEnabled,
+		DefaultCountry:           input.nullable("default_country", input.DefaultCountry),
+		InboundContentMaxLength:  input.InboundContentMaxLength,
+		InboundContentLimitMode:  inboundContentLimitMode(input.InboundContentLimitMode),
+		GetSendEnabled:           input.GetSendEnabled,
+	}
+}
+
+// inboundContentLimitMode converts the limit mode of a user request to an entities.InboundContentLimitMode
+func inboundContentLimitMode(value *string) *entities.InboundContentLimitMode {
+	if value == nil {
+		return nil
+	}
+	mode := entities.InboundContentLimitMode(*value)
+	return &mode
+}
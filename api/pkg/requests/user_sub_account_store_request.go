@@ -0,0 +1,17 @@
+package requests
+
+import "strings"
+
+// UserSubAccountStore is the payload for creating a sub-account
+type UserSubAccountStore struct {
+	request
+
+	// Name is the label of the sub-account e.g. the name of the client of a reseller
+	Name string `json:"name" example:"Acme Inc"`
+}
+
+// Sanitize sets defaults to UserSubAccountStore
+func (input *UserSubAccountStore) Sanitize() UserSubAccountStore {
+	input.Name = strings.TrimSpace(input.Name)
+	return *input
+}
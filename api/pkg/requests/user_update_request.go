@@ -14,12 +14,39 @@ type UserUpdate struct {
 	request
 	Timezone      string `json:"timezone" example:"Europe/Helsinki"`
 	ActivePhoneID string `json:"active_phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+
+	// Locale is the language of the response messages e.g. en or fr, the Accept-Language header is used when it is empty
+	Locale string `json:"locale" example:"en"`
+
+	// LanguageDetectionEnabled detects the language of received messages, it is not changed when it is not set
+	LanguageDetectionEnabled *bool `json:"language_detection_enabled" example:"true"`
+
+	// DefaultCountry is the ISO 3166-1 alpha-2 code used to resolve national numbers, it is not changed when it is not set and it is removed when it is empty
+	DefaultCountry *string `json:"default_country" example:"US"`
+
+	// InboundContentMaxLength is the maximum number of characters in the content of a received message, 0 removes the limit and it is not changed when it is not set
+	InboundContentMaxLength *uint `json:"inbound_content_max_length" example:"1600"`
+
+	// InboundContentLimitMode is truncate or reject, it is not changed when it is not set
+	InboundContentLimitMode *string `json:"inbound_content_limit_mode" example:"truncate"`
+
+	// GetSendEnabled allows the API key to send messages with GET /v1/messages/send, it is not changed when it is not set
+	GetSendEnabled *bool `json:"get_send_enabled" example:"false"`
 }
 
 // Sanitize sets defaults to MessageOutstanding
 func (input *UserUpdate) Sanitize() UserUpdate {
 	input.ActivePhoneID = strings.TrimSpace(input.ActivePhoneID)
 	input.Timezone = strings.TrimSpace(input.Timezone)
+	input.Locale = strings.ToLower(strings.TrimSpace(input.Locale))
+	if input.DefaultCountry != nil {
+		country := strings.ToUpper(strings.TrimSpace(*input.DefaultCountry))
+		input.DefaultCountry = &country
+	}
+	if input.InboundContentLimitMode != nil {
+		mode := strings.ToLower(strings.TrimSpace(*input.InboundContentLimitMode))
+		input.InboundContentLimitMode = &mode
+	}
 	return *input
 }
 
@@ -39,5 +66,12 @@ func (input *UserUpdate) ToUpdateParams() services.UserUpdateParams {
 	return services.UserUpdateParams{
 		ActivePhoneID: activePhoneID,
 		Timezone:      location,
+		Locale:        input.Locale,
+
+		LanguageDetectionEnabled: input.LanguageDetectionEnabled,
+		DefaultCountry:           input.DefaultCountry,
+		InboundContentMaxLength:  input.InboundContentMaxLength,
+		InboundContentLimitMode:  inboundContentLimitMode(input.InboundContentLimitMode),
+		GetSendEnabled:           input.GetSendEnabled,
 	}
 }
@@ -0,0 +1,92 @@
+package requests
+
+import (
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// UssdStore is the payload for executing a USSD request on a phone
+type UssdStore struct {
+	request
+	Code string `json:"code" example:"*123#"`
+
+	// SIM is the SIM slot of the phone which will execute the USSD request
+	SIM string `json:"sim" example:"SIM1"`
+
+	PhoneID string `json:"phoneID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to UssdStore
+func (input *UssdStore) Sanitize() UssdStore {
+	input.Code = strings.ReplaceAll(strings.TrimSpace(input.Code), " ", "")
+	input.SIM = input.sanitizeSIM(strings.TrimSpace(input.SIM))
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	return *input
+}
+
+// ToStoreParams converts UssdStore to services.UssdStoreParams
+func (input *UssdStore) ToStoreParams(user entities.AuthUser) *services.UssdStoreParams {
+	return &services.UssdStoreParams{
+		PhoneID: uuid.MustParse(input.PhoneID),
+		UserID:  user.ID,
+		Code:    input.Code,
+		SIM:     entities.SIM(input.SIM),
+	}
+}
+
+// UssdLoad is the payload for fetching a USSD session
+type UssdLoad struct {
+	request
+	PhoneID   string `json:"phoneID" swaggerignore:"true"`   // used internally for validation
+	SessionID string `json:"sessionID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to UssdLoad
+func (input *UssdLoad) Sanitize() UssdLoad {
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	input.SessionID = strings.TrimSpace(input.SessionID)
+	return *input
+}
+
+// PhoneIDUuid returns the phoneID as uuid.UUID
+func (input *UssdLoad) PhoneIDUuid() uuid.UUID {
+	return uuid.MustParse(input.PhoneID)
+}
+
+// SessionIDUuid returns the sessionID as uuid.UUID
+func (input *UssdLoad) SessionIDUuid() uuid.UUID {
+	return uuid.MustParse(input.SessionID)
+}
+
+// UssdResponseStore is the payload for reporting the response of a USSD request from the android phone
+type UssdResponseStore struct {
+	request
+	Response      string `json:"response" example:"Your balance is $10.00"`
+	FailureReason string `json:"failure_reason" example:"USSD_RETURN_FAILURE"`
+
+	PhoneID   string `json:"phoneID" swaggerignore:"true"`   // used internally for validation
+	SessionID string `json:"sessionID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to UssdResponseStore
+func (input *UssdResponseStore) Sanitize() UssdResponseStore {
+	input.FailureReason = strings.TrimSpace(input.FailureReason)
+	input.PhoneID = strings.TrimSpace(input.PhoneID)
+	input.SessionID = strings.TrimSpace(input.SessionID)
+	return *input
+}
+
+// ToResponseParams converts UssdResponseStore to services.UssdResponseParams
+func (input *UssdResponseStore) ToResponseParams(user entities.AuthUser) *services.UssdResponseParams {
+	return &services.UssdResponseParams{
+		PhoneID:       uuid.MustParse(input.PhoneID),
+		SessionID:     uuid.MustParse(input.SessionID),
+		UserID:        user.ID,
+		Response:      input.sanitizeStringPointer(input.Response),
+		FailureReason: input.sanitizeStringPointer(input.FailureReason),
+	}
+}
@@ -0,0 +1,44 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
+)
+
+// WebhookReplay is the payload for re-sending historical events to an entities.Webhook
+type WebhookReplay struct {
+	request
+	From time.Time `json:"from" example:"2022-06-05T08:26:02.302718+03:00"`
+
+	// To defaults to the current time when it is not set
+	To time.Time `json:"to" example:"2022-06-05T14:26:02.302718+03:00"`
+
+	// Events are the event types to replay, all the events of the webhook are replayed when it is empty
+	Events []string `json:"events" example:"message.phone.received"`
+
+	WebhookID string `json:"webhookID" swaggerignore:"true"` // used internally for validation
+}
+
+// Sanitize sets defaults to WebhookReplay
+func (input *WebhookReplay) Sanitize() WebhookReplay {
+	if input.To.IsZero() {
+		input.To = time.Now().UTC()
+	}
+	input.Events = input.removeStringDuplicates(input.Events)
+	return *input
+}
+
+// ToReplayParams converts WebhookReplay to services.WebhookReplayParams
+func (input *WebhookReplay) ToReplayParams(user entities.AuthUser, source string) *services.WebhookReplayParams {
+	return &services.WebhookReplayParams{
+		UserID:    user.ID,
+		WebhookID: uuid.MustParse(input.WebhookID),
+		Events:    input.Events,
+		From:      input.From,
+		To:        input.To,
+		Source:    source,
+	}
+}
@@ -1,6 +1,8 @@
 package requests
 
 import (
+	"time"
+
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/google/uuid"
@@ -9,6 +11,7 @@ import (
 // WebhookUpdate is the payload for updating an entities.Webhook
 type WebhookUpdate struct {
 	WebhookStore
+	Enabled   *bool  `json:"enabled" example:"true"`
 	WebhookID string `json:"webhookID" swaggerignore:"true"` // used internally for validation
 }
 
@@ -19,7 +22,7 @@ func (input *WebhookUpdate) Sanitize() WebhookUpdate {
 }
 
 // ToUpdateParams converts WebhookUpdate to services.WebhookUpdateParams
-func (input *WebhookUpdate) ToUpdateParams(user entities.AuthUser) *services.WebhookUpdateParams {
+func (input *WebhookUpdate) ToUpdateParams(user entities.AuthUser, source string) *services.WebhookUpdateParams {
 	return &services.WebhookUpdateParams{
 		UserID:       user.ID,
 		WebhookID:    uuid.MustParse(input.WebhookID),
@@ -27,5 +30,17 @@ func (input *WebhookUpdate) ToUpdateParams(user entities.AuthUser) *services.Web
 		URL:          input.URL,
 		PhoneNumbers: input.PhoneNumbers,
 		Events:       input.Events,
+		BatchSize:    input.BatchSize,
+		BatchWindow:  time.Duration(input.BatchWindowSeconds) * time.Second,
+		Enabled:      input.Enabled,
+		Source:       source,
+		Headers:      input.Headers,
+		PhoneID:      input.phoneID(),
+		FieldMask:    input.FieldMask,
+		Timeout:      time.Duration(input.TimeoutSeconds) * time.Second,
+		ContentType:  input.contentType(),
+
+		ClientCertificate: input.ClientCertificate,
+		ClientKey:         input.ClientKey,
 	}
 }
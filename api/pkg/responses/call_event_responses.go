@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// CallEventResponse is the payload containing entities.CallEvent
+type CallEventResponse struct {
+	response
+	Data entities.CallEvent `json:"data"`
+}
+
+// CallEventsResponse is the payload containing []entities.CallEvent
+type CallEventsResponse struct {
+	response
+	Data []entities.CallEvent `json:"data"`
+}
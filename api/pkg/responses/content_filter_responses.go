@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// ContentFilterResponse is the payload containing entities.ContentFilter
+type ContentFilterResponse struct {
+	response
+	Data entities.ContentFilter `json:"data"`
+}
+
+// ContentFiltersResponse is the payload containing []entities.ContentFilter
+type ContentFiltersResponse struct {
+	response
+	Data []entities.ContentFilter `json:"data"`
+}
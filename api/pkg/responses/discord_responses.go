@@ -1,6 +1,9 @@
 package responses
 
-import "github.com/NdoleStudio/httpsms/pkg/entities"
+import (
+	"github.com/NdoleStudio/httpsms/pkg/discord"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
 
 // DiscordResponse is the payload containing entities.Discord
 type DiscordResponse struct {
@@ -13,3 +16,9 @@ type DiscordsResponse struct {
 	response
 	Data []entities.Discord `json:"data"`
 }
+
+// DiscordCommandResponse is the payload containing discord.CommandCreateResponse
+type DiscordCommandResponse struct {
+	response
+	Data discord.CommandCreateResponse `json:"data"`
+}
@@ -0,0 +1,97 @@
+package responses
+
+// ErrorCode is a stable machine-readable code which is returned in the error_code field of every 4xx and 5xx response.
+// The message of a response can change or be translated, clients should use the ErrorCode to handle an error.
+type ErrorCode string
+
+const (
+	// ErrorCodeBadRequest means the request body or query parameters cannot be parsed
+	ErrorCodeBadRequest = ErrorCode("bad_request")
+
+	// ErrorCodeUnauthorized means the API key or bearer token is missing or invalid
+	ErrorCodeUnauthorized = ErrorCode("unauthorized")
+
+	// ErrorCodeForbidden means the authenticated user is not allowed to carry out the request
+	ErrorCodeForbidden = ErrorCode("forbidden")
+
+	// ErrorCodeNotFound means the resource e.g. a message or a phone does not exist
+	ErrorCodeNotFound = ErrorCode("not_found")
+
+	// ErrorCodeValidationFailed means at least 1 field in the request is invalid, the data field has the errors for each field
+	ErrorCodeValidationFailed = ErrorCode("validation_failed")
+
+	// ErrorCodeInvalidPhoneNumber means a phone number in the request is not a valid E.164 phone number
+	ErrorCodeInvalidPhoneNumber = ErrorCode("invalid_phone_number")
+
+	// ErrorCodePaymentRequired means the request exceeds the limits of the subscription of the user
+	ErrorCodePaymentRequired = ErrorCode("payment_required")
+
+	// ErrorCodeConflict means the request conflicts with an existing resource
+	ErrorCodeConflict = ErrorCode("conflict")
+
+	// ErrorCodePhoneAlreadyExists means a phone in a bulk registration is a duplicate or is already registered
+	ErrorCodePhoneAlreadyExists = ErrorCode("phone_already_exists")
+
+	// ErrorCodePhoneOffline means the phone cannot be reached because it has no valid FCM token
+	ErrorCodePhoneOffline = ErrorCode("phone_offline")
+
+	// ErrorCodePhoneReassignTargetNotFound means there is no other phone to reassign the messages of a deleted phone
+	ErrorCodePhoneReassignTargetNotFound = ErrorCode("phone_reassign_target_not_found")
+
+	// ErrorCodeUssdSessionInvalidState means the USSD session is not in the right state e.g. it is not waiting for a response
+	ErrorCodeUssdSessionInvalidState = ErrorCode("ussd_session_invalid_state")
+
+	// ErrorCodeWebhookReplayTooLarge means the time range of a webhook replay has more events than the maximum allowed
+	ErrorCodeWebhookReplayTooLarge = ErrorCode("webhook_replay_too_large")
+
+	// ErrorCodeMessageInReplyToInvalid means the message being replied to does not exist or is not in the same conversation
+	ErrorCodeMessageInReplyToInvalid = ErrorCode("message_in_reply_to_invalid")
+
+	// ErrorCodeRecipientNotAllowed means the phone has an allowlist of recipients which does not contain the recipient of the message
+	ErrorCodeRecipientNotAllowed = ErrorCode("recipient_not_allowed")
+
+	// ErrorCodeContactUnresolvable means the recipient is a national number which is not possible in the default country of the user
+	ErrorCodeContactUnresolvable = ErrorCode("contact_unresolvable")
+
+	// ErrorCodeShortCodeNotSupported means the recipient is a short code and the SIM of the phone cannot send messages to short codes
+	ErrorCodeShortCodeNotSupported = ErrorCode("short_code_not_supported")
+
+	// ErrorCodeReplyTokenInvalid means the reply token of a received message is malformed, has an invalid signature or has expired
+	ErrorCodeReplyTokenInvalid = ErrorCode("reply_token_invalid")
+
+	// ErrorCodeFcmSigningDisabled means the key of a phone cannot be rotated because the push notifications are not signed
+	ErrorCodeFcmSigningDisabled = ErrorCode("fcm_signing_disabled")
+
+	// ErrorCodeFcmKeyNotRotated means the key of a phone cannot be promoted because it is not being rotated
+	ErrorCodeFcmKeyNotRotated = ErrorCode("fcm_key_not_rotated")
+
+	// ErrorCodeMessageLabelLimitExceeded means the message already has the maximum number of labels
+	ErrorCodeMessageLabelLimitExceeded = ErrorCode("message_label_limit_exceeded")
+
+	// ErrorCodeMessageContentTooLong means the content of a received message is longer than the inbound content limit of the user
+	ErrorCodeMessageContentTooLong = ErrorCode("message_content_too_long")
+
+	// ErrorCodeBulkMessageJobInvalidState means the bulk message job cannot be paused or resumed in its current status
+	ErrorCodeBulkMessageJobInvalidState = ErrorCode("bulk_message_job_invalid_state")
+
+	// ErrorCodePhonePaused means the phone is paused and it rejects messages or all the phones of a group are paused
+	ErrorCodePhonePaused = ErrorCode("phone_paused")
+
+	// ErrorCodeCountryRouteNotFound means a message is sent without a from number and no country route matches the recipient
+	ErrorCodeCountryRouteNotFound = ErrorCode("country_route_not_found")
+
+	// ErrorCodeRateLimited means an upstream service e.g. discord is rate limiting requests
+	ErrorCodeRateLimited = ErrorCode("rate_limited")
+
+	// ErrorCodeDailyQuotaExceeded means the phone has already sent its daily quota of messages
+	ErrorCodeDailyQuotaExceeded = ErrorCode("daily_quota_exceeded")
+
+	// ErrorCodeRangeNotSatisfiable means the Range header of the request is outside the size of the file
+	ErrorCodeRangeNotSatisfiable = ErrorCode("range_not_satisfiable")
+
+	// ErrorCodeInternalError means we ran into an unexpected error while handling the request
+	ErrorCodeInternalError = ErrorCode("internal_error")
+
+	// ErrorCodeServiceUnavailable means a dependency e.g. the database is unavailable
+	ErrorCodeServiceUnavailable = ErrorCode("service_unavailable")
+)
@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// EventLogsResponse is the payload containing []entities.EventLog
+type EventLogsResponse struct {
+	response
+	Data []entities.EventLog `json:"data"`
+}
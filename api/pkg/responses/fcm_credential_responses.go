@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// FcmCredentialResponse is the payload containing entities.FcmCredential
+type FcmCredentialResponse struct {
+	response
+	Data entities.FcmCredential `json:"data"`
+}
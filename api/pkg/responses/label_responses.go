@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// LabelResponse is the payload containing entities.Label
+type LabelResponse struct {
+	response
+	Data entities.Label `json:"data"`
+}
+
+// LabelsResponse is the payload containing []entities.Label
+type LabelsResponse struct {
+	response
+	Data []entities.Label `json:"data"`
+}
@@ -1,6 +1,9 @@
 package responses
 
-import "github.com/NdoleStudio/httpsms/pkg/entities"
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
 
 // MessageResponse is the payload containing an entities.Message
 type MessageResponse struct {
@@ -8,8 +11,26 @@ type MessageResponse struct {
 	Data entities.Message `json:"data"`
 }
 
+// MessagePollResponse is the payload containing a services.MessagePoll
+type MessagePollResponse struct {
+	response
+	Data services.MessagePoll `json:"data"`
+}
+
+// MessageFanOutResponse is the payload containing a services.MessageFanOut, the status is 207 when at least 1 recipient is rejected
+type MessageFanOutResponse struct {
+	response
+	Data services.MessageFanOut `json:"data"`
+}
+
 // MessagesResponse is the payload containing []entities.Message
 type MessagesResponse struct {
 	response
 	Data []entities.Message `json:"data"`
 }
+
+// MessagesMarkedReadResponse is the payload containing services.MessagesMarkedRead
+type MessagesMarkedReadResponse struct {
+	response
+	Data services.MessagesMarkedRead `json:"data"`
+}
@@ -0,0 +1,15 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// MessageRuleResponse is the payload containing entities.MessageRule
+type MessageRuleResponse struct {
+	response
+	Data entities.MessageRule `json:"data"`
+}
+
+// MessageRulesResponse is the payload containing []entities.MessageRule
+type MessageRulesResponse struct {
+	response
+	Data []entities.MessageRule `json:"data"`
+}
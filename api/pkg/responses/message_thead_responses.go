@@ -1,9 +1,18 @@
 package responses
 
-import "github.com/NdoleStudio/httpsms/pkg/entities"
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
 
 // MessageThreadsResponse is the payload containing []entities.MessageThread
 type MessageThreadsResponse struct {
 	response
 	Data []entities.MessageThread `json:"data"`
 }
+
+// MessageThreadMessagesDeletedResponse is the payload containing services.MessageThreadMessagesDeleted
+type MessageThreadMessagesDeletedResponse struct {
+	response
+	Data services.MessageThreadMessagesDeleted `json:"data"`
+}
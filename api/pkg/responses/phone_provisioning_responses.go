@@ -0,0 +1,18 @@
+package responses
+
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
+
+// PhoneProvisioningCodeResponse is the payload containing entities.PhoneProvisioningCode
+type PhoneProvisioningCodeResponse struct {
+	response
+	Data entities.PhoneProvisioningCode `json:"data"`
+}
+
+// PhoneProvisioningResponse is the payload containing services.PhoneProvisioning
+type PhoneProvisioningResponse struct {
+	response
+	Data services.PhoneProvisioning `json:"data"`
+}
@@ -1,6 +1,9 @@
 package responses
 
-import "github.com/NdoleStudio/httpsms/pkg/entities"
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
 
 // PhonesResponse is the payload containing entities.Phone
 type PhonesResponse struct {
@@ -13,3 +16,33 @@ type PhoneResponse struct {
 	response
 	Data entities.Phone `json:"data"`
 }
+
+// PhoneBulkStoreResponse is the payload containing the result of registering each phone
+type PhoneBulkStoreResponse struct {
+	response
+	Data []services.PhoneBulkStoreResult `json:"data"`
+}
+
+// PhoneNotifyAllResponse is the payload containing the result of notifying each phone
+type PhoneNotifyAllResponse struct {
+	response
+	Data []services.PhoneNotifyResult `json:"data"`
+}
+
+// PhoneGroupResponse is the payload containing entities.PhoneGroup
+type PhoneGroupResponse struct {
+	response
+	Data entities.PhoneGroup `json:"data"`
+}
+
+// CountryRouteResponse is the payload containing entities.CountryRoute
+type CountryRouteResponse struct {
+	response
+	Data entities.CountryRoute `json:"data"`
+}
+
+// CountryRoutesResponse is the payload containing []entities.CountryRoute
+type CountryRoutesResponse struct {
+	response
+	Data []entities.CountryRoute `json:"data"`
+}
@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// ProviderFallbackResponse is the payload containing entities.ProviderFallback
+type ProviderFallbackResponse struct {
+	response
+	Data entities.ProviderFallback `json:"data"`
+}
@@ -7,35 +7,54 @@ type response struct {
 
 // InternalServerError is the response with status code is 500
 type InternalServerError struct {
-	Status  string `json:"status" example:"error"`
-	Message string `json:"message" example:"We ran into an internal error while handling the request."`
+	Status    string    `json:"status" example:"error"`
+	Message   string    `json:"message" example:"We ran into an internal error while handling the request."`
+	ErrorCode ErrorCode `json:"error_code" example:"internal_error"`
 }
 
 // NotFound is the response with status code is 404
 type NotFound struct {
-	Status  string `json:"status" example:"error"`
-	Message string `json:"message" example:"cannot find message with ID [32343a19-da5e-4b1b-a767-3298a73703ca]"`
+	Status    string    `json:"status" example:"error"`
+	Message   string    `json:"message" example:"cannot find message with ID [32343a19-da5e-4b1b-a767-3298a73703ca]"`
+	ErrorCode ErrorCode `json:"error_code" example:"not_found"`
 }
 
 // BadRequest is the response with status code is 400
 type BadRequest struct {
-	Status  string `json:"status" example:"error"`
-	Message string `json:"message" example:"The request isn't properly formed"`
-	Data    string `json:"data" example:"The request body is not a valid JSON string"`
+	Status    string    `json:"status" example:"error"`
+	Message   string    `json:"message" example:"The request isn't properly formed"`
+	ErrorCode ErrorCode `json:"error_code" example:"bad_request"`
+	Data      string    `json:"data" example:"The request body is not a valid JSON string"`
+}
+
+// TooManyRequests is the response with status code is 429
+type TooManyRequests struct {
+	Status    string    `json:"status" example:"error"`
+	Message   string    `json:"message" example:"discord is rate limiting requests, try again in 5s"`
+	ErrorCode ErrorCode `json:"error_code" example:"rate_limited"`
 }
 
 // UnprocessableEntity is the response with status code is 422
 type UnprocessableEntity struct {
-	Status  string              `json:"status" example:"error"`
-	Message string              `json:"message" example:"validation errors while sending message"`
-	Data    map[string][]string `json:"data"`
+	Status    string              `json:"status" example:"error"`
+	Message   string              `json:"message" example:"validation errors while sending message"`
+	ErrorCode ErrorCode           `json:"error_code" example:"validation_failed"`
+	Data      map[string][]string `json:"data"`
 }
 
 // Unauthorized is the response with status code is 403
 type Unauthorized struct {
-	Status  string `json:"status" example:"error"`
-	Message string `json:"message" example:"You are not authorized to carry out this request."`
-	Data    string `json:"data" example:"Make sure your API key is set in the [X-API-Key] header in the request"`
+	Status    string    `json:"status" example:"error"`
+	Message   string    `json:"message" example:"You are not authorized to carry out this request."`
+	ErrorCode ErrorCode `json:"error_code" example:"unauthorized"`
+	Data      string    `json:"data" example:"Make sure your API key is set in the [X-API-Key] header in the request"`
+}
+
+// Forbidden is the response when the authenticated user is not allowed to carry out the request
+type Forbidden struct {
+	Status    string    `json:"status" example:"error"`
+	Message   string    `json:"message" example:"Forbidden"`
+	ErrorCode ErrorCode `json:"error_code" example:"forbidden"`
 }
 
 // NoContent is the response when status code is 204
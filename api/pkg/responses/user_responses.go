@@ -7,3 +7,9 @@ type UserResponse struct {
 	response
 	Data entities.User `json:"data"`
 }
+
+// UsersResponse is the payload containing a list of entities.User
+type UsersResponse struct {
+	response
+	Data []entities.User `json:"data"`
+}
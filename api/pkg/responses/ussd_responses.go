@@ -0,0 +1,9 @@
+package responses
+
+import "github.com/NdoleStudio/httpsms/pkg/entities"
+
+// UssdSessionResponse is the payload containing entities.UssdSession
+type UssdSessionResponse struct {
+	response
+	Data entities.UssdSession `json:"data"`
+}
@@ -1,6 +1,10 @@
 package responses
 
-import "github.com/NdoleStudio/httpsms/pkg/entities"
+import (
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+)
 
 // WebhookResponse is the payload containing entities.Webhook
 type WebhookResponse struct {
@@ -13,3 +17,27 @@ type WebhooksResponse struct {
 	response
 	Data []entities.Webhook `json:"data"`
 }
+
+// WebhookReplayResponse is the payload containing an entities.WebhookReplay
+type WebhookReplayResponse struct {
+	response
+	Data entities.WebhookReplay `json:"data"`
+}
+
+// WebhookStatsResponse is the payload containing the services.WebhookStats
+type WebhookStatsResponse struct {
+	response
+	Data services.WebhookStats `json:"data"`
+}
+
+// WebhookEgressResponse is the payload containing the services.WebhookEgress
+type WebhookEgressResponse struct {
+	response
+	Data services.WebhookEgress `json:"data"`
+}
+
+// WebhookSchemaResponse is the payload containing the events.JSONSchema of the webhook events
+type WebhookSchemaResponse struct {
+	response
+	Data events.JSONSchema `json:"data"`
+}
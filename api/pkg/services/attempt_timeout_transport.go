@@ -0,0 +1,55 @@
+package services
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+type attemptTimeoutKey struct{}
+
+// WithAttemptTimeout sets the timeout of each attempt of a request which is sent through an AttemptTimeoutTransport
+func WithAttemptTimeout(ctx context.Context, timeout time.Duration) context.Context {
+	return context.WithValue(ctx, attemptTimeoutKey{}, timeout)
+}
+
+// AttemptTimeoutTransport applies the timeout of the request context to each attempt of a retried request.
+// It is used below the retry transport so that an attempt which times out is retried instead of cancelling the request.
+type AttemptTimeoutTransport struct {
+	transport http.RoundTripper
+}
+
+// NewAttemptTimeoutTransport creates a new AttemptTimeoutTransport
+func NewAttemptTimeoutTransport(transport http.RoundTripper) *AttemptTimeoutTransport {
+	return &AttemptTimeoutTransport{transport: transport}
+}
+
+// RoundTrip sends the request with the timeout of the request context, the request is sent without a timeout when the context has no timeout
+func (transport *AttemptTimeoutTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	timeout, ok := request.Context().Value(attemptTimeoutKey{}).(time.Duration)
+	if !ok || timeout <= 0 {
+		return transport.transport.RoundTrip(request)
+	}
+
+	ctx, cancel := context.WithTimeout(request.Context(), timeout)
+	response, err := transport.transport.RoundTrip(request.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	// the timeout also applies to reading the body so the context is cancelled when the body is closed
+	response.Body = &cancelOnCloseBody{ReadCloser: response.Body, cancel: cancel}
+	return response, nil
+}
+
+type cancelOnCloseBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (body *cancelOnCloseBody) Close() error {
+	defer body.cancel()
+	return body.ReadCloser.Close()
+}
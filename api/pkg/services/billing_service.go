@@ -53,16 +53,16 @@ func (service *BillingService) IsEntitledWithCount(ctx context.Context, userID e
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	user, err := service.userRepository.Load(ctx, userID)
+	user, err := service.billingUser(ctx, userID)
 	if err != nil {
 		msg := fmt.Sprintf("cannot load user with ID [%s], entitlement successfull", userID)
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 		return nil
 	}
 
-	usage, err := service.billingUsageRepository.GetCurrent(ctx, userID)
+	usage, err := service.billingUsageRepository.GetCurrent(ctx, user.ID)
 	if err != nil {
-		msg := fmt.Sprintf("cannot load billing usage for user with ID [%s], entitlement successfull", userID)
+		msg := fmt.Sprintf("cannot load billing usage for user with ID [%s], entitlement successfull", user.ID)
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 		return nil
 	}
@@ -74,6 +74,42 @@ func (service *BillingService) IsEntitledWithCount(ctx context.Context, userID e
 	return nil
 }
 
+// billingUser loads the user who is billed for the messages of a user, the main account is billed for the messages of its sub-accounts
+func (service *BillingService) billingUser(ctx context.Context, userID entities.UserID) (*entities.User, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !user.IsSubAccount() {
+		return user, nil
+	}
+
+	parent, err := service.userRepository.Load(ctx, user.BillingUserID())
+	if err != nil {
+		msg := fmt.Sprintf("cannot load main account with ID [%s] of sub-account [%s]", user.BillingUserID(), userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return parent, nil
+}
+
+// billingUserID is the ID of the user who is billed for the messages of a user, it is the user when the user cannot be loaded
+func (service *BillingService) billingUserID(ctx context.Context, userID entities.UserID) entities.UserID {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load user with ID [%s] to find the billing user", userID)))
+		return userID
+	}
+	return user.BillingUserID()
+}
+
 // IsEntitled checks if a user can send or receive and SMS message
 func (service *BillingService) IsEntitled(ctx context.Context, userID entities.UserID) *string {
 	return service.IsEntitledWithCount(ctx, userID, 1)
@@ -125,7 +161,7 @@ func (service *BillingService) GetCurrentUsage(ctx context.Context, userID entit
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
-	return service.billingUsageRepository.GetCurrent(ctx, userID)
+	return service.billingUsageRepository.GetCurrent(ctx, service.billingUserID(ctx, userID))
 }
 
 // GetUsageHistory gets the billing usage history for a user
@@ -133,7 +169,7 @@ func (service *BillingService) GetUsageHistory(ctx context.Context, userID entit
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
-	return service.billingUsageRepository.GetHistory(ctx, userID, params)
+	return service.billingUsageRepository.GetHistory(ctx, service.billingUserID(ctx, userID), params)
 }
 
 // RegisterSentMessage records the billing usage for a sent message
@@ -143,6 +179,7 @@ func (service *BillingService) RegisterSentMessage(ctx context.Context, messageI
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	userID = service.billingUserID(ctx, userID)
 	if err := service.billingUsageRepository.RegisterSentMessage(ctx, timestamp, userID); err != nil {
 		msg := fmt.Sprintf("could not register [sent] message with ID [%s] for user with ID [%s]", messageID, userID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -160,6 +197,7 @@ func (service *BillingService) RegisterReceivedMessage(ctx context.Context, mess
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	userID = service.billingUserID(ctx, userID)
 	if err := service.billingUsageRepository.RegisterReceivedMessage(ctx, timestamp, userID); err != nil {
 		msg := fmt.Sprintf("could not register [received] message with ID [%s] for user with ID [%s]", messageID, userID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -0,0 +1,380 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// ErrCodeBulkMessageJobInvalidState is returned when a bulk message job cannot be paused or resumed in its current status
+	ErrCodeBulkMessageJobInvalidState = stacktrace.ErrorCode(1123)
+
+	// MessageBulkJobRequestIDPrefix is the prefix of the request ID of the messages which are released by an entities.BulkMessageJob.
+	// It is different from MessageBulkRequestIDPrefix so that a running job is not counted twice as a pending bulk message file.
+	MessageBulkJobRequestIDPrefix = "drip-"
+
+	// bulkMessageJobUpdateAttempts is the number of times an update is retried when the job was changed by another request
+	bulkMessageJobUpdateAttempts = 3
+)
+
+// BulkMessageJobService spreads the messages of a bulk message file over a period of time
+type BulkMessageJobService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.BulkMessageJobRepository
+	messageService *MessageService
+	dispatcher     *EventDispatcher
+}
+
+// NewBulkMessageJobService creates a new BulkMessageJobService
+func NewBulkMessageJobService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.BulkMessageJobRepository,
+	messageService *MessageService,
+	dispatcher *EventDispatcher,
+) (s *BulkMessageJobService) {
+	return &BulkMessageJobService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		messageService: messageService,
+		dispatcher:     dispatcher,
+	}
+}
+
+// BulkMessageJobStoreParams are parameters for creating an entities.BulkMessageJob
+type BulkMessageJobStoreParams struct {
+	UserID   entities.UserID
+	Interval time.Duration
+	Items    []*entities.BulkMessageJobItem
+	Source   string
+}
+
+// Store creates an entities.BulkMessageJob and releases its first message
+func (service *BulkMessageJobService) Store(ctx context.Context, params *BulkMessageJobStoreParams) (*entities.BulkMessageJob, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	timestamp := time.Now().UTC()
+	job := &entities.BulkMessageJob{
+		ID:                   uuid.New(),
+		UserID:               params.UserID,
+		Status:               entities.BulkMessageJobStatusRunning,
+		IntervalMilliseconds: uint(max(params.Interval.Milliseconds(), 1)),
+		TotalMessages:        uint(len(params.Items)),
+		CreatedAt:            timestamp,
+		UpdatedAt:            timestamp,
+	}
+	job.Schedule(timestamp, 0)
+
+	for index, item := range params.Items {
+		item.JobID = job.ID
+		item.UserID = job.UserID
+		item.Position = uint(index)
+	}
+
+	if err := service.repository.Store(ctx, job, params.Items); err != nil {
+		msg := fmt.Sprintf("cannot store bulk message job with [%d] messages for user [%s]", job.TotalMessages, job.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := service.dispatchRelease(ctx, params.Source, job, 0); err != nil {
+		msg := fmt.Sprintf("cannot dispatch the first release of bulk message job [%s]", job.ID)
+		return nil, service.suspend(ctx, job, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created bulk message job [%s] with [%d] messages every [%s] for user [%s]", job.ID, job.TotalMessages, job.Interval(), job.UserID))
+	return job, nil
+}
+
+// Load an entities.BulkMessageJob by ID
+func (service *BulkMessageJobService) Load(ctx context.Context, userID entities.UserID, jobID uuid.UUID) (*entities.BulkMessageJob, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	job, err := service.repository.Load(ctx, userID, jobID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load bulk message job with ID [%s] for user [%s]", jobID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return job, nil
+}
+
+// CountActive counts the entities.BulkMessageJob of a user which are running or paused
+func (service *BulkMessageJobService) CountActive(ctx context.Context, userID entities.UserID) (int, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	count, err := service.repository.CountActive(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count the active bulk message jobs of user [%s]", userID)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return count, nil
+}
+
+// Pause stops releasing the messages of a running entities.BulkMessageJob
+func (service *BulkMessageJobService) Pause(ctx context.Context, userID entities.UserID, jobID uuid.UUID) (*entities.BulkMessageJob, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	job, err := service.update(ctx, userID, jobID, func(job *entities.BulkMessageJob) error {
+		if job.Status != entities.BulkMessageJobStatusRunning {
+			return stacktrace.NewErrorWithCode(ErrCodeBulkMessageJobInvalidState, fmt.Sprintf("bulk message job [%s] with status [%s] cannot be paused", job.ID, job.Status))
+		}
+
+		timestamp := time.Now().UTC()
+		job.Status = entities.BulkMessageJobStatusPaused
+		job.PausedAt = &timestamp
+		job.PausedReason = nil
+		job.NextReleaseAt = nil
+		job.EstimatedCompletionAt = nil
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot pause bulk message job with ID [%s] for user [%s]", jobID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("paused bulk message job [%s] after releasing [%d/%d] messages", job.ID, job.ProcessedMessages(), job.TotalMessages))
+	return job, nil
+}
+
+// BulkMessageJobResumeParams are parameters for resuming an entities.BulkMessageJob
+type BulkMessageJobResumeParams struct {
+	UserID entities.UserID
+	JobID  uuid.UUID
+	Source string
+}
+
+// Resume releases the remaining messages of a paused entities.BulkMessageJob at the interval of the job
+func (service *BulkMessageJobService) Resume(ctx context.Context, params *BulkMessageJobResumeParams) (*entities.BulkMessageJob, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	job, err := service.update(ctx, params.UserID, params.JobID, func(job *entities.BulkMessageJob) error {
+		if job.Status != entities.BulkMessageJobStatusPaused {
+			return stacktrace.NewErrorWithCode(ErrCodeBulkMessageJobInvalidState, fmt.Sprintf("bulk message job [%s] with status [%s] cannot be resumed", job.ID, job.Status))
+		}
+
+		job.Status = entities.BulkMessageJobStatusRunning
+		job.PausedAt = nil
+		job.PausedReason = nil
+		job.Schedule(time.Now().UTC(), 0)
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot resume bulk message job with ID [%s] for user [%s]", params.JobID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.dispatchRelease(ctx, params.Source, job, 0); err != nil {
+		msg := fmt.Sprintf("cannot dispatch the release of resumed bulk message job [%s]", job.ID)
+		return nil, service.suspend(ctx, job, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("resumed bulk message job [%s] with [%d] messages left", job.ID, job.TotalMessages-job.ProcessedMessages()))
+	return job, nil
+}
+
+// BulkMessageJobReleaseParams are parameters for releasing the next messages of an entities.BulkMessageJob
+type BulkMessageJobReleaseParams struct {
+	UserID   entities.UserID
+	JobID    uuid.UUID
+	Sequence uint
+	Source   string
+}
+
+// Release sends the next batch of messages of a running entities.BulkMessageJob and schedules the batch after it
+func (service *BulkMessageJobService) Release(ctx context.Context, params *BulkMessageJobReleaseParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	// the release is claimed by changing the sequence of the job so a release which is delivered twice does not send the same batch again
+	job, err := service.update(ctx, params.UserID, params.JobID, func(job *entities.BulkMessageJob) error {
+		if job.Status != entities.BulkMessageJobStatusRunning || job.Sequence != params.Sequence {
+			return stacktrace.NewErrorWithCode(ErrCodeBulkMessageJobInvalidState, fmt.Sprintf("release [%d] of bulk message job [%s] with status [%s] and sequence [%d] is not valid", params.Sequence, job.ID, job.Status, job.Sequence))
+		}
+		return nil
+	})
+	if stacktrace.GetCode(err) == ErrCodeBulkMessageJobInvalidState {
+		ctxLogger.Info(fmt.Sprintf("skipping release [%d] of bulk message job [%s]: %s", params.Sequence, params.JobID, stacktrace.RootCause(err)))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot claim release [%d] of bulk message job with ID [%s] for user [%s]", params.Sequence, params.JobID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	claimed := job.Sequence
+	size, delay := job.ReleaseBatch()
+	items, err := service.repository.FetchItems(ctx, job.ID, job.ProcessedMessages(), size)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the items at position [%d] of bulk message job [%s]", job.ProcessedMessages(), job.ID)
+		return service.suspend(ctx, job, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	released, failed := service.sendItems(ctx, params.Source, items)
+
+	scheduled := false
+	updated, err := service.update(ctx, job.UserID, job.ID, func(job *entities.BulkMessageJob) error {
+		scheduled = false
+		job.ReleasedMessages += released
+		job.FailedMessages += failed
+		if job.ProcessedMessages() >= job.TotalMessages || len(items) < int(size) {
+			timestamp := time.Now().UTC()
+			job.Status = entities.BulkMessageJobStatusCompleted
+			job.CompletedAt = &timestamp
+			job.PausedAt = nil
+			job.PausedReason = nil
+			job.NextReleaseAt = nil
+			job.EstimatedCompletionAt = nil
+			return nil
+		}
+
+		// the job was paused or resumed while the messages were sent so only the progress is recorded
+		if job.Status != entities.BulkMessageJobStatusRunning || job.Sequence != claimed {
+			return nil
+		}
+
+		scheduled = true
+		job.Schedule(time.Now().UTC(), delay)
+		return nil
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot save the progress of release [%d] of bulk message job [%s]", params.Sequence, params.JobID)
+		return service.suspend(ctx, job, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	job = updated
+	if !scheduled {
+		ctxLogger.Info(fmt.Sprintf("bulk message job [%s] has status [%s] after releasing [%d/%d] messages", job.ID, job.Status, job.ProcessedMessages(), job.TotalMessages))
+		return nil
+	}
+
+	if err = service.dispatchRelease(ctx, params.Source, job, delay); err != nil {
+		msg := fmt.Sprintf("cannot dispatch release [%d] of bulk message job [%s]", job.Sequence, job.ID)
+		return service.suspend(ctx, job, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("released [%d/%d] messages of bulk message job [%s], the next release is in [%s]", job.ProcessedMessages(), job.TotalMessages, job.ID, delay))
+	return nil
+}
+
+// sendItems adds the items to the queue of their phone and returns the number of released and failed messages
+func (service *BulkMessageJobService) sendItems(ctx context.Context, source string, items []*entities.BulkMessageJobItem) (released uint, failed uint) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	for _, item := range items {
+		owner, _ := phonenumbers.Parse(item.Owner, phonenumbers.UNKNOWN_REGION)
+		requestID := MessageBulkJobRequestIDPrefix + item.JobID.String()
+		messageID := item.MessageID()
+		_, err := service.messageService.SendMessage(ctx, MessageSendParams{
+			ID:                &messageID,
+			Source:            source,
+			Owner:             owner,
+			RequestID:         &requestID,
+			UserID:            item.UserID,
+			RequestReceivedAt: time.Now().UTC(),
+			Contact:           item.Contact,
+			Content:           item.Content,
+		})
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot send the message at position [%d] of bulk message job [%s]", item.Position, item.JobID)))
+			failed++
+			continue
+		}
+		released++
+	}
+	return released, failed
+}
+
+// update applies the change to the latest version of an entities.BulkMessageJob and retries when the job was changed by another request
+func (service *BulkMessageJobService) update(ctx context.Context, userID entities.UserID, jobID uuid.UUID, change func(job *entities.BulkMessageJob) error) (*entities.BulkMessageJob, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	for attempt := 0; attempt < bulkMessageJobUpdateAttempts; attempt++ {
+		job, err := service.repository.Load(ctx, userID, jobID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load bulk message job with ID [%s] for user [%s]", jobID, userID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+
+		sequence := job.Sequence
+		if err = change(job); err != nil {
+			return nil, service.tracer.WrapErrorSpan(span, err)
+		}
+
+		job.Sequence = sequence + 1
+		job.UpdatedAt = time.Now().UTC()
+		updated, err := service.repository.Update(ctx, job, sequence)
+		if err != nil {
+			msg := fmt.Sprintf("cannot update bulk message job with ID [%s] for user [%s]", jobID, userID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if updated {
+			return job, nil
+		}
+	}
+
+	msg := fmt.Sprintf("cannot update bulk message job with ID [%s] after [%d] attempts because it keeps changing", jobID, bulkMessageJobUpdateAttempts)
+	return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+}
+
+// suspend pauses a job which cannot release its next messages so that it does not stay running without a scheduled release, the job continues from the last recorded position when it is resumed
+func (service *BulkMessageJobService) suspend(ctx context.Context, job *entities.BulkMessageJob, cause error) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	reason := "cannot schedule the next release of the messages"
+	_, err := service.update(ctx, job.UserID, job.ID, func(job *entities.BulkMessageJob) error {
+		if job.Status != entities.BulkMessageJobStatusRunning {
+			return nil
+		}
+
+		timestamp := time.Now().UTC()
+		job.Status = entities.BulkMessageJobStatusPaused
+		job.PausedAt = &timestamp
+		job.PausedReason = &reason
+		job.NextReleaseAt = nil
+		job.EstimatedCompletionAt = nil
+		return nil
+	})
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot pause bulk message job [%s] which cannot release its messages", job.ID)))
+	}
+
+	ctxLogger.Warn(stacktrace.Propagate(cause, fmt.Sprintf("paused bulk message job [%s] with reason [%s]", job.ID, reason)))
+	return cause
+}
+
+func (service *BulkMessageJobService) dispatchRelease(ctx context.Context, source string, job *entities.BulkMessageJob, delay time.Duration) error {
+	event, err := service.createEvent(events.EventTypeBulkMessageJobRelease, source, &events.BulkMessageJobReleasePayload{
+		JobID:    job.ID,
+		UserID:   job.UserID,
+		Sequence: job.Sequence,
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for bulk message job [%s]", events.EventTypeBulkMessageJobRelease, job.ID))
+	}
+
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, max(delay, time.Nanosecond)); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch [%s] event for bulk message job [%s]", event.Type(), job.ID))
+	}
+	return nil
+}
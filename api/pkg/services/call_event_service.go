@@ -0,0 +1,152 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// CallEventService is responsible for handling phone calls registered by the android phone
+type CallEventService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	repository      repositories.CallEventRepository
+	phoneRepository repositories.PhoneRepository
+	dispatcher      *EventDispatcher
+}
+
+// NewCallEventService creates a new CallEventService
+func NewCallEventService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.CallEventRepository,
+	phoneRepository repositories.PhoneRepository,
+	dispatcher *EventDispatcher,
+) (s *CallEventService) {
+	return &CallEventService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		repository:      repository,
+		phoneRepository: phoneRepository,
+		dispatcher:      dispatcher,
+	}
+}
+
+// Index fetches the entities.CallEvent of a phone
+func (service *CallEventService) Index(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, params repositories.IndexParams) (*[]entities.CallEvent, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	callEvents, err := service.repository.Index(ctx, userID, phoneID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch call events for phone [%s] with params [%+#v]", phoneID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] call events for phone [%s] with params [%+#v]", len(*callEvents), phoneID, params))
+	return callEvents, nil
+}
+
+// CallEventStoreParams are parameters for creating a new entities.CallEvent
+type CallEventStoreParams struct {
+	PhoneID         uuid.UUID
+	UserID          entities.UserID
+	Contact         string
+	Type            entities.CallEventType
+	SIM             entities.SIM
+	DurationSeconds uint
+	Timestamp       time.Time
+	Source          string
+}
+
+// Store a new entities.CallEvent
+func (service *CallEventService) Store(ctx context.Context, params *CallEventStoreParams) (*entities.CallEvent, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with ID [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	callEvent := &entities.CallEvent{
+		ID:              uuid.New(),
+		PhoneID:         phone.ID,
+		UserID:          phone.UserID,
+		Owner:           phone.PhoneNumber,
+		Contact:         params.Contact,
+		Type:            params.Type,
+		SIM:             params.SIM,
+		DurationSeconds: params.DurationSeconds,
+		Timestamp:       params.Timestamp,
+		CreatedAt:       time.Now().UTC(),
+	}
+
+	if err = service.repository.Store(ctx, callEvent); err != nil {
+		msg := fmt.Sprintf("cannot save call event with ID [%s] for phone [%s]", callEvent.ID, phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("call event saved with ID [%s] for phone [%s] and user [%s]", callEvent.ID, callEvent.PhoneID, callEvent.UserID))
+
+	if err = service.dispatchCallEvent(ctx, params.Source, callEvent); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event for call event with ID [%s]", callEvent.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return callEvent, nil
+}
+
+func (service *CallEventService) dispatchCallEvent(ctx context.Context, source string, callEvent *entities.CallEvent) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	eventType := events.EventTypeCallReceived
+	var payload any = &events.CallReceivedPayload{
+		CallEventID:     callEvent.ID,
+		PhoneID:         callEvent.PhoneID,
+		UserID:          callEvent.UserID,
+		Owner:           callEvent.Owner,
+		Contact:         callEvent.Contact,
+		SIM:             callEvent.SIM,
+		DurationSeconds: callEvent.DurationSeconds,
+		Timestamp:       callEvent.Timestamp,
+	}
+
+	if callEvent.IsMissed() {
+		eventType = events.EventTypeCallMissed
+		payload = &events.CallMissedPayload{
+			CallEventID:     callEvent.ID,
+			PhoneID:         callEvent.PhoneID,
+			UserID:          callEvent.UserID,
+			Owner:           callEvent.Owner,
+			Contact:         callEvent.Contact,
+			SIM:             callEvent.SIM,
+			DurationSeconds: callEvent.DurationSeconds,
+			Timestamp:       callEvent.Timestamp,
+		}
+	}
+
+	event, err := service.createEvent(eventType, source, payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for call event [%s]", eventType, callEvent.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event with ID [%s] for call event [%s]", event.Type(), event.ID(), callEvent.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] event dispatched with ID [%s] for call event [%s]", event.Type(), event.ID(), callEvent.ID))
+	return nil
+}
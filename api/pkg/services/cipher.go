@@ -0,0 +1,68 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"io"
+
+	"github.com/palantir/stacktrace"
+)
+
+// Cipher encrypts secrets with AES-256-GCM before they are stored in the database
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a new Cipher from a base64 encoded 32 byte key
+func NewCipher(key string) (*Cipher, error) {
+	secret, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decode the base64 encryption key")
+	}
+
+	if len(secret) != 32 {
+		return nil, stacktrace.NewError(fmt.Sprintf("the encryption key must have 32 bytes but it has [%d] bytes", len(secret)))
+	}
+
+	block, err := aes.NewCipher(secret)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create AES block cipher")
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot create GCM cipher")
+	}
+
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt returns the base64 encoded nonce and ciphertext of the plaintext
+func (c *Cipher) Encrypt(plaintext []byte) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", stacktrace.Propagate(err, "cannot generate nonce")
+	}
+	return base64.StdEncoding.EncodeToString(c.aead.Seal(nonce, nonce, plaintext, nil)), nil
+}
+
+// Decrypt returns the plaintext of a value which was encrypted with Encrypt
+func (c *Cipher) Decrypt(ciphertext string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decode the base64 ciphertext")
+	}
+
+	if len(data) < c.aead.NonceSize() {
+		return nil, stacktrace.NewError(fmt.Sprintf("the ciphertext has [%d] bytes which is shorter than the nonce", len(data)))
+	}
+
+	plaintext, err := c.aead.Open(nil, data[:c.aead.NonceSize()], data[c.aead.NonceSize():], nil)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decrypt the ciphertext")
+	}
+	return plaintext, nil
+}
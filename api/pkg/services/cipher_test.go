@@ -0,0 +1,96 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCipher(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{name: "a 32 byte key is valid", key: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))), valid: true},
+		{name: "a 16 byte key is not valid", key: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 16)))},
+		{name: "a key which is not base64 is not valid", key: "!!"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			cipher, err := NewCipher(tt.key)
+
+			// Assert
+			assert.Equal(t, tt.valid, err == nil)
+			assert.Equal(t, tt.valid, cipher != nil)
+		})
+	}
+}
+
+func TestCipher_Decrypt(t *testing.T) {
+	cipher, err := NewCipher(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	assert.Nil(t, err)
+	other, err := NewCipher(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32))))
+	assert.Nil(t, err)
+
+	ciphertext, err := cipher.Encrypt([]byte("secret"))
+	assert.Nil(t, err)
+	otherCiphertext, err := other.Encrypt([]byte("secret"))
+	assert.Nil(t, err)
+
+	data, _ := base64.StdEncoding.DecodeString(ciphertext)
+	data[len(data)-1] ^= 1
+	tampered := base64.StdEncoding.EncodeToString(data)
+
+	tests := []struct {
+		name       string
+		ciphertext string
+		valid      bool
+	}{
+		{name: "an encrypted value is decrypted", ciphertext: ciphertext, valid: true},
+		{name: "a value encrypted with another key is not decrypted", ciphertext: otherCiphertext},
+		{name: "a tampered value is not decrypted", ciphertext: tampered},
+		{name: "a value shorter than the nonce is not decrypted", ciphertext: base64.StdEncoding.EncodeToString([]byte("short"))},
+		{name: "a value which is not base64 is not decrypted", ciphertext: "!!"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			plaintext, err := cipher.Decrypt(tt.ciphertext)
+
+			// Assert
+			if !tt.valid {
+				assert.NotNil(t, err)
+				assert.Nil(t, plaintext)
+				return
+			}
+			assert.Nil(t, err)
+			assert.Equal(t, "secret", string(plaintext))
+		})
+	}
+
+	t.Run("the same value is encrypted with a different nonce", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Act
+		second, err := cipher.Encrypt([]byte("secret"))
+
+		// Assert
+		assert.Nil(t, err)
+		assert.NotEqual(t, ciphertext, second)
+	})
+}
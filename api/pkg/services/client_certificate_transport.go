@@ -0,0 +1,90 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	"github.com/palantir/stacktrace"
+)
+
+// clientCertificateTransportsMax is the maximum number of client certificates which have a pool of connections at the same time
+const clientCertificateTransportsMax = 256
+
+type clientCertificateKey struct{}
+
+// WithClientCertificate sets the certificate which is presented to the server by a ClientCertificateTransport during the TLS handshake
+func WithClientCertificate(ctx context.Context, certificate *tls.Certificate) context.Context {
+	return context.WithValue(ctx, clientCertificateKey{}, certificate)
+}
+
+// ParseClientCertificate parses a PEM encoded certificate and private key pair which is used for mutual TLS
+func ParseClientCertificate(certificatePEM string, keyPEM string) (*tls.Certificate, error) {
+	certificate, err := tls.X509KeyPair([]byte(certificatePEM), []byte(keyPEM))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot parse the PEM encoded client certificate and private key pair")
+	}
+
+	if certificate.Leaf, err = x509.ParseCertificate(certificate.Certificate[0]); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot parse the leaf of the client certificate")
+	}
+	return &certificate, nil
+}
+
+// ClientCertificateTransport presents the client certificate of the request context during the TLS handshake.
+// Each certificate has its own pool of connections so that a connection which was authenticated with a certificate is never reused for another certificate.
+type ClientCertificateTransport struct {
+	transport  *http.Transport
+	mutex      sync.Mutex
+	transports map[string]*http.Transport
+}
+
+// NewClientCertificateTransport creates a new ClientCertificateTransport
+func NewClientCertificateTransport(transport *http.Transport) *ClientCertificateTransport {
+	return &ClientCertificateTransport{
+		transport:  transport,
+		transports: map[string]*http.Transport{},
+	}
+}
+
+// RoundTrip sends the request with the client certificate of the request context, the request is sent without a certificate when the context has no certificate
+func (transport *ClientCertificateTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	certificate, ok := request.Context().Value(clientCertificateKey{}).(*tls.Certificate)
+	if !ok || certificate == nil || len(certificate.Certificate) == 0 {
+		return transport.transport.RoundTrip(request)
+	}
+	return transport.certificateTransport(certificate).RoundTrip(request)
+}
+
+func (transport *ClientCertificateTransport) certificateTransport(certificate *tls.Certificate) *http.Transport {
+	hash := sha256.Sum256(certificate.Certificate[0])
+	fingerprint := hex.EncodeToString(hash[:])
+
+	transport.mutex.Lock()
+	defer transport.mutex.Unlock()
+
+	if result, ok := transport.transports[fingerprint]; ok {
+		return result
+	}
+
+	// the pools of rotated certificates are removed once there are too many of them
+	if len(transport.transports) >= clientCertificateTransportsMax {
+		for key, value := range transport.transports {
+			value.CloseIdleConnections()
+			delete(transport.transports, key)
+		}
+	}
+
+	result := transport.transport.Clone()
+	if result.TLSClientConfig == nil {
+		result.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	result.TLSClientConfig.Certificates = []tls.Certificate{*certificate}
+
+	transport.transports[fingerprint] = result
+	return result
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ContentFilterService is responsible for the words and regular expressions which block sent messages and flag received messages.
+// The terms are never logged because they usually contain offensive words.
+type ContentFilterService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.ContentFilterRepository
+}
+
+// NewContentFilterService creates a new ContentFilterService
+func NewContentFilterService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.ContentFilterRepository,
+) (s *ContentFilterService) {
+	return &ContentFilterService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+	}
+}
+
+// Index fetches the entities.ContentFilter of a user
+func (service *ContentFilterService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.ContentFilter, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	filters, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch content filters for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] content filters for user [%s]", len(filters), userID))
+	return filters, nil
+}
+
+// ContentFilterStoreParams are parameters for creating a new entities.ContentFilter
+type ContentFilterStoreParams struct {
+	UserID    entities.UserID
+	Term      string
+	IsRegex   bool
+	Direction entities.ContentFilterDirection
+}
+
+// Store a new entities.ContentFilter
+func (service *ContentFilterService) Store(ctx context.Context, params *ContentFilterStoreParams) (*entities.ContentFilter, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	filter := &entities.ContentFilter{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Term:      params.Term,
+		IsRegex:   params.IsRegex,
+		Direction: params.Direction,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.Save(ctx, filter); err != nil {
+		msg := fmt.Sprintf("cannot save content filter with id [%s]", filter.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("content filter saved with id [%s] for user [%s] in the [%T]", filter.ID, filter.UserID, service.repository))
+	return filter, nil
+}
+
+// ContentFilterUpdateParams are parameters for updating an entities.ContentFilter
+type ContentFilterUpdateParams struct {
+	UserID    entities.UserID
+	FilterID  uuid.UUID
+	Term      string
+	IsRegex   bool
+	Direction entities.ContentFilterDirection
+}
+
+// Update an entities.ContentFilter
+func (service *ContentFilterService) Update(ctx context.Context, params *ContentFilterUpdateParams) (*entities.ContentFilter, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	filter, err := service.repository.Load(ctx, params.UserID, params.FilterID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load content filter with userID [%s] and filterID [%s]", params.UserID, params.FilterID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	filter.Term = params.Term
+	filter.IsRegex = params.IsRegex
+	filter.Direction = params.Direction
+	filter.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, filter); err != nil {
+		msg := fmt.Sprintf("cannot save content filter with id [%s] after update", filter.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("content filter updated with id [%s] for user [%s]", filter.ID, filter.UserID))
+	return filter, nil
+}
+
+// Delete an entities.ContentFilter
+func (service *ContentFilterService) Delete(ctx context.Context, userID entities.UserID, filterID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, filterID); err != nil {
+		msg := fmt.Sprintf("cannot load content filter with userID [%s] and filterID [%s]", userID, filterID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, filterID); err != nil {
+		msg := fmt.Sprintf("cannot delete content filter with id [%s] and user id [%s]", filterID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted content filter with id [%s] and user id [%s]", filterID, userID))
+	return nil
+}
+
+// Match returns the first entities.ContentFilter of the user which matches the content of a message in the direction, it is nil when no filter matches
+func (service *ContentFilterService) Match(ctx context.Context, userID entities.UserID, direction entities.ContentFilterDirection, content string) (*entities.ContentFilter, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	filters, err := service.repository.FetchAll(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch content filters for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, filter := range filters {
+		if filter.AppliesTo(direction) && filter.Matches(content) {
+			ctxLogger.Info(fmt.Sprintf("content filter [%s] of user [%s] matched an [%s] message", filter.ID, userID, direction))
+			return filter, nil
+		}
+	}
+
+	return nil, nil
+}
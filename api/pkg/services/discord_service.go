@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
+	"unicode"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
 	cloudevents "github.com/cloudevents/sdk-go/v2"
@@ -19,6 +21,20 @@ import (
 	"github.com/palantir/stacktrace"
 )
 
+const (
+	// ErrCodeDiscordRateLimited is returned when discord keeps rate limiting the requests
+	ErrCodeDiscordRateLimited = stacktrace.ErrorCode(1100)
+
+	// ErrCodeDiscordSenderRequired is returned when a slash command has no from option and the integration has no default sender
+	ErrCodeDiscordSenderRequired = stacktrace.ErrorCode(1108)
+
+	// ErrCodeDiscordSenderNotOwned is returned when the sender of a slash command is not a phone of the user
+	ErrCodeDiscordSenderNotOwned = stacktrace.ErrorCode(1109)
+
+	discordRateLimitMaxAttempts = 3
+	discordRateLimitMaxWait     = 10 * time.Second
+)
+
 // DiscordService is responsible for handling discordIntegrations
 type DiscordService struct {
 	service
@@ -27,6 +43,12 @@ type DiscordService struct {
 	client     *discord.Client
 	dispatcher *EventDispatcher
 	repository repositories.DiscordRepository
+
+	// phoneRepository is used to check that the sender of a slash command is a phone of the user
+	phoneRepository repositories.PhoneRepository
+
+	// drainer keeps track of the deferred responses to interactions which are still being sent
+	drainer *Drainer
 }
 
 // NewDiscordService creates a new DiscordService
@@ -36,16 +58,56 @@ func NewDiscordService(
 	client *discord.Client,
 	repository repositories.DiscordRepository,
 	dispatcher *EventDispatcher,
+	phoneRepository repositories.PhoneRepository,
+	drainer *Drainer,
 ) (s *DiscordService) {
 	return &DiscordService{
-		logger:     logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:     tracer,
-		client:     client,
-		dispatcher: dispatcher,
-		repository: repository,
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		client:          client,
+		dispatcher:      dispatcher,
+		repository:      repository,
+		phoneRepository: phoneRepository,
+		drainer:         drainer,
 	}
 }
 
+// ResolveSender returns the sender of a slash command, the default sender of the integration is used when the from option is empty.
+// The sender must be the phone number or the alphanumeric sender ID of one of the phones of the user.
+func (service *DiscordService) ResolveSender(ctx context.Context, discordIntegration *entities.Discord, from string) (string, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if from == "" && discordIntegration.DefaultFrom != nil {
+		from = *discordIntegration.DefaultFrom
+	}
+
+	if from == "" {
+		msg := fmt.Sprintf("the slash command has no from option and discord integration [%s] has no default sender", discordIntegration.ID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeDiscordSenderRequired, msg))
+	}
+
+	var err error
+	if strings.IndexFunc(from, unicode.IsLetter) != -1 {
+		_, err = service.phoneRepository.LoadByAlphanumericSenderID(ctx, discordIntegration.UserID, from)
+	} else {
+		_, err = service.phoneRepository.Load(ctx, discordIntegration.UserID, from)
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("the sender [%s] of discord integration [%s] is not a phone of user [%s]", from, discordIntegration.ID, discordIntegration.UserID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeDiscordSenderNotOwned, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with sender [%s] for user [%s]", from, discordIntegration.UserID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("resolved sender [%s] for discord integration [%s]", from, discordIntegration.ID))
+	return from, nil
+}
+
 // GetByServerID fetches the entities.Discord by the serverID
 func (service *DiscordService) GetByServerID(ctx context.Context, serverID string) (*entities.Discord, error) {
 	ctx, span, _ := service.tracer.StartWithLogger(ctx, service.logger)
@@ -71,23 +133,39 @@ func (service *DiscordService) Index(ctx context.Context, userID entities.UserID
 }
 
 // Delete an entities.Discord
-func (service *DiscordService) Delete(ctx context.Context, userID entities.UserID, discordID uuid.UUID) error {
+func (service *DiscordService) Delete(ctx context.Context, source string, userID entities.UserID, discordID uuid.UUID) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	if _, err := service.repository.Load(ctx, userID, discordID); err != nil {
+	discordIntegration, err := service.repository.Load(ctx, userID, discordID)
+	if err != nil {
 		msg := fmt.Sprintf("cannot load discord integration with userID [%s] and discordID [%s]", userID, discordID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	if err := service.repository.Delete(ctx, userID, discordID); err != nil {
+	if err = service.repository.Delete(ctx, userID, discordID); err != nil {
 		msg := fmt.Sprintf("cannot delete discord integration with id [%s] and discordID [%s]", discordID, userID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
 	ctxLogger.Info(fmt.Sprintf("deleted discord integration with id [%s] and user id [%s]", discordID, userID))
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeIntegrationDeleted, source, events.IntegrationDeletedPayload{
+		IntegrationID:   discordID,
+		IntegrationType: "discord",
+		UserID:          userID,
+		Timestamp:       time.Now().UTC(),
+	})
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeDiscordIntegrationDeleted, source, events.DiscordIntegrationDeletedPayload{
+		IntegrationID: discordIntegration.ID,
+		UserID:        userID,
+		Name:          discordIntegration.Name,
+		ServerID:      discordIntegration.ServerID,
+		Timestamp:     time.Now().UTC(),
+	})
 	return nil
 }
 
@@ -97,6 +175,8 @@ type DiscordStoreParams struct {
 	Name              string
 	ServerID          string
 	IncomingChannelID string
+	DefaultFrom       *string
+	Source            string
 }
 
 // Store a new entities.Discord
@@ -104,9 +184,10 @@ func (service *DiscordService) Store(ctx context.Context, params *DiscordStorePa
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	if err := service.createSlashCommand(ctx, params.ServerID); err != nil {
+	command, err := service.createSlashCommand(ctx, params.ServerID)
+	if err != nil {
 		msg := fmt.Sprintf("cannot create slash command for server [%s]", params.ServerID)
-		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
 	discordIntegration := &entities.Discord{
@@ -115,6 +196,8 @@ func (service *DiscordService) Store(ctx context.Context, params *DiscordStorePa
 		Name:              params.Name,
 		ServerID:          params.ServerID,
 		IncomingChannelID: params.IncomingChannelID,
+		DefaultFrom:       params.DefaultFrom,
+		CommandID:         &command.ID,
 		CreatedAt:         time.Now().UTC(),
 		UpdatedAt:         time.Now().UTC(),
 	}
@@ -125,24 +208,62 @@ func (service *DiscordService) Store(ctx context.Context, params *DiscordStorePa
 	}
 
 	ctxLogger.Info(fmt.Sprintf("discord integration saved with id [%s] in the [%T]", discordIntegration.ID, service.repository))
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeIntegrationCreated, params.Source, events.IntegrationCreatedPayload{
+		IntegrationID:   discordIntegration.ID,
+		IntegrationType: "discord",
+		UserID:          discordIntegration.UserID,
+		Timestamp:       discordIntegration.CreatedAt,
+	})
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeDiscordIntegrationCreated, params.Source, events.DiscordIntegrationCreatedPayload{
+		IntegrationID: discordIntegration.ID,
+		UserID:        discordIntegration.UserID,
+		Name:          discordIntegration.Name,
+		ServerID:      discordIntegration.ServerID,
+		Timestamp:     discordIntegration.CreatedAt,
+	})
 	return discordIntegration, nil
 }
 
-func (service *DiscordService) createSlashCommand(ctx context.Context, serverID string) error {
+// SyncCommand registers the slash command of an entities.Discord again and stores the command ID
+func (service *DiscordService) SyncCommand(ctx context.Context, userID entities.UserID, discordID uuid.UUID) (*discord.CommandCreateResponse, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	discordIntegration, err := service.repository.Load(ctx, userID, discordID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load discord integration with userID [%s] and discordID [%s]", userID, discordID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	command, err := service.createSlashCommand(ctx, discordIntegration.ServerID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot sync slash command for discord integration [%s]", discordIntegration.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	discordIntegration.CommandID = &command.ID
+	discordIntegration.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, discordIntegration); err != nil {
+		msg := fmt.Sprintf("cannot save discord integration with id [%s] after syncing command [%s]", discordIntegration.ID, command.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("synced slash command [%s] for discord integration [%s]", command.ID, discordIntegration.ID))
+	return command, nil
+}
+
+func (service *DiscordService) createSlashCommand(ctx context.Context, serverID string) (*discord.CommandCreateResponse, error) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	command, _, err := service.client.Application.CreateCommand(ctx, serverID, &discord.CommandCreateRequest{
+	request := &discord.CommandCreateRequest{
 		Name:        "httpsms",
 		Type:        1,
 		Description: "Send an SMS via httpsms.com",
 		Options: []discord.CommandCreateRequestOption{
-			{
-				Name:        "from",
-				Description: "Sender phone number",
-				Type:        3,
-				Required:    true,
-			},
 			{
 				Name:        "to",
 				Description: "Recipient phone number",
@@ -155,15 +276,89 @@ func (service *DiscordService) createSlashCommand(ctx context.Context, serverID
 				Type:        3,
 				Required:    true,
 			},
+			// discord requires the optional options after the required options
+			{
+				Name:        "from",
+				Description: "Sender phone number, defaults to the sender of the integration",
+				Type:        3,
+				Required:    false,
+			},
 		},
-	})
-	if err != nil {
-		msg := fmt.Sprintf("cannot create slash command for server [%s]", serverID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	ctxLogger.Info(fmt.Sprintf("upserted a slash command with ID [%s] for discord server [%s] and applicationID [%s]", command.ID, serverID, command.ApplicationID))
-	return nil
+	for attempt := 1; ; attempt++ {
+		command, response, err := service.client.Application.CreateCommand(ctx, serverID, request)
+		if err == nil {
+			ctxLogger.Info(fmt.Sprintf("upserted a slash command with ID [%s] for discord server [%s] and applicationID [%s]", command.ID, serverID, command.ApplicationID))
+			return command, nil
+		}
+
+		if response == nil || !response.IsRateLimited() {
+			msg := fmt.Sprintf("cannot create slash command for server [%s]", serverID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		retryAfter := response.RetryAfter()
+		if attempt >= discordRateLimitMaxAttempts || retryAfter > discordRateLimitMaxWait {
+			msg := fmt.Sprintf("discord rate limited creating slash command for server [%s] after [%d] attempts, retry after [%s]", serverID, attempt, retryAfter)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeDiscordRateLimited, msg))
+		}
+
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("discord rate limited creating slash command for server [%s], retrying after [%s]", serverID, retryAfter)))
+		select {
+		case <-ctx.Done():
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(ctx.Err(), fmt.Sprintf("context done while waiting to create slash command for server [%s]", serverID)))
+		case <-time.After(retryAfter):
+		}
+	}
+}
+
+// DeferInteraction runs respond in the background after a deferred response to an interaction and edits the response with the payload returned by respond.
+// The interaction token is only kept in memory until the response is edited since discord invalidates it after 15 minutes.
+func (service *DiscordService) DeferInteraction(ctx context.Context, interactionID string, interactionToken string, respond func(ctx context.Context) fiber.Map) {
+	done := service.drainer.Add()
+	go func(ctx context.Context) {
+		defer done()
+		service.editInteractionResponse(ctx, interactionID, interactionToken, respond(ctx))
+	}(context.WithoutCancel(ctx))
+}
+
+func (service *DiscordService) editInteractionResponse(ctx context.Context, interactionID string, interactionToken string, payload fiber.Map) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	for attempt := 1; ; attempt++ {
+		_, response, err := service.client.Interaction.EditOriginalResponse(ctx, interactionToken, payload)
+		if err == nil {
+			ctxLogger.Info(fmt.Sprintf("edited the deferred response of discord interaction [%s]", interactionID))
+			return
+		}
+
+		if response == nil || !response.IsRateLimited() {
+			msg := fmt.Sprintf("cannot edit the deferred response of discord interaction [%s]", interactionID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+			return
+		}
+
+		retryAfter := response.RetryAfter()
+		if attempt >= discordRateLimitMaxAttempts || retryAfter > discordRateLimitMaxWait {
+			msg := fmt.Sprintf("discord rate limited editing the response of interaction [%s] after [%d] attempts, retry after [%s]", interactionID, attempt, retryAfter)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeDiscordRateLimited, msg)))
+			return
+		}
+
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("discord rate limited editing the response of interaction [%s], retrying after [%s]", interactionID, retryAfter)))
+		select {
+		case <-ctx.Done():
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(ctx.Err(), fmt.Sprintf("context done while waiting to edit the response of discord interaction [%s]", interactionID))))
+			return
+		case <-service.drainer.Stopping():
+			msg := fmt.Sprintf("stopped waiting to edit the response of discord interaction [%s] because the server is shutting down", interactionID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeDiscordRateLimited, msg)))
+			return
+		case <-time.After(retryAfter):
+		}
+	}
 }
 
 // DiscordUpdateParams are parameters for updating an entities.Discord
@@ -172,7 +367,9 @@ type DiscordUpdateParams struct {
 	Name              string
 	ServerID          string
 	IncomingChannelID string
+	DefaultFrom       *string
 	DiscordID         uuid.UUID
+	Source            string
 }
 
 // Update an entities.Discord
@@ -186,14 +383,17 @@ func (service *DiscordService) Update(ctx context.Context, params *DiscordUpdate
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	if err = service.createSlashCommand(ctx, params.ServerID); err != nil {
+	command, err := service.createSlashCommand(ctx, params.ServerID)
+	if err != nil {
 		msg := fmt.Sprintf("cannot create slash command for server [%s]", params.ServerID)
-		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
+	discordIntegration.CommandID = &command.ID
 	discordIntegration.Name = params.Name
 	discordIntegration.ServerID = params.ServerID
 	discordIntegration.IncomingChannelID = params.IncomingChannelID
+	discordIntegration.DefaultFrom = params.DefaultFrom
 
 	if err = service.repository.Save(ctx, discordIntegration); err != nil {
 		msg := fmt.Sprintf("cannot save discord integration with id [%s] after update", discordIntegration.ID)
@@ -201,9 +401,40 @@ func (service *DiscordService) Update(ctx context.Context, params *DiscordUpdate
 	}
 
 	ctxLogger.Info(fmt.Sprintf("discord integration updated with id [%s] in the [%T]", discordIntegration.ID, service.repository))
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeIntegrationUpdated, params.Source, events.IntegrationUpdatedPayload{
+		IntegrationID:   discordIntegration.ID,
+		IntegrationType: "discord",
+		UserID:          discordIntegration.UserID,
+		Timestamp:       time.Now().UTC(),
+	})
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeDiscordIntegrationUpdated, params.Source, events.DiscordIntegrationUpdatedPayload{
+		IntegrationID: discordIntegration.ID,
+		UserID:        discordIntegration.UserID,
+		Name:          discordIntegration.Name,
+		ServerID:      discordIntegration.ServerID,
+		Timestamp:     time.Now().UTC(),
+	})
 	return discordIntegration, nil
 }
 
+// dispatchIntegrationEvent records a change to a discord integration, the error is logged because the change has already been saved
+func (service *DiscordService) dispatchIntegrationEvent(ctx context.Context, eventType string, source string, payload any) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createEvent(eventType, source, payload)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for payload [%+#v]", eventType, payload)))
+		return
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch [%s] event with ID [%s]", event.Type(), event.ID())))
+	}
+}
+
 // HandleMessageReceived sends an incoming SMS to a discord channel
 func (service *DiscordService) HandleMessageReceived(ctx context.Context, userID entities.UserID, event cloudevents.Event) error {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+)
+
+// Drainer keeps track of in-flight work e.g. FCM pushes and webhook deliveries so they can be drained on shutdown
+type Drainer struct {
+	logger    telemetry.Logger
+	mutex     sync.Mutex
+	pending   int
+	completed int
+	stopping  chan struct{}
+	stop      sync.Once
+}
+
+// NewDrainer creates a new Drainer
+func NewDrainer(logger telemetry.Logger) (d *Drainer) {
+	return &Drainer{
+		logger:   logger.WithService(fmt.Sprintf("%T", d)),
+		stopping: make(chan struct{}),
+	}
+}
+
+// Add registers a new in-flight item, the returned function must be called when the item is done
+func (drainer *Drainer) Add() (done func()) {
+	drainer.mutex.Lock()
+	drainer.pending++
+	drainer.mutex.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			drainer.mutex.Lock()
+			drainer.pending--
+			drainer.completed++
+			drainer.mutex.Unlock()
+		})
+	}
+}
+
+// Stopping is closed when the drainer starts draining so long-lived items e.g. websockets can end themselves
+func (drainer *Drainer) Stopping() <-chan struct{} {
+	return drainer.stopping
+}
+
+// Drain waits for the in-flight items to complete until the context is done.
+// It returns the number of items which completed while draining and the number of items which were abandoned.
+func (drainer *Drainer) Drain(ctx context.Context) (drained int, abandoned int) {
+	drainer.stop.Do(func() { close(drainer.stopping) })
+
+	drainer.mutex.Lock()
+	start := drainer.completed
+	drainer.mutex.Unlock()
+
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		drainer.mutex.Lock()
+		drained, abandoned = drainer.completed-start, drainer.pending
+		drainer.mutex.Unlock()
+
+		if abandoned == 0 {
+			return drained, abandoned
+		}
+
+		select {
+		case <-ctx.Done():
+			return drained, abandoned
+		case <-ticker.C:
+		}
+	}
+}
@@ -104,7 +104,7 @@ func (service *EmailNotificationService) NotifyMessageFailed(ctx context.Context
 
 	user, err := service.userRepository.Load(ctx, payload.UserID)
 	if err != nil {
-		msg := fmt.Sprintf("cannot load user with ID [%s] for [%s] message with ID [%s]", payload.UserID, payload.ID)
+		msg := fmt.Sprintf("cannot load user with ID [%s] for [%s] message with ID [%s]", payload.UserID, events.EventTypeMessageSendFailed, payload.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
@@ -115,7 +115,7 @@ func (service *EmailNotificationService) NotifyMessageFailed(ctx context.Context
 
 	email, err := service.factory.MessageFailed(user, payload)
 	if err != nil {
-		msg := fmt.Sprintf("cannot create email for user with ID [%s] for [%s] message with ID [%s]", payload.UserID, payload.ID)
+		msg := fmt.Sprintf("cannot create email for user with ID [%s] for [%s] message with ID [%s]", payload.UserID, events.EventTypeMessageSendFailed, payload.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
@@ -168,6 +168,32 @@ func (service *EmailNotificationService) NotifyWebhookSendFailed(ctx context.Con
 	return nil
 }
 
+// NotifyWebhookDisabled sends an email to the user when a webhook is disabled after too many consecutive failures
+func (service *EmailNotificationService) NotifyWebhookDisabled(ctx context.Context, payload *events.WebhookDisabledPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, payload.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s] for [%s] event for webhook with ID [%s]", payload.UserID, events.EventTypeWebhookDisabled, payload.WebhookID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	email, err := service.factory.WebhookDisabled(user, payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] email for user with ID [%s] for webhook with ID [%s]", events.EventTypeWebhookDisabled, payload.UserID, payload.WebhookID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.mailer.Send(ctx, email); err != nil {
+		msg := fmt.Sprintf("cannot send [%s] email for user with ID [%s] for webhook with ID [%s]", events.EventTypeWebhookDisabled, payload.UserID, payload.WebhookID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] email sent to [%s] for webhook with ID [%s]", events.EventTypeWebhookDisabled, user.ID, payload.WebhookID))
+	return nil
+}
+
 // NotifyDiscordSendFailed sends an email to the user about a failed discord webhook event
 func (service *EmailNotificationService) NotifyDiscordSendFailed(ctx context.Context, payload *events.DiscordSendFailedPayload) error {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
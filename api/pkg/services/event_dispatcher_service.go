@@ -26,6 +26,7 @@ type EventDispatcher struct {
 	meter       metric.Float64Histogram
 	queue       PushQueue
 	queueConfig PushQueueConfig
+	drainer     *Drainer
 }
 
 // NewEventDispatcher creates a new EventDispatcher
@@ -35,6 +36,7 @@ func NewEventDispatcher(
 	meter metric.Float64Histogram,
 	queue PushQueue,
 	queueConfig PushQueueConfig,
+	drainer *Drainer,
 ) (dispatcher *EventDispatcher) {
 	return &EventDispatcher{
 		logger:      logger,
@@ -43,6 +45,7 @@ func NewEventDispatcher(
 		listeners:   make(map[string][]events.EventListener),
 		queue:       queue,
 		queueConfig: queueConfig,
+		drainer:     drainer,
 	}
 }
 
@@ -93,7 +96,9 @@ func (dispatcher *EventDispatcher) enqueue(ctx context.Context, event cloudevent
 		msg := fmt.Sprintf("cannot enqueue event with ID [%s] and type [%s] to [%T]", event.ID(), event.Type(), dispatcher.queue)
 		ctxLogger.Warn(stacktrace.Propagate(err, msg))
 		queueID, err = fmt.Sprintf("local-%s", event.ID()), nil
+		done := dispatcher.drainer.Add()
 		time.AfterFunc(timeout, func() {
+			defer done()
 			dispatcher.Publish(ctx, event)
 		})
 	}
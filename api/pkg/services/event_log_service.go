@@ -0,0 +1,309 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"gorm.io/datatypes"
+)
+
+// EventLogService is responsible for the append-only audit trail of the events which change the state of a resource
+type EventLogService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	drainer    *Drainer
+	repository repositories.EventLogRepository
+	broker     *UserEventBroker
+}
+
+// NewEventLogService creates a new EventLogService
+func NewEventLogService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	drainer *Drainer,
+	repository repositories.EventLogRepository,
+	broker *UserEventBroker,
+) (s *EventLogService) {
+	return &EventLogService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		drainer:    drainer,
+		repository: repository,
+		broker:     broker,
+	}
+}
+
+// eventLogStreamBatchSize is the number of events fetched at once when a stream catches up
+const eventLogStreamBatchSize = 100
+
+// eventLogStreamKeepAliveInterval is the interval at which a stream checks that the client is still connected
+const eventLogStreamKeepAliveInterval = 15 * time.Second
+
+// EventLogStreamParams are parameters for streaming the events of a user
+type EventLogStreamParams struct {
+	UserID entities.UserID
+	// LastSequence is the sequence of the last event received by the client, only new events are streamed when it is nil
+	LastSequence *int64
+
+	// Send writes an event to the client and KeepAlive is called periodically while there are no events, the stream stops when any of them returns an error
+	Send      func(log *entities.EventLog) error
+	KeepAlive func() error
+}
+
+// Stream sends the message and phone events of a user in the order in which they were stored until the client disconnects.
+// The events published while the client is busy are coalesced and fetched from the audit trail so that a slow client does not miss events.
+func (service *EventLogService) Stream(ctx context.Context, params EventLogStreamParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	// the subscription is created before the first query so that an event which is stored in-between is not missed
+	events, unsubscribe := service.broker.Subscribe(params.UserID)
+	defer unsubscribe()
+
+	sequence, err := service.lastStreamSequence(ctx, params)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot start the event stream for user [%s]", params.UserID)))
+	}
+
+	keepAlive := time.NewTicker(eventLogStreamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	ctxLogger.Info(fmt.Sprintf("streaming events for user [%s] after sequence [%d]", params.UserID, sequence))
+	for {
+		if sequence, err = service.sendAfter(ctx, params, sequence); err != nil {
+			msg := fmt.Sprintf("stopped the event stream for user [%s] at sequence [%d]", params.UserID, sequence)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		select {
+		case <-events:
+			service.drain(events)
+		case <-keepAlive.C:
+			if err = params.KeepAlive(); err != nil {
+				msg := fmt.Sprintf("stopped the event stream for user [%s] because the keep alive failed", params.UserID)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+		case <-ctx.Done():
+			msg := fmt.Sprintf("stopped the event stream for user [%s] because the context is done", params.UserID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(ctx.Err(), msg))
+		}
+	}
+}
+
+func (service *EventLogService) lastStreamSequence(ctx context.Context, params EventLogStreamParams) (int64, error) {
+	if params.LastSequence != nil {
+		return *params.LastSequence, nil
+	}
+
+	sequence, err := service.repository.LastSequence(ctx, params.UserID)
+	if err != nil {
+		return 0, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch the last event sequence for user [%s]", params.UserID))
+	}
+	return sequence, nil
+}
+
+// sendAfter sends the events after the sequence in batches and returns the sequence of the last event which was sent
+func (service *EventLogService) sendAfter(ctx context.Context, params EventLogStreamParams, sequence int64) (int64, error) {
+	for {
+		logs, err := service.repository.IndexAfterSequence(ctx, params.UserID, sequence, []string{"message", "phone"}, eventLogStreamBatchSize)
+		if err != nil {
+			return sequence, stacktrace.Propagate(err, fmt.Sprintf("cannot fetch events for user [%s] after sequence [%d]", params.UserID, sequence))
+		}
+
+		for _, log := range logs {
+			if err = params.Send(log); err != nil {
+				return sequence, stacktrace.Propagate(err, fmt.Sprintf("cannot send event log [%s] with sequence [%d]", log.ID, log.Sequence))
+			}
+			sequence = log.Sequence
+		}
+
+		if len(logs) < eventLogStreamBatchSize {
+			return sequence, nil
+		}
+	}
+}
+
+// drain removes the pending notifications because a single query fetches all the events which they refer to
+func (service *EventLogService) drain(events <-chan *entities.EventLog) {
+	for {
+		select {
+		case <-events:
+		default:
+			return
+		}
+	}
+}
+
+// phoneStatusEventTypes are the events which are fired when a phone goes online or offline
+var phoneStatusEventTypes = []string{
+	events.EventTypePhoneHeartbeatOnline,
+	events.EventTypePhoneHeartbeatOffline,
+}
+
+// PhoneStatusStreamParams are parameters for streaming the online status of the phones of a user
+type PhoneStatusStreamParams struct {
+	UserID entities.UserID
+
+	// Send writes an event to the client and KeepAlive is called periodically while there are no events, the stream stops when any of them returns an error
+	Send      func(log *entities.EventLog) error
+	KeepAlive func() error
+}
+
+// StreamPhoneStatus sends the online and offline events of the phones of a user which are stored by this instance of the API until the context is done
+func (service *EventLogService) StreamPhoneStatus(ctx context.Context, params PhoneStatusStreamParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	logs, unsubscribe := service.broker.Subscribe(params.UserID)
+	defer unsubscribe()
+
+	keepAlive := time.NewTicker(eventLogStreamKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	ctxLogger.Info(fmt.Sprintf("streaming the phone status for user [%s]", params.UserID))
+	for {
+		select {
+		case log := <-logs:
+			if !slices.Contains(phoneStatusEventTypes, log.Type) {
+				continue
+			}
+			if err := params.Send(log); err != nil {
+				msg := fmt.Sprintf("stopped the phone status stream for user [%s] at event log [%s]", params.UserID, log.ID)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+		case <-keepAlive.C:
+			if err := params.KeepAlive(); err != nil {
+				msg := fmt.Sprintf("stopped the phone status stream for user [%s] because the keep alive failed", params.UserID)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+		case <-ctx.Done():
+			ctxLogger.Info(fmt.Sprintf("stopped the phone status stream for user [%s] because the client disconnected", params.UserID))
+			return nil
+		}
+	}
+}
+
+// Index fetches the entities.EventLog of a user
+func (service *EventLogService) Index(ctx context.Context, userID entities.UserID, params repositories.EventLogSearchParams) ([]*entities.EventLog, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	logs, err := service.repository.Search(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch event logs for user [%s] with params [%+#v]", userID, params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] event logs for user [%s] with params [%+#v]", len(logs), userID, params))
+	return logs, nil
+}
+
+// eventLogPayload contains the fields used to find the resource which was changed by a cloudevents.Event
+type eventLogPayload struct {
+	ID              string          `json:"id"`
+	MessageID       string          `json:"message_id"`
+	PhoneID         string          `json:"phone_id"`
+	CallEventID     string          `json:"call_event_id"`
+	WebhookID       string          `json:"webhook_id"`
+	IntegrationID   string          `json:"integration_id"`
+	IntegrationType string          `json:"integration_type"`
+	UserID          entities.UserID `json:"user_id"`
+	Owner           string          `json:"owner"`
+}
+
+// Log stores a cloudevents.Event in the background so that the audit trail does not slow down the delivery of the event.
+// An event log which cannot be stored is logged and dropped, the event is not delivered again.
+func (service *EventLogService) Log(ctx context.Context, event cloudevents.Event) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	log, err := service.createEventLog(event)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event log for [%s] event with ID [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	done := service.drainer.Add()
+	go func(ctx context.Context) {
+		defer done()
+		service.store(ctx, log)
+	}(context.WithoutCancel(ctx))
+
+	return nil
+}
+
+func (service *EventLogService) store(ctx context.Context, log *entities.EventLog) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.Store(ctx, log); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot store event log for [%s] event with ID [%s]", log.Type, log.ID)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("stored event log [%s] for [%s] [%s] of user [%s]", log.ID, log.ResourceType, log.ResourceID, log.UserID))
+	service.broker.Publish(log)
+}
+
+func (service *EventLogService) createEventLog(event cloudevents.Event) (*entities.EventLog, error) {
+	var payload eventLogPayload
+	if err := event.DataAs(&payload); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode [%s] into [%T]", event.Data(), payload))
+	}
+
+	if payload.UserID == "" {
+		return nil, stacktrace.NewError(fmt.Sprintf("the [%s] event with ID [%s] has no user ID", event.Type(), event.ID()))
+	}
+
+	data, err := event.MarshalJSON()
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal [%s] event with ID [%s] into JSON", event.Type(), event.ID()))
+	}
+
+	resourceType, resourceID := service.resource(event.Type(), payload)
+	return &entities.EventLog{
+		ID:           uuid.New(),
+		UserID:       payload.UserID,
+		Owner:        payload.Owner,
+		EventID:      event.ID(),
+		Type:         event.Type(),
+		Source:       event.Source(),
+		Event:        datatypes.JSON(data),
+		Timestamp:    event.Time().UTC(),
+		CreatedAt:    time.Now().UTC(),
+		ResourceType: resourceType,
+		ResourceID:   resourceID,
+	}, nil
+}
+
+// resource returns the type and the ID of the resource which was changed by an event using the prefix of the event type
+func (service *EventLogService) resource(eventType string, payload eventLogPayload) (string, string) {
+	switch {
+	case strings.HasPrefix(eventType, "message."):
+		if payload.MessageID != "" {
+			return "message", payload.MessageID
+		}
+		return "message", payload.ID
+	case strings.HasPrefix(eventType, "phone."):
+		return "phone", payload.PhoneID
+	case strings.HasPrefix(eventType, "call."):
+		return "call", payload.CallEventID
+	case strings.HasPrefix(eventType, "webhook."):
+		return "webhook", payload.WebhookID
+	case strings.HasPrefix(eventType, "integration."):
+		return payload.IntegrationType, payload.IntegrationID
+	default:
+		return strings.Split(eventType, ".")[0], payload.ID
+	}
+}
@@ -0,0 +1,249 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	firebase "firebase.google.com/go"
+	"firebase.google.com/go/messaging"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/hashicorp/golang-lru/v2/expirable"
+	"github.com/palantir/stacktrace"
+	"google.golang.org/api/option"
+)
+
+// fcmCredentialClientsMax is the maximum number of firebase messaging clients of users which are cached at the same time
+const fcmCredentialClientsMax = 256
+
+// fcmCredentialClientsTTL is the maximum duration a cached client is used, the cache of another instance is not invalidated when a credential changes
+const fcmCredentialClientsTTL = 5 * time.Minute
+
+// FcmServiceAccount is the JSON key of a google service account which is downloaded from the firebase console
+type FcmServiceAccount struct {
+	Type        string `json:"type"`
+	ProjectID   string `json:"project_id"`
+	PrivateKey  string `json:"private_key"`
+	ClientEmail string `json:"client_email"`
+}
+
+// ParseFcmServiceAccount decodes the JSON key of a service account
+func ParseFcmServiceAccount(serviceAccount string) (*FcmServiceAccount, error) {
+	account := new(FcmServiceAccount)
+	if err := json.Unmarshal([]byte(serviceAccount), account); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decode the JSON key of the service account")
+	}
+	return account, nil
+}
+
+// FcmCredentialService manages the firebase projects which send the push notifications to the phones of a user instead of the httpSMS firebase project
+type FcmCredentialService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.FcmCredentialRepository
+
+	// cipher encrypts the service accounts, the credentials cannot be configured when it is nil
+	cipher *Cipher
+
+	// clients are the firebase messaging clients of the users, the client is nil when the user has no FCM credential
+	clients *expirable.LRU[entities.UserID, *messaging.Client]
+}
+
+// NewFcmCredentialService creates a new FcmCredentialService
+func NewFcmCredentialService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.FcmCredentialRepository,
+	cipher *Cipher,
+) (s *FcmCredentialService) {
+	return &FcmCredentialService{
+		logger:     logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:     tracer,
+		repository: repository,
+		cipher:     cipher,
+		clients:    expirable.NewLRU[entities.UserID, *messaging.Client](fcmCredentialClientsMax, nil, fcmCredentialClientsTTL),
+	}
+}
+
+// Enabled checks if FCM credentials can be stored, the service accounts are encrypted so the encryption key must be configured
+func (service *FcmCredentialService) Enabled() bool {
+	return service.cipher != nil
+}
+
+// Load the entities.FcmCredential of a user
+func (service *FcmCredentialService) Load(ctx context.Context, userID entities.UserID) (*entities.FcmCredential, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	credential, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load FCM credential for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return credential, nil
+}
+
+// Verify checks that the service account can send push notifications by sending a dry run message with it
+func (service *FcmCredentialService) Verify(ctx context.Context, serviceAccount string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	client, err := service.client(serviceAccount)
+	if err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot create a firebase messaging client with the service account"))
+	}
+
+	if _, err = client.SendDryRun(ctx, &messaging.Message{Topic: "httpsms"}); err != nil {
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, "cannot send a dry run message with the service account"))
+	}
+	return nil
+}
+
+// FcmCredentialUpsertParams are parameters for setting the entities.FcmCredential of a user
+type FcmCredentialUpsertParams struct {
+	UserID         entities.UserID
+	ServiceAccount string
+}
+
+// Upsert sets the entities.FcmCredential of a user
+func (service *FcmCredentialService) Upsert(ctx context.Context, params *FcmCredentialUpsertParams) (*entities.FcmCredential, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	account, err := ParseFcmServiceAccount(params.ServiceAccount)
+	if err != nil {
+		msg := fmt.Sprintf("cannot parse the service account of the FCM credential for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	credential, err := service.repository.Load(ctx, params.UserID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		credential, err = &entities.FcmCredential{UserID: params.UserID, CreatedAt: time.Now().UTC()}, nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load FCM credential for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if credential.EncryptedServiceAccount, err = service.encrypt(params.ServiceAccount); err != nil {
+		msg := fmt.Sprintf("cannot encrypt the service account of the FCM credential for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	credential.ProjectID = account.ProjectID
+	credential.ClientEmail = account.ClientEmail
+	credential.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, credential); err != nil {
+		msg := fmt.Sprintf("cannot save FCM credential for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	service.clients.Remove(params.UserID)
+
+	ctxLogger.Info(fmt.Sprintf("set the FCM credential of user [%s] to the firebase project [%s]", credential.UserID, credential.ProjectID))
+	return credential, nil
+}
+
+// Delete the entities.FcmCredential of a user, the push notifications are then sent with the httpSMS firebase project
+func (service *FcmCredentialService) Delete(ctx context.Context, userID entities.UserID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID); err != nil {
+		msg := fmt.Sprintf("cannot load FCM credential for user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID); err != nil {
+		msg := fmt.Sprintf("cannot delete FCM credential for user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.clients.Remove(userID)
+
+	ctxLogger.Info(fmt.Sprintf("deleted the FCM credential of user [%s]", userID))
+	return nil
+}
+
+// MessagingClient returns the firebase messaging client of the project of a user, it returns the fallback client when the user has no FCM credential.
+// An error is returned instead of the fallback when the credential cannot be loaded because the FCM tokens of the phones belong to the project of the user.
+// The clients and the users without a credential are cached so the credential is not loaded for every push notification.
+func (service *FcmCredentialService) MessagingClient(ctx context.Context, userID entities.UserID, fallback *messaging.Client) (*messaging.Client, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if client, ok := service.clients.Get(userID); ok {
+		if client == nil {
+			return fallback, nil
+		}
+		return client, nil
+	}
+
+	credential, err := service.repository.Load(ctx, userID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		service.clients.Add(userID, nil)
+		return fallback, nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load FCM credential for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	serviceAccount, err := service.decrypt(credential.EncryptedServiceAccount)
+	if err != nil {
+		msg := fmt.Sprintf("cannot decrypt the service account of the FCM credential for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	client, err := service.client(serviceAccount)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create a firebase messaging client for the project [%s] of user [%s]", credential.ProjectID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.clients.Add(userID, client)
+	return client, nil
+}
+
+func (service *FcmCredentialService) client(serviceAccount string) (*messaging.Client, error) {
+	account, err := ParseFcmServiceAccount(serviceAccount)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot parse the service account")
+	}
+
+	// the context of the request is not used because the clients keep it to refresh the access tokens
+	app, err := firebase.NewApp(context.Background(), &firebase.Config{ProjectID: account.ProjectID}, option.WithCredentialsJSON([]byte(serviceAccount)))
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot initialize firebase application for the project [%s]", account.ProjectID))
+	}
+
+	client, err := app.Messaging(context.Background())
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot initialize firebase messaging client for the project [%s]", account.ProjectID))
+	}
+	return client, nil
+}
+
+func (service *FcmCredentialService) encrypt(serviceAccount string) (string, error) {
+	if service.cipher == nil {
+		return "", stacktrace.NewError("cannot encrypt the service account because the encryption key is not configured")
+	}
+	return service.cipher.Encrypt([]byte(serviceAccount))
+}
+
+func (service *FcmCredentialService) decrypt(encryptedServiceAccount string) (string, error) {
+	if service.cipher == nil {
+		return "", stacktrace.NewError("cannot decrypt the service account because the encryption key is not configured")
+	}
+
+	plaintext, err := service.cipher.Decrypt(encryptedServiceAccount)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "cannot decrypt the service account")
+	}
+	return string(plaintext), nil
+}
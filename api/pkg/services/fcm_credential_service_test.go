@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"sync"
+	"testing"
+
+	"firebase.google.com/go/messaging"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+// fcmCredentialRepository is an in-memory repositories.FcmCredentialRepository which counts the loads
+type fcmCredentialRepository struct {
+	mutex       sync.Mutex
+	loads       int
+	credentials map[entities.UserID]*entities.FcmCredential
+}
+
+func (repository *fcmCredentialRepository) Save(_ context.Context, credential *entities.FcmCredential) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	repository.credentials[credential.UserID] = credential
+	return nil
+}
+
+func (repository *fcmCredentialRepository) Load(_ context.Context, userID entities.UserID) (*entities.FcmCredential, error) {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	repository.loads++
+	if credential, ok := repository.credentials[userID]; ok {
+		return credential, nil
+	}
+	return nil, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, "credential not found")
+}
+
+func (repository *fcmCredentialRepository) Delete(_ context.Context, userID entities.UserID) error {
+	repository.mutex.Lock()
+	defer repository.mutex.Unlock()
+	delete(repository.credentials, userID)
+	return nil
+}
+
+func newTestFcmCredentialService(t *testing.T) (*FcmCredentialService, *fcmCredentialRepository) {
+	cipher, err := NewCipher(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	assert.Nil(t, err)
+
+	repository := &fcmCredentialRepository{credentials: map[entities.UserID]*entities.FcmCredential{}}
+	return NewFcmCredentialService(testLogger(), telemetry.NewOtelLogger("test", testLogger()), repository, cipher), repository
+}
+
+func TestFcmCredentialService_MessagingClient(t *testing.T) {
+	userID := entities.UserID("user-id")
+	fallback := &messaging.Client{}
+
+	t.Run("a user without a credential is loaded once", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository := newTestFcmCredentialService(t)
+
+		// Act
+		first, firstErr := service.MessagingClient(context.Background(), userID, fallback)
+		second, secondErr := service.MessagingClient(context.Background(), userID, fallback)
+
+		// Assert
+		assert.Nil(t, firstErr)
+		assert.Nil(t, secondErr)
+		assert.Same(t, fallback, first)
+		assert.Same(t, fallback, second)
+		assert.Equal(t, 1, repository.loads)
+	})
+
+	t.Run("the cached user is loaded again after the credential is set", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository := newTestFcmCredentialService(t)
+		_, err := service.MessagingClient(context.Background(), userID, fallback)
+		assert.Nil(t, err)
+
+		// Arrange
+		_, err = service.Upsert(context.Background(), &FcmCredentialUpsertParams{
+			UserID:         userID,
+			ServiceAccount: `{"type":"service_account","project_id":"httpsms-test","client_email":"test@httpsms-test.iam.gserviceaccount.com"}`,
+		})
+		assert.Nil(t, err)
+		loads := repository.loads
+
+		// Act
+		client, _ := service.MessagingClient(context.Background(), userID, fallback)
+
+		// Assert
+		assert.NotSame(t, fallback, client)
+		assert.Equal(t, loads+1, repository.loads)
+	})
+
+	t.Run("the cached client is removed when the credential is deleted", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		service, repository := newTestFcmCredentialService(t)
+		service.clients.Add(userID, &messaging.Client{})
+		repository.credentials[userID] = &entities.FcmCredential{UserID: userID}
+
+		// Arrange
+		assert.Nil(t, service.Delete(context.Background(), userID))
+
+		// Act
+		client, err := service.MessagingClient(context.Background(), userID, fallback)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Same(t, fallback, client)
+	})
+}
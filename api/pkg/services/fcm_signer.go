@@ -0,0 +1,104 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// fcmSignatureKey is the data key of the base64 encoded ed25519 signature of a push notification
+	fcmSignatureKey = "KEY_SIGNATURE"
+
+	// fcmTimestampKey is the data key of the unix timestamp in seconds when a push notification was signed
+	fcmTimestampKey = "KEY_TIMESTAMP"
+
+	// fcmNonceKey is the data key of the random nonce which makes the signature of every push notification unique
+	fcmNonceKey = "KEY_NONCE"
+
+	// fcmKeyVersionKey is the data key of the version of the key of the phone which signed a push notification
+	fcmKeyVersionKey = "KEY_SIGNATURE_VERSION"
+
+	// fcmTTLKey is the data key of the number of seconds a push notification is valid after it was signed, the app uses a default of 10 minutes when it is not set
+	fcmTTLKey = "KEY_TTL"
+)
+
+// FcmSigner signs the data of the push notifications sent to phones with ed25519 so that the httpSMS app can verify that a command was sent by the server.
+// The app rejects a notification when the signature does not match, when the timestamp is older than the KEY_TTL of the notification or when the nonce has already been seen.
+type FcmSigner struct {
+	key     ed25519.PrivateKey
+	version uint
+}
+
+// NewFcmSigner creates a new FcmSigner from a base64 encoded 32 byte ed25519 seed
+func NewFcmSigner(seed string) (*FcmSigner, error) {
+	secret, err := base64.StdEncoding.DecodeString(seed)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decode the base64 signing key")
+	}
+
+	if len(secret) != ed25519.SeedSize {
+		return nil, stacktrace.NewError(fmt.Sprintf("the signing key must have %d bytes but it has [%d] bytes", ed25519.SeedSize, len(secret)))
+	}
+
+	return &FcmSigner{key: ed25519.NewKeyFromSeed(secret)}, nil
+}
+
+// VerificationKey is the base64 encoded ed25519 public key which the phone uses to verify the push notifications
+func (signer *FcmSigner) VerificationKey() string {
+	return base64.StdEncoding.EncodeToString(signer.key.Public().(ed25519.PublicKey))
+}
+
+// ForPhone returns the signer of a version of the key of a phone, the version 0 is the key of the server so the phones which have never rotated their key keep the same key.
+// The keys of the phones are derived from the key of the server so they are never stored.
+func (signer *FcmSigner) ForPhone(phoneID uuid.UUID, version uint) *FcmSigner {
+	if version == 0 {
+		return signer
+	}
+
+	mac := hmac.New(sha256.New, signer.key.Seed())
+	mac.Write([]byte(fmt.Sprintf("%s:%d", phoneID, version)))
+	return &FcmSigner{key: ed25519.NewKeyFromSeed(mac.Sum(nil)), version: version}
+}
+
+// Sign adds the timestamp, the nonce, the key version and the signature to the data of a push notification
+func (signer *FcmSigner) Sign(data map[string]string, timestamp time.Time) (map[string]string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, stacktrace.Propagate(err, "cannot generate nonce")
+	}
+
+	data[fcmKeyVersionKey] = strconv.FormatUint(uint64(signer.version), 10)
+	data[fcmTimestampKey] = strconv.FormatInt(timestamp.Unix(), 10)
+	data[fcmNonceKey] = hex.EncodeToString(nonce)
+	data[fcmSignatureKey] = base64.StdEncoding.EncodeToString(ed25519.Sign(signer.key, signer.payload(data)))
+	return data, nil
+}
+
+// payload is the signed content which contains a key=value line for every data key except the signature in alphabetical order
+func (signer *FcmSigner) payload(data map[string]string) []byte {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		if key != fcmSignatureKey {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	lines := make([]string, 0, len(keys))
+	for _, key := range keys {
+		lines = append(lines, key+"="+data[key])
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
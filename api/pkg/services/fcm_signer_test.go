@@ -0,0 +1,135 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFcmSigner(t *testing.T) *FcmSigner {
+	signer, err := NewFcmSigner(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", ed25519.SeedSize))))
+	assert.Nil(t, err)
+	return signer
+}
+
+// verifyFcmSignature verifies the data of a push notification the way the app does with the verification key of the signer
+func verifyFcmSignature(t *testing.T, verificationKey string, data map[string]string) bool {
+	key, err := base64.StdEncoding.DecodeString(verificationKey)
+	assert.Nil(t, err)
+	signature, err := base64.StdEncoding.DecodeString(data[fcmSignatureKey])
+	assert.Nil(t, err)
+	return ed25519.Verify(key, (&FcmSigner{}).payload(data), signature)
+}
+
+func TestNewFcmSigner(t *testing.T) {
+	tests := []struct {
+		name  string
+		seed  string
+		valid bool
+	}{
+		{name: "a 32 byte seed is valid", seed: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 32))), valid: true},
+		{name: "a 64 byte seed is not valid", seed: base64.StdEncoding.EncodeToString([]byte(strings.Repeat("s", 64)))},
+		{name: "a seed which is not base64 is not valid", seed: "!!"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			signer, err := NewFcmSigner(tt.seed)
+
+			// Assert
+			assert.Equal(t, tt.valid, err == nil)
+			assert.Equal(t, tt.valid, signer != nil)
+		})
+	}
+}
+
+func TestFcmSigner_Sign(t *testing.T) {
+	timestamp := time.Date(2022, 6, 5, 14, 26, 0, 0, time.UTC)
+	phoneID := uuid.MustParse("32343a19-da5e-4b1b-a767-3298a73703cb")
+
+	t.Run("the signature is verified with the verification key", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		signer := newTestFcmSigner(t)
+
+		// Act
+		data, err := signer.Sign(map[string]string{"KEY_MESSAGE_ID": "message-id"}, timestamp)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, "1654439160", data[fcmTimestampKey])
+		assert.Equal(t, "0", data[fcmKeyVersionKey])
+		assert.True(t, verifyFcmSignature(t, signer.VerificationKey(), data))
+	})
+
+	t.Run("the signature is not verified when the data is changed", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		signer := newTestFcmSigner(t)
+		data, err := signer.Sign(map[string]string{"KEY_MESSAGE_ID": "message-id"}, timestamp)
+		assert.Nil(t, err)
+
+		// Arrange
+		data["KEY_MESSAGE_ID"] = "other-message-id"
+
+		// Act
+		verified := verifyFcmSignature(t, signer.VerificationKey(), data)
+
+		// Assert
+		assert.False(t, verified)
+	})
+
+	t.Run("every notification has a different nonce", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		signer := newTestFcmSigner(t)
+
+		// Act
+		first, _ := signer.Sign(map[string]string{}, timestamp)
+		second, _ := signer.Sign(map[string]string{}, timestamp)
+
+		// Assert
+		assert.NotEqual(t, first[fcmNonceKey], second[fcmNonceKey])
+		assert.NotEqual(t, first[fcmSignatureKey], second[fcmSignatureKey])
+	})
+
+	t.Run("the version 0 of a phone is the key of the server", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		signer := newTestFcmSigner(t)
+
+		// Act
+		phoneSigner := signer.ForPhone(phoneID, 0)
+
+		// Assert
+		assert.Same(t, signer, phoneSigner)
+	})
+
+	t.Run("a rotated key of a phone is not verified with the previous key", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		signer := newTestFcmSigner(t)
+		rotated := signer.ForPhone(phoneID, 2)
+
+		// Act
+		data, err := rotated.Sign(map[string]string{}, timestamp)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, "2", data[fcmKeyVersionKey])
+		assert.Equal(t, rotated.VerificationKey(), signer.ForPhone(phoneID, 2).VerificationKey())
+		assert.True(t, verifyFcmSignature(t, rotated.VerificationKey(), data))
+		assert.False(t, verifyFcmSignature(t, signer.ForPhone(phoneID, 1).VerificationKey(), data))
+		assert.False(t, verifyFcmSignature(t, signer.ForPhone(uuid.New(), 2).VerificationKey(), data))
+	})
+}
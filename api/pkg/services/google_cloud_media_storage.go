@@ -0,0 +1,73 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+type googleCloudMediaStorage struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	bucket *gcs.BucketHandle
+}
+
+// NewGoogleCloudMediaStorage creates a MediaStorage which saves files in a google cloud storage bucket
+func NewGoogleCloudMediaStorage(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	bucket *gcs.BucketHandle,
+) MediaStorage {
+	return &googleCloudMediaStorage{
+		logger: logger.WithService(fmt.Sprintf("%T", googleCloudMediaStorage{})),
+		tracer: tracer,
+		bucket: bucket,
+	}
+}
+
+// Store saves the content of a media file with the key
+func (storage *googleCloudMediaStorage) Store(ctx context.Context, key string, contentType string, content []byte) error {
+	ctx, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	writer := storage.bucket.Object(key).NewWriter(ctx)
+	writer.ContentType = contentType
+
+	if _, err := writer.Write(content); err != nil {
+		_ = writer.Close()
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot write media file [%s] to google cloud storage", key)))
+	}
+
+	if err := writer.Close(); err != nil {
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot close writer of media file [%s] to google cloud storage", key)))
+	}
+	return nil
+}
+
+// Load reads length bytes of a media file starting at offset
+func (storage *googleCloudMediaStorage) Load(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	ctx, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	reader, err := storage.bucket.Object(key).NewRangeReader(ctx, offset, length)
+	if err != nil {
+		return nil, storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot read media file [%s] from google cloud storage", key)))
+	}
+	return reader, nil
+}
+
+// Delete removes the media file with the key, it does not fail when the file does not exist
+func (storage *googleCloudMediaStorage) Delete(ctx context.Context, key string) error {
+	ctx, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	if err := storage.bucket.Object(key).Delete(ctx); err != nil && !errors.Is(err, gcs.ErrObjectNotExist) {
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot delete media file [%s] from google cloud storage", key)))
+	}
+	return nil
+}
@@ -0,0 +1,144 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"firebase.google.com/go/messaging"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"gorm.io/gorm"
+)
+
+// HealthCheckStatus is the status of a dependency
+type HealthCheckStatus string
+
+const (
+	// HealthCheckStatusUp means the dependency is reachable
+	HealthCheckStatusUp = HealthCheckStatus("up")
+
+	// HealthCheckStatusDown means the dependency cannot be reached
+	HealthCheckStatusDown = HealthCheckStatus("down")
+)
+
+// HealthCheck is the result of checking a single dependency
+type HealthCheck struct {
+	Name       string            `json:"name" example:"database"`
+	Status     HealthCheckStatus `json:"status" example:"up"`
+	DurationMS int64             `json:"duration_ms" example:"12"`
+}
+
+// healthCheckFCMTTL is the duration the result of the FCM check is reused so that every readiness probe does not send a dry run message
+const healthCheckFCMTTL = time.Minute
+
+// HealthService checks if the dependencies needed to serve requests are reachable
+type HealthService struct {
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	db              *gorm.DB
+	dedicatedDB     *gorm.DB
+	messagingClient *messaging.Client
+
+	mutex        sync.Mutex
+	fcmCheckedAt time.Time
+	fcmErr       error
+}
+
+// NewHealthService creates a new HealthService
+func NewHealthService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	db *gorm.DB,
+	dedicatedDB *gorm.DB,
+	messagingClient *messaging.Client,
+) (s *HealthService) {
+	return &HealthService{
+		logger:          logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:          tracer,
+		db:              db,
+		dedicatedDB:     dedicatedDB,
+		messagingClient: messagingClient,
+	}
+}
+
+// Readiness checks all the dependencies concurrently and returns true only if every dependency is up
+func (service *HealthService) Readiness(ctx context.Context) (bool, []HealthCheck) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	checks := []struct {
+		name  string
+		check func(ctx context.Context) error
+	}{
+		{name: "database", check: service.pingDB(service.db)},
+		{name: "dedicated_database", check: service.pingDB(service.dedicatedDB)},
+		{name: "fcm", check: service.checkFCM},
+	}
+
+	results := make([]HealthCheck, len(checks))
+
+	var wg sync.WaitGroup
+	for index, item := range checks {
+		wg.Add(1)
+		go func(index int, name string, check func(ctx context.Context) error) {
+			defer wg.Done()
+			results[index] = service.run(ctx, name, check)
+		}(index, item.name, item.check)
+	}
+	wg.Wait()
+
+	for _, result := range results {
+		if result.Status != HealthCheckStatusUp {
+			return false, results
+		}
+	}
+	return true, results
+}
+
+func (service *HealthService) run(ctx context.Context, name string, check func(ctx context.Context) error) HealthCheck {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	start := time.Now()
+	result := HealthCheck{Name: name, Status: HealthCheckStatusUp}
+
+	// the error is logged and not returned so that the unauthenticated endpoint does not leak connection details
+	if err := check(ctx); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("health check [%s] failed", name)))
+		result.Status = HealthCheckStatusDown
+	}
+
+	result.DurationMS = time.Since(start).Milliseconds()
+	return result
+}
+
+func (service *HealthService) pingDB(db *gorm.DB) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return stacktrace.Propagate(err, "cannot get the sql database from the gorm connection")
+		}
+		return sqlDB.PingContext(ctx)
+	}
+}
+
+// checkFCM sends a dry run message which fails if the firebase credentials are invalid, the result is cached for healthCheckFCMTTL
+func (service *HealthService) checkFCM(ctx context.Context) error {
+	service.mutex.Lock()
+	defer service.mutex.Unlock()
+
+	if !service.fcmCheckedAt.IsZero() && time.Since(service.fcmCheckedAt) < healthCheckFCMTTL {
+		return service.fcmErr
+	}
+
+	_, err := service.messagingClient.SendDryRun(ctx, &messaging.Message{
+		Topic: "healthz",
+	})
+	service.fcmCheckedAt, service.fcmErr = time.Now(), err
+	return err
+}
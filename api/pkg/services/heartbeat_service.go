@@ -18,8 +18,9 @@ import (
 )
 
 const (
-	// select id, a.timestamp, a.owner,  a.timestamp - (SELECT timestamp from heartbeats b where  b.timestamp < a.timestamp and a.owner = b.owner and a.user_id = b.user_id order by b.timestamp desc  limit 1) as diff  from heartbeats a;
-	heartbeatCheckInterval = 16 * time.Minute
+	// batteryRecoveryMargin is added to the battery low threshold before firing the phone.battery_ok event
+	// so that a battery level hovering around the threshold does not fire events on every heartbeat.
+	batteryRecoveryMargin = 5
 )
 
 // HeartbeatService is handles heartbeat requests
@@ -29,6 +30,7 @@ type HeartbeatService struct {
 	tracer            telemetry.Tracer
 	repository        repositories.HeartbeatRepository
 	monitorRepository repositories.HeartbeatMonitorRepository
+	phoneRepository   repositories.PhoneRepository
 	dispatcher        *EventDispatcher
 }
 
@@ -38,6 +40,7 @@ func NewHeartbeatService(
 	tracer telemetry.Tracer,
 	repository repositories.HeartbeatRepository,
 	monitorRepository repositories.HeartbeatMonitorRepository,
+	phoneRepository repositories.PhoneRepository,
 	dispatcher *EventDispatcher,
 ) (s *HeartbeatService) {
 	return &HeartbeatService{
@@ -45,6 +48,7 @@ func NewHeartbeatService(
 		tracer:            tracer,
 		repository:        repository,
 		monitorRepository: monitorRepository,
+		phoneRepository:   phoneRepository,
 		dispatcher:        dispatcher,
 	}
 }
@@ -66,11 +70,26 @@ func (service *HeartbeatService) Index(ctx context.Context, userID entities.User
 	return heartbeats, nil
 }
 
+// IndexVersion fetches the repositories.ListVersion of all the heartbeats of an owner ignoring the pagination
+func (service *HeartbeatService) IndexVersion(ctx context.Context, userID entities.UserID, owner string, params repositories.IndexParams) (*repositories.ListVersion, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	version, err := service.repository.IndexVersion(ctx, userID, owner, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch the version of heartbeats with parms [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return version, nil
+}
+
 // HeartbeatStoreParams are parameters for creating a new entities.Heartbeat
 type HeartbeatStoreParams struct {
 	Owner     string
 	Version   string
 	Charging  bool
+	Battery   *uint
 	Source    string
 	Timestamp time.Time
 	UserID    entities.UserID
@@ -87,6 +106,7 @@ func (service *HeartbeatService) Store(ctx context.Context, params HeartbeatStor
 		ID:        uuid.New(),
 		Owner:     params.Owner,
 		Charging:  params.Charging,
+		Battery:   params.Battery,
 		Timestamp: params.Timestamp,
 		Version:   params.Version,
 		UserID:    params.UserID,
@@ -115,6 +135,10 @@ func (service *HeartbeatService) Store(ctx context.Context, params HeartbeatStor
 		service.handleHeartbeatWhenPhoneWasOffline(ctx, params.Source, heartbeat, monitor)
 	}
 
+	if heartbeat.Battery != nil {
+		service.handleBatteryLevel(ctx, params.Source, heartbeat, monitor)
+	}
+
 	return heartbeat, nil
 }
 
@@ -159,6 +183,72 @@ func (service *HeartbeatService) handleHeartbeatWhenPhoneWasOffline(ctx context.
 	return
 }
 
+func (service *HeartbeatService) handleBatteryLevel(ctx context.Context, source string, heartbeat *entities.Heartbeat, monitor *entities.HeartbeatMonitor) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.Load(ctx, heartbeat.UserID, heartbeat.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with owner [%s] for user [%s]", heartbeat.Owner, heartbeat.UserID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	threshold := phone.BatteryLowThresholdSanitized()
+	battery := *heartbeat.Battery
+
+	var eventType string
+	var payload any
+	switch {
+	case !monitor.BatteryLow && battery < threshold:
+		eventType = events.EventTypePhoneBatteryLow
+		payload = &events.PhoneBatteryLowPayload{
+			PhoneID:   phone.ID,
+			UserID:    phone.UserID,
+			Owner:     phone.PhoneNumber,
+			Battery:   battery,
+			Threshold: threshold,
+			Charging:  heartbeat.Charging,
+			Timestamp: heartbeat.Timestamp,
+		}
+	// the recovery level is capped at a full battery so that phone.battery_ok still fires for thresholds close to 100
+	case monitor.BatteryLow && battery >= min(threshold+batteryRecoveryMargin, 100):
+		eventType = events.EventTypePhoneBatteryOk
+		payload = &events.PhoneBatteryOkPayload{
+			PhoneID:   phone.ID,
+			UserID:    phone.UserID,
+			Owner:     phone.PhoneNumber,
+			Battery:   battery,
+			Threshold: threshold,
+			Charging:  heartbeat.Charging,
+			Timestamp: heartbeat.Timestamp,
+		}
+	default:
+		return
+	}
+
+	if err = service.monitorRepository.UpdateBatteryLow(ctx, monitor.UserID, monitor.ID, !monitor.BatteryLow); err != nil {
+		msg := fmt.Sprintf("cannot update battery low status for heartbeat monitor [%s]", monitor.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	event, err := service.createEvent(eventType, source, payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for phone with ID [%s]", eventType, phone.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with ID [%s]", event.Type(), phone.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	ctxLogger.Info(fmt.Sprintf("[%s] event created with ID [%s] for phone [%s] with battery [%d%%] and threshold [%d%%]", event.Type(), event.ID(), phone.ID, battery, threshold))
+}
+
 // StoreMonitor a new entities.HeartbeatMonitor
 func (service *HeartbeatService) StoreMonitor(ctx context.Context, params *HeartbeatMonitorStoreParams) (*entities.HeartbeatMonitor, error) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
@@ -184,6 +274,7 @@ func (service *HeartbeatService) StoreMonitor(ctx context.Context, params *Heart
 		MonitorID: monitor.ID,
 		Source:    params.Source,
 	}
+	monitorParams.Phone = service.monitoredPhone(ctx, monitorParams)
 	if err = service.scheduleHeartbeatCheck(ctx, time.Now().UTC(), monitorParams); err != nil {
 		msg := fmt.Sprintf("cannot schedule healthcheck for monitor [%s] with owner [%s] and userID [%s]", monitor.ID, params.Owner, params.UserID)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -264,6 +355,9 @@ type HeartbeatMonitorParams struct {
 	PhoneID   uuid.UUID
 	UserID    entities.UserID
 	Source    string
+
+	// Phone is used for the heartbeat interval, it is loaded when the heartbeat is checked
+	Phone *entities.Phone
 }
 
 // Monitor the heartbeats of an owner and phone number
@@ -282,12 +376,14 @@ func (service *HeartbeatService) Monitor(ctx context.Context, params *HeartbeatM
 	if err != nil {
 		msg := fmt.Sprintf("cannot check if monitor exists with userID [%s] and owner [%s]", params.UserID, params.Owner)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		params.Phone = service.monitoredPhone(ctx, params)
 		return service.scheduleHeartbeatCheck(ctx, time.Now().UTC(), params)
 	}
 
 	// Update params in case of ID duplicate
 	params.PhoneID = monitor.PhoneID
 	params.MonitorID = monitor.ID
+	params.Phone = service.monitoredPhone(ctx, params)
 
 	heartbeat, err := service.repository.Last(ctx, params.UserID, params.Owner)
 	if err != nil {
@@ -296,20 +392,39 @@ func (service *HeartbeatService) Monitor(ctx context.Context, params *HeartbeatM
 		return nil
 	}
 
+	checkInterval := params.Phone.HeartbeatCheckInterval()
+	offlineThreshold := params.Phone.HeartbeatOfflineThreshold()
+	elapsed := time.Now().UTC().Sub(heartbeat.Timestamp)
+
 	// send urgent FCM message if the last heartbeat is late
-	if time.Now().UTC().Sub(heartbeat.Timestamp) > heartbeatCheckInterval && time.Now().UTC().Sub(heartbeat.Timestamp) < (heartbeatCheckInterval*5) {
+	if elapsed > checkInterval && elapsed < offlineThreshold+checkInterval {
 		ctxLogger.Info(fmt.Sprintf("sending missed heartbeat notification for userID [%s] and owner [%s] and monitor ID [%s]", params.UserID, params.Owner, params.MonitorID))
 		service.handleMissedMonitor(ctx, heartbeat.Timestamp, params)
 	}
 
-	if time.Now().UTC().Sub(heartbeat.Timestamp) > (heartbeatCheckInterval*4) &&
-		time.Now().UTC().Sub(heartbeat.Timestamp) < (heartbeatCheckInterval*5) {
+	// the phone is marked offline only once since the checks are scheduled every check interval
+	if elapsed > offlineThreshold && elapsed < offlineThreshold+checkInterval {
 		return service.handleFailedMonitor(ctx, heartbeat.Timestamp, params)
 	}
 
 	return service.scheduleHeartbeatCheck(ctx, heartbeat.Timestamp, params)
 }
 
+// monitoredPhone loads the phone of a heartbeat monitor, the default heartbeat interval is used if the phone cannot be loaded
+func (service *HeartbeatService) monitoredPhone(ctx context.Context, params *HeartbeatMonitorParams) *entities.Phone {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.Load(ctx, params.UserID, params.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with owner [%s] for user [%s], using the default heartbeat interval", params.Owner, params.UserID)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return &entities.Phone{ID: params.PhoneID, UserID: params.UserID, PhoneNumber: params.Owner}
+	}
+
+	return phone
+}
+
 func (service *HeartbeatService) handleMissedMonitor(ctx context.Context, lastTimestamp time.Time, params *HeartbeatMonitorParams) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
@@ -328,7 +443,7 @@ func (service *HeartbeatService) handleMissedMonitor(ctx context.Context, lastTi
 		return
 	}
 
-	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, heartbeatCheckInterval); err != nil {
+	if _, err = service.dispatcher.DispatchWithTimeout(ctx, event, params.Phone.HeartbeatCheckInterval()); err != nil {
 		msg := fmt.Sprintf("cannot dispatch event [%s] for heartbeat monitor with phone id [%s]", event.Type(), params.PhoneID)
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
 	}
@@ -374,7 +489,7 @@ func (service *HeartbeatService) scheduleHeartbeatCheck(ctx context.Context, las
 		PhoneID:     params.PhoneID,
 		UserID:      params.UserID,
 		MonitorID:   params.MonitorID,
-		ScheduledAt: lastTimestamp.Add(heartbeatCheckInterval),
+		ScheduledAt: lastTimestamp.Add(params.Phone.HeartbeatCheckInterval()),
 		Owner:       params.Owner,
 	})
 	if err != nil {
@@ -382,7 +497,7 @@ func (service *HeartbeatService) scheduleHeartbeatCheck(ctx context.Context, las
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	queueID, err := service.dispatcher.DispatchWithTimeout(ctx, event, heartbeatCheckInterval)
+	queueID, err := service.dispatcher.DispatchWithTimeout(ctx, event, params.Phone.HeartbeatCheckInterval())
 	if err != nil {
 		msg := fmt.Sprintf("cannot dispatch event [%s] for heartbeat monitor with phone id [%s]", event.Type(), params.PhoneID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+// ErrCodeMessageLabelLimitExceeded is returned when a label is attached to a message which already has the maximum number of labels
+const ErrCodeMessageLabelLimitExceeded = stacktrace.ErrorCode(1121)
+
+// MaxLabelsPerMessage is the maximum number of labels which can be attached to a message
+const MaxLabelsPerMessage = 10
+
+// LabelService is responsible for the labels which users attach to their messages
+type LabelService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	repository        repositories.LabelRepository
+	messageRepository repositories.MessageRepository
+}
+
+// NewLabelService creates a new LabelService
+func NewLabelService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.LabelRepository,
+	messageRepository repositories.MessageRepository,
+) (s *LabelService) {
+	return &LabelService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		repository:        repository,
+		messageRepository: messageRepository,
+	}
+}
+
+// Index fetches the entities.Label of a user
+func (service *LabelService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Label, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	labels, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch labels for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] labels for user [%s]", len(labels), userID))
+	return labels, nil
+}
+
+// LabelStoreParams are parameters for creating a new entities.Label
+type LabelStoreParams struct {
+	UserID entities.UserID
+	Name   string
+	Color  string
+}
+
+// Store a new entities.Label
+func (service *LabelService) Store(ctx context.Context, params *LabelStoreParams) (*entities.Label, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	label := &entities.Label{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		Name:      params.Name,
+		Color:     params.Color,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err := service.repository.Save(ctx, label); err != nil {
+		msg := fmt.Sprintf("cannot save label with id [%s]", label.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("label saved with id [%s] for user [%s] in the [%T]", label.ID, label.UserID, service.repository))
+	return label, nil
+}
+
+// LabelUpdateParams are parameters for updating an entities.Label
+type LabelUpdateParams struct {
+	UserID  entities.UserID
+	LabelID uuid.UUID
+	Name    string
+	Color   string
+}
+
+// Update an entities.Label
+func (service *LabelService) Update(ctx context.Context, params *LabelUpdateParams) (*entities.Label, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	label, err := service.repository.Load(ctx, params.UserID, params.LabelID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load label with userID [%s] and labelID [%s]", params.UserID, params.LabelID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	label.Name = params.Name
+	label.Color = params.Color
+	label.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, label); err != nil {
+		msg := fmt.Sprintf("cannot save label with id [%s] after update", label.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("label updated with id [%s] for user [%s]", label.ID, label.UserID))
+	return label, nil
+}
+
+// Delete an entities.Label, it is detached from all the messages of the user
+func (service *LabelService) Delete(ctx context.Context, userID entities.UserID, labelID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, labelID); err != nil {
+		msg := fmt.Sprintf("cannot load label with userID [%s] and labelID [%s]", userID, labelID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, labelID); err != nil {
+		msg := fmt.Sprintf("cannot delete label with id [%s] and user id [%s]", labelID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted label with id [%s] and user id [%s]", labelID, userID))
+	return nil
+}
+
+// MessageLabels fetches the entities.Label attached to a message
+func (service *LabelService) MessageLabels(ctx context.Context, userID entities.UserID, messageID uuid.UUID) ([]*entities.Label, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if _, err := service.messageRepository.Load(ctx, userID, messageID); err != nil {
+		msg := fmt.Sprintf("cannot load message with userID [%s] and messageID [%s]", userID, messageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	labels, err := service.repository.IndexByMessage(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the labels of message [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return labels, nil
+}
+
+// Attach an entities.Label to a message and return the labels of the message, attaching a label twice is harmless
+func (service *LabelService) Attach(ctx context.Context, userID entities.UserID, messageID uuid.UUID, labelID uuid.UUID) ([]*entities.Label, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, labelID); err != nil {
+		msg := fmt.Sprintf("cannot load label with userID [%s] and labelID [%s]", userID, labelID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	labels, err := service.MessageLabels(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the labels of message [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	for _, label := range labels {
+		if label.ID == labelID {
+			return labels, nil
+		}
+	}
+
+	if len(labels) >= MaxLabelsPerMessage {
+		msg := fmt.Sprintf("cannot attach label [%s] to message [%s] because it already has [%d] labels", labelID, messageID, len(labels))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageLabelLimitExceeded, msg))
+	}
+
+	err = service.repository.Attach(ctx, &entities.MessageLabel{
+		MessageID: messageID,
+		LabelID:   labelID,
+		UserID:    userID,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot attach label [%s] to message [%s] for user [%s]", labelID, messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("attached label [%s] to message [%s] for user [%s]", labelID, messageID, userID))
+	return service.MessageLabels(ctx, userID, messageID)
+}
+
+// Detach an entities.Label from a message and return the remaining labels of the message
+func (service *LabelService) Detach(ctx context.Context, userID entities.UserID, messageID uuid.UUID, labelID uuid.UUID) ([]*entities.Label, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.Detach(ctx, userID, messageID, labelID); err != nil {
+		msg := fmt.Sprintf("cannot detach label [%s] from message [%s] for user [%s]", labelID, messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("detached label [%s] from message [%s] for user [%s]", labelID, messageID, userID))
+	return service.MessageLabels(ctx, userID, messageID)
+}
@@ -0,0 +1,128 @@
+package services
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// LanguageUnknown is used when the content is too short or too ambiguous to detect the language
+const LanguageUnknown = "unknown"
+
+// minLanguageWords is the number of words needed before the stop words of a latin script language are trusted
+const minLanguageWords = 4
+
+// languageStopWords are frequent words which identify languages written in the latin script
+var languageStopWords = map[string][]string{
+	"en": {"the", "and", "is", "are", "you", "your", "to", "of", "for", "in", "it", "this", "that", "with", "have", "be", "will", "not", "on", "my", "me", "we", "at", "can"},
+	"fr": {"le", "la", "les", "et", "est", "vous", "votre", "de", "des", "pour", "une", "un", "dans", "que", "qui", "pas", "ce", "je", "nous", "sur", "avec", "au", "du"},
+	"es": {"el", "los", "las", "y", "es", "usted", "su", "de", "para", "una", "en", "que", "por", "con", "no", "se", "del", "mi", "tu", "esta", "como", "al"},
+	"pt": {"o", "os", "as", "e", "é", "você", "seu", "sua", "de", "para", "uma", "um", "em", "que", "não", "com", "do", "da", "meu", "obrigado", "está"},
+	"de": {"der", "die", "das", "und", "ist", "sie", "ihr", "ihre", "zu", "für", "ein", "eine", "nicht", "mit", "ich", "wir", "auf", "dem", "den", "sind", "bitte"},
+	"it": {"il", "lo", "gli", "e", "è", "lei", "di", "per", "una", "non", "che", "con", "sono", "ti", "grazie", "della", "questo", "mio", "ciao"},
+	"nl": {"de", "het", "een", "en", "is", "u", "uw", "je", "van", "voor", "niet", "met", "ik", "wij", "op", "dat", "zijn", "bedankt"},
+	"sw": {"na", "ni", "wa", "kwa", "ya", "za", "katika", "hii", "yako", "asante", "sana", "tafadhali", "habari", "wewe", "mimi", "leo"},
+}
+
+// languageScripts are the languages which can be detected from the unicode script of the letters
+var languageScripts = []struct {
+	language string
+	table    *unicode.RangeTable
+}{
+	{language: "ja", table: unicode.Hiragana},
+	{language: "ja", table: unicode.Katakana},
+	{language: "ko", table: unicode.Hangul},
+	{language: "zh", table: unicode.Han},
+	{language: "ru", table: unicode.Cyrillic},
+	{language: "el", table: unicode.Greek},
+	{language: "he", table: unicode.Hebrew},
+	{language: "ar", table: unicode.Arabic},
+	{language: "th", table: unicode.Thai},
+	{language: "hi", table: unicode.Devanagari},
+}
+
+// DetectedLanguages returns the ISO 639-1 codes which can be returned by DetectLanguage
+func DetectedLanguages() []string {
+	languages := []string{LanguageUnknown}
+	for language := range languageStopWords {
+		languages = append(languages, language)
+	}
+	for _, script := range languageScripts {
+		if !slices.Contains(languages, script.language) {
+			languages = append(languages, script.language)
+		}
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// DetectLanguage returns the ISO 639-1 code of the language of the content or LanguageUnknown when there is no clear winner
+func DetectLanguage(content string) string {
+	if language := detectLanguageFromScript(content); language != "" {
+		return language
+	}
+
+	words := strings.FieldsFunc(strings.ToLower(content), func(char rune) bool {
+		return !unicode.IsLetter(char)
+	})
+	if len(words) < minLanguageWords {
+		return LanguageUnknown
+	}
+
+	scores := map[string]int{}
+	for _, word := range words {
+		for language, stopWords := range languageStopWords {
+			if slices.Contains(stopWords, word) {
+				scores[language]++
+			}
+		}
+	}
+
+	best, bestScore, secondScore := LanguageUnknown, 0, 0
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, secondScore = language, score, bestScore
+		case score > secondScore:
+			secondScore = score
+		}
+	}
+
+	// a tie or a single matching word is more likely to be a wrong guess than a detection
+	if bestScore < 2 || bestScore == secondScore {
+		return LanguageUnknown
+	}
+	return best
+}
+
+// detectLanguageFromScript returns the language of the script used by most of the letters when it is not latin
+func detectLanguageFromScript(content string) string {
+	counts := map[string]int{}
+	letters := 0
+	for _, char := range content {
+		if !unicode.IsLetter(char) {
+			continue
+		}
+		letters++
+		for _, script := range languageScripts {
+			if unicode.Is(script.table, char) {
+				counts[script.language]++
+				break
+			}
+		}
+	}
+
+	// japanese text mixes kana with han characters
+	if counts["ja"] > 0 {
+		counts["ja"] += counts["zh"]
+		delete(counts, "zh")
+	}
+
+	for language, count := range counts {
+		if count*2 > letters {
+			return language
+		}
+	}
+	return ""
+}
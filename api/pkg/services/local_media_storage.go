@@ -0,0 +1,88 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+type localMediaStorage struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	root   string
+}
+
+// NewLocalMediaStorage creates a MediaStorage which saves files in the root directory of the local file system
+func NewLocalMediaStorage(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	root string,
+) MediaStorage {
+	return &localMediaStorage{
+		logger: logger.WithService(fmt.Sprintf("%T", localMediaStorage{})),
+		tracer: tracer,
+		root:   root,
+	}
+}
+
+// Store saves the content of a media file with the key
+func (storage *localMediaStorage) Store(ctx context.Context, key string, _ string, content []byte) error {
+	_, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	path := storage.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot create directory for media file [%s]", key)))
+	}
+
+	if err := os.WriteFile(path, content, 0o640); err != nil {
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot write media file [%s]", key)))
+	}
+	return nil
+}
+
+// Load reads length bytes of a media file starting at offset
+func (storage *localMediaStorage) Load(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	_, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	file, err := os.Open(storage.path(key))
+	if err != nil {
+		return nil, storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot open media file [%s]", key)))
+	}
+
+	if _, err = file.Seek(offset, io.SeekStart); err != nil {
+		_ = file.Close()
+		return nil, storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot seek media file [%s] to offset [%d]", key, offset)))
+	}
+
+	if length < 0 {
+		return file, nil
+	}
+
+	return struct {
+		io.Reader
+		io.Closer
+	}{io.LimitReader(file, length), file}, nil
+}
+
+// Delete removes the media file with the key, it does not fail when the file does not exist
+func (storage *localMediaStorage) Delete(ctx context.Context, key string) error {
+	_, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	if err := os.Remove(storage.path(key)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot delete media file [%s]", key)))
+	}
+	return nil
+}
+
+func (storage *localMediaStorage) path(key string) string {
+	return filepath.Join(storage.root, filepath.FromSlash(filepath.Clean("/"+key)))
+}
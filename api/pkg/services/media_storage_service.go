@@ -0,0 +1,18 @@
+package services
+
+import (
+	"context"
+	"io"
+)
+
+// MediaStorage stores the media files of messages e.g. images received in an MMS and the archived messages
+type MediaStorage interface {
+	// Store saves the content of a media file with the key
+	Store(ctx context.Context, key string, contentType string, content []byte) error
+
+	// Load reads length bytes of a media file starting at offset, a negative length reads until the end of the file
+	Load(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error)
+
+	// Delete removes the media file with the key, it does not fail when the file does not exist
+	Delete(ctx context.Context, key string) error
+}
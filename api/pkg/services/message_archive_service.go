@@ -0,0 +1,214 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// messageArchiveInterval is the interval at which the archiver looks for messages which are older than the archival window
+	messageArchiveInterval = time.Hour
+
+	// messageArchiveBatchSize is the number of messages which are loaded from the database at once by the archiver
+	messageArchiveBatchSize = 500
+)
+
+// MessageArchiveService moves the content of old messages to cold storage and keeps a stub of the message and its event logs in the database
+type MessageArchiveService struct {
+	service
+	logger     telemetry.Logger
+	tracer     telemetry.Tracer
+	repository repositories.MessageRepository
+	storage    MediaStorage
+
+	// eventLogRepository removes the content of the archived messages from the event logs
+	eventLogRepository repositories.EventLogRepository
+
+	// archiveAfter is the age of the messages which are archived, messages are never archived when it is 0
+	archiveAfter time.Duration
+}
+
+// NewMessageArchiveService creates a new MessageArchiveService
+func NewMessageArchiveService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.MessageRepository,
+	storage MediaStorage,
+	eventLogRepository repositories.EventLogRepository,
+	archiveAfter time.Duration,
+) (s *MessageArchiveService) {
+	return &MessageArchiveService{
+		logger:             logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:             tracer,
+		repository:         repository,
+		storage:            storage,
+		eventLogRepository: eventLogRepository,
+		archiveAfter:       archiveAfter,
+	}
+}
+
+// Run archives the old messages every messageArchiveInterval until the context is done
+func (service *MessageArchiveService) Run(ctx context.Context) {
+	if service.archiveAfter <= 0 {
+		service.logger.Info("message archival is disabled because the archival window is not set")
+		return
+	}
+
+	ticker := time.NewTicker(messageArchiveInterval)
+	defer ticker.Stop()
+
+	for {
+		if count, err := service.Archive(ctx); err != nil {
+			service.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot archive messages after archiving [%d] messages", count)))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Archive moves the messages which are older than the archival window to cold storage and returns the number of archived messages
+func (service *MessageArchiveService) Archive(ctx context.Context) (count int, err error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	before := time.Now().UTC().Add(-service.archiveAfter)
+	for ctx.Err() == nil {
+		messages, err := service.repository.IndexArchivable(ctx, before, messageArchiveBatchSize)
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch the messages created before [%s] which can be archived", before)
+			return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		for _, message := range messages {
+			if err = service.archive(ctx, message); err != nil {
+				msg := fmt.Sprintf("cannot archive message with ID [%s] for user [%s]", message.ID, message.UserID)
+				return count, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+			count++
+		}
+
+		if len(messages) < messageArchiveBatchSize {
+			break
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("archived [%d] messages created before [%s] to [%T]", count, before, service.storage))
+	return count, nil
+}
+
+// Restore sets the content of an archived message from cold storage, the message is not changed when it is not archived
+func (service *MessageArchiveService) Restore(ctx context.Context, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if !message.IsArchived() {
+		return nil
+	}
+
+	reader, err := service.storage.Load(ctx, message.ArchiveKey(), 0, -1)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load archived message with ID [%s] from [%T]", message.ID, service.storage)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	defer func() { _ = reader.Close() }()
+
+	payload, err := io.ReadAll(reader)
+	if err != nil {
+		msg := fmt.Sprintf("cannot read archived message with ID [%s] from [%T]", message.ID, service.storage)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	archived := new(entities.Message)
+	if err = json.Unmarshal(payload, archived); err != nil {
+		msg := fmt.Sprintf("cannot decode archived message with ID [%s]", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// only the content is restored because the other fields of the stub can change after the message is archived e.g. when it is starred
+	message.Content = archived.Content
+	return nil
+}
+
+// ArchivedMessages fetches the archived messages between an owner and a contact so that their archives can be deleted after the messages are deleted
+func (service *MessageArchiveService) ArchivedMessages(ctx context.Context, userID entities.UserID, owner string, contact string) ([]*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	messages, err := service.repository.IndexArchived(ctx, userID, owner, contact)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the archived messages between owner [%s] and contact [%s] for user [%s]", owner, contact, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return messages, nil
+}
+
+// Delete removes the archives of deleted messages from cold storage, the messages which are not archived are skipped
+func (service *MessageArchiveService) Delete(ctx context.Context, messages ...*entities.Message) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count := 0
+	for _, message := range messages {
+		if !message.IsArchived() {
+			continue
+		}
+
+		if err := service.storage.Delete(ctx, message.ArchiveKey()); err != nil {
+			msg := fmt.Sprintf("cannot delete the archive of message with ID [%s] from [%T] after deleting [%d] archives", message.ID, service.storage, count)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		count++
+	}
+
+	if count > 0 {
+		ctxLogger.Info(fmt.Sprintf("deleted the archives of [%d] messages from [%T]", count, service.storage))
+	}
+	return nil
+}
+
+func (service *MessageArchiveService) archive(ctx context.Context, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		msg := fmt.Sprintf("cannot encode message with ID [%s] as JSON", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the archive is written before the content is removed from the database so that the content is never lost, writing it twice is harmless
+	if err = service.storage.Store(ctx, message.ArchiveKey(), "application/json", payload); err != nil {
+		msg := fmt.Sprintf("cannot store message with ID [%s] in [%T]", message.ID, service.storage)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the event logs are redacted before the message so that a failure is retried by the next run of the archiver
+	if err = service.eventLogRepository.RedactContent(ctx, message.UserID, "message", message.ID.String()); err != nil {
+		msg := fmt.Sprintf("cannot redact the content of the event logs of archived message with ID [%s]", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	err = service.repository.Archive(ctx, message, time.Now().UTC())
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		// the message was archived by another instance of the archiver or deleted after it was fetched
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot remove the content of archived message with ID [%s]", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/palantir/stacktrace"
+)
+
+// DefaultMessageCategoryRules are used to classify received messages when the user has not configured custom rules
+var DefaultMessageCategoryRules = entities.MessageCategoryRules{
+	{
+		Category: entities.MessageCategoryOTP,
+		Pattern:  `(?is)\b(code|otp|verification|verify|passcode|password|pin)\b.*\b[0-9]{4,8}\b|\b[0-9]{4,8}\b.*\b(code|otp|verification|verify|passcode|password|pin)\b`,
+	},
+	{
+		Category: entities.MessageCategoryMarketing,
+		Pattern:  `(?i)\b(sale|discount|offer|promo|promotion|deal|coupon|unsubscribe)\b|\b[0-9]{1,2}% off\b|\breply stop\b|\bstop to (opt[- ]?out|unsubscribe|end)\b`,
+	},
+}
+
+// messageClassifierPatternCacheSize is the maximum number of compiled category patterns which are kept in memory
+const messageClassifierPatternCacheSize = 1024
+
+// personalContactRegex matches contacts which are regular phone numbers and not short codes or alphanumeric sender IDs
+var personalContactRegex = regexp.MustCompile(`^\+?[0-9]{7,15}$`)
+
+// MessageClassifier detects the entities.MessageCategory of a received message
+type MessageClassifier struct {
+	logger   telemetry.Logger
+	tracer   telemetry.Tracer
+	patterns *lru.Cache[string, *regexp.Regexp]
+}
+
+// NewMessageClassifier creates a new MessageClassifier
+func NewMessageClassifier(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (c *MessageClassifier) {
+	patterns, _ := lru.New[string, *regexp.Regexp](messageClassifierPatternCacheSize)
+	return &MessageClassifier{
+		logger:   logger.WithService(fmt.Sprintf("%T", c)),
+		tracer:   tracer,
+		patterns: patterns,
+	}
+}
+
+// Classify detects the category of a message using the user's rules, the DefaultMessageCategoryRules are used when rules is empty.
+// Messages which don't match any rule are personal if they come from a regular phone number and unknown otherwise.
+func (classifier *MessageClassifier) Classify(ctx context.Context, rules entities.MessageCategoryRules, contact string, content string, encrypted bool) entities.MessageCategory {
+	_, span, ctxLogger := classifier.tracer.StartWithLogger(ctx, classifier.logger)
+	defer span.End()
+
+	if encrypted {
+		return entities.MessageCategoryUnknown
+	}
+
+	if len(rules) == 0 {
+		rules = DefaultMessageCategoryRules
+	}
+
+	for _, rule := range rules {
+		pattern, err := classifier.compile(rule.Pattern)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot compile message category rule [%s] for category [%s]", rule.Pattern, rule.Category)))
+			continue
+		}
+		if pattern.MatchString(content) {
+			return rule.Category
+		}
+	}
+
+	if personalContactRegex.MatchString(contact) {
+		return entities.MessageCategoryPersonal
+	}
+
+	return entities.MessageCategoryUnknown
+}
+
+func (classifier *MessageClassifier) compile(pattern string) (*regexp.Regexp, error) {
+	if compiled, ok := classifier.patterns.Get(pattern); ok {
+		return compiled, nil
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot compile pattern [%s]", pattern))
+	}
+
+	classifier.patterns.Add(pattern, compiled)
+	return compiled, nil
+}
@@ -0,0 +1,49 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageClassifier_Classify(t *testing.T) {
+	custom := entities.MessageCategoryRules{
+		{Category: entities.MessageCategoryOTP, Pattern: `(?i)\border\b`},
+		{Category: entities.MessageCategoryMarketing, Pattern: `(`},
+	}
+
+	tests := []struct {
+		name      string
+		rules     entities.MessageCategoryRules
+		contact   string
+		content   string
+		encrypted bool
+		category  entities.MessageCategory
+	}{
+		{name: "a verification code is an OTP", contact: "GOOGLE", content: "Your verification code is 123456", category: entities.MessageCategoryOTP},
+		{name: "a promotion is marketing", contact: "12345", content: "Get 20% off today, reply STOP to opt out", category: entities.MessageCategoryMarketing},
+		{name: "a message from a phone number is personal", contact: "+18005550100", content: "See you tomorrow", category: entities.MessageCategoryPersonal},
+		{name: "a message from a short code is unknown", contact: "12345", content: "See you tomorrow", category: entities.MessageCategoryUnknown},
+		{name: "an encrypted message is unknown", contact: "+18005550100", content: "Your verification code is 123456", encrypted: true, category: entities.MessageCategoryUnknown},
+		{name: "the rules of the user replace the default rules", rules: custom, contact: "+18005550100", content: "Your order has shipped", category: entities.MessageCategoryOTP},
+		{name: "the default rules are not used with the rules of the user", rules: custom, contact: "+18005550100", content: "Your verification code is 123456", category: entities.MessageCategoryPersonal},
+	}
+
+	classifier := NewMessageClassifier(testLogger(), telemetry.NewOtelLogger("test", testLogger()))
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			category := classifier.Classify(context.Background(), tt.rules, tt.contact, tt.content, tt.encrypted)
+
+			// Assert
+			assert.Equal(t, tt.category, category)
+		})
+	}
+}
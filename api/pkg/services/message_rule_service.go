@@ -0,0 +1,315 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	lru "github.com/hashicorp/golang-lru/v2"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// messageRulePatternCacheSize is the maximum number of compiled content patterns which are kept in memory
+const messageRulePatternCacheSize = 1024
+
+// MessageRuleService is responsible for the rules which forward, reply to and label the messages received by a user.
+// It is the only pipeline which replies to received messages, the AutoAckMessage and the OutOfOfficeMessage of a phone are evaluated as rules.
+type MessageRuleService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.MessageRuleRepository
+	phoneService   *PhoneService
+	messageService *MessageService
+	webhookService *WebhookService
+	labelService   *LabelService
+	patterns       *lru.Cache[string, *regexp.Regexp]
+}
+
+// NewMessageRuleService creates a new MessageRuleService
+func NewMessageRuleService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.MessageRuleRepository,
+	phoneService *PhoneService,
+	messageService *MessageService,
+	webhookService *WebhookService,
+	labelService *LabelService,
+) (s *MessageRuleService) {
+	patterns, _ := lru.New[string, *regexp.Regexp](messageRulePatternCacheSize)
+	return &MessageRuleService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		phoneService:   phoneService,
+		messageService: messageService,
+		webhookService: webhookService,
+		labelService:   labelService,
+		patterns:       patterns,
+	}
+}
+
+// Index fetches the entities.MessageRule of a user
+func (service *MessageRuleService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.MessageRule, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rules, err := service.repository.Index(ctx, userID, params)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch message rules for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] message rules for user [%s]", len(rules), userID))
+	return rules, nil
+}
+
+// MessageRuleStoreParams are parameters for creating a new entities.MessageRule
+type MessageRuleStoreParams struct {
+	UserID         entities.UserID
+	Name           string
+	Position       uint
+	Enabled        bool
+	StopProcessing bool
+	Sender         *string
+	Recipient      *string
+	ContentPattern *string
+	Schedule       entities.PhoneBusinessHoursSchedule
+	WebhookID      *uuid.UUID
+	ReplyContent   *string
+	LabelID        *uuid.UUID
+}
+
+// Store a new entities.MessageRule
+func (service *MessageRuleService) Store(ctx context.Context, params *MessageRuleStoreParams) (*entities.MessageRule, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rule := &entities.MessageRule{
+		ID:        uuid.New(),
+		UserID:    params.UserID,
+		CreatedAt: time.Now().UTC(),
+	}
+	service.apply(rule, params)
+
+	if err := service.repository.Save(ctx, rule); err != nil {
+		msg := fmt.Sprintf("cannot save message rule with id [%s]", rule.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message rule saved with id [%s] for user [%s] in the [%T]", rule.ID, rule.UserID, service.repository))
+	return rule, nil
+}
+
+// MessageRuleUpdateParams are parameters for updating an entities.MessageRule
+type MessageRuleUpdateParams struct {
+	MessageRuleStoreParams
+	RuleID uuid.UUID
+}
+
+// Update an entities.MessageRule
+func (service *MessageRuleService) Update(ctx context.Context, params *MessageRuleUpdateParams) (*entities.MessageRule, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	rule, err := service.repository.Load(ctx, params.UserID, params.RuleID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message rule with userID [%s] and ruleID [%s]", params.UserID, params.RuleID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	service.apply(rule, &params.MessageRuleStoreParams)
+
+	if err = service.repository.Save(ctx, rule); err != nil {
+		msg := fmt.Sprintf("cannot save message rule with id [%s] after update", rule.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message rule updated with id [%s] for user [%s]", rule.ID, rule.UserID))
+	return rule, nil
+}
+
+func (service *MessageRuleService) apply(rule *entities.MessageRule, params *MessageRuleStoreParams) {
+	rule.Name = params.Name
+	rule.Position = params.Position
+	rule.Enabled = params.Enabled
+	rule.StopProcessing = params.StopProcessing
+	rule.Sender = params.Sender
+	rule.Recipient = params.Recipient
+	rule.ContentPattern = params.ContentPattern
+	rule.Schedule = params.Schedule
+	rule.WebhookID = params.WebhookID
+	rule.ReplyContent = params.ReplyContent
+	rule.LabelID = params.LabelID
+	rule.UpdatedAt = time.Now().UTC()
+}
+
+// Delete an entities.MessageRule
+func (service *MessageRuleService) Delete(ctx context.Context, userID entities.UserID, ruleID uuid.UUID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID, ruleID); err != nil {
+		msg := fmt.Sprintf("cannot load message rule with userID [%s] and ruleID [%s]", userID, ruleID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID, ruleID); err != nil {
+		msg := fmt.Sprintf("cannot delete message rule with id [%s] and user id [%s]", ruleID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted message rule with id [%s] and user id [%s]", ruleID, userID))
+	return nil
+}
+
+// Evaluate performs the actions of the rules of the phone and the enabled entities.MessageRule of the user which match a received message.
+// A failed action is logged and the other actions are still performed because the replies and labels are idempotent but the webhook may have received the event.
+func (service *MessageRuleService) Evaluate(ctx context.Context, event cloudevents.Event, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneService.Load(ctx, payload.UserID, payload.Owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find phone with owner [%s] for user with ID [%s] when evaluating message rules for message [%s]", payload.Owner, payload.UserID, payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	rules, err := service.repository.FetchEnabled(ctx, payload.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch message rules for user [%s]", payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	content := payload.Content
+	if payload.Encrypted {
+		content = ""
+	}
+
+	for _, rule := range append(phone.MessageRules(), rules...) {
+		pattern, err := service.contentPattern(rule)
+		if err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("skipping message rule [%s] for message [%s] because its content pattern is not valid", rule.ID, payload.MessageID)))
+			continue
+		}
+
+		if !rule.Matches(pattern, payload.Owner, payload.Contact, content, payload.Timestamp, phone.Location()) {
+			continue
+		}
+
+		ctxLogger.Info(fmt.Sprintf("message rule [%s] of user [%s] matched the message [%s]", rule.ID, payload.UserID, payload.MessageID))
+		service.perform(ctx, event, phone, rule, payload)
+
+		if rule.StopProcessing {
+			ctxLogger.Info(fmt.Sprintf("skipping the message rules after [%s] for message [%s] because it stops processing", rule.ID, payload.MessageID))
+			break
+		}
+	}
+
+	return nil
+}
+
+// contentPattern returns the compiled ContentPattern of an entities.MessageRule, the patterns are validated when a rule is saved and each pattern is compiled once
+func (service *MessageRuleService) contentPattern(rule *entities.MessageRule) (*regexp.Regexp, error) {
+	if rule.ContentPattern == nil {
+		return nil, nil
+	}
+
+	if pattern, ok := service.patterns.Get(*rule.ContentPattern); ok {
+		return pattern, nil
+	}
+
+	pattern, err := regexp.Compile(*rule.ContentPattern)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot compile the content pattern [%s]", *rule.ContentPattern))
+	}
+
+	service.patterns.Add(*rule.ContentPattern, pattern)
+	return pattern, nil
+}
+
+// perform the actions of a matching entities.MessageRule on a received message
+func (service *MessageRuleService) perform(ctx context.Context, event cloudevents.Event, phone *entities.Phone, rule *entities.MessageRule, payload *events.MessagePhoneReceivedPayload) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if rule.LabelID != nil {
+		if _, err := service.labelService.Attach(ctx, payload.UserID, payload.MessageID, *rule.LabelID); err != nil {
+			msg := fmt.Sprintf("cannot attach label [%s] of message rule [%s] to message [%s]", *rule.LabelID, rule.ID, payload.MessageID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		}
+	}
+
+	if rule.WebhookID != nil {
+		if err := service.webhookService.SendTo(ctx, payload.UserID, *rule.WebhookID, event, payload.Owner); err != nil {
+			msg := fmt.Sprintf("cannot forward message [%s] to webhook [%s] of message rule [%s]", payload.MessageID, *rule.WebhookID, rule.ID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		}
+	}
+
+	if rule.ReplyContent != nil {
+		if err := service.reply(ctx, event.Source(), phone, rule, payload); err != nil {
+			msg := fmt.Sprintf("cannot reply to message [%s] with message rule [%s]", payload.MessageID, rule.ID)
+			ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		}
+	}
+}
+
+// reply to a received message with the ReplyContent of an entities.MessageRule, the ID of the reply is derived from the rule and the message so it is sent once.
+// Messages from the other phones of the user are not replied to so that 2 phones do not reply to each other forever,
+// and messages which are flagged by a content filter or from contacts which are not in the allowed recipients are not replied to.
+func (service *MessageRuleService) reply(ctx context.Context, source string, phone *entities.Phone, rule *entities.MessageRule, payload *events.MessagePhoneReceivedPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if reason := service.replySkipReason(ctx, phone, payload); reason != "" {
+		ctxLogger.Info(fmt.Sprintf("not replying with message rule [%s] to message [%s] from [%s] for user [%s] because %s", rule.ID, payload.MessageID, payload.Contact, payload.UserID, reason))
+		return nil
+	}
+
+	messageID := rule.ReplyMessageID(payload.MessageID, payload.Contact, payload.Timestamp)
+	requestID := fmt.Sprintf("message-rule-%s", payload.MessageID)
+	owner, _ := phonenumbers.Parse(payload.Owner, phonenumbers.UNKNOWN_REGION)
+	message, err := service.messageService.SendMessage(ctx, MessageSendParams{
+		ID:                &messageID,
+		Owner:             owner,
+		Contact:           payload.Contact,
+		Content:           rule.ReplyText(payload.Contact, payload.Timestamp),
+		Source:            source,
+		RequestID:         &requestID,
+		UserID:            payload.UserID,
+		InReplyTo:         &payload.MessageID,
+		RequestReceivedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot send reply of message rule [%s] for owner [%s] for user with ID [%s] when handling received message [%s]", rule.ID, payload.Owner, payload.UserID, payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message rule [%s] replied with message [%s] to received message [%s] for user [%s]", rule.ID, message.ID, payload.MessageID, message.UserID))
+	return nil
+}
+
+// replySkipReason returns the reason why a received message must not be replied to, it is empty when the message can be replied to
+func (service *MessageRuleService) replySkipReason(ctx context.Context, phone *entities.Phone, payload *events.MessagePhoneReceivedPayload) string {
+	if payload.ContentFilterID != nil {
+		return fmt.Sprintf("it is flagged by the content filter [%s]", *payload.ContentFilterID)
+	}
+
+	if !phone.AllowsRecipient(payload.Contact) {
+		return "the contact is not one of the allowed recipients of the phone"
+	}
+
+	if _, err := service.phoneService.Load(ctx, payload.UserID, payload.Contact); err == nil {
+		return "the contact is another phone of the user"
+	}
+	return ""
+}
@@ -3,8 +3,10 @@ package services
 import (
 	"context"
 	"fmt"
+	"io"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/davecgh/go-spew/spew"
 
@@ -20,6 +22,35 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 )
 
+const (
+	// ErrCodeMessageInReplyToInvalid is returned when the message being replied to does not exist or is not in the same conversation
+	ErrCodeMessageInReplyToInvalid = stacktrace.ErrorCode(1107)
+
+	// ErrCodeMessageIDConflict is returned when the client generated ID of a message is already used by a message of another user
+	ErrCodeMessageIDConflict = stacktrace.ErrorCode(1110)
+
+	// ErrCodeMessageRecipientNotAllowed is returned when the phone has an allowlist of recipients which does not contain the contact
+	ErrCodeMessageRecipientNotAllowed = stacktrace.ErrorCode(1111)
+
+	// ErrCodeMessageNotCancellable is returned when a message is cancelled after it has left the pending or scheduled state
+	ErrCodeMessageNotCancellable = stacktrace.ErrorCode(1112)
+
+	// ErrCodeMessageContactUnresolvable is returned when the contact is a national number which is not possible in the default country of the user
+	ErrCodeMessageContactUnresolvable = stacktrace.ErrorCode(1113)
+
+	// ErrCodeMessageShortCodeNotSupported is returned when the contact is a short code and the SIM of the phone cannot send messages to short codes
+	ErrCodeMessageShortCodeNotSupported = stacktrace.ErrorCode(1117)
+
+	// ErrCodeMessageContentTooLong is returned when the content of a received message is longer than the inbound content limit of the user and the limit mode is reject
+	ErrCodeMessageContentTooLong = stacktrace.ErrorCode(1122)
+
+	// ErrCodeMessageNotReactable is returned when reacting to an outgoing message which has not been sent by the phone
+	ErrCodeMessageNotReactable = stacktrace.ErrorCode(1127)
+
+	// maxReplyChainLength is the maximum number of messages returned in a reply chain
+	maxReplyChainLength = 50
+)
+
 // MessageService is handles message requests
 type MessageService struct {
 	service
@@ -28,6 +59,25 @@ type MessageService struct {
 	eventDispatcher *EventDispatcher
 	phoneService    *PhoneService
 	repository      repositories.MessageRepository
+	userRepository  repositories.UserRepository
+	classifier      *MessageClassifier
+	mediaStorage    MediaStorage
+
+	// contentFilterService flags received messages which match the inbound content filters of the user
+	contentFilterService *ContentFilterService
+
+	// broker notifies long-poll requests when the messages of a user change
+	broker      *UserEventBroker
+	pollTimeout time.Duration
+
+	// replyTokenSigner signs the reply tokens of received messages, there are no reply tokens when it is nil
+	replyTokenSigner *ReplyTokenSigner
+
+	// notificationRepository is the rate limited queue of the phones which is used to compute the queue position of pending messages
+	notificationRepository repositories.PhoneNotificationRepository
+
+	// archiveService fetches the content of archived messages from cold storage
+	archiveService *MessageArchiveService
 }
 
 // NewMessageService creates a new MessageService
@@ -37,13 +87,168 @@ func NewMessageService(
 	repository repositories.MessageRepository,
 	eventDispatcher *EventDispatcher,
 	phoneService *PhoneService,
+	userRepository repositories.UserRepository,
+	classifier *MessageClassifier,
+	mediaStorage MediaStorage,
+	broker *UserEventBroker,
+	pollTimeout time.Duration,
+	contentFilterService *ContentFilterService,
+	replyTokenSigner *ReplyTokenSigner,
+	notificationRepository repositories.PhoneNotificationRepository,
+	archiveService *MessageArchiveService,
 ) (s *MessageService) {
 	return &MessageService{
-		logger:          logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:          tracer,
-		repository:      repository,
-		phoneService:    phoneService,
-		eventDispatcher: eventDispatcher,
+		logger:                 logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:                 tracer,
+		repository:             repository,
+		phoneService:           phoneService,
+		userRepository:         userRepository,
+		classifier:             classifier,
+		mediaStorage:           mediaStorage,
+		eventDispatcher:        eventDispatcher,
+		broker:                 broker,
+		pollTimeout:            pollTimeout,
+		contentFilterService:   contentFilterService,
+		replyTokenSigner:       replyTokenSigner,
+		notificationRepository: notificationRepository,
+		archiveService:         archiveService,
+	}
+}
+
+// messagePollKeepAliveInterval is the interval at which a long-poll request checks that the client is still connected
+const messagePollKeepAliveInterval = 5 * time.Second
+
+// MessagePollParams are parameters for waiting for the new messages of a user
+type MessagePollParams struct {
+	UserID entities.UserID
+	Since  time.Time
+	Limit  int
+
+	// SinceID is the ID of the last message of the previous poll, the messages updated at Since with a greater ID are also returned
+	SinceID uuid.UUID
+
+	// KeepAlive is called periodically while waiting, the wait stops when it returns an error e.g. because the client has disconnected
+	KeepAlive func() error
+}
+
+// MessagePoll contains the messages returned by a long-poll request
+type MessagePoll struct {
+	Messages []*entities.Message `json:"messages"`
+
+	// Cursor is the since parameter of the next long-poll request
+	Cursor string `json:"cursor" example:"2022-06-05T14:26:10.303278Z_32343a19-da5e-4b1b-a767-3298a73703cb"`
+}
+
+// messagePollCursorSeparator separates the timestamp and the ID of the last message in the cursor of a poll
+const messagePollCursorSeparator = "_"
+
+// MessagePollCursor returns the cursor of a poll which continues after the message with the ID which was updated at the timestamp
+func MessagePollCursor(updatedAt time.Time, messageID uuid.UUID) string {
+	return updatedAt.UTC().Format(time.RFC3339Nano) + messagePollCursorSeparator + messageID.String()
+}
+
+// ParseMessagePollCursor returns the timestamp and the ID of a cursor returned by MessagePollCursor.
+// A cursor can also be an RFC3339 timestamp and then only the messages which are updated after the timestamp are returned.
+func ParseMessagePollCursor(cursor string) (time.Time, uuid.UUID, error) {
+	timestamp, id, found := strings.Cut(cursor, messagePollCursorSeparator)
+	since, err := time.Parse(time.RFC3339Nano, timestamp)
+	if err != nil {
+		return since, uuid.Nil, stacktrace.Propagate(err, fmt.Sprintf("cannot parse the timestamp of the cursor [%s]", cursor))
+	}
+
+	if !found {
+		return since.UTC(), uuid.Max, nil
+	}
+
+	sinceID, err := uuid.Parse(id)
+	if err != nil {
+		return since, uuid.Nil, stacktrace.Propagate(err, fmt.Sprintf("cannot parse the message ID of the cursor [%s]", cursor))
+	}
+	return since.UTC(), sinceID, nil
+}
+
+// Poll waits until a message of the user is created or updated after params.Since and returns the messages.
+// The poll returns no messages when nothing changes before the poll timeout.
+func (service *MessageService) Poll(ctx context.Context, params MessagePollParams) (*MessagePoll, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	// the subscription is created before the first query so that a message which changes in-between is not missed
+	events, unsubscribe := service.broker.Subscribe(params.UserID)
+	defer unsubscribe()
+
+	timeout := time.NewTimer(service.pollTimeout)
+	defer timeout.Stop()
+
+	keepAlive := time.NewTicker(messagePollKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		messages, err := service.repository.IndexUpdatedSince(ctx, params.UserID, params.Since, params.SinceID, params.Limit)
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch messages of user [%s] updated after [%s]", params.UserID, params.Since)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if len(messages) > 0 {
+			ctxLogger.Info(fmt.Sprintf("fetched [%d] messages of user [%s] updated after [%s]", len(messages), params.UserID, params.Since))
+			last := messages[len(messages)-1]
+			return &MessagePoll{Messages: messages, Cursor: MessagePollCursor(last.UpdatedAt, last.ID)}, nil
+		}
+
+		// the messages are also fetched on every keep alive in case the event was published on another instance of the API
+		select {
+		case <-events:
+		case <-timeout.C:
+			return &MessagePoll{Messages: messages, Cursor: MessagePollCursor(params.Since, params.SinceID)}, nil
+		case <-keepAlive.C:
+			if err = params.KeepAlive(); err != nil {
+				msg := fmt.Sprintf("stopped waiting for messages of user [%s] because the keep alive failed", params.UserID)
+				return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+		case <-ctx.Done():
+			msg := fmt.Sprintf("stopped waiting for messages of user [%s] because the context is done", params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(ctx.Err(), msg))
+		}
+	}
+}
+
+// messagePushWaitInterval is the interval at which a send request which waits for the push notification reloads the message, the push is sent by the queue so it can be on another instance of the API
+const messagePushWaitInterval = 250 * time.Millisecond
+
+// WaitForPush waits until firebase cloud messaging accepts or rejects the push notification of a message, or until the phone picks up the message.
+// The message is returned with PushWaitTimedOut set when neither happens before the timeout.
+func (service *MessageService) WaitForPush(ctx context.Context, userID entities.UserID, messageID uuid.UUID, timeout time.Duration) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	ticker := time.NewTicker(messagePushWaitInterval)
+	defer ticker.Stop()
+
+	for {
+		message, err := service.repository.Load(ctx, userID, messageID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load message [%s] of user [%s] while waiting for its push notification", messageID, userID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if message.PushStatus != nil || (!message.IsPending() && !message.IsScheduled()) {
+			return message, nil
+		}
+
+		select {
+		case <-ticker.C:
+		case <-deadline.C:
+			ctxLogger.Info(fmt.Sprintf("the push notification of message [%s] of user [%s] was not sent after waiting for [%s]", messageID, userID, timeout))
+			message.PushWaitTimedOut = true
+			return message, nil
+		case <-ctx.Done():
+			msg := fmt.Sprintf("stopped waiting for the push notification of message [%s] because the context is done", messageID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(ctx.Err(), msg))
+		}
 	}
 }
 
@@ -106,6 +311,11 @@ func (service *MessageService) DeleteMessage(ctx context.Context, source string,
 		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
+	// the message has already been deleted so a failure to delete its archive is logged
+	if err := service.archiveService.Delete(ctx, message); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete the archive of message with ID [%s] for user with ID [%s]", message.ID, message.UserID)))
+	}
+
 	var prevID *uuid.UUID
 	var prevStatus *entities.MessageStatus
 	var prevContent *string
@@ -152,12 +362,24 @@ func (service *MessageService) DeleteByOwnerAndContact(ctx context.Context, user
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	if err := service.repository.DeleteByOwnerAndContact(ctx, userID, owner, contact); err != nil {
+	archived, err := service.archiveService.ArchivedMessages(ctx, userID, owner, contact)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the archived messages for user with ID [%s] between owner [%s] and contact [%s]", userID, owner, contact)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	count, err := service.repository.DeleteByOwnerAndContact(ctx, userID, owner, contact)
+	if err != nil {
 		msg := fmt.Sprintf("could not all delete messages for user with ID [%s] between owner [%s] and contact [%s] ", userID, owner, contact)
 		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	ctxLogger.Info(fmt.Sprintf("deleted all messages for user with ID [%s] between owner [%s] and contact [%s] ", userID, owner, contact))
+	// the messages have already been deleted so a failure to delete their archives is logged
+	if err = service.archiveService.Delete(ctx, archived...); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete the archives of the messages for user with ID [%s] between owner [%s] and contact [%s]", userID, owner, contact)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted all [%d] messages for user with ID [%s] between owner [%s] and contact [%s] ", count, userID, owner, contact))
 	return nil
 }
 
@@ -202,9 +424,19 @@ func (service *MessageService) RespondToMissedCall(ctx context.Context, source s
 // MessageGetParams parameters for sending a new message
 type MessageGetParams struct {
 	repositories.IndexParams
-	UserID  entities.UserID
-	Owner   string
-	Contact string
+	UserID   entities.UserID
+	Owner    string
+	Contact  string
+	Metadata entities.MessageMetadata
+	Category entities.MessageCategory
+	Language string
+	Starred  bool
+
+	// LabelID fetches only the messages with the entities.Label when it is set
+	LabelID *uuid.UUID
+
+	// ContactMatch is how the Contact is matched, it is an exact match by default
+	ContactMatch repositories.ContactMatch
 }
 
 // GetMessages fetches sent between 2 phone numbers
@@ -214,17 +446,37 @@ func (service *MessageService) GetMessages(ctx context.Context, params MessageGe
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	messages, err := service.repository.Index(ctx, params.UserID, params.Owner, params.Contact, params.IndexParams)
+	messages, err := service.repository.Index(ctx, params.UserID, params.Owner, params.Contact, params.ContactMatch, params.Metadata, params.Category, params.Language, params.Starred, params.LabelID, params.IndexParams)
 	if err != nil {
 		msg := fmt.Sprintf("could not fetch messages with parms [%+#v]", params)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	result := make([]*entities.Message, 0, len(*messages))
+	for index := range *messages {
+		result = append(result, &(*messages)[index])
+	}
+	service.setQueuePositions(ctx, result)
+
 	ctxLogger.Info(fmt.Sprintf("fetched [%d] messages with prams [%+#v]", len(*messages), params))
 	return messages, nil
 }
 
-// GetMessage fetches a message by the ID
+// GetMessagesVersion fetches the repositories.ListVersion of all the messages which match the params ignoring the pagination
+func (service *MessageService) GetMessagesVersion(ctx context.Context, params MessageGetParams) (*repositories.ListVersion, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	version, err := service.repository.IndexVersion(ctx, params.UserID, params.Owner, params.Contact, params.ContactMatch, params.Metadata, params.Category, params.Language, params.Starred, params.LabelID, params.IndexParams)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch the version of messages with parms [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return version, nil
+}
+
+// GetMessage fetches a message by the ID, the content of an archived message is fetched from cold storage
 func (service *MessageService) GetMessage(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
@@ -235,9 +487,80 @@ func (service *MessageService) GetMessage(ctx context.Context, userID entities.U
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
+	if err = service.archiveService.Restore(ctx, message); err != nil {
+		msg := fmt.Sprintf("could not restore the content of archived message with ID [%s]", messageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	service.setQueuePositions(ctx, []*entities.Message{message})
+	return message, nil
+}
+
+// ToggleStar stars a message which is not starred and unstars a starred message, no event is fired since it is only a state of the user
+func (service *MessageService) ToggleStar(ctx context.Context, userID entities.UserID, messageID uuid.UUID) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.ToggleStarred(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot toggle the star of message with ID [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message [%s] of user [%s] has starred [%t]", message.ID, userID, message.Starred))
 	return message, nil
 }
 
+// MessagesMarkedRead is the result of marking many messages as read
+type MessagesMarkedRead struct {
+	Count int64 `json:"count" example:"42"`
+}
+
+// MarkRead marks the unread messages of the user with the given IDs as read in one query, no event is fired since it is only a state of the user.
+// IDs of messages which do not belong to the user or which are already read are skipped and are not included in the count.
+func (service *MessageService) MarkRead(ctx context.Context, userID entities.UserID, messageIDs []uuid.UUID) (*MessagesMarkedRead, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count, err := service.repository.MarkRead(ctx, userID, messageIDs, time.Now().UTC())
+	if err != nil {
+		msg := fmt.Sprintf("cannot mark [%d] messages as read for user [%s]", len(messageIDs), userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("marked [%d] out of [%d] messages as read for user [%s]", count, len(messageIDs), userID))
+	return &MessagesMarkedRead{Count: count}, nil
+}
+
+// setQueuePositions sets the queue position and the estimated send time of the pending messages from the rate limited queue of their phones
+func (service *MessageService) setQueuePositions(ctx context.Context, messages []*entities.Message) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	pending := make(map[uuid.UUID]*entities.Message)
+	messageIDs := make([]uuid.UUID, 0, len(messages))
+	for _, message := range messages {
+		if message.IsPending() {
+			pending[message.ID] = message
+			messageIDs = append(messageIDs, message.ID)
+		}
+	}
+
+	if len(messageIDs) == 0 {
+		return
+	}
+
+	positions, err := service.notificationRepository.QueuePositions(ctx, messageIDs)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load the queue positions of [%d] pending messages", len(pending))))
+		return
+	}
+
+	for _, position := range positions {
+		pending[position.MessageID].SetQueuePosition(position.Position, position.ScheduledAt, time.Now().UTC())
+	}
+}
+
 // MessageStoreEventParams parameters registering a message event
 type MessageStoreEventParams struct {
 	MessageID    uuid.UUID
@@ -283,6 +606,14 @@ type MessageReceiveParams struct {
 	Timestamp time.Time
 	Encrypted bool
 	Source    string
+
+	Attachments []MessageAttachmentParams
+}
+
+// MessageAttachmentParams is a media file received in an MMS message
+type MessageAttachmentParams struct {
+	ContentType string
+	Content     []byte
 }
 
 // ReceiveMessage handles message received by a mobile phone
@@ -292,16 +623,48 @@ func (service *MessageService) ReceiveMessage(ctx context.Context, params *Messa
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	// the content is limited before the duplicate check so that a retry of a truncated message matches the stored content
+	user := service.loadReceiver(ctx, params.UserID)
+	originalContentLength, err := service.limitContent(user, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot receive message from [%s] for user [%s]", params.Contact, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if originalContentLength != nil {
+		ctxLogger.Info(fmt.Sprintf("truncated the content of the message from [%s] for user [%s] from [%d] to [%d] characters", params.Contact, params.UserID, *originalContentLength, user.InboundContentMaxLength))
+	}
+
 	eventPayload := events.MessagePhoneReceivedPayload{
 		MessageID: uuid.New(),
 		UserID:    params.UserID,
 		Encrypted: params.Encrypted,
 		Owner:     phonenumbers.Format(&params.Owner, phonenumbers.E164),
-		Contact:   params.Contact,
+		Contact:   NormalizePhoneNumber(phonenumbers.Format(&params.Owner, phonenumbers.E164), params.Contact),
 		Timestamp: params.Timestamp,
 		Content:   params.Content,
 		SIM:       params.SIM,
+
+		OriginalContentLength: originalContentLength,
+	}
+
+	if message := service.duplicateReceivedMessage(ctx, eventPayload); message != nil {
+		ctxLogger.Info(fmt.Sprintf("message [%s] from [%s] to [%s] has already been received at [%s]", message.ID, message.Contact, message.Owner, params.Timestamp))
+		return message, nil
+	}
+
+	eventPayload.ContactType = ContactType(eventPayload.Contact)
+	eventPayload.Category = service.classify(ctx, user, params)
+	eventPayload.Language = service.detectLanguage(ctx, user, params)
+	eventPayload.ContentFilterID = service.flagContent(ctx, params)
+	eventPayload.ReplyToken = service.replyToken(ctx, eventPayload)
+
+	attachments, err := service.storeAttachments(ctx, params.UserID, eventPayload.MessageID, params.Attachments)
+	if err != nil {
+		msg := fmt.Sprintf("cannot store [%d] attachments of received message with ID [%s]", len(params.Attachments), eventPayload.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
+	eventPayload.Attachments = attachments
 
 	ctxLogger.Info(fmt.Sprintf("creating cloud event for received with ID [%s]", eventPayload.MessageID))
 
@@ -322,6 +685,179 @@ func (service *MessageService) ReceiveMessage(ctx context.Context, params *Messa
 	return service.storeReceivedMessage(ctx, eventPayload)
 }
 
+func (service *MessageService) storeAttachments(ctx context.Context, userID entities.UserID, messageID uuid.UUID, params []MessageAttachmentParams) (entities.MessageAttachments, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if len(params) == 0 {
+		return nil, nil
+	}
+
+	message := &entities.Message{ID: messageID, UserID: userID}
+	attachments := make(entities.MessageAttachments, 0, len(params))
+	for index, attachment := range params {
+		if err := service.mediaStorage.Store(ctx, message.AttachmentKey(index), attachment.ContentType, attachment.Content); err != nil {
+			msg := fmt.Sprintf("cannot store attachment [%d] of message with ID [%s] in [%T]", index, messageID, service.mediaStorage)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		attachments = append(attachments, entities.MessageAttachment{
+			ContentType: attachment.ContentType,
+			Size:        int64(len(attachment.Content)),
+		})
+	}
+
+	ctxLogger.Info(fmt.Sprintf("stored [%d] attachments of message with ID [%s] in [%T]", len(attachments), messageID, service.mediaStorage))
+	return attachments, nil
+}
+
+// LoadAttachment reads length bytes of the attachment at the index of an entities.Message starting at offset
+func (service *MessageService) LoadAttachment(ctx context.Context, message *entities.Message, index int, offset int64, length int64) (io.ReadCloser, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	reader, err := service.mediaStorage.Load(ctx, message.AttachmentKey(index), offset, length)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load attachment [%d] of message with ID [%s] from [%T]", index, message.ID, service.mediaStorage)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return reader, nil
+}
+
+// limitContent truncates the content of a received message which is longer than the inbound content limit of the user and returns the original length.
+// An error is returned instead when the limit mode of the user is reject, encrypted content is never truncated because it could not be decrypted.
+func (service *MessageService) limitContent(user *entities.User, params *MessageReceiveParams) (*uint, error) {
+	if user == nil || user.InboundContentMaxLength == 0 {
+		return nil, nil
+	}
+
+	length := uint(utf8.RuneCountInString(params.Content))
+	if length <= user.InboundContentMaxLength {
+		return nil, nil
+	}
+
+	if user.InboundContentLimitMode == entities.InboundContentLimitModeReject {
+		msg := fmt.Sprintf("the content of the message from [%s] has [%d] characters which is more than the inbound limit of [%d] characters of user [%s]", params.Contact, length, user.InboundContentMaxLength, user.ID)
+		return nil, stacktrace.NewErrorWithCode(ErrCodeMessageContentTooLong, msg)
+	}
+
+	if params.Encrypted {
+		return nil, nil
+	}
+
+	params.Content = string([]rune(params.Content)[:user.InboundContentMaxLength])
+	return &length, nil
+}
+
+// loadReceiver loads the settings used to process a received message, it returns nil when the user cannot be loaded so the defaults are used
+func (service *MessageService) loadReceiver(ctx context.Context, userID entities.UserID) *entities.User {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user [%s] to process received message, using the default settings", userID)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return nil
+	}
+	return user
+}
+
+func (service *MessageService) classify(ctx context.Context, user *entities.User, params *MessageReceiveParams) entities.MessageCategory {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	var rules entities.MessageCategoryRules
+	if user != nil {
+		rules = user.MessageCategoryRules
+	}
+
+	return service.classifier.Classify(ctx, rules, params.Contact, params.Content, params.Encrypted)
+}
+
+// flagContent returns the ID of the inbound content filter which matches the content of a received message
+func (service *MessageService) flagContent(ctx context.Context, params *MessageReceiveParams) *uuid.UUID {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	// the content of an encrypted message cannot be read by the server
+	if params.Encrypted {
+		return nil
+	}
+
+	filter, err := service.contentFilterService.Match(ctx, params.UserID, entities.ContentFilterDirectionInbound, params.Content)
+	if err != nil {
+		// a received message is never dropped because the content filters cannot be loaded
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot match the content filters of user [%s] for a received message", params.UserID)))
+		return nil
+	}
+
+	if filter == nil {
+		return nil
+	}
+	return &filter.ID
+}
+
+// replyToken returns nil when reply tokens are not enabled so that the message is still received
+func (service *MessageService) replyToken(ctx context.Context, payload events.MessagePhoneReceivedPayload) *string {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if service.replyTokenSigner == nil {
+		return nil
+	}
+
+	token, err := service.replyTokenSigner.Sign(payload.UserID, payload.MessageID, payload.Owner, payload.Contact, time.Now().UTC())
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create the reply token of received message [%s]", payload.MessageID)))
+		return nil
+	}
+	return &token
+}
+
+// VerifyReplyToken decodes the conversation of a reply token which was created when a message was received
+func (service *MessageService) VerifyReplyToken(ctx context.Context, token string) (*ReplyTokenClaims, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if service.replyTokenSigner == nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeReplyTokenInvalid, "reply tokens are not enabled"))
+	}
+
+	claims, err := service.replyTokenSigner.Verify(token, time.Now().UTC())
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), "cannot verify the reply token"))
+	}
+
+	_, err = service.repository.Load(ctx, claims.UserID, claims.ReplyMessageID())
+	if err == nil {
+		msg := fmt.Sprintf("the reply token of message [%s] has already been used to send reply [%s]", claims.MessageID, claims.ReplyMessageID())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeReplyTokenUsed, msg))
+	}
+	if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load reply [%s] to check if the reply token of message [%s] has been used", claims.ReplyMessageID(), claims.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("verified the reply token of message [%s] for user [%s]", claims.MessageID, claims.UserID))
+	return claims, nil
+}
+
+// detectLanguage returns an empty string when the user has not enabled language detection
+func (service *MessageService) detectLanguage(ctx context.Context, user *entities.User, params *MessageReceiveParams) string {
+	_, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if user == nil || !user.LanguageDetectionEnabled {
+		return ""
+	}
+
+	// the content of an encrypted message cannot be read by the server
+	if params.Encrypted {
+		return LanguageUnknown
+	}
+	return DetectLanguage(params.Content)
+}
+
 func (service *MessageService) handleMessageSentEvent(ctx context.Context, params MessageStoreEventParams, message *entities.Message) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
@@ -335,6 +871,7 @@ func (service *MessageService) handleMessageSentEvent(ctx context.Context, param
 		Contact:   message.Contact,
 		Encrypted: message.Encrypted,
 		Content:   message.Content,
+		Metadata:  message.Metadata,
 		SIM:       message.SIM,
 	})
 	if err != nil {
@@ -362,6 +899,7 @@ func (service *MessageService) handleMessageDeliveredEvent(ctx context.Context,
 		Encrypted: message.Encrypted,
 		Contact:   message.Contact,
 		Content:   message.Content,
+		Metadata:  message.Metadata,
 		SIM:       message.SIM,
 	})
 	if err != nil {
@@ -377,7 +915,7 @@ func (service *MessageService) handleMessageDeliveredEvent(ctx context.Context,
 }
 
 func (service *MessageService) handleMessageFailedEvent(ctx context.Context, params MessageStoreEventParams, message *entities.Message) error {
-	ctx, span := service.tracer.Start(ctx)
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
 	errorMessage := "UNKNOWN ERROR"
@@ -395,6 +933,7 @@ func (service *MessageService) handleMessageFailedEvent(ctx context.Context, par
 		RequestID:    message.RequestID,
 		UserID:       message.UserID,
 		Content:      message.Content,
+		Metadata:     message.Metadata,
 		SIM:          message.SIM,
 	})
 	if err != nil {
@@ -406,6 +945,11 @@ func (service *MessageService) handleMessageFailedEvent(ctx context.Context, par
 		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
+
+	// the event has already been dispatched so a failure is logged instead of retrying the event
+	if err = service.phoneService.UpdateLastError(ctx, message.UserID, message.Owner, errorMessage, params.Timestamp); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot update the last error of phone [%s] for message [%s]", message.Owner, message.ID)))
+	}
 	return nil
 }
 
@@ -418,8 +962,73 @@ type MessageSendParams struct {
 	Source            string
 	SendAt            *time.Time
 	RequestID         *string
+	Metadata          entities.MessageMetadata
 	UserID            entities.UserID
 	RequestReceivedAt time.Time
+
+	// SendAtTimezone is used to interpret the date and time of SendAt without its UTC offset, SendAt is used as it is when it is empty
+	SendAtTimezone MessageSendAtTimezone
+
+	// SenderID is the alphanumeric sender ID of the phone, the Owner is ignored when it is set
+	SenderID *string
+
+	// ValidityPeriod is how long the carrier should attempt to deliver the message, the message is not retried when it is set
+	ValidityPeriod *time.Duration
+
+	// InReplyTo is the ID of the message in the same conversation which this message is a reply to
+	InReplyTo *uuid.UUID
+
+	// ID is the client generated ID of the message, a new ID is generated when it is nil
+	ID *uuid.UUID
+
+	// GroupID is shared by the messages which are sent with the same content to multiple recipients
+	GroupID *uuid.UUID
+
+	// PhoneGroup is the group of phones which sends the message, the Owner is selected by the strategy of the group when it is set
+	PhoneGroup *string
+
+	// ProviderFallback sends the message with the third-party SMS provider of the user if no phone picks it up within the timeout of the provider
+	ProviderFallback bool
+
+	// SkipSignature sends the content without the signature of the phone
+	SkipSignature bool
+
+	// CountryRoute is the country of the route which selected the Owner when the message is sent without a from number
+	CountryRoute *string
+}
+
+// IsRouted checks if the phone which sends the message is selected by the country routes of the user
+func (params MessageSendParams) IsRouted() bool {
+	return params.Owner == nil && params.SenderID == nil && params.PhoneGroup == nil
+}
+
+// MessageSendAtTimezone determines the timezone in which the date and time of a scheduled message are interpreted
+type MessageSendAtTimezone string
+
+const (
+	// MessageSendAtTimezoneUser interprets the send time in the timezone of the user
+	MessageSendAtTimezoneUser = MessageSendAtTimezone("user")
+
+	// MessageSendAtTimezonePhone interprets the send time in the timezone of the phone which sends the message
+	MessageSendAtTimezonePhone = MessageSendAtTimezone("phone")
+)
+
+// MessageFanOut contains the messages which were created when the same content was sent to multiple recipients
+type MessageFanOut struct {
+	GroupID  uuid.UUID          `json:"group_id" example:"8f9c71b8-b84e-4417-8408-a62274f65a08"`
+	Messages []entities.Message `json:"messages"`
+
+	// Failures are the recipients which were not sent the message e.g. because the phone number is not valid
+	Failures []MessageFanOutFailure `json:"failures"`
+}
+
+// MessageFanOutFailure is a recipient of a MessageFanOut which was not sent the message
+type MessageFanOutFailure struct {
+	To    string `json:"to" example:"+18005550100"`
+	Error string `json:"error" example:"The to field must contain only digits and must be less than 14 characters"`
+
+	// ErrorCode is the machine-readable reason why the recipient was rejected e.g. invalid_phone_number
+	ErrorCode string `json:"error_code" example:"invalid_phone_number"`
 }
 
 // SendMessage a new message
@@ -429,30 +1038,106 @@ func (service *MessageService) SendMessage(ctx context.Context, params MessageSe
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	sendAttempts, sim := service.phoneSettings(ctx, params.UserID, phonenumbers.Format(params.Owner, phonenumbers.E164))
-
-	eventPayload := events.MessageAPISentPayload{
-		MessageID:         uuid.New(),
-		UserID:            params.UserID,
-		Encrypted:         params.Encrypted,
-		MaxSendAttempts:   sendAttempts,
-		RequestID:         params.RequestID,
-		Owner:             phonenumbers.Format(params.Owner, phonenumbers.E164),
-		Contact:           params.Contact,
-		RequestReceivedAt: params.RequestReceivedAt,
-		Content:           params.Content,
-		ScheduledSendTime: params.SendAt,
-		SIM:               sim,
+	// a retry with the same client generated ID returns the message of the first attempt without sending it again
+	if existing, err := service.loadByClientID(ctx, params); err != nil || existing != nil {
+		return existing, err
 	}
 
-	event, err := service.createMessageAPISentEvent(params.Source, eventPayload)
+	contact, err := service.resolveContact(ctx, params)
 	if err != nil {
-		msg := fmt.Sprintf("cannot create %T from payload with message id [%s]", event, eventPayload.MessageID)
-		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		msg := fmt.Sprintf("cannot resolve the contact [%s] of message for user [%s]", params.Contact, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+	params.Contact = contact
+
+	if params, err = service.routeMessage(ctx, params); err != nil {
+		msg := fmt.Sprintf("cannot route the message to [%s] for user [%s]", params.Contact, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	owner, err := service.messageOwner(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find the owner of message for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.validateRecipient(ctx, params, owner); err != nil {
+		msg := fmt.Sprintf("cannot send message from [%s] to [%s] for user [%s]", owner, params.Contact, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if params.SendAt, err = service.localSendAt(ctx, params, owner); err != nil {
+		msg := fmt.Sprintf("cannot resolve the send time [%s] in the [%s] timezone for user [%s]", params.SendAt, params.SendAtTimezone, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.validateInReplyTo(ctx, params, owner); err != nil {
+		msg := fmt.Sprintf("cannot reply to message [%s] for user [%s]", params.InReplyTo, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	sendAttempts, sim, content := service.phoneSettings(ctx, params, owner)
+	if params.ValidityPeriod != nil {
+		// the message expires on the server when the validity period elapses on the phone so it is not retried
+		sendAttempts = 1
+	}
+
+	messageID := uuid.New()
+	if params.ID != nil {
+		messageID = *params.ID
+	}
+
+	eventPayload := events.MessageAPISentPayload{
+		MessageID:         messageID,
+		UserID:            params.UserID,
+		Encrypted:         params.Encrypted,
+		MaxSendAttempts:   sendAttempts,
+		RequestID:         params.RequestID,
+		Owner:             owner,
+		SenderID:          params.SenderID,
+		Contact:           NormalizePhoneNumber(owner, params.Contact),
+		RequestReceivedAt: params.RequestReceivedAt,
+		Content:           content,
+		Metadata:          params.Metadata,
+		ScheduledSendTime: params.SendAt,
+		SIM:               sim,
+		ValidityPeriod:    params.ValidityPeriod,
+		InReplyTo:         params.InReplyTo,
+		GroupID:           params.GroupID,
+		PhoneGroup:        params.PhoneGroup,
+		ProviderFallback:  params.ProviderFallback,
+		CountryRoute:      params.CountryRoute,
+	}
+
+	event, err := service.createMessageAPISentEvent(params.Source, eventPayload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create %T from payload with message id [%s]", event, eventPayload.MessageID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	ctxLogger.Info(fmt.Sprintf("created event [%s] with id [%s] and message id [%s] and user [%s]", event.Type(), event.ID(), eventPayload.MessageID, eventPayload.UserID))
+
+	// a scheduled message is charged to the daily quota of the day when it is sent
+	quotaTimestamp := params.RequestReceivedAt
+	if params.SendAt != nil {
+		quotaTimestamp = *params.SendAt
+	}
+
+	if err = service.phoneService.ConsumeDailyQuota(ctx, params.UserID, owner, quotaTimestamp); err != nil {
+		msg := fmt.Sprintf("cannot consume the daily quota of phone [%s] for user [%s]", owner, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	message, err := service.storeSentMessage(ctx, eventPayload)
+	if err != nil {
+		if releaseErr := service.phoneService.ReleaseDailyQuota(ctx, params.UserID, owner, quotaTimestamp); releaseErr != nil {
+			ctxLogger.Error(stacktrace.Propagate(releaseErr, fmt.Sprintf("cannot release the daily quota of phone [%s] for message [%s]", owner, eventPayload.MessageID)))
+		}
+	}
+
+	if stacktrace.GetCode(err) == repositories.ErrCodeConflict && params.ID != nil {
+		return service.loadConflictingMessage(ctx, params)
 	}
-	ctxLogger.Info(fmt.Sprintf("created event [%s] with id [%s] and message id [%s] and user [%s]", event.Type(), event.ID(), eventPayload.MessageID, eventPayload.UserID))
 
-	message, err := service.storeSentMessage(ctx, eventPayload)
 	if err != nil {
 		msg := fmt.Sprintf("cannot store message with id [%s]", eventPayload.MessageID)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
@@ -465,7 +1150,304 @@ func (service *MessageService) SendMessage(ctx context.Context, params MessageSe
 	}
 
 	ctxLogger.Info(fmt.Sprintf("[%s] event with ID [%s] dispatched succesfully for message [%s] with user [%s] and delay [%s]", event.Type(), event.ID(), eventPayload.MessageID, eventPayload.UserID, timeout))
-	return message, err
+
+	if service.isScheduled(eventPayload) {
+		// the message has already been accepted so a failure is logged instead of failing the request
+		if err = service.dispatchMessageScheduled(ctx, params.Source, message); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch the [%s] event for message [%s]", events.EventTypeMessageScheduled, message.ID)))
+		}
+	}
+	return message, nil
+}
+
+// isScheduled checks if a message was sent with a send time in the future
+func (service *MessageService) isScheduled(payload events.MessageAPISentPayload) bool {
+	return payload.ScheduledSendTime != nil && payload.ScheduledSendTime.After(payload.RequestReceivedAt)
+}
+
+// dispatchMessageScheduled fires the events.EventTypeMessageScheduled event when a message with a send time in the future is accepted
+func (service *MessageService) dispatchMessageScheduled(ctx context.Context, source string, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	event, err := service.createEvent(events.EventTypeMessageScheduled, source, events.MessageScheduledPayload{
+		MessageID: message.ID,
+		Owner:     message.Owner,
+		Contact:   message.Contact,
+		RequestID: message.RequestID,
+		UserID:    message.UserID,
+		Encrypted: message.Encrypted,
+		SendAt:    *message.ScheduledSendTime,
+		Timestamp: message.RequestReceivedAt,
+		Content:   message.Content,
+		Metadata:  message.Metadata,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageScheduled, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// HandleMessageReleased fires the events.EventTypeMessageReleased event when the delayed events.EventTypeMessageAPISent event of a scheduled message is delivered at the send time.
+// No event is fired when the message is not scheduled or when it has been cancelled before the send time.
+func (service *MessageService) HandleMessageReleased(ctx context.Context, source string, payload events.MessageAPISentPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if !service.isScheduled(payload) {
+		return nil
+	}
+
+	message, err := service.repository.Load(ctx, payload.UserID, payload.MessageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("scheduled message [%s] of user [%s] was deleted before its send time", payload.MessageID, payload.UserID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load scheduled message [%s] for user [%s]", payload.MessageID, payload.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.IsCancelled() {
+		ctxLogger.Info(fmt.Sprintf("scheduled message [%s] was cancelled before its send time [%s]", message.ID, payload.ScheduledSendTime))
+		return nil
+	}
+
+	event, err := service.createEvent(events.EventTypeMessageReleased, source, events.MessageReleasedPayload{
+		MessageID:  message.ID,
+		Owner:      message.Owner,
+		Contact:    message.Contact,
+		RequestID:  message.RequestID,
+		UserID:     message.UserID,
+		Encrypted:  message.Encrypted,
+		SendAt:     *payload.ScheduledSendTime,
+		ReleasedAt: time.Now().UTC(),
+		Content:    message.Content,
+		Metadata:   message.Metadata,
+		SIM:        message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageReleased, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("scheduled message [%s] released with send time [%s]", message.ID, payload.ScheduledSendTime))
+	return nil
+}
+
+// loadByClientID returns the message of the user with the client generated ID of the params or nil when it has not been sent yet
+func (service *MessageService) loadByClientID(ctx context.Context, params MessageSendParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if params.ID == nil {
+		return nil, nil
+	}
+
+	message, err := service.repository.Load(ctx, params.UserID, *params.ID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil, nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with client ID [%s] for user [%s]", *params.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message with client ID [%s] has already been sent by user [%s]", message.ID, message.UserID))
+	return message, nil
+}
+
+// loadConflictingMessage returns the message of a concurrent request with the same client generated ID or ErrCodeMessageIDConflict when the ID belongs to another user
+func (service *MessageService) loadConflictingMessage(ctx context.Context, params MessageSendParams) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.loadByClientID(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the message which conflicts with client ID [%s]", *params.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message == nil {
+		msg := fmt.Sprintf("the client ID [%s] is already used by a message which does not belong to user [%s]", *params.ID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageIDConflict, msg))
+	}
+	return message, nil
+}
+
+// MessageBulkRequestIDPrefix is the prefix of the request ID of the messages which are sent from a bulk message file
+const MessageBulkRequestIDPrefix = "bulk-"
+
+// CountPendingBulkJobs counts the bulk message files of a user which still have messages that are not yet sent
+func (service *MessageService) CountPendingBulkJobs(ctx context.Context, userID entities.UserID) (int, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	count, err := service.repository.CountPendingRequests(ctx, userID, MessageBulkRequestIDPrefix)
+	if err != nil {
+		msg := fmt.Sprintf("cannot count the pending bulk jobs of user [%s]", userID)
+		return 0, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return count, nil
+}
+
+// resolveContact formats the contact in E.164 with the default country of the user when it is a national number
+func (service *MessageService) resolveContact(ctx context.Context, params MessageSendParams) (string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	user, err := service.userRepository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user [%s] to resolve the contact [%s]", params.UserID, params.Contact)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if user.DefaultCountry == nil {
+		return params.Contact, nil
+	}
+
+	contact, ok := ResolvePhoneNumber(*user.DefaultCountry, params.Contact)
+	if !ok {
+		msg := fmt.Sprintf("the contact [%s] is not a possible number in the default country [%s] of user [%s]", params.Contact, *user.DefaultCountry, params.UserID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageContactUnresolvable, msg))
+	}
+	return contact, nil
+}
+
+// localSendAt returns the send time with the date and time of MessageSendParams.SendAt in the timezone of the user or the phone
+func (service *MessageService) localSendAt(ctx context.Context, params MessageSendParams, owner string) (*time.Time, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if params.SendAt == nil || params.SendAtTimezone == "" {
+		return params.SendAt, nil
+	}
+
+	var location *time.Location
+	switch params.SendAtTimezone {
+	case MessageSendAtTimezoneUser:
+		user, err := service.userRepository.Load(ctx, params.UserID)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load user [%s] to get the timezone of the send time", params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		location = user.Location()
+	case MessageSendAtTimezonePhone:
+		phone, err := service.phoneService.Load(ctx, params.UserID, owner)
+		if err != nil {
+			msg := fmt.Sprintf("cannot load phone [%s] of user [%s] to get the timezone of the send time", owner, params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		location = phone.Location()
+	default:
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewError(fmt.Sprintf("the send time timezone [%s] is not supported", params.SendAtTimezone)))
+	}
+
+	local := params.SendAt
+	sendAt := time.Date(local.Year(), local.Month(), local.Day(), local.Hour(), local.Minute(), local.Second(), local.Nanosecond(), location).UTC()
+	return &sendAt, nil
+}
+
+// validateRecipient checks that the contact is on the allowlist of the phone when the phone has an allowlist and that the phone can send to short codes
+func (service *MessageService) validateRecipient(ctx context.Context, params MessageSendParams, owner string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	phone, err := service.phoneService.Load(ctx, params.UserID, owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] of user [%s] to check the allowed recipients", owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if contact := NormalizePhoneNumber(owner, params.Contact); !phone.AllowsRecipient(contact) {
+		msg := fmt.Sprintf("the contact [%s] is not one of the [%d] allowed recipients of phone [%s]", contact, len(phone.AllowedRecipients), phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageRecipientNotAllowed, msg))
+	}
+
+	if phone.ShortCodesDisabled && IsShortCode(params.Contact) {
+		msg := fmt.Sprintf("the contact [%s] is a short code and phone [%s] cannot send messages to short codes", params.Contact, phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageShortCodeNotSupported, msg))
+	}
+
+	if phone.IsPaused() && phone.PausedRejectsMessages {
+		msg := fmt.Sprintf("phone [%s] has been paused since [%s] and it rejects messages until it is resumed", phone.ID, phone.PausedAt)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhonePaused, msg))
+	}
+	return nil
+}
+
+// validateInReplyTo checks that the message being replied to belongs to the user and is in the same conversation
+func (service *MessageService) validateInReplyTo(ctx context.Context, params MessageSendParams, owner string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if params.InReplyTo == nil {
+		return nil
+	}
+
+	message, err := service.repository.Load(ctx, params.UserID, *params.InReplyTo)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("the message [%s] being replied to does not exist for user [%s]", params.InReplyTo, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageInReplyToInvalid, msg))
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message [%s] being replied to for user [%s]", params.InReplyTo, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.Owner != owner || message.Contact != NormalizePhoneNumber(owner, params.Contact) {
+		msg := fmt.Sprintf("the message [%s] between [%s] and [%s] is not in the conversation between [%s] and [%s]", message.ID, message.Owner, message.Contact, owner, params.Contact)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageInReplyToInvalid, msg))
+	}
+
+	return nil
+}
+
+// GetReplyChain returns a message followed by the messages it replies to with the oldest message last
+func (service *MessageService) GetReplyChain(ctx context.Context, userID entities.UserID, messageID uuid.UUID) ([]*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, userID, messageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message [%s] for user [%s]", messageID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	chain := []*entities.Message{message}
+	for message.InReplyTo != nil && len(chain) < maxReplyChainLength {
+		message, err = service.repository.Load(ctx, userID, *message.InReplyTo)
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			ctxLogger.Info(fmt.Sprintf("the reply chain of message [%s] ends at a deleted message", messageID))
+			break
+		}
+		if err != nil {
+			msg := fmt.Sprintf("cannot load the reply chain of message [%s] for user [%s]", messageID, userID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		chain = append(chain, message)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("fetched [%d] messages in the reply chain of message [%s] for user [%s]", len(chain), messageID, userID))
+	return chain, nil
 }
 
 // MissedCallParams parameters for sending a new message
@@ -531,6 +1513,19 @@ func (service *MessageService) getSendDelay(ctxLogger telemetry.Logger, eventPay
 	return delay
 }
 
+// duplicateReceivedMessage returns the stored message when the phone retries a received message with the same device timestamp, nil is returned when the message is new
+func (service *MessageService) duplicateReceivedMessage(ctx context.Context, payload events.MessagePhoneReceivedPayload) *entities.Message {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.LoadReceived(ctx, payload.UserID, payload.Owner, payload.Contact, payload.Content, payload.Timestamp)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		// a failed lookup should not drop the message so it is received as a new message
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot check if the message from [%s] to [%s] has already been received", payload.Contact, payload.Owner)))
+	}
+	return message
+}
+
 // StoreReceivedMessage a new message
 func (service *MessageService) storeReceivedMessage(ctx context.Context, params events.MessagePhoneReceivedPayload) (*entities.Message, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -543,6 +1538,7 @@ func (service *MessageService) storeReceivedMessage(ctx context.Context, params
 		Owner:             params.Owner,
 		UserID:            params.UserID,
 		Contact:           params.Contact,
+		ContactType:       ContactType(params.Contact),
 		Content:           params.Content,
 		SIM:               params.SIM,
 		Encrypted:         params.Encrypted,
@@ -553,6 +1549,22 @@ func (service *MessageService) storeReceivedMessage(ctx context.Context, params
 		UpdatedAt:         time.Now().UTC(),
 		OrderTimestamp:    params.Timestamp,
 		ReceivedAt:        &params.Timestamp,
+		Category:          &params.Category,
+		Attachments:       params.Attachments,
+	}
+
+	if params.Language != "" {
+		message.Language = &params.Language
+	}
+
+	if params.ContentFilterID != nil {
+		message.ContentFilterID = params.ContentFilterID
+		message.ContentFlagged = true
+	}
+
+	if params.OriginalContentLength != nil {
+		message.OriginalContentLength = params.OriginalContentLength
+		message.ContentTruncated = true
 	}
 
 	if err := service.repository.Store(ctx, message); err != nil {
@@ -561,6 +1573,11 @@ func (service *MessageService) storeReceivedMessage(ctx context.Context, params
 	}
 
 	ctxLogger.Info(fmt.Sprintf("message saved with id [%s]", message.ID))
+
+	// the message has already been stored so a failure is logged instead of failing the request
+	if err := service.phoneService.UpdateLastReceivedAt(ctx, message.UserID, message.Owner, params.Timestamp); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot update the last received time of phone [%s] for message [%s]", message.Owner, message.ID)))
+	}
 	return message, nil
 }
 
@@ -590,108 +1607,540 @@ func (service *MessageService) HandleMessageSending(ctx context.Context, params
 		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
 	}
 
-	if err = service.repository.Update(ctx, message.AddSendAttempt(params.Timestamp)); err != nil {
-		msg := fmt.Sprintf("cannot update message with id [%s] after sending", message.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if err = service.repository.Update(ctx, message.AddSendAttempt(params.Timestamp)); err != nil {
+		msg := fmt.Sprintf("cannot update message with id [%s] after sending", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message with id [%s] updated after adding send attempt", message.ID))
+	return nil
+}
+
+// HandleMessageSent handles when a message has been sent by a mobile phone
+func (service *MessageService) HandleMessageSent(ctx context.Context, params HandleMessageParams) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	message, err := service.repository.Load(ctx, params.UserID, params.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", params.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.IsSent() || message.IsDelivered() {
+		ctxLogger.Info(fmt.Sprintf("message [%s] for [%s] has already been processed with status [%s]", message.ID, message.UserID, message.Status))
+		return nil
+	}
+
+	if !message.IsSending() && !message.IsExpired() {
+		msg := fmt.Sprintf("message has wrong status [%s]. expected [%s, %s]", message.Status, entities.MessageStatusSending, entities.MessageStatusExpired)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	if err = service.repository.Update(ctx, message.Sent(params.Timestamp)); err != nil {
+		msg := fmt.Sprintf("cannot update message with id [%s] as sent", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
+
+	// the message has already been updated so a failure is logged instead of retrying the event
+	if err = service.phoneService.UpdateLastSentAt(ctx, message.UserID, message.Owner, params.Timestamp); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot update the last sent time of phone [%s] for message [%s]", message.Owner, message.ID)))
+	}
+
+	if err = service.phoneService.ClearLastError(ctx, message.UserID, message.Owner, params.Timestamp); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot clear the last error of phone [%s] for message [%s]", message.Owner, message.ID)))
+	}
+	return nil
+}
+
+// HandleMessageFailedParams are parameters for handling a failed message event
+type HandleMessageFailedParams struct {
+	ID           uuid.UUID
+	UserID       entities.UserID
+	ErrorMessage string
+	Timestamp    time.Time
+}
+
+// HandleMessageFailed handles when a message could not be sent by a mobile phone
+func (service *MessageService) HandleMessageFailed(ctx context.Context, params HandleMessageFailedParams) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	message, err := service.repository.Load(ctx, params.UserID, params.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", params.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if message.IsDelivered() {
+		msg := fmt.Sprintf("message has already been delivered with status [%s]", message.Status)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	if err = service.repository.Update(ctx, message.Failed(params.Timestamp, params.ErrorMessage)); err != nil {
+		msg := fmt.Sprintf("cannot update message with id [%s] as sent", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
+	return nil
+}
+
+// HandlePhoneDeletedParams are parameters for handling the pending messages of a deleted entities.Phone
+type HandlePhoneDeletedParams struct {
+	UserID     entities.UserID
+	Owner      string
+	ReassignTo []string
+	Timestamp  time.Time
+	Source     string
+}
+
+// HandlePhoneDeleted reassigns the pending messages of a deleted phone to the ReassignTo phones in a round-robin order.
+// The pending messages are marked as failed when there are no phones to reassign them to.
+func (service *MessageService) HandlePhoneDeleted(ctx context.Context, params HandlePhoneDeletedParams) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	statuses := []entities.MessageStatus{entities.MessageStatusPending, entities.MessageStatusScheduled, entities.MessageStatusSending}
+	types := []entities.MessageType{entities.MessageTypeMobileTerminated}
+
+	count := 0
+	for {
+		// the handled messages no longer match the search so we always fetch the first page
+		messages, err := service.repository.Search(ctx, params.UserID, []string{params.Owner}, types, statuses, repositories.IndexParams{Limit: 100})
+		if err != nil {
+			msg := fmt.Sprintf("cannot search pending messages for owner [%s] and user [%s]", params.Owner, params.UserID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		for _, message := range messages {
+			if len(params.ReassignTo) == 0 {
+				err = service.failDeletedPhoneMessage(ctx, params, message)
+			} else {
+				err = service.reassignMessage(ctx, params, message, params.ReassignTo[count%len(params.ReassignTo)])
+			}
+			if err != nil {
+				msg := fmt.Sprintf("cannot handle message with ID [%s] for deleted phone [%s]", message.ID, params.Owner)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+			count++
+		}
+
+		if len(messages) < 100 {
+			break
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("handled [%d] pending messages for deleted phone [%s] with reassign targets [%s]", count, params.Owner, strings.Join(params.ReassignTo, ",")))
+	return nil
+}
+
+func (service *MessageService) failDeletedPhoneMessage(ctx context.Context, params HandlePhoneDeletedParams, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	errorMessage := fmt.Sprintf("the phone [%s] was deleted before the message was sent", params.Owner)
+	if err := service.repository.Update(ctx, message.Failed(params.Timestamp, errorMessage)); err != nil {
+		msg := fmt.Sprintf("cannot update message with id [%s] as failed", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createMessageSendFailedEvent(params.Source, events.MessageSendFailedPayload{
+		ID:           message.ID,
+		Owner:        message.Owner,
+		ErrorMessage: errorMessage,
+		Timestamp:    params.Timestamp,
+		Encrypted:    message.Encrypted,
+		Contact:      message.Contact,
+		RequestID:    message.RequestID,
+		UserID:       message.UserID,
+		Content:      message.Content,
+		Metadata:     message.Metadata,
+		SIM:          message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageSendFailed, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+func (service *MessageService) reassignMessage(ctx context.Context, params HandlePhoneDeletedParams, message *entities.Message, owner string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	phone, err := service.phoneService.Load(ctx, params.UserID, owner)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with owner [%s] for user [%s]", owner, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the sender ID belongs to the SIM of the deleted phone
+	message.Owner = phone.PhoneNumber
+	message.SIM = phone.SIM
+	message.SenderID = nil
+	message.Status = entities.MessageStatusPending
+	if err = service.repository.Update(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot reassign message with id [%s] to owner [%s]", message.ID, owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createMessageSendRetryEvent(params.Source, &events.MessageSendRetryPayload{
+		MessageID: message.ID,
+		Timestamp: params.Timestamp,
+		Contact:   message.Contact,
+		Owner:     message.Owner,
+		Encrypted: message.Encrypted,
+		UserID:    message.UserID,
+		Content:   message.Content,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for reassigned message with ID [%s]", events.EventTypeMessageSendRetry, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for message with ID [%s]", event.Type(), message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// HandlePhoneFcmTokenRefreshedParams are parameters for requeuing the messages of an entities.Phone with a new FCM token
+type HandlePhoneFcmTokenRefreshedParams struct {
+	UserID    entities.UserID
+	Owner     string
+	Timestamp time.Time
+	Source    string
+}
+
+// HandlePhoneFcmTokenRefreshed requeues the messages which failed only because FCM rejected the previous token of the phone
+func (service *MessageService) HandlePhoneFcmTokenRefreshed(ctx context.Context, params HandlePhoneFcmTokenRefreshedParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	count := 0
+	for {
+		// the requeued messages are no longer failed so we always fetch the first page
+		messages, err := service.repository.IndexFailedByReason(ctx, params.UserID, params.Owner, fcmTokenInvalidFailureReason, 100)
+		if err != nil {
+			msg := fmt.Sprintf("cannot fetch messages which failed with an invalid FCM token for owner [%s] and user [%s]", params.Owner, params.UserID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		for _, message := range messages {
+			if err = service.requeueMessage(ctx, params, message); err != nil {
+				msg := fmt.Sprintf("cannot requeue message with ID [%s] for phone [%s]", message.ID, params.Owner)
+				return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+			}
+			count++
+		}
+
+		if len(messages) < 100 {
+			break
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("requeued [%d] messages after the FCM token of phone [%s] was refreshed for user [%s]", count, params.Owner, params.UserID))
+	return nil
+}
+
+func (service *MessageService) requeueMessage(ctx context.Context, params HandlePhoneFcmTokenRefreshedParams, message *entities.Message) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message.Status = entities.MessageStatusPending
+	message.FailedAt = nil
+	message.FailureReason = nil
+	if err := service.repository.Update(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot requeue message with id [%s] for owner [%s]", message.ID, message.Owner)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createMessageSendRetryEvent(params.Source, &events.MessageSendRetryPayload{
+		MessageID: message.ID,
+		Timestamp: params.Timestamp,
+		Contact:   message.Contact,
+		Owner:     message.Owner,
+		Encrypted: message.Encrypted,
+		UserID:    message.UserID,
+		Content:   message.Content,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for requeued message with ID [%s]", events.EventTypeMessageSendRetry, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for message with ID [%s]", event.Type(), message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// HandleMessageDelivered handles when a message is has been delivered by a mobile phone
+func (service *MessageService) HandleMessageDelivered(ctx context.Context, params HandleMessageParams) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	message, err := service.repository.Load(ctx, params.UserID, params.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", params.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !message.IsSent() && !message.IsSending() && !message.IsExpired() && !message.IsScheduled() {
+		msg := fmt.Sprintf("message has wrong status [%s]. expected [%s, %s, %s, %s]", message.Status, entities.MessageStatusSent, entities.MessageStatusScheduled, entities.MessageStatusSending, entities.MessageStatusExpired)
+		ctxLogger.Warn(stacktrace.NewError(msg))
+		return nil
+	}
+
+	if err = service.repository.Update(ctx, message.Delivered(params.Timestamp)); err != nil {
+		msg := fmt.Sprintf("cannot update message with id [%s] as delivered", message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
+	return nil
+}
+
+// MessageCancelParams are parameters for cancelling a message
+type MessageCancelParams struct {
+	UserID    entities.UserID
+	MessageID uuid.UUID
+	Timestamp time.Time
+	Source    string
+}
+
+// CancelMessage cancels a message which is pending or scheduled and fires the events.EventTypeMessageCancelled event.
+// ErrCodeMessageNotCancellable is returned with the message when a phone has already picked it up.
+func (service *MessageService) CancelMessage(ctx context.Context, params MessageCancelParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.Cancel(ctx, params.UserID, params.MessageID, params.Timestamp)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return service.loadUncancellableMessage(ctx, params)
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot cancel message with id [%s] for user [%s]", params.MessageID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.EventTypeMessageCancelled, params.Source, events.MessageCancelledPayload{
+		MessageID: message.ID,
+		Owner:     message.Owner,
+		Contact:   message.Contact,
+		RequestID: message.RequestID,
+		UserID:    message.UserID,
+		Encrypted: message.Encrypted,
+		Timestamp: params.Timestamp,
+		Content:   message.Content,
+		Metadata:  message.Metadata,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageCancelled, message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
+	return message, nil
+}
+
+// loadUncancellableMessage returns ErrCodeMessageNotCancellable with the message which could not be cancelled because it is no longer pending or scheduled
+func (service *MessageService) loadUncancellableMessage(ctx context.Context, params MessageCancelParams) (*entities.Message, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, params.UserID, params.MessageID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with id [%s] which could not be cancelled for user [%s]", params.MessageID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	ctxLogger.Info(fmt.Sprintf("message with id [%s] updated after adding send attempt", message.ID))
-	return nil
+	msg := fmt.Sprintf("cannot cancel message with id [%s] because it has status [%s]", message.ID, message.Status)
+	return message, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageNotCancellable, msg))
 }
 
-// HandleMessageSent handles when a message has been sent by a mobile phone
-func (service *MessageService) HandleMessageSent(ctx context.Context, params HandleMessageParams) error {
-	ctx, span := service.tracer.Start(ctx)
+// MessageReadReceiptParams are parameters for registering that the recipient has read a message
+type MessageReadReceiptParams struct {
+	MessageID uuid.UUID
+	Timestamp time.Time
+	Supported bool
+	Source    string
+}
+
+// StoreReadReceipt registers that the recipient has read a delivered message and fires the events.EventTypeMessageRead event.
+// The message is not changed when read receipts are not supported by the carrier of the recipient or when the message has not been sent.
+func (service *MessageService) StoreReadReceipt(ctx context.Context, message *entities.Message, params MessageReadReceiptParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+	if !params.Supported {
+		ctxLogger.Info(fmt.Sprintf("read receipts are not supported for message [%s] sent to [%s]", message.ID, message.Contact))
+		return message, nil
+	}
 
-	message, err := service.repository.Load(ctx, params.UserID, params.ID)
-	if err != nil {
-		msg := fmt.Sprintf("cannot find message with id [%s]", params.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if message.IsRead() || (!message.IsDelivered() && !message.IsSent()) {
+		ctxLogger.Info(fmt.Sprintf("ignoring read receipt for message [%s] with status [%s]", message.ID, message.Status))
+		return message, nil
 	}
 
-	if message.IsSent() || message.IsDelivered() {
-		ctxLogger.Info(fmt.Sprintf("message [%s] for [%s] has already been processed with status [%s]", message.ID, message.UserID, message.Status))
-		return nil
+	if err := service.repository.Update(ctx, message.RecipientRead(params.Timestamp)); err != nil {
+		msg := fmt.Sprintf("cannot update message with id [%s] as read", message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if !message.IsSending() && !message.IsExpired() {
-		msg := fmt.Sprintf("message has wrong status [%s]. expected [%s, %s]", message.Status, entities.MessageStatusSending, entities.MessageStatusExpired)
-		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	event, err := service.createEvent(events.EventTypeMessageRead, params.Source, events.MessageReadPayload{
+		ID:        message.ID,
+		Owner:     message.Owner,
+		Contact:   message.Contact,
+		RequestID: message.RequestID,
+		UserID:    message.UserID,
+		Encrypted: message.Encrypted,
+		Timestamp: params.Timestamp,
+		Content:   message.Content,
+		Metadata:  message.Metadata,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageRead, message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if err = service.repository.Update(ctx, message.Sent(params.Timestamp)); err != nil {
-		msg := fmt.Sprintf("cannot update message with id [%s] as sent", message.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
 	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
-	return nil
+	return message, nil
 }
 
-// HandleMessageFailedParams are parameters for handling a failed message event
-type HandleMessageFailedParams struct {
-	ID           uuid.UUID
-	UserID       entities.UserID
-	ErrorMessage string
-	Timestamp    time.Time
+// MessageReactParams are parameters for sending an emoji reaction to a message
+type MessageReactParams struct {
+	Emoji  string
+	Source string
 }
 
-// HandleMessageFailed handles when a message could not be sent by a mobile phone
-func (service *MessageService) HandleMessageFailed(ctx context.Context, params HandleMessageFailedParams) error {
-	ctx, span := service.tracer.Start(ctx)
+// React sends an emoji reaction to a message over RCS from the phone of the message and fires the events.EventTypeMessageReacted event.
+// The message is not changed when the phone does not have RCS turned on and ErrCodeMessageNotReactable is returned when the message has not been sent yet.
+func (service *MessageService) React(ctx context.Context, message *entities.Message, params MessageReactParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+	if !message.CanBeReacted() {
+		msg := fmt.Sprintf("cannot react to message [%s] with status [%s] because it has not been sent", message.ID, message.Status)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeMessageNotReactable, msg))
+	}
 
-	message, err := service.repository.Load(ctx, params.UserID, params.ID)
+	phone, err := service.phoneService.Load(ctx, message.UserID, message.Owner)
 	if err != nil {
-		msg := fmt.Sprintf("cannot find message with id [%s]", params.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		msg := fmt.Sprintf("cannot load phone [%s] of user [%s] to react to message [%s]", message.Owner, message.UserID, message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	if message.IsDelivered() {
-		msg := fmt.Sprintf("message has already been delivered with status [%s]", message.Status)
-		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	if !phone.RcsEnabled || phone.IsVirtual() {
+		ctxLogger.Info(fmt.Sprintf("not reacting to message [%s] because RCS is not enabled on phone [%s]", message.ID, phone.ID))
+		return message, nil
 	}
 
-	if err = service.repository.Update(ctx, message.Failed(params.Timestamp, params.ErrorMessage)); err != nil {
-		msg := fmt.Sprintf("cannot update message with id [%s] as sent", message.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if err = service.phoneService.SendReaction(ctx, params.Source, phone, message.ID, params.Emoji); err != nil {
+		msg := fmt.Sprintf("cannot send reaction [%s] to message [%s]", params.Emoji, message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
-	return nil
+	return service.storeReaction(ctx, params.Source, message.AddReaction(params.Emoji, message.Owner, time.Now().UTC()))
 }
 
-// HandleMessageDelivered handles when a message is has been delivered by a mobile phone
-func (service *MessageService) HandleMessageDelivered(ctx context.Context, params HandleMessageParams) error {
-	ctx, span := service.tracer.Start(ctx)
+// MessageReactionParams are parameters for registering an emoji reaction which the contact sent to a message
+type MessageReactionParams struct {
+	Emoji     string
+	Timestamp time.Time
+	Supported bool
+	Source    string
+}
+
+// StoreReaction attaches the reaction which the contact sent to a message and fires the events.EventTypeMessageReacted event.
+// The message is not changed when the contact does not support RCS or when the reaction has already been stored.
+func (service *MessageService) StoreReaction(ctx context.Context, message *entities.Message, params MessageReactionParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+	if !params.Supported {
+		ctxLogger.Info(fmt.Sprintf("reactions are not supported for message [%s] with contact [%s]", message.ID, message.Contact))
+		return message, nil
+	}
 
-	message, err := service.repository.Load(ctx, params.UserID, params.ID)
-	if err != nil {
-		msg := fmt.Sprintf("cannot find message with id [%s]", params.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	for _, reaction := range message.Reactions {
+		if reaction.Emoji == params.Emoji && reaction.From == message.Contact && reaction.Timestamp.Equal(params.Timestamp) {
+			ctxLogger.Info(fmt.Sprintf("reaction [%s] to message [%s] at [%s] has already been stored", params.Emoji, message.ID, params.Timestamp))
+			return message, nil
+		}
 	}
 
-	if !message.IsSent() && !message.IsSending() && !message.IsExpired() && !message.IsScheduled() {
-		msg := fmt.Sprintf("message has wrong status [%s]. expected [%s, %s, %s, %s]", message.Status, entities.MessageStatusSent, entities.MessageStatusScheduled, entities.MessageStatusSending, entities.MessageStatusExpired)
-		ctxLogger.Warn(stacktrace.NewError(msg))
-		return nil
+	return service.storeReaction(ctx, params.Source, message.AddReaction(params.Emoji, message.Contact, params.Timestamp))
+}
+
+// storeReaction saves the last reaction of a message and dispatches the events.EventTypeMessageReacted event
+func (service *MessageService) storeReaction(ctx context.Context, source string, message *entities.Message) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.Update(ctx, message); err != nil {
+		msg := fmt.Sprintf("cannot save the reactions of message [%s]", message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if err = service.repository.Update(ctx, message.Delivered(params.Timestamp)); err != nil {
-		msg := fmt.Sprintf("cannot update message with id [%s] as delivered", message.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	reaction := message.Reactions[len(message.Reactions)-1]
+	event, err := service.createEvent(events.EventTypeMessageReacted, source, events.MessageReactedPayload{
+		ID:        message.ID,
+		Owner:     message.Owner,
+		Contact:   message.Contact,
+		RequestID: message.RequestID,
+		UserID:    message.UserID,
+		Emoji:     reaction.Emoji,
+		From:      reaction.From,
+		Timestamp: reaction.Timestamp,
+		Metadata:  message.Metadata,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessageReacted, message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	ctxLogger.Info(fmt.Sprintf("message with id [%s] has been updated to status [%s]", message.ID, message.Status))
-	return nil
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("stored reaction [%s] from [%s] to message [%s]", reaction.Emoji, reaction.From, message.ID))
+	return message, nil
 }
 
 // HandleMessageNotificationScheduled handles the event when the notification of a message has been scheduled
@@ -720,8 +2169,17 @@ func (service *MessageService) HandleMessageNotificationScheduled(ctx context.Co
 	return nil
 }
 
+// HandleMessageNotificationSentParams are parameters for handling the event when the notification of a message has been sent
+type HandleMessageNotificationSentParams struct {
+	ID           uuid.UUID
+	Source       string
+	UserID       entities.UserID
+	Timestamp    time.Time
+	FcmMessageID string
+}
+
 // HandleMessageNotificationSent handles the event when the notification of a message has been sent
-func (service *MessageService) HandleMessageNotificationSent(ctx context.Context, params HandleMessageParams) error {
+func (service *MessageService) HandleMessageNotificationSent(ctx context.Context, params HandleMessageNotificationSentParams) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
@@ -733,15 +2191,44 @@ func (service *MessageService) HandleMessageNotificationSent(ctx context.Context
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if err = service.repository.Update(ctx, message.AddSendAttemptCount()); err != nil {
+	if err = service.repository.Update(ctx, message.AddSendAttemptCount().PushSent(params.Timestamp, params.FcmMessageID)); err != nil {
 		msg := fmt.Sprintf("cannot update message with id [%s] as expired", message.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	ctxLogger.Info(fmt.Sprintf("notification for message with id [%s] has been sent at [%s]", message.ID, params.Timestamp.String()))
+	ctxLogger.Info(fmt.Sprintf("notification [%s] for message with id [%s] has been sent at [%s]", params.FcmMessageID, message.ID, params.Timestamp.String()))
 	return nil
 }
 
+// HandleMessageNotificationFailedParams are parameters for handling the event when the notification of a message could not be sent
+type HandleMessageNotificationFailedParams struct {
+	ID           uuid.UUID
+	UserID       entities.UserID
+	Timestamp    time.Time
+	ErrorCode    string
+	ErrorMessage string
+}
+
+// HandleMessageNotificationFailed records the error of the push notification on the message so that it is not mistaken for an SMS which the phone could not send
+func (service *MessageService) HandleMessageNotificationFailed(ctx context.Context, params HandleMessageNotificationFailedParams) (*entities.Message, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, params.UserID, params.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot find message with id [%s]", params.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.repository.Update(ctx, message.PushFailed(params.Timestamp, params.ErrorCode, params.ErrorMessage)); err != nil {
+		msg := fmt.Sprintf("cannot update the push error [%s] of message with id [%s]", params.ErrorCode, message.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("notification for message with id [%s] failed with code [%s] at [%s]", message.ID, params.ErrorCode, params.Timestamp.String()))
+	return message, nil
+}
+
 // HandleMessageExpired handles when a message is has been expired
 func (service *MessageService) HandleMessageExpired(ctx context.Context, params HandleMessageParams) error {
 	ctx, span := service.tracer.Start(ctx)
@@ -836,6 +2323,112 @@ func (service *MessageService) ScheduleExpirationCheck(ctx context.Context, para
 	return nil
 }
 
+// MessageScheduleDeliveryReportCheckParams are parameters for scheduling the check of the delivery report of a sent message
+type MessageScheduleDeliveryReportCheckParams struct {
+	MessageID uuid.UUID
+	UserID    entities.UserID
+	Owner     string
+	SentAt    time.Time
+	Source    string
+}
+
+// ScheduleDeliveryReportCheck schedules an event to mark a sent message as failed if the phone does not send a delivery report
+// within the entities.Phone.DeliveryReportTimeout
+func (service *MessageService) ScheduleDeliveryReportCheck(ctx context.Context, params MessageScheduleDeliveryReportCheckParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneService.Load(ctx, params.UserID, params.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("phone [%s] of user [%s] has been deleted, cannot check the delivery report of message [%s]", params.Owner, params.UserID, params.MessageID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] of user [%s] for message [%s]", params.Owner, params.UserID, params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if phone.DeliveryReportTimeout() == 0 {
+		ctxLogger.Info(fmt.Sprintf("delivery report timeout not set for message [%s] using phone [%s]", params.MessageID, phone.ID))
+		return nil
+	}
+
+	event, err := service.createMessageDeliveryReportCheckEvent(params.Source, &events.MessageDeliveryReportCheckPayload{
+		MessageID:   params.MessageID,
+		ScheduledAt: params.SentAt.Add(phone.DeliveryReportTimeout()),
+		UserID:      params.UserID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message with id [%s]", events.EventTypeMessageDeliveryReportCheck, params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.eventDispatcher.DispatchWithTimeout(ctx, event, phone.DeliveryReportTimeout()); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for message with ID [%s]", event.Type(), params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("scheduled delivery report check of message [%s] at [%s]", params.MessageID, params.SentAt.Add(phone.DeliveryReportTimeout())))
+	return nil
+}
+
+// MessageCheckDeliveryReport are parameters for checking if a sent message has received a delivery report
+type MessageCheckDeliveryReport struct {
+	MessageID uuid.UUID
+	UserID    entities.UserID
+	Source    string
+}
+
+// CheckDeliveryReport fires the events.EventTypeMessageSendFailed event when a message is still sent after the delivery report timeout.
+// Messages which have been delivered or have any other status are not changed.
+func (service *MessageService) CheckDeliveryReport(ctx context.Context, params MessageCheckDeliveryReport) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.repository.Load(ctx, params.UserID, params.MessageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("message has been deleted for userID [%s] and messageID [%s]", params.UserID, params.MessageID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with userID [%s] and messageID [%s]", params.UserID, params.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !message.IsSent() {
+		ctxLogger.Info(fmt.Sprintf("message with ID [%s] has status [%s] and does not need a delivery report", message.ID, message.Status))
+		return nil
+	}
+
+	event, err := service.createMessageSendFailedEvent(params.Source, events.MessageSendFailedPayload{
+		ID:           message.ID,
+		Owner:        message.Owner,
+		ErrorMessage: "the phone did not receive a delivery report for the message before the delivery report timeout",
+		Timestamp:    time.Now().UTC(),
+		Encrypted:    message.Encrypted,
+		Contact:      message.Contact,
+		RequestID:    message.RequestID,
+		UserID:       message.UserID,
+		Content:      message.Content,
+		Metadata:     message.Metadata,
+		SIM:          message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message with id [%s]", events.EventTypeMessageSendFailed, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for message with ID [%s]", event.Type(), message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message [%s] has no delivery report and it was marked as failed", message.ID))
+	return nil
+}
+
 // MessageCheckExpired are parameters for checking if a message is expired
 type MessageCheckExpired struct {
 	MessageID uuid.UUID
@@ -877,6 +2470,7 @@ func (service *MessageService) CheckExpired(ctx context.Context, params MessageC
 		UserID:           message.UserID,
 		Timestamp:        time.Now().UTC(),
 		Content:          message.Content,
+		Metadata:         message.Metadata,
 		SIM:              message.SIM,
 	})
 	if err != nil {
@@ -915,24 +2509,82 @@ func (service *MessageService) SearchMessages(ctx context.Context, params *Messa
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	service.setQueuePositions(ctx, messages)
+
 	ctxLogger.Info(fmt.Sprintf("fetched [%d] messages with prams [%+#v]", len(messages), params))
 	return messages, nil
 }
 
-func (service *MessageService) phoneSettings(ctx context.Context, userID entities.UserID, owner string) (uint, entities.SIM) {
+// messageOwner returns the phone number of the phone which will send the message
+func (service *MessageService) messageOwner(ctx context.Context, params MessageSendParams) (string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if params.PhoneGroup != nil {
+		phone, err := service.phoneService.SelectGroupPhone(ctx, params.UserID, *params.PhoneGroup)
+		if err != nil {
+			msg := fmt.Sprintf("cannot select a phone of group [%s] for user [%s]", *params.PhoneGroup, params.UserID)
+			return "", service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+		return phone.PhoneNumber, nil
+	}
+
+	if params.SenderID == nil {
+		return phonenumbers.Format(params.Owner, phonenumbers.E164), nil
+	}
+
+	phone, err := service.phoneService.LoadByAlphanumericSenderID(ctx, params.UserID, *params.SenderID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone for user [%s] with alphanumeric sender ID [%s]", params.UserID, *params.SenderID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return phone.PhoneNumber, nil
+}
+
+// routeMessage selects the phone which sends a message without a from number using the country routes of the user
+func (service *MessageService) routeMessage(ctx context.Context, params MessageSendParams) (MessageSendParams, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if !params.IsRouted() {
+		return params, nil
+	}
+
+	phone, country, err := service.phoneService.SelectCountryPhone(ctx, params.UserID, params.Contact)
+	if err != nil {
+		msg := fmt.Sprintf("cannot select the phone of the country route of contact [%s] for user [%s]", params.Contact, params.UserID)
+		return params, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if params.Owner, err = phonenumbers.Parse(phone.PhoneNumber, phonenumbers.UNKNOWN_REGION); err != nil {
+		msg := fmt.Sprintf("cannot parse the phone number [%s] of the country route [%s] for user [%s]", phone.PhoneNumber, country, params.UserID)
+		return params, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	params.CountryRoute = &country
+	return params, nil
+}
+
+// phoneSettings returns the max send attempts, the SIM and the content of a message with the signature of the phone which sends it
+func (service *MessageService) phoneSettings(ctx context.Context, params MessageSendParams, owner string) (uint, entities.SIM, string) {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
-	phone, err := service.phoneService.Load(ctx, userID, owner)
+	phone, err := service.phoneService.Load(ctx, params.UserID, owner)
 	if err != nil {
-		msg := fmt.Sprintf("cannot load phone for userID [%s] and owner [%s]. using default max send attempt of 2", userID, owner)
+		msg := fmt.Sprintf("cannot load phone for userID [%s] and owner [%s]. using default max send attempt of 2", params.UserID, owner)
 		ctxLogger.Error(stacktrace.Propagate(err, msg))
-		return 2, entities.SIM1
+		return 2, entities.SIM1, params.Content
 	}
 
-	return phone.MaxSendAttemptsSanitized(), phone.SIM
+	// the signature cannot be appended to an encrypted content because the server cannot read it
+	if params.Encrypted || params.SkipSignature {
+		return phone.MaxSendAttemptsSanitized(), phone.SIM, params.Content
+	}
+	return phone.MaxSendAttemptsSanitized(), phone.SIM, phone.SignedContent(params.Content)
 }
 
 // storeSentMessage a new message
@@ -951,11 +2603,14 @@ func (service *MessageService) storeSentMessage(ctx context.Context, payload eve
 		ID:                payload.MessageID,
 		Owner:             payload.Owner,
 		Contact:           payload.Contact,
+		ContactType:       ContactType(payload.Contact),
 		UserID:            payload.UserID,
 		Content:           payload.Content,
 		RequestID:         payload.RequestID,
 		SIM:               payload.SIM,
 		Encrypted:         payload.Encrypted,
+		Metadata:          payload.Metadata,
+		SenderID:          payload.SenderID,
 		ScheduledSendTime: payload.ScheduledSendTime,
 		Type:              entities.MessageTypeMobileTerminated,
 		Status:            entities.MessageStatusPending,
@@ -964,6 +2619,21 @@ func (service *MessageService) storeSentMessage(ctx context.Context, payload eve
 		UpdatedAt:         time.Now().UTC(),
 		MaxSendAttempts:   payload.MaxSendAttempts,
 		OrderTimestamp:    timestamp,
+		InReplyTo:         payload.InReplyTo,
+		GroupID:           payload.GroupID,
+		PhoneGroup:        payload.PhoneGroup,
+		ProviderFallback:  payload.ProviderFallback,
+		CountryRoute:      payload.CountryRoute,
+	}
+
+	if payload.ValidityPeriod != nil {
+		validityPeriod := uint(payload.ValidityPeriod.Seconds())
+		message.ValidityPeriod = &validityPeriod
+	}
+
+	if !payload.Encrypted {
+		segments, _ := CountSMSSegments(payload.Content)
+		message.Segments = &segments
 	}
 
 	if err := service.repository.Store(ctx, message); err != nil {
@@ -986,6 +2656,7 @@ func (service *MessageService) storeMissedCallMessage(ctx context.Context, paylo
 		ID:                payload.MessageID,
 		Owner:             payload.Owner,
 		Contact:           payload.Contact,
+		ContactType:       ContactType(payload.Contact),
 		UserID:            payload.UserID,
 		SIM:               payload.SIM,
 		Type:              entities.MessageTypeCallMissed,
@@ -1009,6 +2680,10 @@ func (service *MessageService) createMessageSendExpiredEvent(source string, payl
 	return service.createEvent(events.EventTypeMessageSendExpired, source, payload)
 }
 
+func (service *MessageService) createMessageDeliveryReportCheckEvent(source string, payload *events.MessageDeliveryReportCheckPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypeMessageDeliveryReportCheck, source, payload)
+}
+
 func (service *MessageService) createMessageSendExpiredCheckEvent(source string, payload *events.MessageSendExpiredCheckPayload) (cloudevents.Event, error) {
 	return service.createEvent(events.EventTypeMessageSendExpiredCheck, source, payload)
 }
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMessagePollCursor(t *testing.T) {
+	timestamp := time.Date(2022, 6, 5, 14, 26, 10, 303278000, time.UTC)
+	messageID := uuid.MustParse("32343a19-da5e-4b1b-a767-3298a73703cb")
+
+	tests := []struct {
+		name      string
+		cursor    string
+		timestamp time.Time
+		messageID uuid.UUID
+		valid     bool
+	}{
+		{name: "the cursor of a poll contains the timestamp and the ID", cursor: MessagePollCursor(timestamp, messageID), timestamp: timestamp, messageID: messageID, valid: true},
+		{name: "a timestamp cursor continues after every message updated at the timestamp", cursor: "2022-06-05T14:26:10.303278Z", timestamp: timestamp, messageID: uuid.Max, valid: true},
+		{name: "a timestamp with an offset is converted to UTC", cursor: "2022-06-05T17:26:10.303278+03:00", timestamp: timestamp, messageID: uuid.Max, valid: true},
+		{name: "a cursor with an invalid timestamp is not valid", cursor: "yesterday_32343a19-da5e-4b1b-a767-3298a73703cb"},
+		{name: "a cursor with an invalid ID is not valid", cursor: "2022-06-05T14:26:10.303278Z_message"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			since, sinceID, err := ParseMessagePollCursor(tt.cursor)
+
+			// Assert
+			if !tt.valid {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			assert.True(t, tt.timestamp.Equal(since))
+			assert.Equal(t, tt.messageID, sinceID)
+		})
+	}
+}
@@ -22,6 +22,9 @@ type MessageThreadService struct {
 	tracer          telemetry.Tracer
 	repository      repositories.MessageThreadRepository
 	eventDispatcher *EventDispatcher
+
+	messageRepository repositories.MessageRepository
+	archiveService    *MessageArchiveService
 }
 
 // NewMessageThreadService creates a new MessageThreadService
@@ -30,12 +33,16 @@ func NewMessageThreadService(
 	tracer telemetry.Tracer,
 	repository repositories.MessageThreadRepository,
 	eventDispatcher *EventDispatcher,
+	messageRepository repositories.MessageRepository,
+	archiveService *MessageArchiveService,
 ) (s *MessageThreadService) {
 	return &MessageThreadService{
-		logger:          logger.WithService(fmt.Sprintf("%T", s)),
-		tracer:          tracer,
-		eventDispatcher: eventDispatcher,
-		repository:      repository,
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		eventDispatcher:   eventDispatcher,
+		repository:        repository,
+		messageRepository: messageRepository,
+		archiveService:    archiveService,
 	}
 }
 
@@ -57,6 +64,10 @@ func (service *MessageThreadService) UpdateThread(ctx context.Context, params Me
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	// normalize numbers so a conversation doesn't fragment when the contact uses a national format
+	params.Owner = NormalizePhoneNumber(params.Owner, params.Owner)
+	params.Contact = NormalizePhoneNumber(params.Owner, params.Contact)
+
 	thread, err := service.repository.LoadByOwnerContact(ctx, params.UserID, params.Owner, params.Contact)
 	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
 		ctxLogger.Info(fmt.Sprintf("cannot find thread with owner [%s], and contact [%s]. creating new thread", params.Owner, params.Contact))
@@ -121,9 +132,12 @@ func (service *MessageThreadService) UpdateAfterDeletedMessage(ctx context.Conte
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	thread, err := service.repository.LoadByOwnerContact(ctx, payload.UserID, payload.Owner, payload.Contact)
+	owner := NormalizePhoneNumber(payload.Owner, payload.Owner)
+	contact := NormalizePhoneNumber(payload.Owner, payload.Contact)
+
+	thread, err := service.repository.LoadByOwnerContact(ctx, payload.UserID, owner, contact)
 	if err != nil {
-		msg := fmt.Sprintf("cannot find thread for user [%s] with owner [%s], and contact [%s]", payload.UserID, payload.Owner, payload.Contact)
+		msg := fmt.Sprintf("cannot find thread for user [%s] with owner [%s], and contact [%s]", payload.UserID, owner, contact)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
@@ -244,6 +258,20 @@ func (service *MessageThreadService) GetThreads(ctx context.Context, params Mess
 	return threads, nil
 }
 
+// GetThreadsVersion fetches the repositories.ListVersion of all the message threads which match the params ignoring the pagination
+func (service *MessageThreadService) GetThreadsVersion(ctx context.Context, params MessageThreadGetParams) (*repositories.ListVersion, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	version, err := service.repository.IndexVersion(ctx, params.UserID, params.Owner, params.IsArchived, params.IndexParams)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch the version of messages threads for params [%+#v]", params)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return version, nil
+}
+
 // GetThread fetches an entities.MessageThread  message thread by the ID
 func (service *MessageThreadService) GetThread(ctx context.Context, userID entities.UserID, messageThreadID uuid.UUID) (*entities.MessageThread, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -292,3 +320,56 @@ func (service *MessageThreadService) DeleteThread(ctx context.Context, source st
 	ctxLogger.Info(fmt.Sprintf("dispatched [%s] event with id [%s] for message thread [%s]", event.Type(), event.ID(), thread.ID))
 	return nil
 }
+
+// MessageThreadMessagesDeleted is the result of deleting all the messages of a conversation
+type MessageThreadMessagesDeleted struct {
+	Owner   string `json:"owner" example:"+18005550199"`
+	Contact string `json:"contact" example:"+18005550100"`
+	Count   int64  `json:"count" example:"42"`
+}
+
+// DeleteMessages deletes all the messages between an owner and a contact in one query and then deletes the thread of the conversation.
+// An error with the repositories.ErrCodeNotFound code is returned when the user has neither a thread nor messages between the owner and the contact.
+func (service *MessageThreadService) DeleteMessages(ctx context.Context, source string, userID entities.UserID, owner string, contact string) (*MessageThreadMessagesDeleted, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	thread, err := service.repository.LoadByOwnerContact(ctx, userID, owner, contact)
+	if err != nil && stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("cannot load thread between owner [%s] and contact [%s] for user with ID [%s]", owner, contact, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	archived, err := service.archiveService.ArchivedMessages(ctx, userID, owner, contact)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the archived messages between owner [%s] and contact [%s] for user with ID [%s]", owner, contact, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	count, err := service.messageRepository.DeleteByOwnerAndContact(ctx, userID, owner, contact)
+	if err != nil {
+		msg := fmt.Sprintf("cannot delete messages between owner [%s] and contact [%s] for user with ID [%s]", owner, contact, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the messages have already been deleted so a failure to delete their archives is logged
+	if err = service.archiveService.Delete(ctx, archived...); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot delete the archives of the messages between owner [%s] and contact [%s] for user with ID [%s]", owner, contact, userID)))
+	}
+
+	if thread == nil && count == 0 {
+		msg := fmt.Sprintf("user with ID [%s] has no messages between owner [%s] and contact [%s]", userID, owner, contact)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted [%d] messages between owner [%s] and contact [%s] for user with ID [%s]", count, owner, contact, userID))
+
+	if thread != nil {
+		if err = service.DeleteThread(ctx, source, thread); err != nil {
+			msg := fmt.Sprintf("cannot delete thread with ID [%s] after deleting its [%d] messages", thread.ID, count)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	return &MessageThreadMessagesDeleted{Owner: owner, Contact: contact, Count: count}, nil
+}
@@ -1,9 +1,13 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
@@ -17,6 +21,41 @@ import (
 	"github.com/palantir/stacktrace"
 )
 
+// fcmTokenInvalidFailureReason is the failure reason of messages which were not sent because FCM rejected the token of the phone.
+// These messages are sent again when the phone sends a new FCM token.
+const fcmTokenInvalidFailureReason = "the FCM token of the phone is no longer valid, the message will be sent again when the httpSMS app sends a new token"
+
+const (
+	// fcmErrorCodeUnregistered is the firebase cloud messaging error code when the token of the phone is no longer valid
+	fcmErrorCodeUnregistered = "UNREGISTERED"
+
+	// fcmErrorCodeInternal is used when the push notification failed because of an error on the server
+	fcmErrorCodeInternal = "INTERNAL"
+
+	// fcmErrorCodeNoToken is used when the phone has not registered an FCM token so no push notification can be sent
+	fcmErrorCodeNoToken = "NO_FCM_TOKEN"
+
+	// virtualErrorCodeNoURL is used when a virtual phone has no URL to post the message to
+	virtualErrorCodeNoURL = "NO_VIRTUAL_URL"
+
+	// virtualErrorCodeRequestFailed is used when the URL of a virtual phone did not accept the message
+	virtualErrorCodeRequestFailed = "VIRTUAL_REQUEST_FAILED"
+)
+
+const (
+	// virtualAttemptTimeout is the timeout of each attempt to post a message to the URL of a virtual phone
+	virtualAttemptTimeout = 10 * time.Second
+
+	// virtualRequestTimeout is the timeout of all the attempts to post a message to the URL of a virtual phone
+	virtualRequestTimeout = 30 * time.Second
+)
+
+// ErrCodePhoneControlUnreachable is returned when a control notification cannot be sent because the phone has no valid FCM token
+const ErrCodePhoneControlUnreachable = stacktrace.ErrorCode(1116)
+
+// phoneCommandTypeReaction is the KEY_TYPE of the push notification which tells the phone to send a reaction to a message over RCS
+const phoneCommandTypeReaction = "reaction"
+
 // PhoneNotificationService sends out notifications to mobile phones
 type PhoneNotificationService struct {
 	service
@@ -24,8 +63,22 @@ type PhoneNotificationService struct {
 	tracer                      telemetry.Tracer
 	phoneNotificationRepository repositories.PhoneNotificationRepository
 	phoneRepository             repositories.PhoneRepository
+	messageRepository           repositories.MessageRepository
 	messagingClient             *messaging.Client
 	eventDispatcher             *EventDispatcher
+	drainer                     *Drainer
+
+	// semaphore limits the number of notifications which are pushed to a phone at the same time
+	semaphore *PhoneSemaphore
+
+	// signer signs the data of the push notifications, the notifications are not signed when it is nil
+	signer *FcmSigner
+
+	// client posts the messages of virtual phones to their URL
+	client *http.Client
+
+	// credentialService loads the firebase project of the users who push the notifications with their own FCM credentials
+	credentialService *FcmCredentialService
 }
 
 // NewNotificationService creates a new PhoneNotificationService
@@ -35,7 +88,13 @@ func NewNotificationService(
 	messagingClient *messaging.Client,
 	phoneRepository repositories.PhoneRepository,
 	phoneNotificationRepository repositories.PhoneNotificationRepository,
+	messageRepository repositories.MessageRepository,
 	dispatcher *EventDispatcher,
+	drainer *Drainer,
+	semaphore *PhoneSemaphore,
+	signer *FcmSigner,
+	client *http.Client,
+	credentialService *FcmCredentialService,
 ) (s *PhoneNotificationService) {
 	return &PhoneNotificationService{
 		logger:                      logger.WithService(fmt.Sprintf("%T", s)),
@@ -43,30 +102,66 @@ func NewNotificationService(
 		messagingClient:             messagingClient,
 		phoneNotificationRepository: phoneNotificationRepository,
 		phoneRepository:             phoneRepository,
+		messageRepository:           messageRepository,
 		eventDispatcher:             dispatcher,
+		drainer:                     drainer,
+		semaphore:                   semaphore,
+		signer:                      signer,
+		client:                      client,
+		credentialService:           credentialService,
 	}
 }
 
+// fcmClient returns the firebase messaging client which sends push notifications to the phones of a user
+func (service *PhoneNotificationService) fcmClient(ctx context.Context, userID entities.UserID) (*messaging.Client, error) {
+	return service.credentialService.MessagingClient(ctx, userID, service.messagingClient)
+}
+
 // SendHeartbeatFCM sends a heartbeat message so the phone can request a heartbeat
-func (service *PhoneNotificationService) SendHeartbeatFCM(ctx context.Context, payload *events.PhoneHeartbeatMissedPayload) error {
+func (service *PhoneNotificationService) SendHeartbeatFCM(ctx context.Context, source string, payload *events.PhoneHeartbeatMissedPayload) error {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
+	done := service.drainer.Add()
+	defer done()
+
 	phone, err := service.phoneRepository.LoadByID(ctx, payload.UserID, payload.PhoneID)
 	if err != nil {
 		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", payload.UserID, payload.PhoneID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	if phone.IsVirtual() {
+		ctxLogger.Info(fmt.Sprintf("skipping heartbeat FCM to virtual phone with id [%s]", phone.ID))
+		return nil
+	}
+
 	if phone.FcmToken == nil {
 		msg := fmt.Sprintf("phone with id [%s] has no FCM token", phone.ID)
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	result, err := service.messagingClient.Send(ctx, &messaging.Message{
-		Data: map[string]string{
-			"KEY_HEARTBEAT_ID": time.Now().UTC().Format(time.RFC3339),
-		},
+	if !phone.HasValidFcmToken() {
+		ctxLogger.Info(fmt.Sprintf("skipping heartbeat FCM to phone with id [%s] because its FCM token was rejected at [%s]", phone.ID, phone.FcmTokenInvalidAt))
+		return nil
+	}
+
+	data, err := service.sign(phone, map[string]string{
+		"KEY_HEARTBEAT_ID": time.Now().UTC().Format(time.RFC3339),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot sign heartbeat FCM to phone with id [%s] for user [%s]", phone.ID, phone.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	client, err := service.fcmClient(ctx, phone.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the messaging client for the heartbeat FCM to phone with id [%s] for user [%s]", phone.ID, phone.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result, err := client.Send(ctx, &messaging.Message{
+		Data: data,
 		Android: &messaging.AndroidConfig{
 			Priority: "high",
 		},
@@ -75,6 +170,7 @@ func (service *PhoneNotificationService) SendHeartbeatFCM(ctx context.Context, p
 	if err != nil {
 		msg := fmt.Sprintf("cannot send heartbeat FCM to phone with id [%s] for user [%s]", phone.ID, phone.UserID)
 		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		service.handleFcmError(ctx, source, phone, err)
 		return nil
 	}
 
@@ -82,6 +178,104 @@ func (service *PhoneNotificationService) SendHeartbeatFCM(ctx context.Context, p
 	return nil
 }
 
+// SendControl sends a control notification which the httpSMS app interprets instead of sending an SMS.
+// The KEY_TYPE field of the push notification differentiates it from the notifications of messages which have the KEY_MESSAGE_ID field.
+func (service *PhoneNotificationService) SendControl(ctx context.Context, source string, phone *entities.Phone, controlType entities.PhoneControlType, message string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	done := service.drainer.Add()
+	defer done()
+
+	if !phone.HasValidFcmToken() {
+		msg := fmt.Sprintf("cannot send [%s] control notification to phone with id [%s] because it has no valid FCM token", controlType, phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneControlUnreachable, msg))
+	}
+
+	payload := map[string]string{"KEY_TYPE": controlType.String()}
+	if message != "" {
+		payload["KEY_CONTROL_MESSAGE"] = message
+	}
+
+	result, err := service.sendCommand(ctx, source, phone, payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send [%s] control notification to phone with id [%s] for user [%s]", controlType, phone.ID, phone.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	timestamp := time.Now().UTC()
+	if err = service.phoneRepository.UpdateLastControl(ctx, phone.ID, controlType, timestamp); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot record [%s] control notification of phone [%s]", controlType, phone.ID)))
+	}
+	phone.LastControlType = &controlType
+	phone.LastControlSentAt = &timestamp
+
+	ctxLogger.Info(fmt.Sprintf("sent [%s] control notification [%s] to phone with ID [%s] for user [%s]", controlType, result, phone.ID, phone.UserID))
+	return nil
+}
+
+// SendReaction tells the phone to send an emoji reaction to a message over RCS, the phone ignores it when the contact does not support RCS
+func (service *PhoneNotificationService) SendReaction(ctx context.Context, source string, phone *entities.Phone, messageID uuid.UUID, emoji string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	done := service.drainer.Add()
+	defer done()
+
+	if !phone.HasValidFcmToken() {
+		msg := fmt.Sprintf("cannot send reaction to message [%s] to phone with id [%s] because it has no valid FCM token", messageID, phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneControlUnreachable, msg))
+	}
+
+	// the ID of the message is not sent in KEY_MESSAGE_ID since the httpSMS app sends the outstanding message with that ID as an SMS
+	result, err := service.sendCommand(ctx, source, phone, map[string]string{
+		"KEY_TYPE":                phoneCommandTypeReaction,
+		"KEY_REACTION_MESSAGE_ID": messageID.String(),
+		"KEY_REACTION":            emoji,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot send reaction to message [%s] to phone with id [%s] for user [%s]", messageID, phone.ID, phone.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent reaction [%s] to message [%s] with notification [%s] to phone with ID [%s] for user [%s]", emoji, messageID, result, phone.ID, phone.UserID))
+	return nil
+}
+
+// sendCommand sends a signed push notification with a high priority to a phone which has a valid FCM token and returns the ID of the notification
+func (service *PhoneNotificationService) sendCommand(ctx context.Context, source string, phone *entities.Phone, payload map[string]string) (string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	data, err := service.sign(phone, payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot sign [%s] notification to phone with id [%s]", payload["KEY_TYPE"], phone.ID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	client, err := service.fcmClient(ctx, phone.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the messaging client for the [%s] notification to phone with id [%s]", payload["KEY_TYPE"], phone.ID)
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result, err := client.Send(ctx, &messaging.Message{
+		Data: data,
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+		},
+		Token: *phone.FcmToken,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot send [%s] notification to phone with id [%s] for user [%s]", payload["KEY_TYPE"], phone.ID, phone.UserID)
+		if service.handleFcmError(ctx, source, phone, err) {
+			return "", service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodePhoneControlUnreachable, msg))
+		}
+		return "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return result, nil
+}
+
 // PhoneNotificationSendParams are parameters for sending a notification
 type PhoneNotificationSendParams struct {
 	UserID              entities.UserID
@@ -90,6 +284,7 @@ type PhoneNotificationSendParams struct {
 	Source              string
 	ScheduledAt         time.Time
 	MessageID           uuid.UUID
+	ValidityPeriod      *time.Duration
 }
 
 // Send sends a message when a message is sent
@@ -97,22 +292,77 @@ func (service *PhoneNotificationService) Send(ctx context.Context, params *Phone
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
+	done := service.drainer.Add()
+	defer done()
+
+	if service.isCancelled(ctx, params.UserID, params.MessageID) {
+		ctxLogger.Info(fmt.Sprintf("skipping notification [%s] because message [%s] has been cancelled", params.PhoneNotificationID, params.MessageID))
+		service.updateStatus(ctx, params.PhoneNotificationID, entities.PhoneNotificationStatusCancelled)
+		return nil
+	}
+
 	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		// the pending messages of a deleted phone are reassigned or failed when the events.EventTypePhoneDeleted event is handled
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone with ID [%s] was deleted before sending notification for message [%s]", params.PhoneID, params.MessageID)))
+		service.updateStatus(ctx, params.PhoneNotificationID, entities.PhoneNotificationStatusFailed)
+		return nil
+	}
 	if err != nil {
 		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", params.UserID, params.PhoneID)
-		return service.handleNotificationFailed(ctx, errors.New(msg), params)
+		return service.handleNotificationFailed(ctx, errors.New(msg), fcmErrorCodeInternal, params)
+	}
+
+	if phone.IsPaused() {
+		// the notification is scheduled again when the events.EventTypePhoneResumed event is handled
+		ctxLogger.Info(fmt.Sprintf("holding notification [%s] for message [%s] because phone [%s] is paused", params.PhoneNotificationID, params.MessageID, phone.ID))
+		service.updateStatus(ctx, params.PhoneNotificationID, entities.PhoneNotificationStatusPaused)
+
+		if phone = service.resumedWhileHolding(ctx, params); phone == nil {
+			return nil
+		}
+	}
+
+	if phone.IsVirtual() {
+		return service.sendVirtual(ctx, phone, params)
 	}
 
 	if phone.FcmToken == nil {
 		msg := fmt.Sprintf("phone with id [%s] has no FCM token", phone.ID)
-		return service.handleNotificationFailed(ctx, errors.New(msg), params)
+		return service.handleNotificationFailed(ctx, errors.New(msg), fcmErrorCodeNoToken, params)
 	}
 
-	ttl := phone.MessageExpirationDuration()
-	result, err := service.messagingClient.Send(ctx, &messaging.Message{
-		Data: map[string]string{
-			"KEY_MESSAGE_ID": params.MessageID.String(),
-		},
+	if !phone.HasValidFcmToken() {
+		return service.handleNotificationFailed(ctx, errors.New(fcmTokenInvalidFailureReason), fcmErrorCodeUnregistered, params)
+	}
+
+	// the slot is keyed by the phone which sends the message and it is held until FCM returns, a notification above the limit waits for a slot
+	release, err := service.semaphore.Acquire(ctx, phone.ID.String(), phone.MaxConcurrentSendsSanitized())
+	if err != nil {
+		msg := fmt.Sprintf("cannot acquire a send slot for notification [%s] to phone [%s]", params.PhoneNotificationID, phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	defer release()
+
+	// the TTL is signed with the message ID so the phone accepts a notification which was delivered late by FCM
+	ttl := service.messageExpirationDuration(phone, params.ValidityPeriod)
+	data, err := service.sign(phone, map[string]string{
+		"KEY_MESSAGE_ID": params.MessageID.String(),
+		fcmTTLKey:        strconv.Itoa(int(ttl.Seconds())),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot sign the notification for message [%s] to phone [%s]", params.MessageID, phone.ID)
+		return service.handleNotificationFailed(ctx, errors.New(msg), fcmErrorCodeInternal, params)
+	}
+
+	client, err := service.fcmClient(ctx, phone.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the messaging client for the notification of message [%s] to phone [%s]", params.MessageID, phone.ID)
+		return service.handleNotificationFailed(ctx, errors.New(msg), fcmErrorCodeInternal, params)
+	}
+
+	result, err := client.Send(ctx, &messaging.Message{
+		Data: data,
 		Android: &messaging.AndroidConfig{
 			Priority: "normal",
 			TTL:      &ttl,
@@ -121,8 +371,11 @@ func (service *PhoneNotificationService) Send(ctx context.Context, params *Phone
 	})
 	if err != nil {
 		ctxLogger.Warn(stacktrace.Propagate(err, "cannot send FCM to phone"))
+		if service.handleFcmError(ctx, params.Source, phone, err) {
+			return service.handleNotificationFailed(ctx, errors.New(fcmTokenInvalidFailureReason), fcmErrorCode(err), params)
+		}
 		msg := fmt.Sprintf("cannot send notification for to your phone [%s]. Reinstall the httpSMS app on your Android phone.", phone.PhoneNumber)
-		return service.handleNotificationFailed(ctx, errors.New(msg), params)
+		return service.handleNotificationFailed(ctx, errors.New(msg), fcmErrorCode(err), params)
 	}
 
 	return service.handleNotificationSent(ctx, phone, result, params)
@@ -138,6 +391,9 @@ type PhoneNotificationScheduleParams struct {
 	Content   string
 	SIM       entities.SIM
 	MessageID uuid.UUID
+
+	// ValidityPeriod is used as the expiration of the message instead of entities.Phone.MessageExpirationDuration when it is set
+	ValidityPeriod *time.Duration
 }
 
 // Schedule a notification to be sent to a phone
@@ -147,6 +403,12 @@ func (service *PhoneNotificationService) Schedule(ctx context.Context, params *P
 
 	ctxLogger := service.tracer.CtxLogger(service.logger, span)
 
+	// a scheduled message is cancelled while its events.EventTypeMessageAPISent event is delayed until the send time
+	if service.isCancelled(ctx, params.UserID, params.MessageID) {
+		ctxLogger.Info(fmt.Sprintf("not scheduling a notification for message [%s] because it has been cancelled", params.MessageID))
+		return nil
+	}
+
 	phone, err := service.phoneRepository.Load(ctx, params.UserID, params.Owner)
 	if err != nil {
 		msg := fmt.Sprintf("cannot load phone with userID [%s] and phone [%s]", params.UserID, params.Owner)
@@ -173,7 +435,7 @@ func (service *PhoneNotificationService) Schedule(ctx context.Context, params *P
 		ctxLogger.Error(err)
 	}
 
-	if err = service.dispatchMessageNotificationSend(ctx, params.Source, notification); err != nil {
+	if err = service.dispatchMessageNotificationSend(ctx, params.Source, notification, params.ValidityPeriod); err != nil {
 		return service.tracer.WrapErrorSpan(span, err)
 	}
 
@@ -181,13 +443,222 @@ func (service *PhoneNotificationService) Schedule(ctx context.Context, params *P
 	return nil
 }
 
-func (service *PhoneNotificationService) dispatchMessageNotificationSend(ctx context.Context, source string, notification *entities.PhoneNotification) error {
+// resumedWhileHolding reloads the phone after a notification is held because the phone can be resumed after it was loaded and before the notification was held,
+// then Resume does not find the notification. The notification is released and the phone is returned when it is no longer paused, otherwise nil is returned.
+func (service *PhoneNotificationService) resumedWhileHolding(ctx context.Context, params *PhoneNotificationSendParams) *entities.Phone {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot reload phone [%s] after holding notification [%s], the notification is scheduled when the phone is resumed", params.PhoneID, params.PhoneNotificationID)
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		return nil
+	}
+
+	if phone.IsPaused() {
+		return nil
+	}
+
+	// Resume cancels the held notification with the same conditional update so only one of them sends the message
+	err = service.phoneNotificationRepository.UpdateStatusFrom(ctx, params.PhoneNotificationID, entities.PhoneNotificationStatusPaused, entities.PhoneNotificationStatusPending)
+	if err != nil {
+		ctxLogger.Info(fmt.Sprintf("notification [%s] was scheduled again by the resumed phone [%s]: %s", params.PhoneNotificationID, phone.ID, err.Error()))
+		return nil
+	}
+
+	ctxLogger.Info(fmt.Sprintf("releasing notification [%s] for message [%s] because phone [%s] was resumed while it was held", params.PhoneNotificationID, params.MessageID, phone.ID))
+	return phone
+}
+
+// PhoneNotificationResumeParams are parameters for scheduling the notifications which were held while a phone was paused
+type PhoneNotificationResumeParams struct {
+	UserID  entities.UserID
+	PhoneID uuid.UUID
+	Source  string
+}
+
+// Resume schedules the notifications which were held while the phone was paused in the order in which they were scheduled before
+func (service *PhoneNotificationService) Resume(ctx context.Context, params *PhoneNotificationResumeParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("phone with ID [%s] was deleted before its paused notifications were scheduled", params.PhoneID)))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", params.UserID, params.PhoneID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if phone.IsPaused() {
+		ctxLogger.Info(fmt.Sprintf("phone [%s] was paused again before its paused notifications were scheduled", phone.ID))
+		return nil
+	}
+
+	paused, err := service.phoneNotificationRepository.IndexPaused(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the paused notifications of phone [%s]", params.PhoneID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for _, held := range paused {
+		// the held notification is replaced so that the message is sent within the messages per minute of the phone
+		err = service.phoneNotificationRepository.UpdateStatusFrom(ctx, held.ID, entities.PhoneNotificationStatusPaused, entities.PhoneNotificationStatusCancelled)
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			ctxLogger.Info(fmt.Sprintf("notification [%s] for message [%s] was released by the send after the phone [%s] was resumed", held.ID, held.MessageID, phone.ID))
+			continue
+		}
+		if err != nil {
+			msg := fmt.Sprintf("cannot cancel the held notification [%s] of resumed phone [%s]", held.ID, phone.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		notification := &entities.PhoneNotification{
+			ID:          uuid.New(),
+			MessageID:   held.MessageID,
+			UserID:      held.UserID,
+			PhoneID:     held.PhoneID,
+			Status:      entities.PhoneNotificationStatusPending,
+			ScheduledAt: time.Now().UTC(),
+			CreatedAt:   time.Now().UTC(),
+			UpdatedAt:   time.Now().UTC(),
+		}
+
+		if err = service.phoneNotificationRepository.Schedule(ctx, phone.MessagesPerMinute, notification); err != nil {
+			msg := fmt.Sprintf("cannot schedule notification for message [%s] to resumed phone [%s]", held.MessageID, phone.ID)
+			return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		if err = service.dispatchMessageNotificationSend(ctx, params.Source, notification, nil); err != nil {
+			return service.tracer.WrapErrorSpan(span, err)
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("scheduled [%d] paused notifications of phone [%s] for user [%s]", len(paused), phone.ID, phone.UserID))
+	return nil
+}
+
+// sendVirtual posts the message to the URL of a virtual phone and marks it as sent by the phone when the URL accepts it
+func (service *PhoneNotificationService) sendVirtual(ctx context.Context, phone *entities.Phone, params *PhoneNotificationSendParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if phone.VirtualURL == nil {
+		msg := fmt.Sprintf("virtual phone with id [%s] has no virtual_url to send the message to", phone.ID)
+		return service.handleNotificationFailed(ctx, errors.New(msg), virtualErrorCodeNoURL, params)
+	}
+
+	message, err := service.messageRepository.GetOutstanding(ctx, params.UserID, params.MessageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("message [%s] of virtual phone [%s] is no longer outstanding", params.MessageID, phone.ID))
+		service.updateStatus(ctx, params.PhoneNotificationID, entities.PhoneNotificationStatusCancelled)
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load outstanding message [%s] for virtual phone [%s]", params.MessageID, phone.ID)
+		return service.handleNotificationFailed(ctx, errors.New(msg), fcmErrorCodeInternal, params)
+	}
+
+	if err = service.postVirtual(ctx, *phone.VirtualURL, message); err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot post message [%s] to virtual phone [%s]", message.ID, phone.ID)))
+		msg := fmt.Sprintf("cannot send the message to the virtual_url [%s] of your phone [%s]", *phone.VirtualURL, phone.PhoneNumber)
+		return service.handleNotificationFailed(ctx, errors.New(msg), virtualErrorCodeRequestFailed, params)
+	}
+
+	if err = service.handleNotificationSent(ctx, phone, message.ID.String(), params); err != nil {
+		return service.tracer.WrapErrorSpan(span, err)
+	}
+
+	// the virtual phone sends the message immediately so the event which the android app sends after sending an SMS is dispatched here
+	event, err := service.createEvent(events.EventTypeMessagePhoneSent, params.Source, events.MessagePhoneSentPayload{
+		ID:        message.ID,
+		Owner:     message.Owner,
+		UserID:    message.UserID,
+		RequestID: message.RequestID,
+		Timestamp: time.Now().UTC(),
+		Contact:   message.Contact,
+		Encrypted: message.Encrypted,
+		Content:   message.Content,
+		Metadata:  message.Metadata,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessagePhoneSent, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message [%s] sent with virtual phone [%s] to url [%s]", message.ID, phone.ID, *phone.VirtualURL))
+	return nil
+}
+
+// postVirtual posts the message as JSON to the URL of a virtual phone, an attempt which times out is retried
+func (service *PhoneNotificationService) postVirtual(ctx context.Context, url string, message *entities.Message) error {
+	ctx, cancel := context.WithTimeout(WithAttemptTimeout(ctx, virtualAttemptTimeout), virtualRequestTimeout)
+	defer cancel()
+
+	payload, err := json.Marshal(message)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal message [%s] into JSON", message.ID))
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create request to url [%s]", url))
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	response, err := service.client.Do(request)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot send request to url [%s]", url))
+	}
+
+	if err = response.Body.Close(); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot close the response body of url [%s]", url))
+	}
+
+	if response.StatusCode >= 400 {
+		return stacktrace.NewError(fmt.Sprintf("the url [%s] responded with status code [%d]", url, response.StatusCode))
+	}
+	return nil
+}
+
+// sign adds the signature of the current key of the phone to the data of a push notification when the signer is configured
+func (service *PhoneNotificationService) sign(phone *entities.Phone, data map[string]string) (map[string]string, error) {
+	if service.signer == nil {
+		return data, nil
+	}
+	return service.signer.ForPhone(phone.ID, phone.FcmKeyVersion).Sign(data, time.Now().UTC())
+}
+
+// isCancelled checks if the message of a notification has been cancelled, the phone is notified when the message cannot be loaded
+func (service *PhoneNotificationService) isCancelled(ctx context.Context, userID entities.UserID, messageID uuid.UUID) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.messageRepository.Load(ctx, userID, messageID)
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot load message [%s] of user [%s] to check if it is cancelled", messageID, userID)))
+		return false
+	}
+	return message.IsCancelled()
+}
+
+func (service *PhoneNotificationService) dispatchMessageNotificationSend(ctx context.Context, source string, notification *entities.PhoneNotification, validityPeriod *time.Duration) error {
 	event, err := service.createMessageNotificationSendEvent(source, &events.MessageNotificationSendPayload{
 		MessageID:      notification.MessageID,
 		UserID:         notification.UserID,
 		PhoneID:        notification.PhoneID,
 		ScheduledAt:    notification.ScheduledAt,
 		NotificationID: notification.ID,
+		ValidityPeriod: validityPeriod,
 	})
 	if err != nil {
 		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for notification [%s]", events.EventTypeMessageNotificationSend, notification.ID))
@@ -222,7 +693,7 @@ func (service *PhoneNotificationService) dispatchMessageNotificationScheduled(ct
 	return nil
 }
 
-func (service *PhoneNotificationService) handleNotificationFailed(ctx context.Context, err error, params *PhoneNotificationSendParams) error {
+func (service *PhoneNotificationService) handleNotificationFailed(ctx context.Context, err error, errorCode string, params *PhoneNotificationSendParams) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
@@ -231,7 +702,7 @@ func (service *PhoneNotificationService) handleNotificationFailed(ctx context.Co
 	msg := fmt.Sprintf("cannot send notification for message [%s] to phone [%s]", params.MessageID, params.PhoneNotificationID)
 	ctxLogger.Warn(stacktrace.Propagate(err, msg))
 
-	event, err := service.createMessageNotificationFailedEvent(params.Source, err.Error(), params)
+	event, err := service.createMessageNotificationFailedEvent(params.Source, err.Error(), errorCode, params)
 	if err != nil {
 		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for notification [%s]", events.EventTypeMessageNotificationFailed, params.PhoneNotificationID))
 	}
@@ -265,10 +736,82 @@ func (service *PhoneNotificationService) handleNotificationSent(ctx context.Cont
 	return nil
 }
 
+// handleFcmError marks the FCM token of the phone as invalid when FCM rejects it so that the dead token is not used again.
+// It returns true when the error was caused by the token.
+func (service *PhoneNotificationService) handleFcmError(ctx context.Context, source string, phone *entities.Phone, fcmErr error) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if !messaging.IsRegistrationTokenNotRegistered(fcmErr) && !messaging.IsInvalidArgument(fcmErr) {
+		return false
+	}
+
+	timestamp := time.Now().UTC()
+	invalidated, err := service.phoneRepository.InvalidateFcmToken(ctx, phone.ID, *phone.FcmToken, timestamp)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot invalidate the FCM token of phone [%s]", phone.ID)))
+		return true
+	}
+
+	if !invalidated {
+		ctxLogger.Info(fmt.Sprintf("the FCM token of phone [%s] was already invalidated or refreshed", phone.ID))
+		return true
+	}
+
+	event, err := service.createEvent(events.EventTypePhoneFcmTokenInvalid, source, &events.PhoneFcmTokenInvalidPayload{
+		PhoneID:      phone.ID,
+		UserID:       phone.UserID,
+		Owner:        phone.PhoneNumber,
+		ErrorMessage: fcmErr.Error(),
+		Timestamp:    timestamp,
+	})
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for phone [%s]", events.EventTypePhoneFcmTokenInvalid, phone.ID)))
+		return true
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch [%s] event for phone [%s]", event.Type(), phone.ID)))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("invalidated the FCM token of phone [%s] for user [%s]", phone.ID, phone.UserID))
+	return true
+}
+
+// fcmErrorCode returns the firebase cloud messaging error code of an error returned when sending a push notification
+func fcmErrorCode(err error) string {
+	switch {
+	case messaging.IsRegistrationTokenNotRegistered(err):
+		return fcmErrorCodeUnregistered
+	case messaging.IsInvalidArgument(err):
+		return "INVALID_ARGUMENT"
+	case messaging.IsMessageRateExceeded(err):
+		return "QUOTA_EXCEEDED"
+	case messaging.IsMismatchedCredential(err):
+		return "SENDER_ID_MISMATCH"
+	case messaging.IsInvalidAPNSCredentials(err):
+		return "THIRD_PARTY_AUTH_ERROR"
+	case messaging.IsServerUnavailable(err):
+		return "UNAVAILABLE"
+	case messaging.IsInternal(err):
+		return fcmErrorCodeInternal
+	default:
+		return "UNKNOWN"
+	}
+}
+
 func (service *PhoneNotificationService) createMessageNotificationScheduledEvent(source string, payload *events.MessageNotificationScheduledPayload) (cloudevents.Event, error) {
 	return service.createEvent(events.EventTypeMessageNotificationScheduled, source, payload)
 }
 
+// messageExpirationDuration uses the validity period of the message so the FCM TTL and the server-side expiry match the expiry on the phone
+func (service *PhoneNotificationService) messageExpirationDuration(phone *entities.Phone, validityPeriod *time.Duration) time.Duration {
+	if validityPeriod != nil {
+		return *validityPeriod
+	}
+	return phone.MessageExpirationDuration()
+}
+
 func (service *PhoneNotificationService) createMessageNotificationSendEvent(source string, payload *events.MessageNotificationSendPayload) (cloudevents.Event, error) {
 	return service.createEvent(events.EventTypeMessageNotificationSend, source, payload)
 }
@@ -286,7 +829,7 @@ func (service *PhoneNotificationService) createMessageNotificationSentEvent(sour
 		UserID:                    params.UserID,
 		PhoneID:                   params.PhoneID,
 		ScheduledAt:               params.ScheduledAt,
-		MessageExpirationDuration: phone.MessageExpirationDuration(),
+		MessageExpirationDuration: service.messageExpirationDuration(phone, params.ValidityPeriod),
 		FcmMessageID:              fcmMessageID,
 		NotificationSentAt:        time.Now().UTC(),
 		NotificationID:            params.PhoneNotificationID,
@@ -300,7 +843,7 @@ func (service *PhoneNotificationService) createMessageNotificationSentEvent(sour
 	return event, nil
 }
 
-func (service *PhoneNotificationService) createMessageNotificationFailedEvent(source string, errorMessage string, params *PhoneNotificationSendParams) (cloudevents.Event, error) {
+func (service *PhoneNotificationService) createMessageNotificationFailedEvent(source string, errorMessage string, errorCode string, params *PhoneNotificationSendParams) (cloudevents.Event, error) {
 	event := cloudevents.NewEvent()
 
 	event.SetSource(source)
@@ -313,6 +856,7 @@ func (service *PhoneNotificationService) createMessageNotificationFailedEvent(so
 		UserID:               params.UserID,
 		PhoneID:              params.PhoneID,
 		ErrorMessage:         errorMessage,
+		ErrorCode:            errorCode,
 		NotificationFailedAt: time.Now().UTC(),
 		NotificationID:       params.PhoneNotificationID,
 	}
@@ -0,0 +1,90 @@
+package services
+
+import (
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/nyaruka/phonenumbers"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// shortCodeRegex matches the short codes e.g. 12345 which are used by OTP and marketing services instead of a phone number
+var shortCodeRegex = regexp.MustCompile(`^[0-9]{3,8}$`)
+
+// IsShortCode checks if the number is a short code which is not normalized to E.164
+func IsShortCode(number string) bool {
+	return shortCodeRegex.MatchString(strings.TrimSpace(number))
+}
+
+// ContactType returns the type of the contact of a message, a contact with letters is an alphanumeric sender e.g. MyBrand
+func ContactType(number string) entities.MessageContactType {
+	switch {
+	case IsShortCode(number):
+		return entities.MessageContactTypeShortCode
+	case strings.IndexFunc(number, unicode.IsLetter) != -1:
+		return entities.MessageContactTypeAlphanumeric
+	default:
+		return entities.MessageContactTypePhoneNumber
+	}
+}
+
+// NormalizePhoneNumber formats a number in E.164 using the region of the owner's phone number for national numbers.
+// Short codes, alphanumeric senders and numbers which cannot be parsed are returned unchanged.
+func NormalizePhoneNumber(owner string, number string) string {
+	number = strings.TrimSpace(number)
+	if number == "" || strings.IndexFunc(number, unicode.IsLetter) != -1 {
+		return number
+	}
+
+	region := phonenumbers.UNKNOWN_REGION
+	if ownerNumber, err := phonenumbers.Parse(owner, phonenumbers.UNKNOWN_REGION); err == nil {
+		region = phonenumbers.GetRegionCodeForNumber(ownerNumber)
+	}
+
+	parsed, err := phonenumbers.Parse(number, region)
+	if err != nil || !phonenumbers.IsPossibleNumber(parsed) {
+		return number
+	}
+
+	return phonenumbers.Format(parsed, phonenumbers.E164)
+}
+
+// ResolvePhoneNumber formats a national number in E.164 using the default country of the user.
+// Numbers in the international format, short codes and alphanumeric senders are returned unchanged and false is returned when the number is not possible in the default country.
+func ResolvePhoneNumber(defaultCountry string, number string) (string, bool) {
+	number = strings.TrimSpace(number)
+	if number == "" || strings.HasPrefix(number, "+") || strings.IndexFunc(number, unicode.IsLetter) != -1 {
+		return number, true
+	}
+
+	parsed, err := phonenumbers.Parse(number, defaultCountry)
+	if err != nil {
+		return number, false
+	}
+
+	if !phonenumbers.IsPossibleNumber(parsed) {
+		return number, phonenumbers.IsPossibleShortNumberForRegion(parsed, defaultCountry)
+	}
+
+	return phonenumbers.Format(parsed, phonenumbers.E164), true
+}
+
+// IsSupportedCountry checks if the ISO 3166-1 alpha-2 code is a country which can be used to resolve national numbers
+func IsSupportedCountry(country string) bool {
+	return phonenumbers.GetSupportedRegions()[country]
+}
+
+// RecipientCountry returns the ISO 3166-1 alpha-2 code of the country of a number in the E.164 format, it is empty when the country is not known
+func RecipientCountry(number string) string {
+	parsed, err := phonenumbers.Parse(number, phonenumbers.UNKNOWN_REGION)
+	if err != nil {
+		return ""
+	}
+
+	if country := phonenumbers.GetRegionCodeForNumber(parsed); country != phonenumbers.UNKNOWN_REGION {
+		return country
+	}
+	return ""
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizePhoneNumber(t *testing.T) {
+	tests := []struct {
+		name       string
+		owner      string
+		number     string
+		normalized string
+	}{
+		{name: "an E.164 number is unchanged", owner: "+18005550199", number: "+18005550100", normalized: "+18005550100"},
+		{name: "a national number uses the region of the owner", owner: "+18005550199", number: "(800) 555-0100", normalized: "+18005550100"},
+		{name: "a national number of another region uses the region of the owner", owner: "+237677777777", number: "677 77 77 78", normalized: "+237677777778"},
+		{name: "an international number with spaces is formatted", owner: "+18005550199", number: " +44 20 7946 0958 ", normalized: "+442079460958"},
+		{name: "a short code is unchanged", owner: "+18005550199", number: "12345", normalized: "12345"},
+		{name: "an alphanumeric sender is unchanged", owner: "+18005550199", number: "MyBrand", normalized: "MyBrand"},
+		{name: "a national number without a known owner region is unchanged", owner: "", number: "8005550100", normalized: "8005550100"},
+		{name: "an empty number is unchanged", owner: "+18005550199", number: "", normalized: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			normalized := NormalizePhoneNumber(tt.owner, tt.number)
+
+			// Assert
+			assert.Equal(t, tt.normalized, normalized)
+		})
+	}
+}
+
+func TestRecipientCountry(t *testing.T) {
+	tests := []struct {
+		number  string
+		country string
+	}{
+		{number: "+18005550100", country: "US"},
+		{number: "+237677777777", country: "CM"},
+		{number: "+442079460958", country: "GB"},
+		{number: "8005550100", country: ""},
+		{number: "MyBrand", country: ""},
+		{number: "", country: ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.number, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			country := RecipientCountry(tt.number)
+
+			// Assert
+			assert.Equal(t, tt.country, country)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/nyaruka/phonenumbers"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneProvisioningCodeTTL is the duration after which an unused provisioning code expires
+const PhoneProvisioningCodeTTL = 10 * time.Minute
+
+// phoneProvisioningCodeAlphabet has no characters which look alike e.g. 0 and O so that the code can be typed on a phone
+const phoneProvisioningCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// phoneProvisioningCodeLength is the number of characters of a provisioning code without the separator
+const phoneProvisioningCodeLength = 8
+
+// PhoneProvisioning is the result of exchanging a provisioning code, the android app is configured with the API key and the registered phone
+type PhoneProvisioning struct {
+	APIKey string          `json:"api_key" example:"x-api-key"`
+	Phone  *entities.Phone `json:"phone"`
+}
+
+// PhoneProvisioningService pairs the android app with the account of a user with a short-lived code instead of the API key
+type PhoneProvisioningService struct {
+	service
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
+	repository     repositories.PhoneProvisioningCodeRepository
+	userRepository repositories.UserRepository
+	phoneService   *PhoneService
+}
+
+// NewPhoneProvisioningService creates a new PhoneProvisioningService
+func NewPhoneProvisioningService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.PhoneProvisioningCodeRepository,
+	userRepository repositories.UserRepository,
+	phoneService *PhoneService,
+) (s *PhoneProvisioningService) {
+	return &PhoneProvisioningService{
+		logger:         logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:         tracer,
+		repository:     repository,
+		userRepository: userRepository,
+		phoneService:   phoneService,
+	}
+}
+
+// Store a new entities.PhoneProvisioningCode for a user, the code is only returned here because only its hash is stored
+func (service *PhoneProvisioningService) Store(ctx context.Context, userID entities.UserID) (*entities.PhoneProvisioningCode, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	code, err := service.generateCode()
+	if err != nil {
+		msg := fmt.Sprintf("cannot generate provisioning code for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	provisioningCode := &entities.PhoneProvisioningCode{
+		ID:        uuid.New(),
+		UserID:    userID,
+		CodeHash:  PhoneProvisioningCodeHash(code),
+		Code:      code[:phoneProvisioningCodeLength/2] + "-" + code[phoneProvisioningCodeLength/2:],
+		ExpiresAt: time.Now().UTC().Add(PhoneProvisioningCodeTTL),
+		CreatedAt: time.Now().UTC(),
+	}
+
+	if err = service.repository.Store(ctx, provisioningCode); err != nil {
+		msg := fmt.Sprintf("cannot store provisioning code with ID [%s] for user [%s]", provisioningCode.ID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created provisioning code with ID [%s] for user [%s] which expires at [%s]", provisioningCode.ID, userID, provisioningCode.ExpiresAt))
+	return provisioningCode, nil
+}
+
+// PhoneProvisioningExchangeParams are parameters for exchanging a provisioning code
+type PhoneProvisioningExchangeParams struct {
+	Code        string
+	PhoneNumber *phonenumbers.PhoneNumber
+	FcmToken    *string
+	SIM         entities.SIM
+	Source      string
+}
+
+// Exchange a provisioning code for the API key of its user and register the phone, repositories.ErrCodeNotFound is returned when the code is invalid, used or expired
+func (service *PhoneProvisioningService) Exchange(ctx context.Context, params *PhoneProvisioningExchangeParams) (*PhoneProvisioning, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	code, err := service.repository.Consume(ctx, PhoneProvisioningCodeHash(params.Code), time.Now().UTC())
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), "cannot consume provisioning code"))
+	}
+
+	user, err := service.userRepository.Load(ctx, code.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user [%s] of provisioning code with ID [%s]", code.UserID, code.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	phone, err := service.phoneService.Upsert(ctx, &PhoneUpsertParams{
+		PhoneNumber: params.PhoneNumber,
+		FcmToken:    params.FcmToken,
+		SIM:         params.SIM,
+		Source:      params.Source,
+		UserID:      user.ID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot register phone [%s] with provisioning code with ID [%s] for user [%s]", phonenumbers.Format(params.PhoneNumber, phonenumbers.E164), code.ID, user.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("exchanged provisioning code with ID [%s] for phone [%s] of user [%s]", code.ID, phone.ID, user.ID))
+	return &PhoneProvisioning{APIKey: user.APIKey, Phone: phone}, nil
+}
+
+// PhoneProvisioningCodeHash is the SHA-256 hash which is stored instead of a provisioning code
+func PhoneProvisioningCodeHash(code string) string {
+	hash := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(hash[:])
+}
+
+func (service *PhoneProvisioningService) generateCode() (string, error) {
+	code := make([]byte, phoneProvisioningCodeLength)
+	for i := range code {
+		index, err := rand.Int(rand.Reader, big.NewInt(int64(len(phoneProvisioningCodeAlphabet))))
+		if err != nil {
+			return "", stacktrace.Propagate(err, "cannot generate a random character")
+		}
+		code[i] = phoneProvisioningCodeAlphabet[index.Int64()]
+	}
+	return string(code), nil
+}
@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// PhoneSemaphore limits the number of messages which are pushed concurrently to a phone.
+// The messages above the limit wait for a slot instead of failing, the limit is per instance of the API.
+type PhoneSemaphore struct {
+	logger   telemetry.Logger
+	mutex    sync.Mutex
+	inFlight map[string]uint
+	released map[string]chan struct{}
+}
+
+// NewPhoneSemaphore creates a new PhoneSemaphore
+func NewPhoneSemaphore(logger telemetry.Logger) (s *PhoneSemaphore) {
+	return &PhoneSemaphore{
+		logger:   logger.WithService(fmt.Sprintf("%T", s)),
+		inFlight: map[string]uint{},
+		released: map[string]chan struct{}{},
+	}
+}
+
+// Acquire waits until there are less than limit messages in flight for the key or the context is done.
+// The returned function must be called when the message has been sent.
+func (semaphore *PhoneSemaphore) Acquire(ctx context.Context, key string, limit uint) (release func(), err error) {
+	for {
+		semaphore.mutex.Lock()
+		if semaphore.inFlight[key] < limit {
+			semaphore.inFlight[key]++
+			semaphore.mutex.Unlock()
+			return semaphore.release(key), nil
+		}
+
+		released, ok := semaphore.released[key]
+		if !ok {
+			released = make(chan struct{})
+			semaphore.released[key] = released
+		}
+		semaphore.mutex.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return nil, stacktrace.Propagate(ctx.Err(), fmt.Sprintf("cannot acquire a slot for [%s] with [%d] messages in flight", key, limit))
+		case <-released:
+		}
+	}
+}
+
+// InFlight returns the number of messages in flight for the key
+func (semaphore *PhoneSemaphore) InFlight(key string) uint {
+	semaphore.mutex.Lock()
+	defer semaphore.mutex.Unlock()
+	return semaphore.inFlight[key]
+}
+
+func (semaphore *PhoneSemaphore) release(key string) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			semaphore.mutex.Lock()
+			defer semaphore.mutex.Unlock()
+
+			semaphore.inFlight[key]--
+			if semaphore.inFlight[key] == 0 {
+				delete(semaphore.inFlight, key)
+			}
+
+			// closing the channel wakes up all the waiting messages so they can compete for the free slot
+			if released, ok := semaphore.released[key]; ok {
+				close(released)
+				delete(semaphore.released, key)
+			}
+		})
+	}
+}
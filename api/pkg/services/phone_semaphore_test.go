@@ -0,0 +1,129 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/hirosassa/zerodriver"
+	"github.com/rs/zerolog"
+	"github.com/stretchr/testify/assert"
+)
+
+// testLogger is a telemetry.Logger which discards the logs of the services under test
+func testLogger() telemetry.Logger {
+	logger := zerolog.Nop()
+	return telemetry.NewZerologLogger("test", map[string]string{}, &zerodriver.Logger{Logger: &logger}, nil)
+}
+
+func TestPhoneSemaphore_Acquire(t *testing.T) {
+	tests := []struct {
+		name     string
+		limit    uint
+		acquired int
+		waits    bool
+	}{
+		{name: "a slot is acquired below the limit", limit: 2, acquired: 1, waits: false},
+		{name: "the limit waits when all the slots are acquired", limit: 2, acquired: 2, waits: true},
+		{name: "a limit of 1 serializes the messages", limit: 1, acquired: 1, waits: true},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+			semaphore := NewPhoneSemaphore(testLogger())
+
+			// Arrange
+			var releases []func()
+			for i := 0; i < tt.acquired; i++ {
+				release, err := semaphore.Acquire(context.Background(), "phone", tt.limit)
+				assert.Nil(t, err)
+				releases = append(releases, release)
+			}
+
+			// Act
+			ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+			defer cancel()
+			release, err := semaphore.Acquire(ctx, "phone", tt.limit)
+
+			// Assert
+			assert.Equal(t, tt.waits, err != nil)
+			if release != nil {
+				release()
+			}
+			for _, release := range releases {
+				release()
+			}
+			assert.Equal(t, uint(0), semaphore.InFlight("phone"))
+		})
+	}
+}
+
+func TestPhoneSemaphore_Release(t *testing.T) {
+	t.Run("a waiting message acquires the slot when it is released", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		semaphore := NewPhoneSemaphore(testLogger())
+
+		// Arrange
+		release, err := semaphore.Acquire(context.Background(), "phone", 1)
+		assert.Nil(t, err)
+
+		acquired := make(chan struct{})
+		go func() {
+			next, err := semaphore.Acquire(context.Background(), "phone", 1)
+			assert.Nil(t, err)
+			close(acquired)
+			next()
+		}()
+
+		// Act
+		release()
+
+		// Assert
+		select {
+		case <-acquired:
+		case <-time.After(time.Second):
+			t.Fatal("the waiting message did not acquire the released slot")
+		}
+	})
+
+	t.Run("a slot is released once when the release is called twice", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		semaphore := NewPhoneSemaphore(testLogger())
+
+		// Arrange
+		first, _ := semaphore.Acquire(context.Background(), "phone", 2)
+		_, _ = semaphore.Acquire(context.Background(), "phone", 2)
+
+		// Act
+		first()
+		first()
+
+		// Assert
+		assert.Equal(t, uint(1), semaphore.InFlight("phone"))
+	})
+
+	t.Run("the slots of different phones are independent", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		semaphore := NewPhoneSemaphore(testLogger())
+
+		// Arrange
+		_, _ = semaphore.Acquire(context.Background(), "phone-1", 1)
+
+		// Act
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := semaphore.Acquire(ctx, "phone-2", 1)
+
+		// Assert
+		assert.Nil(t, err)
+		assert.Equal(t, uint(1), semaphore.InFlight("phone-1"))
+		assert.Equal(t, uint(1), semaphore.InFlight("phone-2"))
+	})
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
@@ -18,6 +19,38 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 )
 
+const (
+	// ErrCodePhoneDailyQuotaExceeded is returned when a phone has already sent its daily quota of messages
+	ErrCodePhoneDailyQuotaExceeded = stacktrace.ErrorCode(1103)
+
+	// ErrCodePhoneBulkStoreRejected is returned when at least 1 phone in a bulk registration cannot be created
+	ErrCodePhoneBulkStoreRejected = stacktrace.ErrorCode(1104)
+
+	// ErrCodePhoneReassignTargetNotFound is returned when there is no other phone to reassign the messages of a deleted phone
+	ErrCodePhoneReassignTargetNotFound = stacktrace.ErrorCode(1105)
+
+	// ErrCodePhoneGroupEmpty is returned when a message is sent from a group which has no phones
+	ErrCodePhoneGroupEmpty = stacktrace.ErrorCode(1114)
+
+	// ErrCodePhoneFcmSigningDisabled is returned when the key of a phone is rotated but the push notifications are not signed
+	ErrCodePhoneFcmSigningDisabled = stacktrace.ErrorCode(1119)
+
+	// ErrCodePhoneFcmKeyNotRotated is returned when the key of a phone is promoted before it is rotated
+	ErrCodePhoneFcmKeyNotRotated = stacktrace.ErrorCode(1120)
+
+	// ErrCodePhonePaused is returned when a message is sent from a paused phone which rejects messages or from a group whose phones are all paused
+	ErrCodePhonePaused = stacktrace.ErrorCode(1124)
+
+	// ErrCodeCountryRouteNotFound is returned when a message is sent without a from number and no country route matches the recipient
+	ErrCodeCountryRouteNotFound = stacktrace.ErrorCode(1125)
+
+	// ErrCodePhoneExternalIDConflict is returned when the external ID of a phone is already used by another phone of the user
+	ErrCodePhoneExternalIDConflict = stacktrace.ErrorCode(1126)
+
+	// ErrCodePhoneSenderIDConflict is returned when the alphanumeric sender ID of a phone is already used by another phone of the user
+	ErrCodePhoneSenderIDConflict = stacktrace.ErrorCode(1129)
+)
+
 // PhoneService is handles phone requests
 type PhoneService struct {
 	service
@@ -25,6 +58,24 @@ type PhoneService struct {
 	tracer     telemetry.Tracer
 	repository repositories.PhoneRepository
 	dispatcher *EventDispatcher
+
+	// heartbeatRepository is used to compute the time until a phone is considered offline
+	heartbeatRepository repositories.HeartbeatRepository
+
+	// semaphore limits the number of messages of bulk sends which are sent to a phone at the same time
+	semaphore *PhoneSemaphore
+
+	// signer is used to expose the key which verifies the push notifications, it is nil when the notifications are not signed
+	signer *FcmSigner
+
+	// groupRepository stores the strategy which selects the phone of a group that sends a message
+	groupRepository repositories.PhoneGroupRepository
+
+	// routeRepository stores the country routes which select the phone that sends a message without a from number
+	routeRepository repositories.CountryRouteRepository
+
+	// notificationService sends the control notifications to the phones
+	notificationService *PhoneNotificationService
 }
 
 // NewPhoneService creates a new PhoneService
@@ -33,12 +84,25 @@ func NewPhoneService(
 	tracer telemetry.Tracer,
 	repository repositories.PhoneRepository,
 	dispatcher *EventDispatcher,
+	heartbeatRepository repositories.HeartbeatRepository,
+	semaphore *PhoneSemaphore,
+	signer *FcmSigner,
+	groupRepository repositories.PhoneGroupRepository,
+	routeRepository repositories.CountryRouteRepository,
+	notificationService *PhoneNotificationService,
 ) (s *PhoneService) {
 	return &PhoneService{
 		logger:     logger.WithService(fmt.Sprintf("%T", s)),
 		tracer:     tracer,
 		dispatcher: dispatcher,
 		repository: repository,
+
+		heartbeatRepository: heartbeatRepository,
+		semaphore:           semaphore,
+		signer:              signer,
+		groupRepository:     groupRepository,
+		routeRepository:     routeRepository,
+		notificationService: notificationService,
 	}
 }
 
@@ -55,10 +119,34 @@ func (service *PhoneService) Index(ctx context.Context, authUser entities.AuthUs
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	for index := range *phones {
+		service.setStatus(ctx, &(*phones)[index])
+	}
+
 	ctxLogger.Info(fmt.Sprintf("fetched [%d] phones with prams [%+#v]", len(*phones), params))
 	return phones, nil
 }
 
+// IndexByExternalID fetches the phone of a user with the ID of the phone in an external system, the list is empty when there is no such phone
+func (service *PhoneService) IndexByExternalID(ctx context.Context, authUser entities.AuthUser, externalID string) (*[]entities.Phone, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	phones := new([]entities.Phone)
+	phone, err := service.repository.LoadByExternalID(ctx, authUser.ID, externalID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return phones, nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch phone with external ID [%s] for user [%s]", externalID, authUser.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	*phones = append(*phones, *service.setStatus(ctx, phone))
+	return phones, nil
+}
+
 // Load a phone by userID and owner
 func (service *PhoneService) Load(ctx context.Context, userID entities.UserID, owner string) (*entities.Phone, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -67,18 +155,177 @@ func (service *PhoneService) Load(ctx context.Context, userID entities.UserID, o
 	return service.repository.Load(ctx, userID, owner)
 }
 
+// LoadByID loads a phone by userID and phoneID
+func (service *PhoneService) LoadByID(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.Phone, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	return service.repository.LoadByID(ctx, userID, phoneID)
+}
+
+// LoadByAlphanumericSenderID loads the phone whose SIM can send messages with the alphanumeric sender ID
+func (service *PhoneService) LoadByAlphanumericSenderID(ctx context.Context, userID entities.UserID, senderID string) (*entities.Phone, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	return service.repository.LoadByAlphanumericSenderID(ctx, userID, senderID)
+}
+
+// UpdateLastSentAt records the time when the phone sent a message
+func (service *PhoneService) UpdateLastSentAt(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.repository.UpdateLastSentAt(ctx, userID, owner, timestamp.UTC()); err != nil {
+		msg := fmt.Sprintf("cannot update the last sent time of phone [%s] for user [%s] to [%s]", owner, userID, timestamp)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// UpdateLastReceivedAt records the time when the phone received a message
+func (service *PhoneService) UpdateLastReceivedAt(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.repository.UpdateLastReceivedAt(ctx, userID, owner, timestamp.UTC()); err != nil {
+		msg := fmt.Sprintf("cannot update the last received time of phone [%s] for user [%s] to [%s]", owner, userID, timestamp)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// UpdateLastError records the latest error reported by the phone when it failed to send a message
+func (service *PhoneService) UpdateLastError(ctx context.Context, userID entities.UserID, owner string, lastError string, timestamp time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.repository.UpdateLastError(ctx, userID, owner, &lastError, timestamp.UTC()); err != nil {
+		msg := fmt.Sprintf("cannot update the last error of phone [%s] for user [%s] to [%s]", owner, userID, lastError)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// ClearLastError removes the last error of the phone after it sends a message at the timestamp
+func (service *PhoneService) ClearLastError(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.repository.UpdateLastError(ctx, userID, owner, nil, timestamp.UTC()); err != nil {
+		msg := fmt.Sprintf("cannot clear the last error of phone [%s] for user [%s]", owner, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// ConsumeDailyQuota counts a message sent by the phone on the date of the timestamp, it returns ErrCodePhoneDailyQuotaExceeded when the daily quota is exhausted
+func (service *PhoneService) ConsumeDailyQuota(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.repository.Load(ctx, userID, owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("phone with owner [%s] does not exist for user [%s], skipping daily quota", owner, userID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with owner [%s] for user [%s]", owner, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	date := phone.DailyQuotaDate(timestamp)
+	ok, err := service.repository.IncrementDailySent(ctx, phone, date)
+	if err != nil {
+		msg := fmt.Sprintf("cannot increment daily sent messages for phone [%s] and user [%s]", phone.ID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !ok {
+		msg := fmt.Sprintf("phone [%s] has already sent its daily quota of [%d] messages on [%s]", phone.ID, phone.DailyQuota, date)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneDailyQuotaExceeded, msg))
+	}
+
+	return nil
+}
+
+// ReleaseDailyQuota gives back a message which was counted by ConsumeDailyQuota on the date of the timestamp but was not sent
+func (service *PhoneService) ReleaseDailyQuota(ctx context.Context, userID entities.UserID, owner string, timestamp time.Time) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.repository.Load(ctx, userID, owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("phone with owner [%s] does not exist for user [%s], skipping daily quota", owner, userID))
+		return nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with owner [%s] for user [%s]", owner, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.repository.DecrementDailySent(ctx, phone.ID, phone.DailyQuotaDate(timestamp)); err != nil {
+		msg := fmt.Sprintf("cannot decrement daily sent messages for phone [%s] and user [%s]", phone.ID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
 // PhoneUpsertParams are parameters for creating a new entities.Phone
 type PhoneUpsertParams struct {
 	PhoneNumber               *phonenumbers.PhoneNumber
 	FcmToken                  *string
 	MessagesPerMinute         *uint
 	MaxSendAttempts           *uint
+	BatteryLowThreshold       *uint
 	WebhookURL                *string
 	MessageExpirationDuration *time.Duration
 	MissedCallAutoReply       *string
+	AutoAckMessage            *string
+	AlphanumericSenderID      *string
+	DailyQuota                *uint
+	DailyQuotaTimezone        *string
+	Group                     *string
+	GroupPriority             *uint
+	ShortCodesDisabled        *bool
+	RcsEnabled                *bool
 	SIM                       entities.SIM
 	Source                    string
 	UserID                    entities.UserID
+
+	// HeartbeatInterval is the expected duration between the heartbeats of the phone
+	HeartbeatInterval *time.Duration
+
+	// MaxConcurrentSends is the maximum number of messages which are pushed to the phone at the same time
+	MaxConcurrentSends *uint
+
+	// DeliveryReportTimeout is the duration after which a sent message without a delivery report is marked as failed
+	DeliveryReportTimeout *time.Duration
+
+	// AllowedRecipients are the only phone numbers which the phone can send messages to, an empty list removes the restriction
+	AllowedRecipients *[]string
+
+	// Timezone is the IANA timezone of the phone
+	Timezone *string
+
+	// BusinessHours is the weekly schedule of the phone, an empty schedule removes the business hours
+	BusinessHours *entities.PhoneBusinessHoursSchedule
+
+	// OutOfOfficeMessage is sent to the messages which are received outside the BusinessHours
+	OutOfOfficeMessage *string
+
+	// Signature is appended to the content of the messages sent from the phone
+	Signature *string
+
+	// ExternalID is the ID of the phone in an external system
+	ExternalID *string
+
+	// Type and VirtualURL make the phone a virtual phone which posts its outgoing messages to the URL
+	Type       *entities.PhoneType
+	VirtualURL *string
 }
 
 // Upsert a new entities.Phone
@@ -98,136 +345,1324 @@ func (service *PhoneService) Upsert(ctx context.Context, params *PhoneUpsertPara
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if err = service.repository.Save(ctx, service.update(phone, params)); err != nil {
+	refreshed := params.FcmToken != nil && (phone.FcmToken == nil || *phone.FcmToken != *params.FcmToken)
+	if err = service.validateExternalID(ctx, service.update(phone, params)); err != nil {
+		msg := fmt.Sprintf("cannot update the external ID of phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.validateAlphanumericSenderID(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot update the alphanumeric sender ID of phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.repository.Save(ctx, phone); err != nil {
 		msg := fmt.Sprintf("cannot update phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
 	ctxLogger.Info(fmt.Sprintf("phone updated with id [%s] in the phone repository for user [%s]", phone.ID, phone.UserID))
-	return phone, service.dispatchPhoneUpdatedEvent(ctx, params.Source, phone)
+
+	if refreshed {
+		if err = service.dispatchPhoneFcmTokenRefreshedEvent(ctx, params.Source, phone); err != nil {
+			return nil, service.tracer.WrapErrorSpan(span, err)
+		}
+	}
+
+	return service.setStatus(ctx, phone), service.dispatchPhoneUpdatedEvent(ctx, params.Source, phone)
 }
 
-func (service *PhoneService) dispatchPhoneUpdatedEvent(ctx context.Context, source string, phone *entities.Phone) error {
-	ctx, span := service.tracer.Start(ctx)
-	defer span.End()
+// PhonePatchParams are parameters for updating some fields of an entities.Phone, the fields which are nil are not changed
+type PhonePatchParams struct {
+	UserID  entities.UserID
+	PhoneID uuid.UUID
+	Source  string
 
-	event, err := service.createPhoneUpdatedEvent(source, events.PhoneUpdatedPayload{
-		PhoneID:   phone.ID,
-		UserID:    phone.UserID,
-		Timestamp: phone.UpdatedAt,
-		Owner:     phone.PhoneNumber,
-		SIM:       phone.SIM,
-	})
-	if err != nil {
-		msg := fmt.Sprintf("cannot create event when phone [%s] is updated for user [%s]", phone.ID, phone.UserID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-	}
+	MessagesPerMinute         *uint
+	MessageExpirationDuration *time.Duration
+	MaxSendAttempts           *uint
+	BatteryLowThreshold       *uint
+	DailyQuota                *uint
+	DailyQuotaTimezone        *string
+	SIM                       *entities.SIM
+	HeartbeatInterval         *time.Duration
+	MaxConcurrentSends        *uint
+	DeliveryReportTimeout     *time.Duration
+	Timezone                  *string
+	GroupPriority             *uint
+	ShortCodesDisabled        *bool
+	RcsEnabled                *bool
 
-	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
-		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with id [%s]", event.Type(), phone.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
-	}
-	return nil
+	// MissedCallAutoReply, AutoAckMessage, AlphanumericSenderID and Group are removed when they are empty
+	MissedCallAutoReply  *string
+	AutoAckMessage       *string
+	AlphanumericSenderID *string
+	Group                *string
+
+	// AllowedRecipients removes the restriction when it is empty
+	AllowedRecipients *[]string
+
+	// BusinessHours are removed when they are empty and OutOfOfficeMessage is removed when it is empty
+	BusinessHours      *entities.PhoneBusinessHoursSchedule
+	OutOfOfficeMessage *string
+
+	// Signature is removed when it is empty
+	Signature *string
+
+	// ExternalID is removed when it is empty
+	ExternalID *string
+
+	// Type changes the phone to a virtual or android phone, VirtualURL is removed when it is empty
+	Type       *entities.PhoneType
+	VirtualURL *string
 }
 
-// Delete an entities.Phone
-func (service *PhoneService) Delete(ctx context.Context, source string, userID entities.UserID, phoneID uuid.UUID) error {
-	ctx, span := service.tracer.Start(ctx)
+// Patch updates only the fields of an entities.Phone which are set in the params
+func (service *PhoneService) Patch(ctx context.Context, params *PhonePatchParams) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	ctxLogger := service.tracer.CtxLogger(service.logger, span)
-
-	phone, err := service.repository.LoadByID(ctx, userID, phoneID)
+	phone, err := service.repository.LoadByID(ctx, params.UserID, params.PhoneID)
 	if err != nil {
-		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", userID, phoneID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	if err = service.repository.Delete(ctx, userID, phoneID); err != nil {
-		msg := fmt.Sprintf("cannot delete phone with id [%s] and user id [%s]", phoneID, userID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if err = service.validateExternalID(ctx, service.patch(phone, params)); err != nil {
+		msg := fmt.Sprintf("cannot patch the external ID of phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	ctxLogger.Info(fmt.Sprintf("deleted phone with id [%s] and user id [%s]", phoneID, userID))
+	if err = service.validateAlphanumericSenderID(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot patch the alphanumeric sender ID of phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
 
-	event, err := service.createPhoneDeletedEvent(source, events.PhoneDeletedPayload{
-		PhoneID:   phone.ID,
-		UserID:    phone.UserID,
-		Timestamp: phone.UpdatedAt,
-		Owner:     phone.PhoneNumber,
-		SIM:       phone.SIM,
-	})
+	if err = service.repository.Save(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot patch phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("phone patched with id [%s] in the phone repository for user [%s]", phone.ID, phone.UserID))
+	return service.setStatus(ctx, phone), service.dispatchPhoneUpdatedEvent(ctx, params.Source, phone)
+}
+
+// RotateFcmKey creates the next key of a phone which signs the push notifications after it is promoted, the current key is still used until then.
+// The phone is not changed when its key is already being rotated.
+func (service *PhoneService) RotateFcmKey(ctx context.Context, source string, userID entities.UserID, phoneID uuid.UUID) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.loadForFcmKey(ctx, userID, phoneID)
 	if err != nil {
-		msg := "cannot create event when phone is deleted"
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), fmt.Sprintf("cannot rotate the key of phone [%s]", phoneID)))
 	}
 
-	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
-		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with id [%s]", event.Type(), phone.ID)
-		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	if phone.FcmNextKeyVersion != nil {
+		ctxLogger.Info(fmt.Sprintf("the key of phone [%s] is already being rotated to version [%d]", phone.ID, *phone.FcmNextKeyVersion))
+		return service.setStatus(ctx, phone), nil
 	}
 
-	return nil
+	version := phone.FcmKeyVersion + 1
+	phone.FcmNextKeyVersion = &version
+	phone.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Save(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot save the rotated key of phone [%s] for user [%s]", phone.ID, phone.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("rotating the key of phone [%s] from version [%d] to version [%d]", phone.ID, phone.FcmKeyVersion, version))
+	service.refreshFcmKeys(ctx, source, phone)
+	return service.setStatus(ctx, phone), service.dispatchPhoneUpdatedEvent(ctx, source, phone)
 }
 
-func (service *PhoneService) createPhone(ctx context.Context, params *PhoneUpsertParams) (*entities.Phone, error) {
+// PromoteFcmKey makes the rotated key of a phone its current key and retires the previous key
+func (service *PhoneService) PromoteFcmKey(ctx context.Context, source string, userID entities.UserID, phoneID uuid.UUID) (*entities.Phone, error) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	phone := &entities.Phone{
-		ID:       uuid.New(),
-		UserID:   params.UserID,
-		FcmToken: params.FcmToken,
-		// Android has a limit of 30 SMS messages per minute without user permission, to be safe let's use 10 messages per minute
-		// https://android.googlesource.com/platform/frameworks/opt/telephony/+/master/src/java/com/android/internal/telephony/SmsUsageMonitor.java#80
-		MessagesPerMinute:        10,
-		MessageExpirationSeconds: 10 * 60, // 10 minutes
-		MaxSendAttempts:          2,
-		SIM:                      params.SIM,
-		MissedCallAutoReply:      nil,
-		PhoneNumber:              phonenumbers.Format(params.PhoneNumber, phonenumbers.E164),
-		CreatedAt:                time.Now().UTC(),
-		UpdatedAt:                time.Now().UTC(),
+	phone, err := service.loadForFcmKey(ctx, userID, phoneID)
+	if err != nil {
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), fmt.Sprintf("cannot promote the key of phone [%s]", phoneID)))
 	}
 
-	if err := service.repository.Save(ctx, phone); err != nil {
-		msg := fmt.Sprintf("cannot create phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
+	if phone.FcmNextKeyVersion == nil {
+		msg := fmt.Sprintf("the key of phone [%s] cannot be promoted because it is not being rotated", phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneFcmKeyNotRotated, msg))
+	}
+
+	retired := phone.FcmKeyVersion
+	phone.FcmKeyVersion = *phone.FcmNextKeyVersion
+	phone.FcmNextKeyVersion = nil
+	phone.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Save(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot save the promoted key of phone [%s] for user [%s]", phone.ID, phone.UserID)
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	ctxLogger.Info(fmt.Sprintf("phone updated with id [%s] in the phone repository for user [%s]", phone.ID, phone.UserID))
-	return phone, service.dispatchPhoneUpdatedEvent(ctx, params.Source, phone)
+	ctxLogger.Info(fmt.Sprintf("promoted the key of phone [%s] to version [%d] and retired version [%d]", phone.ID, phone.FcmKeyVersion, retired))
+	service.refreshFcmKeys(ctx, source, phone)
+	return service.setStatus(ctx, phone), service.dispatchPhoneUpdatedEvent(ctx, source, phone)
 }
 
-func (service *PhoneService) createPhoneUpdatedEvent(source string, payload events.PhoneUpdatedPayload) (cloudevents.Event, error) {
-	return service.createEvent(events.EventTypePhoneUpdated, source, payload)
+// refreshFcmKeys tells the app to fetch the verification keys of the phone, the app also fetches them when it cannot verify a notification so a failure does not stop the rotation
+func (service *PhoneService) refreshFcmKeys(ctx context.Context, source string, phone *entities.Phone) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.notificationService.SendControl(ctx, source, phone, entities.PhoneControlTypeConfigRefresh, ""); err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send [%s] control notification to phone [%s] after changing its key", entities.PhoneControlTypeConfigRefresh, phone.ID)))
+	}
 }
 
-func (service *PhoneService) createPhoneDeletedEvent(source string, payload events.PhoneDeletedPayload) (cloudevents.Event, error) {
-	return service.createEvent(events.EventTypePhoneDeleted, source, payload)
+// PhonePauseParams are parameters for pausing an entities.Phone
+type PhonePauseParams struct {
+	Source         string
+	UserID         entities.UserID
+	PhoneID        uuid.UUID
+	RejectMessages bool
 }
 
-func (service *PhoneService) update(phone *entities.Phone, params *PhoneUpsertParams) *entities.Phone {
-	if phone.FcmToken != nil {
-		phone.FcmToken = params.FcmToken
+// Pause takes a phone out of rotation, the messages sent from the phone are held until it is resumed unless RejectMessages is set.
+// The phone keeps its original PausedAt when it is already paused.
+func (service *PhoneService) Pause(ctx context.Context, params *PhonePauseParams) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.repository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
-	if params.MessagesPerMinute != nil && *params.MessagesPerMinute > 0 {
-		phone.MessagesPerMinute = *params.MessagesPerMinute
+
+	if !phone.IsPaused() {
+		timestamp := time.Now().UTC()
+		phone.PausedAt = &timestamp
+	}
+	phone.PausedRejectsMessages = params.RejectMessages
+	phone.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Save(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot save the paused phone [%s] for user [%s]", phone.ID, phone.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	if params.MaxSendAttempts != nil && *params.MaxSendAttempts > 0 {
-		phone.MaxSendAttempts = *params.MaxSendAttempts
+	ctxLogger.Info(fmt.Sprintf("phone [%s] of user [%s] paused at [%s] with rejected messages [%t]", phone.ID, phone.UserID, phone.PausedAt, phone.PausedRejectsMessages))
+	return service.setStatus(ctx, phone), service.dispatchPhoneUpdatedEvent(ctx, params.Source, phone)
+}
+
+// Resume puts a paused phone back into rotation and sends the messages which were held while it was paused
+func (service *PhoneService) Resume(ctx context.Context, source string, userID entities.UserID, phoneID uuid.UUID) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.repository.LoadByID(ctx, userID, phoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", phoneID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
 	}
 
-	if params.MessageExpirationDuration != nil {
-		phone.MessageExpirationSeconds = uint(params.MessageExpirationDuration.Seconds())
+	if !phone.IsPaused() {
+		ctxLogger.Info(fmt.Sprintf("phone [%s] of user [%s] is not paused", phone.ID, phone.UserID))
+		return service.setStatus(ctx, phone), nil
 	}
 
-	if params.MissedCallAutoReply != nil {
-		phone.MissedCallAutoReply = params.MissedCallAutoReply
+	pausedAt := *phone.PausedAt
+	phone.PausedAt = nil
+	phone.PausedRejectsMessages = false
+	phone.UpdatedAt = time.Now().UTC()
+	if err = service.repository.Save(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot save the resumed phone [%s] for user [%s]", phone.ID, phone.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
-	phone.SIM = params.SIM
+	ctxLogger.Info(fmt.Sprintf("phone [%s] of user [%s] resumed after being paused at [%s]", phone.ID, phone.UserID, pausedAt))
+	if err = service.dispatchPhoneResumedEvent(ctx, source, phone, pausedAt); err != nil {
+		return service.setStatus(ctx, phone), err
+	}
+	return service.setStatus(ctx, phone), service.dispatchPhoneUpdatedEvent(ctx, source, phone)
+}
 
-	return phone
+func (service *PhoneService) dispatchPhoneResumedEvent(ctx context.Context, source string, phone *entities.Phone, pausedAt time.Time) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	event, err := service.createEvent(events.EventTypePhoneResumed, source, events.PhoneResumedPayload{
+		PhoneID:   phone.ID,
+		UserID:    phone.UserID,
+		Owner:     phone.PhoneNumber,
+		SIM:       phone.SIM,
+		PausedAt:  pausedAt,
+		Timestamp: phone.UpdatedAt,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for phone [%s] of user [%s]", events.EventTypePhoneResumed, phone.ID, phone.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with id [%s]", event.Type(), phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// loadForFcmKey loads a phone whose key is rotated or promoted
+func (service *PhoneService) loadForFcmKey(ctx context.Context, userID entities.UserID, phoneID uuid.UUID) (*entities.Phone, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if service.signer == nil {
+		msg := fmt.Sprintf("the key of phone [%s] cannot be changed because FCM_SIGNING_KEY is not set", phoneID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneFcmSigningDisabled, msg))
+	}
+
+	phone, err := service.repository.LoadByID(ctx, userID, phoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", phoneID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+	return phone, nil
+}
+
+// SendReaction tells the phone to send an emoji reaction to its message over RCS
+func (service *PhoneService) SendReaction(ctx context.Context, source string, phone *entities.Phone, messageID uuid.UUID, emoji string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if err := service.notificationService.SendReaction(ctx, source, phone, messageID, emoji); err != nil {
+		msg := fmt.Sprintf("cannot send reaction to message [%s] with phone [%s]", messageID, phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+	return nil
+}
+
+// PhoneFcmTokenUpdateParams are parameters for updating the FCM token of an entities.Phone
+type PhoneFcmTokenUpdateParams struct {
+	UserID   entities.UserID
+	PhoneID  uuid.UUID
+	FcmToken string
+	Source   string
+}
+
+// UpdateFcmToken sets the new FCM token of an entities.Phone after the token is rotated on the phone
+func (service *PhoneService) UpdateFcmToken(ctx context.Context, params *PhoneFcmTokenUpdateParams) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if err := service.repository.UpdateFcmToken(ctx, params.UserID, params.PhoneID, params.FcmToken, time.Now().UTC()); err != nil {
+		msg := fmt.Sprintf("cannot update the FCM token of phone [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	phone, err := service.repository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("updated the FCM token of phone [%s] for user [%s]", phone.ID, phone.UserID))
+	return service.setStatus(ctx, phone), service.dispatchPhoneFcmTokenRefreshedEvent(ctx, params.Source, phone)
+}
+
+// PhoneNotifyParams are parameters for sending a control notification to phones
+type PhoneNotifyParams struct {
+	UserID  entities.UserID
+	Source  string
+	Type    entities.PhoneControlType
+	Message string
+
+	// PhoneID is the phone which is notified by PhoneService.Notify
+	PhoneID uuid.UUID
+
+	// Group limits the phones which are notified by PhoneService.NotifyAll, all the phones of the user are notified when it is nil
+	Group *string
+}
+
+// PhoneNotifyResult is the result of sending a control notification to a single phone in PhoneService.NotifyAll
+type PhoneNotifyResult struct {
+	PhoneID     uuid.UUID `json:"phone_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	PhoneNumber string    `json:"phone_number" example:"+18005550199"`
+	Success     bool      `json:"success" example:"true"`
+	Error       *string   `json:"error" example:"the phone has no valid FCM token"`
+}
+
+// phoneNotifyAllLimit is the maximum number of phones which are notified by PhoneService.NotifyAll
+const phoneNotifyAllLimit = 1000
+
+// Notify sends a control notification e.g. entities.PhoneControlTypeAppUpdate to a phone
+func (service *PhoneService) Notify(ctx context.Context, params *PhoneNotifyParams) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.repository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone [%s] for user [%s]", params.PhoneID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err = service.notificationService.SendControl(ctx, params.Source, phone, params.Type, params.Message); err != nil {
+		msg := fmt.Sprintf("cannot send [%s] control notification to phone [%s]", params.Type, phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("notified phone [%s] of user [%s] with [%s] control notification", phone.ID, phone.UserID, params.Type))
+	return service.setStatus(ctx, phone), nil
+}
+
+// NotifyAll sends a control notification to all the phones of a user or to the phones of a group, a phone which cannot be notified does not stop the other phones from being notified
+func (service *PhoneService) NotifyAll(ctx context.Context, params *PhoneNotifyParams) ([]PhoneNotifyResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phones, err := service.notifyTargets(ctx, params)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the phones of user [%s] to send [%s] control notification", params.UserID, params.Type)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	results := make([]PhoneNotifyResult, 0, len(phones))
+	for _, phone := range phones {
+		result := PhoneNotifyResult{PhoneID: phone.ID, PhoneNumber: phone.PhoneNumber, Success: true}
+		if err = service.notificationService.SendControl(ctx, params.Source, phone, params.Type, params.Message); err != nil {
+			ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send [%s] control notification to phone [%s]", params.Type, phone.ID)))
+			result.Success = false
+			result.Error = service.stringPointer(service.notifyError(err))
+		}
+		results = append(results, result)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent [%s] control notification to [%d] phones of user [%s]", params.Type, len(results), params.UserID))
+	return results, nil
+}
+
+func (service *PhoneService) notifyTargets(ctx context.Context, params *PhoneNotifyParams) ([]*entities.Phone, error) {
+	if params.Group != nil {
+		return service.repository.IndexByGroup(ctx, params.UserID, *params.Group)
+	}
+
+	phones, err := service.repository.Index(ctx, params.UserID, repositories.IndexParams{Limit: phoneNotifyAllLimit})
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*entities.Phone, 0, len(*phones))
+	for index := range *phones {
+		result = append(result, &(*phones)[index])
+	}
+	return result, nil
+}
+
+// notifyError is the reason why a phone was not notified which is returned to the user
+func (service *PhoneService) notifyError(err error) string {
+	if stacktrace.GetCode(err) == ErrCodePhoneControlUnreachable {
+		return "the phone has no valid FCM token, open the httpSMS app on the phone to register a new token"
+	}
+	return "the control notification could not be sent to the phone"
+}
+
+// setStatus sets the computed fields of an entities.Phone which are not stored in the database
+func (service *PhoneService) setStatus(ctx context.Context, phone *entities.Phone) *entities.Phone {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone.DailySentDate = phone.DailyQuotaDate(time.Now().UTC())
+	if sent, err := service.repository.LoadDailySent(ctx, phone.ID, phone.DailySentDate); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load the daily sent messages of phone [%s]", phone.ID)))
+	} else {
+		phone.DailySent = sent
+	}
+
+	phone.SetDailyQuotaRemaining(time.Now().UTC())
+	phone.SendsInFlight = service.semaphore.InFlight(phone.ID.String())
+	if service.signer != nil {
+		key := service.signer.ForPhone(phone.ID, phone.FcmKeyVersion).VerificationKey()
+		phone.FcmVerificationKey = &key
+	}
+	if service.signer != nil && phone.FcmNextKeyVersion != nil {
+		key := service.signer.ForPhone(phone.ID, *phone.FcmNextKeyVersion).VerificationKey()
+		phone.FcmNextVerificationKey = &key
+	}
+
+	heartbeat, err := service.heartbeatRepository.Last(ctx, phone.UserID, phone.PhoneNumber)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return phone.SetSecondsUntilOffline(nil, time.Now().UTC())
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load last heartbeat for phone [%s] of user [%s]", phone.ID, phone.UserID)
+		ctxLogger.Error(stacktrace.Propagate(err, msg))
+		return phone.SetSecondsUntilOffline(nil, time.Now().UTC())
+	}
+
+	phone.SetSecondsUntilOffline(&heartbeat.Timestamp, time.Now().UTC())
+	if phone.FcmTokenInvalidAt != nil {
+		// the phone cannot receive messages until it sends a new FCM token
+		offline := int64(0)
+		phone.SecondsUntilOffline = &offline
+	}
+	return phone
+}
+
+func (service *PhoneService) dispatchPhoneUpdatedEvent(ctx context.Context, source string, phone *entities.Phone) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	event, err := service.createPhoneUpdatedEvent(source, events.PhoneUpdatedPayload{
+		PhoneID:   phone.ID,
+		UserID:    phone.UserID,
+		Timestamp: phone.UpdatedAt,
+		Owner:     phone.PhoneNumber,
+		SIM:       phone.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event when phone [%s] is updated for user [%s]", phone.ID, phone.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with id [%s]", event.Type(), phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+func (service *PhoneService) dispatchPhoneFcmTokenRefreshedEvent(ctx context.Context, source string, phone *entities.Phone) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	event, err := service.createEvent(events.EventTypePhoneFcmTokenRefreshed, source, events.PhoneFcmTokenRefreshedPayload{
+		PhoneID:   phone.ID,
+		UserID:    phone.UserID,
+		Owner:     phone.PhoneNumber,
+		SIM:       phone.SIM,
+		Timestamp: time.Now().UTC(),
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create [%s] event for phone [%s] of user [%s]", events.EventTypePhoneFcmTokenRefreshed, phone.ID, phone.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with id [%s]", event.Type(), phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+// Delete an entities.Phone
+func (service *PhoneService) Delete(ctx context.Context, source string, userID entities.UserID, phoneID uuid.UUID, reassignTo string) error {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	ctxLogger := service.tracer.CtxLogger(service.logger, span)
+
+	phone, err := service.repository.LoadByID(ctx, userID, phoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", userID, phoneID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	owners, err := service.reassignTargets(ctx, phone, reassignTo)
+	if err != nil {
+		msg := fmt.Sprintf("cannot resolve the phones to reassign [%s] for phone with ID [%s]", reassignTo, phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.repository.Delete(ctx, userID, phoneID); err != nil {
+		msg := fmt.Sprintf("cannot delete phone with id [%s] and user id [%s]", phoneID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted phone with id [%s] and user id [%s]", phoneID, userID))
+
+	event, err := service.createPhoneDeletedEvent(source, events.PhoneDeletedPayload{
+		PhoneID:    phone.ID,
+		UserID:     phone.UserID,
+		Timestamp:  phone.UpdatedAt,
+		Owner:      phone.PhoneNumber,
+		SIM:        phone.SIM,
+		ReassignTo: owners,
+	})
+	if err != nil {
+		msg := "cannot create event when phone is deleted"
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for phone with id [%s]", event.Type(), phone.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return nil
+}
+
+// reassignTargets returns the phone numbers which will send the pending messages of a deleted entities.Phone.
+// reassignTo is either a phone number or a group, the deleted phone is never a target.
+func (service *PhoneService) reassignTargets(ctx context.Context, phone *entities.Phone, reassignTo string) ([]string, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	if reassignTo == "" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(reassignTo, "+") {
+		target, err := service.repository.Load(ctx, phone.UserID, reassignTo)
+		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+			msg := fmt.Sprintf("the phone [%s] does not exist", reassignTo)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodePhoneReassignTargetNotFound, msg))
+		}
+		if err != nil {
+			msg := fmt.Sprintf("cannot load phone with userID [%s] and phone number [%s]", phone.UserID, reassignTo)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+		if target.ID == phone.ID {
+			msg := fmt.Sprintf("cannot reassign the messages of phone [%s] to itself", phone.PhoneNumber)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneReassignTargetNotFound, msg))
+		}
+		return []string{target.PhoneNumber}, nil
+	}
+
+	phones, err := service.repository.IndexByGroup(ctx, phone.UserID, reassignTo)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch phones with userID [%s] and group [%s]", phone.UserID, reassignTo)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	owners := make([]string, 0, len(phones))
+	for _, target := range phones {
+		if target.ID != phone.ID {
+			owners = append(owners, target.PhoneNumber)
+		}
+	}
+
+	if len(owners) == 0 {
+		msg := fmt.Sprintf("the group [%s] has no other phone apart from [%s]", reassignTo, phone.PhoneNumber)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneReassignTargetNotFound, msg))
+	}
+
+	return owners, nil
+}
+
+// PhoneGroupUpsertParams are parameters for setting the strategy of a group of phones
+type PhoneGroupUpsertParams struct {
+	UserID   entities.UserID
+	Name     string
+	Strategy entities.PhoneGroupStrategy
+
+	// ProviderFallback is not changed when it is nil
+	ProviderFallback *bool
+}
+
+// UpsertGroup sets the strategy which selects the phone of a group that sends a message
+func (service *PhoneService) UpsertGroup(ctx context.Context, params *PhoneGroupUpsertParams) (*entities.PhoneGroup, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	group, err := service.loadGroup(ctx, params.UserID, params.Name)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone group [%s] for user [%s]", params.Name, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	group.Strategy = params.Strategy
+	if params.ProviderFallback != nil {
+		group.ProviderFallback = *params.ProviderFallback
+	}
+	group.UpdatedAt = time.Now().UTC()
+	if err = service.groupRepository.Save(ctx, group); err != nil {
+		msg := fmt.Sprintf("cannot save phone group [%s] for user [%s]", group.Name, group.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("set the strategy of phone group [%s] for user [%s] to [%s]", group.Name, group.UserID, group.Strategy))
+	return group, nil
+}
+
+// loadGroup returns the settings of a group, the round robin strategy is used when the group has no settings
+func (service *PhoneService) loadGroup(ctx context.Context, userID entities.UserID, name string) (*entities.PhoneGroup, error) {
+	group, err := service.groupRepository.Load(ctx, userID, name)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return &entities.PhoneGroup{
+			UserID:    userID,
+			Name:      name,
+			Strategy:  entities.PhoneGroupStrategyRoundRobin,
+			CreatedAt: time.Now().UTC(),
+			UpdatedAt: time.Now().UTC(),
+		}, nil
+	}
+	return group, err
+}
+
+// SelectGroupPhone returns the phone of a group which sends the next message using the strategy of the group.
+// The paused phones are skipped and only the online phones are considered unless all the phones of the group are offline.
+func (service *PhoneService) SelectGroupPhone(ctx context.Context, userID entities.UserID, name string) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phones, err := service.repository.IndexByGroup(ctx, userID, name)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch phones with userID [%s] and group [%s]", userID, name)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if len(phones) == 0 {
+		msg := fmt.Sprintf("the group [%s] of user [%s] has no phones", name, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneGroupEmpty, msg))
+	}
+
+	group, err := service.loadGroup(ctx, userID, name)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone group [%s] for user [%s]", name, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	active := make([]*entities.Phone, 0, len(phones))
+	candidates := make([]*entities.Phone, 0, len(phones))
+	for _, phone := range phones {
+		if phone.IsPaused() {
+			continue
+		}
+		active = append(active, phone)
+		if service.setStatus(ctx, phone).IsOnline() {
+			candidates = append(candidates, phone)
+		}
+	}
+
+	if len(active) == 0 {
+		msg := fmt.Sprintf("all the [%d] phones of group [%s] of user [%s] are paused", len(phones), name, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhonePaused, msg))
+	}
+
+	// the message waits on a phone of the group until it comes back online
+	if len(candidates) == 0 {
+		candidates = active
+	}
+
+	selected := candidates[0]
+	for _, phone := range candidates[1:] {
+		if service.isPreferred(group.Strategy, phone, selected) {
+			selected = phone
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("selected phone [%s] out of [%d] candidates of group [%s] with strategy [%s] for user [%s]", selected.PhoneNumber, len(candidates), name, group.Strategy, userID))
+	return selected, nil
+}
+
+// isPreferred checks if the phone should send the message instead of the current choice, the oldest phone wins a tie
+func (service *PhoneService) isPreferred(strategy entities.PhoneGroupStrategy, phone *entities.Phone, current *entities.Phone) bool {
+	if strategy == entities.PhoneGroupStrategyFailover {
+		return phone.GroupPriority > current.GroupPriority
+	}
+
+	// round robin sends from the phone which has not sent a message for the longest time
+	if phone.LastSentAt == nil || current.LastSentAt == nil {
+		return phone.LastSentAt == nil && current.LastSentAt != nil
+	}
+	return phone.LastSentAt.Before(*current.LastSentAt)
+}
+
+// CountryRouteUpsertParams are parameters for setting the phone which sends the messages to the recipients in a country
+type CountryRouteUpsertParams struct {
+	UserID  entities.UserID
+	Country string
+
+	// Owner or PhoneGroup sends the messages, only one of them is set
+	Owner      *string
+	PhoneGroup *string
+}
+
+// UpsertCountryRoute sets the phone or the group of phones which sends the messages without a from number to the recipients in a country
+func (service *PhoneService) UpsertCountryRoute(ctx context.Context, params *CountryRouteUpsertParams) (*entities.CountryRoute, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	route, err := service.routeRepository.Load(ctx, params.UserID, params.Country)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		route, err = &entities.CountryRoute{
+			UserID:    params.UserID,
+			Country:   params.Country,
+			CreatedAt: time.Now().UTC(),
+		}, nil
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load country route [%s] for user [%s]", params.Country, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	route.Owner = params.Owner
+	route.PhoneGroup = params.PhoneGroup
+	route.UpdatedAt = time.Now().UTC()
+	if err = service.routeRepository.Save(ctx, route); err != nil {
+		msg := fmt.Sprintf("cannot save country route [%s] for user [%s]", route.Country, route.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("set the country route [%s] for user [%s]", route.Country, route.UserID))
+	return route, nil
+}
+
+// IndexCountryRoutes returns the country routes of a user
+func (service *PhoneService) IndexCountryRoutes(ctx context.Context, userID entities.UserID) ([]entities.CountryRoute, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	routes, err := service.routeRepository.Index(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the country routes of user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return routes, nil
+}
+
+// DeleteCountryRoute removes the route of a country so that its recipients are sent messages with the default route
+func (service *PhoneService) DeleteCountryRoute(ctx context.Context, userID entities.UserID, country string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.routeRepository.Load(ctx, userID, country); err != nil {
+		msg := fmt.Sprintf("cannot load country route [%s] for user [%s]", country, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.routeRepository.Delete(ctx, userID, country); err != nil {
+		msg := fmt.Sprintf("cannot delete country route [%s] for user [%s]", country, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted the country route [%s] for user [%s]", country, userID))
+	return nil
+}
+
+// SelectCountryPhone returns the phone which sends a message without a from number to the contact and the country of the route which selected it.
+// The route of the country of the contact is used and the entities.CountryRouteDefault route is used when the country has no route.
+func (service *PhoneService) SelectCountryPhone(ctx context.Context, userID entities.UserID, contact string) (*entities.Phone, string, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	route, err := service.loadCountryRoute(ctx, userID, RecipientCountry(contact))
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the country route of contact [%s] for user [%s]", contact, userID)
+		return nil, "", service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if route.PhoneGroup != nil {
+		phone, err := service.SelectGroupPhone(ctx, userID, *route.PhoneGroup)
+		if err != nil {
+			msg := fmt.Sprintf("cannot select a phone of group [%s] of country route [%s] for user [%s]", *route.PhoneGroup, route.Country, userID)
+			return nil, "", service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+		}
+		ctxLogger.Info(fmt.Sprintf("selected phone [%s] of group [%s] with country route [%s] for contact [%s]", phone.PhoneNumber, *route.PhoneGroup, route.Country, contact))
+		return phone, route.Country, nil
+	}
+
+	phone, err := service.repository.Load(ctx, userID, *route.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("the phone [%s] of country route [%s] for user [%s] does not exist", *route.Owner, route.Country, userID)
+		return nil, "", service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, ErrCodeCountryRouteNotFound, msg))
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load the phone [%s] of country route [%s] for user [%s]", *route.Owner, route.Country, userID)
+		return nil, "", service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("selected phone [%s] with country route [%s] for contact [%s]", phone.PhoneNumber, route.Country, contact))
+	return phone, route.Country, nil
+}
+
+// loadCountryRoute returns the route of the country and falls back to the entities.CountryRouteDefault route
+func (service *PhoneService) loadCountryRoute(ctx context.Context, userID entities.UserID, country string) (*entities.CountryRoute, error) {
+	if country != "" {
+		route, err := service.routeRepository.Load(ctx, userID, country)
+		if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			return route, err
+		}
+	}
+
+	route, err := service.routeRepository.Load(ctx, userID, entities.CountryRouteDefault)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		msg := fmt.Sprintf("no country route matches the country [%s] and user [%s] has no default route", country, userID)
+		return nil, stacktrace.PropagateWithCode(err, ErrCodeCountryRouteNotFound, msg)
+	}
+	return route, err
+}
+
+// PhoneBulkStoreResult is the result of registering a single phone in PhoneService.BulkStore
+type PhoneBulkStoreResult struct {
+	PhoneNumber string          `json:"phone_number" example:"+18005550199"`
+	Success     bool            `json:"success" example:"true"`
+	Error       *string         `json:"error" example:"a phone with this number already exists"`
+	Phone       *entities.Phone `json:"phone"`
+}
+
+// BulkStore registers multiple phones in a single transaction. No phone is created when at least 1 phone is a
+// duplicate in the batch or already exists, in that case ErrCodePhoneBulkStoreRejected is returned with the results.
+func (service *PhoneService) BulkStore(ctx context.Context, params []*PhoneUpsertParams) ([]PhoneBulkStoreResult, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	results := make([]PhoneBulkStoreResult, len(params))
+	phones := make([]*entities.Phone, len(params))
+	seen := map[string]struct{}{}
+	seenExternalIDs := map[string]struct{}{}
+	rejected := 0
+
+	for index, item := range params {
+		phoneNumber := phonenumbers.Format(item.PhoneNumber, phonenumbers.E164)
+		results[index] = PhoneBulkStoreResult{PhoneNumber: phoneNumber, Success: true}
+
+		if _, ok := seen[phoneNumber]; ok {
+			results[index].Success, results[index].Error = false, service.stringPointer("this phone number is a duplicate in the batch")
+			rejected++
+			continue
+		}
+		seen[phoneNumber] = struct{}{}
+
+		_, err := service.repository.Load(ctx, item.UserID, phoneNumber)
+		if err == nil {
+			results[index].Success, results[index].Error = false, service.stringPointer("a phone with this number already exists")
+			rejected++
+			continue
+		}
+
+		if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			msg := fmt.Sprintf("cannot load phone with number [%s] for user [%s]", phoneNumber, item.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+
+		phones[index] = service.newPhone(item)
+		if externalID := phones[index].ExternalID; externalID != nil {
+			if _, ok := seenExternalIDs[*externalID]; ok {
+				results[index].Success, results[index].Error = false, service.stringPointer("this external ID is a duplicate in the batch")
+				rejected++
+				continue
+			}
+			seenExternalIDs[*externalID] = struct{}{}
+		}
+
+		if err = service.validateExternalID(ctx, phones[index]); stacktrace.GetCode(err) == ErrCodePhoneExternalIDConflict {
+			results[index].Success, results[index].Error = false, service.stringPointer("a phone with this external ID already exists")
+			rejected++
+			continue
+		}
+
+		if err != nil {
+			msg := fmt.Sprintf("cannot validate the external ID of phone with number [%s] for user [%s]", phoneNumber, item.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	if rejected > 0 {
+		msg := fmt.Sprintf("[%d] out of [%d] phones cannot be registered", rejected, len(params))
+		return results, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodePhoneBulkStoreRejected, msg))
+	}
+
+	if err := service.repository.StoreMany(ctx, phones); err != nil {
+		msg := fmt.Sprintf("cannot store [%d] phones", len(phones))
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	for index, phone := range phones {
+		results[index].Phone = phone.SetDailyQuotaRemaining(time.Now().UTC())
+		if err := service.dispatchPhoneUpdatedEvent(ctx, params[index].Source, phone); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch event for phone [%s]", phone.ID)))
+		}
+	}
+
+	ctxLogger.Info(fmt.Sprintf("registered [%d] phones in the phone repository", len(phones)))
+	return results, nil
+}
+
+// validateExternalID checks that the external ID of the phone is not used by another phone of the user
+func (service *PhoneService) validateExternalID(ctx context.Context, phone *entities.Phone) error {
+	if phone.ExternalID == nil {
+		return nil
+	}
+
+	existing, err := service.repository.LoadByExternalID(ctx, phone.UserID, *phone.ExternalID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load phone with external ID [%s] for user [%s]", *phone.ExternalID, phone.UserID))
+	}
+
+	if existing.ID != phone.ID {
+		return stacktrace.NewErrorWithCode(ErrCodePhoneExternalIDConflict, fmt.Sprintf("the external ID [%s] is already used by phone [%s] of user [%s]", *phone.ExternalID, existing.ID, phone.UserID))
+	}
+	return nil
+}
+
+// validateAlphanumericSenderID checks that the alphanumeric sender ID of the phone is not used by another phone of the user
+func (service *PhoneService) validateAlphanumericSenderID(ctx context.Context, phone *entities.Phone) error {
+	if phone.AlphanumericSenderID == nil {
+		return nil
+	}
+
+	existing, err := service.repository.LoadByAlphanumericSenderID(ctx, phone.UserID, *phone.AlphanumericSenderID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return nil
+	}
+
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot load phone with alphanumeric sender ID [%s] for user [%s]", *phone.AlphanumericSenderID, phone.UserID))
+	}
+
+	if existing.ID != phone.ID {
+		return stacktrace.NewErrorWithCode(ErrCodePhoneSenderIDConflict, fmt.Sprintf("the alphanumeric sender ID [%s] is already used by phone [%s] of user [%s]", *phone.AlphanumericSenderID, existing.ID, phone.UserID))
+	}
+	return nil
+}
+
+func (service *PhoneService) stringPointer(value string) *string {
+	return &value
+}
+
+func (service *PhoneService) createPhone(ctx context.Context, params *PhoneUpsertParams) (*entities.Phone, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone := service.newPhone(params)
+	if err := service.validateExternalID(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot create phone with number [%s] and external ID [%s]", phone.PhoneNumber, *phone.ExternalID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Save(ctx, phone); err != nil {
+		msg := fmt.Sprintf("cannot create phone with id [%s] and number [%s]", phone.ID, phone.PhoneNumber)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("phone updated with id [%s] in the phone repository for user [%s]", phone.ID, phone.UserID))
+	return service.setStatus(ctx, phone), service.dispatchPhoneUpdatedEvent(ctx, params.Source, phone)
+}
+
+func (service *PhoneService) newPhone(params *PhoneUpsertParams) *entities.Phone {
+	phone := &entities.Phone{
+		ID:       uuid.New(),
+		UserID:   params.UserID,
+		FcmToken: params.FcmToken,
+		// Android has a limit of 30 SMS messages per minute without user permission, to be safe let's use 10 messages per minute
+		// https://android.googlesource.com/platform/frameworks/opt/telephony/+/master/src/java/com/android/internal/telephony/SmsUsageMonitor.java#80
+		MessagesPerMinute:        10,
+		MessageExpirationSeconds: 10 * 60, // 10 minutes
+		MaxSendAttempts:          2,
+		BatteryLowThreshold:      20,
+		SIM:                      params.SIM,
+		Type:                     entities.PhoneTypeAndroid,
+		MissedCallAutoReply:      nil,
+		PhoneNumber:              phonenumbers.Format(params.PhoneNumber, phonenumbers.E164),
+		CreatedAt:                time.Now().UTC(),
+		UpdatedAt:                time.Now().UTC(),
+		DailyQuotaTimezone:       time.UTC.String(),
+		Timezone:                 time.UTC.String(),
+	}
+
+	if params.FcmToken != nil {
+		phone.FcmTokenRefreshedAt = &phone.CreatedAt
+	}
+
+	if params.DailyQuota != nil {
+		phone.DailyQuota = *params.DailyQuota
+	}
+
+	if params.DailyQuotaTimezone != nil {
+		phone.DailyQuotaTimezone = *params.DailyQuotaTimezone
+	}
+
+	if params.Group != nil {
+		phone.Group = params.Group
+	}
+
+	if params.GroupPriority != nil {
+		phone.GroupPriority = *params.GroupPriority
+	}
+
+	if params.ShortCodesDisabled != nil {
+		phone.ShortCodesDisabled = *params.ShortCodesDisabled
+	}
+
+	if params.RcsEnabled != nil {
+		phone.RcsEnabled = *params.RcsEnabled
+	}
+
+	if params.HeartbeatInterval != nil {
+		phone.HeartbeatIntervalSeconds = uint(params.HeartbeatInterval.Seconds())
+	}
+
+	if params.MaxConcurrentSends != nil {
+		phone.MaxConcurrentSends = *params.MaxConcurrentSends
+	}
+
+	if params.DeliveryReportTimeout != nil {
+		phone.DeliveryReportTimeoutSeconds = uint(params.DeliveryReportTimeout.Seconds())
+	}
+
+	if params.AllowedRecipients != nil {
+		phone.AllowedRecipients = *params.AllowedRecipients
+	}
+
+	if params.Timezone != nil {
+		phone.Timezone = *params.Timezone
+	}
+
+	if params.BusinessHours != nil {
+		phone.BusinessHours = *params.BusinessHours
+	}
+
+	if params.OutOfOfficeMessage != nil {
+		phone.OutOfOfficeMessage = params.OutOfOfficeMessage
+	}
+
+	if params.Signature != nil {
+		phone.Signature = params.Signature
+	}
+
+	if params.ExternalID != nil {
+		phone.ExternalID = params.ExternalID
+	}
+
+	if params.Type != nil {
+		phone.Type = *params.Type
+	}
+
+	if params.VirtualURL != nil {
+		phone.VirtualURL = params.VirtualURL
+	}
+
+	return phone
+}
+
+func (service *PhoneService) createPhoneUpdatedEvent(source string, payload events.PhoneUpdatedPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypePhoneUpdated, source, payload)
+}
+
+func (service *PhoneService) createPhoneDeletedEvent(source string, payload events.PhoneDeletedPayload) (cloudevents.Event, error) {
+	return service.createEvent(events.EventTypePhoneDeleted, source, payload)
+}
+
+func (service *PhoneService) update(phone *entities.Phone, params *PhoneUpsertParams) *entities.Phone {
+	if params.FcmToken != nil && (phone.FcmToken == nil || *phone.FcmToken != *params.FcmToken) {
+		timestamp := time.Now().UTC()
+		phone.FcmTokenRefreshedAt = &timestamp
+		phone.FcmTokenInvalidAt = nil
+		phone.FcmToken = params.FcmToken
+	}
+	if phone.FcmToken != nil {
+		phone.FcmToken = params.FcmToken
+	}
+	if params.MessagesPerMinute != nil && *params.MessagesPerMinute > 0 {
+		phone.MessagesPerMinute = *params.MessagesPerMinute
+	}
+
+	if params.MaxSendAttempts != nil && *params.MaxSendAttempts > 0 {
+		phone.MaxSendAttempts = *params.MaxSendAttempts
+	}
+
+	if params.BatteryLowThreshold != nil && *params.BatteryLowThreshold > 0 {
+		phone.BatteryLowThreshold = *params.BatteryLowThreshold
+	}
+
+	if params.MessageExpirationDuration != nil {
+		phone.MessageExpirationSeconds = uint(params.MessageExpirationDuration.Seconds())
+	}
+
+	if params.MissedCallAutoReply != nil {
+		phone.MissedCallAutoReply = params.MissedCallAutoReply
+	}
+
+	if params.AutoAckMessage != nil {
+		phone.AutoAckMessage = params.AutoAckMessage
+	}
+
+	if params.AlphanumericSenderID != nil {
+		phone.AlphanumericSenderID = params.AlphanumericSenderID
+	}
+
+	if params.DailyQuota != nil {
+		phone.DailyQuota = *params.DailyQuota
+	}
+
+	if params.DailyQuotaTimezone != nil {
+		phone.DailyQuotaTimezone = *params.DailyQuotaTimezone
+	}
+
+	if params.Group != nil {
+		phone.Group = params.Group
+	}
+
+	if params.GroupPriority != nil {
+		phone.GroupPriority = *params.GroupPriority
+	}
+
+	if params.ShortCodesDisabled != nil {
+		phone.ShortCodesDisabled = *params.ShortCodesDisabled
+	}
+
+	if params.RcsEnabled != nil {
+		phone.RcsEnabled = *params.RcsEnabled
+	}
+
+	if params.HeartbeatInterval != nil {
+		phone.HeartbeatIntervalSeconds = uint(params.HeartbeatInterval.Seconds())
+	}
+
+	if params.MaxConcurrentSends != nil {
+		phone.MaxConcurrentSends = *params.MaxConcurrentSends
+	}
+
+	if params.DeliveryReportTimeout != nil {
+		phone.DeliveryReportTimeoutSeconds = uint(params.DeliveryReportTimeout.Seconds())
+	}
+
+	if params.AllowedRecipients != nil {
+		phone.AllowedRecipients = *params.AllowedRecipients
+	}
+
+	if params.Timezone != nil {
+		phone.Timezone = *params.Timezone
+	}
+
+	if params.BusinessHours != nil {
+		phone.BusinessHours = *params.BusinessHours
+	}
+
+	if params.OutOfOfficeMessage != nil {
+		phone.OutOfOfficeMessage = params.OutOfOfficeMessage
+	}
+
+	if params.Signature != nil {
+		phone.Signature = params.Signature
+	}
+
+	if params.ExternalID != nil {
+		phone.ExternalID = params.ExternalID
+	}
+
+	if params.Type != nil {
+		phone.Type = *params.Type
+	}
+
+	if params.VirtualURL != nil {
+		phone.VirtualURL = params.VirtualURL
+	}
+
+	phone.SIM = params.SIM
+
+	return phone
+}
+
+func (service *PhoneService) patch(phone *entities.Phone, params *PhonePatchParams) *entities.Phone {
+	if params.MessagesPerMinute != nil {
+		phone.MessagesPerMinute = *params.MessagesPerMinute
+	}
+
+	if params.MessageExpirationDuration != nil {
+		phone.MessageExpirationSeconds = uint(params.MessageExpirationDuration.Seconds())
+	}
+
+	if params.MaxSendAttempts != nil {
+		phone.MaxSendAttempts = *params.MaxSendAttempts
+	}
+
+	if params.BatteryLowThreshold != nil {
+		phone.BatteryLowThreshold = *params.BatteryLowThreshold
+	}
+
+	if params.DailyQuota != nil {
+		phone.DailyQuota = *params.DailyQuota
+	}
+
+	if params.DailyQuotaTimezone != nil {
+		phone.DailyQuotaTimezone = *params.DailyQuotaTimezone
+	}
+
+	if params.SIM != nil {
+		phone.SIM = *params.SIM
+	}
+
+	if params.HeartbeatInterval != nil {
+		phone.HeartbeatIntervalSeconds = uint(params.HeartbeatInterval.Seconds())
+	}
+
+	if params.MaxConcurrentSends != nil {
+		phone.MaxConcurrentSends = *params.MaxConcurrentSends
+	}
+
+	if params.DeliveryReportTimeout != nil {
+		phone.DeliveryReportTimeoutSeconds = uint(params.DeliveryReportTimeout.Seconds())
+	}
+
+	if params.Timezone != nil {
+		phone.Timezone = *params.Timezone
+	}
+
+	if params.GroupPriority != nil {
+		phone.GroupPriority = *params.GroupPriority
+	}
+
+	if params.ShortCodesDisabled != nil {
+		phone.ShortCodesDisabled = *params.ShortCodesDisabled
+	}
+
+	if params.RcsEnabled != nil {
+		phone.RcsEnabled = *params.RcsEnabled
+	}
+
+	if params.MissedCallAutoReply != nil {
+		phone.MissedCallAutoReply = service.emptyToNil(*params.MissedCallAutoReply)
+	}
+
+	if params.AutoAckMessage != nil {
+		phone.AutoAckMessage = service.emptyToNil(*params.AutoAckMessage)
+	}
+
+	if params.AlphanumericSenderID != nil {
+		phone.AlphanumericSenderID = service.emptyToNil(*params.AlphanumericSenderID)
+	}
+
+	if params.Group != nil {
+		phone.Group = service.emptyToNil(*params.Group)
+	}
+
+	if params.AllowedRecipients != nil {
+		phone.AllowedRecipients = *params.AllowedRecipients
+	}
+
+	if params.BusinessHours != nil {
+		phone.BusinessHours = *params.BusinessHours
+	}
+
+	if params.OutOfOfficeMessage != nil {
+		phone.OutOfOfficeMessage = service.emptyToNil(*params.OutOfOfficeMessage)
+	}
+
+	if params.Signature != nil {
+		phone.Signature = service.emptyToNil(*params.Signature)
+	}
+
+	if params.ExternalID != nil {
+		phone.ExternalID = service.emptyToNil(*params.ExternalID)
+	}
+
+	if params.Type != nil {
+		phone.Type = *params.Type
+	}
+
+	if params.VirtualURL != nil {
+		phone.VirtualURL = service.emptyToNil(*params.VirtualURL)
+	}
+
+	return phone
+}
+
+// emptyToNil removes the value of a nullable field when it is empty
+func (service *PhoneService) emptyToNil(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
 }
@@ -0,0 +1,360 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// ProviderFallbackService sends the messages of a user with a third-party SMS provider when no phone picks them up within the timeout of the provider
+type ProviderFallbackService struct {
+	service
+	logger            telemetry.Logger
+	tracer            telemetry.Tracer
+	repository        repositories.ProviderFallbackRepository
+	messageRepository repositories.MessageRepository
+	groupRepository   repositories.PhoneGroupRepository
+	eventDispatcher   *EventDispatcher
+	providers         map[entities.SmsProviderName]SmsProvider
+
+	// cipher encrypts the auth token of the provider, the fallback cannot be configured when it is nil
+	cipher *Cipher
+
+	// userService checks the entities.FeatureFlagProviderFallback feature flag of the user
+	userService *UserService
+}
+
+// NewProviderFallbackService creates a new ProviderFallbackService
+func NewProviderFallbackService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.ProviderFallbackRepository,
+	messageRepository repositories.MessageRepository,
+	groupRepository repositories.PhoneGroupRepository,
+	dispatcher *EventDispatcher,
+	providers []SmsProvider,
+	cipher *Cipher,
+	userService *UserService,
+) (s *ProviderFallbackService) {
+	registry := make(map[entities.SmsProviderName]SmsProvider, len(providers))
+	for _, provider := range providers {
+		registry[provider.Name()] = provider
+	}
+
+	return &ProviderFallbackService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		repository:        repository,
+		messageRepository: messageRepository,
+		groupRepository:   groupRepository,
+		eventDispatcher:   dispatcher,
+		providers:         registry,
+		cipher:            cipher,
+		userService:       userService,
+	}
+}
+
+// Enabled checks if a provider fallback can be stored, the auth token is encrypted so the encryption key must be configured
+func (service *ProviderFallbackService) Enabled() bool {
+	return service.cipher != nil
+}
+
+// Load the entities.ProviderFallback of a user
+func (service *ProviderFallbackService) Load(ctx context.Context, userID entities.UserID) (*entities.ProviderFallback, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	fallback, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load provider fallback for user [%s]", userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	return service.setEnabled(ctx, fallback), nil
+}
+
+// setEnabled sets the computed Enabled field of an entities.ProviderFallback from the feature flag of the user
+func (service *ProviderFallbackService) setEnabled(ctx context.Context, fallback *entities.ProviderFallback) *entities.ProviderFallback {
+	fallback.Enabled = service.userService.IsEnabled(ctx, fallback.UserID, entities.FeatureFlagProviderFallback)
+	return fallback
+}
+
+// ProviderFallbackUpsertParams are parameters for setting the entities.ProviderFallback of a user
+type ProviderFallbackUpsertParams struct {
+	UserID    entities.UserID
+	Provider  entities.SmsProviderName
+	AccountID string
+	From      string
+	Timeout   time.Duration
+
+	// AuthToken is not changed when it is empty
+	AuthToken string
+}
+
+// Upsert sets the entities.ProviderFallback of a user
+func (service *ProviderFallbackService) Upsert(ctx context.Context, params *ProviderFallbackUpsertParams) (*entities.ProviderFallback, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	fallback, err := service.repository.Load(ctx, params.UserID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		fallback, err = &entities.ProviderFallback{UserID: params.UserID, CreatedAt: time.Now().UTC()}, nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load provider fallback for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if params.AuthToken != "" {
+		if fallback.EncryptedAuthToken, err = service.encrypt(params.AuthToken); err != nil {
+			msg := fmt.Sprintf("cannot encrypt the auth token of the provider fallback for user [%s]", params.UserID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	fallback.Provider = params.Provider
+	fallback.AccountID = params.AccountID
+	fallback.From = params.From
+	fallback.TimeoutSeconds = uint(params.Timeout.Seconds())
+	fallback.UpdatedAt = time.Now().UTC()
+
+	if err = service.repository.Save(ctx, fallback); err != nil {
+		msg := fmt.Sprintf("cannot save provider fallback for user [%s]", params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("set the provider fallback of user [%s] to [%s] with timeout [%s]", fallback.UserID, fallback.Provider, fallback.Timeout()))
+	return service.setEnabled(ctx, fallback), nil
+}
+
+// Delete the entities.ProviderFallback of a user
+func (service *ProviderFallbackService) Delete(ctx context.Context, userID entities.UserID) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if _, err := service.repository.Load(ctx, userID); err != nil {
+		msg := fmt.Sprintf("cannot load provider fallback for user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if err := service.repository.Delete(ctx, userID); err != nil {
+		msg := fmt.Sprintf("cannot delete provider fallback for user [%s]", userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("deleted the provider fallback of user [%s]", userID))
+	return nil
+}
+
+// ScheduleCheck schedules sending a message with the provider of the user after the timeout of the provider when the message or its phone group opted in
+func (service *ProviderFallbackService) ScheduleCheck(ctx context.Context, source string, payload events.MessageAPISentPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if !payload.ProviderFallback && !service.groupOptedIn(ctx, payload) {
+		return nil
+	}
+
+	if !service.userService.IsEnabled(ctx, payload.UserID, entities.FeatureFlagProviderFallback) {
+		ctxLogger.Info(fmt.Sprintf("the [%s] feature flag is not enabled for user [%s] and message [%s]", entities.FeatureFlagProviderFallback, payload.UserID, payload.MessageID))
+		return nil
+	}
+
+	fallback, err := service.repository.Load(ctx, payload.UserID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("user [%s] has no provider fallback for message [%s]", payload.UserID, payload.MessageID))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load provider fallback for user [%s] and message [%s]", payload.UserID, payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.EventTypeMessageProviderFallbackCheck, source, &events.MessageProviderFallbackCheckPayload{
+		MessageID:   payload.MessageID,
+		ScheduledAt: time.Now().UTC().Add(fallback.Timeout()),
+		UserID:      payload.UserID,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message with id [%s]", events.EventTypeMessageProviderFallbackCheck, payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if _, err = service.eventDispatcher.DispatchWithTimeout(ctx, event, fallback.Timeout()); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for message with ID [%s]", event.Type(), payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("scheduled the [%s] provider fallback of message [%s] in [%s]", fallback.Provider, payload.MessageID, fallback.Timeout()))
+	return nil
+}
+
+// groupOptedIn checks if the phone group of a message sends its messages with the provider of the user
+func (service *ProviderFallbackService) groupOptedIn(ctx context.Context, payload events.MessageAPISentPayload) bool {
+	if payload.PhoneGroup == nil {
+		return false
+	}
+
+	group, err := service.groupRepository.Load(ctx, payload.UserID, *payload.PhoneGroup)
+	if err != nil {
+		if stacktrace.GetCode(err) != repositories.ErrCodeNotFound {
+			service.logger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load phone group [%s] of user [%s]", *payload.PhoneGroup, payload.UserID)))
+		}
+		return false
+	}
+	return group.ProviderFallback
+}
+
+// Send sends a message with the provider of the user when no phone has picked it up, messages which are being sent by a phone or are already sent are not changed
+func (service *ProviderFallbackService) Send(ctx context.Context, source string, payload events.MessageProviderFallbackCheckPayload) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	message, err := service.messageRepository.Load(ctx, payload.UserID, payload.MessageID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("message has been deleted for userID [%s] and messageID [%s]", payload.UserID, payload.MessageID))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load message with userID [%s] and messageID [%s]", payload.UserID, payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !message.IsPending() && !message.IsScheduled() && !message.IsExpired() {
+		ctxLogger.Info(fmt.Sprintf("message [%s] has status [%s] and does not need the provider fallback", message.ID, message.Status))
+		return nil
+	}
+
+	// the content of an encrypted message can only be decrypted by the phone
+	if message.Encrypted {
+		ctxLogger.Info(fmt.Sprintf("message [%s] is encrypted and cannot be sent with the provider fallback", message.ID))
+		return nil
+	}
+
+	fallback, err := service.repository.Load(ctx, message.UserID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("the provider fallback of user [%s] was deleted before sending message [%s]", message.UserID, message.ID))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load provider fallback for user [%s] and message [%s]", message.UserID, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	provider, ok := service.providers[fallback.Provider]
+	if !ok {
+		msg := fmt.Sprintf("the provider [%s] of user [%s] is not supported", fallback.Provider, fallback.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewError(msg))
+	}
+
+	// the message is marked as sending so that a phone which comes online does not send it as well
+	message, err = service.messageRepository.GetOutstanding(ctx, message.UserID, message.ID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("message [%s] was picked up by a phone before the provider fallback", payload.MessageID))
+		return nil
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch outstanding message with userID [%s] and messageID [%s]", payload.UserID, payload.MessageID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	authToken, err := service.decrypt(fallback.EncryptedAuthToken)
+	if err != nil {
+		msg := fmt.Sprintf("cannot decrypt the auth token of the provider fallback for user [%s]", fallback.UserID)
+		return service.dispatchFailed(ctx, source, message, stacktrace.Propagate(err, msg), "the auth token of the SMS provider cannot be decrypted")
+	}
+
+	credentials := SmsProviderCredentials{AccountID: fallback.AccountID, AuthToken: authToken, From: fallback.From}
+	providerMessageID, err := provider.Send(ctx, credentials, message.Contact, message.Content)
+	if err != nil {
+		msg := fmt.Sprintf("cannot send message [%s] with provider [%s]", message.ID, fallback.Provider)
+		return service.dispatchFailed(ctx, source, message, stacktrace.Propagate(err, msg), fmt.Sprintf("the message could not be sent with the %s provider fallback", fallback.Provider))
+	}
+
+	if err = service.messageRepository.Update(ctx, message.SentWithProvider(provider.Name(), providerMessageID)); err != nil {
+		msg := fmt.Sprintf("cannot record provider [%s] on message [%s]", provider.Name(), message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	event, err := service.createEvent(events.EventTypeMessagePhoneSent, source, events.MessagePhoneSentPayload{
+		ID:        message.ID,
+		Owner:     message.Owner,
+		UserID:    message.UserID,
+		RequestID: message.RequestID,
+		Timestamp: time.Now().UTC(),
+		Contact:   message.Contact,
+		Encrypted: message.Encrypted,
+		Content:   message.Content,
+		Metadata:  message.Metadata,
+		SIM:       message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message [%s]", events.EventTypeMessagePhoneSent, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event type [%s] and id [%s]", event.Type(), event.ID())
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("message [%s] sent with provider [%s] and provider message ID [%s]", message.ID, provider.Name(), providerMessageID))
+	return nil
+}
+
+// dispatchFailed fires the events.EventTypeMessageSendFailed event for a message which could not be sent with the provider
+func (service *ProviderFallbackService) dispatchFailed(ctx context.Context, source string, message *entities.Message, sendErr error, errorMessage string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	ctxLogger.Warn(sendErr)
+
+	event, err := service.createEvent(events.EventTypeMessageSendFailed, source, events.MessageSendFailedPayload{
+		ID:           message.ID,
+		Owner:        message.Owner,
+		ErrorMessage: errorMessage,
+		Timestamp:    time.Now().UTC(),
+		Encrypted:    message.Encrypted,
+		Contact:      message.Contact,
+		RequestID:    message.RequestID,
+		UserID:       message.UserID,
+		Content:      message.Content,
+		Metadata:     message.Metadata,
+		SIM:          message.SIM,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for message with id [%s]", events.EventTypeMessageSendFailed, message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.eventDispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for message with ID [%s]", event.Type(), message.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+	return nil
+}
+
+func (service *ProviderFallbackService) encrypt(authToken string) (string, error) {
+	if service.cipher == nil {
+		return "", stacktrace.NewError("cannot encrypt the auth token because the encryption key is not configured")
+	}
+	return service.cipher.Encrypt([]byte(authToken))
+}
+
+func (service *ProviderFallbackService) decrypt(encryptedAuthToken string) (string, error) {
+	if service.cipher == nil {
+		return "", stacktrace.NewError("cannot decrypt the auth token because the encryption key is not configured")
+	}
+
+	plaintext, err := service.cipher.Decrypt(encryptedAuthToken)
+	if err != nil {
+		return "", stacktrace.Propagate(err, "cannot decrypt the auth token")
+	}
+	return string(plaintext), nil
+}
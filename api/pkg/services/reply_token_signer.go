@@ -0,0 +1,111 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// ErrCodeReplyTokenInvalid is thrown when a reply token is malformed, has an invalid signature or has expired
+	ErrCodeReplyTokenInvalid = stacktrace.ErrorCode(1115)
+
+	// ErrCodeReplyTokenUsed is thrown when the reply token of a message has already been used to send a reply
+	ErrCodeReplyTokenUsed = stacktrace.ErrorCode(1128)
+)
+
+// replyTokenTTL is the duration after a message is received during which its reply token can be used
+const replyTokenTTL = 15 * time.Minute
+
+// ReplyTokenClaims is the conversation which is encoded in a reply token
+type ReplyTokenClaims struct {
+	UserID    entities.UserID `json:"uid"`
+	MessageID uuid.UUID       `json:"mid"`
+	Owner     string          `json:"own"`
+	Contact   string          `json:"con"`
+	ExpiresAt int64           `json:"exp"`
+}
+
+// ReplyMessageID is the ID of the only reply which can be sent with the token, it is derived from the received message so a token cannot be used twice
+func (claims *ReplyTokenClaims) ReplyMessageID() uuid.UUID {
+	return uuid.NewSHA1(claims.MessageID, []byte("reply"))
+}
+
+// ReplyTokenSigner signs the reply tokens of received messages with HMAC-SHA256 so that a bot can reply to a message without an API key.
+// A token is the base64 encoded claims and the base64 encoded signature of the claims separated by a dot.
+type ReplyTokenSigner struct {
+	key []byte
+}
+
+// NewReplyTokenSigner creates a new ReplyTokenSigner from a base64 encoded key with at least 32 bytes
+func NewReplyTokenSigner(key string) (*ReplyTokenSigner, error) {
+	secret, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, "cannot decode the base64 reply token key")
+	}
+
+	if len(secret) < 32 {
+		return nil, stacktrace.NewError(fmt.Sprintf("the reply token key must have at least 32 bytes but it has [%d] bytes", len(secret)))
+	}
+
+	return &ReplyTokenSigner{key: secret}, nil
+}
+
+// Sign creates the reply token of a message which was received at the timestamp
+func (signer *ReplyTokenSigner) Sign(userID entities.UserID, messageID uuid.UUID, owner string, contact string, timestamp time.Time) (string, error) {
+	claims, err := json.Marshal(ReplyTokenClaims{
+		UserID:    userID,
+		MessageID: messageID,
+		Owner:     owner,
+		Contact:   contact,
+		ExpiresAt: timestamp.Add(replyTokenTTL).Unix(),
+	})
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot marshal the reply token claims of message [%s]", messageID))
+	}
+
+	payload := base64.RawURLEncoding.EncodeToString(claims)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(signer.signature(payload)), nil
+}
+
+// Verify decodes the claims of a reply token after checking its signature and that it has not expired at the timestamp
+func (signer *ReplyTokenSigner) Verify(token string, timestamp time.Time) (*ReplyTokenClaims, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found {
+		return nil, stacktrace.NewErrorWithCode(ErrCodeReplyTokenInvalid, "the reply token does not have a signature")
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(decoded, signer.signature(payload)) {
+		return nil, stacktrace.NewErrorWithCode(ErrCodeReplyTokenInvalid, "the signature of the reply token is not valid")
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return nil, stacktrace.PropagateWithCode(err, ErrCodeReplyTokenInvalid, "cannot decode the claims of the reply token")
+	}
+
+	claims := new(ReplyTokenClaims)
+	if err = json.Unmarshal(data, claims); err != nil {
+		return nil, stacktrace.PropagateWithCode(err, ErrCodeReplyTokenInvalid, "cannot unmarshal the claims of the reply token")
+	}
+
+	if timestamp.Unix() >= claims.ExpiresAt {
+		return nil, stacktrace.NewErrorWithCode(ErrCodeReplyTokenInvalid, fmt.Sprintf("the reply token of message [%s] expired at [%d]", claims.MessageID, claims.ExpiresAt))
+	}
+	return claims, nil
+}
+
+func (signer *ReplyTokenSigner) signature(payload string) []byte {
+	mac := hmac.New(sha256.New, signer.key)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,115 @@
+package services
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplyTokenSigner_Verify(t *testing.T) {
+	receivedAt := time.Date(2022, 6, 5, 14, 26, 0, 0, time.UTC)
+	messageID := uuid.MustParse("32343a19-da5e-4b1b-a767-3298a73703cb")
+
+	signer, err := NewReplyTokenSigner(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("k", 32))))
+	assert.Nil(t, err)
+	other, err := NewReplyTokenSigner(base64.StdEncoding.EncodeToString([]byte(strings.Repeat("o", 32))))
+	assert.Nil(t, err)
+
+	token, err := signer.Sign("user-id", messageID, "+18005550199", "+18005550100", receivedAt)
+	assert.Nil(t, err)
+	otherToken, err := other.Sign("user-id", messageID, "+18005550199", "+18005550100", receivedAt)
+	assert.Nil(t, err)
+
+	payload, signature, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte(`{"uid":"attacker","mid":"32343a19-da5e-4b1b-a767-3298a73703cb","own":"+18005550199","con":"+18005550100","exp":9999999999}`))
+
+	tests := []struct {
+		name      string
+		token     string
+		timestamp time.Time
+		valid     bool
+	}{
+		{name: "a token is valid before it expires", token: token, timestamp: receivedAt.Add(replyTokenTTL - time.Second), valid: true},
+		{name: "a token is not valid when it expires", token: token, timestamp: receivedAt.Add(replyTokenTTL)},
+		{name: "a token without a signature is not valid", token: payload, timestamp: receivedAt},
+		{name: "a token with changed claims is not valid", token: forged + "." + signature, timestamp: receivedAt},
+		{name: "a token signed with another key is not valid", token: otherToken, timestamp: receivedAt},
+		{name: "a token with a malformed signature is not valid", token: payload + ".!!", timestamp: receivedAt},
+		{name: "a signed token with malformed claims is not valid", token: "!!." + base64.RawURLEncoding.EncodeToString(signer.signature("!!")), timestamp: receivedAt},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			claims, err := signer.Verify(tt.token, tt.timestamp)
+
+			// Assert
+			if !tt.valid {
+				assert.Nil(t, claims)
+				assert.Equal(t, ErrCodeReplyTokenInvalid, stacktrace.GetCode(err))
+				return
+			}
+
+			assert.Nil(t, err)
+			assert.Equal(t, messageID, claims.MessageID)
+			assert.Equal(t, "+18005550199", claims.Owner)
+			assert.Equal(t, "+18005550100", claims.Contact)
+		})
+	}
+}
+
+func TestNewReplyTokenSigner(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   string
+		valid bool
+	}{
+		{name: "a key with 32 bytes is valid", key: base64.StdEncoding.EncodeToString(make([]byte, 32)), valid: true},
+		{name: "a key with less than 32 bytes is not valid", key: base64.StdEncoding.EncodeToString(make([]byte, 31))},
+		{name: "a key which is not base64 is not valid", key: "not base64!"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			// Setup
+			t.Parallel()
+
+			// Act
+			signer, err := NewReplyTokenSigner(tt.key)
+
+			// Assert
+			assert.Equal(t, tt.valid, err == nil)
+			assert.Equal(t, tt.valid, signer != nil)
+		})
+	}
+}
+
+func TestReplyTokenClaims_ReplyMessageID(t *testing.T) {
+	t.Run("a token can only send the reply with the ID derived from the message", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+
+		// Arrange
+		first := &ReplyTokenClaims{MessageID: uuid.MustParse("32343a19-da5e-4b1b-a767-3298a73703cb"), ExpiresAt: 1}
+		second := &ReplyTokenClaims{MessageID: uuid.MustParse("32343a19-da5e-4b1b-a767-3298a73703cb"), ExpiresAt: 2}
+		other := &ReplyTokenClaims{MessageID: uuid.MustParse("32343a19-da5e-4b1b-a767-3298a73703ca")}
+
+		// Act
+		id := first.ReplyMessageID()
+
+		// Assert
+		assert.Equal(t, id, second.ReplyMessageID())
+		assert.NotEqual(t, id, other.ReplyMessageID())
+		assert.NotEqual(t, first.MessageID, id)
+	})
+}
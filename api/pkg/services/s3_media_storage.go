@@ -0,0 +1,116 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/palantir/stacktrace"
+)
+
+type s3MediaStorage struct {
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+	client *s3.Client
+	bucket string
+}
+
+// NewS3MediaStorage creates a MediaStorage which saves files in an S3 compatible bucket e.g. AWS S3, Cloudflare R2 or MinIO.
+// The objects are addressed with path style URLs e.g. https://s3.us-east-1.amazonaws.com/{bucket}/{key} and the AWS endpoint is used when the endpoint is empty
+func NewS3MediaStorage(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	client *http.Client,
+	endpoint string,
+	region string,
+	bucket string,
+	accessKeyID string,
+	secretAccessKey string,
+) MediaStorage {
+	options := s3.Options{
+		Region:       region,
+		Credentials:  credentials.NewStaticCredentialsProvider(accessKeyID, secretAccessKey, ""),
+		HTTPClient:   client,
+		UsePathStyle: true,
+	}
+	if endpoint != "" {
+		options.BaseEndpoint = aws.String(strings.TrimRight(endpoint, "/"))
+	}
+
+	return &s3MediaStorage{
+		logger: logger.WithService(fmt.Sprintf("%T", s3MediaStorage{})),
+		tracer: tracer,
+		client: s3.New(options),
+		bucket: bucket,
+	}
+}
+
+// Store saves the content of a media file with the key
+func (storage *s3MediaStorage) Store(ctx context.Context, key string, contentType string, content []byte) error {
+	ctx, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	_, err := storage.client.PutObject(ctx, &s3.PutObjectInput{
+		Bucket:        aws.String(storage.bucket),
+		Key:           aws.String(key),
+		Body:          bytes.NewReader(content),
+		ContentType:   aws.String(contentType),
+		ContentLength: aws.Int64(int64(len(content))),
+	})
+	if err != nil {
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot write media file [%s] to S3", key)))
+	}
+	return nil
+}
+
+// Load reads length bytes of a media file starting at offset, a negative length reads until the end of the file
+func (storage *s3MediaStorage) Load(ctx context.Context, key string, offset int64, length int64) (io.ReadCloser, error) {
+	ctx, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	if length == 0 {
+		return io.NopCloser(bytes.NewReader(nil)), nil
+	}
+
+	input := &s3.GetObjectInput{
+		Bucket: aws.String(storage.bucket),
+		Key:    aws.String(key),
+	}
+	if length > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-%d", offset, offset+length-1))
+	} else if offset > 0 {
+		input.Range = aws.String(fmt.Sprintf("bytes=%d-", offset))
+	}
+
+	output, err := storage.client.GetObject(ctx, input)
+	if err != nil {
+		return nil, storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot read media file [%s] from S3", key)))
+	}
+	return output.Body, nil
+}
+
+// Delete removes the media file with the key, it does not fail when the file does not exist
+func (storage *s3MediaStorage) Delete(ctx context.Context, key string) error {
+	ctx, span := storage.tracer.Start(ctx)
+	defer span.End()
+
+	_, err := storage.client.DeleteObject(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(storage.bucket),
+		Key:    aws.String(key),
+	})
+
+	var notFound *types.NoSuchKey
+	if err != nil && !errors.As(err, &notFound) {
+		return storage.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot delete media file [%s] from S3", key)))
+	}
+	return nil
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"context"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+)
+
+// SmsProviderCredentials are the credentials of the account of a user at a third-party SMS provider
+type SmsProviderCredentials struct {
+	AccountID string
+	AuthToken string
+	From      string
+}
+
+// SmsProvider sends messages with a third-party SMS provider when none of the phones of a user can send them
+type SmsProvider interface {
+	// Name is the name of the provider which is recorded on the messages it sends
+	Name() entities.SmsProviderName
+
+	// Send the content to the contact with the account of the credentials and return the ID of the message at the provider
+	Send(ctx context.Context, credentials SmsProviderCredentials, contact string, content string) (string, error)
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"strings"
+	"unicode/utf16"
+)
+
+const (
+	// SMSEncodingGSM7 is the default 7 bit alphabet which fits 160 characters in a single segment
+	SMSEncodingGSM7 = "GSM-7"
+
+	// SMSEncodingUCS2 is used when the content has a character which is not in the GSM-7 alphabet e.g. an emoji
+	SMSEncodingUCS2 = "UCS-2"
+)
+
+// gsm7Alphabet is the GSM 03.38 basic character set
+const gsm7Alphabet = "@£$¥èéùìòÇ\nØø\rÅåΔ_ΦΓΛΩΠΨΣΘΞÆæßÉ !\"#¤%&'()*+,-./0123456789:;<=>?¡ABCDEFGHIJKLMNOPQRSTUVWXYZÄÖÑÜ§¿abcdefghijklmnopqrstuvwxyzäöñüà"
+
+// gsm7Extension are the characters which need an escape character so they take 2 septets
+const gsm7Extension = "^{}\\[~]|€\f"
+
+// CountSMSSegments returns the number of SMS segments needed to send the content and the encoding used
+func CountSMSSegments(content string) (segments uint, encoding string) {
+	if content == "" {
+		return 0, SMSEncodingGSM7
+	}
+
+	if septets, ok := countGSM7Septets(content); ok {
+		return countSegments(septets, 160, 153), SMSEncodingGSM7
+	}
+
+	return countSegments(uint(len(utf16.Encode([]rune(content)))), 70, 67), SMSEncodingUCS2
+}
+
+func countGSM7Septets(content string) (uint, bool) {
+	var septets uint
+	for _, char := range content {
+		switch {
+		case strings.ContainsRune(gsm7Alphabet, char):
+			septets++
+		case strings.ContainsRune(gsm7Extension, char):
+			septets += 2
+		default:
+			return 0, false
+		}
+	}
+	return septets, true
+}
+
+func countSegments(length uint, singleLimit uint, multipartLimit uint) uint {
+	if length <= singleLimit {
+		return 1
+	}
+	return (length + multipartLimit - 1) / multipartLimit
+}
@@ -0,0 +1,67 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/palantir/stacktrace"
+)
+
+// twilioBaseURL is the base URL of the Twilio REST API
+const twilioBaseURL = "https://api.twilio.com/2010-04-01"
+
+// TwilioSmsProvider sends messages with the Twilio messaging API
+type TwilioSmsProvider struct {
+	client *http.Client
+}
+
+// NewTwilioSmsProvider creates a new TwilioSmsProvider
+func NewTwilioSmsProvider(client *http.Client) *TwilioSmsProvider {
+	return &TwilioSmsProvider{client: client}
+}
+
+// Name is the name of the provider which is recorded on the messages it sends
+func (provider *TwilioSmsProvider) Name() entities.SmsProviderName {
+	return entities.SmsProviderTwilio
+}
+
+// Send the content to the contact with the Twilio account of the credentials and return the SID of the message
+func (provider *TwilioSmsProvider) Send(ctx context.Context, credentials SmsProviderCredentials, contact string, content string) (string, error) {
+	form := url.Values{}
+	form.Set("To", contact)
+	form.Set("From", credentials.From)
+	form.Set("Body", content)
+
+	endpoint := fmt.Sprintf("%s/Accounts/%s/Messages.json", twilioBaseURL, url.PathEscape(credentials.AccountID))
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot create twilio request for account [%s]", credentials.AccountID))
+	}
+	request.SetBasicAuth(credentials.AccountID, credentials.AuthToken)
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot send twilio request for account [%s]", credentials.AccountID))
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	payload := new(struct {
+		SID     string `json:"sid"`
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	})
+	if err = json.NewDecoder(response.Body).Decode(payload); err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot decode twilio response with status code [%d]", response.StatusCode))
+	}
+
+	if response.StatusCode >= 400 {
+		return "", stacktrace.NewError(fmt.Sprintf("twilio responded with status code [%d] and error [%d]: %s", response.StatusCode, payload.Code, payload.Message))
+	}
+	return payload.SID, nil
+}
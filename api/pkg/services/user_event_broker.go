@@ -0,0 +1,67 @@
+package services
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+)
+
+// userEventBrokerBufferSize is the number of events buffered for a subscriber, new events are dropped when a subscriber falls behind
+const userEventBrokerBufferSize = 32
+
+// UserEventBroker publishes the stored events of a user to the subscribers which are connected to this instance of the API e.g. long-poll requests
+type UserEventBroker struct {
+	logger      telemetry.Logger
+	mutex       sync.RWMutex
+	subscribers map[entities.UserID]map[chan *entities.EventLog]struct{}
+}
+
+// NewUserEventBroker creates a new UserEventBroker
+func NewUserEventBroker(logger telemetry.Logger) (b *UserEventBroker) {
+	return &UserEventBroker{
+		logger:      logger.WithService(fmt.Sprintf("%T", b)),
+		subscribers: map[entities.UserID]map[chan *entities.EventLog]struct{}{},
+	}
+}
+
+// Subscribe returns a channel which receives the events of the user, the returned function must be called to stop receiving events
+func (broker *UserEventBroker) Subscribe(userID entities.UserID) (<-chan *entities.EventLog, func()) {
+	events := make(chan *entities.EventLog, userEventBrokerBufferSize)
+
+	broker.mutex.Lock()
+	if broker.subscribers[userID] == nil {
+		broker.subscribers[userID] = map[chan *entities.EventLog]struct{}{}
+	}
+	broker.subscribers[userID][events] = struct{}{}
+	broker.mutex.Unlock()
+
+	var once sync.Once
+	return events, func() {
+		once.Do(func() {
+			broker.mutex.Lock()
+			defer broker.mutex.Unlock()
+
+			delete(broker.subscribers[userID], events)
+			if len(broker.subscribers[userID]) == 0 {
+				delete(broker.subscribers, userID)
+			}
+		})
+	}
+}
+
+// Publish sends an event to all the subscribers of the user without blocking, the event is dropped for a subscriber whose buffer is full
+func (broker *UserEventBroker) Publish(log *entities.EventLog) {
+	broker.mutex.RLock()
+	defer broker.mutex.RUnlock()
+
+	for subscriber := range broker.subscribers[log.UserID] {
+		select {
+		case subscriber <- log:
+		default:
+			broker.logger.Warn(stacktrace.NewError(fmt.Sprintf("dropped [%s] event log with ID [%s] for a subscriber of user [%s] which has fallen behind", log.Type, log.ID, log.UserID)))
+		}
+	}
+}
@@ -3,6 +3,7 @@ package services
 import (
 	"context"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/events"
@@ -18,6 +19,9 @@ import (
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 )
 
+// ErrCodeUserSubAccountNested is returned when a sub-account is created under another sub-account
+const ErrCodeUserSubAccountNested = stacktrace.ErrorCode(1118)
+
 // UserService is handles user requests
 type UserService struct {
 	service
@@ -29,6 +33,9 @@ type UserService struct {
 	dispatcher         *EventDispatcher
 	marketingService   *MarketingService
 	lemonsqueezyClient *lemonsqueezy.Client
+
+	// adminUserIDs are the users who can set the feature flags of other users
+	adminUserIDs []entities.UserID
 }
 
 // NewUserService creates a new UserService
@@ -41,6 +48,7 @@ func NewUserService(
 	marketingService *MarketingService,
 	lemonsqueezyClient *lemonsqueezy.Client,
 	dispatcher *EventDispatcher,
+	adminUserIDs []entities.UserID,
 ) (s *UserService) {
 	return &UserService{
 		logger:             logger.WithService(fmt.Sprintf("%T", s)),
@@ -51,6 +59,7 @@ func NewUserService(
 		repository:         repository,
 		dispatcher:         dispatcher,
 		lemonsqueezyClient: lemonsqueezyClient,
+		adminUserIDs:       adminUserIDs,
 	}
 }
 
@@ -86,10 +95,78 @@ func (service *UserService) GetByID(ctx context.Context, userID entities.UserID)
 	return user, nil
 }
 
+// CreateSubAccount creates a sub-account of a main account with its own API key, phones and messages
+func (service *UserService) CreateSubAccount(ctx context.Context, parentUserID entities.UserID, name string) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	parent, err := service.repository.Load(ctx, parentUserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not get [%T] with ID [%s]", parent, parentUserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if parent.IsSubAccount() {
+		msg := fmt.Sprintf("user [%s] is a sub-account of [%s] and it cannot have sub-accounts", parent.ID, *parent.ParentUserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUserSubAccountNested, msg))
+	}
+
+	user, err := service.repository.StoreSubAccount(ctx, parent, name)
+	if err != nil {
+		msg := fmt.Sprintf("could not create sub-account [%s] of user [%s]", name, parent.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created sub-account [%s] of user [%s]", user.ID, parent.ID))
+	return user, nil
+}
+
+// GetSubAccounts fetches the sub-accounts of a main account
+func (service *UserService) GetSubAccounts(ctx context.Context, parentUserID entities.UserID) ([]*entities.User, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	users, err := service.repository.IndexSubAccounts(ctx, parentUserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not fetch the sub-accounts of user [%s]", parentUserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	return users, nil
+}
+
+// syncSubAccounts gives the sub-accounts of a main account the same plan as the main account after its subscription changes
+func (service *UserService) syncSubAccounts(ctx context.Context, user *entities.User) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if user.IsSubAccount() {
+		return
+	}
+
+	if err := service.repository.UpdateSubAccountSubscriptions(ctx, user); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot update the subscription of the sub-accounts of user [%s]", user.ID)))
+	}
+}
+
 // UserUpdateParams are parameters for updating an entities.User
 type UserUpdateParams struct {
 	Timezone      *time.Location
 	ActivePhoneID *uuid.UUID
+	Locale        string
+
+	// LanguageDetectionEnabled is not changed when it is nil
+	LanguageDetectionEnabled *bool
+
+	// DefaultCountry is not changed when it is nil and it is removed when it is empty
+	DefaultCountry *string
+
+	// InboundContentMaxLength and InboundContentLimitMode are not changed when they are nil
+	InboundContentMaxLength *uint
+	InboundContentLimitMode *entities.InboundContentLimitMode
+
+	// GetSendEnabled is not changed when it is nil
+	GetSendEnabled *bool
 }
 
 // Update an entities.User
@@ -111,6 +188,27 @@ func (service *UserService) Update(ctx context.Context, authUser entities.AuthUs
 
 	user.Timezone = params.Timezone.String()
 	user.ActivePhoneID = params.ActivePhoneID
+	if params.Locale != "" {
+		user.Locale = params.Locale
+	}
+	if params.LanguageDetectionEnabled != nil {
+		user.LanguageDetectionEnabled = *params.LanguageDetectionEnabled
+	}
+	if params.DefaultCountry != nil {
+		user.DefaultCountry = params.DefaultCountry
+		if *params.DefaultCountry == "" {
+			user.DefaultCountry = nil
+		}
+	}
+	if params.InboundContentMaxLength != nil {
+		user.InboundContentMaxLength = *params.InboundContentMaxLength
+	}
+	if params.InboundContentLimitMode != nil {
+		user.InboundContentLimitMode = *params.InboundContentLimitMode
+	}
+	if params.GetSendEnabled != nil {
+		user.GetSendEnabled = *params.GetSendEnabled
+	}
 
 	if err = service.repository.Update(ctx, user); err != nil {
 		msg := fmt.Sprintf("cannot save user with id [%s]", user.ID)
@@ -121,6 +219,75 @@ func (service *UserService) Update(ctx context.Context, authUser entities.AuthUs
 	return user, nil
 }
 
+// UserPatchParams are parameters for updating some fields of an entities.User, the fields which are nil are not changed
+type UserPatchParams struct {
+	Timezone *time.Location
+	Locale   *string
+
+	// ActivePhoneID is removed when it is uuid.Nil
+	ActivePhoneID *uuid.UUID
+
+	LanguageDetectionEnabled *bool
+
+	// DefaultCountry is removed when it is empty
+	DefaultCountry *string
+
+	InboundContentMaxLength *uint
+	InboundContentLimitMode *entities.InboundContentLimitMode
+	GetSendEnabled          *bool
+}
+
+// Patch updates only the fields of an entities.User which are set in the params
+func (service *UserService) Patch(ctx context.Context, userID entities.UserID, params UserPatchParams) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not load [%T] with ID [%s]", user, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if params.Timezone != nil {
+		user.Timezone = params.Timezone.String()
+	}
+	if params.Locale != nil {
+		user.Locale = *params.Locale
+	}
+	if params.ActivePhoneID != nil {
+		user.ActivePhoneID = params.ActivePhoneID
+		if *params.ActivePhoneID == uuid.Nil {
+			user.ActivePhoneID = nil
+		}
+	}
+	if params.LanguageDetectionEnabled != nil {
+		user.LanguageDetectionEnabled = *params.LanguageDetectionEnabled
+	}
+	if params.DefaultCountry != nil {
+		user.DefaultCountry = params.DefaultCountry
+		if *params.DefaultCountry == "" {
+			user.DefaultCountry = nil
+		}
+	}
+	if params.InboundContentMaxLength != nil {
+		user.InboundContentMaxLength = *params.InboundContentMaxLength
+	}
+	if params.InboundContentLimitMode != nil {
+		user.InboundContentLimitMode = *params.InboundContentLimitMode
+	}
+	if params.GetSendEnabled != nil {
+		user.GetSendEnabled = *params.GetSendEnabled
+	}
+
+	if err = service.repository.Update(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot save user with id [%s] in [%T]", user.ID, service.repository)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("patched [%T] with ID [%s] in the [%T]", user, user.ID, service.repository))
+	return user, nil
+}
+
 // UserNotificationUpdateParams are parameters for updating the notifications of a user
 type UserNotificationUpdateParams struct {
 	MessageStatusEnabled bool
@@ -152,6 +319,88 @@ func (service *UserService) UpdateNotificationSettings(ctx context.Context, user
 	return user, nil
 }
 
+// UserMessageCategoryRulesUpdateParams are parameters for overriding the rules used to classify received messages
+type UserMessageCategoryRulesUpdateParams struct {
+	Rules entities.MessageCategoryRules
+}
+
+// UpdateMessageCategoryRules for an entities.User, the default rules are used when params.Rules is empty
+func (service *UserService) UpdateMessageCategoryRules(ctx context.Context, userID entities.UserID, params *UserMessageCategoryRulesUpdateParams) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("could not load [%T] with ID [%s]", user, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	user.MessageCategoryRules = params.Rules
+
+	if err = service.repository.Update(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot save user with id [%s] in [%T]", user.ID, service.repository)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("updated [%d] message category rules for [%T] with ID [%s] in the [%T]", len(params.Rules), user, user.ID, service.repository))
+	return user, nil
+}
+
+// IsAdmin checks if a user can set the feature flags of other users
+func (service *UserService) IsAdmin(userID entities.UserID) bool {
+	return slices.Contains(service.adminUserIDs, userID)
+}
+
+// IsEnabled checks if a feature flag is enabled for a user, the flag is disabled when the user cannot be loaded
+func (service *UserService) IsEnabled(ctx context.Context, userID entities.UserID, flag entities.FeatureFlag) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, userID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load user with ID [%s] to check the feature flag [%s]", userID, flag)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return false
+	}
+
+	return user.FeatureFlags.IsEnabled(flag)
+}
+
+// UserFeatureFlagsUpdateParams are parameters for setting the feature flags of a user
+type UserFeatureFlagsUpdateParams struct {
+	UserID entities.UserID
+
+	// Flags are merged with the existing flags of the user
+	Flags entities.UserFeatureFlags
+}
+
+// UpdateFeatureFlags sets the feature flags of an entities.User, the flags which are not in params.Flags are not changed
+func (service *UserService) UpdateFeatureFlags(ctx context.Context, params *UserFeatureFlagsUpdateParams) (*entities.User, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	user, err := service.repository.Load(ctx, params.UserID)
+	if err != nil {
+		msg := fmt.Sprintf("could not load [%T] with ID [%s]", user, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if user.FeatureFlags == nil {
+		user.FeatureFlags = entities.UserFeatureFlags{}
+	}
+	for flag, enabled := range params.Flags {
+		user.FeatureFlags[flag] = enabled
+	}
+
+	if err = service.repository.Update(ctx, user); err != nil {
+		msg := fmt.Sprintf("cannot save user with id [%s] in [%T]", user.ID, service.repository)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("updated [%d] feature flags for [%T] with ID [%s] in the [%T]", len(params.Flags), user, user.ID, service.repository))
+	return user, nil
+}
+
 // RotateAPIKey for an entities.User
 func (service *UserService) RotateAPIKey(ctx context.Context, source string, userID entities.UserID) (*entities.User, error) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
@@ -271,6 +520,7 @@ func (service *UserService) StartSubscription(ctx context.Context, params *event
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	service.syncSubAccounts(ctx, user)
 	return nil
 }
 
@@ -336,6 +586,7 @@ func (service *UserService) CancelSubscription(ctx context.Context, params *even
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	service.syncSubAccounts(ctx, user)
 	return nil
 }
 
@@ -361,6 +612,7 @@ func (service *UserService) ExpireSubscription(ctx context.Context, params *even
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	service.syncSubAccounts(ctx, user)
 	return nil
 }
 
@@ -392,5 +644,6 @@ func (service *UserService) UpdateSubscription(ctx context.Context, params *even
 		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	service.syncSubAccounts(ctx, user)
 	return nil
 }
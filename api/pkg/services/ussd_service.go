@@ -0,0 +1,248 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"firebase.google.com/go/messaging"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+const (
+	// ErrCodeUssdSessionInvalidState is returned when a USSD session cannot accept the requested action in its current status
+	ErrCodeUssdSessionInvalidState = stacktrace.ErrorCode(1101)
+
+	// ErrCodeUssdPhoneUnreachable is returned when the phone has no FCM token to receive the USSD request
+	ErrCodeUssdPhoneUnreachable = stacktrace.ErrorCode(1102)
+)
+
+// ussdCommandType is the KEY_TYPE of the push notification which executes a USSD request on the phone
+const ussdCommandType = "ussd"
+
+// UssdService executes USSD requests on the android phone
+type UssdService struct {
+	service
+	logger          telemetry.Logger
+	tracer          telemetry.Tracer
+	repository      repositories.UssdSessionRepository
+	phoneRepository repositories.PhoneRepository
+	messagingClient *messaging.Client
+	drainer         *Drainer
+	signer          *FcmSigner
+
+	// credentialService loads the firebase project of the users who push the notifications with their own FCM credentials
+	credentialService *FcmCredentialService
+}
+
+// NewUssdService creates a new UssdService
+func NewUssdService(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	repository repositories.UssdSessionRepository,
+	phoneRepository repositories.PhoneRepository,
+	messagingClient *messaging.Client,
+	drainer *Drainer,
+	signer *FcmSigner,
+	credentialService *FcmCredentialService,
+) (s *UssdService) {
+	return &UssdService{
+		logger:            logger.WithService(fmt.Sprintf("%T", s)),
+		tracer:            tracer,
+		repository:        repository,
+		phoneRepository:   phoneRepository,
+		messagingClient:   messagingClient,
+		drainer:           drainer,
+		signer:            signer,
+		credentialService: credentialService,
+	}
+}
+
+// Load an entities.UssdSession by ID
+func (service *UssdService) Load(ctx context.Context, userID entities.UserID, phoneID uuid.UUID, sessionID uuid.UUID) (*entities.UssdSession, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	session, err := service.repository.Load(ctx, userID, sessionID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load USSD session with ID [%s] for user [%s]", sessionID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if session.PhoneID != phoneID {
+		msg := fmt.Sprintf("USSD session with ID [%s] does not belong to phone [%s]", sessionID, phoneID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	return session, nil
+}
+
+// UssdStoreParams are parameters for creating a new entities.UssdSession
+type UssdStoreParams struct {
+	PhoneID uuid.UUID
+	UserID  entities.UserID
+	Code    string
+	SIM     entities.SIM
+}
+
+// Store creates a new entities.UssdSession and sends the USSD request to the phone
+func (service *UssdService) Store(ctx context.Context, params *UssdStoreParams) (*entities.UssdSession, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	phone, err := service.phoneRepository.LoadByID(ctx, params.UserID, params.PhoneID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load phone with userID [%s] and phoneID [%s]", params.UserID, params.PhoneID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	session := &entities.UssdSession{
+		ID:        uuid.New(),
+		PhoneID:   phone.ID,
+		UserID:    phone.UserID,
+		Owner:     phone.PhoneNumber,
+		SIM:       params.SIM,
+		Code:      params.Code,
+		Status:    entities.UssdSessionStatusPending,
+		Request:   params.Code,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	if err = service.repository.Save(ctx, session); err != nil {
+		msg := fmt.Sprintf("cannot save USSD session [%s] for phone [%s]", session.ID, phone.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created USSD session [%s] for phone [%s] and user [%s]", session.ID, phone.ID, phone.UserID))
+	return session, service.send(ctx, phone, session)
+}
+
+// UssdResponseParams are parameters for storing the response of a USSD request reported by the phone
+type UssdResponseParams struct {
+	PhoneID       uuid.UUID
+	SessionID     uuid.UUID
+	UserID        entities.UserID
+	Response      *string
+	FailureReason *string
+}
+
+// StoreResponse stores the response of a USSD request which is reported by the phone
+func (service *UssdService) StoreResponse(ctx context.Context, params *UssdResponseParams) (*entities.UssdSession, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	session, err := service.Load(ctx, params.UserID, params.PhoneID, params.SessionID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load USSD session [%s] for user [%s]", params.SessionID, params.UserID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if !session.IsAwaitingResponse() {
+		msg := fmt.Sprintf("USSD session [%s] has status [%s] and is not waiting for a response", session.ID, session.Status)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUssdSessionInvalidState, msg))
+	}
+
+	session.Response = params.Response
+	session.UpdatedAt = time.Now().UTC()
+
+	session.Status = entities.UssdSessionStatusCompleted
+	if params.FailureReason != nil {
+		session.Status = entities.UssdSessionStatusFailed
+		session.FailureReason = params.FailureReason
+	}
+
+	if err = service.repository.Save(ctx, session); err != nil {
+		msg := fmt.Sprintf("cannot save response for USSD session [%s]", session.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("stored response for USSD session [%s] with status [%s]", session.ID, session.Status))
+	return session, nil
+}
+
+func (service *UssdService) send(ctx context.Context, phone *entities.Phone, session *entities.UssdSession) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	done := service.drainer.Add()
+	defer done()
+
+	if phone.FcmToken == nil {
+		service.markAsFailed(ctx, session, "phone has no FCM token")
+		msg := fmt.Sprintf("phone with id [%s] has no FCM token to receive USSD session [%s]", phone.ID, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUssdPhoneUnreachable, msg))
+	}
+
+	if !phone.HasValidFcmToken() {
+		service.markAsFailed(ctx, session, "the FCM token of the phone is no longer valid")
+		msg := fmt.Sprintf("the FCM token of phone with id [%s] was rejected at [%s] before USSD session [%s]", phone.ID, phone.FcmTokenInvalidAt, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeUssdPhoneUnreachable, msg))
+	}
+
+	client, err := service.credentialService.MessagingClient(ctx, phone.UserID, service.messagingClient)
+	if err != nil {
+		service.markAsFailed(ctx, session, "cannot load the firebase project of the user")
+		msg := fmt.Sprintf("cannot load the messaging client for the USSD FCM to phone with id [%s] for session [%s]", phone.ID, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	// the USSD request is a command of the phone so it has a type and it is signed like the other notifications which the app executes
+	data, err := service.sign(phone, map[string]string{
+		"KEY_TYPE":            ussdCommandType,
+		"KEY_PHONE_ID":        phone.ID.String(),
+		"KEY_USSD_SESSION_ID": session.ID.String(),
+		"KEY_USSD_REQUEST":    session.Request,
+		"KEY_USSD_SIM":        session.SIM.String(),
+	})
+	if err != nil {
+		service.markAsFailed(ctx, session, "cannot sign the USSD request")
+		msg := fmt.Sprintf("cannot sign the USSD FCM to phone with id [%s] for session [%s]", phone.ID, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	result, err := client.Send(ctx, &messaging.Message{
+		Data: data,
+		Android: &messaging.AndroidConfig{
+			Priority: "high",
+		},
+		Token: *phone.FcmToken,
+	})
+	if err != nil {
+		service.markAsFailed(ctx, session, err.Error())
+		msg := fmt.Sprintf("cannot send USSD FCM to phone with id [%s] for session [%s]", phone.ID, session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	session.Status = entities.UssdSessionStatusSent
+	if err = service.repository.Save(ctx, session); err != nil {
+		msg := fmt.Sprintf("cannot save sent USSD session [%s]", session.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent USSD FCM [%s] to phone [%s] for session [%s]", result, phone.ID, session.ID))
+	return nil
+}
+
+func (service *UssdService) sign(phone *entities.Phone, data map[string]string) (map[string]string, error) {
+	if service.signer == nil {
+		return data, nil
+	}
+	return service.signer.ForPhone(phone.ID, phone.FcmKeyVersion).Sign(data, time.Now().UTC())
+}
+
+func (service *UssdService) markAsFailed(ctx context.Context, session *entities.UssdSession, reason string) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	session.Status = entities.UssdSessionStatusFailed
+	session.FailureReason = &reason
+	if err := service.repository.Save(ctx, session); err != nil {
+		msg := fmt.Sprintf("cannot save failed USSD session [%s]", session.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+}
@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/palantir/stacktrace"
+)
+
+// vonageSmsURL is the endpoint of the Vonage SMS API
+const vonageSmsURL = "https://rest.nexmo.com/sms/json"
+
+// vonageStatusSuccess is the status of a message which was accepted by the Vonage SMS API
+const vonageStatusSuccess = "0"
+
+// VonageSmsProvider sends messages with the Vonage SMS API
+type VonageSmsProvider struct {
+	client *http.Client
+}
+
+// NewVonageSmsProvider creates a new VonageSmsProvider
+func NewVonageSmsProvider(client *http.Client) *VonageSmsProvider {
+	return &VonageSmsProvider{client: client}
+}
+
+// Name is the name of the provider which is recorded on the messages it sends
+func (provider *VonageSmsProvider) Name() entities.SmsProviderName {
+	return entities.SmsProviderVonage
+}
+
+// Send the content to the contact with the Vonage account of the credentials and return the ID of the first part of the message
+func (provider *VonageSmsProvider) Send(ctx context.Context, credentials SmsProviderCredentials, contact string, content string) (string, error) {
+	// the Vonage API expects phone numbers in the E.164 format without the leading +
+	form := url.Values{}
+	form.Set("api_key", credentials.AccountID)
+	form.Set("api_secret", credentials.AuthToken)
+	form.Set("from", strings.TrimPrefix(credentials.From, "+"))
+	form.Set("to", strings.TrimPrefix(contact, "+"))
+	form.Set("text", content)
+	form.Set("type", "unicode")
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, vonageSmsURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot create vonage request for api key [%s]", credentials.AccountID))
+	}
+	request.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	response, err := provider.client.Do(request)
+	if err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot send vonage request for api key [%s]", credentials.AccountID))
+	}
+	defer func() { _ = response.Body.Close() }()
+
+	if response.StatusCode >= 400 {
+		return "", stacktrace.NewError(fmt.Sprintf("vonage responded with status code [%d]", response.StatusCode))
+	}
+
+	payload := new(struct {
+		Messages []struct {
+			MessageID string `json:"message-id"`
+			Status    string `json:"status"`
+			ErrorText string `json:"error-text"`
+		} `json:"messages"`
+	})
+	if err = json.NewDecoder(response.Body).Decode(payload); err != nil {
+		return "", stacktrace.Propagate(err, fmt.Sprintf("cannot decode vonage response with status code [%d]", response.StatusCode))
+	}
+
+	if len(payload.Messages) == 0 {
+		return "", stacktrace.NewError("vonage responded without any messages")
+	}
+
+	// a long message is split into parts which each have a status
+	for _, message := range payload.Messages {
+		if message.Status != vonageStatusSuccess {
+			return "", stacktrace.NewError(fmt.Sprintf("vonage rejected the message with status [%s]: %s", message.Status, message.ErrorText))
+		}
+	}
+	return payload.Messages[0].MessageID, nil
+}
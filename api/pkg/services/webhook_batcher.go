@@ -0,0 +1,117 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+)
+
+// WebhookBatchEvent is an event buffered in a webhook batch
+type WebhookBatchEvent struct {
+	Event cloudevents.Event
+	Owner string
+}
+
+// WebhookBatchFlusher sends a batch of events to a webhook, the webhook is the one which was used when the first event of the batch was added
+type WebhookBatchFlusher func(ctx context.Context, webhook *entities.Webhook, events []WebhookBatchEvent)
+
+type webhookBatch struct {
+	webhook *entities.Webhook
+	events  []WebhookBatchEvent
+	timer   *time.Timer
+	flusher WebhookBatchFlusher
+	done    func()
+}
+
+// WebhookBatcher buffers events per entities.Webhook and flushes them when the batch is full or the batch window elapses
+type WebhookBatcher struct {
+	logger  telemetry.Logger
+	tracer  telemetry.Tracer
+	drainer *Drainer
+	mutex   sync.Mutex
+	batches map[uuid.UUID]*webhookBatch
+}
+
+// NewWebhookBatcher creates a new WebhookBatcher
+func NewWebhookBatcher(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	drainer *Drainer,
+) (b *WebhookBatcher) {
+	return &WebhookBatcher{
+		logger:  logger.WithService(fmt.Sprintf("%T", b)),
+		tracer:  tracer,
+		drainer: drainer,
+		batches: map[uuid.UUID]*webhookBatch{},
+	}
+}
+
+// Add buffers an event for an entities.Webhook. The batch is flushed synchronously when it reaches entities.Webhook.BatchSize
+func (batcher *WebhookBatcher) Add(ctx context.Context, webhook *entities.Webhook, owner string, event cloudevents.Event, flusher WebhookBatchFlusher) {
+	ctx, span, ctxLogger := batcher.tracer.StartWithLogger(ctx, batcher.logger)
+	defer span.End()
+
+	batcher.mutex.Lock()
+	batch, ok := batcher.batches[webhook.ID]
+	if !ok {
+		batch = &webhookBatch{webhook: webhook, flusher: flusher, done: batcher.drainer.Add()}
+		// the batch outlives the request which added its first event so only the trace of the request is kept
+		flushCtx := context.WithoutCancel(ctx)
+		batch.timer = time.AfterFunc(webhook.BatchWindow(), func() {
+			batcher.flush(flushCtx, webhook.ID, batch)
+		})
+		batcher.batches[webhook.ID] = batch
+	}
+
+	batch.events = append(batch.events, WebhookBatchEvent{Event: event, Owner: owner})
+	if count := len(batch.events); uint(count) < webhook.BatchSize {
+		batcher.mutex.Unlock()
+		ctxLogger.Info(fmt.Sprintf("buffered [%s] event with ID [%s] for webhook [%s] with [%d/%d] events", event.Type(), event.ID(), webhook.ID, count, webhook.BatchSize))
+		return
+	}
+
+	batch.timer.Stop()
+	delete(batcher.batches, webhook.ID)
+	batcher.mutex.Unlock()
+
+	batch.flusher(ctx, batch.webhook, batch.events)
+	batch.done()
+}
+
+// Flush sends all the buffered batches without waiting for their batch window to elapse
+func (batcher *WebhookBatcher) Flush(ctx context.Context) int {
+	batcher.mutex.Lock()
+	batches := batcher.batches
+	batcher.batches = map[uuid.UUID]*webhookBatch{}
+	batcher.mutex.Unlock()
+
+	for _, batch := range batches {
+		batch.timer.Stop()
+		go func(batch *webhookBatch) {
+			batch.flusher(ctx, batch.webhook, batch.events)
+			batch.done()
+		}(batch)
+	}
+
+	batcher.logger.Info(fmt.Sprintf("flushing [%d] buffered webhook batches", len(batches)))
+	return len(batches)
+}
+
+func (batcher *WebhookBatcher) flush(ctx context.Context, webhookID uuid.UUID, batch *webhookBatch) {
+	batcher.mutex.Lock()
+	if batcher.batches[webhookID] != batch {
+		batcher.mutex.Unlock()
+		return
+	}
+	delete(batcher.batches, webhookID)
+	batcher.mutex.Unlock()
+
+	batch.flusher(ctx, batch.webhook, batch.events)
+	batch.done()
+}
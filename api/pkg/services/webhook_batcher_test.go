@@ -0,0 +1,112 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	cloudevents "github.com/cloudevents/sdk-go/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type webhookBatcherContextKey struct{}
+
+// webhookBatchFlush is a batch which was sent by the WebhookBatcher
+type webhookBatchFlush struct {
+	ctx    context.Context
+	events []WebhookBatchEvent
+}
+
+func newTestWebhookBatcher() (*WebhookBatcher, *Drainer) {
+	drainer := NewDrainer(testLogger())
+	return NewWebhookBatcher(testLogger(), telemetry.NewOtelLogger("test", testLogger()), drainer), drainer
+}
+
+func newTestWebhookBatchEvent(id string) cloudevents.Event {
+	event := cloudevents.NewEvent()
+	event.SetID(id)
+	event.SetType("message.phone.received")
+	return event
+}
+
+func TestWebhookBatcher_Add(t *testing.T) {
+	t.Run("the batch is flushed when it reaches the batch size", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		batcher, _ := newTestWebhookBatcher()
+		webhook := &entities.Webhook{ID: uuid.New(), BatchSize: 2, BatchWindowSeconds: 60}
+		flushes := make(chan webhookBatchFlush, 1)
+		flusher := func(ctx context.Context, _ *entities.Webhook, events []WebhookBatchEvent) {
+			flushes <- webhookBatchFlush{ctx: ctx, events: events}
+		}
+
+		// Act
+		batcher.Add(context.Background(), webhook, "+18005550199", newTestWebhookBatchEvent("1"), flusher)
+		batcher.Add(context.Background(), webhook, "+18005550199", newTestWebhookBatchEvent("2"), flusher)
+
+		// Assert
+		flush := <-flushes
+		assert.Equal(t, 2, len(flush.events))
+		assert.Equal(t, "1", flush.events[0].Event.ID())
+		assert.Equal(t, "2", flush.events[1].Event.ID())
+	})
+
+	t.Run("the batch is flushed with the trace of the request after the batch window when the request is cancelled", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		batcher, _ := newTestWebhookBatcher()
+		webhook := &entities.Webhook{ID: uuid.New(), BatchSize: 10, BatchWindowSeconds: 1}
+		flushes := make(chan webhookBatchFlush, 1)
+		flusher := func(ctx context.Context, _ *entities.Webhook, events []WebhookBatchEvent) {
+			flushes <- webhookBatchFlush{ctx: ctx, events: events}
+		}
+
+		// Arrange
+		ctx, cancel := context.WithCancel(context.WithValue(context.Background(), webhookBatcherContextKey{}, "trace"))
+
+		// Act
+		batcher.Add(ctx, webhook, "+18005550199", newTestWebhookBatchEvent("1"), flusher)
+		cancel()
+
+		// Assert
+		select {
+		case flush := <-flushes:
+			assert.Equal(t, 1, len(flush.events))
+			assert.Nil(t, flush.ctx.Err())
+			assert.Equal(t, "trace", flush.ctx.Value(webhookBatcherContextKey{}))
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "the batch was not flushed after the batch window")
+		}
+	})
+}
+
+func TestWebhookBatcher_Flush(t *testing.T) {
+	t.Run("the buffered batches are sent and drained", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		batcher, drainer := newTestWebhookBatcher()
+		flushes := make(chan webhookBatchFlush, 2)
+		flusher := func(ctx context.Context, _ *entities.Webhook, events []WebhookBatchEvent) {
+			flushes <- webhookBatchFlush{ctx: ctx, events: events}
+		}
+
+		// Arrange
+		for _, id := range []string{"1", "2"} {
+			webhook := &entities.Webhook{ID: uuid.New(), BatchSize: 10, BatchWindowSeconds: 60}
+			batcher.Add(context.Background(), webhook, "+18005550199", newTestWebhookBatchEvent(id), flusher)
+		}
+
+		// Act
+		count := batcher.Flush(context.Background())
+		drained, abandoned := drainer.Drain(context.Background())
+
+		// Assert
+		assert.Equal(t, 2, count)
+		assert.Equal(t, 2, len(flushes))
+		assert.Equal(t, 2, drained)
+		assert.Equal(t, 0, abandoned)
+	})
+}
@@ -0,0 +1,32 @@
+package services
+
+import (
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// webhookDeliveryIDHeader identifies a single attempt to deliver an event to a webhook, the X-Event-ID header stays the same across the attempts
+const webhookDeliveryIDHeader = "X-Delivery-ID"
+
+// WebhookDeliveryIDTransport sets a new X-Delivery-ID header on each attempt of a retried webhook request.
+// It is used below the retry transport so that the receiver can tell a retry apart from the first attempt.
+type WebhookDeliveryIDTransport struct {
+	transport http.RoundTripper
+}
+
+// NewWebhookDeliveryIDTransport creates a new WebhookDeliveryIDTransport
+func NewWebhookDeliveryIDTransport(transport http.RoundTripper) *WebhookDeliveryIDTransport {
+	return &WebhookDeliveryIDTransport{transport: transport}
+}
+
+// RoundTrip sends the request with a new delivery ID, the request is sent unchanged when it is not a webhook delivery
+func (transport *WebhookDeliveryIDTransport) RoundTrip(request *http.Request) (*http.Response, error) {
+	if request.Header.Get(webhookDeliveryIDHeader) == "" {
+		return transport.transport.RoundTrip(request)
+	}
+
+	attempt := request.Clone(request.Context())
+	attempt.Header.Set(webhookDeliveryIDHeader, uuid.New().String())
+	return transport.transport.RoundTrip(attempt)
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+)
+
+type webhookDelivery struct {
+	webhookID uuid.UUID
+	deliver   func()
+	started   bool
+	done      chan struct{}
+}
+
+// WebhookDeliveryPool sends the webhook deliveries with a fixed number of workers so that a burst of events cannot open an unbounded number of connections.
+// The deliveries above the limits wait in a queue in the order they were added, the limits are per instance of the API.
+type WebhookDeliveryPool struct {
+	logger telemetry.Logger
+	mutex  sync.Mutex
+	ready  *sync.Cond
+	queue  []*webhookDelivery
+
+	inFlight           uint
+	webhookInFlight    map[uuid.UUID]uint
+	maxConcurrency     uint
+	maxWebhookInFlight uint
+}
+
+// NewWebhookDeliveryPool creates a new WebhookDeliveryPool with maxConcurrency workers, a webhook has no limit of its own when maxWebhookInFlight is 0
+func NewWebhookDeliveryPool(logger telemetry.Logger, maxConcurrency uint, maxWebhookInFlight uint) (p *WebhookDeliveryPool) {
+	p = &WebhookDeliveryPool{
+		logger:             logger.WithService(fmt.Sprintf("%T", p)),
+		webhookInFlight:    map[uuid.UUID]uint{},
+		maxConcurrency:     max(maxConcurrency, 1),
+		maxWebhookInFlight: maxWebhookInFlight,
+	}
+	p.ready = sync.NewCond(&p.mutex)
+
+	for i := uint(0); i < p.maxConcurrency; i++ {
+		go p.work()
+	}
+	return p
+}
+
+// Do waits for a worker to run the delivery to the webhook and returns once the delivery is done.
+// An error is returned when the context is done before a worker picks up the delivery, a delivery which has started is never abandoned.
+func (pool *WebhookDeliveryPool) Do(ctx context.Context, webhookID uuid.UUID, deliver func()) error {
+	delivery := &webhookDelivery{webhookID: webhookID, deliver: deliver, done: make(chan struct{})}
+
+	pool.mutex.Lock()
+	pool.queue = append(pool.queue, delivery)
+	pool.ready.Signal()
+	pool.mutex.Unlock()
+
+	select {
+	case <-delivery.done:
+		return nil
+	case <-ctx.Done():
+	}
+
+	pool.mutex.Lock()
+	if delivery.started {
+		pool.mutex.Unlock()
+		<-delivery.done
+		return nil
+	}
+	pool.queue = slices.DeleteFunc(pool.queue, func(item *webhookDelivery) bool { return item == delivery })
+	pool.mutex.Unlock()
+
+	return stacktrace.Propagate(ctx.Err(), fmt.Sprintf("cannot deliver to webhook [%s] with [%d] deliveries in flight", webhookID, pool.InFlight()))
+}
+
+// InFlight returns the number of deliveries which are being sent
+func (pool *WebhookDeliveryPool) InFlight() uint {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return pool.inFlight
+}
+
+// Queued returns the number of deliveries which are waiting for a worker
+func (pool *WebhookDeliveryPool) Queued() uint {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+	return uint(len(pool.queue))
+}
+
+func (pool *WebhookDeliveryPool) work() {
+	pool.mutex.Lock()
+	defer pool.mutex.Unlock()
+
+	for {
+		delivery := pool.next()
+		if delivery == nil {
+			pool.ready.Wait()
+			continue
+		}
+
+		pool.mutex.Unlock()
+		delivery.deliver()
+		close(delivery.done)
+		pool.mutex.Lock()
+
+		pool.inFlight--
+		pool.webhookInFlight[delivery.webhookID]--
+		if pool.webhookInFlight[delivery.webhookID] == 0 {
+			delete(pool.webhookInFlight, delivery.webhookID)
+		}
+
+		// the idle workers are woken up because the finished delivery may unblock a queued delivery to the same webhook
+		pool.ready.Broadcast()
+	}
+}
+
+// next removes the oldest queued delivery to a webhook which is below its limit, it must be called with the mutex locked
+func (pool *WebhookDeliveryPool) next() *webhookDelivery {
+	for index, delivery := range pool.queue {
+		if pool.maxWebhookInFlight > 0 && pool.webhookInFlight[delivery.webhookID] >= pool.maxWebhookInFlight {
+			continue
+		}
+
+		pool.queue = slices.Delete(pool.queue, index, index+1)
+		delivery.started = true
+		pool.inFlight++
+		pool.webhookInFlight[delivery.webhookID]++
+		return delivery
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/palantir/stacktrace"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWebhookDeliveryPool_Do(t *testing.T) {
+	t.Run("the deliveries do not exceed the concurrency of the pool", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		pool := NewWebhookDeliveryPool(testLogger(), 2, 0)
+		var inFlight, maxInFlight atomic.Int32
+
+		// Act
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = pool.Do(context.Background(), uuid.New(), func() {
+					current := inFlight.Add(1)
+					for previous := maxInFlight.Load(); current > previous && !maxInFlight.CompareAndSwap(previous, current); previous = maxInFlight.Load() {
+					}
+					time.Sleep(5 * time.Millisecond)
+					inFlight.Add(-1)
+				})
+			}()
+		}
+		wg.Wait()
+
+		// Assert
+		assert.Equal(t, int32(2), maxInFlight.Load())
+		assert.Equal(t, uint(0), pool.Queued())
+		// the worker updates the gauges after the delivery is done
+		assert.Eventually(t, func() bool { return pool.InFlight() == 0 }, time.Second, time.Millisecond)
+	})
+
+	t.Run("a webhook at its limit does not block the deliveries to other webhooks", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		pool := NewWebhookDeliveryPool(testLogger(), 2, 1)
+		slowWebhook := uuid.New()
+		release := make(chan struct{})
+		started := make(chan struct{})
+
+		// Arrange
+		go func() {
+			_ = pool.Do(context.Background(), slowWebhook, func() {
+				close(started)
+				<-release
+			})
+		}()
+		<-started
+		go func() { _ = pool.Do(context.Background(), slowWebhook, func() {}) }()
+
+		// Act
+		err := pool.Do(context.Background(), uuid.New(), func() {})
+
+		// Assert
+		assert.Nil(t, err)
+		close(release)
+	})
+
+	t.Run("a queued delivery is abandoned when the context is done", func(t *testing.T) {
+		// Setup
+		t.Parallel()
+		pool := NewWebhookDeliveryPool(testLogger(), 1, 0)
+		release := make(chan struct{})
+		started := make(chan struct{})
+		defer close(release)
+
+		// Arrange
+		go func() {
+			_ = pool.Do(context.Background(), uuid.New(), func() {
+				close(started)
+				<-release
+			})
+		}()
+		<-started
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		delivered := false
+
+		// Act
+		err := pool.Do(ctx, uuid.New(), func() { delivered = true })
+
+		// Assert
+		assert.Equal(t, context.DeadlineExceeded, stacktrace.RootCause(err))
+		assert.False(t, delivered)
+		assert.Equal(t, uint(0), pool.Queued())
+	})
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+
+	"github.com/palantir/stacktrace"
+)
+
+// encodeWebhookForm encodes a payload as form values with the same structure as its JSON encoding.
+// Nested fields use brackets e.g. data[message_id] and data[metadata][campaign], null values are empty strings.
+func encodeWebhookForm(payload any) (url.Values, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot marshal [%T] into JSON", payload))
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+
+	var value any
+	if err = decoder.Decode(&value); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decode the JSON of [%T]", payload))
+	}
+
+	fields, ok := value.(map[string]any)
+	if !ok {
+		return nil, stacktrace.NewError(fmt.Sprintf("cannot form encode [%T] because it is not a JSON object", payload))
+	}
+
+	values := url.Values{}
+	for key, field := range fields {
+		addWebhookFormValue(values, key, field)
+	}
+	return values, nil
+}
+
+func addWebhookFormValue(values url.Values, key string, value any) {
+	switch v := value.(type) {
+	case map[string]any:
+		for name, field := range v {
+			addWebhookFormValue(values, fmt.Sprintf("%s[%s]", key, name), field)
+		}
+	case []any:
+		for index, item := range v {
+			addWebhookFormValue(values, fmt.Sprintf("%s[%d]", key, index), item)
+		}
+	case nil:
+		values.Add(key, "")
+	default:
+		values.Add(key, fmt.Sprint(v))
+	}
+}
@@ -3,12 +3,19 @@ package services
 import (
 	"bytes"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
@@ -27,6 +34,23 @@ import (
 	"github.com/palantir/stacktrace"
 )
 
+const (
+	// ErrCodeWebhookReplayTooLarge is returned when a webhook replay matches more events than the maximum allowed
+	ErrCodeWebhookReplayTooLarge = stacktrace.ErrorCode(1106)
+
+	// webhookReplayPageSize is the number of events sent for each events.EventTypeWebhookReplayRequested event
+	webhookReplayPageSize = 50
+
+	// webhookStatsWindow is the duration over which the delivery stats of a webhook are computed
+	webhookStatsWindow = time.Hour
+
+	// webhookTimeoutAttempts is the number of delivery attempts which can time out before the delivery fails
+	webhookTimeoutAttempts = 2
+)
+
+// webhookLatencyBuckets are the upper bounds in milliseconds of the buckets used to aggregate the latency of webhook deliveries
+var webhookLatencyBuckets = []int64{10, 25, 50, 75, 100, 150, 200, 300, 500, 750, 1000, 1500, 2000, 3000, 5000, 7500, 10000}
+
 // WebhookService is responsible for handling webhooks
 type WebhookService struct {
 	service
@@ -35,6 +59,33 @@ type WebhookService struct {
 	client     *http.Client
 	repository repositories.WebhookRepository
 	dispatcher *EventDispatcher
+	batcher    *WebhookBatcher
+	drainer    *Drainer
+
+	// maxConsecutiveFailures is the number of consecutive failed deliveries after which a webhook is disabled
+	maxConsecutiveFailures uint
+
+	// eventLogRepository contains the events which are sent again when a webhook is replayed
+	eventLogRepository repositories.EventLogRepository
+	replayRepository   repositories.WebhookReplayRepository
+
+	// maxReplayEvents is the maximum number of events which can be sent in a single replay
+	maxReplayEvents uint
+
+	// egressIPs are the IP addresses which the webhook requests are sent from
+	egressIPs []string
+
+	// cipher encrypts the custom headers of a webhook, it is nil when the encryption key is not configured
+	cipher *Cipher
+
+	// statRepository contains the rolling aggregate of the deliveries in the last webhookStatsWindow
+	statRepository repositories.WebhookDeliveryStatRepository
+
+	// lastStatPrune is the unix minute when the expired delivery stats were last deleted
+	lastStatPrune atomic.Int64
+
+	// pool limits the number of deliveries which are sent concurrently
+	pool *WebhookDeliveryPool
 }
 
 // NewWebhookService creates a new WebhookService
@@ -44,6 +95,16 @@ func NewWebhookService(
 	client *http.Client,
 	repository repositories.WebhookRepository,
 	dispatcher *EventDispatcher,
+	batcher *WebhookBatcher,
+	drainer *Drainer,
+	maxConsecutiveFailures uint,
+	eventLogRepository repositories.EventLogRepository,
+	replayRepository repositories.WebhookReplayRepository,
+	maxReplayEvents uint,
+	egressIPs []string,
+	cipher *Cipher,
+	statRepository repositories.WebhookDeliveryStatRepository,
+	pool *WebhookDeliveryPool,
 ) (s *WebhookService) {
 	return &WebhookService{
 		logger:     logger.WithService(fmt.Sprintf("%T", s)),
@@ -51,9 +112,38 @@ func NewWebhookService(
 		client:     client,
 		dispatcher: dispatcher,
 		repository: repository,
+		batcher:    batcher,
+		drainer:    drainer,
+
+		maxConsecutiveFailures: maxConsecutiveFailures,
+
+		eventLogRepository: eventLogRepository,
+		replayRepository:   replayRepository,
+		maxReplayEvents:    maxReplayEvents,
+
+		egressIPs: egressIPs,
+		cipher:    cipher,
+
+		statRepository: statRepository,
+		pool:           pool,
 	}
 }
 
+// WebhookEgress contains the IP addresses which the webhook requests are sent from
+type WebhookEgress struct {
+	IPs []string `json:"ips" example:"203.0.113.10"`
+}
+
+// Egress returns the IP addresses which the webhook requests are sent from, the list is empty when the egress is not configured
+func (service *WebhookService) Egress() *WebhookEgress {
+	return &WebhookEgress{IPs: service.egressIPs}
+}
+
+// Schema returns the JSON schema of the events which are sent to a webhook
+func (service *WebhookService) Schema() *events.JSONSchema {
+	return events.WebhookSchema()
+}
+
 // Index fetches the entities.Webhook for an entities.UserID
 func (service *WebhookService) Index(ctx context.Context, userID entities.UserID, params repositories.IndexParams) ([]*entities.Webhook, error) {
 	ctx, span := service.tracer.Start(ctx)
@@ -67,12 +157,19 @@ func (service *WebhookService) Index(ctx context.Context, userID entities.UserID
 		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
+	for _, webhook := range webhooks {
+		if err = service.decryptHeaders(webhook); err != nil {
+			msg := fmt.Sprintf("cannot decrypt headers of webhook [%s]", webhook.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
 	ctxLogger.Info(fmt.Sprintf("fetched [%d] webhooks with prams [%+#v]", len(webhooks), params))
 	return webhooks, nil
 }
 
 // Delete an entities.Webhook
-func (service *WebhookService) Delete(ctx context.Context, userID entities.UserID, webhookID uuid.UUID) error {
+func (service *WebhookService) Delete(ctx context.Context, source string, userID entities.UserID, webhookID uuid.UUID) error {
 	ctx, span := service.tracer.Start(ctx)
 	defer span.End()
 
@@ -89,6 +186,13 @@ func (service *WebhookService) Delete(ctx context.Context, userID entities.UserI
 	}
 
 	ctxLogger.Info(fmt.Sprintf("deleted webhook with id [%s] and user id [%s]", webhookID, userID))
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeIntegrationDeleted, source, events.IntegrationDeletedPayload{
+		IntegrationID:   webhookID,
+		IntegrationType: "webhook",
+		UserID:          userID,
+		Timestamp:       time.Now().UTC(),
+	})
 	return nil
 }
 
@@ -99,6 +203,18 @@ type WebhookStoreParams struct {
 	URL          string
 	PhoneNumbers pq.StringArray
 	Events       pq.StringArray
+	BatchSize    uint
+	BatchWindow  time.Duration
+	Headers      map[string]string
+	PhoneID      *uuid.UUID
+	FieldMask    pq.StringArray
+	Timeout      time.Duration
+	ContentType  entities.WebhookContentType
+	Source       string
+
+	// ClientCertificate and ClientKey are the PEM encoded certificate and private key for mutual TLS, the webhook has no client certificate when they are empty
+	ClientCertificate string
+	ClientKey         string
 }
 
 // Store a new entities.Webhook
@@ -117,6 +233,25 @@ func (service *WebhookService) Store(ctx context.Context, params *WebhookStorePa
 		Events:       params.Events,
 		CreatedAt:    time.Now().UTC(),
 		UpdatedAt:    time.Now().UTC(),
+
+		BatchSize:          params.BatchSize,
+		BatchWindowSeconds: uint(params.BatchWindow.Seconds()),
+
+		Enabled:        true,
+		PhoneID:        params.PhoneID,
+		FieldMask:      params.FieldMask,
+		TimeoutSeconds: uint(params.Timeout.Seconds()),
+		ContentType:    params.ContentType,
+	}
+
+	if err := service.setHeaders(webhook, params.Headers); err != nil {
+		msg := fmt.Sprintf("cannot set headers of webhook with id [%s]", webhook.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err := service.setClientCertificate(webhook, params.ClientCertificate, params.ClientKey); err != nil {
+		msg := fmt.Sprintf("cannot set the client certificate of webhook with id [%s]", webhook.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
 	}
 
 	if err := service.repository.Save(ctx, webhook); err != nil {
@@ -125,6 +260,13 @@ func (service *WebhookService) Store(ctx context.Context, params *WebhookStorePa
 	}
 
 	ctxLogger.Info(fmt.Sprintf("webhook saved with id [%s] for user [%s] in the [%T]", webhook.ID, webhook.UserID, service.repository))
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeIntegrationCreated, params.Source, events.IntegrationCreatedPayload{
+		IntegrationID:   webhook.ID,
+		IntegrationType: "webhook",
+		UserID:          webhook.UserID,
+		Timestamp:       webhook.CreatedAt,
+	})
 	return webhook, nil
 }
 
@@ -135,7 +277,30 @@ type WebhookUpdateParams struct {
 	URL          string
 	Events       pq.StringArray
 	PhoneNumbers pq.StringArray
+	BatchSize    uint
+	BatchWindow  time.Duration
+	Enabled      *bool
 	WebhookID    uuid.UUID
+	Source       string
+
+	// Headers replace the custom headers of the webhook, the existing headers are kept when it is nil
+	Headers map[string]string
+
+	// PhoneID scopes the webhook to the events of a single phone, the webhook is global when it is nil
+	PhoneID *uuid.UUID
+
+	// FieldMask replaces the fields of the message events which are sent to the webhook, all the fields are sent when it is empty
+	FieldMask pq.StringArray
+
+	// Timeout is the duration to wait for each delivery attempt, the default timeout is used when it is 0
+	Timeout time.Duration
+
+	// ContentType is the encoding of the body of the requests which are sent to the webhook
+	ContentType entities.WebhookContentType
+
+	// ClientCertificate and ClientKey replace the client certificate of the webhook when they are not nil, the client certificate is removed when they are empty
+	ClientCertificate *string
+	ClientKey         *string
 }
 
 // Update an entities.Webhook
@@ -153,6 +318,42 @@ func (service *WebhookService) Update(ctx context.Context, params *WebhookUpdate
 	webhook.SigningKey = params.SigningKey
 	webhook.Events = params.Events
 	webhook.PhoneNumbers = params.PhoneNumbers
+	webhook.BatchSize = params.BatchSize
+	webhook.BatchWindowSeconds = uint(params.BatchWindow.Seconds())
+	webhook.PhoneID = params.PhoneID
+	webhook.FieldMask = params.FieldMask
+	webhook.TimeoutSeconds = uint(params.Timeout.Seconds())
+	webhook.ContentType = params.ContentType
+
+	if err = service.decryptHeaders(webhook); err != nil {
+		msg := fmt.Sprintf("cannot decrypt headers of webhook with id [%s]", webhook.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if params.Headers != nil {
+		if err = service.setHeaders(webhook, params.Headers); err != nil {
+			msg := fmt.Sprintf("cannot set headers of webhook with id [%s]", webhook.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	if params.ClientCertificate != nil && params.ClientKey != nil {
+		if err = service.setClientCertificate(webhook, *params.ClientCertificate, *params.ClientKey); err != nil {
+			msg := fmt.Sprintf("cannot set the client certificate of webhook with id [%s]", webhook.ID)
+			return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+		}
+	}
+
+	// a disabled webhook can only be enabled again explicitly by the user
+	if params.Enabled != nil && *params.Enabled != webhook.Enabled {
+		webhook.Enabled = *params.Enabled
+		webhook.ConsecutiveFailures = 0
+		webhook.DisabledAt = nil
+		if !webhook.Enabled {
+			timestamp := time.Now().UTC()
+			webhook.DisabledAt = &timestamp
+		}
+	}
 
 	if err = service.repository.Save(ctx, webhook); err != nil {
 		msg := fmt.Sprintf("cannot save webhook with id [%s] after update", webhook.ID)
@@ -160,6 +361,13 @@ func (service *WebhookService) Update(ctx context.Context, params *WebhookUpdate
 	}
 
 	ctxLogger.Info(fmt.Sprintf("webhook updated with id [%s] in the [%T]", webhook.ID, service.repository))
+
+	service.dispatchIntegrationEvent(ctx, events.EventTypeIntegrationUpdated, params.Source, events.IntegrationUpdatedPayload{
+		IntegrationID:   webhook.ID,
+		IntegrationType: "webhook",
+		UserID:          webhook.UserID,
+		Timestamp:       time.Now().UTC(),
+	})
 	return webhook, nil
 }
 
@@ -184,6 +392,10 @@ func (service *WebhookService) Send(ctx context.Context, userID entities.UserID,
 		wg.Add(1)
 		go func(webhook *entities.Webhook) {
 			defer wg.Done()
+			if webhook.IsBatched() {
+				service.batcher.Add(ctx, webhook, phoneNumber, event, service.flushBatch)
+				return
+			}
 			service.sendNotification(ctx, event, phoneNumber, webhook)
 		}(webhook)
 	}
@@ -192,25 +404,111 @@ func (service *WebhookService) Send(ctx context.Context, userID entities.UserID,
 	return nil
 }
 
-func (service *WebhookService) sendNotification(ctx context.Context, event cloudevents.Event, owner string, webhook *entities.Webhook) {
+// SendTo sends an event to a webhook of the user even when the webhook is not subscribed to the event, it is not batched and it is skipped when the webhook is disabled
+func (service *WebhookService) SendTo(ctx context.Context, userID entities.UserID, webhookID uuid.UUID, event cloudevents.Event, phoneNumber string) error {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	requestCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	webhook, err := service.repository.Load(ctx, userID, webhookID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook [%s] for userID [%s]", webhookID, userID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if !webhook.Enabled {
+		ctxLogger.Info(fmt.Sprintf("not sending [%s] event with ID [%s] to webhook [%s] because it is disabled", event.Type(), event.ID(), webhook.ID))
+		return nil
+	}
+
+	service.sendNotification(ctx, event, phoneNumber, webhook)
+	return nil
+}
+
+// dispatchIntegrationEvent records a change to a webhook, the error is logged because the change has already been saved
+func (service *WebhookService) dispatchIntegrationEvent(ctx context.Context, eventType string, source string, payload any) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	event, err := service.createEvent(eventType, source, payload)
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for payload [%+#v]", eventType, payload)))
+		return
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch [%s] event with ID [%s]", event.Type(), event.ID())))
+	}
+}
+
+// flushBatch is the WebhookBatchFlusher used by the WebhookBatcher, the webhook is loaded again because it can be updated or deleted during the batch window
+func (service *WebhookService) flushBatch(ctx context.Context, webhook *entities.Webhook, batch []WebhookBatchEvent) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	current, err := service.repository.Load(ctx, webhook.UserID, webhook.ID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		ctxLogger.Info(fmt.Sprintf("dropping batch of [%d] events because webhook [%s] was deleted", len(batch), webhook.ID))
+		return
+	}
+
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook [%s] for userID [%s], the batch of [%d] events is sent with the buffered webhook", webhook.ID, webhook.UserID, len(batch))
+		ctxLogger.Warn(stacktrace.Propagate(err, msg))
+		current = webhook
+	}
+
+	if !current.Enabled {
+		ctxLogger.Info(fmt.Sprintf("dropping batch of [%d] events because webhook [%s] is disabled", len(batch), current.ID))
+		return
+	}
+
+	service.sendBatch(ctx, current, batch)
+}
+
+// sendNotification sends an event to a webhook with the WebhookDeliveryPool and returns true if the webhook accepted the event
+func (service *WebhookService) sendNotification(ctx context.Context, event cloudevents.Event, owner string, webhook *entities.Webhook) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	// the queued delivery is drained on shutdown like a delivery which is in flight
+	done := service.drainer.Add()
+	defer done()
+
+	var delivered bool
+	err := service.pool.Do(ctx, webhook.ID, func() {
+		delivered = service.deliverNotification(ctx, event, owner, webhook)
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot send [%s] event with ID [%s] to webhook [%s] for user [%s] with [%d] queued deliveries", event.Type(), event.ID(), webhook.ID, webhook.UserID, service.pool.Queued())
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return false
+	}
+	return delivered
+}
+
+// deliverNotification sends an event to a webhook and returns true if the webhook accepted the event
+func (service *WebhookService) deliverNotification(ctx context.Context, event cloudevents.Event, owner string, webhook *entities.Webhook) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	requestCtx, cancel := service.requestContext(ctx, webhook)
 	defer cancel()
 
 	request, err := service.createRequest(requestCtx, event, webhook)
 	if err != nil {
 		msg := fmt.Sprintf("cannot create [%s] event to webhook [%s] for user [%s]", event.Type(), webhook.URL, webhook.UserID)
 		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
-		return
+		return false
 	}
 
+	start := time.Now()
 	response, err := service.client.Do(request)
+	service.recordDeliveryStat(ctx, webhook, response, time.Since(start))
 	if err != nil {
 		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send [%s] event to webhook [%s] for user [%s]", event.Type(), webhook.URL, webhook.UserID)))
 		service.handleWebhookSendFailed(ctx, event, webhook, owner, err, nil)
-		return
+		service.recordDeliveryFailure(ctx, event.Source(), webhook, err.Error())
+		return false
 	}
 
 	defer func() {
@@ -223,17 +521,756 @@ func (service *WebhookService) sendNotification(ctx context.Context, event cloud
 	if response.StatusCode >= 400 {
 		ctxLogger.Info(fmt.Sprintf("cannot send [%s] event to webhook [%s] for user [%s] with response code [%d]", event.Type(), webhook.URL, webhook.UserID, response.StatusCode))
 		service.handleWebhookSendFailed(ctx, event, webhook, owner, stacktrace.NewError(http.StatusText(response.StatusCode)), response)
-		return
+		service.recordDeliveryFailure(ctx, event.Source(), webhook, http.StatusText(response.StatusCode))
+		return false
 	}
 
 	ctxLogger.Info(fmt.Sprintf("sent webhook to url [%s] for event [%s] with ID [%s] and response code [%d]", webhook.URL, event.Type(), event.ID(), response.StatusCode))
+	service.recordDeliverySuccess(ctx, webhook)
+	return true
+}
+
+// requestContext is the context of a delivery, each attempt times out after the timeout of the webhook so that a timed out attempt is retried
+func (service *WebhookService) requestContext(ctx context.Context, webhook *entities.Webhook) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(WithAttemptTimeout(ctx, webhook.Timeout()), webhookTimeoutAttempts*webhook.Timeout())
+}
+
+// addClientCertificate sets the client certificate of the webhook on the context of the request for mutual TLS
+func (service *WebhookService) addClientCertificate(request *http.Request, webhook *entities.Webhook) (*http.Request, error) {
+	certificate, err := service.clientCertificate(webhook)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt the client certificate of webhook [%s]", webhook.ID))
+	}
+
+	if certificate == nil {
+		return request, nil
+	}
+	return request.WithContext(WithClientCertificate(request.Context(), certificate)), nil
+}
+
+// sendBatch sends a batch of events to a webhook with the WebhookDeliveryPool and returns true if the webhook accepted the batch
+func (service *WebhookService) sendBatch(ctx context.Context, webhook *entities.Webhook, batch []WebhookBatchEvent) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	var delivered bool
+	err := service.pool.Do(ctx, webhook.ID, func() {
+		delivered = service.deliverBatch(ctx, webhook, batch)
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot send batch of [%d] events to webhook [%s] for user [%s] with [%d] queued deliveries", len(batch), webhook.ID, webhook.UserID, service.pool.Queued())
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return false
+	}
+	return delivered
+}
+
+// deliverBatch sends a batch of events to a webhook and returns true if the webhook accepted the batch
+func (service *WebhookService) deliverBatch(ctx context.Context, webhook *entities.Webhook, batch []WebhookBatchEvent) bool {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	requestCtx, cancel := service.requestContext(ctx, webhook)
+	defer cancel()
+
+	request, err := service.createBatchRequest(requestCtx, webhook, batch)
+	if err != nil {
+		msg := fmt.Sprintf("cannot create batch of [%d] events to webhook [%s] for user [%s]", len(batch), webhook.URL, webhook.UserID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return false
+	}
+
+	start := time.Now()
+	response, err := service.client.Do(request)
+	service.recordDeliveryStat(ctx, webhook, response, time.Since(start))
+	if err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send batch of [%d] events to webhook [%s] for user [%s]", len(batch), webhook.URL, webhook.UserID)))
+		for _, item := range batch {
+			service.handleWebhookSendFailed(ctx, item.Event, webhook, item.Owner, err, nil)
+		}
+		service.recordDeliveryFailure(ctx, batch[0].Event.Source(), webhook, err.Error())
+		return false
+	}
+
+	defer func() {
+		err = response.Body.Close()
+		if err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot close response body for batch of [%d] events to webhook [%s]", len(batch), webhook.ID)))
+		}
+	}()
+
+	if response.StatusCode >= 400 {
+		ctxLogger.Info(fmt.Sprintf("cannot send batch of [%d] events to webhook [%s] for user [%s] with response code [%d]", len(batch), webhook.URL, webhook.UserID, response.StatusCode))
+		body, _ := io.ReadAll(response.Body)
+		for _, item := range batch {
+			response.Body = io.NopCloser(bytes.NewReader(body))
+			service.handleWebhookSendFailed(ctx, item.Event, webhook, item.Owner, stacktrace.NewError(http.StatusText(response.StatusCode)), response)
+		}
+		service.recordDeliveryFailure(ctx, batch[0].Event.Source(), webhook, http.StatusText(response.StatusCode))
+		return false
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent batch of [%d] events to webhook url [%s] with response code [%d]", len(batch), webhook.URL, response.StatusCode))
+	service.recordDeliverySuccess(ctx, webhook)
+	return true
+}
+
+// WebhookReplayParams are parameters for replaying historical events to an entities.Webhook
+type WebhookReplayParams struct {
+	UserID    entities.UserID
+	WebhookID uuid.UUID
+	Events    []string
+	From      time.Time
+	To        time.Time
+	Source    string
+}
+
+// Replay creates an entities.WebhookReplay which re-sends the events in the time range to the webhook asynchronously
+func (service *WebhookService) Replay(ctx context.Context, params *WebhookReplayParams) (*entities.WebhookReplay, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	webhook, err := service.repository.Load(ctx, params.UserID, params.WebhookID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with userID [%s] and webhookID [%s]", params.UserID, params.WebhookID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	replay := &entities.WebhookReplay{
+		ID:        uuid.New(),
+		WebhookID: webhook.ID,
+		UserID:    webhook.UserID,
+		Events:    service.replayEvents(webhook, params.Events),
+		From:      params.From.UTC(),
+		To:        params.To.UTC(),
+		Status:    entities.WebhookReplayStatusPending,
+		CreatedAt: time.Now().UTC(),
+		UpdatedAt: time.Now().UTC(),
+	}
+
+	total, err := service.eventLogRepository.Count(ctx, params.UserID, service.replaySearchParams(webhook, replay, 0))
+	if err != nil {
+		msg := fmt.Sprintf("cannot count events to replay for webhook [%s]", webhook.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if uint(total) > service.maxReplayEvents {
+		msg := fmt.Sprintf("the replay has [%d] events which is more than the maximum of [%d] events, use a shorter time range", total, service.maxReplayEvents)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(ErrCodeWebhookReplayTooLarge, msg))
+	}
+
+	replay.TotalEvents = uint(total)
+	if total == 0 {
+		replay.Status = entities.WebhookReplayStatusCompleted
+		replay.CompletedAt = &replay.CreatedAt
+	}
+
+	if err = service.replayRepository.Save(ctx, replay); err != nil {
+		msg := fmt.Sprintf("cannot save replay for webhook [%s]", webhook.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if replay.IsFinished() {
+		ctxLogger.Info(fmt.Sprintf("replay [%s] for webhook [%s] has no events", replay.ID, webhook.ID))
+		return replay, nil
+	}
+
+	if err = service.dispatchReplayRequested(ctx, params.Source, replay, 0); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for replay [%s]", events.EventTypeWebhookReplayRequested, replay.ID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("created replay [%s] with [%d] events for webhook [%s]", replay.ID, replay.TotalEvents, webhook.ID))
+	return replay, nil
+}
+
+// LoadReplay loads an entities.WebhookReplay of a webhook
+func (service *WebhookService) LoadReplay(ctx context.Context, userID entities.UserID, webhookID uuid.UUID, replayID uuid.UUID) (*entities.WebhookReplay, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	replay, err := service.replayRepository.Load(ctx, userID, replayID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load replay with ID [%s] for user [%s]", replayID, userID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	if replay.WebhookID != webhookID {
+		msg := fmt.Sprintf("replay with ID [%s] does not belong to webhook [%s]", replayID, webhookID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.NewErrorWithCode(repositories.ErrCodeNotFound, msg))
+	}
+
+	return replay, nil
+}
+
+// WebhookReplayProcessParams are parameters for sending a page of events of an entities.WebhookReplay
+type WebhookReplayProcessParams struct {
+	UserID   entities.UserID
+	ReplayID uuid.UUID
+	Offset   int
+	Source   string
+}
+
+// ProcessReplay sends the next page of events of an entities.WebhookReplay and dispatches an event for the page after it
+func (service *WebhookService) ProcessReplay(ctx context.Context, params *WebhookReplayProcessParams) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	replay, err := service.replayRepository.Load(ctx, params.UserID, params.ReplayID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load replay with ID [%s] for user [%s]", params.ReplayID, params.UserID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if replay.IsFinished() {
+		ctxLogger.Info(fmt.Sprintf("replay [%s] has already finished with status [%s]", replay.ID, replay.Status))
+		return nil
+	}
+
+	webhook, err := service.repository.Load(ctx, replay.UserID, replay.WebhookID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		return service.finishReplay(ctx, replay, entities.WebhookReplayStatusFailed, "the webhook was deleted")
+	}
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with ID [%s] for replay [%s]", replay.WebhookID, replay.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if !webhook.Enabled {
+		return service.finishReplay(ctx, replay, entities.WebhookReplayStatusFailed, "the webhook was disabled")
+	}
+
+	logs, err := service.eventLogRepository.Search(ctx, replay.UserID, service.replaySearchParams(webhook, replay, params.Offset))
+	if err != nil {
+		msg := fmt.Sprintf("cannot search events at offset [%d] for replay [%s]", params.Offset, replay.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	replay.Status = entities.WebhookReplayStatusRunning
+	delivered, failed := service.replayLogs(ctx, webhook, logs)
+	replay.DeliveredEvents += delivered
+	replay.FailedEvents += failed
+
+	if len(logs) < webhookReplayPageSize || replay.ProcessedEvents() >= replay.TotalEvents {
+		return service.finishReplay(ctx, replay, entities.WebhookReplayStatusCompleted, "")
+	}
+
+	replay.UpdatedAt = time.Now().UTC()
+	if err = service.replayRepository.Save(ctx, replay); err != nil {
+		msg := fmt.Sprintf("cannot save progress of replay [%s]", replay.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	if err = service.dispatchReplayRequested(ctx, params.Source, replay, params.Offset+len(logs)); err != nil {
+		msg := fmt.Sprintf("cannot dispatch [%s] event for replay [%s]", events.EventTypeWebhookReplayRequested, replay.ID)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("sent [%d/%d] events for replay [%s]", replay.ProcessedEvents(), replay.TotalEvents, replay.ID))
+	return nil
+}
+
+// replayLogs sends the events to the webhook in the same format as live events and returns the number of delivered and failed events
+func (service *WebhookService) replayLogs(ctx context.Context, webhook *entities.Webhook, logs []*entities.EventLog) (delivered uint, failed uint) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	batch := make([]WebhookBatchEvent, 0, len(logs))
+	for _, log := range logs {
+		event := cloudevents.NewEvent()
+		if err := json.Unmarshal(log.Event, &event); err != nil {
+			ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal event log [%s] into [%T]", log.ID, event)))
+			failed++
+			continue
+		}
+		batch = append(batch, WebhookBatchEvent{Event: event, Owner: log.Owner})
+	}
+
+	if !webhook.IsBatched() {
+		for _, item := range batch {
+			if service.sendNotification(ctx, item.Event, item.Owner, webhook) {
+				delivered++
+			} else {
+				failed++
+			}
+		}
+		return delivered, failed
+	}
+
+	for start := 0; start < len(batch); start += int(webhook.BatchSize) {
+		end := min(start+int(webhook.BatchSize), len(batch))
+		if service.sendBatch(ctx, webhook, batch[start:end]) {
+			delivered += uint(end - start)
+		} else {
+			failed += uint(end - start)
+		}
+	}
+	return delivered, failed
+}
+
+func (service *WebhookService) finishReplay(ctx context.Context, replay *entities.WebhookReplay, status entities.WebhookReplayStatus, reason string) error {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	timestamp := time.Now().UTC()
+	replay.Status = status
+	replay.CompletedAt = &timestamp
+	replay.UpdatedAt = timestamp
+	if reason != "" {
+		replay.FailureReason = &reason
+	}
+
+	if err := service.replayRepository.Save(ctx, replay); err != nil {
+		msg := fmt.Sprintf("cannot save replay [%s] with status [%s]", replay.ID, status)
+		return service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	ctxLogger.Info(fmt.Sprintf("replay [%s] finished with status [%s] after sending [%d/%d] events", replay.ID, replay.Status, replay.ProcessedEvents(), replay.TotalEvents))
+	return nil
+}
+
+// replayEvents returns the events of the webhook which were requested, all the events of the webhook are replayed when none is requested
+func (service *WebhookService) replayEvents(webhook *entities.Webhook, requested []string) pq.StringArray {
+	if len(requested) == 0 {
+		return webhook.Events
+	}
+
+	result := pq.StringArray{}
+	for _, event := range requested {
+		for _, subscribed := range webhook.Events {
+			if event == subscribed {
+				result = append(result, event)
+			}
+		}
+	}
+	return result
+}
+
+func (service *WebhookService) replaySearchParams(webhook *entities.Webhook, replay *entities.WebhookReplay, offset int) repositories.EventLogSearchParams {
+	return repositories.EventLogSearchParams{
+		Types:  replay.Events,
+		Owners: webhook.PhoneNumbers,
+		From:   replay.From,
+		To:     replay.To,
+		Skip:   offset,
+		Limit:  webhookReplayPageSize,
+	}
+}
+
+func (service *WebhookService) dispatchReplayRequested(ctx context.Context, source string, replay *entities.WebhookReplay, offset int) error {
+	event, err := service.createEvent(events.EventTypeWebhookReplayRequested, source, &events.WebhookReplayRequestedPayload{
+		ReplayID:  replay.ID,
+		WebhookID: replay.WebhookID,
+		UserID:    replay.UserID,
+		Offset:    offset,
+	})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot create [%s] event for replay [%s]", events.EventTypeWebhookReplayRequested, replay.ID))
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot dispatch [%s] event for replay [%s]", event.Type(), replay.ID))
+	}
+	return nil
+}
+
+// WebhookStats are the delivery metrics of an entities.Webhook over the last webhookStatsWindow
+type WebhookStats struct {
+	WebhookID uuid.UUID `json:"webhook_id" example:"32343a19-da5e-4b1b-a767-3298a73703cb"`
+	From      time.Time `json:"from" example:"2022-06-05T13:26:00+03:00"`
+	To        time.Time `json:"to" example:"2022-06-05T14:26:02.302718+03:00"`
+
+	// Deliveries is the number of requests which were sent to the webhook, a batch of events is a single request
+	Deliveries uint `json:"deliveries" example:"120"`
+
+	// SuccessRate is the fraction of the deliveries which were accepted by the webhook with a status code below 400
+	SuccessRate float64 `json:"success_rate" example:"0.98"`
+
+	// LatencyP50Milliseconds and LatencyP95Milliseconds are the upper bounds of the latency buckets which contain the percentiles
+	LatencyP50Milliseconds int64 `json:"latency_p50_milliseconds" example:"150"`
+	LatencyP95Milliseconds int64 `json:"latency_p95_milliseconds" example:"750"`
+
+	// StatusCodes is the number of deliveries for each status code, the status code is 0 when the webhook did not respond
+	StatusCodes map[string]uint `json:"status_codes" example:"200:118,500:2"`
+}
+
+// Stats computes the WebhookStats of a webhook from the rolling aggregate of its deliveries
+func (service *WebhookService) Stats(ctx context.Context, userID entities.UserID, webhookID uuid.UUID) (*WebhookStats, error) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	webhook, err := service.repository.Load(ctx, userID, webhookID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot load webhook with userID [%s] and webhookID [%s]", userID, webhookID)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.PropagateWithCode(err, stacktrace.GetCode(err), msg))
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-webhookStatsWindow).Truncate(time.Minute)
+	deliveryStats, err := service.statRepository.Index(ctx, userID, webhook.ID, from)
+	if err != nil {
+		msg := fmt.Sprintf("cannot fetch the delivery stats of webhook [%s] since [%s]", webhook.ID, from)
+		return nil, service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg))
+	}
+
+	stats := &WebhookStats{WebhookID: webhook.ID, From: from, To: to, StatusCodes: map[string]uint{}}
+	successes := uint(0)
+	latencies := map[int64]uint{}
+	for _, stat := range deliveryStats {
+		stats.Deliveries += stat.Deliveries
+		stats.StatusCodes[strconv.Itoa(stat.StatusCode)] += stat.Deliveries
+		latencies[stat.LatencyMilliseconds] += stat.Deliveries
+		if stat.IsSuccessful() {
+			successes += stat.Deliveries
+		}
+	}
+
+	if stats.Deliveries > 0 {
+		stats.SuccessRate = float64(successes) / float64(stats.Deliveries)
+		stats.LatencyP50Milliseconds = service.latencyPercentile(latencies, stats.Deliveries, 0.5)
+		stats.LatencyP95Milliseconds = service.latencyPercentile(latencies, stats.Deliveries, 0.95)
+	}
+
+	ctxLogger.Info(fmt.Sprintf("computed stats of [%d] deliveries from [%d] buckets for webhook [%s]", stats.Deliveries, len(deliveryStats), webhook.ID))
+	return stats, nil
+}
+
+// latencyPercentile returns the upper bound of the latency bucket which contains the percentile of the deliveries
+func (service *WebhookService) latencyPercentile(latencies map[int64]uint, total uint, percentile float64) int64 {
+	buckets := make([]int64, 0, len(latencies))
+	for bucket := range latencies {
+		buckets = append(buckets, bucket)
+	}
+	sort.Slice(buckets, func(i, j int) bool { return buckets[i] < buckets[j] })
+
+	count := uint(0)
+	for _, bucket := range buckets {
+		count += latencies[bucket]
+		if float64(count) >= percentile*float64(total) {
+			return bucket
+		}
+	}
+	return buckets[len(buckets)-1]
+}
+
+// recordDeliveryStat adds a delivery to the rolling aggregate of the webhook, the error is logged because the delivery has already been made
+func (service *WebhookService) recordDeliveryStat(ctx context.Context, webhook *entities.Webhook, response *http.Response, latency time.Duration) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	stat := &entities.WebhookDeliveryStat{
+		WebhookID:           webhook.ID,
+		BucketStart:         time.Now().UTC().Truncate(time.Minute),
+		StatusCode:          0,
+		LatencyMilliseconds: webhookLatencyBuckets[len(webhookLatencyBuckets)-1],
+		UserID:              webhook.UserID,
+		Deliveries:          1,
+	}
+
+	if response != nil {
+		stat.StatusCode = response.StatusCode
+	}
+
+	for _, bucket := range webhookLatencyBuckets {
+		if latency.Milliseconds() <= bucket {
+			stat.LatencyMilliseconds = bucket
+			break
+		}
+	}
+
+	if err := service.statRepository.Increment(ctx, stat); err != nil {
+		msg := fmt.Sprintf("cannot record delivery with status code [%d] for webhook [%s]", stat.StatusCode, webhook.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+
+	service.pruneDeliveryStats(ctx, stat.BucketStart)
+}
+
+// pruneDeliveryStats deletes the buckets which are outside the webhookStatsWindow at most once per minute
+func (service *WebhookService) pruneDeliveryStats(ctx context.Context, bucketStart time.Time) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	minute := bucketStart.Unix() / 60
+	if last := service.lastStatPrune.Load(); last >= minute || !service.lastStatPrune.CompareAndSwap(last, minute) {
+		return
+	}
+
+	cutoff := bucketStart.Add(-webhookStatsWindow - time.Minute)
+	if err := service.statRepository.DeleteBefore(ctx, cutoff); err != nil {
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot delete webhook delivery stats before [%s]", cutoff))))
+	}
+}
+
+// recordDeliverySuccess resets the consecutive failures of a webhook after a successful delivery
+func (service *WebhookService) recordDeliverySuccess(ctx context.Context, webhook *entities.Webhook) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	if webhook.ConsecutiveFailures == 0 {
+		return
+	}
+
+	if err := service.repository.ResetFailures(ctx, webhook.ID); err != nil {
+		msg := fmt.Sprintf("cannot reset consecutive failures for webhook [%s] and user [%s]", webhook.ID, webhook.UserID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+}
+
+// recordDeliveryFailure increments the consecutive failures of a webhook and disables it when it reaches maxConsecutiveFailures
+func (service *WebhookService) recordDeliveryFailure(ctx context.Context, source string, webhook *entities.Webhook, errorMessage string) {
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	failures, err := service.repository.IncrementFailures(ctx, webhook.ID)
+	if err != nil {
+		msg := fmt.Sprintf("cannot increment consecutive failures for webhook [%s] and user [%s]", webhook.ID, webhook.UserID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if service.maxConsecutiveFailures == 0 || failures < service.maxConsecutiveFailures {
+		return
+	}
+
+	timestamp := time.Now().UTC()
+	disabled, err := service.repository.Disable(ctx, webhook.ID, timestamp)
+	if err != nil {
+		msg := fmt.Sprintf("cannot disable webhook [%s] for user [%s] after [%d] consecutive failures", webhook.ID, webhook.UserID, failures)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	// the webhook was already disabled by a concurrent delivery so the user has already been notified
+	if !disabled {
+		return
+	}
+
+	ctxLogger.Warn(stacktrace.NewError(fmt.Sprintf("disabled webhook [%s] for user [%s] after [%d] consecutive failures", webhook.ID, webhook.UserID, failures)))
+
+	event, err := service.createEvent(events.EventTypeWebhookDisabled, source, &events.WebhookDisabledPayload{
+		WebhookID:           webhook.ID,
+		WebhookURL:          webhook.URL,
+		UserID:              webhook.UserID,
+		ConsecutiveFailures: failures,
+		LastErrorMessage:    errorMessage,
+		DisabledAt:          timestamp,
+	})
+	if err != nil {
+		msg := fmt.Sprintf("cannot create event [%s] for webhook [%s]", events.EventTypeWebhookDisabled, webhook.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+		return
+	}
+
+	if err = service.dispatcher.Dispatch(ctx, event); err != nil {
+		msg := fmt.Sprintf("cannot dispatch event [%s] for webhook [%s]", event.Type(), webhook.ID)
+		ctxLogger.Error(service.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
+	}
+}
+
+func (service *WebhookService) createBatchRequest(ctx context.Context, webhook *entities.Webhook, batch []WebhookBatchEvent) (*http.Request, error) {
+	ctx, span := service.tracer.Start(ctx)
+	defer span.End()
+
+	payload := make([]cloudevents.Event, 0, len(batch))
+	for _, item := range batch {
+		payload = append(payload, service.applyFieldMask(ctx, item.Event, webhook))
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		msg := fmt.Sprintf("cannot marshal batch of [%d] events for user [%s] and webhook [%s]", len(batch), webhook.UserID, webhook.ID)
+		return nil, stacktrace.Propagate(err, msg)
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		msg := fmt.Sprintf("cannot create batch request for user [%s] and webhook [%s]", webhook.UserID, webhook.ID)
+		return nil, stacktrace.Propagate(err, msg)
+	}
+
+	request.Header.Add("X-Event-Type", "batch")
+	request.Header.Add("X-Batch-Size", strconv.Itoa(len(batch)))
+	request.Header.Add(webhookDeliveryIDHeader, uuid.New().String())
+	request.Header.Set("Content-Type", "application/json")
+
+	if strings.TrimSpace(webhook.SigningKey) != "" {
+		token, err := service.getAuthToken(webhook)
+		if err != nil {
+			msg := fmt.Sprintf("cannot generate auth token for user [%s] and webhook [%s]", webhook.UserID, webhook.ID)
+			return nil, stacktrace.Propagate(err, msg)
+		}
+		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		request.Header.Add("X-Signature", service.getSignature(webhook, body))
+	}
+
+	if err = service.addHeaders(request, webhook); err != nil {
+		msg := fmt.Sprintf("cannot add custom headers for user [%s] and webhook [%s]", webhook.UserID, webhook.ID)
+		return nil, stacktrace.Propagate(err, msg)
+	}
+
+	return service.addClientCertificate(request, webhook)
+}
+
+// HeadersEnabled checks if custom headers can be stored, the headers are encrypted so the encryption key must be configured
+func (service *WebhookService) HeadersEnabled() bool {
+	return service.cipher != nil
+}
+
+// setHeaders encrypts the custom headers of a webhook
+func (service *WebhookService) setHeaders(webhook *entities.Webhook, headers map[string]string) error {
+	webhook.SetHeaders(headers)
+	webhook.EncryptedHeaders = ""
+	if len(headers) == 0 {
+		return nil
+	}
+
+	if service.cipher == nil {
+		return stacktrace.NewError(fmt.Sprintf("cannot encrypt headers of webhook [%s] because the encryption key is not configured", webhook.ID))
+	}
+
+	plaintext, err := json.Marshal(headers)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal headers of webhook [%s]", webhook.ID))
+	}
+
+	if webhook.EncryptedHeaders, err = service.cipher.Encrypt(plaintext); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot encrypt headers of webhook [%s]", webhook.ID))
+	}
+	return nil
+}
+
+// ClientCertificateEnabled checks if client certificates can be stored, the certificates are encrypted with the same key as the custom headers
+func (service *WebhookService) ClientCertificateEnabled() bool {
+	return service.cipher != nil
+}
+
+// webhookClientCertificate is the JSON which is encrypted in the EncryptedClientCertificate of a webhook
+type webhookClientCertificate struct {
+	Certificate string `json:"certificate"`
+	Key         string `json:"key"`
+}
+
+// setClientCertificate encrypts the client certificate of a webhook, the client certificate is removed when the certificate and key are empty
+func (service *WebhookService) setClientCertificate(webhook *entities.Webhook, certificatePEM string, keyPEM string) error {
+	webhook.EncryptedClientCertificate = ""
+	webhook.ClientCertificateExpiresAt = nil
+	if certificatePEM == "" && keyPEM == "" {
+		return nil
+	}
+
+	if service.cipher == nil {
+		return stacktrace.NewError(fmt.Sprintf("cannot encrypt the client certificate of webhook [%s] because the encryption key is not configured", webhook.ID))
+	}
+
+	certificate, err := ParseClientCertificate(certificatePEM, keyPEM)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot parse the client certificate of webhook [%s]", webhook.ID))
+	}
+
+	plaintext, err := json.Marshal(webhookClientCertificate{Certificate: certificatePEM, Key: keyPEM})
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot marshal the client certificate of webhook [%s]", webhook.ID))
+	}
+
+	if webhook.EncryptedClientCertificate, err = service.cipher.Encrypt(plaintext); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot encrypt the client certificate of webhook [%s]", webhook.ID))
+	}
+
+	expiresAt := certificate.Leaf.NotAfter.UTC()
+	webhook.ClientCertificateExpiresAt = &expiresAt
+	return nil
+}
+
+// clientCertificate decrypts the client certificate of a webhook, it is nil when the webhook has no client certificate
+func (service *WebhookService) clientCertificate(webhook *entities.Webhook) (*tls.Certificate, error) {
+	if !webhook.HasClientCertificate() {
+		return nil, nil
+	}
+
+	if service.cipher == nil {
+		return nil, stacktrace.NewError(fmt.Sprintf("cannot decrypt the client certificate of webhook [%s] because the encryption key is not configured", webhook.ID))
+	}
+
+	plaintext, err := service.cipher.Decrypt(webhook.EncryptedClientCertificate)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt the client certificate of webhook [%s]", webhook.ID))
+	}
+
+	payload := new(webhookClientCertificate)
+	if err = json.Unmarshal(plaintext, payload); err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal the client certificate of webhook [%s]", webhook.ID))
+	}
+
+	certificate, err := ParseClientCertificate(payload.Certificate, payload.Key)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot parse the client certificate of webhook [%s]", webhook.ID))
+	}
+	return certificate, nil
+}
+
+// decryptHeaders sets the custom headers of a webhook from the encrypted headers which are stored in the database
+func (service *WebhookService) decryptHeaders(webhook *entities.Webhook) error {
+	webhook.SetHeaders(map[string]string{})
+	if webhook.EncryptedHeaders == "" {
+		return nil
+	}
+
+	if service.cipher == nil {
+		return stacktrace.NewError(fmt.Sprintf("cannot decrypt headers of webhook [%s] because the encryption key is not configured", webhook.ID))
+	}
+
+	plaintext, err := service.cipher.Decrypt(webhook.EncryptedHeaders)
+	if err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt headers of webhook [%s]", webhook.ID))
+	}
+
+	headers := map[string]string{}
+	if err = json.Unmarshal(plaintext, &headers); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot unmarshal headers of webhook [%s]", webhook.ID))
+	}
+
+	webhook.SetHeaders(headers)
+	return nil
+}
+
+// addHeaders adds the custom headers of a webhook to a request, reserved headers are rejected by the validator when the webhook is saved
+func (service *WebhookService) addHeaders(request *http.Request, webhook *entities.Webhook) error {
+	if err := service.decryptHeaders(webhook); err != nil {
+		return stacktrace.Propagate(err, fmt.Sprintf("cannot decrypt headers of webhook [%s]", webhook.ID))
+	}
+
+	for name, value := range webhook.Headers {
+		request.Header.Set(name, value)
+	}
+	return nil
+}
+
+// getSignature is the hex encoded HMAC-SHA256 of the encoded request body so the receiver can verify the whole body
+func (service *WebhookService) getSignature(webhook *entities.Webhook, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(webhook.SigningKey))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// encodeBody encodes the payload of a request with the content type of the webhook
+func (service *WebhookService) encodeBody(webhook *entities.Webhook, payload any) ([]byte, error) {
+	if webhook.ContentType != entities.WebhookContentTypeForm {
+		return json.Marshal(payload)
+	}
+
+	values, err := encodeWebhookForm(payload)
+	if err != nil {
+		return nil, stacktrace.Propagate(err, fmt.Sprintf("cannot form encode the payload of webhook [%s]", webhook.ID))
+	}
+	return []byte(values.Encode()), nil
 }
 
 func (service *WebhookService) createRequest(ctx context.Context, event cloudevents.Event, webhook *entities.Webhook) (*http.Request, error) {
 	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
 	defer span.End()
 
-	payload, err := json.Marshal(service.getPayload(ctxLogger, event, webhook))
+	payload, err := service.encodeBody(webhook, service.getPayload(ctxLogger, service.applyFieldMask(ctx, event, webhook), webhook))
 	if err != nil {
 		msg := fmt.Sprintf("cannot marshal payload for user [%s] and webhook [%s] for event [%s]", webhook.UserID, webhook.ID, event.ID())
 		return nil, stacktrace.Propagate(err, msg)
@@ -246,7 +1283,9 @@ func (service *WebhookService) createRequest(ctx context.Context, event cloudeve
 	}
 
 	request.Header.Add("X-Event-Type", event.Type())
-	request.Header.Set("Content-Type", "application/json")
+	request.Header.Add("X-Event-ID", event.ID())
+	request.Header.Add(webhookDeliveryIDHeader, uuid.New().String())
+	request.Header.Set("Content-Type", webhook.ContentType.MimeType())
 
 	if strings.TrimSpace(webhook.SigningKey) != "" {
 		token, err := service.getAuthToken(webhook)
@@ -255,9 +1294,46 @@ func (service *WebhookService) createRequest(ctx context.Context, event cloudeve
 			return nil, stacktrace.Propagate(err, msg)
 		}
 		request.Header.Add("Authorization", fmt.Sprintf("Bearer %s", token))
+		request.Header.Add("X-Signature", service.getSignature(webhook, payload))
+	}
+
+	if err = service.addHeaders(request, webhook); err != nil {
+		msg := fmt.Sprintf("cannot add custom headers for user [%s] and webhook [%s] for event [%s]", webhook.UserID, webhook.ID, event.ID())
+		return nil, stacktrace.Propagate(err, msg)
+	}
+
+	return service.addClientCertificate(request, webhook)
+}
+
+// applyFieldMask removes the fields of the data of a message event which are not in the FieldMask of the webhook.
+// The event is sent without the mask when its data cannot be decoded so that the delivery is not lost.
+func (service *WebhookService) applyFieldMask(ctx context.Context, event cloudevents.Event, webhook *entities.Webhook) cloudevents.Event {
+	if !webhook.HasFieldMask() || !strings.HasPrefix(event.Type(), "message.") {
+		return event
 	}
 
-	return request, nil
+	ctx, span, ctxLogger := service.tracer.StartWithLogger(ctx, service.logger)
+	defer span.End()
+
+	data := map[string]json.RawMessage{}
+	if err := event.DataAs(&data); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot decode the data of event [%s] with ID [%s] to apply the field mask of webhook [%s]", event.Type(), event.ID(), webhook.ID)))
+		return event
+	}
+
+	masked := make(map[string]json.RawMessage, len(webhook.FieldMask))
+	for _, field := range webhook.FieldMask {
+		if value, ok := data[field]; ok {
+			masked[field] = value
+		}
+	}
+
+	result := event.Clone()
+	if err := result.SetData(cloudevents.ApplicationJSON, masked); err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot encode the masked data of event [%s] with ID [%s] for webhook [%s]", event.Type(), event.ID(), webhook.ID)))
+		return event
+	}
+	return result
 }
 
 func (service *WebhookService) getPayload(ctxLogger telemetry.Logger, event cloudevents.Event, webhook *entities.Webhook) any {
@@ -337,7 +1413,7 @@ func (service *WebhookService) handleWebhookSendFailed(ctx context.Context, even
 	}
 
 	if errors.Is(err, context.DeadlineExceeded) {
-		payload.ErrorMessage = "TIMOUT after 10 seconds"
+		payload.ErrorMessage = fmt.Sprintf("TIMEOUT after %d seconds", uint(webhook.Timeout().Seconds()))
 	}
 
 	if response != nil {
@@ -7,6 +7,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -26,10 +27,12 @@ import (
 // BulkMessageHandlerValidator validates models used in handlers.BillingHandler
 type BulkMessageHandlerValidator struct {
 	validator
-	phoneService *services.PhoneService
-	userService  *services.UserService
-	logger       telemetry.Logger
-	tracer       telemetry.Tracer
+	phoneService   *services.PhoneService
+	userService    *services.UserService
+	messageService *services.MessageService
+	jobService     *services.BulkMessageJobService
+	logger         telemetry.Logger
+	tracer         telemetry.Tracer
 }
 
 // NewBulkMessageHandlerValidator creates a new handlers.BulkMessageHandlerValidator validator
@@ -38,17 +41,21 @@ func NewBulkMessageHandlerValidator(
 	tracer telemetry.Tracer,
 	phoneService *services.PhoneService,
 	userService *services.UserService,
+	messageService *services.MessageService,
+	jobService *services.BulkMessageJobService,
 ) (v *BulkMessageHandlerValidator) {
 	return &BulkMessageHandlerValidator{
-		logger:       logger.WithService(fmt.Sprintf("%T", v)),
-		tracer:       tracer,
-		userService:  userService,
-		phoneService: phoneService,
+		logger:         logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:         tracer,
+		userService:    userService,
+		phoneService:   phoneService,
+		messageService: messageService,
+		jobService:     jobService,
 	}
 }
 
 // ValidateStore validates the requests.BillingUsageHistory request
-func (v *BulkMessageHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, header *multipart.FileHeader) ([]*requests.BulkMessage, url.Values) {
+func (v *BulkMessageHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, header *multipart.FileHeader, request requests.BulkMessageStore) ([]*requests.BulkMessage, url.Values) {
 	ctx, span, ctxLogger := v.tracer.StartWithLogger(ctx, v.logger)
 	defer span.End()
 
@@ -60,6 +67,10 @@ func (v *BulkMessageHandlerValidator) ValidateStore(ctx context.Context, userID
 		return nil, result
 	}
 
+	if result := v.validatePendingJobs(ctx, user); len(result) != 0 {
+		return nil, result
+	}
+
 	messages, result := v.parseFile(ctxLogger, user, header)
 	if len(result) != 0 {
 		return messages, result
@@ -70,8 +81,8 @@ func (v *BulkMessageHandlerValidator) ValidateStore(ctx context.Context, userID
 		return messages, result
 	}
 
-	if len(messages) > 1000 {
-		result.Add("document", "The uploaded file must contain less than 1000 records.")
+	if maxRecipients := user.SubscriptionName.MaxBulkRecipients(); len(messages) > maxRecipients {
+		result.Add("document", fmt.Sprintf("The uploaded file has [%d] records which is more than the maximum of [%d] recipients allowed on your [%s] plan.", len(messages), maxRecipients, user.SubscriptionName.Plan()))
 		return messages, result
 	}
 
@@ -89,7 +100,69 @@ func (v *BulkMessageHandlerValidator) ValidateStore(ctx context.Context, userID
 		return messages, result
 	}
 
-	return messages, result
+	return messages, v.validatePace(request, messages)
+}
+
+// validatePace validates the duration or the rate which spreads the messages of the file over a period of time
+func (v *BulkMessageHandlerValidator) validatePace(request requests.BulkMessageStore, messages []*requests.BulkMessage) url.Values {
+	result := url.Values{}
+	if !request.IsPaced() {
+		return result
+	}
+
+	if request.Duration != "" && request.Rate != "" {
+		result.Add("duration", "The duration and the rate fields cannot be used together")
+		return result
+	}
+
+	if request.Duration != "" {
+		duration, err := time.ParseDuration(request.Duration)
+		if err != nil {
+			result.Add("duration", fmt.Sprintf("The duration [%s] is not a valid duration e.g. [6h] or [90m]", request.Duration))
+		} else if duration < time.Minute || duration > 7*24*time.Hour {
+			result.Add("duration", "The duration must be between 1 minute and 7 days")
+		}
+	}
+
+	if request.Rate != "" {
+		if rate, err := strconv.Atoi(request.Rate); err != nil || rate < 1 || rate > 600 {
+			result.Add("rate", "The rate must be between 1 and 600 messages per minute")
+		}
+	}
+
+	for index, message := range messages {
+		if message.SendTime != nil {
+			result.Add("document", fmt.Sprintf("Row [%d]: The SendTime cannot be used when the messages are sent with a duration or a rate.", index+2))
+		}
+	}
+	return result
+}
+
+// validatePendingJobs checks that the user has not reached the maximum number of bulk message files which are sending at the same time
+func (v *BulkMessageHandlerValidator) validatePendingJobs(ctx context.Context, user *entities.User) url.Values {
+	ctx, span, ctxLogger := v.tracer.StartWithLogger(ctx, v.logger)
+	defer span.End()
+
+	result := url.Values{}
+	count, err := v.messageService.CountPendingBulkJobs(ctx, user.ID)
+	if err != nil {
+		ctxLogger.Error(v.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot count the pending bulk jobs of user [%s]", user.ID))))
+		result.Add("document", "Cannot check your pending bulk messages. Please try again later or contact support.")
+		return result
+	}
+
+	jobs, err := v.jobService.CountActive(ctx, user.ID)
+	if err != nil {
+		ctxLogger.Error(v.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("cannot count the active bulk message jobs of user [%s]", user.ID))))
+		result.Add("document", "Cannot check your pending bulk messages. Please try again later or contact support.")
+		return result
+	}
+	count += jobs
+
+	if maxJobs := user.SubscriptionName.MaxPendingBulkJobs(); count >= maxJobs {
+		result.Add("document", fmt.Sprintf("You already have [%d] bulk message files which are still sending and the maximum allowed on your [%s] plan is [%d]. Please wait for them to be sent.", count, user.SubscriptionName.Plan(), maxJobs))
+	}
+	return result
 }
 
 func (v *BulkMessageHandlerValidator) parseFile(ctxLogger telemetry.Logger, user *entities.User, header *multipart.FileHeader) ([]*requests.BulkMessage, url.Values) {
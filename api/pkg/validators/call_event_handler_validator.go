@@ -0,0 +1,100 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// CallEventHandlerValidator validates models used in handlers.CallEventHandler
+type CallEventHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewCallEventHandlerValidator creates a new handlers.CallEventHandler validator
+func NewCallEventHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *CallEventHandlerValidator) {
+	return &CallEventHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateStore validates the requests.CallEventStore request
+func (validator *CallEventHandlerValidator) ValidateStore(_ context.Context, request requests.CallEventStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"from": []string{
+				"required",
+				contactPhoneNumberRule,
+			},
+			"type": []string{
+				"required",
+				"in:" + strings.Join([]string{entities.CallEventTypeReceived.String(), entities.CallEventTypeMissed.String()}, ","),
+			},
+			"duration": []string{
+				"min:0",
+				"max:86400",
+			},
+			"sim": []string{
+				"required",
+				"in:" + strings.Join([]string{entities.SIM1.String(), entities.SIM2.String()}, ","),
+			},
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.Timestamp.IsZero() {
+		result.Add("timestamp", "The timestamp field is required")
+	}
+
+	if request.Timestamp.After(time.Now().Add(time.Hour)) {
+		result.Add("timestamp", "The timestamp field cannot be in the future")
+	}
+
+	return result
+}
+
+// ValidateIndex validates the requests.CallEventIndex request
+func (validator *CallEventHandlerValidator) ValidateIndex(_ context.Context, request requests.CallEventIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
@@ -0,0 +1,121 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// ContentFilterHandlerValidator validates models used in handlers.ContentFilterHandler
+type ContentFilterHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewContentFilterHandlerValidator creates a new handlers.ContentFilterHandler validator
+func NewContentFilterHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *ContentFilterHandlerValidator) {
+	return &ContentFilterHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.ContentFilterIndex request
+func (validator *ContentFilterHandlerValidator) ValidateIndex(_ context.Context, request requests.ContentFilterIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.ContentFilterStore request
+func (validator *ContentFilterHandlerValidator) ValidateStore(_ context.Context, request requests.ContentFilterStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: validator.rules(),
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+	return validator.validateTerm(request)
+}
+
+// ValidateUpdate validates the requests.ContentFilterUpdate request
+func (validator *ContentFilterHandlerValidator) ValidateUpdate(_ context.Context, request requests.ContentFilterUpdate) url.Values {
+	rules := validator.rules()
+	rules["contentFilterID"] = []string{
+		"required",
+		"uuid",
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+	return validator.validateTerm(request.ContentFilterStore)
+}
+
+func (validator *ContentFilterHandlerValidator) rules() govalidator.MapData {
+	return govalidator.MapData{
+		"term": []string{
+			"required",
+			"min:1",
+			"max:255",
+		},
+		"direction": []string{
+			"required",
+			"in:" + strings.Join([]string{
+				string(entities.ContentFilterDirectionOutbound),
+				string(entities.ContentFilterDirectionInbound),
+				string(entities.ContentFilterDirectionAll),
+			}, ","),
+		},
+	}
+}
+
+// validateTerm checks that a regular expression compiles, the error does not contain the term
+func (validator *ContentFilterHandlerValidator) validateTerm(request requests.ContentFilterStore) url.Values {
+	result := url.Values{}
+	if !request.IsRegex {
+		return result
+	}
+
+	if _, err := regexp.Compile(request.Term); err != nil {
+		result.Add("term", "The term field must be a valid regular expression")
+	}
+	return result
+}
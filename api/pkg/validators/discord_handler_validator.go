@@ -6,6 +6,9 @@ import (
 	"net/url"
 
 	"github.com/NdoleStudio/httpsms/pkg/discord"
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/palantir/stacktrace"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
@@ -17,9 +20,10 @@ import (
 // DiscordHandlerValidator validates models used in handlers.DiscordHandler
 type DiscordHandlerValidator struct {
 	validator
-	client *discord.Client
-	logger telemetry.Logger
-	tracer telemetry.Tracer
+	client       *discord.Client
+	logger       telemetry.Logger
+	tracer       telemetry.Tracer
+	phoneService *services.PhoneService
 }
 
 // NewDiscordHandlerValidator creates a new handlers.DiscordHandler validator
@@ -27,11 +31,13 @@ func NewDiscordHandlerValidator(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	client *discord.Client,
+	phoneService *services.PhoneService,
 ) (v *DiscordHandlerValidator) {
 	return &DiscordHandlerValidator{
-		logger: logger.WithService(fmt.Sprintf("%T", v)),
-		tracer: tracer,
-		client: client,
+		logger:       logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:       tracer,
+		client:       client,
+		phoneService: phoneService,
 	}
 }
 
@@ -59,8 +65,36 @@ func (validator *DiscordHandlerValidator) ValidateIndex(_ context.Context, reque
 	return v.ValidateStruct()
 }
 
+// ValidateEvent validates the requests.DiscordEvent request
+func (validator *DiscordHandlerValidator) ValidateEvent(_ context.Context, request requests.DiscordEvent) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"type": []string{
+				"required",
+				"in:1,2",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 || request.IsPing() {
+		return result
+	}
+
+	if request.GuildID == "" {
+		result.Add("guild_id", "The guild_id field is required for application commands")
+	}
+
+	if request.Data == nil {
+		result.Add("data", "The data field is required for application commands")
+	}
+
+	return result
+}
+
 // ValidateStore validates the requests.DiscordStore request
-func (validator *DiscordHandlerValidator) ValidateStore(ctx context.Context, request requests.DiscordStore) url.Values {
+func (validator *DiscordHandlerValidator) ValidateStore(ctx context.Context, userID entities.UserID, request requests.DiscordStore) url.Values {
 	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
 	defer span.End()
 
@@ -82,6 +116,9 @@ func (validator *DiscordHandlerValidator) ValidateStore(ctx context.Context, req
 				"max:255",
 				"numeric",
 			},
+			"default_from": []string{
+				"max:20",
+			},
 		},
 	})
 
@@ -90,6 +127,10 @@ func (validator *DiscordHandlerValidator) ValidateStore(ctx context.Context, req
 		return result
 	}
 
+	for key, values := range validator.validateDefaultFrom(ctx, userID, request.DefaultFrom) {
+		result[key] = append(result[key], values...)
+	}
+
 	if _, _, err := validator.client.Channel.Get(ctx, request.IncomingChannelID); err != nil {
 		msg := fmt.Sprintf("cannot fetch discord channel with ID [%s]", request.IncomingChannelID)
 		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
@@ -106,7 +147,7 @@ func (validator *DiscordHandlerValidator) ValidateStore(ctx context.Context, req
 }
 
 // ValidateUpdate validates the requests.DiscordUpdate request
-func (validator *DiscordHandlerValidator) ValidateUpdate(ctx context.Context, request requests.DiscordUpdate) url.Values {
+func (validator *DiscordHandlerValidator) ValidateUpdate(ctx context.Context, userID entities.UserID, request requests.DiscordUpdate) url.Values {
 	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
 	defer span.End()
 
@@ -128,6 +169,9 @@ func (validator *DiscordHandlerValidator) ValidateUpdate(ctx context.Context, re
 				"max:255",
 				"numeric",
 			},
+			"default_from": []string{
+				"max:20",
+			},
 			"discordID": []string{
 				"required",
 				"uuid",
@@ -140,6 +184,10 @@ func (validator *DiscordHandlerValidator) ValidateUpdate(ctx context.Context, re
 		return result
 	}
 
+	for key, values := range validator.validateDefaultFrom(ctx, userID, request.DefaultFrom) {
+		result[key] = append(result[key], values...)
+	}
+
 	if _, _, err := validator.client.Channel.Get(ctx, request.IncomingChannelID); err != nil {
 		msg := fmt.Sprintf("cannot fetch discord channel with ID [%s]", request.IncomingChannelID)
 		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, msg)))
@@ -154,3 +202,26 @@ func (validator *DiscordHandlerValidator) ValidateUpdate(ctx context.Context, re
 
 	return result
 }
+
+// validateDefaultFrom checks that the default sender of the slash command is a phone of the user
+func (validator *DiscordHandlerValidator) validateDefaultFrom(ctx context.Context, userID entities.UserID, defaultFrom string) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	result := url.Values{}
+	if defaultFrom == "" {
+		return result
+	}
+
+	_, err := validator.phoneService.Load(ctx, userID, defaultFrom)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("default_from", fmt.Sprintf("no phone found with the 'default_from' number [%s]. install the android app on your phone to start sending messages", defaultFrom))
+		return result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, defaultFrom))))
+		result.Add("default_from", fmt.Sprintf("could not validate 'default_from' number [%s], please try again later", defaultFrom))
+	}
+	return result
+}
@@ -0,0 +1,83 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// EventLogHandlerValidator validates models used in handlers.EventLogHandler
+type EventLogHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewEventLogHandlerValidator creates a new handlers.EventLogHandler validator
+func NewEventLogHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *EventLogHandlerValidator) {
+	return &EventLogHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.EventLogIndex request
+func (validator *EventLogHandlerValidator) ValidateIndex(_ context.Context, request requests.EventLogIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"resource_type": []string{
+				"in:message,phone,call,webhook,discord",
+			},
+			"resource_id": []string{
+				"max:255",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+
+	for field, value := range map[string]string{"from": request.From, "to": request.To} {
+		if value == "" {
+			continue
+		}
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			result.Add(field, fmt.Sprintf("The %s field must be an RFC3339 timestamp e.g. 2022-06-05T14:26:02+03:00", field))
+		}
+	}
+
+	return result
+}
+
+// ValidateStream validates the requests.EventLogStream request
+func (validator *EventLogHandlerValidator) ValidateStream(_ context.Context, request requests.EventLogStream) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"last_event_id": []string{
+				"numeric",
+				"min:0",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
@@ -0,0 +1,80 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// FcmCredentialHandlerValidator validates models used in handlers.FcmCredentialHandler
+type FcmCredentialHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+
+	// service is used to check if the service account can be encrypted and if it can send firebase cloud messages
+	service *services.FcmCredentialService
+}
+
+// NewFcmCredentialHandlerValidator creates a new handlers.FcmCredentialHandler validator
+func NewFcmCredentialHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.FcmCredentialService,
+) (v *FcmCredentialHandlerValidator) {
+	return &FcmCredentialHandlerValidator{
+		logger:  logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// ValidateUpsert validates the requests.FcmCredentialUpsert request
+func (validator *FcmCredentialHandlerValidator) ValidateUpsert(ctx context.Context, userID entities.UserID, request requests.FcmCredentialUpsert) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"service_account": []string{
+				"required",
+				"max:16384",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	if !validator.service.Enabled() {
+		result.Add("service_account", "the FCM credentials cannot be stored because the FCM_CREDENTIALS_ENCRYPTION_KEY is not configured")
+		return result
+	}
+
+	account, err := services.ParseFcmServiceAccount(request.ServiceAccount)
+	if err != nil {
+		result.Add("service_account", "The service_account field must be the content of the JSON key of a service account")
+		return result
+	}
+
+	if account.Type != "service_account" || account.ProjectID == "" || account.ClientEmail == "" || account.PrivateKey == "" {
+		result.Add("service_account", "The service_account field must be a JSON key with the type, project_id, client_email and private_key of a service account")
+		return result
+	}
+
+	if err = validator.service.Verify(ctx, request.ServiceAccount); err != nil {
+		ctxLogger.Warn(stacktrace.Propagate(err, fmt.Sprintf("cannot send a dry run message with the service account [%s] of user [%s]", account.ClientEmail, userID)))
+		result.Add("service_account", fmt.Sprintf("The service account [%s] cannot send firebase cloud messages in the project [%s], make sure the Firebase Cloud Messaging API is enabled", account.ClientEmail, account.ProjectID))
+	}
+	return result
+}
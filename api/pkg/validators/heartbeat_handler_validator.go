@@ -68,5 +68,10 @@ func (validator *HeartbeatHandlerValidator) ValidateStore(_ context.Context, req
 			},
 		},
 	})
-	return v.ValidateStruct()
+
+	result := v.ValidateStruct()
+	if request.Battery != nil && *request.Battery > 100 {
+		result.Add("battery", "The battery field must be between 0 and 100")
+	}
+	return result
 }
@@ -0,0 +1,121 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// labelColorPattern matches the hex code of a color e.g. #ff5722
+var labelColorPattern = regexp.MustCompile(`^#[0-9a-f]{6}$`)
+
+// LabelHandlerValidator validates models used in handlers.LabelHandler
+type LabelHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewLabelHandlerValidator creates a new handlers.LabelHandler validator
+func NewLabelHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *LabelHandlerValidator) {
+	return &LabelHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.LabelIndex request
+func (validator *LabelHandlerValidator) ValidateIndex(_ context.Context, request requests.LabelIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:50",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.LabelStore request
+func (validator *LabelHandlerValidator) ValidateStore(_ context.Context, request requests.LabelStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: validator.rules(),
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+	return validator.validateColor(request)
+}
+
+// ValidateUpdate validates the requests.LabelUpdate request
+func (validator *LabelHandlerValidator) ValidateUpdate(_ context.Context, request requests.LabelUpdate) url.Values {
+	rules := validator.rules()
+	rules["labelID"] = []string{
+		"required",
+		"uuid",
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+	return validator.validateColor(request.LabelStore)
+}
+
+// ValidateMessageLabel validates the IDs of the message and the label which is attached to or detached from the message
+func (validator *LabelHandlerValidator) ValidateMessageLabel(ctx context.Context, messageID string, labelID string) url.Values {
+	result := validator.ValidateUUID(ctx, messageID, "messageID")
+	for key, values := range validator.ValidateUUID(ctx, labelID, "labelID") {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
+
+func (validator *LabelHandlerValidator) rules() govalidator.MapData {
+	return govalidator.MapData{
+		"name": []string{
+			"required",
+			"min:1",
+			"max:50",
+		},
+		"color": []string{
+			"required",
+		},
+	}
+}
+
+func (validator *LabelHandlerValidator) validateColor(request requests.LabelStore) url.Values {
+	result := url.Values{}
+	if !labelColorPattern.MatchString(request.Color) {
+		result.Add("color", "The color field must be a hex color code e.g. #ff5722")
+	}
+	return result
+}
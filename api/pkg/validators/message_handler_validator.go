@@ -2,9 +2,13 @@ package validators
 
 import (
 	"context"
+	"encoding/base64"
 	"fmt"
+	"mime"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/services"
@@ -14,15 +18,26 @@ import (
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
 	"github.com/thedevsaddam/govalidator"
 )
 
+const (
+	maxMessageAttachments    = 10
+	maxMessageAttachmentSize = 3 * 1024 * 1024
+	maxMessagesMarkedRead    = 1000
+)
+
 // MessageHandlerValidator validates models used in handlers.MessageHandler
 type MessageHandlerValidator struct {
 	validator
 	logger       telemetry.Logger
 	tracer       telemetry.Tracer
 	phoneService *services.PhoneService
+	userService  *services.UserService
+
+	// contentFilterService is used to reject messages which contain a blocked term
+	contentFilterService *services.ContentFilterService
 }
 
 // NewMessageHandlerValidator creates a new handlers.MessageHandler validator
@@ -30,11 +45,15 @@ func NewMessageHandlerValidator(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	phoneService *services.PhoneService,
+	userService *services.UserService,
+	contentFilterService *services.ContentFilterService,
 ) (v *MessageHandlerValidator) {
 	return &MessageHandlerValidator{
-		logger:       logger.WithService(fmt.Sprintf("%T", v)),
-		tracer:       tracer,
-		phoneService: phoneService,
+		logger:               logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:               tracer,
+		phoneService:         phoneService,
+		userService:          userService,
+		contentFilterService: contentFilterService,
 	}
 }
 
@@ -50,11 +69,7 @@ func (validator MessageHandlerValidator) ValidateMessageReceive(_ context.Contex
 			"from": []string{
 				"required",
 			},
-			"content": []string{
-				"required",
-				"min:1",
-				"max:2048",
-			},
+			"content": validator.messageReceiveContentRules(request),
 			"sim": []string{
 				"required",
 				"in:" + strings.Join([]string{
@@ -65,7 +80,45 @@ func (validator MessageHandlerValidator) ValidateMessageReceive(_ context.Contex
 		},
 	})
 
-	return v.ValidateStruct()
+	result := v.ValidateStruct()
+	for key, values := range validator.validateMessageAttachments(request.Attachments) {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
+
+// messageReceiveContentRules returns the rules of the content field which can be empty for an MMS message with attachments
+func (validator MessageHandlerValidator) messageReceiveContentRules(request requests.MessageReceive) []string {
+	if len(request.Attachments) > 0 {
+		return []string{"max:2048"}
+	}
+	return []string{"required", "min:1", "max:2048"}
+}
+
+// validateMessageAttachments checks the content type and the size of the media files received in an MMS message
+func (validator MessageHandlerValidator) validateMessageAttachments(attachments []requests.MessageReceiveAttachment) url.Values {
+	result := url.Values{}
+	if len(attachments) > maxMessageAttachments {
+		result.Add("attachments", fmt.Sprintf("The attachments field cannot have more than [%d] media files", maxMessageAttachments))
+		return result
+	}
+
+	for index, attachment := range attachments {
+		if _, _, err := mime.ParseMediaType(attachment.ContentType); err != nil || len(attachment.ContentType) > 255 {
+			result.Add("attachments", fmt.Sprintf("The content_type [%s] of attachment [%d] is not a valid media type e.g. image/jpeg", attachment.ContentType, index))
+		}
+
+		content, err := base64.StdEncoding.DecodeString(attachment.Content)
+		if err != nil || len(content) == 0 {
+			result.Add("attachments", fmt.Sprintf("The content of attachment [%d] must be a non empty base64 encoded string", index))
+			continue
+		}
+
+		if len(content) > maxMessageAttachmentSize {
+			result.Add("attachments", fmt.Sprintf("The content of attachment [%d] has [%d] bytes which is more than the maximum of [%d] bytes", index, len(content), maxMessageAttachmentSize))
+		}
+	}
+	return result
 }
 
 // ValidateMessageSend validates the requests.MessageSend request
@@ -78,17 +131,11 @@ func (validator MessageHandlerValidator) ValidateMessageSend(ctx context.Context
 	v := govalidator.New(govalidator.Options{
 		Data: &request,
 		Rules: govalidator.MapData{
-			"to": []string{
-				"required",
-				contactPhoneNumberRule,
-			},
+			"to": validator.messageSendToRules(request),
 			"request_id": []string{
 				"max:255",
 			},
-			"from": []string{
-				"required",
-				phoneNumberRule,
-			},
+			"from": validator.messageSendFromRules(request),
 			"content": []string{
 				"required",
 				"min:1",
@@ -98,23 +145,328 @@ func (validator MessageHandlerValidator) ValidateMessageSend(ctx context.Context
 	})
 
 	result := v.ValidateStruct()
+	for key, values := range validator.validateMessageMetadata("metadata", request.Metadata) {
+		result[key] = append(result[key], values...)
+	}
+
+	if !request.IsFanOut() && validator.isAlphanumericSenderID(request.To) {
+		result["to"] = []string{fmt.Sprintf("The to field cannot be the alphanumeric sender ID [%s] because replies to alphanumeric senders cannot be routed", request.To)}
+	}
+
+	for key, values := range validator.validateMessageFanOut(request) {
+		result[key] = append(result[key], values...)
+	}
+
+	if request.IsAlphanumericSender() && !validator.isAlphanumericSenderID(request.From) {
+		result.Add("from", "The from field must be a valid E.164 phone number or an alphanumeric sender ID with 1 to 11 letters, digits or spaces")
+	}
+
+	// carriers accept a relative validity period of at least 5 minutes and FCM keeps a push notification for at most 4 weeks
+	if request.ValidityPeriod != nil && (*request.ValidityPeriod < 5*60 || *request.ValidityPeriod > 28*24*60*60) {
+		result.Add("validity_period", "The validity_period field must be between 300 (5 minutes) and 2419200 (4 weeks) seconds")
+	}
+
+	if _, err := uuid.Parse(request.InReplyTo); request.InReplyTo != "" && err != nil {
+		result.Add("in_reply_to", "The in_reply_to field must be a valid message ID")
+	}
+
+	if timezone := services.MessageSendAtTimezone(request.SendAtTimezone); timezone != "" && timezone != services.MessageSendAtTimezoneUser && timezone != services.MessageSendAtTimezonePhone {
+		result.Add("send_at_timezone", fmt.Sprintf("The send_at_timezone field must be either [%s] or [%s]", services.MessageSendAtTimezoneUser, services.MessageSendAtTimezonePhone))
+	}
+
+	if request.SendAtTimezone != "" && request.SendAt == nil {
+		result.Add("send_at_timezone", "The send_at_timezone field can only be used with the send_at field")
+	}
+
+	if _, err := uuid.Parse(request.ID); request.ID != "" && err != nil {
+		result.Add("id", "The id field must be a valid UUID e.g. b0f3a8d2-3c4e-4c1b-9d2a-6f1e2b7c8d9e")
+	}
+
+	if request.FromGroup != "" && request.From != "" {
+		result.Add("from_group", "The from_group field cannot be used together with the from field")
+	}
+
+	if len(request.FromGroup) > 50 {
+		result.Add("from_group", "The from_group field must have at most 50 characters")
+	}
+
 	if len(result) != 0 {
 		return result
 	}
 
-	_, err := validator.phoneService.Load(ctx, userID, request.From)
+	if request.FromGroup != "" {
+		phone, result := validator.validateFromGroup(ctx, userID, request.FromGroup)
+		if len(result) != 0 {
+			return result
+		}
+		return validator.validateSegments(ctx, userID, phone, request.Content, !request.Encrypted && !request.SkipSignature)
+	}
+
+	// each recipient of a fan out is routed when it is sent and the recipients which have no route are returned as failures
+	if request.From == "" && request.IsFanOut() {
+		return result
+	}
+
+	if request.From == "" {
+		phone, result := validator.validateCountryRoute(ctx, userID, request.To)
+		if len(result) != 0 {
+			return result
+		}
+		return validator.validateSegments(ctx, userID, phone, request.Content, !request.Encrypted && !request.SkipSignature)
+	}
+
+	if request.IsAlphanumericSender() {
+		phone, result := validator.validateAlphanumericSender(ctx, userID, request.From)
+		if len(result) != 0 {
+			return result
+		}
+		return validator.validateSegments(ctx, userID, phone, request.Content, !request.Encrypted && !request.SkipSignature)
+	}
+
+	phone, err := validator.phoneService.Load(ctx, userID, request.From)
 	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
 		result.Add("from", fmt.Sprintf("no phone found with with 'from' number [%s]. install the android app on your phone to start sending messages", request.From))
+		return result
 	}
 
 	if err != nil {
 		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, request.From))))
 		result.Add("from", fmt.Sprintf("could not validate 'from' number [%s], please try again later", request.From))
+		return result
+	}
+
+	return validator.validateSegments(ctx, userID, phone, request.Content, !request.Encrypted && !request.SkipSignature)
+}
+
+// ValidateMessageReply validates requests.MessageReply before the reply token is verified
+func (validator MessageHandlerValidator) ValidateMessageReply(_ context.Context, request requests.MessageReply) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"reply_token": []string{
+				"required",
+				"max:1024",
+			},
+			"content": []string{
+				"required",
+				"min:1",
+				"max:2048",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateMessageReplySegments checks that the content of a reply fits in the maximum number of SMS segments of the phone which received the message
+func (validator MessageHandlerValidator) ValidateMessageReplySegments(ctx context.Context, claims *services.ReplyTokenClaims, content string) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	result := url.Values{}
+	phone, err := validator.phoneService.Load(ctx, claims.UserID, claims.Owner)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("reply_token", fmt.Sprintf("the phone [%s] which received the message no longer exists", claims.Owner))
+		return result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", claims.UserID, claims.Owner))))
+		result.Add("reply_token", fmt.Sprintf("could not validate the phone [%s] which received the message, please try again later", claims.Owner))
+		return result
+	}
+
+	return validator.validateSegments(ctx, claims.UserID, phone, content, true)
+}
+
+// validateSegments checks that the content fits in the maximum number of SMS segments of the phone or the user's plan, the signature of the phone is counted when the content is signed
+func (validator MessageHandlerValidator) validateSegments(ctx context.Context, userID entities.UserID, phone *entities.Phone, content string, signed bool) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	result := url.Values{}
+	user, err := validator.userService.GetByID(ctx, userID)
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load user [%s] to validate message segments", userID))))
+		result.Add("content", "could not validate the number of segments in the content, please try again later")
+		return result
+	}
+
+	if signed && phone.Signature != nil {
+		segments, encoding := services.CountSMSSegments(phone.SignedContent(content))
+		if maxSegments := phone.MaxSegmentsOrDefault(user.SubscriptionName); segments > maxSegments {
+			result.Add("content", fmt.Sprintf("The content with the signature of the phone has [%d] %s segments which is more than the maximum of [%d] segments allowed on your plan", segments, encoding, maxSegments))
+		}
+		return result
+	}
+
+	segments, encoding := services.CountSMSSegments(content)
+	if maxSegments := phone.MaxSegmentsOrDefault(user.SubscriptionName); segments > maxSegments {
+		result.Add("content", fmt.Sprintf("The content has [%d] %s segments which is more than the maximum of [%d] segments allowed on your plan", segments, encoding, maxSegments))
+	}
+	return result
+}
+
+// ValidateMessageContent checks the content of a message against the outbound content filters of the user.
+// The errors contain the blocked term so they must not be logged.
+func (validator MessageHandlerValidator) ValidateMessageContent(ctx context.Context, userID entities.UserID, content string, encrypted bool) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	result := url.Values{}
+
+	// the content of an encrypted message cannot be read by the server
+	if encrypted {
+		return result
 	}
 
+	filter, err := validator.contentFilterService.Match(ctx, userID, entities.ContentFilterDirectionOutbound, content)
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not match the content filters of user [%s]", userID))))
+		result.Add("content", "could not check the content against your content filters, please try again later")
+		return result
+	}
+
+	if filter != nil && filter.IsRegex {
+		result.Add("content", fmt.Sprintf("The content matches the blocked pattern [%s] of your content filters", filter.Term))
+	} else if filter != nil {
+		result.Add("content", fmt.Sprintf("The content contains the blocked term [%s] of your content filters", filter.Term))
+	}
 	return result
 }
 
+// messageSendToRules returns the rules of the to field which are checked for each recipient by ValidateMessageSendRecipient when it is an array
+func (validator MessageHandlerValidator) messageSendToRules(request requests.MessageSend) []string {
+	if request.IsFanOut() {
+		return []string{}
+	}
+	return []string{"required", contactPhoneNumberRule}
+}
+
+// validateMessageFanOut checks the structure of a requests.MessageSend whose to field is an array of recipients
+func (validator MessageHandlerValidator) validateMessageFanOut(request requests.MessageSend) url.Values {
+	result := url.Values{}
+	if !request.IsFanOut() {
+		return result
+	}
+
+	if len(request.Recipients) == 0 || len(request.Recipients) > 100 {
+		result.Add("to", "The to field must contain between 1 and 100 phone numbers when it is an array, use the bulk messages API to send to more recipients")
+	}
+
+	// the client generated ID and the replied message identify a single message in a single conversation
+	if request.ID != "" {
+		result.Add("id", "The id field cannot be set when the to field is an array of phone numbers")
+	}
+
+	if request.InReplyTo != "" {
+		result.Add("in_reply_to", "The in_reply_to field cannot be set when the to field is an array of phone numbers")
+	}
+	return result
+}
+
+// ValidateMessageSendRecipient validates a recipient of a requests.MessageSend whose to field is an array
+func (validator MessageHandlerValidator) ValidateMessageSendRecipient(to string) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &struct {
+			To string `json:"to"`
+		}{To: to},
+		Rules: govalidator.MapData{
+			"to": []string{
+				"required",
+				contactPhoneNumberRule,
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if validator.isAlphanumericSenderID(to) {
+		result["to"] = []string{fmt.Sprintf("The to field cannot be the alphanumeric sender ID [%s] because replies to alphanumeric senders cannot be routed", to)}
+	}
+	return result
+}
+
+// messageSendFromRules returns the rules of the from field which is a phone number unless it is an alphanumeric sender ID
+func (validator MessageHandlerValidator) messageSendFromRules(request requests.MessageSend) []string {
+	// the phone is selected by the country routes of the user when both the from and the from_group fields are empty
+	if request.FromGroup != "" || request.From == "" {
+		return []string{}
+	}
+	if request.IsAlphanumericSender() {
+		return []string{"required", "max:11"}
+	}
+	return []string{"required", phoneNumberRule}
+}
+
+// validateFromGroup checks that the group has a phone which can send the message
+func (validator MessageHandlerValidator) validateFromGroup(ctx context.Context, userID entities.UserID, group string) (*entities.Phone, url.Values) {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	result := url.Values{}
+	phone, err := validator.phoneService.SelectGroupPhone(ctx, userID, group)
+	if stacktrace.GetCode(err) == services.ErrCodePhoneGroupEmpty {
+		result.Add("from_group", fmt.Sprintf("no phone found in the group [%s]. add the group to your phones to start sending messages from the group", group))
+		return nil, result
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhonePaused {
+		result.Add("from_group", fmt.Sprintf("all the phones in the group [%s] are paused. resume a phone of the group to start sending messages from the group", group))
+		return nil, result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not select a phone of group [%s] for user [%s]", group, userID))))
+		result.Add("from_group", fmt.Sprintf("could not validate the group [%s], please try again later", group))
+		return nil, result
+	}
+	return phone, result
+}
+
+// validateCountryRoute checks that a country route of the user selects a phone which can send a message without a from number to the recipient
+func (validator MessageHandlerValidator) validateCountryRoute(ctx context.Context, userID entities.UserID, to string) (*entities.Phone, url.Values) {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	result := url.Values{}
+	phone, _, err := validator.phoneService.SelectCountryPhone(ctx, userID, to)
+	if stacktrace.GetCode(err) == services.ErrCodeCountryRouteNotFound {
+		result.Add("from", fmt.Sprintf("no country route found for the recipient [%s]. set the from field or add a default country route to send messages without a from number", to))
+		return nil, result
+	}
+
+	if stacktrace.GetCode(err) == services.ErrCodePhoneGroupEmpty || stacktrace.GetCode(err) == services.ErrCodePhonePaused {
+		result.Add("from", fmt.Sprintf("the country route of the recipient [%s] has no phone which can send the message. set the from field or update the country route", to))
+		return nil, result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not select the phone of the country route of [%s] for user [%s]", to, userID))))
+		result.Add("from", fmt.Sprintf("could not validate the country route of the recipient [%s], please try again later", to))
+		return nil, result
+	}
+	return phone, result
+}
+
+// validateAlphanumericSender checks that the user has a phone whose SIM supports the alphanumeric sender ID
+func (validator MessageHandlerValidator) validateAlphanumericSender(ctx context.Context, userID entities.UserID, senderID string) (*entities.Phone, url.Values) {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	result := url.Values{}
+	phone, err := validator.phoneService.LoadByAlphanumericSenderID(ctx, userID, senderID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("from", fmt.Sprintf("no phone found with the alphanumeric sender ID [%s]. set the sender ID on a phone whose SIM supports alphanumeric senders", senderID))
+		return nil, result
+	}
+
+	if err != nil {
+		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and sender ID [%s]", userID, senderID))))
+		result.Add("from", fmt.Sprintf("could not validate 'from' sender ID [%s], please try again later", senderID))
+	}
+
+	return phone, result
+}
+
 // ValidateMessageBulkSend validates the requests.MessageBulkSend request
 func (validator MessageHandlerValidator) ValidateMessageBulkSend(ctx context.Context, userID entities.UserID, request requests.MessageBulkSend) url.Values {
 	ctx, span := validator.tracer.Start(ctx)
@@ -125,11 +477,11 @@ func (validator MessageHandlerValidator) ValidateMessageBulkSend(ctx context.Con
 	v := govalidator.New(govalidator.Options{
 		Data: &request,
 		Rules: govalidator.MapData{
+			// the recipients are validated one by one so that an invalid phone number does not reject the other recipients
 			"to": []string{
 				"required",
 				"max:1000",
 				"min:1",
-				multipleContactPhoneNumberRule,
 			},
 			"from": []string{
 				"required",
@@ -148,17 +500,19 @@ func (validator MessageHandlerValidator) ValidateMessageBulkSend(ctx context.Con
 		return result
 	}
 
-	_, err := validator.phoneService.Load(ctx, userID, request.From)
+	phone, err := validator.phoneService.Load(ctx, userID, request.From)
 	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
 		result.Add("from", fmt.Sprintf("no phone found with with 'from' number [%s]. Install the android app on your phone to start sending messages", request.From))
+		return result
 	}
 
 	if err != nil {
 		ctxLogger.Error(validator.tracer.WrapErrorSpan(span, stacktrace.Propagate(err, fmt.Sprintf("could not load phone for user [%s] and phone [%s]", userID, request.From))))
 		result.Add("from", fmt.Sprintf("could not validate 'from' number [%s], please try again later", request.From))
+		return result
 	}
 
-	return result
+	return validator.validateSegments(ctx, userID, phone, request.Content, true)
 }
 
 // ValidateMessageOutstanding validates the requests.MessageOutstanding request
@@ -175,8 +529,73 @@ func (validator MessageHandlerValidator) ValidateMessageOutstanding(_ context.Co
 	return v.ValidateStruct()
 }
 
+// ValidateMessagePoll validates the requests.MessagePoll request
+func (validator MessageHandlerValidator) ValidateMessagePoll(_ context.Context, request requests.MessagePoll) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if _, _, err := services.ParseMessagePollCursor(request.Since); err != nil {
+		result.Add("since", "The since field must be a cursor returned by a previous poll or an RFC3339 timestamp")
+	}
+	return result
+}
+
+// ValidateMessageSendWait validates the requests.MessageSendWait query parameters of a send request
+func (validator MessageHandlerValidator) ValidateMessageSendWait(_ context.Context, request requests.MessageSendWait, send requests.MessageSend) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"wait": []string{
+				"in:true,false",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if timeout, err := time.ParseDuration(request.Timeout); err != nil || timeout < time.Second || timeout > 30*time.Second {
+		result.Add("timeout", "The timeout field must be a duration between 1s and 30s e.g. 5s")
+	}
+
+	if request.IsWaiting() && send.SendAt != nil {
+		result.Add("wait", "The wait field cannot be used with send_at because the push notification of a scheduled message is sent later")
+	}
+
+	if request.IsWaiting() && send.IsFanOut() {
+		result.Add("wait", "The wait field cannot be used when the to field contains multiple recipients")
+	}
+	return result
+}
+
 // ValidateMessageIndex validates the requests.MessageIndex request
-func (validator MessageHandlerValidator) ValidateMessageIndex(_ context.Context, request requests.MessageIndex) url.Values {
+func (validator MessageHandlerValidator) ValidateMessageIndex(ctx context.Context, request requests.MessageIndex) url.Values {
+	if result := validator.validateContactMatch(request); len(result) > 0 {
+		return result
+	}
+
+	if request.MetadataKey != "" || request.MetadataValue != "" {
+		return validator.validateMessageIndexByMetadata(request)
+	}
+
+	if request.LabelID != "" {
+		if result := validator.ValidateUUID(ctx, request.LabelID, "label_id"); len(result) > 0 {
+			return result
+		}
+	}
+
+	if request.Starred == "true" || request.LabelID != "" {
+		return validator.validateMessageIndexByStarred(request)
+	}
+
 	v := govalidator.New(govalidator.Options{
 		Data: &request,
 		Rules: govalidator.MapData{
@@ -202,11 +621,130 @@ func (validator MessageHandlerValidator) ValidateMessageIndex(_ context.Context,
 				"required",
 				phoneNumberRule,
 			},
+			"category": []string{
+				"in:" + validator.messageCategories(),
+			},
+			"language": []string{
+				"in:" + strings.Join(services.DetectedLanguages(), ","),
+			},
+			"starred": []string{
+				"in:true,false",
+			},
 		},
 	})
 	return v.ValidateStruct()
 }
 
+// validateContactMatch validates the contact_match of the requests.MessageIndex, the partial matches need enough digits to use the slower query
+func (validator MessageHandlerValidator) validateContactMatch(request requests.MessageIndex) url.Values {
+	result := url.Values{}
+	if request.ContactMatch == "" {
+		return result
+	}
+
+	var matches []string
+	for _, match := range repositories.ContactMatches() {
+		matches = append(matches, string(match))
+	}
+
+	if !slices.Contains(matches, request.ContactMatch) {
+		result.Add("contact_match", fmt.Sprintf("The contact_match field must be one of [%s]", strings.Join(matches, ", ")))
+		return result
+	}
+
+	if repositories.ContactMatch(request.ContactMatch).IsPartial() && (len(request.Contact) < 4 || len(request.Contact) > 15) {
+		result.Add("contact", fmt.Sprintf("The contact field must have between 4 and 15 digits when the contact_match is [%s]", request.ContactMatch))
+	}
+	return result
+}
+
+// validateMessageIndexByStarred validates the requests.MessageIndex request when filtering by starred messages or by a label
+func (validator MessageHandlerValidator) validateMessageIndexByStarred(request requests.MessageIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:20",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"contact": []string{
+				contactPhoneNumberRule,
+			},
+			"query": []string{
+				"max:100",
+			},
+			"owner": []string{
+				phoneNumberRule,
+			},
+			"category": []string{
+				"in:" + validator.messageCategories(),
+			},
+			"language": []string{
+				"in:" + strings.Join(services.DetectedLanguages(), ","),
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// validateMessageIndexByMetadata validates the requests.MessageIndex request when filtering by metadata
+func (validator MessageHandlerValidator) validateMessageIndexByMetadata(request requests.MessageIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:20",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"contact": []string{
+				contactPhoneNumberRule,
+			},
+			"query": []string{
+				"max:100",
+			},
+			"owner": []string{
+				phoneNumberRule,
+			},
+			"metadata_key": []string{
+				"required",
+			},
+			"metadata_value": []string{
+				"required",
+				"max:500",
+			},
+			"category": []string{
+				"in:" + validator.messageCategories(),
+			},
+			"language": []string{
+				"in:" + strings.Join(services.DetectedLanguages(), ","),
+			},
+			"starred": []string{
+				"in:true,false",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	for key, values := range validator.validateMessageMetadata("metadata_key", map[string]string{request.MetadataKey: request.MetadataValue}) {
+		result[key] = append(result[key], values...)
+	}
+	return result
+}
+
 // ValidateMessageSearch validates the requests.MessageSearch request
 func (validator MessageHandlerValidator) ValidateMessageSearch(_ context.Context, request requests.MessageSearch) url.Values {
 	v := govalidator.New(govalidator.Options{
@@ -227,9 +765,11 @@ func (validator MessageHandlerValidator) ValidateMessageSearch(_ context.Context
 					entities.MessageStatusPending,
 					entities.MessageStatusSent,
 					entities.MessageStatusDelivered,
+					entities.MessageStatusRead,
 					entities.MessageStatusFailed,
 					entities.MessageStatusExpired,
 					entities.MessageStatusReceived,
+					entities.MessageStatusCancelled,
 				}, ","),
 			},
 			"sort_by": []string{
@@ -282,6 +822,84 @@ func (validator MessageHandlerValidator) ValidateMessageEvent(_ context.Context,
 	return v.ValidateStruct()
 }
 
+// ValidateReadReceipt validates the requests.MessageReadReceipt request
+func (validator MessageHandlerValidator) ValidateReadReceipt(_ context.Context, request requests.MessageReadReceipt) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"messageID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.Timestamp.After(time.Now().UTC().Add(time.Minute)) {
+		result.Add("timestamp", "The timestamp field cannot be in the future")
+	}
+	return result
+}
+
+// ValidateReact validates the requests.MessageReact request
+func (validator MessageHandlerValidator) ValidateReact(_ context.Context, request requests.MessageReact) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"messageID": []string{
+				"required",
+				"uuid",
+			},
+			"emoji": []string{
+				"required",
+				"max:32",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateReaction validates the requests.MessageReaction request
+func (validator MessageHandlerValidator) ValidateReaction(_ context.Context, request requests.MessageReaction) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"messageID": []string{
+				"required",
+				"uuid",
+			},
+			"emoji": []string{
+				"max:32",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.Emoji == "" && (request.Supported == nil || *request.Supported) {
+		result.Add("emoji", "The emoji field is required")
+	}
+	if request.Timestamp.After(time.Now().UTC().Add(time.Minute)) {
+		result.Add("timestamp", "The timestamp field cannot be in the future")
+	}
+	return result
+}
+
+// ValidateMarkRead validates the requests.MessageMarkRead request
+func (validator MessageHandlerValidator) ValidateMarkRead(_ context.Context, request requests.MessageMarkRead) url.Values {
+	result := url.Values{}
+	if len(request.MessageIDs) == 0 || len(request.MessageIDs) > maxMessagesMarkedRead {
+		result.Add("message_ids", fmt.Sprintf("The message_ids field must contain between 1 and %d message IDs", maxMessagesMarkedRead))
+		return result
+	}
+
+	for _, id := range request.MessageIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			result.Add("message_ids", fmt.Sprintf("The message ID [%s] is not a valid UUID", id))
+		}
+	}
+	return result
+}
+
 // ValidateCallMissed validates the requests.MessageCallMissed request
 func (validator MessageHandlerValidator) ValidateCallMissed(_ context.Context, request requests.MessageCallMissed) url.Values {
 	v := govalidator.New(govalidator.Options{
@@ -0,0 +1,133 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// MessageRuleHandlerValidator validates models used in handlers.MessageRuleHandler
+type MessageRuleHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewMessageRuleHandlerValidator creates a new handlers.MessageRuleHandler validator
+func NewMessageRuleHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *MessageRuleHandlerValidator) {
+	return &MessageRuleHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateIndex validates the requests.MessageRuleIndex request
+func (validator *MessageRuleHandlerValidator) ValidateIndex(_ context.Context, request requests.MessageRuleIndex) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"limit": []string{
+				"required",
+				"numeric",
+				"min:1",
+				"max:100",
+			},
+			"skip": []string{
+				"required",
+				"numeric",
+				"min:0",
+			},
+			"query": []string{
+				"max:100",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateStore validates the requests.MessageRuleStore request
+func (validator *MessageRuleHandlerValidator) ValidateStore(_ context.Context, request requests.MessageRuleStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: validator.rules(),
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+	return validator.validateRule(request)
+}
+
+// ValidateUpdate validates the requests.MessageRuleUpdate request
+func (validator *MessageRuleHandlerValidator) ValidateUpdate(_ context.Context, request requests.MessageRuleUpdate) url.Values {
+	rules := validator.rules()
+	rules["messageRuleID"] = []string{
+		"required",
+		"uuid",
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+	return validator.validateRule(request.MessageRuleStore)
+}
+
+func (validator *MessageRuleHandlerValidator) rules() govalidator.MapData {
+	return govalidator.MapData{
+		"name": []string{
+			"required",
+			"min:1",
+			"max:100",
+		},
+		"sender": []string{
+			contactPhoneNumberRule,
+		},
+		"recipient": []string{
+			phoneNumberRule,
+		},
+		"content_pattern": []string{
+			"max:255",
+		},
+		"webhook_id": []string{
+			"uuid",
+		},
+		"reply_content": []string{
+			"max:2048",
+		},
+		"label_id": []string{
+			"uuid",
+		},
+	}
+}
+
+// validateRule checks that the content pattern compiles, the schedule is valid and the rule has at least one action
+func (validator *MessageRuleHandlerValidator) validateRule(request requests.MessageRuleStore) url.Values {
+	result := url.Values{}
+	if request.ContentPattern != "" {
+		if _, err := regexp.Compile(request.ContentPattern); err != nil {
+			result.Add("content_pattern", "The content_pattern field must be a valid regular expression")
+		}
+	}
+
+	validator.validateBusinessHours(result, "schedule", request.Schedule)
+
+	if request.WebhookID == "" && request.ReplyContent == "" && request.LabelID == "" {
+		result.Add("webhook_id", "The message rule must have a webhook_id, a reply_content or a label_id")
+	}
+	return result
+}
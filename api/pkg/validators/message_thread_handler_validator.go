@@ -74,3 +74,23 @@ func (validator *MessageThreadHandlerValidator) ValidateUpdate(_ context.Context
 
 	return v.ValidateStruct()
 }
+
+// ValidateDeleteMessages validates requests.MessageThreadDeleteMessages
+func (validator *MessageThreadHandlerValidator) ValidateDeleteMessages(_ context.Context, request requests.MessageThreadDeleteMessages) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"ownerNumber": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"contactNumber": []string{
+				"required",
+				"min:1",
+				"max:50",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
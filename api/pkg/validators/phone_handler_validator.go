@@ -4,15 +4,27 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"regexp"
 	"strings"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
 	"github.com/thedevsaddam/govalidator"
 )
 
+// phoneAutoAckMessageMaxLength is the maximum length of the auto_ack_message of a phone which is the same as the content of a message
+const phoneAutoAckMessageMaxLength = 2048
+
+// phoneExternalIDMaxLength is the maximum length of the ID of a phone in an external system
+const phoneExternalIDMaxLength = 255
+
+// phoneSignatureMaxLength is the maximum length of the signature of a phone which is the length of a single GSM-7 segment
+const phoneSignatureMaxLength = 160
+
 // PhoneHandlerValidator validates models used in handlers.PhoneHandler
 type PhoneHandlerValidator struct {
 	validator
@@ -50,6 +62,9 @@ func (validator *PhoneHandlerValidator) ValidateIndex(_ context.Context, request
 			"query": []string{
 				"max:100",
 			},
+			"external_id": []string{
+				"max:255",
+			},
 		},
 	})
 	return v.ValidateStruct()
@@ -84,6 +99,18 @@ func (validator *PhoneHandlerValidator) ValidateUpsert(_ context.Context, reques
 				"min:60",
 				"max:3600",
 			},
+			"battery_low_threshold": []string{
+				"min:0",
+				"max:100",
+			},
+			"heartbeat_interval_seconds": []string{
+				"min:0",
+				"max:86400",
+			},
+			"max_concurrent_sends": []string{
+				"min:0",
+				"max:100",
+			},
 		},
 	})
 
@@ -92,15 +119,356 @@ func (validator *PhoneHandlerValidator) ValidateUpsert(_ context.Context, reques
 		return result
 	}
 
+	if timeout := request.DeliveryReportTimeoutSeconds; timeout != nil && *timeout != 0 && (*timeout < 60 || *timeout > 259200) {
+		result.Add("delivery_report_timeout_seconds", "delivery_report_timeout_seconds must be 0 or between 60 and 259200")
+	}
+
 	if request.MaxSendAttempts > 0 && request.MessageExpirationSeconds == 0 {
 		result.Add("message_expiration_seconds", "message_expiration_seconds cannot be 0 when max_send_attempts is greater than 0")
 	}
 
+	if request.AutoAckMessage != nil && len(*request.AutoAckMessage) > phoneAutoAckMessageMaxLength {
+		result.Add("auto_ack_message", fmt.Sprintf("The auto_ack_message field must be less than %d characters", phoneAutoAckMessageMaxLength))
+	}
+
+	if request.AlphanumericSenderID != nil && !validator.isAlphanumericSenderID(*request.AlphanumericSenderID) {
+		result.Add("alphanumeric_sender_id", "The alphanumeric_sender_id field must contain 1 to 11 letters, digits or spaces and at least 1 letter")
+	}
+
+	if request.HeartbeatIntervalSeconds != 0 && request.HeartbeatIntervalSeconds < 60 {
+		result.Add("heartbeat_interval_seconds", "The heartbeat_interval_seconds field must be between 60 and 86400")
+	}
+
+	if request.DailyQuota != nil && *request.DailyQuota > 100_000 {
+		result.Add("daily_quota", "The daily_quota field must be between 0 and 100000")
+	}
+
+	if request.DailyQuotaTimezone != nil {
+		if _, err := time.LoadLocation(*request.DailyQuotaTimezone); err != nil {
+			result.Add("daily_quota_timezone", "The daily_quota_timezone field must be a valid timezone e.g. Europe/Helsinki")
+		}
+	}
+
+	if request.Timezone != nil {
+		if _, err := time.LoadLocation(*request.Timezone); err != nil || *request.Timezone == "Local" {
+			result.Add("timezone", "The timezone field must be a valid IANA timezone e.g. Europe/Helsinki")
+		}
+	}
+
+	if request.AllowedRecipients != nil {
+		for key, values := range validator.validateAllowedRecipients(*request.AllowedRecipients) {
+			result[key] = append(result[key], values...)
+		}
+	}
+
+	if request.OutOfOfficeMessage != nil && len(*request.OutOfOfficeMessage) > phoneAutoAckMessageMaxLength {
+		result.Add("out_of_office_message", fmt.Sprintf("The out_of_office_message field must be less than %d characters", phoneAutoAckMessageMaxLength))
+	}
+
+	if request.Signature != nil && len(*request.Signature) > phoneSignatureMaxLength {
+		result.Add("signature", fmt.Sprintf("The signature field must be less than %d characters", phoneSignatureMaxLength))
+	}
+
+	if request.ExternalID != nil && len(*request.ExternalID) > phoneExternalIDMaxLength {
+		result.Add("external_id", fmt.Sprintf("The external_id field must be less than %d characters", phoneExternalIDMaxLength))
+	}
+
+	if request.BusinessHours != nil {
+		validator.validateBusinessHours(result, "business_hours", *request.BusinessHours)
+	}
+
+	validator.validateVirtual(result, request.Type, request.VirtualURL)
+	if request.Type != nil && *request.Type == entities.PhoneTypeVirtual.String() && request.VirtualURL == nil {
+		result.Add("virtual_url", "The virtual_url field is required when the type is virtual")
+	}
+
+	return result
+}
+
+// ValidatePatch validates requests.PhonePatch
+func (validator *PhoneHandlerValidator) ValidatePatch(_ context.Context, request requests.PhonePatch) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	for _, field := range []string{"messages_per_minute", "message_expiration_seconds", "max_send_attempts", "battery_low_threshold", "daily_quota", "daily_quota_timezone", "sim", "heartbeat_interval_seconds", "max_concurrent_sends", "delivery_report_timeout_seconds", "timezone", "group_priority", "type"} {
+		if request.IsNull(field) {
+			result.Add(field, fmt.Sprintf("The %s field cannot be null", field))
+		}
+	}
+
+	validator.validateRange(result, "messages_per_minute", request.MessagesPerMinute, 1, 60)
+	validator.validateRange(result, "message_expiration_seconds", request.MessageExpirationSeconds, 60, 3600)
+	validator.validateRange(result, "max_send_attempts", request.MaxSendAttempts, 1, 5)
+	validator.validateRange(result, "battery_low_threshold", request.BatteryLowThreshold, 1, 100)
+	validator.validateRange(result, "daily_quota", request.DailyQuota, 0, 100_000)
+	validator.validateRange(result, "heartbeat_interval_seconds", request.HeartbeatIntervalSeconds, 60, 86400)
+	validator.validateRange(result, "max_concurrent_sends", request.MaxConcurrentSends, 0, 100)
+
+	if timeout := request.DeliveryReportTimeoutSeconds; timeout != nil && *timeout != 0 && (*timeout < 60 || *timeout > 259200) {
+		result.Add("delivery_report_timeout_seconds", "delivery_report_timeout_seconds must be 0 or between 60 and 259200")
+	}
+
+	if request.SIM != nil && *request.SIM != entities.SIM1.String() && *request.SIM != entities.SIM2.String() {
+		result.Add("sim", fmt.Sprintf("The sim field must be one of %s, %s", entities.SIM1, entities.SIM2))
+	}
+
+	if request.AutoAckMessage != nil && len(*request.AutoAckMessage) > phoneAutoAckMessageMaxLength {
+		result.Add("auto_ack_message", fmt.Sprintf("The auto_ack_message field must be less than %d characters", phoneAutoAckMessageMaxLength))
+	}
+
+	if request.AlphanumericSenderID != nil && *request.AlphanumericSenderID != "" && !validator.isAlphanumericSenderID(*request.AlphanumericSenderID) {
+		result.Add("alphanumeric_sender_id", "The alphanumeric_sender_id field must contain 1 to 11 letters, digits or spaces and at least 1 letter")
+	}
+
+	if request.Group != nil && len(*request.Group) > 50 {
+		result.Add("group", "The group field must be less than 50 characters")
+	}
+
+	if request.DailyQuotaTimezone != nil {
+		if _, err := time.LoadLocation(*request.DailyQuotaTimezone); err != nil || *request.DailyQuotaTimezone == "" {
+			result.Add("daily_quota_timezone", "The daily_quota_timezone field must be a valid timezone e.g. Europe/Helsinki")
+		}
+	}
+
+	if request.Timezone != nil {
+		if _, err := time.LoadLocation(*request.Timezone); err != nil || *request.Timezone == "" || *request.Timezone == "Local" {
+			result.Add("timezone", "The timezone field must be a valid IANA timezone e.g. Europe/Helsinki")
+		}
+	}
+
+	if request.AllowedRecipients != nil {
+		for key, values := range validator.validateAllowedRecipients(*request.AllowedRecipients) {
+			result[key] = append(result[key], values...)
+		}
+	}
+
+	if request.OutOfOfficeMessage != nil && len(*request.OutOfOfficeMessage) > phoneAutoAckMessageMaxLength {
+		result.Add("out_of_office_message", fmt.Sprintf("The out_of_office_message field must be less than %d characters", phoneAutoAckMessageMaxLength))
+	}
+
+	if request.Signature != nil && len(*request.Signature) > phoneSignatureMaxLength {
+		result.Add("signature", fmt.Sprintf("The signature field must be less than %d characters", phoneSignatureMaxLength))
+	}
+
+	if request.ExternalID != nil && len(*request.ExternalID) > phoneExternalIDMaxLength {
+		result.Add("external_id", fmt.Sprintf("The external_id field must be less than %d characters", phoneExternalIDMaxLength))
+	}
+
+	if request.BusinessHours != nil {
+		validator.validateBusinessHours(result, "business_hours", *request.BusinessHours)
+	}
+
+	validator.validateVirtual(result, request.Type, request.VirtualURL)
+
+	return result
+}
+
+// validateVirtual checks the type of a phone and the URL which receives the outgoing messages of a virtual phone
+func (validator *PhoneHandlerValidator) validateVirtual(result url.Values, phoneType *string, virtualURL *string) {
+	if phoneType != nil && *phoneType != entities.PhoneTypeAndroid.String() && *phoneType != entities.PhoneTypeVirtual.String() {
+		result.Add("type", fmt.Sprintf("The type field must be one of %s, %s", entities.PhoneTypeAndroid, entities.PhoneTypeVirtual))
+	}
+
+	if virtualURL == nil || *virtualURL == "" {
+		return
+	}
+
+	if target, err := url.ParseRequestURI(*virtualURL); err != nil || (target.Scheme != "http" && target.Scheme != "https") || target.Host == "" || len(*virtualURL) > 500 {
+		result.Add("virtual_url", "The virtual_url field must be a valid http or https URL with less than 500 characters")
+	}
+}
+
+// validateRange checks that an optional field of a partial update is between the min and max values
+func (validator *PhoneHandlerValidator) validateRange(result url.Values, field string, value *uint, min uint, max uint) {
+	if value != nil && (*value < min || *value > max) {
+		result.Add(field, fmt.Sprintf("The %s field must be between %d and %d", field, min, max))
+	}
+}
+
+// validateAllowedRecipients checks that the allowlist of a phone contains valid phone numbers
+func (validator *PhoneHandlerValidator) validateAllowedRecipients(recipients []string) url.Values {
+	result := url.Values{}
+	if len(recipients) > 1000 {
+		result.Add("allowed_recipients", "The allowed_recipients field cannot contain more than 1000 phone numbers")
+		return result
+	}
+
+	for index, recipient := range recipients {
+		if match, err := regexp.MatchString("^\\+?[0-9]\\d{1,14}$", recipient); err != nil || !match {
+			result.Add("allowed_recipients", fmt.Sprintf("The allowed_recipients field in index [%d] must be a phone number with only digits and less than 15 characters", index))
+		}
+	}
+	return result
+}
+
+// ValidateBulkStore validates requests.PhoneBulkStore
+func (validator *PhoneHandlerValidator) ValidateBulkStore(_ context.Context, request requests.PhoneBulkStore) url.Values {
+	result := url.Values{}
+	if len(request.Phones) == 0 || len(request.Phones) > 100 {
+		result.Add("phones", "The phones field must contain between 1 and 100 phones")
+		return result
+	}
+
+	for index, phone := range request.Phones {
+		v := govalidator.New(govalidator.Options{
+			Data: &phone,
+			Rules: govalidator.MapData{
+				"phone_number": []string{
+					"required",
+					phoneNumberRule,
+				},
+				"fcm_token": []string{
+					"min:0",
+					"max:1000",
+				},
+				"group": []string{
+					"max:50",
+				},
+				"external_id": []string{
+					"max:255",
+				},
+				"sim": []string{
+					"required",
+					"in:" + strings.Join([]string{entities.SIM1.String(), entities.SIM2.String()}, ","),
+				},
+			},
+		})
+
+		for _, errors := range v.ValidateStruct() {
+			for _, err := range errors {
+				result.Add("phones", fmt.Sprintf("phone [%d]: %s", index, err))
+			}
+		}
+	}
+
+	return result
+}
+
+// ValidateGroupUpsert validates requests.PhoneGroupUpsert
+func (validator *PhoneHandlerValidator) ValidateGroupUpsert(_ context.Context, request requests.PhoneGroupUpsert) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"group": []string{
+				"required",
+				"max:50",
+			},
+			"strategy": []string{
+				"required",
+				"in:" + strings.Join([]string{string(entities.PhoneGroupStrategyRoundRobin), string(entities.PhoneGroupStrategyFailover)}, ","),
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateCountryRouteUpsert validates requests.CountryRouteUpsert
+func (validator *PhoneHandlerValidator) ValidateCountryRouteUpsert(ctx context.Context, request requests.CountryRouteUpsert) url.Values {
+	ownerRules := []string{}
+	if request.Owner != "" {
+		ownerRules = []string{phoneNumberRule}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"owner": ownerRules,
+			"phone_group": []string{
+				"max:50",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	for key, values := range validator.ValidateCountry(ctx, request.Country) {
+		result[key] = append(result[key], values...)
+	}
+
+	if (request.Owner == "") == (request.PhoneGroup == "") {
+		result.Add("owner", "Either the owner field or the phone_group field must be set")
+	}
+	return result
+}
+
+// ValidateCountry validates the country of a country route
+func (validator *PhoneHandlerValidator) ValidateCountry(_ context.Context, country string) url.Values {
+	result := url.Values{}
+	if country != entities.CountryRouteDefault && !services.IsSupportedCountry(country) {
+		result.Add("country", fmt.Sprintf("The country field must be an ISO 3166-1 alpha-2 country code e.g. US or [%s] for the default route", entities.CountryRouteDefault))
+	}
 	return result
 }
 
 // ValidateDelete ValidateUpsert validates requests.PhoneDelete
 func (validator *PhoneHandlerValidator) ValidateDelete(_ context.Context, request requests.PhoneDelete) url.Values {
+	reassignToRules := []string{"max:50"}
+	if strings.HasPrefix(request.ReassignTo, "+") {
+		reassignToRules = []string{phoneNumberRule}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"reassign_to": reassignToRules,
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateFcmTokenUpdate validates requests.PhoneFcmTokenUpdate
+func (validator *PhoneHandlerValidator) ValidateFcmTokenUpdate(_ context.Context, request requests.PhoneFcmTokenUpdate) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"fcm_token": []string{
+				"required",
+				"max:1000",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidatePause validates requests.PhonePause
+func (validator *PhoneHandlerValidator) ValidatePause(_ context.Context, request requests.PhonePause) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateNotify validates requests.PhoneNotify
+func (validator *PhoneHandlerValidator) ValidateNotify(_ context.Context, request requests.PhoneNotify) url.Values {
 	v := govalidator.New(govalidator.Options{
 		Data: &request,
 		Rules: govalidator.MapData{
@@ -108,8 +476,44 @@ func (validator *PhoneHandlerValidator) ValidateDelete(_ context.Context, reques
 				"required",
 				"uuid",
 			},
+			"type": []string{
+				"required",
+				"in:" + validator.controlTypes(),
+			},
+			"message": []string{
+				"max:500",
+			},
+		},
+	})
+
+	return v.ValidateStruct()
+}
+
+// ValidateNotifyAll validates requests.PhoneNotifyAll
+func (validator *PhoneHandlerValidator) ValidateNotifyAll(_ context.Context, request requests.PhoneNotifyAll) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"type": []string{
+				"required",
+				"in:" + validator.controlTypes(),
+			},
+			"message": []string{
+				"max:500",
+			},
+			"group": []string{
+				"max:50",
+			},
 		},
 	})
 
 	return v.ValidateStruct()
 }
+
+func (validator *PhoneHandlerValidator) controlTypes() string {
+	types := make([]string, 0, len(entities.PhoneControlTypes))
+	for _, controlType := range entities.PhoneControlTypes {
+		types = append(types, controlType.String())
+	}
+	return strings.Join(types, ",")
+}
@@ -0,0 +1,57 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// PhoneProvisioningHandlerValidator validates models used in handlers.PhoneProvisioningHandler
+type PhoneProvisioningHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewPhoneProvisioningHandlerValidator creates a new handlers.PhoneProvisioningHandler validator
+func NewPhoneProvisioningHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *PhoneProvisioningHandlerValidator) {
+	return &PhoneProvisioningHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateExchange validates the requests.PhoneProvisioningExchange request
+func (validator *PhoneProvisioningHandlerValidator) ValidateExchange(_ context.Context, request requests.PhoneProvisioningExchange) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"code": []string{
+				"required",
+				"len:8",
+			},
+			"phone_number": []string{
+				"required",
+				phoneNumberRule,
+			},
+			"fcm_token": []string{
+				"min:0",
+				"max:1000",
+			},
+			"sim": []string{
+				"required",
+				"in:" + strings.Join([]string{entities.SIM1.String(), entities.SIM2.String()}, ","),
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
@@ -0,0 +1,102 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/repositories"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/palantir/stacktrace"
+	"github.com/thedevsaddam/govalidator"
+)
+
+// ProviderFallbackHandlerValidator validates models used in handlers.ProviderFallbackHandler
+type ProviderFallbackHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+
+	// service is used to check if the auth token can be encrypted and if the user already has an auth token
+	service *services.ProviderFallbackService
+}
+
+// NewProviderFallbackHandlerValidator creates a new handlers.ProviderFallbackHandler validator
+func NewProviderFallbackHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+	service *services.ProviderFallbackService,
+) (v *ProviderFallbackHandlerValidator) {
+	return &ProviderFallbackHandlerValidator{
+		logger:  logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:  tracer,
+		service: service,
+	}
+}
+
+// ValidateUpsert validates the requests.ProviderFallbackUpsert request
+func (validator *ProviderFallbackHandlerValidator) ValidateUpsert(ctx context.Context, userID entities.UserID, request requests.ProviderFallbackUpsert) url.Values {
+	ctx, span, ctxLogger := validator.tracer.StartWithLogger(ctx, validator.logger)
+	defer span.End()
+
+	providers := make([]string, 0, len(entities.SmsProviderNames))
+	for _, provider := range entities.SmsProviderNames {
+		providers = append(providers, provider.String())
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"provider": []string{
+				"required",
+				"in:" + strings.Join(providers, ","),
+			},
+			"account_id": []string{
+				"required",
+				"max:100",
+			},
+			"auth_token": []string{
+				"max:500",
+			},
+			"from": []string{
+				"required",
+				"max:20",
+			},
+			"timeout_seconds": []string{
+				"required",
+				"min:60",
+				"max:86400",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(result) > 0 {
+		return result
+	}
+
+	if !validator.service.Enabled() {
+		result.Add("auth_token", "the provider fallback cannot be stored because the PROVIDER_FALLBACK_ENCRYPTION_KEY is not configured")
+		return result
+	}
+
+	if request.AuthToken != "" {
+		return result
+	}
+
+	_, err := validator.service.Load(ctx, userID)
+	if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("auth_token", "The auth_token field is required")
+		return result
+	}
+
+	if err != nil {
+		ctxLogger.Error(stacktrace.Propagate(err, fmt.Sprintf("cannot load provider fallback for user [%s]", userID)))
+		result.Add("auth_token", "The auth_token could not be validated, please try again later")
+	}
+	return result
+}
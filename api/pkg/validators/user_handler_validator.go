@@ -4,9 +4,16 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"slices"
+	"strings"
+	"time"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/i18n"
 	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/services"
 	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/google/uuid"
 	"github.com/thedevsaddam/govalidator"
 )
 
@@ -36,8 +43,122 @@ func (validator *UserHandlerValidator) ValidateUpdate(_ context.Context, request
 			"active_phone_id": []string{
 				"uuid",
 			},
+			"locale": []string{
+				"in:" + validator.locales(),
+			},
 		},
 	})
 
+	result := v.ValidateStruct()
+	if _, err := time.LoadLocation(request.Timezone); err != nil {
+		result.Add("timezone", "The timezone field must be a valid timezone e.g. Europe/Helsinki")
+	}
+	if request.DefaultCountry != nil && *request.DefaultCountry != "" && !services.IsSupportedCountry(*request.DefaultCountry) {
+		result.Add("default_country", "The default_country field must be an ISO 3166-1 alpha-2 country code e.g. US")
+	}
+	validator.validateInboundContentLimit(result, request.InboundContentMaxLength, request.InboundContentLimitMode)
+	return result
+}
+
+// ValidatePatch validates requests.UserPatch
+func (validator *UserHandlerValidator) ValidatePatch(_ context.Context, request requests.UserPatch) url.Values {
+	result := url.Values{}
+	for _, field := range []string{"timezone", "locale", "language_detection_enabled", "inbound_content_max_length", "inbound_content_limit_mode"} {
+		if request.IsNull(field) {
+			result.Add(field, fmt.Sprintf("The %s field cannot be null", field))
+		}
+	}
+
+	if request.Timezone != nil {
+		if _, err := time.LoadLocation(*request.Timezone); err != nil || *request.Timezone == "" {
+			result.Add("timezone", "The timezone field must be a valid timezone e.g. Europe/Helsinki")
+		}
+	}
+	if request.ActivePhoneID != nil && *request.ActivePhoneID != "" {
+		if _, err := uuid.Parse(*request.ActivePhoneID); err != nil {
+			result.Add("active_phone_id", "The active_phone_id field must contain valid UUID")
+		}
+	}
+	if request.Locale != nil && !slices.Contains(strings.Split(validator.locales(), ","), *request.Locale) {
+		result.Add("locale", "The locale field must be one of "+validator.locales())
+	}
+	if request.DefaultCountry != nil && *request.DefaultCountry != "" && !services.IsSupportedCountry(*request.DefaultCountry) {
+		result.Add("default_country", "The default_country field must be an ISO 3166-1 alpha-2 country code e.g. US")
+	}
+	validator.validateInboundContentLimit(result, request.InboundContentMaxLength, request.InboundContentLimitMode)
+	return result
+}
+
+// validateInboundContentLimit checks the limit of the content of the received messages of a user
+func (validator *UserHandlerValidator) validateInboundContentLimit(result url.Values, maxLength *uint, mode *string) {
+	if maxLength != nil && *maxLength != 0 && (*maxLength < 160 || *maxLength > 100_000) {
+		result.Add("inbound_content_max_length", "The inbound_content_max_length field must be 0 or between 160 and 100000")
+	}
+
+	modes := []string{entities.InboundContentLimitModeTruncate.String(), entities.InboundContentLimitModeReject.String()}
+	if mode != nil && !slices.Contains(modes, *mode) {
+		result.Add("inbound_content_limit_mode", "The inbound_content_limit_mode field must be one of "+strings.Join(modes, ", "))
+	}
+}
+
+// ValidateSubAccountStore validates requests.UserSubAccountStore
+func (validator *UserHandlerValidator) ValidateSubAccountStore(_ context.Context, request requests.UserSubAccountStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"name": []string{
+				"required",
+				"max:100",
+			},
+		},
+	})
 	return v.ValidateStruct()
 }
+
+// ValidateMessageCategoryRulesUpdate validates the requests.UserMessageCategoryRulesUpdate request
+func (validator *UserHandlerValidator) ValidateMessageCategoryRulesUpdate(_ context.Context, request requests.UserMessageCategoryRulesUpdate) url.Values {
+	return validator.validateMessageCategoryRules("rules", request.Rules)
+}
+
+// ValidateFeatureFlagsUpdate validates the requests.UserFeatureFlagsUpdate request
+func (validator *UserHandlerValidator) ValidateFeatureFlagsUpdate(_ context.Context, request requests.UserFeatureFlagsUpdate) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"userID": []string{
+				"required",
+				"max:255",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if len(request.Flags) == 0 {
+		result.Add("flags", "The flags field must contain at least 1 feature flag")
+	}
+
+	for flag := range request.Flags {
+		if !slices.Contains(entities.FeatureFlags, entities.FeatureFlag(flag)) {
+			result.Add("flags", fmt.Sprintf("The feature flag [%s] is not supported, the supported flags are [%s]", flag, validator.featureFlags()))
+		}
+	}
+	return result
+}
+
+// featureFlags returns the supported feature flags as a comma separated string
+func (validator *UserHandlerValidator) featureFlags() string {
+	flags := make([]string, 0, len(entities.FeatureFlags))
+	for _, flag := range entities.FeatureFlags {
+		flags = append(flags, flag.String())
+	}
+	return strings.Join(flags, ", ")
+}
+
+// locales returns the supported locales as a comma separated string
+func (validator *UserHandlerValidator) locales() string {
+	locales := make([]string, 0, len(i18n.Locales))
+	for _, locale := range i18n.Locales {
+		locales = append(locales, locale.String())
+	}
+	return strings.Join(locales, ",")
+}
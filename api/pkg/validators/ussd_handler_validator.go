@@ -0,0 +1,108 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
+	"github.com/NdoleStudio/httpsms/pkg/telemetry"
+	"github.com/thedevsaddam/govalidator"
+)
+
+var ussdCodeRegex = regexp.MustCompile(`^[*#][0-9*#]*#$`)
+
+// UssdHandlerValidator validates models used in handlers.UssdHandler
+type UssdHandlerValidator struct {
+	validator
+	logger telemetry.Logger
+	tracer telemetry.Tracer
+}
+
+// NewUssdHandlerValidator creates a new handlers.UssdHandler validator
+func NewUssdHandlerValidator(
+	logger telemetry.Logger,
+	tracer telemetry.Tracer,
+) (v *UssdHandlerValidator) {
+	return &UssdHandlerValidator{
+		logger: logger.WithService(fmt.Sprintf("%T", v)),
+		tracer: tracer,
+	}
+}
+
+// ValidateStore validates the requests.UssdStore request
+func (validator *UssdHandlerValidator) ValidateStore(_ context.Context, request requests.UssdStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"code": []string{
+				"required",
+				"max:182",
+			},
+			"sim": []string{
+				"required",
+				"in:" + strings.Join([]string{entities.SIM1.String(), entities.SIM2.String()}, ","),
+			},
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if request.Code != "" && !ussdCodeRegex.MatchString(request.Code) {
+		result.Add("code", "The code field must be a valid USSD code e.g *123#")
+	}
+	return result
+}
+
+// ValidateLoad validates the requests.UssdLoad request
+func (validator *UssdHandlerValidator) ValidateLoad(_ context.Context, request requests.UssdLoad) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"sessionID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+	return v.ValidateStruct()
+}
+
+// ValidateResponse validates the requests.UssdResponseStore request
+func (validator *UssdHandlerValidator) ValidateResponse(_ context.Context, request requests.UssdResponseStore) url.Values {
+	v := govalidator.New(govalidator.Options{
+		Data: &request,
+		Rules: govalidator.MapData{
+			"response": []string{
+				"max:2048",
+			},
+			"failure_reason": []string{
+				"max:255",
+			},
+			"phoneID": []string{
+				"required",
+				"uuid",
+			},
+			"sessionID": []string{
+				"required",
+				"uuid",
+			},
+		},
+	})
+
+	result := v.ValidateStruct()
+	if strings.TrimSpace(request.Response) == "" && request.FailureReason == "" {
+		result.Add("response", "The response field is required when the failure_reason field is empty")
+	}
+	return result
+}
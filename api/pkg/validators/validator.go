@@ -2,12 +2,16 @@ package validators
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 
+	"github.com/NdoleStudio/httpsms/pkg/entities"
 	"github.com/NdoleStudio/httpsms/pkg/events"
+	"github.com/NdoleStudio/httpsms/pkg/requests"
 
 	"github.com/nyaruka/phonenumbers"
 	"github.com/thedevsaddam/govalidator"
@@ -21,8 +25,20 @@ const (
 	multipleContactPhoneNumberRule = "multipleContactPhoneNumber"
 	multipleInRule                 = "multipleIn"
 	webhookEventsRule              = "webhookEvents"
+
+	messageMetadataMaxKeys  = 16
+	messageMetadataMaxBytes = 2048
+
+	messageCategoryRulesMax         = 20
+	messageCategoryPatternMaxLength = 500
 )
 
+var messageMetadataKeyRegex = regexp.MustCompile(`^[a-zA-Z0-9_.\-]{1,64}$`)
+
+var alphanumericSenderIDRegex = regexp.MustCompile(`^[a-zA-Z0-9 ]{1,11}$`)
+
+var letterRegex = regexp.MustCompile(`[a-zA-Z]`)
+
 func init() {
 	// custom rules to take fixed length word.
 	// e.g: max_word:5 will throw error if the field contains more than 5 words
@@ -105,19 +121,9 @@ func init() {
 			return fmt.Errorf("The %s field is an empty array", field)
 		}
 
-		validEvents := map[string]bool{
-			events.EventTypeMessagePhoneReceived:  true,
-			events.EventTypeMessagePhoneSent:      true,
-			events.EventTypeMessagePhoneDelivered: true,
-			events.EventTypeMessageSendFailed:     true,
-			events.EventTypeMessageSendExpired:    true,
-			events.EventTypePhoneHeartbeatOnline:  true,
-			events.EventTypePhoneHeartbeatOffline: true,
-			events.MessageCallMissed:              true,
-		}
-
+		validEvents := events.WebhookEventTypes()
 		for _, event := range input {
-			if _, ok := validEvents[event]; !ok {
+			if !slices.Contains(validEvents, event) {
 				return fmt.Errorf("The %s field has an invalid event with name [%s]", field, event)
 			}
 		}
@@ -126,6 +132,22 @@ func init() {
 	})
 }
 
+// HasPhoneNumberError returns true when all the validation errors are because of invalid E.164 phone numbers
+func HasPhoneNumberError(errors url.Values) bool {
+	if len(errors) == 0 {
+		return false
+	}
+
+	for _, messages := range errors {
+		for _, message := range messages {
+			if !strings.Contains(message, "E.164") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 // ValidateUUID that the payload is a UUID
 func (validator *validator) ValidateUUID(_ context.Context, ID string, name string) url.Values {
 	request := map[string]string{
@@ -144,3 +166,95 @@ func (validator *validator) ValidateUUID(_ context.Context, ID string, name stri
 
 	return v.ValidateStruct()
 }
+
+// validateMessageMetadata validates the metadata attached to a message
+func (validator *validator) validateMessageMetadata(field string, metadata map[string]string) url.Values {
+	result := url.Values{}
+	if len(metadata) == 0 {
+		return result
+	}
+
+	if len(metadata) > messageMetadataMaxKeys {
+		result.Add(field, fmt.Sprintf("The %s field cannot have more than %d keys", field, messageMetadataMaxKeys))
+	}
+
+	for key := range metadata {
+		if !messageMetadataKeyRegex.MatchString(key) {
+			result.Add(field, fmt.Sprintf("The %s field has an invalid key [%s], keys must be 1-64 characters long and contain only letters, digits, '_', '-' or '.'", field, key))
+		}
+	}
+
+	if data, err := json.Marshal(metadata); err != nil || len(data) > messageMetadataMaxBytes {
+		result.Add(field, fmt.Sprintf("The %s field cannot be larger than %d bytes when encoded as JSON", field, messageMetadataMaxBytes))
+	}
+
+	return result
+}
+
+// messageCategories returns the supported message categories as a comma separated string
+func (validator *validator) messageCategories() string {
+	categories := make([]string, 0, len(entities.MessageCategories))
+	for _, category := range entities.MessageCategories {
+		categories = append(categories, category.String())
+	}
+	return strings.Join(categories, ",")
+}
+
+// validateMessageCategoryRules validates the rules used to classify received messages
+func (validator *validator) validateMessageCategoryRules(field string, rules []requests.UserMessageCategoryRule) url.Values {
+	result := url.Values{}
+	if len(rules) > messageCategoryRulesMax {
+		result.Add(field, fmt.Sprintf("The %s field cannot have more than %d rules", field, messageCategoryRulesMax))
+	}
+
+	for index, rule := range rules {
+		if !validator.isMessageCategory(rule.Category) {
+			result.Add(field, fmt.Sprintf("The category of rule [%d] must be one of [%s]", index, validator.messageCategories()))
+		}
+
+		if rule.Pattern == "" || len(rule.Pattern) > messageCategoryPatternMaxLength {
+			result.Add(field, fmt.Sprintf("The pattern of rule [%d] must be between 1 and %d characters long", index, messageCategoryPatternMaxLength))
+			continue
+		}
+
+		if _, err := regexp.Compile(rule.Pattern); err != nil {
+			result.Add(field, fmt.Sprintf("The pattern of rule [%d] is not a valid regular expression: %s", index, err.Error()))
+		}
+	}
+
+	return result
+}
+
+// isMessageCategory checks if the value is a supported message category
+func (validator *validator) isMessageCategory(value string) bool {
+	for _, category := range entities.MessageCategories {
+		if category.String() == value {
+			return true
+		}
+	}
+	return false
+}
+
+// isAlphanumericSenderID checks if the value is an alphanumeric sender ID e.g. MyBrand which is not a phone number
+func (validator *validator) isAlphanumericSenderID(value string) bool {
+	return alphanumericSenderIDRegex.MatchString(value) && letterRegex.MatchString(value)
+}
+
+// validateBusinessHours checks the days and the times of a weekly schedule in the field
+func (validator *validator) validateBusinessHours(result url.Values, field string, schedule entities.PhoneBusinessHoursSchedule) {
+	if len(schedule) > 50 {
+		result.Add(field, fmt.Sprintf("The %s field cannot contain more than 50 opening hours", field))
+		return
+	}
+
+	for index, hours := range schedule {
+		if !slices.Contains(entities.PhoneBusinessHoursDays, hours.Day) {
+			result.Add(field, fmt.Sprintf("The day of the %s field in index [%d] must be one of %s", field, index, strings.Join(entities.PhoneBusinessHoursDays, ", ")))
+			continue
+		}
+
+		if _, _, ok := hours.Minutes(); !ok {
+			result.Add(field, fmt.Sprintf("The %s field in index [%d] must have a start and an end time e.g. 09:00 and the end must be after the start", field, index))
+		}
+	}
+}
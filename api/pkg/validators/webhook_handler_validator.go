@@ -4,10 +4,16 @@ import (
 	"context"
 	"fmt"
 	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/NdoleStudio/httpsms/pkg/entities"
+	"github.com/NdoleStudio/httpsms/pkg/events"
 	"github.com/NdoleStudio/httpsms/pkg/repositories"
 	"github.com/NdoleStudio/httpsms/pkg/services"
+	"github.com/google/uuid"
 	"github.com/palantir/stacktrace"
 
 	"github.com/NdoleStudio/httpsms/pkg/requests"
@@ -22,6 +28,9 @@ type WebhookHandlerValidator struct {
 	logger       telemetry.Logger
 	tracer       telemetry.Tracer
 	phoneService *services.PhoneService
+
+	// webhookService is used to check if custom headers can be encrypted
+	webhookService *services.WebhookService
 }
 
 // NewWebhookHandlerValidator creates a new handlers.WebhookHandler validator
@@ -29,14 +38,40 @@ func NewWebhookHandlerValidator(
 	logger telemetry.Logger,
 	tracer telemetry.Tracer,
 	phoneService *services.PhoneService,
+	webhookService *services.WebhookService,
 ) (v *WebhookHandlerValidator) {
 	return &WebhookHandlerValidator{
-		logger:       logger.WithService(fmt.Sprintf("%T", v)),
-		tracer:       tracer,
-		phoneService: phoneService,
+		logger:         logger.WithService(fmt.Sprintf("%T", v)),
+		tracer:         tracer,
+		phoneService:   phoneService,
+		webhookService: webhookService,
 	}
 }
 
+// maxWebhookHeaders is the maximum number of custom headers of a webhook
+const maxWebhookHeaders = 10
+
+// webhookHeaderNameRegex matches the token characters which are allowed in an HTTP header name
+var webhookHeaderNameRegex = regexp.MustCompile("^[a-zA-Z0-9!#$%&'*+.^_`|~-]{1,100}$")
+
+// maxWebhookClientCertificateSize is the maximum number of characters of the PEM encoded client certificate and key of a webhook
+const maxWebhookClientCertificateSize = 16384
+
+// reservedWebhookHeaders are set on every delivery so they cannot be overridden by custom headers
+var reservedWebhookHeaders = []string{
+	"Authorization",
+	"Connection",
+	"Content-Length",
+	"Content-Type",
+	"Host",
+	"Transfer-Encoding",
+	"X-Batch-Size",
+	"X-Delivery-ID",
+	"X-Event-ID",
+	"X-Event-Type",
+	"X-Signature",
+}
+
 // ValidateIndex validates the requests.HeartbeatIndex request
 func (validator *WebhookHandlerValidator) ValidateIndex(_ context.Context, request requests.WebhookIndex) url.Values {
 	v := govalidator.New(govalidator.Options{
@@ -66,27 +101,46 @@ func (validator *WebhookHandlerValidator) ValidateStore(ctx context.Context, use
 	ctx, span := validator.tracer.Start(ctx)
 	defer span.End()
 
-	v := govalidator.New(govalidator.Options{
-		Data: &request,
-		Rules: govalidator.MapData{
-			"signing_key": []string{
-				"min:1",
-				"max:255",
-			},
-			"url": []string{
-				"required",
-				"url",
-				"max:255",
-			},
-			"events": []string{
-				"required",
-				webhookEventsRule,
-			},
-			"phone_numbers": []string{
-				"required",
-				multipleContactPhoneNumberRule,
-			},
+	rules := govalidator.MapData{
+		"signing_key": []string{
+			"min:1",
+			"max:255",
+		},
+		"url": []string{
+			"required",
+			"url",
+			"max:255",
 		},
+		"events": []string{
+			"required",
+			webhookEventsRule,
+		},
+		"phone_numbers": []string{
+			"required",
+			multipleContactPhoneNumberRule,
+		},
+		"batch_size": []string{
+			"min:0",
+			"max:100",
+		},
+		"batch_window_seconds": []string{
+			"min:0",
+			"max:60",
+		},
+		"timeout_seconds": []string{
+			"min:0",
+			fmt.Sprintf("max:%d", entities.WebhookMaxTimeoutSeconds),
+		},
+	}
+
+	// the phone numbers of a phone scoped webhook are not used to match events
+	if request.PhoneID != "" {
+		delete(rules, "phone_numbers")
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
 	})
 
 	result := v.ValidateStruct()
@@ -94,6 +148,30 @@ func (validator *WebhookHandlerValidator) ValidateStore(ctx context.Context, use
 		return result
 	}
 
+	if result = validator.validateBatch(request); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validateContentType(request); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validateHeaders(request); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validateClientCertificate(request); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validatePhoneID(ctx, userID, request); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validateFieldMask(request); len(result) > 0 {
+		return result
+	}
+
 	for _, address := range request.PhoneNumbers {
 		_, err := validator.phoneService.Load(ctx, userID, address)
 		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
@@ -108,31 +186,50 @@ func (validator *WebhookHandlerValidator) ValidateUpdate(ctx context.Context, us
 	ctx, span := validator.tracer.Start(ctx)
 	defer span.End()
 
-	v := govalidator.New(govalidator.Options{
-		Data: &request,
-		Rules: govalidator.MapData{
-			"signing_key": []string{
-				"min:1",
-				"max:255",
-			},
-			"webhookID": []string{
-				"required",
-				"uuid",
-			},
-			"url": []string{
-				"required",
-				"url",
-				"max:255",
-			},
-			"events": []string{
-				"required",
-				webhookEventsRule,
-			},
-			"phone_numbers": []string{
-				"required",
-				multipleContactPhoneNumberRule,
-			},
+	rules := govalidator.MapData{
+		"signing_key": []string{
+			"min:1",
+			"max:255",
 		},
+		"webhookID": []string{
+			"required",
+			"uuid",
+		},
+		"url": []string{
+			"required",
+			"url",
+			"max:255",
+		},
+		"events": []string{
+			"required",
+			webhookEventsRule,
+		},
+		"phone_numbers": []string{
+			"required",
+			multipleContactPhoneNumberRule,
+		},
+		"batch_size": []string{
+			"min:0",
+			"max:100",
+		},
+		"batch_window_seconds": []string{
+			"min:0",
+			"max:60",
+		},
+		"timeout_seconds": []string{
+			"min:0",
+			fmt.Sprintf("max:%d", entities.WebhookMaxTimeoutSeconds),
+		},
+	}
+
+	// the phone numbers of a phone scoped webhook are not used to match events
+	if request.PhoneID != "" {
+		delete(rules, "phone_numbers")
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
 	})
 
 	result := v.ValidateStruct()
@@ -140,6 +237,30 @@ func (validator *WebhookHandlerValidator) ValidateUpdate(ctx context.Context, us
 		return result
 	}
 
+	if result = validator.validateBatch(request.WebhookStore); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validateContentType(request.WebhookStore); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validateHeaders(request.WebhookStore); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validateClientCertificate(request.WebhookStore); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validatePhoneID(ctx, userID, request.WebhookStore); len(result) > 0 {
+		return result
+	}
+
+	if result = validator.validateFieldMask(request.WebhookStore); len(result) > 0 {
+		return result
+	}
+
 	for _, address := range request.PhoneNumbers {
 		_, err := validator.phoneService.Load(ctx, userID, address)
 		if stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
@@ -148,3 +269,182 @@ func (validator *WebhookHandlerValidator) ValidateUpdate(ctx context.Context, us
 	}
 	return result
 }
+
+// ValidateReplay validates the requests.WebhookReplay request
+func (validator *WebhookHandlerValidator) ValidateReplay(_ context.Context, request requests.WebhookReplay) url.Values {
+	rules := govalidator.MapData{
+		"webhookID": []string{
+			"required",
+			"uuid",
+		},
+	}
+	if len(request.Events) > 0 {
+		rules["events"] = []string{webhookEventsRule}
+	}
+
+	v := govalidator.New(govalidator.Options{
+		Data:  &request,
+		Rules: rules,
+	})
+
+	result := v.ValidateStruct()
+	if request.From.IsZero() {
+		result.Add("from", "The from field is required")
+	}
+
+	if !request.From.IsZero() && !request.From.Before(request.To) {
+		result.Add("from", "The from field must be before the to field")
+	}
+
+	if request.To.After(time.Now().UTC().Add(time.Minute)) {
+		result.Add("to", "The to field cannot be in the future")
+	}
+	return result
+}
+
+func (validator *WebhookHandlerValidator) validateBatch(request requests.WebhookStore) url.Values {
+	result := url.Values{}
+	if request.BatchSize < 2 {
+		return result
+	}
+
+	if request.BatchWindowSeconds == 0 {
+		result.Add("batch_window_seconds", "batch_window_seconds cannot be 0 when batch_size is greater than 1")
+	}
+
+	if strings.HasPrefix(request.URL, "https://discord.com/api/webhooks/") {
+		result.Add("batch_size", "discord webhooks cannot receive events in batches")
+	}
+	return result
+}
+
+func (validator *WebhookHandlerValidator) validateContentType(request requests.WebhookStore) url.Values {
+	result := url.Values{}
+	if request.ContentType == "" || request.ContentType == entities.WebhookContentTypeJSON.String() {
+		return result
+	}
+
+	if request.ContentType != entities.WebhookContentTypeForm.String() {
+		result.Add("content_type", fmt.Sprintf("the content_type [%s] is not supported, it must be either [%s] or [%s]", request.ContentType, entities.WebhookContentTypeJSON, entities.WebhookContentTypeForm))
+		return result
+	}
+
+	if request.BatchSize > 1 {
+		result.Add("content_type", "form encoded webhooks cannot receive events in batches")
+	}
+
+	if strings.HasPrefix(request.URL, "https://discord.com/api/webhooks/") {
+		result.Add("content_type", "discord webhooks can only receive json events")
+	}
+	return result
+}
+
+func (validator *WebhookHandlerValidator) validateHeaders(request requests.WebhookStore) url.Values {
+	result := url.Values{}
+	if len(request.Headers) == 0 {
+		return result
+	}
+
+	if !validator.webhookService.HeadersEnabled() {
+		result.Add("headers", "custom headers cannot be stored because the WEBHOOK_HEADERS_ENCRYPTION_KEY is not configured")
+		return result
+	}
+
+	if len(request.Headers) > maxWebhookHeaders {
+		result.Add("headers", fmt.Sprintf("a webhook cannot have more than %d headers", maxWebhookHeaders))
+	}
+
+	for name, value := range request.Headers {
+		if !webhookHeaderNameRegex.MatchString(name) {
+			result.Add("headers", fmt.Sprintf("the header name [%s] is not a valid HTTP header name", name))
+			continue
+		}
+
+		for _, reserved := range reservedWebhookHeaders {
+			if strings.EqualFold(name, reserved) {
+				result.Add("headers", fmt.Sprintf("the [%s] header is set by httpSMS and cannot be overridden", reserved))
+			}
+		}
+
+		if len(value) > 1024 || strings.ContainsAny(value, "\r\n\x00") {
+			result.Add("headers", fmt.Sprintf("the value of the [%s] header must have at most 1024 characters without line breaks", name))
+		}
+	}
+	return result
+}
+
+// validateClientCertificate checks the client certificate and key pair, the errors never contain the certificate or the key
+func (validator *WebhookHandlerValidator) validateClientCertificate(request requests.WebhookStore) url.Values {
+	result := url.Values{}
+	if request.ClientCertificate == nil && request.ClientKey == nil {
+		return result
+	}
+
+	if request.ClientCertificate == nil || request.ClientKey == nil || (*request.ClientCertificate == "") != (*request.ClientKey == "") {
+		result.Add("client_certificate", "the client_certificate and client_key must be set together")
+		return result
+	}
+
+	if *request.ClientCertificate == "" {
+		return result
+	}
+
+	if !validator.webhookService.ClientCertificateEnabled() {
+		result.Add("client_certificate", "client certificates cannot be stored because the WEBHOOK_HEADERS_ENCRYPTION_KEY is not configured")
+		return result
+	}
+
+	if len(*request.ClientCertificate) > maxWebhookClientCertificateSize || len(*request.ClientKey) > maxWebhookClientCertificateSize {
+		result.Add("client_certificate", fmt.Sprintf("the client_certificate and client_key must have at most %d characters", maxWebhookClientCertificateSize))
+		return result
+	}
+
+	if !strings.HasPrefix(request.URL, "https://") {
+		result.Add("client_certificate", "a client certificate can only be presented to an https URL")
+		return result
+	}
+
+	certificate, err := services.ParseClientCertificate(*request.ClientCertificate, *request.ClientKey)
+	if err != nil {
+		result.Add("client_certificate", "the client_certificate and client_key are not a valid PEM encoded certificate and matching private key")
+		return result
+	}
+
+	if time.Now().After(certificate.Leaf.NotAfter) {
+		result.Add("client_certificate", fmt.Sprintf("the client certificate expired at [%s]", certificate.Leaf.NotAfter.UTC().Format(time.RFC3339)))
+	}
+	return result
+}
+
+func (validator *WebhookHandlerValidator) validatePhoneID(ctx context.Context, userID entities.UserID, request requests.WebhookStore) url.Values {
+	result := url.Values{}
+	if request.PhoneID == "" {
+		return result
+	}
+
+	phoneID, err := uuid.Parse(request.PhoneID)
+	if err != nil {
+		result.Add("phone_id", "The phone_id field must be a valid UUID")
+		return result
+	}
+
+	if _, err = validator.phoneService.LoadByID(ctx, userID, phoneID); stacktrace.GetCode(err) == repositories.ErrCodeNotFound {
+		result.Add("phone_id", fmt.Sprintf("The phone with ID [%s] is not available in your account", request.PhoneID))
+	}
+	return result
+}
+
+func (validator *WebhookHandlerValidator) validateFieldMask(request requests.WebhookStore) url.Values {
+	result := url.Values{}
+	if len(request.FieldMask) == 0 {
+		return result
+	}
+
+	fields := events.MessagePayloadFields()
+	for _, field := range request.FieldMask {
+		if !slices.Contains(fields, field) {
+			result.Add("field_mask", fmt.Sprintf("the field [%s] is not a field of the message events, the valid fields are [%s]", field, strings.Join(fields, ", ")))
+		}
+	}
+	return result
+}
@@ -369,6 +369,9 @@ export const actions = {
         ),
         missed_call_auto_reply: phone.missed_call_auto_reply,
         max_send_attempts: parseInt(phone.max_send_attempts.toString()),
+        battery_low_threshold: parseInt(
+          (phone.battery_low_threshold ?? 0).toString(),
+        ),
         messages_per_minute: parseInt(phone.messages_per_minute.toString()),
       })
       .catch((error: AxiosError) => {